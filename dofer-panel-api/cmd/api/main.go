@@ -17,12 +17,19 @@ import (
 	_ "time/tzdata"
 
 	"github.com/dofer/panel-api/internal/db"
+	"github.com/dofer/panel-api/internal/modules/bazar"
+	"github.com/dofer/panel-api/internal/modules/materials"
+	"github.com/dofer/panel-api/internal/modules/notifications"
 	ordersApp "github.com/dofer/panel-api/internal/modules/orders/app"
 	ordersInfra "github.com/dofer/panel-api/internal/modules/orders/infra"
+	"github.com/dofer/panel-api/internal/modules/printers"
+	quotesApp "github.com/dofer/panel-api/internal/modules/quotes/app"
+	quotesInfra "github.com/dofer/panel-api/internal/modules/quotes/infra"
 	"github.com/dofer/panel-api/internal/platform/config"
 	"github.com/dofer/panel-api/internal/platform/email"
 	"github.com/dofer/panel-api/internal/platform/httpserver"
 	"github.com/dofer/panel-api/internal/platform/logger"
+	"github.com/dofer/panel-api/internal/platform/secrets"
 	"github.com/joho/godotenv"
 )
 
@@ -50,19 +57,84 @@ func main() {
 	defer dbPool.Close()
 	slog.Info("database connection established")
 
+	// Servicio de correo: consola en desarrollo, SMTP con MAILER_DRIVER=smtp
+	mailer, err := email.New(cfg)
+	if err != nil {
+		slog.Error("failed to configure mailer", slog.Any("error", err))
+		os.Exit(1)
+	}
+	slog.Info("mailer configured", slog.String("driver", cfg.MailerDriver))
+	if cfg.QuoteLinkSecret == "" {
+		slog.Warn("QUOTE_LINK_SECRET is not set; public quote links are disabled")
+	}
+
+	// Credenciales de integraciones guardadas cifradas
+	secretsBox := secrets.NewBox(cfg.SecretsEncryptionKey)
+
+	// La API y el worker de sincronización comparten el mismo módulo del bazar
+	bazarModule := bazar.NewModule(dbPool, secretsBox, bazar.SheetsConfig{
+		SpreadsheetID: cfg.GoogleSheetsID,
+		ServiceEmail:  cfg.GoogleServiceEmail,
+		PrivateKey:    cfg.GooglePrivateKey,
+		InventoryName: cfg.GoogleInventorySheet,
+		SalesName:     cfg.GoogleSalesSheet,
+		Timezone:      cfg.BazarTimezone,
+	}, cfg.BazarTimezone)
+
 	// Crear servidor HTTP
-	server := httpserver.New(cfg, dbPool)
-	jobCancel := func() {}
+	server := httpserver.New(cfg, dbPool, bazarModule)
+	jobCtx, jobCancel := context.WithCancel(context.Background())
+
+	// Entrega de notificaciones guardadas en notification_events
+	dispatchSeconds := parseIntEnv("NOTIFICATIONS_DISPATCH_INTERVAL_SECONDS", 15)
+	if dispatchSeconds <= 0 {
+		dispatchSeconds = 15
+	}
+	dispatcher := notifications.NewDispatcher(
+		notifications.NewRepository(dbPool),
+		notifications.NewEmailChannel(mailer),
+	)
+	go dispatcher.Run(jobCtx, time.Duration(dispatchSeconds)*time.Second)
+	slog.Info("notification dispatcher enabled", slog.Int("interval_seconds", dispatchSeconds))
+
+	// Telemetría de impresoras con conector OctoPrint/Moonraker
+	if parseBoolEnv("PRINTER_TELEMETRY_ENABLED", true) {
+		pollSeconds := parseIntEnv("PRINTER_TELEMETRY_INTERVAL_SECONDS", 30)
+		if pollSeconds <= 0 {
+			pollSeconds = 30
+		}
+		// Las órdenes que detiene la telemetría también descuentan filamento
+		poller := printers.NewTelemetryPoller(
+			printers.NewRepository(dbPool, secretsBox),
+			materials.NewConsumingTimer(ordersInfra.NewPostgresTimerRepository(dbPool), materials.NewRepository(dbPool)),
+		)
+		go poller.Run(jobCtx, time.Duration(pollSeconds)*time.Second)
+		slog.Info("printer telemetry poller enabled", slog.Int("interval_seconds", pollSeconds))
+	}
+
+	// Cola de ventas del bazar pendientes de Google Sheets
+	bazarSyncDone := make(chan struct{})
+	if parseBoolEnv("BAZAR_SYNC_WORKER_ENABLED", true) {
+		syncSeconds := parseIntEnv("BAZAR_SYNC_INTERVAL_SECONDS", 30)
+		if syncSeconds <= 0 {
+			syncSeconds = 30
+		}
+		worker := bazar.NewSyncWorker(bazarModule.Service)
+		go func() {
+			defer close(bazarSyncDone)
+			worker.Run(jobCtx, time.Duration(syncSeconds)*time.Second)
+		}()
+		slog.Info("bazar sync worker enabled", slog.Int("interval_seconds", syncSeconds))
+	} else {
+		close(bazarSyncDone)
+	}
 
 	// Job opcional: recordatorios SLA automáticos
 	if parseBoolEnv("SLA_REMINDER_JOB_ENABLED", false) {
 		orderRepo := ordersInfra.NewPostgresOrderRepository(dbPool)
 		historyRepo := ordersInfra.NewPostgresOrderHistoryRepository(dbPool)
-		mailer := email.NewConsoleMailer()
-		slaHandler := ordersApp.NewSendSLARemindersHandler(orderRepo, historyRepo, mailer)
-
-		jobCtx, cancel := context.WithCancel(context.Background())
-		jobCancel = cancel
+		workflowRepo := ordersInfra.NewPostgresWorkflowRepository(dbPool)
+		slaHandler := ordersApp.NewSendSLARemindersHandler(orderRepo, historyRepo, workflowRepo)
 
 		intervalMinutes := parseIntEnv("SLA_REMINDER_INTERVAL_MINUTES", 60)
 		if intervalMinutes <= 0 {
@@ -82,6 +154,26 @@ func main() {
 		)
 	}
 
+	// Vigencia de cotizaciones: vence las que pasaron valid_until y avisa al
+	// cliente unos días antes.
+	if parseBoolEnv("QUOTE_EXPIRY_JOB_ENABLED", false) {
+		intervalMinutes := parseIntEnv("QUOTE_EXPIRY_INTERVAL_MINUTES", 60)
+		if intervalMinutes <= 0 {
+			intervalMinutes = 60
+		}
+		reminderDays := parseIntEnv("QUOTE_EXPIRY_REMINDER_DAYS", 3)
+		if reminderDays < 0 {
+			reminderDays = 0
+		}
+		expiryHandler := quotesApp.NewExpireQuotesHandler(quotesInfra.NewPostgresQuoteRepository(dbPool))
+
+		go runQuoteExpiryWorker(jobCtx, expiryHandler, time.Duration(intervalMinutes)*time.Minute, reminderDays)
+		slog.Info("quote expiry job enabled",
+			slog.Int("interval_minutes", intervalMinutes),
+			slog.Int("reminder_days", reminderDays),
+		)
+	}
+
 	// Iniciar servidor en goroutine
 	go func() {
 		slog.Info("starting server", slog.String("port", cfg.Port), slog.String("env", cfg.Env))
@@ -107,6 +199,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	// La venta que el worker esté enviando termina antes de cerrar la base.
+	select {
+	case <-bazarSyncDone:
+	case <-ctx.Done():
+		slog.Warn("bazar sync worker did not stop in time")
+	}
+
 	slog.Info("server stopped gracefully")
 }
 
@@ -158,6 +257,45 @@ func runSLAReminderWorker(
 	}
 }
 
+// runQuoteExpiryWorker corre al arrancar y luego en cada intervalo, para que
+// no queden cotizaciones abiertas con la vigencia vencida tras un reinicio.
+func runQuoteExpiryWorker(ctx context.Context, handler *quotesApp.ExpireQuotesHandler, interval time.Duration, reminderDays int) {
+	run := func() {
+		runCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+
+		result, err := handler.Handle(runCtx, quotesApp.ExpireQuotesCommand{
+			ReminderDays: reminderDays,
+			TriggeredBy:  "system:quote-expiry",
+		})
+		if err != nil {
+			slog.Error("quote expiry job failed", slog.Any("error", err))
+			return
+		}
+		if result.Expired > 0 || result.Reminded > 0 || result.Failed > 0 {
+			slog.Info("quote expiry job completed",
+				slog.Int("expired", result.Expired),
+				slog.Int("reminded", result.Reminded),
+				slog.Int("failed", result.Failed),
+			)
+		}
+	}
+
+	run()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
 func parseBoolEnv(key string, fallback bool) bool {
 	raw := strings.TrimSpace(os.Getenv(key))
 	if raw == "" {
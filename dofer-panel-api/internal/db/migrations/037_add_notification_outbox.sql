@@ -0,0 +1,50 @@
+-- Bandeja de salida de notificaciones por organización.
+-- Los eventos de dominio se insertan en la misma transacción que el cambio
+-- (estatus de orden, pago, cotización enviada...). El dispatcher los reparte
+-- en una entrega por canal y guarda intentos y último error de cada una.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS notification_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    aggregate_type TEXT NOT NULL DEFAULT '',
+    aggregate_id TEXT NOT NULL DEFAULT '',
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_events_pending
+    ON notification_events (created_at)
+    WHERE dispatched_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notification_events_aggregate
+    ON notification_events (organization_id, aggregate_type, aggregate_id);
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL REFERENCES notification_events(id) ON DELETE CASCADE,
+    channel TEXT NOT NULL,
+    recipient TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'sent', 'failed', 'skipped')),
+    attempts INTEGER NOT NULL DEFAULT 0 CHECK (attempts >= 0),
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (event_id, channel)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_pending
+    ON notification_deliveries (next_attempt_at)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_org_created
+    ON notification_deliveries (organization_id, created_at DESC);
+
+COMMIT;
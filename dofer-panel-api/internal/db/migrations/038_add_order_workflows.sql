@@ -0,0 +1,27 @@
+-- Flujo de producción configurable por organización.
+-- Cada organización puede definir sus etapas (orders.status) y las
+-- transiciones permitidas. Sin registro se usa el flujo por defecto
+-- new→printing→post→packed→ready→delivered definido en el código.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS order_workflows (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    stages JSONB NOT NULL DEFAULT '[]'::jsonb,
+    transitions JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_order_workflows_updated_at ON order_workflows;
+CREATE TRIGGER update_order_workflows_updated_at
+    BEFORE UPDATE ON order_workflows
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Los estados ya no son una lista fija: los valida la API contra el flujo
+-- de la organización.
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+
+COMMENT ON TABLE order_workflows IS 'Etapas y transiciones de órdenes definidas por cada organización';
+
+COMMIT;
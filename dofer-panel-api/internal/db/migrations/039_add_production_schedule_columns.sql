@@ -0,0 +1,16 @@
+-- Columnas usadas por el planificador de producción (/printers/schedule).
+-- estimated_time_minutes ya lo leen los temporizadores; aquí se garantiza
+-- que exista en todas las bases.
+
+BEGIN;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS estimated_time_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS actual_time_minutes INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_printer_assignments_active
+    ON printer_assignments(organization_id, printer_id, assigned_at)
+    WHERE completed_at IS NULL;
+
+COMMENT ON COLUMN orders.estimated_time_minutes IS 'Tiempo de impresión estimado; el planificador usa 4 h cuando es 0';
+
+COMMIT;
@@ -0,0 +1,34 @@
+-- Conectores de telemetría por impresora (OctoPrint / Moonraker).
+-- El poller consulta base_url con api_key y guarda aquí el último estado
+-- reportado; printers.status se actualiza a partir de él.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS printer_connectors (
+    printer_id UUID PRIMARY KEY REFERENCES printers(id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    connector_type TEXT NOT NULL CHECK (connector_type IN ('octoprint', 'moonraker')),
+    base_url TEXT NOT NULL,
+    api_key TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    telemetry_state TEXT CHECK (telemetry_state IN ('printing', 'idle', 'error', 'offline')),
+    job_name TEXT,
+    job_progress NUMERIC(5,2),
+    job_remaining_seconds INTEGER,
+    last_polled_at TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_printer_connectors_org ON printer_connectors(organization_id);
+CREATE INDEX IF NOT EXISTS idx_printer_connectors_enabled ON printer_connectors(enabled) WHERE enabled = true;
+
+DROP TRIGGER IF EXISTS update_printer_connectors_updated_at ON printer_connectors;
+CREATE TRIGGER update_printer_connectors_updated_at
+    BEFORE UPDATE ON printer_connectors
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON COLUMN printer_connectors.telemetry_state IS 'Último estado normalizado: printing, idle, error u offline';
+
+COMMIT;
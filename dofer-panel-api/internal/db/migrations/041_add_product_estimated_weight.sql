@@ -0,0 +1,9 @@
+-- Peso de filamento por pieza, normalmente tomado del G-code/3MF del slicer.
+
+BEGIN;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS estimated_weight_grams NUMERIC(10,2);
+
+COMMENT ON COLUMN products.estimated_weight_grams IS 'Gramos de filamento por pieza según el slicer';
+
+COMMIT;
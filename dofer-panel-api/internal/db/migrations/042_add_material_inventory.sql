@@ -0,0 +1,59 @@
+-- Inventario de filamento por carrete y consumo por orden.
+-- El consumo se descuenta con el weight_grams del item de la cotización de
+-- origen cuando se detiene el timer de la orden o se completa un item.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS material_spools (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    material TEXT NOT NULL,
+    color TEXT,
+    brand TEXT,
+    initial_grams NUMERIC(10,2) NOT NULL CHECK (initial_grams > 0),
+    remaining_grams NUMERIC(10,2) NOT NULL CHECK (remaining_grams >= 0),
+    cost NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (cost >= 0),
+    location TEXT,
+    low_stock_threshold_grams NUMERIC(10,2) NOT NULL DEFAULT 100 CHECK (low_stock_threshold_grams >= 0),
+    is_archived BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_spools_org_material
+    ON material_spools(organization_id, UPPER(material))
+    WHERE is_archived = false;
+
+DROP TRIGGER IF EXISTS update_material_spools_updated_at ON material_spools;
+CREATE TRIGGER update_material_spools_updated_at
+    BEFORE UPDATE ON material_spools
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS material_consumptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    spool_id UUID REFERENCES material_spools(id) ON DELETE SET NULL,
+    material TEXT NOT NULL,
+    grams NUMERIC(10,2) NOT NULL CHECK (grams > 0),
+    cost NUMERIC(10,2) NOT NULL DEFAULT 0,
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    order_item_id UUID REFERENCES order_items(id) ON DELETE SET NULL,
+    source TEXT NOT NULL CHECK (source IN ('timer_stopped', 'item_completed', 'manual')),
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_consumptions_org_created
+    ON material_consumptions(organization_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_material_consumptions_order_item
+    ON material_consumptions(order_item_id)
+    WHERE order_item_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_material_consumptions_spool
+    ON material_consumptions(spool_id);
+
+-- Material con el que se cotizó cada item; decide de qué carretes se descuenta.
+ALTER TABLE quote_items ADD COLUMN IF NOT EXISTS material_name TEXT;
+
+COMMENT ON COLUMN material_consumptions.spool_id IS 'NULL cuando no había existencias del material (faltante)';
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- Datos de marca de la organización para los PDF de cotizaciones y recibos.
+-- El logo se guarda como data URL (PNG o JPEG) para no depender de un
+-- almacenamiento externo.
+
+BEGIN;
+
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS logo_data_url TEXT;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS brand_color TEXT;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS legal_name TEXT;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS tax_id TEXT;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS address TEXT;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS contact_phone TEXT;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS contact_email TEXT;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS document_footer TEXT;
+
+COMMENT ON COLUMN organizations.brand_color IS 'Color principal de los documentos en formato #RRGGBB';
+
+COMMIT;
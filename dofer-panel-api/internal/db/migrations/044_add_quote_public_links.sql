@@ -0,0 +1,31 @@
+-- Enlace público firmado para que el cliente vea la cotización y la acepte o
+-- rechace escribiendo su nombre. El token no se guarda: se firma con
+-- (quote_id, public_link_version, expiración); subir la versión revoca todos
+-- los enlaces emitidos antes.
+
+BEGIN;
+
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS public_link_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS public_link_expires_at TIMESTAMPTZ;
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS public_link_auto_convert BOOLEAN NOT NULL DEFAULT false;
+
+-- Historial de la cotización: quién, cuándo y desde qué IP cambió algo.
+CREATE TABLE IF NOT EXISTS quote_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE RESTRICT,
+    quote_id UUID NOT NULL REFERENCES quotes(id) ON DELETE CASCADE,
+    changed_by TEXT,
+    change_type TEXT NOT NULL,
+    field_name TEXT,
+    old_value TEXT,
+    new_value TEXT,
+    notes TEXT,
+    ip_address TEXT,
+    user_agent TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_quote_history_quote ON quote_history(quote_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_quote_history_organization ON quote_history(organization_id);
+
+COMMIT;
@@ -0,0 +1,26 @@
+-- Credenciales de Google Sheets por organización para el módulo de bazar.
+-- La clave privada de la cuenta de servicio se guarda cifrada (AES-GCM con
+-- SECRETS_ENCRYPTION_KEY); el resto de los campos no es secreto.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS bazar_sheets_settings (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    spreadsheet_id TEXT NOT NULL,
+    service_email TEXT NOT NULL,
+    private_key_encrypted TEXT NOT NULL,
+    inventory_sheet_name TEXT NOT NULL DEFAULT 'Inventario',
+    sales_sheet_name TEXT NOT NULL DEFAULT 'Ventas',
+    last_tested_at TIMESTAMPTZ,
+    last_test_error TEXT,
+    updated_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_bazar_sheets_settings_updated_at ON bazar_sheets_settings;
+CREATE TRIGGER update_bazar_sheets_settings_updated_at
+    BEFORE UPDATE ON bazar_sheets_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,20 @@
+-- Cola persistente de sincronización de ventas del bazar con Google Sheets.
+-- next_sync_at marca cuándo puede reintentarse una venta (NULL = ya); el
+-- worker la aparta adelantando esa fecha mientras la procesa, de modo que una
+-- venta que se quedó a medias por un reinicio vuelve a tomarse al vencer.
+-- Tras varios intentos fallidos la venta pasa a 'dead' y solo se reintenta al
+-- reencolarla a mano.
+
+BEGIN;
+
+ALTER TABLE bazar_sales ADD COLUMN IF NOT EXISTS next_sync_at TIMESTAMPTZ;
+
+ALTER TABLE bazar_sales DROP CONSTRAINT IF EXISTS bazar_sales_sync_status_check;
+ALTER TABLE bazar_sales ADD CONSTRAINT bazar_sales_sync_status_check
+    CHECK (sync_status IN ('pending', 'synced', 'error', 'dead'));
+
+CREATE INDEX IF NOT EXISTS idx_bazar_sales_sync_queue
+    ON bazar_sales (next_sync_at NULLS FIRST, created_at)
+    WHERE sync_status IN ('pending', 'error');
+
+COMMIT;
@@ -0,0 +1,27 @@
+-- Destino de sincronización del bazar por organización. Sin registro se usa
+-- Google Sheets como hasta ahora. El destino 'file' guarda el último CSV de
+-- inventario que subió la organización y exporta ventas e inventario como
+-- CSV o XLSX; 'webhook' envía cada venta como JSON firmado.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS bazar_sync_targets (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    target_kind TEXT NOT NULL DEFAULT 'google_sheets'
+        CHECK (target_kind IN ('google_sheets', 'file', 'webhook')),
+    webhook_url TEXT,
+    webhook_secret_encrypted TEXT,
+    webhook_inventory_url TEXT,
+    inventory_csv TEXT,
+    inventory_uploaded_at TIMESTAMPTZ,
+    updated_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS update_bazar_sync_targets_updated_at ON bazar_sync_targets;
+CREATE TRIGGER update_bazar_sync_targets_updated_at
+    BEFORE UPDATE ON bazar_sync_targets
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMIT;
@@ -0,0 +1,68 @@
+-- Devoluciones parciales de ventas del bazar. Cada devolución guarda los
+-- renglones devueltos, si regresaron al inventario y cómo se reembolsó el
+-- dinero; los reembolsos en efectivo se descuentan del efectivo esperado del
+-- corte del día en que ocurren.
+
+BEGIN;
+
+ALTER TABLE bazar_sale_items
+    ADD COLUMN IF NOT EXISTS returned_quantity INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE bazar_sale_items DROP CONSTRAINT IF EXISTS bazar_sale_items_returned_quantity_check;
+ALTER TABLE bazar_sale_items ADD CONSTRAINT bazar_sale_items_returned_quantity_check
+    CHECK (returned_quantity >= 0 AND returned_quantity <= quantity);
+
+ALTER TABLE bazar_sales
+    ADD COLUMN IF NOT EXISTS refunded_total NUMERIC(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE bazar_sales DROP CONSTRAINT IF EXISTS bazar_sales_refunded_total_check;
+ALTER TABLE bazar_sales ADD CONSTRAINT bazar_sales_refunded_total_check
+    CHECK (refunded_total >= 0 AND refunded_total <= total);
+
+CREATE TABLE IF NOT EXISTS bazar_sale_returns (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE RESTRICT,
+    external_id TEXT NOT NULL,
+    sale_id UUID NOT NULL REFERENCES bazar_sales(id) ON DELETE CASCADE,
+    bazar_id UUID NOT NULL REFERENCES bazaars(id) ON DELETE RESTRICT,
+    refund_method TEXT NOT NULL
+        CHECK (refund_method IN ('cash', 'transfer', 'card', 'mercado_pago', 'other')),
+    refund_amount NUMERIC(12,2) NOT NULL CHECK (refund_amount >= 0),
+    reason TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_by_name TEXT NOT NULL DEFAULT 'Sistema',
+    returned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (organization_id, external_id)
+);
+
+CREATE TABLE IF NOT EXISTS bazar_sale_return_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE RESTRICT,
+    return_id UUID NOT NULL REFERENCES bazar_sale_returns(id) ON DELETE CASCADE,
+    sale_item_id UUID NOT NULL REFERENCES bazar_sale_items(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+    product_external_id TEXT NOT NULL,
+    product_name TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(12,2) NOT NULL CHECK (unit_price >= 0),
+    refund_amount NUMERIC(12,2) NOT NULL CHECK (refund_amount >= 0),
+    restocked BOOLEAN NOT NULL DEFAULT TRUE,
+    stock_before INTEGER NOT NULL CHECK (stock_before >= 0),
+    stock_after INTEGER NOT NULL CHECK (stock_after >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bazar_sale_returns_sale
+    ON bazar_sale_returns (sale_id, returned_at);
+CREATE INDEX IF NOT EXISTS idx_bazar_sale_returns_cash
+    ON bazar_sale_returns (organization_id, bazar_id, returned_at)
+    WHERE refund_method = 'cash';
+CREATE INDEX IF NOT EXISTS idx_bazar_sale_return_items_return
+    ON bazar_sale_return_items (return_id);
+
+-- Un reembolso grande puede dejar el efectivo esperado del día por debajo de
+-- cero; la diferencia contra el conteo sigue siendo válida.
+ALTER TABLE bazar_daily_cuts
+    ADD COLUMN IF NOT EXISTS cash_refunds NUMERIC(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE bazar_daily_cuts DROP CONSTRAINT IF EXISTS bazar_daily_cuts_expected_cash_check;
+
+COMMIT;
@@ -0,0 +1,31 @@
+-- Pagos combinados en ventas del bazar. Cada venta guarda sus pagos por
+-- método; payment_method queda como el único método usado o 'mixed' cuando
+-- hubo más de uno. Las ventas existentes reciben un pago por su total.
+
+BEGIN;
+
+ALTER TABLE bazar_sales DROP CONSTRAINT IF EXISTS bazar_sales_payment_method_check;
+ALTER TABLE bazar_sales ADD CONSTRAINT bazar_sales_payment_method_check
+    CHECK (payment_method IN ('cash', 'transfer', 'card', 'mercado_pago', 'other', 'mixed'));
+
+CREATE TABLE IF NOT EXISTS bazar_sale_payments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE RESTRICT,
+    sale_id UUID NOT NULL REFERENCES bazar_sales(id) ON DELETE CASCADE,
+    method TEXT NOT NULL
+        CHECK (method IN ('cash', 'transfer', 'card', 'mercado_pago', 'other')),
+    amount NUMERIC(12,2) NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (sale_id, method)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bazar_sale_payments_org_method
+    ON bazar_sale_payments (organization_id, method);
+
+INSERT INTO bazar_sale_payments (organization_id, sale_id, method, amount, created_at)
+SELECT s.organization_id, s.id, s.payment_method, s.total, s.created_at
+FROM bazar_sales s
+WHERE s.payment_method <> 'mixed'
+  AND NOT EXISTS (SELECT 1 FROM bazar_sale_payments p WHERE p.sale_id = s.id);
+
+COMMIT;
@@ -0,0 +1,54 @@
+-- Descuentos y promociones en el punto de venta del bazar. Cada renglón guarda
+-- su precio de lista, lo que le descontaron las promociones y el total de
+-- descuento (promociones, descuento manual y su parte del descuento al ticket);
+-- total = quantity * unit_price - discount. En la venta, subtotal es la suma a
+-- precio de lista y total lo cobrado.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS bazar_promotions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE RESTRICT,
+    bazar_id UUID NOT NULL REFERENCES bazaars(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL
+        CHECK (kind IN ('buy_x_pay_y', 'bundle_price', 'category_discount')),
+    product_id UUID REFERENCES products(id) ON DELETE CASCADE,
+    variant_group_id UUID,
+    category TEXT,
+    quantity INTEGER CHECK (quantity IS NULL OR quantity > 0),
+    pay_quantity INTEGER CHECK (pay_quantity IS NULL OR pay_quantity >= 0),
+    bundle_price NUMERIC(12,2) CHECK (bundle_price IS NULL OR bundle_price >= 0),
+    discount_percent NUMERIC(5,2)
+        CHECK (discount_percent IS NULL OR (discount_percent > 0 AND discount_percent <= 100)),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bazar_promotions_bazar
+    ON bazar_promotions (organization_id, bazar_id, active, created_at);
+
+DROP TRIGGER IF EXISTS update_bazar_promotions_updated_at ON bazar_promotions;
+CREATE TRIGGER update_bazar_promotions_updated_at
+    BEFORE UPDATE ON bazar_promotions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE bazar_sale_items
+    ADD COLUMN IF NOT EXISTS discount NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (discount >= 0),
+    ADD COLUMN IF NOT EXISTS promotion_discount NUMERIC(12,2) NOT NULL DEFAULT 0
+        CHECK (promotion_discount >= 0),
+    ADD COLUMN IF NOT EXISTS promotion_id UUID REFERENCES bazar_promotions(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS discount_reason TEXT;
+
+ALTER TABLE bazar_sales
+    ADD COLUMN IF NOT EXISTS discount_total NUMERIC(12,2) NOT NULL DEFAULT 0
+        CHECK (discount_total >= 0),
+    ADD COLUMN IF NOT EXISTS ticket_discount NUMERIC(12,2) NOT NULL DEFAULT 0
+        CHECK (ticket_discount >= 0),
+    ADD COLUMN IF NOT EXISTS discount_reason TEXT;
+
+COMMIT;
@@ -0,0 +1,46 @@
+-- Inventario por ubicación. products.stock sigue siendo el total de la
+-- organización; bazar_stock_locations guarda lo que está físicamente en el
+-- puesto de cada bazar abierto y el almacén es la diferencia. Los traspasos
+-- mueven piezas entre el almacén y un puesto sin cambiar el total, y al cerrar
+-- el bazar lo que queda en el puesto regresa al almacén.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS bazar_stock_locations (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE RESTRICT,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    bazar_id UUID NOT NULL REFERENCES bazaars(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, bazar_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bazar_stock_locations_bazar
+    ON bazar_stock_locations (organization_id, bazar_id);
+
+DROP TRIGGER IF EXISTS update_bazar_stock_locations_updated_at ON bazar_stock_locations;
+CREATE TRIGGER update_bazar_stock_locations_updated_at
+    BEFORE UPDATE ON bazar_stock_locations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- from_stand indica si las piezas del renglón salieron del puesto; así una
+-- cancelación o devolución las regresa al mismo lugar.
+ALTER TABLE bazar_sale_items
+    ADD COLUMN IF NOT EXISTS from_stand BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE bazar_inventory_movements
+    ADD COLUMN IF NOT EXISTS stand_stock_before INTEGER CHECK (stand_stock_before >= 0),
+    ADD COLUMN IF NOT EXISTS stand_stock_after INTEGER CHECK (stand_stock_after >= 0);
+
+ALTER TABLE bazar_inventory_movements
+    DROP CONSTRAINT IF EXISTS bazar_inventory_movements_movement_type_check;
+ALTER TABLE bazar_inventory_movements
+    ADD CONSTRAINT bazar_inventory_movements_movement_type_check
+    CHECK (movement_type IN (
+        'sale', 'sale_cancelled', 'return', 'damaged', 'lost',
+        'gift', 'sample', 'manual_adjustment', 'inventory_entry',
+        'transfer_to_bazar', 'transfer_to_warehouse'
+    ));
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- Ventas sin conexión que llegan en lote. Si el punto de venta vendió más
+-- piezas de las que había registradas y el lote se envió con la política
+-- allow_oversell, la venta se acepta, el stock queda en cero y el faltante se
+-- guarda en el renglón para conciliarlo después.
+
+BEGIN;
+
+ALTER TABLE bazar_sale_items
+    ADD COLUMN IF NOT EXISTS oversold_quantity INTEGER NOT NULL DEFAULT 0
+        CHECK (oversold_quantity >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_bazar_sale_items_oversold
+    ON bazar_sale_items (organization_id, product_id)
+    WHERE oversold_quantity > 0;
+
+COMMIT;
@@ -0,0 +1,42 @@
+-- Rentabilidad del bazar. Cada renglón de venta guarda el costo del producto
+-- al momento de venderlo para que cambiar el costo después no altere reportes
+-- pasados. Los gastos del evento (renta del puesto, transporte, etc.) se
+-- registran contra el bazar, y al cerrarlo el resultado puede pasarse una sola
+-- vez a los ingresos y gastos de finanzas.
+
+BEGIN;
+
+ALTER TABLE bazar_sale_items
+    ADD COLUMN IF NOT EXISTS unit_cost NUMERIC(12,2) CHECK (unit_cost >= 0);
+
+UPDATE bazar_sale_items i
+SET unit_cost = p.cost
+FROM products p
+WHERE p.id = i.product_id
+  AND i.unit_cost IS NULL
+  AND p.cost IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS bazar_expenses (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE RESTRICT,
+    bazar_id UUID NOT NULL REFERENCES bazaars(id) ON DELETE CASCADE,
+    category TEXT NOT NULL
+        CHECK (category IN ('stand_fee', 'transport', 'staff', 'supplies', 'advertising', 'other')),
+    description TEXT NOT NULL,
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    spent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finance_expense_id UUID REFERENCES finance_expenses(id) ON DELETE SET NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_by_name TEXT NOT NULL DEFAULT 'Sistema',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bazar_expenses_bazar
+    ON bazar_expenses (organization_id, bazar_id, spent_at);
+
+ALTER TABLE bazaars
+    ADD COLUMN IF NOT EXISTS finance_income_id UUID
+        REFERENCES finance_external_incomes(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS finance_posted_at TIMESTAMPTZ;
+
+COMMIT;
@@ -0,0 +1,38 @@
+-- Folios consecutivos por organización y tipo de documento (pedido,
+-- cotización, venta de bazar y ticket). El contador se incrementa dentro de la
+-- transacción que guarda el documento, así que un documento que no se guarda
+-- tampoco consume número. Reemplaza los números basados en la hora
+-- (ORD-20260101120000), que chocaban cuando dos documentos se creaban en el
+-- mismo segundo.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS document_sequences (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    document_type TEXT NOT NULL
+        CHECK (document_type IN ('order', 'quote', 'bazar_sale', 'receipt')),
+    prefix TEXT NOT NULL,
+    format TEXT NOT NULL DEFAULT '{prefix}-{year}-{number}',
+    padding INT NOT NULL DEFAULT 5 CHECK (padding BETWEEN 1 AND 10),
+    reset_yearly BOOLEAN NOT NULL DEFAULT FALSE,
+    period_year INT NOT NULL,
+    last_number BIGINT NOT NULL DEFAULT 0 CHECK (last_number >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, document_type)
+);
+
+DROP TRIGGER IF EXISTS update_document_sequences_updated_at ON document_sequences;
+CREATE TRIGGER update_document_sequences_updated_at
+    BEFORE UPDATE ON document_sequences
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Folio del ticket impreso; las ventas anteriores siguen usando external_id.
+ALTER TABLE bazar_sales
+    ADD COLUMN IF NOT EXISTS receipt_number TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bazar_sales_org_receipt_number
+    ON bazar_sales (organization_id, receipt_number)
+    WHERE receipt_number IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,43 @@
+-- Búsqueda de pedidos en SQL. search_text junta número, cliente, producto y
+-- notas en minúsculas para búsquedas parciales con trigramas (ILIKE '%dra%'),
+-- y search_vector permite búsqueda por palabras en español. Los índices de
+-- (organization_id, created_at DESC, id DESC) sostienen la paginación por
+-- cursor y la ventana de fechas de entrega de los recordatorios SLA.
+
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS search_text TEXT GENERATED ALWAYS AS (
+        lower(
+            coalesce(order_number, '') || ' ' ||
+            coalesce(customer_name, '') || ' ' ||
+            coalesce(product_name, '') || ' ' ||
+            coalesce(notes, '')
+        )
+    ) STORED,
+    ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+        to_tsvector(
+            'spanish'::regconfig,
+            coalesce(order_number, '') || ' ' ||
+            coalesce(customer_name, '') || ' ' ||
+            coalesce(product_name, '') || ' ' ||
+            coalesce(notes, '')
+        )
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_orders_search_text_trgm
+    ON orders USING GIN (search_text gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_orders_search_vector
+    ON orders USING GIN (search_vector);
+
+CREATE INDEX IF NOT EXISTS idx_orders_org_created_keyset
+    ON orders (organization_id, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_orders_org_delivery_deadline
+    ON orders (organization_id, delivery_deadline)
+    WHERE delivery_deadline IS NOT NULL;
+
+COMMIT;
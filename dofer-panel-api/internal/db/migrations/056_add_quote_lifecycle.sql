@@ -0,0 +1,26 @@
+-- Ciclo de vida de cotizaciones: draft → sent → approved / rejected / expired,
+-- con reapertura a draft. El código guardaba "pending" y "approved" aunque la
+-- restricción original pedía "draft" y "accepted"; aquí se unifican los
+-- valores y se vuelve a crear la restricción con los estados vigentes.
+-- expiry_reminder_sent_at evita mandar dos veces el aviso de vencimiento.
+
+BEGIN;
+
+ALTER TABLE quotes DROP CONSTRAINT IF EXISTS quotes_status_check;
+
+UPDATE quotes SET status = 'draft' WHERE status = 'pending';
+UPDATE quotes SET status = 'approved' WHERE status = 'accepted';
+
+ALTER TABLE quotes ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE quotes
+    ADD CONSTRAINT quotes_status_check
+    CHECK (status IN ('draft', 'sent', 'approved', 'rejected', 'expired'));
+
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS expiry_reminder_sent_at TIMESTAMPTZ;
+
+-- El job de vigencia solo busca cotizaciones abiertas por fecha.
+CREATE INDEX IF NOT EXISTS idx_quotes_open_valid_until
+    ON quotes(valid_until)
+    WHERE status IN ('draft', 'sent');
+
+COMMIT;
@@ -0,0 +1,34 @@
+-- Revisiones inmutables de cotizaciones. Se guarda una foto (datos del
+-- cliente, totales e items) al enviar la cotización y cada vez que se edita
+-- después de enviada, para comparar versiones y restaurar una anterior.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS quote_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE RESTRICT,
+    quote_id UUID NOT NULL REFERENCES quotes(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL CHECK (revision > 0),
+    reason TEXT NOT NULL,
+    source_revision INTEGER,
+    snapshot JSONB NOT NULL,
+    created_by TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (quote_id, revision)
+);
+
+CREATE INDEX IF NOT EXISTS idx_quote_revisions_organization ON quote_revisions(organization_id);
+
+-- Las revisiones no se modifican: solo se agregan o se borran con la cotización.
+CREATE OR REPLACE FUNCTION prevent_quote_revision_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'quote revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_quote_revisions_immutable ON quote_revisions;
+CREATE TRIGGER trg_quote_revisions_immutable
+    BEFORE UPDATE ON quote_revisions
+    FOR EACH ROW EXECUTE FUNCTION prevent_quote_revision_update();
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- El planificador guardaba el inicio planeado en assigned_at. Ahora va en su
+-- propia columna y assigned_at vuelve a ser la fecha real de asignación.
+-- started_at marca el trabajo que la impresora ya tiene en curso; esas
+-- asignaciones no se replanifican.
+
+BEGIN;
+
+ALTER TABLE printer_assignments ADD COLUMN IF NOT EXISTS planned_start_at TIMESTAMPTZ;
+ALTER TABLE printer_assignments ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ;
+
+-- Asignaciones abiertas con assigned_at en el futuro: ese valor era el plan.
+UPDATE printer_assignments
+SET planned_start_at = assigned_at,
+    assigned_at = NOW()
+WHERE completed_at IS NULL
+  AND assigned_at > NOW();
+
+COMMIT;
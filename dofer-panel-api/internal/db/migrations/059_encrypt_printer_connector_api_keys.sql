@@ -0,0 +1,12 @@
+-- Las llaves de los conectores de impresora se guardan cifradas con
+-- SECRETS_ENCRYPTION_KEY, igual que las credenciales del bazar. La llave no
+-- está en la base, así que las llaves en texto plano de api_key se sellan
+-- desde la API la próxima vez que el poller las lee.
+
+BEGIN;
+
+ALTER TABLE printer_connectors ADD COLUMN IF NOT EXISTS api_key_encrypted TEXT;
+
+COMMENT ON COLUMN printer_connectors.api_key IS 'Obsoleta: llave en texto plano previa al cifrado; se vacía al sellarla';
+
+COMMIT;
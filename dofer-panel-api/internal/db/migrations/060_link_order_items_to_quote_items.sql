@@ -0,0 +1,32 @@
+-- El consumo de material empataba los items de la orden con los de la
+-- cotización por nombre de producto, y dos productos con el mismo nombre
+-- descontaban el peso equivocado. Cada item guarda ahora el item de la
+-- cotización del que salió. Sin llave foránea: restaurar una revisión vuelve
+-- a insertar los items de la cotización con los mismos IDs.
+
+BEGIN;
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS quote_item_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_order_items_quote_item ON order_items(quote_item_id);
+
+-- Items existentes: solo se enlazan cuando el nombre no es ambiguo.
+UPDATE order_items oi
+SET quote_item_id = matched.id
+FROM (
+    SELECT q.converted_to_order_id AS order_id, qi.product_name, MIN(qi.id::text)::uuid AS id
+    FROM quote_items qi
+    JOIN quotes q ON q.id = qi.quote_id
+    WHERE q.converted_to_order_id IS NOT NULL
+    GROUP BY q.converted_to_order_id, qi.product_name
+    HAVING COUNT(*) = 1
+) matched
+WHERE oi.order_id = matched.order_id
+  AND oi.product_name = matched.product_name
+  AND oi.quote_item_id IS NULL
+  AND (
+      SELECT COUNT(*) FROM order_items other
+      WHERE other.order_id = oi.order_id AND other.product_name = oi.product_name
+  ) = 1;
+
+COMMIT;
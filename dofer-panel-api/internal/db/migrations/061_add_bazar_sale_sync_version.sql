@@ -0,0 +1,10 @@
+-- sync_version cambia cada vez que una venta vuelve a la cola por un cambio
+-- propio (cancelación, devolución). Quien la sincroniza solo puede marcarla
+-- como enviada o fallida si sigue en la versión que apartó; así un envío
+-- viejo que termina tarde no tapa el cambio más reciente.
+
+BEGIN;
+
+ALTER TABLE bazar_sales ADD COLUMN IF NOT EXISTS sync_version INTEGER NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Los correos se reintentan desde notification_deliveries (dispatcher de
+-- notificaciones); email_outbox ya no recibe filas.
+
+BEGIN;
+
+DROP TABLE IF EXISTS email_outbox;
+
+COMMIT;
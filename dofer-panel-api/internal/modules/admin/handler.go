@@ -8,17 +8,21 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dofer/panel-api/internal/platform/branding"
 	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
+	"github.com/dofer/panel-api/internal/platform/numbering"
 	"github.com/go-chi/chi/v5"
 )
 
 type Handler struct {
 	repo             *Repository
 	passwordVerifier PasswordVerifier
+	branding         *branding.Repository
+	numbering        *numbering.Repository
 }
 
-func NewHandler(repo *Repository, passwordVerifier PasswordVerifier) *Handler {
-	return &Handler{repo: repo, passwordVerifier: passwordVerifier}
+func NewHandler(repo *Repository, passwordVerifier PasswordVerifier, brandingRepo *branding.Repository, numberingRepo *numbering.Repository) *Handler {
+	return &Handler{repo: repo, passwordVerifier: passwordVerifier, branding: brandingRepo, numbering: numberingRepo}
 }
 
 func RegisterRoutes(r chi.Router, h *Handler) {
@@ -29,6 +33,10 @@ func RegisterRoutes(r chi.Router, h *Handler) {
 		r.Get("/organizations", h.ListOrganizations)
 		r.Get("/organization", h.GetOrganization)
 		r.Put("/organization", h.UpdateOrganization)
+		r.Get("/organization/branding", h.GetBranding)
+		r.Put("/organization/branding", h.UpdateBranding)
+		r.Get("/organization/numbering", h.ListNumbering)
+		r.Put("/organization/numbering/{documentType}", h.UpdateNumbering)
 		r.With(middleware.RequirePlatformAdmin).Patch("/organization/subscription", h.UpdateOrganizationSubscription)
 		r.Get("/organization/overview", h.GetOrganizationOverview)
 		r.Get("/organization/audit", h.ListAuditLogs)
@@ -123,6 +131,129 @@ func (h *Handler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(summary)
 }
 
+// GetBranding devuelve logo, color y datos fiscales que usan los PDF.
+func (h *Handler) GetBranding(w http.ResponseWriter, r *http.Request) {
+	organizationID, ok := organizationIDFromRequest(r)
+	if !ok {
+		http.Error(w, "organization not available", http.StatusForbidden)
+		return
+	}
+
+	result, err := h.branding.Get(r.Context(), organizationID)
+	if err != nil {
+		if errors.Is(err, branding.ErrOrganizationNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *Handler) UpdateBranding(w http.ResponseWriter, r *http.Request) {
+	organizationID, ok := organizationIDFromRequest(r)
+	if !ok {
+		http.Error(w, "organization not available", http.StatusForbidden)
+		return
+	}
+
+	var request branding.UpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.branding.Update(r.Context(), organizationID, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, branding.ErrInvalidLogo), errors.Is(err, branding.ErrInvalidColor):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, branding.ErrOrganizationNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if actorUserID, ok := middleware.UserIDFromContext(r.Context()); ok {
+		_ = h.repo.CreateAuditLog(r.Context(), organizationID, actorUserID, "organization.branding_updated", "organization", organizationID, map[string]interface{}{
+			"has_logo":    result.LogoDataURL != nil,
+			"brand_color": result.BrandColor,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ListNumbering devuelve las series de folios de pedidos, cotizaciones, ventas
+// de bazar y tickets con el próximo número de cada una.
+func (h *Handler) ListNumbering(w http.ResponseWriter, r *http.Request) {
+	organizationID, ok := organizationIDFromRequest(r)
+	if !ok {
+		http.Error(w, "organization not available", http.StatusForbidden)
+		return
+	}
+
+	sequences, err := h.numbering.List(r.Context(), organizationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"sequences": sequences})
+}
+
+func (h *Handler) UpdateNumbering(w http.ResponseWriter, r *http.Request) {
+	organizationID, ok := organizationIDFromRequest(r)
+	if !ok {
+		http.Error(w, "organization not available", http.StatusForbidden)
+		return
+	}
+
+	var request numbering.UpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	documentType := chi.URLParam(r, "documentType")
+	result, err := h.numbering.Update(r.Context(), organizationID, documentType, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, numbering.ErrUnknownDocumentType):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, numbering.ErrInvalidPrefix),
+			errors.Is(err, numbering.ErrInvalidFormat),
+			errors.Is(err, numbering.ErrInvalidPadding),
+			errors.Is(err, numbering.ErrYearlyResetNeedsYear):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, numbering.ErrNextNumberTooLow):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if actorUserID, ok := middleware.UserIDFromContext(r.Context()); ok {
+		_ = h.repo.CreateAuditLog(r.Context(), organizationID, actorUserID, "organization.numbering_updated", "organization", organizationID, map[string]interface{}{
+			"document_type": documentType,
+			"format":        result.Format,
+			"prefix":        result.Prefix,
+			"next_number":   request.NextNumber,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
 func (h *Handler) UpdateOrganizationSubscription(w http.ResponseWriter, r *http.Request) {
 	organizationID, ok := organizationIDFromRequest(r)
 	if !ok {
@@ -285,7 +285,7 @@ func (r *Repository) GetOrganizationOverview(ctx context.Context, organizationID
 			SELECT
 				COUNT(*) FILTER (WHERE status = 'draft') AS draft_quotes,
 				COUNT(*) FILTER (WHERE status = 'sent') AS sent_quotes,
-				COUNT(*) FILTER (WHERE status = 'accepted') AS accepted_quotes,
+				COUNT(*) FILTER (WHERE status = 'approved') AS accepted_quotes,
 				COUNT(*) FILTER (WHERE status = 'expired') AS expired_quotes,
 				COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '30 days') AS quotes_last_30_days,
 				COUNT(*) AS total_quotes,
@@ -24,12 +24,13 @@ type ApproveOrderRequestResult struct {
 // aprobada en una orden real del pipeline de producción, y genera la
 // comisión pendiente correspondiente.
 type ApproveOrderRequestHandler struct {
-	repo      domain.AffiliateRepository
-	orderRepo ordersDomain.OrderRepository
+	repo         domain.AffiliateRepository
+	orderRepo    ordersDomain.OrderRepository
+	workflowRepo ordersDomain.WorkflowRepository
 }
 
-func NewApproveOrderRequestHandler(repo domain.AffiliateRepository, orderRepo ordersDomain.OrderRepository) *ApproveOrderRequestHandler {
-	return &ApproveOrderRequestHandler{repo: repo, orderRepo: orderRepo}
+func NewApproveOrderRequestHandler(repo domain.AffiliateRepository, orderRepo ordersDomain.OrderRepository, workflowRepo ordersDomain.WorkflowRepository) *ApproveOrderRequestHandler {
+	return &ApproveOrderRequestHandler{repo: repo, orderRepo: orderRepo, workflowRepo: workflowRepo}
 }
 
 func (h *ApproveOrderRequestHandler) Handle(ctx context.Context, cmd ApproveOrderRequestCommand) (*ApproveOrderRequestResult, error) {
@@ -65,6 +66,11 @@ func (h *ApproveOrderRequestHandler) Handle(ctx context.Context, cmd ApproveOrde
 	order.CustomerPhone = req.CustomerPhone
 	order.OrganizationID = organizationID
 	order.AffiliateID = affiliate.ID
+	workflow, err := h.workflowRepo.FindByOrganization(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	order.Status = workflow.InitialStage()
 	order.Priority = ordersDomain.OrderPriority(req.Priority)
 	order.Amount = req.FinalPrice
 	order.AmountPaid = req.CustomerAmountPaid
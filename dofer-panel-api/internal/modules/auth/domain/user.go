@@ -12,6 +12,10 @@ const (
 	RoleViewer   Role = "viewer"
 )
 
+// DefaultOrganizationID es la organización que ya existía antes del
+// multi-tenant; los usuarios sin membresía se agregan a ella.
+const DefaultOrganizationID = "00000000-0000-0000-0000-000000000001"
+
 type User struct {
 	ID        string
 	Email     string
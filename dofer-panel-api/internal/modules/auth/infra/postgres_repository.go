@@ -98,8 +98,6 @@ func (r *PostgresUserRepository) Create(user *domain.User) error {
 	return err
 }
 
-const defaultOrganizationID = "00000000-0000-0000-0000-000000000001"
-
 // UpsertUser sincroniza el usuario de Supabase con el usuario local.
 // Si ya existe un usuario local con el mismo email, conserva su ID local y
 // enlaza el subject de Supabase en auth_user_id.
@@ -203,7 +201,7 @@ func (r *PostgresUserRepository) ensureDefaultOrganizationMembership(ctx context
 		SET name = EXCLUDED.name,
 		    slug = EXCLUDED.slug,
 		    updated_at = NOW()
-	`, defaultOrganizationID)
+	`, domain.DefaultOrganizationID)
 	if err != nil {
 		return err
 	}
@@ -213,7 +211,7 @@ func (r *PostgresUserRepository) ensureDefaultOrganizationMembership(ctx context
 		SELECT COUNT(*)
 		FROM organization_members
 		WHERE organization_id = $1
-	`, defaultOrganizationID).Scan(&membershipCount)
+	`, domain.DefaultOrganizationID).Scan(&membershipCount)
 	if err != nil {
 		return err
 	}
@@ -235,7 +233,7 @@ func (r *PostgresUserRepository) ensureDefaultOrganizationMembership(ctx context
 		INSERT INTO organization_members (organization_id, user_id, role)
 		VALUES ($1, $2, $3)
 		ON CONFLICT (organization_id, user_id) DO NOTHING
-	`, defaultOrganizationID, userID, role)
+	`, domain.DefaultOrganizationID, userID, role)
 	return err
 }
 
@@ -13,6 +13,9 @@ const (
 	PaymentCard        = "card"
 	PaymentMercadoPago = "mercado_pago"
 	PaymentOther       = "other"
+	// PaymentMixed marca las ventas pagadas con más de un método; el detalle
+	// está en Sale.Payments.
+	PaymentMixed = "mixed"
 )
 
 type Product struct {
@@ -69,31 +72,87 @@ type SaleItem struct {
 	Total             float64   `json:"total"`
 	StockBefore       int       `json:"stock_before"`
 	StockAfter        int       `json:"stock_after"`
+	ReturnedQuantity  int       `json:"returned_quantity"`
+	// Discount incluye PromotionDiscount, el descuento manual del renglón y
+	// su parte del descuento al ticket; Total ya lo trae restado.
+	Discount          float64    `json:"discount"`
+	PromotionDiscount float64    `json:"promotion_discount"`
+	PromotionID       *uuid.UUID `json:"promotion_id,omitempty"`
+	DiscountReason    *string    `json:"discount_reason,omitempty"`
+	// FromStand indica que las piezas salieron del puesto del bazar y no del
+	// almacén.
+	FromStand bool `json:"from_stand"`
+	// OversoldQuantity son las piezas vendidas sin conexión que ya no había
+	// en el inventario registrado.
+	OversoldQuantity int `json:"oversold_quantity"`
 }
 
 type Sale struct {
-	ID              uuid.UUID  `json:"id"`
-	ExternalID      string     `json:"external_id"`
-	ClientRequestID uuid.UUID  `json:"client_request_id"`
-	BazarID         uuid.UUID  `json:"bazar_id"`
-	BazarName       string     `json:"bazar_name"`
-	SellerID        *uuid.UUID `json:"seller_id,omitempty"`
-	SellerName      string     `json:"seller_name"`
-	Subtotal        float64    `json:"subtotal"`
-	Total           float64    `json:"total"`
-	PaymentMethod   string     `json:"payment_method"`
-	CashReceived    *float64   `json:"cash_received,omitempty"`
-	ChangeDue       *float64   `json:"change_due,omitempty"`
-	Status          string     `json:"status"`
-	SyncStatus      string     `json:"sync_status"`
-	SyncAttempts    int        `json:"sync_attempts"`
-	LastSyncAt      *time.Time `json:"last_sync_at,omitempty"`
-	SyncError       *string    `json:"sync_error,omitempty"`
-	Notes           *string    `json:"notes,omitempty"`
-	SoldAt          time.Time  `json:"sold_at"`
-	CreatedAt       time.Time  `json:"created_at"`
-	CancelledAt     *time.Time `json:"cancelled_at,omitempty"`
-	Items           []SaleItem `json:"items"`
+	ID              uuid.UUID     `json:"id"`
+	ExternalID      string        `json:"external_id"`
+	ReceiptNumber   *string       `json:"receipt_number,omitempty"`
+	ClientRequestID uuid.UUID     `json:"client_request_id"`
+	BazarID         uuid.UUID     `json:"bazar_id"`
+	BazarName       string        `json:"bazar_name"`
+	SellerID        *uuid.UUID    `json:"seller_id,omitempty"`
+	SellerName      string        `json:"seller_name"`
+	Subtotal        float64       `json:"subtotal"`
+	Total           float64       `json:"total"`
+	PaymentMethod   string        `json:"payment_method"`
+	CashReceived    *float64      `json:"cash_received,omitempty"`
+	ChangeDue       *float64      `json:"change_due,omitempty"`
+	Status          string        `json:"status"`
+	SyncStatus      string        `json:"sync_status"`
+	SyncAttempts    int           `json:"sync_attempts"`
+	LastSyncAt      *time.Time    `json:"last_sync_at,omitempty"`
+	NextSyncAt      *time.Time    `json:"next_sync_at,omitempty"`
+	SyncError       *string       `json:"sync_error,omitempty"`
+	SyncVersion     int           `json:"-"`
+	Notes           *string       `json:"notes,omitempty"`
+	SoldAt          time.Time     `json:"sold_at"`
+	CreatedAt       time.Time     `json:"created_at"`
+	CancelledAt     *time.Time    `json:"cancelled_at,omitempty"`
+	DiscountTotal   float64       `json:"discount_total"`
+	TicketDiscount  float64       `json:"ticket_discount"`
+	DiscountReason  *string       `json:"discount_reason,omitempty"`
+	RefundedTotal   float64       `json:"refunded_total"`
+	Payments        []SalePayment `json:"payments"`
+	Items           []SaleItem    `json:"items"`
+	Returns         []SaleReturn  `json:"returns"`
+}
+
+// SalePayment es lo que se cobró de una venta con un método de pago.
+type SalePayment struct {
+	Method string  `json:"method"`
+	Amount float64 `json:"amount"`
+}
+
+// SaleReturn es una devolución parcial o total de una venta. Puede no traer
+// renglones cuando solo se reembolsa dinero.
+type SaleReturn struct {
+	ID            uuid.UUID        `json:"id"`
+	ExternalID    string           `json:"external_id"`
+	SaleID        uuid.UUID        `json:"sale_id"`
+	RefundMethod  string           `json:"refund_method"`
+	RefundAmount  float64          `json:"refund_amount"`
+	Reason        *string          `json:"reason,omitempty"`
+	CreatedByName string           `json:"created_by_name"`
+	ReturnedAt    time.Time        `json:"returned_at"`
+	Items         []SaleReturnItem `json:"items"`
+}
+
+type SaleReturnItem struct {
+	ID                uuid.UUID `json:"id"`
+	SaleItemID        uuid.UUID `json:"sale_item_id"`
+	ProductID         uuid.UUID `json:"product_id"`
+	ProductExternalID string    `json:"product_external_id"`
+	ProductName       string    `json:"product_name"`
+	Quantity          int       `json:"quantity"`
+	UnitPrice         float64   `json:"unit_price"`
+	RefundAmount      float64   `json:"refund_amount"`
+	Restocked         bool      `json:"restocked"`
+	StockBefore       int       `json:"stock_before"`
+	StockAfter        int       `json:"stock_after"`
 }
 
 type DailyStats struct {
@@ -110,14 +169,72 @@ type DailyStats struct {
 type SyncStatus struct {
 	Configured       bool       `json:"configured"`
 	Status           string     `json:"status"`
+	Target           string     `json:"target"`
 	PendingSales     int        `json:"pending_sales"`
 	FailedSales      int        `json:"failed_sales"`
+	DeadSales        int        `json:"dead_sales"`
+	QueueDepth       int        `json:"queue_depth"`
+	DueSales         int        `json:"due_sales"`
+	NextRetryAt      *time.Time `json:"next_retry_at,omitempty"`
 	LastProductSync  *time.Time `json:"last_product_sync,omitempty"`
 	LastSaleSync     *time.Time `json:"last_sale_sync,omitempty"`
 	LastError        string     `json:"last_error,omitempty"`
 	ConfigurationMsg string     `json:"configuration_message,omitempty"`
 }
 
+// SheetsSettings es la integración de Google Sheets de la organización tal
+// como se muestra en el panel; la clave privada nunca sale del servidor.
+type SheetsSettings struct {
+	Source        string     `json:"source"` // organization, environment o none
+	SpreadsheetID string     `json:"spreadsheet_id,omitempty"`
+	ServiceEmail  string     `json:"service_email,omitempty"`
+	HasPrivateKey bool       `json:"has_private_key"`
+	InventoryName string     `json:"inventory_sheet_name"`
+	SalesName     string     `json:"sales_sheet_name"`
+	LastTestedAt  *time.Time `json:"last_tested_at,omitempty"`
+	LastTestError string     `json:"last_test_error,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+// UpdateSheetsSettingsRequest acepta el JSON de la cuenta de servicio tal
+// como lo descarga Google o los campos por separado. Sin private_key se
+// conserva la clave guardada.
+type UpdateSheetsSettingsRequest struct {
+	SpreadsheetID   string  `json:"spreadsheet_id"`
+	ServiceEmail    string  `json:"service_email,omitempty"`
+	PrivateKey      *string `json:"private_key,omitempty"`
+	CredentialsJSON string  `json:"credentials_json,omitempty"`
+	InventoryName   string  `json:"inventory_sheet_name,omitempty"`
+	SalesName       string  `json:"sales_sheet_name,omitempty"`
+}
+
+// SyncTargetSettings es el destino de sincronización elegido por la
+// organización; el secreto del webhook nunca sale del servidor.
+type SyncTargetSettings struct {
+	Kind                string     `json:"kind"` // google_sheets, file o webhook
+	WebhookURL          string     `json:"webhook_url,omitempty"`
+	HasWebhookSecret    bool       `json:"has_webhook_secret"`
+	WebhookInventoryURL string     `json:"webhook_inventory_url,omitempty"`
+	InventoryUploadedAt *time.Time `json:"inventory_uploaded_at,omitempty"`
+	UpdatedAt           *time.Time `json:"updated_at,omitempty"`
+}
+
+// UpdateSyncTargetRequest cambia el destino. Sin webhook_secret se conserva el
+// guardado; con cadena vacía se borra.
+type UpdateSyncTargetRequest struct {
+	Kind                string  `json:"kind"`
+	WebhookURL          string  `json:"webhook_url,omitempty"`
+	WebhookSecret       *string `json:"webhook_secret,omitempty"`
+	WebhookInventoryURL string  `json:"webhook_inventory_url,omitempty"`
+}
+
+type SheetsTestResult struct {
+	OK        bool      `json:"ok"`
+	Message   string    `json:"message"`
+	TestedAt  time.Time `json:"tested_at"`
+	Inventory int       `json:"inventory_products"`
+}
+
 type CreateBazarRequest struct {
 	Name                 string  `json:"name"`
 	Location             *string `json:"location,omitempty"`
@@ -171,9 +288,57 @@ type InventoryMovement struct {
 	Quantity     int        `json:"quantity"`
 	StockBefore  int        `json:"stock_before"`
 	StockAfter   int        `json:"stock_after"`
-	Reason       *string    `json:"reason,omitempty"`
-	ActorName    string     `json:"actor_name"`
-	CreatedAt    time.Time  `json:"created_at"`
+	// StandStockBefore y StandStockAfter son lo que había en el puesto del
+	// bazar cuando el movimiento lo tocó; quedan vacíos si fue en el almacén.
+	StandStockBefore *int      `json:"stand_stock_before,omitempty"`
+	StandStockAfter  *int      `json:"stand_stock_after,omitempty"`
+	Reason           *string   `json:"reason,omitempty"`
+	ActorName        string    `json:"actor_name"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+const (
+	StockToBazar     = "to_bazar"
+	StockToWarehouse = "to_warehouse"
+)
+
+// StockTransferRequest mueve piezas entre el almacén y el puesto de un bazar
+// abierto; Direction es to_bazar o to_warehouse.
+type StockTransferRequest struct {
+	Direction string                     `json:"direction"`
+	Items     []StockTransferItemRequest `json:"items"`
+	Reason    string                     `json:"reason,omitempty"`
+}
+
+type StockTransferItemRequest struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// StockLocation es lo que hay de un producto en el puesto de un bazar.
+type StockLocation struct {
+	BazarID   uuid.UUID `json:"bazar_id"`
+	BazarName string    `json:"bazar_name"`
+	Quantity  int       `json:"quantity"`
+}
+
+// StandStockItem es un producto del puesto de un bazar.
+type StandStockItem struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	ExternalID  string    `json:"external_id"`
+	ProductName string    `json:"product_name"`
+	Quantity    int       `json:"quantity"`
+}
+
+// ProductStockLocations reparte el stock total de un producto entre el
+// almacén y los puestos.
+type ProductStockLocations struct {
+	ProductID      uuid.UUID       `json:"product_id"`
+	ExternalID     string          `json:"external_id"`
+	ProductName    string          `json:"product_name"`
+	TotalStock     int             `json:"total_stock"`
+	WarehouseStock int             `json:"warehouse_stock"`
+	Locations      []StockLocation `json:"locations"`
 }
 
 type CloseBazarRequest struct {
@@ -181,6 +346,50 @@ type CloseBazarRequest struct {
 	Notes       string  `json:"notes,omitempty"`
 }
 
+const (
+	PromotionBuyXPayY         = "buy_x_pay_y"
+	PromotionBundlePrice      = "bundle_price"
+	PromotionCategoryDiscount = "category_discount"
+)
+
+// Promotion se evalúa al registrar cada venta del bazar. buy_x_pay_y cobra
+// PayQuantity de cada Quantity piezas (2x1), bundle_price cobra BundlePrice
+// por cada Quantity piezas y category_discount descuenta DiscountPercent.
+// El alcance es un producto, un grupo de variantes o una categoría.
+type Promotion struct {
+	ID              uuid.UUID  `json:"id"`
+	BazarID         uuid.UUID  `json:"bazar_id"`
+	Name            string     `json:"name"`
+	Kind            string     `json:"kind"`
+	ProductID       *uuid.UUID `json:"product_id,omitempty"`
+	VariantGroupID  *uuid.UUID `json:"variant_group_id,omitempty"`
+	Category        *string    `json:"category,omitempty"`
+	Quantity        int        `json:"quantity,omitempty"`
+	PayQuantity     int        `json:"pay_quantity,omitempty"`
+	BundlePrice     *float64   `json:"bundle_price,omitempty"`
+	DiscountPercent *float64   `json:"discount_percent,omitempty"`
+	Active          bool       `json:"active"`
+	StartsAt        *time.Time `json:"starts_at,omitempty"`
+	EndsAt          *time.Time `json:"ends_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type PromotionRequest struct {
+	Name            string     `json:"name"`
+	Kind            string     `json:"kind"`
+	ProductID       string     `json:"product_id,omitempty"`
+	VariantGroupID  string     `json:"variant_group_id,omitempty"`
+	Category        string     `json:"category,omitempty"`
+	Quantity        int        `json:"quantity,omitempty"`
+	PayQuantity     int        `json:"pay_quantity,omitempty"`
+	BundlePrice     *float64   `json:"bundle_price,omitempty"`
+	DiscountPercent *float64   `json:"discount_percent,omitempty"`
+	Active          *bool      `json:"active,omitempty"`
+	StartsAt        *time.Time `json:"starts_at,omitempty"`
+	EndsAt          *time.Time `json:"ends_at,omitempty"`
+}
+
 type PaymentSummary struct {
 	Method     string  `json:"method"`
 	Operations int     `json:"operations"`
@@ -203,21 +412,123 @@ type SellerSummary struct {
 }
 
 type BazarReport struct {
-	Bazar          *Bazar           `json:"bazar,omitempty"`
-	Date           string           `json:"date"`
-	From           time.Time        `json:"from"`
-	To             time.Time        `json:"to"`
-	Total          float64          `json:"total"`
-	ProductsSold   int              `json:"products_sold"`
-	Operations     int              `json:"operations"`
-	AverageTicket  float64          `json:"average_ticket"`
-	CancelledSales int              `json:"cancelled_sales"`
+	Bazar *Bazar    `json:"bazar,omitempty"`
+	Date  string    `json:"date"`
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+	Total float64   `json:"total"`
+	// Subtotal es lo vendido a precio de lista; Total ya descuenta Discounts,
+	// de los que PromotionDiscounts vienen de promociones.
+	Subtotal           float64 `json:"subtotal"`
+	Discounts          float64 `json:"discounts"`
+	PromotionDiscounts float64 `json:"promotion_discounts"`
+	ProductsSold       int     `json:"products_sold"`
+	Operations         int     `json:"operations"`
+	AverageTicket      float64 `json:"average_ticket"`
+	CancelledSales     int     `json:"cancelled_sales"`
+	// Returns y Refunds cuentan las devoluciones registradas en el periodo,
+	// sin importar cuándo fue la venta original.
+	Returns        int              `json:"returns"`
+	Refunds        float64          `json:"refunds"`
+	CashRefunds    float64          `json:"cash_refunds"`
 	PaymentMethods []PaymentSummary `json:"payment_methods"`
 	Products       []ProductSummary `json:"products"`
 	Sellers        []SellerSummary  `json:"sellers"`
 	ExpectedCash   float64          `json:"expected_cash"`
 	ClosingCash    *float64         `json:"closing_cash,omitempty"`
 	CashDifference *float64         `json:"cash_difference,omitempty"`
+	// Con un bazar, StockSent y StockReturned suman los traspasos del periodo
+	// y StandStock es lo que hay ahora en su puesto.
+	StockSent     int              `json:"stock_sent"`
+	StockReturned int              `json:"stock_returned"`
+	StandStock    []StandStockItem `json:"stand_stock,omitempty"`
+	// Profitability solo viene en el reporte final del bazar.
+	Profitability *BazarProfitability `json:"profitability,omitempty"`
+}
+
+const (
+	ExpenseStandFee    = "stand_fee"
+	ExpenseTransport   = "transport"
+	ExpenseStaff       = "staff"
+	ExpenseSupplies    = "supplies"
+	ExpenseAdvertising = "advertising"
+	ExpenseOther       = "other"
+)
+
+// BazarExpense es un gasto del evento, como la renta del puesto o el
+// transporte.
+type BazarExpense struct {
+	ID               uuid.UUID  `json:"id"`
+	BazarID          uuid.UUID  `json:"bazar_id"`
+	Category         string     `json:"category"`
+	Description      string     `json:"description"`
+	Amount           float64    `json:"amount"`
+	SpentAt          time.Time  `json:"spent_at"`
+	FinanceExpenseID *uuid.UUID `json:"finance_expense_id,omitempty"`
+	CreatedByName    string     `json:"created_by_name"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+type BazarExpenseRequest struct {
+	Category    string     `json:"category"`
+	Description string     `json:"description"`
+	Amount      float64    `json:"amount"`
+	SpentAt     *time.Time `json:"spent_at,omitempty"`
+}
+
+// BazarProfitability es la utilidad del evento. Revenue son las ventas netas
+// de reembolsos; el costo usa el que tenía cada producto al venderse y no
+// cuenta las piezas devueltas.
+type BazarProfitability struct {
+	Revenue            float64          `json:"revenue"`
+	CostOfGoods        float64          `json:"cost_of_goods"`
+	GrossMargin        float64          `json:"gross_margin"`
+	GrossMarginPercent float64          `json:"gross_margin_percent"`
+	Expenses           float64          `json:"expenses"`
+	NetProfit          float64          `json:"net_profit"`
+	Products           []ProductProfit  `json:"products"`
+	Categories         []CategoryProfit `json:"categories"`
+	ExpenseItems       []BazarExpense   `json:"expense_items"`
+	// ProductsWithoutCost cuenta los productos vendidos sin costo capturado;
+	// su costo se toma como cero.
+	ProductsWithoutCost int        `json:"products_without_cost"`
+	FinanceIncomeID     *uuid.UUID `json:"finance_income_id,omitempty"`
+	FinancePostedAt     *time.Time `json:"finance_posted_at,omitempty"`
+}
+
+type ProductProfit struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	ExternalID  string    `json:"external_id"`
+	ProductName string    `json:"product_name"`
+	Category    string    `json:"category"`
+	Quantity    int       `json:"quantity"`
+	Revenue     float64   `json:"revenue"`
+	Cost        float64   `json:"cost"`
+	GrossMargin float64   `json:"gross_margin"`
+	CostMissing bool      `json:"cost_missing"`
+}
+
+type CategoryProfit struct {
+	Category    string  `json:"category"`
+	Quantity    int     `json:"quantity"`
+	Revenue     float64 `json:"revenue"`
+	Cost        float64 `json:"cost"`
+	GrossMargin float64 `json:"gross_margin"`
+}
+
+// FinancePostRequest pasa el resultado del bazar a finanzas. El costo de la
+// mercancía no se registra por defecto porque el material ya suele estar
+// capturado como gasto de filamento.
+type FinancePostRequest struct {
+	IncludeCostOfGoods bool `json:"include_cost_of_goods"`
+}
+
+type FinancePostResult struct {
+	IncomeID   *uuid.UUID  `json:"income_id,omitempty"`
+	Income     float64     `json:"income"`
+	ExpenseIDs []uuid.UUID `json:"expense_ids"`
+	Expenses   float64     `json:"expenses"`
+	PostedAt   time.Time   `json:"posted_at"`
 }
 
 type AuditLog struct {
@@ -247,8 +558,17 @@ type SyncRequest struct {
 }
 
 type CreateSaleItemRequest struct {
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+	ProductID string           `json:"product_id"`
+	Quantity  int              `json:"quantity"`
+	Discount  *DiscountRequest `json:"discount,omitempty"`
+}
+
+// DiscountRequest es un descuento manual al renglón o al ticket. Type es
+// percentage o fixed y el motivo es obligatorio.
+type DiscountRequest struct {
+	Type   string  `json:"type"`
+	Value  float64 `json:"value"`
+	Reason string  `json:"reason"`
 }
 
 type CreateSaleRequest struct {
@@ -258,12 +578,36 @@ type CreateSaleRequest struct {
 	Quantity        int                     `json:"quantity,omitempty"`
 	Items           []CreateSaleItemRequest `json:"items,omitempty"`
 	PaymentMethod   string                  `json:"payment_method"`
-	CashReceived    *float64                `json:"cash_received,omitempty"`
-	Notes           *string                 `json:"notes,omitempty"`
+	// Payments reparte el cobro entre métodos y debe sumar el total; si viene
+	// vacío se cobra todo con PaymentMethod.
+	Payments []SalePayment `json:"payments,omitempty"`
+	// Discount se aplica al ticket después de promociones y descuentos por
+	// renglón.
+	Discount *DiscountRequest `json:"discount,omitempty"`
+	// CashReceived es el efectivo entregado para la parte en efectivo.
+	CashReceived *float64 `json:"cash_received,omitempty"`
+	Notes        *string  `json:"notes,omitempty"`
 	// SoldAt permite capturar la venta en un día distinto al de hoy.
 	SoldAt *time.Time `json:"sold_at,omitempty"`
 }
 
+type CreateSaleReturnRequest struct {
+	Items []CreateSaleReturnItemRequest `json:"items"`
+	// RefundMethod toma el método de pago de la venta si viene vacío.
+	RefundMethod string `json:"refund_method,omitempty"`
+	// RefundAmount reemplaza el importe calculado de los renglones, por
+	// ejemplo para reembolsar sin devolver mercancía.
+	RefundAmount *float64 `json:"refund_amount,omitempty"`
+	Reason       string   `json:"reason,omitempty"`
+}
+
+type CreateSaleReturnItemRequest struct {
+	SaleItemID string `json:"sale_item_id"`
+	Quantity   int    `json:"quantity"`
+	// Restock en false deja la pieza fuera del inventario (p. ej. dañada).
+	Restock *bool `json:"restock,omitempty"`
+}
+
 type DailyCut struct {
 	ID             uuid.UUID `json:"id"`
 	BazarID        uuid.UUID `json:"bazar_id"`
@@ -271,6 +615,7 @@ type DailyCut struct {
 	BusinessDate   string    `json:"business_date"`
 	OpeningCash    float64   `json:"opening_cash"`
 	CashSales      float64   `json:"cash_sales"`
+	CashRefunds    float64   `json:"cash_refunds"`
 	ExpectedCash   float64   `json:"expected_cash"`
 	ClosingCash    float64   `json:"closing_cash"`
 	CashDifference float64   `json:"cash_difference"`
@@ -291,16 +636,61 @@ type CreateSaleResult struct {
 	Duplicated bool  `json:"duplicated"`
 }
 
+const (
+	StockPolicyReject        = "reject"
+	StockPolicyAllowOversell = "allow_oversell"
+)
+
+// SaleBatchRequest es la cola de ventas capturadas sin conexión, en el orden
+// en que se hicieron. StockPolicy decide qué pasa si una venta deja el stock
+// en negativo: reject (por defecto) la rechaza y allow_oversell la acepta
+// dejando el stock en cero.
+type SaleBatchRequest struct {
+	Sales       []CreateSaleRequest `json:"sales"`
+	StockPolicy string              `json:"stock_policy,omitempty"`
+}
+
+const (
+	SaleBatchCreated    = "created"
+	SaleBatchDuplicated = "duplicated"
+	SaleBatchRejected   = "rejected"
+	SaleBatchFailed     = "failed"
+)
+
+// SaleBatchItemResult es el resultado de una venta del lote. Las rechazadas
+// no se deben reintentar; las fallidas sí.
+type SaleBatchItemResult struct {
+	Index           int    `json:"index"`
+	ClientRequestID string `json:"client_request_id"`
+	Status          string `json:"status"`
+	Sale            *Sale  `json:"sale,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+	Message         string `json:"message,omitempty"`
+	// Oversold son las piezas que se vendieron sin haber stock registrado.
+	Oversold int `json:"oversold,omitempty"`
+}
+
+type SaleBatchResult struct {
+	Results    []SaleBatchItemResult `json:"results"`
+	Created    int                   `json:"created"`
+	Duplicated int                   `json:"duplicated"`
+	Rejected   int                   `json:"rejected"`
+	Failed     int                   `json:"failed"`
+}
+
 type SyncResult struct {
 	ProductsImported  int `json:"products_imported"`
 	SalesSynced       int `json:"sales_synced"`
 	SalesFailed       int `json:"sales_failed"`
+	SalesPending      int `json:"sales_pending"`
 	ConflictsResolved int `json:"conflicts_resolved"`
 }
 
 type serviceError struct {
 	Status  int
 	Message string
+	// Code identifica el motivo para quien procesa lotes sin leer el mensaje.
+	Code string
 }
 
 func (e *serviceError) Error() string {
@@ -314,14 +704,46 @@ type createSaleCommand struct {
 	SellerName      string
 	Items           []createSaleItemCommand
 	PaymentMethod   string
-	CashReceived    *float64
-	Notes           *string
-	SoldAt          *time.Time
+	Payments        []SalePayment
+	Discount        *manualDiscount
+	// MaxDiscountPercent limita los descuentos manuales según el rol.
+	MaxDiscountPercent float64
+	CashReceived       *float64
+	Notes              *string
+	SoldAt             *time.Time
+	// BusinessDate es el día de una venta con fecha anterior; se rechaza si
+	// ese día ya tiene corte, salvo que sea un reenvío de una venta guardada.
+	BusinessDate *time.Time
+	// AllowOversell acepta la venta aunque no alcance el stock registrado.
+	AllowOversell bool
 }
 
 type createSaleItemCommand struct {
 	ProductID uuid.UUID
 	Quantity  int
+	Discount  *manualDiscount
+}
+
+type manualDiscount struct {
+	Type   string
+	Value  float64
+	Reason string
+}
+
+type createSaleReturnCommand struct {
+	SaleID       uuid.UUID
+	UserID       uuid.UUID
+	ActorName    string
+	Items        []createSaleReturnItemCommand
+	RefundMethod string
+	RefundAmount *float64
+	Reason       *string
+}
+
+type createSaleReturnItemCommand struct {
+	SaleItemID uuid.UUID
+	Quantity   int
+	Restock    bool
 }
 
 type createProductCommand struct {
@@ -354,6 +776,19 @@ type updateProductCommand struct {
 	VariantColor   *string
 }
 
+type stockTransferCommand struct {
+	BazarID   uuid.UUID
+	Direction string
+	Items     []stockTransferItemCommand
+	Reason    *string
+	ActorID   uuid.UUID
+}
+
+type stockTransferItemCommand struct {
+	ProductID uuid.UUID
+	Quantity  int
+}
+
 type adjustStockCommand struct {
 	BazarID      *uuid.UUID
 	ProductID    uuid.UUID
@@ -364,6 +799,27 @@ type adjustStockCommand struct {
 	ActorName    string
 }
 
+type syncTargetRecord struct {
+	Kind                   string
+	WebhookURL             string
+	WebhookSecretEncrypted string
+	WebhookInventoryURL    string
+	InventoryCSV           string
+	InventoryUploadedAt    *time.Time
+	UpdatedAt              time.Time
+}
+
+type sheetsSettingsRecord struct {
+	SpreadsheetID       string
+	ServiceEmail        string
+	PrivateKeyEncrypted string
+	InventoryName       string
+	SalesName           string
+	LastTestedAt        *time.Time
+	LastTestError       *string
+	UpdatedAt           time.Time
+}
+
 type sheetProduct struct {
 	ExternalID string
 	Name       string
@@ -6,24 +6,27 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/dofer/panel-api/internal/platform/branding"
 	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 type Handler struct {
-	repo    *Repository
-	service *Service
-	sheets  SheetsGateway
+	repo     *Repository
+	service  *Service
+	targets  *SyncTargets
+	branding branding.Reader
 }
 
-func NewHandler(repo *Repository, service *Service, sheets SheetsGateway) *Handler {
-	return &Handler{repo: repo, service: service, sheets: sheets}
+func NewHandler(repo *Repository, service *Service, targets *SyncTargets, brandingReader branding.Reader) *Handler {
+	return &Handler{repo: repo, service: service, targets: targets, branding: brandingReader}
 }
 
 func RegisterRoutes(r chi.Router, handler *Handler) {
@@ -33,34 +36,202 @@ func RegisterRoutes(r chi.Router, handler *Handler) {
 		r.Get("/bazaars", handler.ListBazaars)
 		r.Get("/bazaars/{id}/report", handler.GetBazarReport)
 		r.Get("/bazaars/{id}/daily-cuts", handler.ListDailyCuts)
+		r.Get("/bazaars/{id}/promotions", handler.ListPromotions)
+		r.Get("/bazaars/{id}/expenses", handler.ListBazarExpenses)
 		r.Get("/products", handler.ListProducts)
 		r.Get("/products/{id}", handler.GetProduct)
 		r.Get("/products/{id}/image", handler.GetProductImage)
 		r.Get("/sales", handler.ListSales)
+		r.Get("/sales/{id}/receipt", handler.GetSaleReceipt)
 		r.Get("/stats", handler.GetStats)
 		r.Get("/activity", handler.GetActivity)
 		r.Get("/reports/daily", handler.GetDailyReport)
 		r.Get("/inventory-movements", handler.ListInventoryMovements)
+		r.Get("/stock-locations", handler.ListStockLocations)
 		r.Get("/audit", handler.ListAudit)
 		r.Get("/sync/status", handler.GetSyncStatus)
 		r.Get("/sync/conflicts", handler.GetSyncConflicts)
+		r.Get("/sync/export/{dataset}", handler.ExportSyncData)
 
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireRole("admin", "operator"))
 			r.Post("/bazaars", handler.CreateBazar)
 			r.Post("/bazaars/{id}/daily-cuts", handler.CloseDailyCut)
 			r.Post("/bazaars/{id}/close", handler.CloseBazar)
+			r.Post("/bazaars/{id}/promotions", handler.CreatePromotion)
+			r.Post("/bazaars/{id}/stock-transfers", handler.TransferStock)
+			r.Post("/bazaars/{id}/expenses", handler.CreateBazarExpense)
+			r.Delete("/expenses/{id}", handler.DeleteBazarExpense)
+			r.Put("/promotions/{id}", handler.UpdatePromotion)
 			r.Post("/products", handler.CreateProduct)
 			r.Put("/products/{id}", handler.UpdateProduct)
 			r.Post("/products/{id}/adjust-stock", handler.AdjustStock)
 			r.Post("/sales", handler.CreateSale)
+			r.Post("/sales/batch", handler.CreateSaleBatch)
 			r.Post("/sales/{id}/cancel", handler.CancelSale)
 			r.Post("/sales/{id}/undo", handler.CancelSale)
+			r.Post("/sales/{id}/returns", handler.CreateSaleReturn)
 			r.Post("/sync", handler.Sync)
+			r.Post("/sync/requeue", handler.RequeueDeadSales)
+			r.Put("/sync/file/inventory", handler.UploadTargetInventory)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireRole("admin"))
+			r.Post("/bazaars/{id}/finance", handler.PostBazarToFinance)
+			r.Get("/integrations/sync-target", handler.GetSyncTarget)
+			r.Put("/integrations/sync-target", handler.UpdateSyncTarget)
+			r.Get("/integrations/google-sheets", handler.GetSheetsSettings)
+			r.Put("/integrations/google-sheets", handler.UpdateSheetsSettings)
+			r.Delete("/integrations/google-sheets", handler.DeleteSheetsSettings)
+			r.Post("/integrations/google-sheets/test", handler.TestSheetsSettings)
 		})
 	})
 }
 
+func (h *Handler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	bazarID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "ID de bazar inválido."})
+		return
+	}
+	promotions, err := h.service.ListPromotions(r.Context(), organizationID(r), bazarID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"promotions": promotions})
+}
+
+func (h *Handler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	bazarID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "ID de bazar inválido."})
+		return
+	}
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req PromotionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	promotion, err := h.service.CreatePromotion(r.Context(), organizationID(r), bazarID, userID, requestActorName(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"promotion": promotion})
+}
+
+func (h *Handler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	promotionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "ID de promoción inválido."})
+		return
+	}
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req PromotionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	promotion, err := h.service.UpdatePromotion(r.Context(), organizationID(r), promotionID, userID, requestActorName(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"promotion": promotion})
+}
+
+func (h *Handler) ListBazarExpenses(w http.ResponseWriter, r *http.Request) {
+	bazarID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "ID de bazar inválido."})
+		return
+	}
+	expenses, err := h.service.ListBazarExpenses(r.Context(), organizationID(r), bazarID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"expenses": expenses})
+}
+
+func (h *Handler) CreateBazarExpense(w http.ResponseWriter, r *http.Request) {
+	bazarID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "ID de bazar inválido."})
+		return
+	}
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req BazarExpenseRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	expense, err := h.service.CreateBazarExpense(r.Context(), organizationID(r), bazarID, userID, requestActorName(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"expense": expense})
+}
+
+func (h *Handler) DeleteBazarExpense(w http.ResponseWriter, r *http.Request) {
+	expenseID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "ID de gasto inválido."})
+		return
+	}
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	expense, err := h.service.DeleteBazarExpense(r.Context(), organizationID(r), expenseID, userID, requestActorName(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"expense": expense})
+}
+
+func (h *Handler) PostBazarToFinance(w http.ResponseWriter, r *http.Request) {
+	bazarID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "ID de bazar inválido."})
+		return
+	}
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req FinancePostRequest
+	if err := decodeOptionalJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	result, err := h.service.PostBazarToFinance(r.Context(), organizationID(r), bazarID, userID, requestActorName(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"finance": result})
+}
+
 func (h *Handler) ListDailyCuts(w http.ResponseWriter, r *http.Request) {
 	bazarID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
@@ -320,6 +491,44 @@ func (h *Handler) AdjustStock(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, product)
 }
 
+func (h *Handler) ListStockLocations(w http.ResponseWriter, r *http.Request) {
+	bazarID, err := optionalUUIDQuery(r, "bazar_id")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	items, err := h.service.ListStockLocations(r.Context(), organizationID(r), bazarID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"products": items})
+}
+
+func (h *Handler) TransferStock(w http.ResponseWriter, r *http.Request) {
+	bazarID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "ID de bazar inválido."})
+		return
+	}
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req StockTransferRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	items, err := h.service.TransferStock(r.Context(), organizationID(r), bazarID, userID, requestActorName(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"products": items})
+}
+
 func (h *Handler) CreateSale(w http.ResponseWriter, r *http.Request) {
 	userID, err := requestUserID(r)
 	if err != nil {
@@ -337,7 +546,8 @@ func (h *Handler) CreateSale(w http.ResponseWriter, r *http.Request) {
 	if strings.TrimSpace(sellerName) == "" {
 		sellerName, _ = middleware.UserEmailFromContext(r.Context())
 	}
-	result, err := h.service.CreateSale(r.Context(), organizationID(r), userID, sellerName, req)
+	role, _ := middleware.UserRoleFromContext(r.Context())
+	result, err := h.service.CreateSale(r.Context(), organizationID(r), userID, sellerName, role == "admin", req)
 	if err != nil {
 		writeError(w, err)
 		return
@@ -350,6 +560,34 @@ func (h *Handler) CreateSale(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, status, result)
 }
 
+// CreateSaleBatch recibe la cola de ventas sin conexión del punto de venta.
+// Responde 200 con el resultado de cada venta aunque algunas se rechacen.
+func (h *Handler) CreateSaleBatch(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req SaleBatchRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sellerName, _ := middleware.UserNameFromContext(r.Context())
+	if strings.TrimSpace(sellerName) == "" {
+		sellerName, _ = middleware.UserEmailFromContext(r.Context())
+	}
+	role, _ := middleware.UserRoleFromContext(r.Context())
+	result, err := h.service.CreateSaleBatch(r.Context(), organizationID(r), userID, sellerName, role == "admin", req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
 func (h *Handler) ListSales(w http.ResponseWriter, r *http.Request) {
 	bazarID, err := queryBazarID(r)
 	if err != nil {
@@ -402,6 +640,31 @@ func queryBazarID(r *http.Request) (*uuid.UUID, error) {
 	return &parsed, nil
 }
 
+// GetSaleReceipt devuelve el ticket de una venta en ESC/POS, PDF o HTML
+// (?format=escpos|pdf|html, ?width=58|80).
+func (h *Handler) GetSaleReceipt(w http.ResponseWriter, r *http.Request) {
+	saleID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "ID de venta inválido."})
+		return
+	}
+	brand, err := h.branding.Get(r.Context(), organizationID(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	query := r.URL.Query()
+	receipt, err := h.service.SaleReceipt(r.Context(), organizationID(r), saleID, brand, query.Get("format"), query.Get("width"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", receipt.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", receipt.Filename))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(receipt.Content)
+}
+
 func (h *Handler) CancelSale(w http.ResponseWriter, r *http.Request) {
 	saleID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
@@ -429,6 +692,37 @@ func (h *Handler) CancelSale(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"sale": sale})
 }
 
+func (h *Handler) CreateSaleReturn(w http.ResponseWriter, r *http.Request) {
+	saleID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "ID de venta inválido."})
+		return
+	}
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req CreateSaleReturnRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	sale, saleReturn, err := h.service.CreateSaleReturn(
+		r.Context(),
+		organizationID(r),
+		saleID,
+		userID,
+		requestActorName(r),
+		req,
+	)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"sale": sale, "return": saleReturn})
+}
+
 func (h *Handler) CloseBazar(w http.ResponseWriter, r *http.Request) {
 	bazarID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
@@ -567,6 +861,33 @@ func (h *Handler) Sync(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
+func (h *Handler) RequeueDeadSales(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	requeued, err := h.service.RequeueDeadSales(r.Context(), organizationID(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if requeued > 0 {
+		_ = h.repo.RecordAudit(
+			r.Context(),
+			organizationID(r),
+			nil,
+			userID,
+			requestActorName(r),
+			"sync.requeued",
+			"sale",
+			nil,
+			map[string]any{"sales": requeued},
+		)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"requeued": requeued})
+}
+
 func (h *Handler) GetSyncConflicts(w http.ResponseWriter, r *http.Request) {
 	conflicts, err := h.service.SyncConflicts(r.Context(), organizationID(r))
 	if err != nil {
@@ -577,15 +898,215 @@ func (h *Handler) GetSyncConflicts(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetSyncStatus(w http.ResponseWriter, r *http.Request) {
-	configured, message := h.sheets.Configured()
+	target, err := h.targets.ForOrganization(r.Context(), organizationID(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	configured, message := target.Configured()
 	status, err := h.repo.GetSyncStatus(r.Context(), organizationID(r), configured, message)
 	if err != nil {
 		writeError(w, err)
 		return
 	}
+	status.Target = target.Kind()
 	writeJSON(w, http.StatusOK, status)
 }
 
+func (h *Handler) ExportSyncData(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	export, err := h.service.ExportSyncData(
+		r.Context(),
+		organizationID(r),
+		chi.URLParam(r, "dataset"),
+		query.Get("format"),
+		query.Get("from"),
+		query.Get("to"),
+	)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", export.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(export.Content)
+}
+
+// UploadTargetInventory recibe el CSV de inventario del destino de archivo
+// (multipart, campo "file").
+func (h *Handler) UploadTargetInventory(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "Sube el inventario como archivo CSV en el campo file."})
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, &serviceError{Status: http.StatusBadRequest, Message: "Sube el inventario como archivo CSV en el campo file."})
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, 10<<20))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	products, err := h.targets.UploadInventory(r.Context(), organizationID(r), userID, content)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = h.repo.RecordAudit(
+		r.Context(),
+		organizationID(r),
+		nil,
+		userID,
+		requestActorName(r),
+		"sync_target.inventory_uploaded",
+		"integration",
+		nil,
+		map[string]any{"products": products},
+	)
+	writeJSON(w, http.StatusOK, map[string]any{"products": products})
+}
+
+func (h *Handler) GetSyncTarget(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.targets.Settings(r.Context(), organizationID(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) UpdateSyncTarget(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req UpdateSyncTargetRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	settings, err := h.targets.Configure(r.Context(), organizationID(r), userID, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = h.repo.RecordAudit(
+		r.Context(),
+		organizationID(r),
+		nil,
+		userID,
+		requestActorName(r),
+		"sync_target.updated",
+		"integration",
+		nil,
+		map[string]any{"kind": settings.Kind, "webhook_url": settings.WebhookURL},
+	)
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) GetSheetsSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.targets.sheets.Settings(r.Context(), organizationID(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) UpdateSheetsSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req UpdateSheetsSettingsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	settings, err := h.targets.sheets.Configure(r.Context(), organizationID(r), userID, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = h.repo.RecordAudit(
+		r.Context(),
+		organizationID(r),
+		nil,
+		userID,
+		requestActorName(r),
+		"sheets.configured",
+		"integration",
+		nil,
+		map[string]any{
+			"spreadsheet_id": settings.SpreadsheetID,
+			"service_email":  settings.ServiceEmail,
+			"key_replaced":   req.PrivateKey != nil || strings.TrimSpace(req.CredentialsJSON) != "",
+		},
+	)
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) TestSheetsSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	result, err := h.targets.sheets.Test(r.Context(), organizationID(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = h.repo.RecordAudit(
+		r.Context(),
+		organizationID(r),
+		nil,
+		userID,
+		requestActorName(r),
+		"sheets.tested",
+		"integration",
+		nil,
+		map[string]any{"ok": result.OK, "message": result.Message},
+	)
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) DeleteSheetsSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := h.targets.sheets.Disconnect(r.Context(), organizationID(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+	_ = h.repo.RecordAudit(
+		r.Context(),
+		organizationID(r),
+		nil,
+		userID,
+		requestActorName(r),
+		"sheets.disconnected",
+		"integration",
+		nil,
+		map[string]any{},
+	)
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func organizationID(r *http.Request) string {
 	value, _ := middleware.OrganizationIDFromContext(r.Context())
 	return value
@@ -0,0 +1,25 @@
+package bazar
+
+import (
+	"github.com/dofer/panel-api/internal/platform/secrets"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Module agrupa las piezas del bazar que comparten la API y el worker de
+// sincronización. Ambos deben usar el mismo Service para que su candado de
+// sincronización los cubra a los dos.
+type Module struct {
+	Repo    *Repository
+	Targets *SyncTargets
+	Service *Service
+}
+
+func NewModule(db *pgxpool.Pool, box *secrets.Box, sheets SheetsConfig, timezone string) *Module {
+	repo := NewRepository(db)
+	targets := NewSyncTargets(repo, box, NewSheetsDirectory(repo, box, sheets))
+	return &Module{
+		Repo:    repo,
+		Targets: targets,
+		Service: NewService(repo, targets, timezone),
+	}
+}
@@ -0,0 +1,270 @@
+package bazar
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maxOperatorDiscountPercent es lo más que un operador puede descontar a mano
+// sobre un renglón o un ticket; los administradores no tienen límite.
+const maxOperatorDiscountPercent = 10
+
+const (
+	DiscountPercentage = "percentage"
+	DiscountFixed      = "fixed"
+)
+
+// pricingLine es un renglón de la venta mientras se calculan sus descuentos.
+type pricingLine struct {
+	ProductID      uuid.UUID
+	VariantGroupID *uuid.UUID
+	Category       string
+	Quantity       int
+	UnitPrice      float64
+	Manual         *manualDiscount
+
+	PromotionID       *uuid.UUID
+	PromotionDiscount float64
+	Discount          float64
+	Reasons           []string
+}
+
+func (l *pricingLine) gross() float64 {
+	return roundMoney(l.UnitPrice * float64(l.Quantity))
+}
+
+func (l *pricingLine) net() float64 {
+	return roundMoney(l.gross() - l.Discount)
+}
+
+func (l *pricingLine) reason() *string {
+	if len(l.Reasons) == 0 {
+		return nil
+	}
+	reason := strings.Join(l.Reasons, "; ")
+	return &reason
+}
+
+type pricedSale struct {
+	Subtotal       float64
+	DiscountTotal  float64
+	TicketDiscount float64
+	Total          float64
+}
+
+// priceSale aplica, en orden, las promociones activas, los descuentos manuales
+// por renglón y el descuento al ticket. Cada renglón recibe a lo más una
+// promoción: la primera que le aplique en el orden en que se crearon. El tope
+// de maxDiscountPercent vale para cada descuento manual y también para la
+// suma de todos ellos sobre el importe después de promociones.
+func priceSale(
+	lines []pricingLine,
+	promotions []Promotion,
+	ticket *manualDiscount,
+	maxDiscountPercent float64,
+) (pricedSale, error) {
+	for _, promotion := range promotions {
+		applyPromotion(lines, promotion)
+	}
+
+	manualBase := 0.0
+	for index := range lines {
+		manualBase += lines[index].net()
+	}
+	manualBase = roundMoney(manualBase)
+
+	manualTotal := 0.0
+	for index := range lines {
+		line := &lines[index]
+		if line.Manual == nil {
+			continue
+		}
+		amount, err := manualDiscountAmount(line.Manual, line.net(), maxDiscountPercent)
+		if err != nil {
+			return pricedSale{}, err
+		}
+		line.Discount = roundMoney(line.Discount + amount)
+		line.Reasons = append(line.Reasons, line.Manual.Reason)
+		manualTotal += amount
+	}
+
+	result := pricedSale{}
+	if ticket != nil {
+		base := 0.0
+		for index := range lines {
+			base += lines[index].net()
+		}
+		amount, err := manualDiscountAmount(ticket, roundMoney(base), maxDiscountPercent)
+		if err != nil {
+			return pricedSale{}, err
+		}
+		result.TicketDiscount = distributeTicketDiscount(lines, amount, roundMoney(base), ticket.Reason)
+		manualTotal += result.TicketDiscount
+	}
+	if manualBase > 0 && roundMoney(manualTotal)/manualBase*100 > maxDiscountPercent+0.005 {
+		return pricedSale{}, &serviceError{
+			Status:  http.StatusForbidden,
+			Message: fmt.Sprintf("Tu rol solo puede descontar hasta %.0f%% en total.", maxDiscountPercent),
+		}
+	}
+
+	for index := range lines {
+		result.Subtotal += lines[index].gross()
+		result.DiscountTotal += lines[index].Discount
+	}
+	result.Subtotal = roundMoney(result.Subtotal)
+	result.DiscountTotal = roundMoney(result.DiscountTotal)
+	result.Total = roundMoney(result.Subtotal - result.DiscountTotal)
+	return result, nil
+}
+
+func manualDiscountAmount(discount *manualDiscount, base, maxDiscountPercent float64) (float64, error) {
+	amount := discount.Value
+	if discount.Type == DiscountPercentage {
+		amount = base * discount.Value / 100
+	}
+	amount = roundMoney(amount)
+	if amount > base {
+		return 0, &serviceError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("El descuento no puede ser mayor a %.2f.", base),
+		}
+	}
+	if base > 0 && amount/base*100 > maxDiscountPercent+0.005 {
+		return 0, &serviceError{
+			Status:  http.StatusForbidden,
+			Message: fmt.Sprintf("Tu rol solo puede descontar hasta %.0f%%.", maxDiscountPercent),
+		}
+	}
+	return amount, nil
+}
+
+// distributeTicketDiscount reparte el descuento al ticket en proporción al
+// importe de cada renglón; el último absorbe el redondeo. Devuelve lo que de
+// verdad se repartió, que puede ser menos si un renglón no alcanza su parte.
+func distributeTicketDiscount(lines []pricingLine, amount, base float64, reason string) float64 {
+	if amount <= 0 || base <= 0 {
+		return 0
+	}
+	last := -1
+	for index := range lines {
+		if lines[index].net() > 0 {
+			last = index
+		}
+	}
+	assigned := 0.0
+	for index := range lines {
+		line := &lines[index]
+		net := line.net()
+		if net <= 0 {
+			continue
+		}
+		share := roundMoney(amount * net / base)
+		if index == last {
+			share = roundMoney(amount - assigned)
+		}
+		if share > net {
+			share = net
+		}
+		assigned = roundMoney(assigned + share)
+		line.Discount = roundMoney(line.Discount + share)
+		line.Reasons = append(line.Reasons, reason)
+	}
+	return assigned
+}
+
+func promotionApplies(promotion Promotion, line *pricingLine) bool {
+	switch {
+	case promotion.ProductID != nil:
+		return *promotion.ProductID == line.ProductID
+	case promotion.VariantGroupID != nil:
+		return line.VariantGroupID != nil && *line.VariantGroupID == *promotion.VariantGroupID
+	case promotion.Category != nil:
+		return strings.EqualFold(strings.TrimSpace(*promotion.Category), strings.TrimSpace(line.Category))
+	default:
+		return false
+	}
+}
+
+// applyPromotion junta las piezas de los renglones elegibles, así un 2x1 por
+// grupo de variantes combina colores distintos.
+func applyPromotion(lines []pricingLine, promotion Promotion) {
+	type unit struct {
+		line  int
+		price float64
+	}
+	units := make([]unit, 0)
+	eligible := make([]int, 0)
+	for index := range lines {
+		if lines[index].PromotionID != nil || !promotionApplies(promotion, &lines[index]) {
+			continue
+		}
+		eligible = append(eligible, index)
+		for count := 0; count < lines[index].Quantity; count++ {
+			units = append(units, unit{line: index, price: lines[index].UnitPrice})
+		}
+	}
+	if len(eligible) == 0 {
+		return
+	}
+
+	discounts := make(map[int]float64)
+	switch promotion.Kind {
+	case PromotionBuyXPayY:
+		if promotion.Quantity <= 0 || promotion.PayQuantity >= promotion.Quantity {
+			return
+		}
+		// Las piezas de regalo son las más baratas.
+		sort.SliceStable(units, func(i, j int) bool { return units[i].price < units[j].price })
+		free := len(units) / promotion.Quantity * (promotion.Quantity - promotion.PayQuantity)
+		for _, item := range units[:free] {
+			discounts[item.line] += item.price
+		}
+	case PromotionBundlePrice:
+		if promotion.Quantity <= 0 || promotion.BundlePrice == nil {
+			return
+		}
+		sort.SliceStable(units, func(i, j int) bool { return units[i].price > units[j].price })
+		for start := 0; start+promotion.Quantity <= len(units); start += promotion.Quantity {
+			set := units[start : start+promotion.Quantity]
+			setTotal := 0.0
+			for _, item := range set {
+				setTotal += item.price
+			}
+			if setTotal <= *promotion.BundlePrice {
+				continue
+			}
+			saving := setTotal - *promotion.BundlePrice
+			for _, item := range set {
+				discounts[item.line] += saving * item.price / setTotal
+			}
+		}
+	case PromotionCategoryDiscount:
+		if promotion.DiscountPercent == nil {
+			return
+		}
+		for _, index := range eligible {
+			discounts[index] = lines[index].gross() * *promotion.DiscountPercent / 100
+		}
+	}
+
+	for index, discount := range discounts {
+		discount = roundMoney(discount)
+		if discount <= 0 {
+			continue
+		}
+		line := &lines[index]
+		if discount > line.net() {
+			discount = line.net()
+		}
+		promotionID := promotion.ID
+		line.PromotionID = &promotionID
+		line.PromotionDiscount = discount
+		line.Discount = roundMoney(line.Discount + discount)
+		line.Reasons = append(line.Reasons, promotion.Name)
+	}
+}
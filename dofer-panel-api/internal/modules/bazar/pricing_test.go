@@ -0,0 +1,141 @@
+package bazar
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPriceSaleBuyTwoPayOneAcrossVariants(t *testing.T) {
+	group := uuid.New()
+	lines := []pricingLine{
+		{ProductID: uuid.New(), VariantGroupID: &group, Quantity: 2, UnitPrice: 50},
+		{ProductID: uuid.New(), VariantGroupID: &group, Quantity: 1, UnitPrice: 40},
+		{ProductID: uuid.New(), Quantity: 1, UnitPrice: 30},
+	}
+	promotion := Promotion{ID: uuid.New(), Name: "2x1 Capibaras", Kind: PromotionBuyXPayY, VariantGroupID: &group, Quantity: 2, PayQuantity: 1}
+
+	priced, err := priceSale(lines, []Promotion{promotion}, nil, maxOperatorDiscountPercent)
+	if err != nil {
+		t.Fatalf("priceSale() error = %v", err)
+	}
+	// Tres piezas del grupo forman un solo 2x1 y sale gratis la más barata.
+	if priced.Subtotal != 170 || priced.DiscountTotal != 40 || priced.Total != 130 {
+		t.Fatalf("unexpected totals %+v", priced)
+	}
+	if lines[1].PromotionID == nil || lines[1].PromotionDiscount != 40 || lines[0].PromotionID != nil || lines[2].Discount != 0 {
+		t.Fatalf("unexpected lines %+v", lines)
+	}
+}
+
+func TestPriceSaleBundleAndManualDiscounts(t *testing.T) {
+	category := "Llaveros"
+	lines := []pricingLine{
+		{ProductID: uuid.New(), Category: "llaveros", Quantity: 3, UnitPrice: 25},
+		{ProductID: uuid.New(), Category: "Figuras", Quantity: 1, UnitPrice: 100,
+			Manual: &manualDiscount{Type: DiscountPercentage, Value: 10, Reason: "Caja dañada"}},
+	}
+	bundlePrice := 60.0
+	promotions := []Promotion{{ID: uuid.New(), Name: "3 llaveros por 60", Kind: PromotionBundlePrice, Category: &category, Quantity: 3, BundlePrice: &bundlePrice}}
+	ticket := &manualDiscount{Type: DiscountFixed, Value: 15, Reason: "Cliente frecuente"}
+
+	priced, err := priceSale(lines, promotions, ticket, 100)
+	if err != nil {
+		t.Fatalf("priceSale() error = %v", err)
+	}
+	// 75 - 15 de paquete = 60; 100 - 10 = 90; el ticket reparte 15 entre 150.
+	if priced.Subtotal != 175 || priced.TicketDiscount != 15 || priced.Total != 135 || priced.DiscountTotal != 40 {
+		t.Fatalf("unexpected totals %+v", priced)
+	}
+	if lines[0].net() != 54 || lines[1].net() != 81 {
+		t.Fatalf("unexpected nets %.2f %.2f", lines[0].net(), lines[1].net())
+	}
+	if reason := lines[1].reason(); reason == nil || *reason != "Caja dañada; Cliente frecuente" {
+		t.Fatalf("unexpected reason %v", reason)
+	}
+}
+
+func TestPriceSaleLimitsOperatorDiscounts(t *testing.T) {
+	lines := []pricingLine{{ProductID: uuid.New(), Quantity: 1, UnitPrice: 100}}
+	_, err := priceSale(lines, nil, &manualDiscount{Type: DiscountFixed, Value: 20, Reason: "Regateo"}, maxOperatorDiscountPercent)
+	var serviceErr *serviceError
+	if !errors.As(err, &serviceErr) || serviceErr.Status != http.StatusForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+
+	lines = []pricingLine{{ProductID: uuid.New(), Quantity: 1, UnitPrice: 100}}
+	_, err = priceSale(lines, nil, &manualDiscount{Type: DiscountFixed, Value: 120, Reason: "Error"}, 100)
+	if !errors.As(err, &serviceErr) || serviceErr.Status != http.StatusBadRequest {
+		t.Fatalf("expected bad request for discount above the amount, got %v", err)
+	}
+}
+
+func TestPriceSaleCapsCombinedManualDiscounts(t *testing.T) {
+	// 10% al renglón y 10% al ticket pasan cada uno, pero juntos son 19%.
+	lines := []pricingLine{{ProductID: uuid.New(), Quantity: 1, UnitPrice: 100,
+		Manual: &manualDiscount{Type: DiscountPercentage, Value: 10, Reason: "Caja dañada"}}}
+	ticket := &manualDiscount{Type: DiscountPercentage, Value: 10, Reason: "Regateo"}
+	_, err := priceSale(lines, nil, ticket, maxOperatorDiscountPercent)
+	var serviceErr *serviceError
+	if !errors.As(err, &serviceErr) || serviceErr.Status != http.StatusForbidden {
+		t.Fatalf("expected forbidden for combined discounts, got %v", err)
+	}
+
+	// Las promociones no cuentan contra el tope de los descuentos manuales.
+	category := "Llaveros"
+	lines = []pricingLine{{ProductID: uuid.New(), Category: category, Quantity: 1, UnitPrice: 100}}
+	percent := 50.0
+	promotions := []Promotion{{ID: uuid.New(), Name: "Mitad", Kind: PromotionCategoryDiscount, Category: &category, DiscountPercent: &percent}}
+	priced, err := priceSale(lines, promotions, &manualDiscount{Type: DiscountFixed, Value: 5, Reason: "Regateo"}, maxOperatorDiscountPercent)
+	if err != nil || priced.Total != 45 {
+		t.Fatalf("expected 5 off the promoted price, got %+v, %v", priced, err)
+	}
+}
+
+func TestPriceSaleReportsDistributedTicketDiscount(t *testing.T) {
+	// El último renglón no alcanza su parte del redondeo: solo se reparten 0.04.
+	lines := []pricingLine{
+		{ProductID: uuid.New(), Quantity: 1, UnitPrice: 0.02},
+		{ProductID: uuid.New(), Quantity: 1, UnitPrice: 0.02},
+		{ProductID: uuid.New(), Quantity: 1, UnitPrice: 0.02},
+		{ProductID: uuid.New(), Quantity: 1, UnitPrice: 0.01},
+	}
+	priced, err := priceSale(lines, nil, &manualDiscount{Type: DiscountFixed, Value: 0.05, Reason: "Redondeo"}, 100)
+	if err != nil {
+		t.Fatalf("priceSale() error = %v", err)
+	}
+	if priced.TicketDiscount != priced.DiscountTotal || priced.TicketDiscount != 0.04 || priced.Total != 0.03 {
+		t.Fatalf("ticket discount must match what the lines received, got %+v", priced)
+	}
+}
+
+func TestNormalizePromotion(t *testing.T) {
+	percent := 15.0
+	promotion, err := normalizePromotion(PromotionRequest{
+		Name:            " Figuras 15% ",
+		Kind:            PromotionCategoryDiscount,
+		Category:        "Figuras",
+		Quantity:        4,
+		DiscountPercent: &percent,
+	})
+	if err != nil {
+		t.Fatalf("normalizePromotion() error = %v", err)
+	}
+	if promotion.Name != "Figuras 15%" || !promotion.Active || promotion.Quantity != 0 || *promotion.DiscountPercent != 15 {
+		t.Fatalf("unexpected promotion %+v", promotion)
+	}
+
+	for name, req := range map[string]PromotionRequest{
+		"no target":    {Name: "2x1", Kind: PromotionBuyXPayY, Quantity: 2, PayQuantity: 1},
+		"two targets":  {Name: "2x1", Kind: PromotionBuyXPayY, Category: "A", ProductID: uuid.NewString(), Quantity: 2, PayQuantity: 1},
+		"pays all":     {Name: "2x2", Kind: PromotionBuyXPayY, Category: "A", Quantity: 2, PayQuantity: 2},
+		"no price":     {Name: "Paquete", Kind: PromotionBundlePrice, Category: "A", Quantity: 3},
+		"unknown kind": {Name: "Otro", Kind: "gift", Category: "A"},
+	} {
+		if _, err := normalizePromotion(req); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
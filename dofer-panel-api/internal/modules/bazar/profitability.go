@@ -0,0 +1,214 @@
+package bazar
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *Service) ListBazarExpenses(ctx context.Context, organizationID string, bazarID uuid.UUID) ([]BazarExpense, error) {
+	bazar, err := s.repo.GetBazar(ctx, organizationID, bazarID)
+	if err != nil {
+		return nil, err
+	}
+	if bazar == nil {
+		return nil, &serviceError{Status: http.StatusNotFound, Message: "Bazar no encontrado."}
+	}
+	return s.repo.ListBazarExpenses(ctx, organizationID, bazarID)
+}
+
+func (s *Service) CreateBazarExpense(
+	ctx context.Context,
+	organizationID string,
+	bazarID, actorID uuid.UUID,
+	actorName string,
+	req BazarExpenseRequest,
+) (*BazarExpense, error) {
+	expense, err := normalizeBazarExpense(req)
+	if err != nil {
+		return nil, err
+	}
+	expense.BazarID = bazarID
+	if strings.TrimSpace(actorName) == "" {
+		actorName = "Sistema"
+	}
+
+	created, err := s.repo.CreateBazarExpense(ctx, organizationID, actorID, actorName, expense)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.repo.RecordAudit(
+		ctx,
+		organizationID,
+		&bazarID,
+		actorID,
+		actorName,
+		"expense.created",
+		"expense",
+		&created.ID,
+		created,
+	)
+	return created, nil
+}
+
+func (s *Service) DeleteBazarExpense(
+	ctx context.Context,
+	organizationID string,
+	expenseID, actorID uuid.UUID,
+	actorName string,
+) (*BazarExpense, error) {
+	deleted, err := s.repo.DeleteBazarExpense(ctx, organizationID, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	if deleted == nil {
+		return nil, &serviceError{Status: http.StatusNotFound, Message: "Gasto no encontrado."}
+	}
+	_ = s.repo.RecordAudit(
+		ctx,
+		organizationID,
+		&deleted.BazarID,
+		actorID,
+		actorName,
+		"expense.deleted",
+		"expense",
+		&deleted.ID,
+		deleted,
+	)
+	return deleted, nil
+}
+
+// PostBazarToFinance pasa el ingreso neto y los gastos del bazar cerrado a los
+// movimientos de finanzas.
+func (s *Service) PostBazarToFinance(
+	ctx context.Context,
+	organizationID string,
+	bazarID, actorID uuid.UUID,
+	actorName string,
+	req FinancePostRequest,
+) (*FinancePostResult, error) {
+	report, err := s.FinalBazarReport(ctx, organizationID, bazarID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.repo.PostBazarToFinance(
+		ctx,
+		organizationID,
+		bazarID,
+		actorID,
+		report.Profitability,
+		req.IncludeCostOfGoods,
+	)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.repo.RecordAudit(
+		ctx,
+		organizationID,
+		&bazarID,
+		actorID,
+		actorName,
+		"bazar.posted_to_finance",
+		"bazar",
+		&bazarID,
+		map[string]any{
+			"income":                result.Income,
+			"expenses":              result.Expenses,
+			"net_profit":            report.Profitability.NetProfit,
+			"include_cost_of_goods": req.IncludeCostOfGoods,
+		},
+	)
+	return result, nil
+}
+
+func normalizeBazarExpense(req BazarExpenseRequest) (BazarExpense, error) {
+	category := strings.ToLower(strings.TrimSpace(req.Category))
+	if category == "" {
+		category = ExpenseOther
+	}
+	if _, ok := financeExpenseCategories[category]; !ok {
+		return BazarExpense{}, &serviceError{
+			Status:  http.StatusBadRequest,
+			Message: "La categoría debe ser stand_fee, transport, staff, supplies, advertising u other.",
+		}
+	}
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		return BazarExpense{}, &serviceError{Status: http.StatusBadRequest, Message: "Describe el gasto."}
+	}
+	if len(description) > 200 {
+		return BazarExpense{}, &serviceError{Status: http.StatusBadRequest, Message: "La descripción no puede superar 200 caracteres."}
+	}
+	if req.Amount <= 0 || req.Amount > 999999999 {
+		return BazarExpense{}, &serviceError{Status: http.StatusBadRequest, Message: "El importe del gasto no es válido."}
+	}
+	spentAt := time.Now()
+	if req.SpentAt != nil {
+		spentAt = *req.SpentAt
+	}
+	return BazarExpense{
+		Category:    category,
+		Description: description,
+		Amount:      roundMoney(req.Amount),
+		SpentAt:     spentAt,
+	}, nil
+}
+
+// buildProfitability calcula márgenes y totales a partir del ingreso neto, la
+// utilidad bruta por producto y los gastos del evento.
+func buildProfitability(revenue float64, products []ProductProfit, expenses []BazarExpense) *BazarProfitability {
+	result := &BazarProfitability{
+		Revenue:      roundMoney(revenue),
+		Products:     products,
+		Categories:   make([]CategoryProfit, 0),
+		ExpenseItems: expenses,
+	}
+	categories := make(map[string]*CategoryProfit)
+	for index := range result.Products {
+		product := &result.Products[index]
+		product.Revenue = roundMoney(product.Revenue)
+		product.Cost = roundMoney(product.Cost)
+		product.GrossMargin = roundMoney(product.Revenue - product.Cost)
+		result.CostOfGoods += product.Cost
+		if product.CostMissing {
+			result.ProductsWithoutCost++
+		}
+
+		category := categories[product.Category]
+		if category == nil {
+			category = &CategoryProfit{Category: product.Category}
+			categories[product.Category] = category
+		}
+		category.Quantity += product.Quantity
+		category.Revenue += product.Revenue
+		category.Cost += product.Cost
+	}
+	for _, category := range categories {
+		category.Revenue = roundMoney(category.Revenue)
+		category.Cost = roundMoney(category.Cost)
+		category.GrossMargin = roundMoney(category.Revenue - category.Cost)
+		result.Categories = append(result.Categories, *category)
+	}
+	sort.Slice(result.Categories, func(i, j int) bool {
+		if result.Categories[i].Revenue != result.Categories[j].Revenue {
+			return result.Categories[i].Revenue > result.Categories[j].Revenue
+		}
+		return result.Categories[i].Category < result.Categories[j].Category
+	})
+
+	for _, expense := range expenses {
+		result.Expenses += expense.Amount
+	}
+	result.CostOfGoods = roundMoney(result.CostOfGoods)
+	result.Expenses = roundMoney(result.Expenses)
+	result.GrossMargin = roundMoney(result.Revenue - result.CostOfGoods)
+	if result.Revenue > 0 {
+		result.GrossMarginPercent = roundMoney(result.GrossMargin / result.Revenue * 100)
+	}
+	result.NetProfit = roundMoney(result.GrossMargin - result.Expenses)
+	return result
+}
@@ -0,0 +1,74 @@
+package bazar
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBuildProfitability(t *testing.T) {
+	products := []ProductProfit{
+		{ProductID: uuid.New(), ProductName: "Capibara café", Category: "Doflins", Quantity: 4, Revenue: 160, Cost: 48},
+		{ProductID: uuid.New(), ProductName: "Capibara rosa", Category: "Doflins", Quantity: 1, Revenue: 40, Cost: 12},
+		{ProductID: uuid.New(), ProductName: "Llavero", Category: "Accesorios", Quantity: 3, Revenue: 45, CostMissing: true},
+	}
+	expenses := []BazarExpense{
+		{Category: ExpenseStandFee, Amount: 80},
+		{Category: ExpenseTransport, Amount: 25.5},
+	}
+	// Un reembolso sin mercancía deja el ingreso neto por debajo de la suma
+	// de los productos.
+	result := buildProfitability(235, products, expenses)
+
+	if result.Revenue != 235 || result.CostOfGoods != 60 || result.GrossMargin != 175 {
+		t.Fatalf("unexpected totals: %+v", result)
+	}
+	if result.Expenses != 105.5 || result.NetProfit != 69.5 || result.GrossMarginPercent != 74.47 {
+		t.Fatalf("unexpected net profit: %+v", result)
+	}
+	if result.ProductsWithoutCost != 1 || result.Products[0].GrossMargin != 112 {
+		t.Fatalf("unexpected products: %+v", result.Products)
+	}
+	if len(result.Categories) != 2 || result.Categories[0].Category != "Doflins" ||
+		result.Categories[0].Quantity != 5 || result.Categories[0].GrossMargin != 140 ||
+		result.Categories[1].Cost != 0 {
+		t.Fatalf("unexpected categories: %+v", result.Categories)
+	}
+
+	empty := buildProfitability(0, []ProductProfit{}, []BazarExpense{{Amount: 50}})
+	if empty.GrossMarginPercent != 0 || empty.NetProfit != -50 {
+		t.Fatalf("unexpected empty bazar profitability: %+v", empty)
+	}
+}
+
+func TestNormalizeBazarExpense(t *testing.T) {
+	expense, err := normalizeBazarExpense(BazarExpenseRequest{
+		Category:    " STAND_FEE ",
+		Description: " Renta del puesto ",
+		Amount:      350.004,
+	})
+	if err != nil {
+		t.Fatalf("normalizeBazarExpense() error = %v", err)
+	}
+	if expense.Category != ExpenseStandFee || expense.Description != "Renta del puesto" ||
+		expense.Amount != 350 || expense.SpentAt.IsZero() {
+		t.Fatalf("unexpected expense %+v", expense)
+	}
+	if expense, _ := normalizeBazarExpense(BazarExpenseRequest{Description: "Hielo", Amount: 20}); expense.Category != ExpenseOther {
+		t.Fatalf("expected other as the default category, got %q", expense.Category)
+	}
+
+	for name, req := range map[string]BazarExpenseRequest{
+		"category":    {Category: "food", Description: "Comida", Amount: 10},
+		"description": {Category: ExpenseTransport, Amount: 10},
+		"amount":      {Category: ExpenseTransport, Description: "Gasolina"},
+	} {
+		_, err := normalizeBazarExpense(req)
+		var serviceErr *serviceError
+		if !errors.As(err, &serviceErr) || serviceErr.Status != http.StatusBadRequest {
+			t.Fatalf("%s: expected bad request, got %v", name, err)
+		}
+	}
+}
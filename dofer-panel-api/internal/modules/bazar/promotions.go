@@ -0,0 +1,212 @@
+package bazar
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+func (s *Service) ListPromotions(ctx context.Context, organizationID string, bazarID uuid.UUID) ([]Promotion, error) {
+	bazar, err := s.repo.GetBazar(ctx, organizationID, bazarID)
+	if err != nil {
+		return nil, err
+	}
+	if bazar == nil {
+		return nil, &serviceError{Status: http.StatusNotFound, Message: "Bazar no encontrado."}
+	}
+	return s.repo.ListPromotions(ctx, organizationID, bazarID)
+}
+
+func (s *Service) CreatePromotion(
+	ctx context.Context,
+	organizationID string,
+	bazarID, actorID uuid.UUID,
+	actorName string,
+	req PromotionRequest,
+) (*Promotion, error) {
+	promotion, err := s.preparePromotion(ctx, organizationID, req)
+	if err != nil {
+		return nil, err
+	}
+	bazar, err := s.repo.GetBazar(ctx, organizationID, bazarID)
+	if err != nil {
+		return nil, err
+	}
+	if bazar == nil {
+		return nil, &serviceError{Status: http.StatusNotFound, Message: "Bazar no encontrado."}
+	}
+	promotion.BazarID = bazarID
+
+	created, err := s.repo.CreatePromotion(ctx, organizationID, actorID, promotion)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.repo.RecordAudit(
+		ctx,
+		organizationID,
+		&bazarID,
+		actorID,
+		actorName,
+		"promotion.created",
+		"promotion",
+		&created.ID,
+		created,
+	)
+	return created, nil
+}
+
+func (s *Service) UpdatePromotion(
+	ctx context.Context,
+	organizationID string,
+	promotionID, actorID uuid.UUID,
+	actorName string,
+	req PromotionRequest,
+) (*Promotion, error) {
+	promotion, err := s.preparePromotion(ctx, organizationID, req)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.UpdatePromotion(ctx, organizationID, promotionID, promotion)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, &serviceError{Status: http.StatusNotFound, Message: "Promoción no encontrada."}
+	}
+	_ = s.repo.RecordAudit(
+		ctx,
+		organizationID,
+		&updated.BazarID,
+		actorID,
+		actorName,
+		"promotion.updated",
+		"promotion",
+		&updated.ID,
+		updated,
+	)
+	return updated, nil
+}
+
+func (s *Service) preparePromotion(ctx context.Context, organizationID string, req PromotionRequest) (Promotion, error) {
+	promotion, err := normalizePromotion(req)
+	if err != nil {
+		return Promotion{}, err
+	}
+	if promotion.ProductID != nil {
+		product, err := s.repo.GetProduct(ctx, organizationID, *promotion.ProductID)
+		if err != nil {
+			return Promotion{}, err
+		}
+		if product == nil {
+			return Promotion{}, &serviceError{Status: http.StatusNotFound, Message: "Producto no encontrado."}
+		}
+	}
+	return promotion, nil
+}
+
+// normalizePromotion valida una promoción y descarta los campos que no usa su
+// tipo. Debe apuntar a exactamente un producto, grupo de variantes o categoría.
+func normalizePromotion(req PromotionRequest) (Promotion, error) {
+	invalid := func(message string) (Promotion, error) {
+		return Promotion{}, &serviceError{Status: http.StatusBadRequest, Message: message}
+	}
+
+	promotion := Promotion{
+		Name:     strings.TrimSpace(req.Name),
+		Kind:     strings.TrimSpace(req.Kind),
+		Active:   req.Active == nil || *req.Active,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+	}
+	if promotion.Name == "" || len(promotion.Name) > 120 {
+		return invalid("El nombre de la promoción es obligatorio (máximo 120 caracteres).")
+	}
+
+	targets := 0
+	if value := strings.TrimSpace(req.ProductID); value != "" {
+		productID, err := uuid.Parse(value)
+		if err != nil {
+			return invalid("El producto de la promoción no es válido.")
+		}
+		promotion.ProductID = &productID
+		targets++
+	}
+	if value := strings.TrimSpace(req.VariantGroupID); value != "" {
+		groupID, err := uuid.Parse(value)
+		if err != nil {
+			return invalid("El grupo de variantes de la promoción no es válido.")
+		}
+		promotion.VariantGroupID = &groupID
+		targets++
+	}
+	if value := strings.TrimSpace(req.Category); value != "" {
+		promotion.Category = &value
+		targets++
+	}
+	if targets != 1 {
+		return invalid("La promoción debe aplicar a un producto, un grupo de variantes o una categoría.")
+	}
+
+	switch promotion.Kind {
+	case PromotionBuyXPayY:
+		if req.Quantity < 2 || req.Quantity > 99 || req.PayQuantity < 0 || req.PayQuantity >= req.Quantity {
+			return invalid("Indica cuántas piezas lleva y cuántas paga (por ejemplo 2 y 1).")
+		}
+		promotion.Quantity = req.Quantity
+		promotion.PayQuantity = req.PayQuantity
+	case PromotionBundlePrice:
+		if req.Quantity < 2 || req.Quantity > 99 {
+			return invalid("El paquete debe ser de al menos 2 piezas.")
+		}
+		if req.BundlePrice == nil || *req.BundlePrice < 0 || *req.BundlePrice > 999999999 {
+			return invalid("Indica el precio del paquete.")
+		}
+		price := roundMoney(*req.BundlePrice)
+		promotion.Quantity = req.Quantity
+		promotion.BundlePrice = &price
+	case PromotionCategoryDiscount:
+		if req.DiscountPercent == nil || *req.DiscountPercent <= 0 || *req.DiscountPercent > 100 {
+			return invalid("El porcentaje de descuento debe estar entre 0 y 100.")
+		}
+		percent := roundMoney(*req.DiscountPercent)
+		promotion.DiscountPercent = &percent
+	default:
+		return invalid("El tipo de promoción no es válido.")
+	}
+
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return invalid("La promoción debe terminar después de empezar.")
+	}
+	return promotion, nil
+}
+
+// normalizeManualDiscount valida un descuento manual sin conocer todavía el
+// importe al que se aplica.
+func normalizeManualDiscount(req *DiscountRequest) (*manualDiscount, error) {
+	if req == nil {
+		return nil, nil
+	}
+	discount := &manualDiscount{
+		Type:   strings.ToLower(strings.TrimSpace(req.Type)),
+		Value:  req.Value,
+		Reason: strings.TrimSpace(req.Reason),
+	}
+	switch discount.Type {
+	case DiscountPercentage:
+		if discount.Value <= 0 || discount.Value > 100 {
+			return nil, &serviceError{Status: http.StatusBadRequest, Message: "El porcentaje de descuento debe estar entre 0 y 100."}
+		}
+	case DiscountFixed:
+		if discount.Value <= 0 || discount.Value > 999999999 {
+			return nil, &serviceError{Status: http.StatusBadRequest, Message: "El descuento debe ser mayor a cero."}
+		}
+	default:
+		return nil, &serviceError{Status: http.StatusBadRequest, Message: "El descuento debe ser percentage o fixed."}
+	}
+	if discount.Reason == "" || len(discount.Reason) > 200 {
+		return nil, &serviceError{Status: http.StatusBadRequest, Message: "Indica el motivo del descuento (máximo 200 caracteres)."}
+	}
+	return discount, nil
+}
@@ -0,0 +1,462 @@
+package bazar
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dofer/panel-api/internal/platform/branding"
+	"github.com/dofer/panel-api/internal/platform/pdf"
+	"github.com/dofer/panel-api/internal/platform/qrcode"
+	"github.com/google/uuid"
+)
+
+const (
+	ReceiptESCPOS = "escpos"
+	ReceiptPDF    = "pdf"
+	ReceiptHTML   = "html"
+)
+
+// receiptColumns es cuántos caracteres caben por renglón con la fuente A de
+// una impresora térmica de 58 y de 80 mm.
+var receiptColumns = map[int]int{58: 32, 80: 48}
+
+// SaleReceipt es el ticket de una venta listo para enviarse.
+type SaleReceipt struct {
+	Content     []byte
+	ContentType string
+	Filename    string
+}
+
+// receiptLine es un renglón del ticket: texto a la izquierda y, opcionalmente,
+// un importe alineado a la derecha.
+type receiptLine struct {
+	Left     string
+	Right    string
+	Bold     bool
+	Centered bool
+	Rule     bool
+}
+
+// receipt es el contenido del ticket, independiente del formato de salida.
+type receipt struct {
+	Lines  []receiptLine
+	QRData string
+	Footer string
+}
+
+// SaleReceipt arma el ticket de una venta en format (escpos, pdf o html) para
+// un rollo de width milímetros (58 u 80).
+func (s *Service) SaleReceipt(
+	ctx context.Context,
+	organizationID string,
+	saleID uuid.UUID,
+	brand *branding.Branding,
+	format, rawWidth string,
+) (*SaleReceipt, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = ReceiptESCPOS
+	}
+	if format != ReceiptESCPOS && format != ReceiptPDF && format != ReceiptHTML {
+		return nil, &serviceError{Status: http.StatusBadRequest, Message: "El formato debe ser escpos, pdf o html."}
+	}
+	width := 80
+	if value := strings.TrimSpace(rawWidth); value != "" {
+		if _, err := fmt.Sscanf(value, "%d", &width); err != nil || receiptColumns[width] == 0 {
+			return nil, &serviceError{Status: http.StatusBadRequest, Message: "El ancho del ticket debe ser 58 u 80."}
+		}
+	}
+
+	sale, err := s.repo.GetSale(ctx, organizationID, saleID)
+	if err != nil {
+		return nil, err
+	}
+	if sale == nil {
+		return nil, &serviceError{Status: http.StatusNotFound, Message: "Venta no encontrada."}
+	}
+
+	content := buildReceipt(sale, brand, s.location)
+	filename := "ticket-" + receiptFolio(sale)
+	switch format {
+	case ReceiptPDF:
+		document, err := renderReceiptPDF(content, width)
+		if err != nil {
+			return nil, err
+		}
+		return &SaleReceipt{Content: document, ContentType: "application/pdf", Filename: filename + ".pdf"}, nil
+	case ReceiptHTML:
+		document, err := renderReceiptHTML(content, width)
+		if err != nil {
+			return nil, err
+		}
+		return &SaleReceipt{Content: document, ContentType: "text/html; charset=utf-8", Filename: filename + ".html"}, nil
+	default:
+		return &SaleReceipt{
+			Content:     renderReceiptESCPOS(content, width),
+			ContentType: "application/octet-stream",
+			Filename:    filename + ".bin",
+		}, nil
+	}
+}
+
+// receiptFolio es el folio impreso del ticket; las ventas anteriores a la
+// numeración de tickets usan su external_id.
+func receiptFolio(sale *Sale) string {
+	if sale.ReceiptNumber != nil && *sale.ReceiptNumber != "" {
+		return *sale.ReceiptNumber
+	}
+	return sale.ExternalID
+}
+
+func buildReceipt(sale *Sale, brand *branding.Branding, location *time.Location) receipt {
+	result := receipt{QRData: sale.ExternalID, Footer: "Gracias por su compra."}
+	add := func(line receiptLine) { result.Lines = append(result.Lines, line) }
+	money := func(amount float64) string { return fmt.Sprintf("%.2f", amount) }
+
+	if brand != nil {
+		add(receiptLine{Left: brand.Name, Bold: true, Centered: true})
+		for _, value := range []*string{brand.Address, brand.ContactPhone} {
+			if value != nil && strings.TrimSpace(*value) != "" {
+				add(receiptLine{Left: strings.TrimSpace(*value), Centered: true})
+			}
+		}
+		result.Footer = brand.Footer(result.Footer)
+	}
+	add(receiptLine{Left: sale.BazarName, Bold: brand == nil, Centered: true})
+	add(receiptLine{Rule: true})
+	add(receiptLine{Left: "Ticket", Right: receiptFolio(sale)})
+	add(receiptLine{Left: "Fecha", Right: sale.SoldAt.In(location).Format("02/01/2006 15:04")})
+	add(receiptLine{Left: "Vendedor", Right: sale.SellerName})
+	if sale.Status == "cancelled" {
+		add(receiptLine{Left: "VENTA CANCELADA", Bold: true, Centered: true})
+	}
+	add(receiptLine{Rule: true})
+
+	for _, item := range sale.Items {
+		add(receiptLine{
+			Left:  fmt.Sprintf("%d x %s", item.Quantity, item.ProductName),
+			Right: money(roundMoney(item.UnitPrice * float64(item.Quantity))),
+		})
+		if item.Discount > 0 {
+			label := "  Descuento"
+			if item.DiscountReason != nil {
+				label += " (" + *item.DiscountReason + ")"
+			}
+			add(receiptLine{Left: label, Right: "-" + money(item.Discount)})
+		}
+		if item.ReturnedQuantity > 0 {
+			add(receiptLine{Left: fmt.Sprintf("  Devueltas: %d", item.ReturnedQuantity)})
+		}
+	}
+	add(receiptLine{Rule: true})
+
+	if sale.DiscountTotal > 0 {
+		add(receiptLine{Left: "Subtotal", Right: money(sale.Subtotal)})
+		add(receiptLine{Left: "Descuentos", Right: "-" + money(sale.DiscountTotal)})
+	}
+	add(receiptLine{Left: "TOTAL", Right: money(sale.Total), Bold: true})
+
+	payments := sale.Payments
+	if len(payments) == 0 {
+		payments = []SalePayment{{Method: sale.PaymentMethod, Amount: sale.Total}}
+	}
+	for _, payment := range payments {
+		add(receiptLine{Left: paymentMethodLabel(payment.Method), Right: money(payment.Amount)})
+	}
+	if sale.CashReceived != nil {
+		add(receiptLine{Left: "Efectivo recibido", Right: money(*sale.CashReceived)})
+	}
+	if sale.ChangeDue != nil {
+		add(receiptLine{Left: "Cambio", Right: money(*sale.ChangeDue)})
+	}
+	if sale.RefundedTotal > 0 {
+		add(receiptLine{Left: "Reembolsado", Right: "-" + money(sale.RefundedTotal)})
+	}
+	return result
+}
+
+// textLines acomoda el ticket a columns caracteres: el texto largo se parte y
+// el importe va en el último renglón, pegado a la derecha.
+func (line receiptLine) textLines(columns int) []string {
+	if line.Rule {
+		return []string{strings.Repeat("-", columns)}
+	}
+	width := columns
+	if line.Right != "" {
+		width = columns - utf8.RuneCountInString(line.Right) - 1
+	}
+	wrapped := wrapText(line.Left, max(width, 1))
+	if line.Right != "" {
+		last := wrapped[len(wrapped)-1]
+		padding := columns - utf8.RuneCountInString(last) - utf8.RuneCountInString(line.Right)
+		wrapped[len(wrapped)-1] = last + strings.Repeat(" ", max(padding, 1)) + line.Right
+	}
+	return wrapped
+}
+
+func wrapText(text string, width int) []string {
+	lines := make([]string, 0, 1)
+	current := ""
+	for _, word := range strings.Fields(text) {
+		for utf8.RuneCountInString(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	return append(lines, current)
+}
+
+// cp850 cubre los caracteres del español en la página de códigos PC850, que
+// traen casi todas las impresoras térmicas.
+var cp850 = map[rune]byte{
+	'á': 0xA0, 'é': 0x82, 'í': 0xA1, 'ó': 0xA2, 'ú': 0xA3, 'ü': 0x81, 'ñ': 0xA4,
+	'Á': 0xB5, 'É': 0x90, 'Í': 0xD6, 'Ó': 0xE0, 'Ú': 0xE9, 'Ü': 0x9A, 'Ñ': 0xA5,
+	'¿': 0xA8, '¡': 0xAD, '°': 0xF8, '·': 0xFA,
+}
+
+func encodeCP850(text string) []byte {
+	result := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 32 && r < 127:
+			result = append(result, byte(r))
+		default:
+			if b, ok := cp850[r]; ok {
+				result = append(result, b)
+			} else {
+				result = append(result, '?')
+			}
+		}
+	}
+	return result
+}
+
+// renderReceiptESCPOS genera los comandos ESC/POS del ticket. El QR lo dibuja
+// la impresora con GS ( k.
+func renderReceiptESCPOS(content receipt, width int) []byte {
+	columns := receiptColumns[width]
+	var out bytes.Buffer
+	out.Write([]byte{0x1B, 0x40})       // ESC @: reinicia
+	out.Write([]byte{0x1B, 0x74, 0x02}) // ESC t 2: PC850
+
+	for _, line := range content.Lines {
+		align := byte(0)
+		if line.Centered {
+			align = 1
+		}
+		out.Write([]byte{0x1B, 0x61, align})
+		if line.Bold {
+			out.Write([]byte{0x1B, 0x45, 0x01})
+		}
+		for _, text := range line.textLines(columns) {
+			out.Write(encodeCP850(text))
+			out.WriteByte('\n')
+		}
+		if line.Bold {
+			out.Write([]byte{0x1B, 0x45, 0x00})
+		}
+	}
+
+	out.Write([]byte{0x1B, 0x61, 0x01})
+	if content.QRData != "" {
+		moduleSize := byte(6)
+		if width == 58 {
+			moduleSize = 4
+		}
+		data := []byte(content.QRData)
+		storeLength := len(data) + 3
+		out.Write([]byte{0x1D, 0x28, 0x6B, 0x04, 0x00, 0x31, 0x41, 0x32, 0x00}) // modelo 2
+		out.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x43, moduleSize})
+		out.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x45, 0x31}) // corrección M
+		out.Write([]byte{0x1D, 0x28, 0x6B, byte(storeLength), byte(storeLength >> 8), 0x31, 0x50, 0x30})
+		out.Write(data)
+		out.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x51, 0x30}) // imprime
+		out.WriteByte('\n')
+	}
+	for _, text := range wrapText(content.Footer, columns) {
+		out.Write(encodeCP850(text))
+		out.WriteByte('\n')
+	}
+	out.Write([]byte{0x1B, 0x64, 0x04})       // ESC d 4: avanza
+	out.Write([]byte{0x1D, 0x56, 0x42, 0x00}) // GS V: corte parcial
+	return out.Bytes()
+}
+
+// renderReceiptPDF dibuja el ticket en una página del ancho del rollo y tan
+// alta como haga falta.
+func renderReceiptPDF(content receipt, width int) ([]byte, error) {
+	const (
+		margin     = 8.0
+		fontSize   = 7.5
+		lineHeight = 10.0
+	)
+	pageWidth := float64(width) * 72 / 25.4
+	columns := receiptColumns[width]
+
+	type pdfLine struct {
+		receiptLine
+		text []string
+	}
+	lines := make([]pdfLine, 0, len(content.Lines))
+	rows := 0
+	for _, line := range content.Lines {
+		// En PDF el importe se alinea aparte, así que se parte solo el texto.
+		textColumns := columns
+		if line.Right != "" {
+			textColumns = columns - utf8.RuneCountInString(line.Right) - 1
+		}
+		text := []string{""}
+		if !line.Rule {
+			text = wrapText(line.Left, max(textColumns, 1))
+		}
+		lines = append(lines, pdfLine{receiptLine: line, text: text})
+		rows += len(text)
+	}
+	footer := wrapText(content.Footer, columns)
+
+	var code *qrcode.Code
+	qrSide := 0.0
+	if content.QRData != "" {
+		var err error
+		code, err = qrcode.Encode(content.QRData)
+		if err != nil {
+			return nil, err
+		}
+		qrSide = pageWidth * 0.5
+	}
+	pageHeight := margin*2 + float64(rows+len(footer))*lineHeight + qrSide + lineHeight
+
+	doc := pdf.NewWithSize(pageWidth, pageHeight)
+	doc.AddPage()
+	right := pageWidth - margin
+	y := margin
+	for _, line := range lines {
+		if line.Rule {
+			doc.Line(margin, y+lineHeight/2, right, y+lineHeight/2, 0.5, pdf.Gray)
+			y += lineHeight
+			continue
+		}
+		font := pdf.Regular
+		if line.Bold {
+			font = pdf.Bold
+		}
+		for index, text := range line.text {
+			y += lineHeight
+			if line.Centered {
+				doc.Text((pageWidth-pdf.TextWidth(text, font, fontSize))/2, y, text, font, fontSize, pdf.Black)
+			} else {
+				doc.Text(margin, y, text, font, fontSize, pdf.Black)
+			}
+			if line.Right != "" && index == len(line.text)-1 {
+				doc.TextRight(right, y, line.Right, font, fontSize, pdf.Black)
+			}
+		}
+	}
+
+	if code != nil {
+		y += lineHeight / 2
+		module := qrSide / float64(code.Size)
+		left := (pageWidth - qrSide) / 2
+		for row := 0; row < code.Size; row++ {
+			// Los módulos oscuros seguidos de un renglón se dibujan juntos.
+			for column := 0; column < code.Size; {
+				if !code.Dark(column, row) {
+					column++
+					continue
+				}
+				start := column
+				for column < code.Size && code.Dark(column, row) {
+					column++
+				}
+				doc.Rect(left+float64(start)*module, y+float64(row)*module, float64(column-start)*module, module, pdf.Black)
+			}
+		}
+		y += qrSide
+	}
+	for _, text := range footer {
+		y += lineHeight
+		doc.Text((pageWidth-pdf.TextWidth(text, pdf.Regular, fontSize))/2, y, text, pdf.Regular, fontSize, pdf.Gray)
+	}
+	return doc.Bytes()
+}
+
+var receiptTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<title>Ticket</title>
+<style>
+@page { size: {{.Width}}mm auto; margin: 0; }
+body { width: {{.Width}}mm; margin: 0 auto; padding: 3mm; box-sizing: border-box; font: 11px/1.35 monospace; color: #000; }
+.row { display: flex; justify-content: space-between; gap: 6px; }
+.center { text-align: center; }
+.bold { font-weight: bold; }
+hr { border: 0; border-top: 1px dashed #000; margin: 4px 0; }
+svg { display: block; margin: 8px auto; width: 50%; height: auto; }
+</style>
+</head>
+<body>
+{{range .Lines}}{{if .Rule}}<hr>
+{{else if .Centered}}<div class="center{{if .Bold}} bold{{end}}">{{.Left}}</div>
+{{else}}<div class="row{{if .Bold}} bold{{end}}"><span>{{.Left}}</span><span>{{.Right}}</span></div>
+{{end}}{{end}}{{.QR}}
+<div class="center">{{.Footer}}</div>
+</body>
+</html>
+`))
+
+func renderReceiptHTML(content receipt, width int) ([]byte, error) {
+	var qr template.HTML
+	if content.QRData != "" {
+		code, err := qrcode.Encode(content.QRData)
+		if err != nil {
+			return nil, err
+		}
+		qr = template.HTML(qrSVG(code))
+	}
+	var out bytes.Buffer
+	err := receiptTemplate.Execute(&out, map[string]any{
+		"Width":  width,
+		"Lines":  content.Lines,
+		"QR":     qr,
+		"Footer": content.Footer,
+	})
+	return out.Bytes(), err
+}
+
+// qrSVG dibuja el código con su zona de silencio de 4 módulos.
+func qrSVG(code *qrcode.Code) string {
+	side := code.Size + 8
+	var path strings.Builder
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Dark(x, y) {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x+4, y+4)
+			}
+		}
+	}
+	return fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges"><rect width="%d" height="%d" fill="#fff"/><path d="%s" fill="#000"/></svg>`,
+		side, side, side, side, path.String(),
+	)
+}
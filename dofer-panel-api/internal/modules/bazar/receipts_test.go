@@ -0,0 +1,119 @@
+package bazar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+func receiptSale() *Sale {
+	cash := 200.0
+	change := 10.0
+	reason := "Cliente frecuente"
+	return &Sale{
+		ID:            uuid.New(),
+		ExternalID:    "BZ-20261017-0001",
+		BazarName:     "Bazar Navideño",
+		SellerName:    "Ana Pérez",
+		Subtotal:      250,
+		DiscountTotal: 20,
+		Total:         230,
+		PaymentMethod: "mixed",
+		CashReceived:  &cash,
+		ChangeDue:     &change,
+		Status:        "completed",
+		SoldAt:        time.Date(2026, 10, 17, 18, 30, 0, 0, time.UTC),
+		Payments: []SalePayment{
+			{Method: "cash", Amount: 190},
+			{Method: "card", Amount: 40},
+		},
+		Items: []SaleItem{
+			{ProductName: "Figura impresa de dragón con base articulada", Quantity: 2, UnitPrice: 100, Total: 180, Discount: 20, DiscountReason: &reason},
+			{ProductName: "Llavero", Quantity: 1, UnitPrice: 50, Total: 50},
+		},
+	}
+}
+
+func TestReceiptESCPOS(t *testing.T) {
+	sale := receiptSale()
+	content := buildReceipt(sale, nil, time.UTC)
+	out := renderReceiptESCPOS(content, 58)
+
+	if !bytes.HasPrefix(out, []byte{0x1B, 0x40, 0x1B, 0x74, 0x02}) {
+		t.Fatalf("receipt must start with ESC @ and select PC850")
+	}
+	if !bytes.HasSuffix(out, []byte{0x1D, 0x56, 0x42, 0x00}) {
+		t.Fatalf("receipt must end with a cut")
+	}
+	store := append([]byte{0x1D, 0x28, 0x6B, byte(len(sale.ExternalID) + 3), 0x00, 0x31, 0x50, 0x30}, sale.ExternalID...)
+	if !bytes.Contains(out, store) {
+		t.Fatalf("receipt must store the external ID in the QR")
+	}
+	if !bytes.Contains(out, []byte("Bazar Navide\xA4o")) || !bytes.Contains(out, []byte("Ana P\x82rez")) {
+		t.Fatalf("accents must be encoded in PC850")
+	}
+	for _, want := range []string{"Tarjeta", "Cambio", "10.00", "TOTAL"} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Fatalf("receipt is missing %q", want)
+		}
+	}
+
+	for _, line := range content.Lines {
+		for _, text := range line.textLines(32) {
+			if utf8.RuneCountInString(text) > 32 {
+				t.Fatalf("line %q exceeds 32 columns", text)
+			}
+		}
+	}
+}
+
+func TestReceiptLineWrapsAndAlignsAmount(t *testing.T) {
+	lines := receiptLine{Left: "2 x Figura impresa de dragón con base articulada", Right: "200.00"}.textLines(32)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", lines)
+	}
+	if last := lines[2]; utf8.RuneCountInString(last) != 32 || !strings.HasSuffix(last, " 200.00") {
+		t.Fatalf("amount must be right aligned, got %q", last)
+	}
+}
+
+func TestReceiptPDFAndHTML(t *testing.T) {
+	content := buildReceipt(receiptSale(), nil, time.UTC)
+
+	document, err := renderReceiptPDF(content, 80)
+	if err != nil {
+		t.Fatalf("renderReceiptPDF() error = %v", err)
+	}
+	if !bytes.HasPrefix(document, []byte("%PDF-")) {
+		t.Fatalf("expected a PDF document")
+	}
+
+	page, err := renderReceiptHTML(content, 58)
+	if err != nil {
+		t.Fatalf("renderReceiptHTML() error = %v", err)
+	}
+	html := string(page)
+	for _, want := range []string{"size: 58mm auto", "<svg", "Bazar Navideño", "Gracias por su compra."} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("receipt HTML is missing %q", want)
+		}
+	}
+}
+
+func TestReceiptPrintsReceiptNumber(t *testing.T) {
+	sale := receiptSale()
+	number := "TKT-2026-00042"
+	sale.ReceiptNumber = &number
+	out := renderReceiptESCPOS(buildReceipt(sale, nil, time.UTC), 80)
+
+	if !bytes.Contains(out, []byte(number)) {
+		t.Fatalf("receipt must print the receipt number")
+	}
+	if !bytes.Contains(out, []byte(sale.ExternalID)) {
+		t.Fatalf("the QR must keep the sale external ID")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dofer/panel-api/internal/platform/numbering"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -343,7 +344,13 @@ func (r *Repository) UpsertSheetProducts(
 						  AND pending_sale.sync_status <> 'synced'
 					) THEN products.stock
 					WHEN products.stock_sync_policy = 'manual' AND $11 = 'keep_manual' THEN products.stock
-					ELSE EXCLUDED.stock
+					-- La hoja trae el total; nunca baja de lo que ya está en
+					-- los puestos, o el almacén quedaría en negativo.
+					ELSE GREATEST(EXCLUDED.stock, (
+						SELECT COALESCE(SUM(location.quantity), 0)::int
+						FROM bazar_stock_locations location
+						WHERE location.product_id = products.id
+					))
 				END,
 				image_url = CASE WHEN products.bazar_source = 'manual' THEN products.image_url ELSE EXCLUDED.image_url END,
 				is_active = CASE WHEN products.bazar_source = 'manual' THEN products.is_active ELSE EXCLUDED.is_active END,
@@ -415,7 +422,27 @@ func (r *Repository) CreateSale(ctx context.Context, organizationID string, cmd
 		return nil, err
 	}
 	if bazarStatus != "active" {
-		return nil, &serviceError{Status: http.StatusConflict, Message: "La sesión del bazar está cerrada."}
+		return nil, &serviceError{Status: http.StatusConflict, Message: "La sesión del bazar está cerrada.", Code: "bazar_closed"}
+	}
+	// El corte también bloquea el bazar, así que no puede cerrarse entre esta
+	// revisión y el INSERT.
+	if cmd.BusinessDate != nil {
+		var closed bool
+		if err := tx.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM bazar_daily_cuts
+				WHERE organization_id = $1 AND bazar_id = $2 AND business_date = $3
+			)
+		`, organizationID, cmd.BazarID, *cmd.BusinessDate).Scan(&closed); err != nil {
+			return nil, err
+		}
+		if closed {
+			return nil, &serviceError{
+				Status:  http.StatusConflict,
+				Message: "Ese día ya tiene corte de caja cerrado; no se le pueden agregar ventas.",
+				Code:    "daily_cut_closed",
+			}
+		}
 	}
 
 	type lockedProduct struct {
@@ -425,6 +452,10 @@ func (r *Repository) CreateSale(ctx context.Context, organizationID string, cmd
 		Price      float64
 		Stock      int
 		TrackStock bool
+		Cost       *float64
+		// VariantGroupID y Category deciden qué promociones aplican.
+		VariantGroupID *uuid.UUID
+		Category       string
 	}
 	// Los productos se bloquean en una sola consulta ordenada por id: antes era
 	// un SELECT ... FOR UPDATE por renglon, y una venta de varios articulos
@@ -442,7 +473,7 @@ func (r *Repository) CreateSale(ctx context.Context, organizationID string, cmd
 		           ELSE name || ' · ' || TRIM(color)
 		       END,
 		       COALESCE(suggested_price, 0), stock, track_stock,
-		       is_active, bazar_enabled
+		       is_active, bazar_enabled, variant_group_id, COALESCE(category, ''), cost
 		FROM products
 		WHERE id = ANY($1) AND organization_id = $2
 		ORDER BY id
@@ -458,6 +489,7 @@ func (r *Repository) CreateSale(ctx context.Context, organizationID string, cmd
 	for rows.Next() {
 		var product lockedProduct
 		var active, bazarEnabled bool
+		var variantGroupID uuid.NullUUID
 		if err := rows.Scan(
 			&product.ID,
 			&product.ExternalID,
@@ -467,10 +499,16 @@ func (r *Repository) CreateSale(ctx context.Context, organizationID string, cmd
 			&product.TrackStock,
 			&active,
 			&bazarEnabled,
+			&variantGroupID,
+			&product.Category,
+			&product.Cost,
 		); err != nil {
 			rows.Close()
 			return nil, err
 		}
+		if variantGroupID.Valid {
+			product.VariantGroupID = &variantGroupID.UUID
+		}
 		locked[product.ID] = product
 		enabled[product.ID] = bazarEnabled
 		activeByID[product.ID] = active
@@ -480,9 +518,19 @@ func (r *Repository) CreateSale(ctx context.Context, organizationID string, cmd
 		return nil, err
 	}
 
+	// Un producto con lugar en el puesto del bazar se vende de ahí; los demás
+	// salen del almacén, sin tocar lo que está en otros puestos.
+	locations, err := lockStandStock(ctx, tx, organizationID, cmd.BazarID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	lockedProducts := make([]lockedProduct, 0, len(cmd.Items))
-	total := 0.0
-	for _, item := range cmd.Items {
+	lines := make([]pricingLine, 0, len(cmd.Items))
+	// oversold es lo que falta por renglón cuando se aceptan ventas sin stock
+	// suficiente; solo se descuenta lo que había.
+	oversold := make([]int, len(cmd.Items))
+	for index, item := range cmd.Items {
 		product, found := locked[item.ProductID]
 		if !found || !enabled[item.ProductID] {
 			return nil, &serviceError{Status: http.StatusNotFound, Message: "Producto no encontrado."}
@@ -490,43 +538,85 @@ func (r *Repository) CreateSale(ctx context.Context, organizationID string, cmd
 		if !activeByID[item.ProductID] {
 			return nil, &serviceError{Status: http.StatusConflict, Message: product.Name + " está inactivo."}
 		}
-		if product.TrackStock && item.Quantity > product.Stock {
-			return nil, &serviceError{
-				Status:  http.StatusConflict,
-				Message: fmt.Sprintf("Stock insuficiente para %s. Disponibles: %d.", product.Name, product.Stock),
+		if product.TrackStock {
+			if standQuantity, atStand := locations.stand[item.ProductID]; atStand {
+				if item.Quantity > standQuantity {
+					if !cmd.AllowOversell {
+						return nil, &serviceError{
+							Status:  http.StatusConflict,
+							Message: fmt.Sprintf("Stock insuficiente para %s en el bazar. Disponibles: %d.", product.Name, standQuantity),
+							Code:    "insufficient_stock",
+						}
+					}
+					oversold[index] = item.Quantity - standQuantity
+				}
+			} else if available := locations.warehouse(item.ProductID, product.Stock); item.Quantity > available {
+				if !cmd.AllowOversell {
+					return nil, &serviceError{
+						Status:  http.StatusConflict,
+						Message: fmt.Sprintf("Stock insuficiente para %s. Disponibles: %d.", product.Name, available),
+						Code:    "insufficient_stock",
+					}
+				}
+				oversold[index] = item.Quantity - available
 			}
 		}
-		total += product.Price * float64(item.Quantity)
+		lines = append(lines, pricingLine{
+			ProductID:      product.ID,
+			VariantGroupID: product.VariantGroupID,
+			Category:       product.Category,
+			Quantity:       item.Quantity,
+			UnitPrice:      product.Price,
+			Manual:         item.Discount,
+		})
 		lockedProducts = append(lockedProducts, product)
 	}
 
+	pricedAt := time.Now()
+	if cmd.SoldAt != nil {
+		pricedAt = *cmd.SoldAt
+	}
+	promotions, err := listActivePromotions(ctx, tx, organizationID, cmd.BazarID, pricedAt)
+	if err != nil {
+		return nil, err
+	}
+	priced, err := priceSale(lines, promotions, cmd.Discount, cmd.MaxDiscountPercent)
+	if err != nil {
+		return nil, err
+	}
+	total := priced.Total
+	var ticketReason *string
+	if cmd.Discount != nil {
+		ticketReason = &cmd.Discount.Reason
+	}
+
+	// Los folios se reservan al final, ya validada la venta, para retener lo
+	// menos posible el bloqueo de los contadores.
 	saleID := uuid.New()
-	externalID := fmt.Sprintf(
-		"SALE-%s-%s",
-		time.Now().Format("20060102"),
-		strings.ToUpper(strings.ReplaceAll(saleID.String(), "-", "")[:8]),
-	)
+	externalID, err := numbering.Next(ctx, tx, organizationID, numbering.DocumentBazarSale, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	receiptNumber, err := numbering.Next(ctx, tx, organizationID, numbering.DocumentReceipt, time.Now())
+	if err != nil {
+		return nil, err
+	}
 	notes := sanitizeString(cmd.Notes)
-	var cashReceived, changeDue *float64
-	if cmd.PaymentMethod == PaymentCash && cmd.CashReceived != nil {
-		if *cmd.CashReceived < total {
-			return nil, &serviceError{
-				Status:  http.StatusBadRequest,
-				Message: fmt.Sprintf("El efectivo recibido debe ser al menos %.2f.", total),
-			}
-		}
-		received := *cmd.CashReceived
-		change := received - total
-		cashReceived = &received
-		changeDue = &change
+	payments, cashReceived, changeDue, err := settleSalePayments(cmd.PaymentMethod, cmd.Payments, total, cmd.CashReceived)
+	if err != nil {
+		return nil, err
 	}
 
 	_, err = tx.Exec(ctx, `
 		INSERT INTO bazar_sales (
 			id, organization_id, external_id, client_request_id, bazar_id,
 			seller_id, seller_name, subtotal, total, payment_method,
-			cash_received, change_due, notes, sold_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8, $9, $10, $11, $12, COALESCE($13, NOW()))
+			cash_received, change_due, notes, sold_at, discount_total,
+			ticket_discount, discount_reason, receipt_number
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
+			COALESCE($14, NOW()), $15, $16, $17, $18
+		)
 	`,
 		saleID,
 		organizationID,
@@ -535,12 +625,17 @@ func (r *Repository) CreateSale(ctx context.Context, organizationID string, cmd
 		cmd.BazarID,
 		cmd.SellerID,
 		cmd.SellerName,
+		priced.Subtotal,
 		total,
 		cmd.PaymentMethod,
 		cashReceived,
 		changeDue,
 		notes,
 		cmd.SoldAt,
+		priced.DiscountTotal,
+		priced.TicketDiscount,
+		ticketReason,
+		receiptNumber,
 	)
 	if err != nil {
 		return nil, err
@@ -551,11 +646,23 @@ func (r *Repository) CreateSale(ctx context.Context, organizationID string, cmd
 	batch := &pgx.Batch{}
 	for index, item := range cmd.Items {
 		product := lockedProducts[index]
+		deducted := item.Quantity - oversold[index]
 		stockAfter := product.Stock
 		if product.TrackStock {
-			stockAfter -= item.Quantity
+			stockAfter -= deducted
+		}
+		line := &lines[index]
+		standQuantity, fromStand := locations.stand[product.ID]
+		fromStand = fromStand && product.TrackStock
+		var standBefore, standAfter *int
+		if fromStand {
+			remaining := standQuantity - deducted
+			standBefore, standAfter = &standQuantity, &remaining
+		}
+		movementReason := "Venta de bazar"
+		if oversold[index] > 0 {
+			movementReason = fmt.Sprintf("Venta de bazar sin conexión; faltaron %d piezas", oversold[index])
 		}
-		lineTotal := product.Price * float64(item.Quantity)
 
 		if product.TrackStock {
 			batch.Queue(`
@@ -564,12 +671,21 @@ func (r *Repository) CreateSale(ctx context.Context, organizationID string, cmd
 				WHERE id = $2 AND organization_id = $3
 			`, stockAfter, product.ID, organizationID)
 		}
+		if fromStand {
+			batch.Queue(`
+				UPDATE bazar_stock_locations
+				SET quantity = $1
+				WHERE product_id = $2 AND bazar_id = $3 AND organization_id = $4
+			`, *standAfter, product.ID, cmd.BazarID, organizationID)
+		}
 
 		batch.Queue(`
 			INSERT INTO bazar_sale_items (
 				organization_id, sale_id, product_id, product_external_id, product_name,
-				quantity, unit_price, total, stock_before, stock_after
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+				quantity, unit_price, total, stock_before, stock_after, discount,
+				promotion_discount, promotion_id, discount_reason, from_stand,
+				oversold_quantity, unit_cost
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 		`,
 			organizationID,
 			saleID,
@@ -578,30 +694,48 @@ func (r *Repository) CreateSale(ctx context.Context, organizationID string, cmd
 			product.Name,
 			item.Quantity,
 			product.Price,
-			lineTotal,
+			line.net(),
 			product.Stock,
 			stockAfter,
+			line.Discount,
+			line.PromotionDiscount,
+			line.PromotionID,
+			line.reason(),
+			fromStand,
+			oversold[index],
+			product.Cost,
 		)
 
 		if product.TrackStock {
 			batch.Queue(`
 				INSERT INTO bazar_inventory_movements (
 					organization_id, product_id, sale_id, bazar_id, movement_type,
-					quantity, stock_before, stock_after, reason, created_by
-				) VALUES ($1, $2, $3, $4, 'sale', $5, $6, $7, 'Venta de bazar', $8)
+					quantity, stock_before, stock_after, stand_stock_before,
+					stand_stock_after, reason, created_by
+				) VALUES ($1, $2, $3, $4, 'sale', $5, $6, $7, $8, $9, $10, $11)
 			`,
 				organizationID,
 				product.ID,
 				saleID,
 				cmd.BazarID,
-				-item.Quantity,
+				-deducted,
 				product.Stock,
 				stockAfter,
+				standBefore,
+				standAfter,
+				movementReason,
 				cmd.SellerID,
 			)
 		}
 	}
 
+	for _, payment := range payments {
+		batch.Queue(`
+			INSERT INTO bazar_sale_payments (organization_id, sale_id, method, amount)
+			VALUES ($1, $2, $3, $4)
+		`, organizationID, saleID, payment.Method, payment.Amount)
+	}
+
 	results := tx.SendBatch(ctx, batch)
 	for index := 0; index < batch.Len(); index++ {
 		if _, err := results.Exec(); err != nil {
@@ -630,7 +764,9 @@ func (r *Repository) GetSale(ctx context.Context, organizationID string, saleID
 		       s.seller_id, s.seller_name, s.subtotal, s.total, s.payment_method,
 		       s.cash_received, s.change_due,
 		       s.status, s.sync_status, s.sync_attempts, s.last_sync_at,
-		       s.sync_error, s.notes, s.sold_at, s.created_at, s.cancelled_at
+		       s.next_sync_at, s.sync_error, s.notes, s.sold_at, s.created_at, s.cancelled_at,
+		       s.refunded_total, s.discount_total, s.ticket_discount, s.discount_reason,
+		       s.receipt_number, s.sync_version
 		FROM bazar_sales s
 		JOIN bazaars b ON b.id = s.bazar_id
 		WHERE s.id = $1 AND s.organization_id = $2
@@ -644,12 +780,30 @@ func (r *Repository) GetSale(ctx context.Context, organizationID string, saleID
 		return nil, err
 	}
 
+	if err := r.loadSaleDetails(ctx, organizationID, sale); err != nil {
+		return nil, err
+	}
+	return sale, nil
+}
+
+// loadSaleDetails completa la venta con sus productos, pagos y devoluciones.
+func (r *Repository) loadSaleDetails(ctx context.Context, organizationID string, sale *Sale) error {
 	items, err := r.listSaleItems(ctx, organizationID, sale.ID)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	sale.Items = items
-	return sale, nil
+	payments, err := r.listSalePayments(ctx, organizationID, sale.ID)
+	if err != nil {
+		return err
+	}
+	sale.Payments = payments
+	returns, err := r.listSaleReturns(ctx, organizationID, sale.ID)
+	if err != nil {
+		return err
+	}
+	sale.Returns = returns
+	return nil
 }
 
 func (r *Repository) ListSales(
@@ -668,7 +822,9 @@ func (r *Repository) ListSales(
 		       s.seller_id, s.seller_name, s.subtotal, s.total, s.payment_method,
 		       s.cash_received, s.change_due,
 		       s.status, s.sync_status, s.sync_attempts, s.last_sync_at,
-		       s.sync_error, s.notes, s.sold_at, s.created_at, s.cancelled_at
+		       s.next_sync_at, s.sync_error, s.notes, s.sold_at, s.created_at, s.cancelled_at,
+		       s.refunded_total, s.discount_total, s.ticket_discount, s.discount_reason,
+		       s.receipt_number, s.sync_version
 		FROM bazar_sales s
 		JOIN bazaars b ON b.id = s.bazar_id
 		WHERE s.organization_id = $1
@@ -708,11 +864,9 @@ func (r *Repository) ListSales(
 	}
 
 	for index := range sales {
-		items, err := r.listSaleItems(ctx, organizationID, sales[index].ID)
-		if err != nil {
+		if err := r.loadSaleDetails(ctx, organizationID, &sales[index]); err != nil {
 			return nil, err
 		}
-		sales[index].Items = items
 	}
 	return sales, nil
 }
@@ -720,8 +874,8 @@ func (r *Repository) ListSales(
 func scanSale(row pgx.Row) (*Sale, error) {
 	var sale Sale
 	var sellerID uuid.NullUUID
-	var lastSyncAt, cancelledAt sql.NullTime
-	var syncError, notes sql.NullString
+	var lastSyncAt, nextSyncAt, cancelledAt sql.NullTime
+	var syncError, notes, discountReason, receiptNumber sql.NullString
 	var cashReceived, changeDue sql.NullFloat64
 	if err := row.Scan(
 		&sale.ID,
@@ -740,14 +894,27 @@ func scanSale(row pgx.Row) (*Sale, error) {
 		&sale.SyncStatus,
 		&sale.SyncAttempts,
 		&lastSyncAt,
+		&nextSyncAt,
 		&syncError,
 		&notes,
 		&sale.SoldAt,
 		&sale.CreatedAt,
 		&cancelledAt,
+		&sale.RefundedTotal,
+		&sale.DiscountTotal,
+		&sale.TicketDiscount,
+		&discountReason,
+		&receiptNumber,
+		&sale.SyncVersion,
 	); err != nil {
 		return nil, err
 	}
+	if discountReason.Valid {
+		sale.DiscountReason = &discountReason.String
+	}
+	if receiptNumber.Valid {
+		sale.ReceiptNumber = &receiptNumber.String
+	}
 	if sellerID.Valid {
 		sale.SellerID = &sellerID.UUID
 	}
@@ -760,6 +927,9 @@ func scanSale(row pgx.Row) (*Sale, error) {
 	if lastSyncAt.Valid {
 		sale.LastSyncAt = &lastSyncAt.Time
 	}
+	if nextSyncAt.Valid {
+		sale.NextSyncAt = &nextSyncAt.Time
+	}
 	if syncError.Valid {
 		sale.SyncError = &syncError.String
 	}
@@ -769,14 +939,18 @@ func scanSale(row pgx.Row) (*Sale, error) {
 	if cancelledAt.Valid {
 		sale.CancelledAt = &cancelledAt.Time
 	}
+	sale.Payments = make([]SalePayment, 0)
 	sale.Items = make([]SaleItem, 0)
+	sale.Returns = make([]SaleReturn, 0)
 	return &sale, nil
 }
 
 func (r *Repository) listSaleItems(ctx context.Context, organizationID string, saleID uuid.UUID) ([]SaleItem, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT id, product_id, product_external_id, product_name, quantity,
-		       unit_price, total, stock_before, stock_after
+		       unit_price, total, stock_before, stock_after, returned_quantity,
+		       discount, promotion_discount, promotion_id, discount_reason, from_stand,
+		       oversold_quantity
 		FROM bazar_sale_items
 		WHERE sale_id = $1 AND organization_id = $2
 		ORDER BY created_at, id
@@ -789,6 +963,8 @@ func (r *Repository) listSaleItems(ctx context.Context, organizationID string, s
 	items := make([]SaleItem, 0)
 	for rows.Next() {
 		var item SaleItem
+		var promotionID uuid.NullUUID
+		var discountReason sql.NullString
 		if err := rows.Scan(
 			&item.ID,
 			&item.ProductID,
@@ -799,14 +975,50 @@ func (r *Repository) listSaleItems(ctx context.Context, organizationID string, s
 			&item.Total,
 			&item.StockBefore,
 			&item.StockAfter,
+			&item.ReturnedQuantity,
+			&item.Discount,
+			&item.PromotionDiscount,
+			&promotionID,
+			&discountReason,
+			&item.FromStand,
+			&item.OversoldQuantity,
 		); err != nil {
 			return nil, err
 		}
+		if promotionID.Valid {
+			item.PromotionID = &promotionID.UUID
+		}
+		if discountReason.Valid {
+			item.DiscountReason = &discountReason.String
+		}
 		items = append(items, item)
 	}
 	return items, rows.Err()
 }
 
+func (r *Repository) listSalePayments(ctx context.Context, organizationID string, saleID uuid.UUID) ([]SalePayment, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT method, amount
+		FROM bazar_sale_payments
+		WHERE sale_id = $1 AND organization_id = $2
+		ORDER BY array_position($3::text[], method)
+	`, saleID, organizationID, paymentMethodOrder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := make([]SalePayment, 0)
+	for rows.Next() {
+		var payment SalePayment
+		if err := rows.Scan(&payment.Method, &payment.Amount); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, rows.Err()
+}
+
 func (r *Repository) CancelSale(
 	ctx context.Context,
 	organizationID string,
@@ -822,12 +1034,14 @@ func (r *Repository) CancelSale(
 	var status string
 	var sellerID uuid.NullUUID
 	var bazarID uuid.UUID
+	var hasReturns bool
 	err = tx.QueryRow(ctx, `
-		SELECT status, seller_id, bazar_id
+		SELECT status, seller_id, bazar_id,
+		       EXISTS (SELECT 1 FROM bazar_sale_returns WHERE sale_id = bazar_sales.id)
 		FROM bazar_sales
 		WHERE id = $1 AND organization_id = $2
 		FOR UPDATE
-	`, saleID, organizationID).Scan(&status, &sellerID, &bazarID)
+	`, saleID, organizationID).Scan(&status, &sellerID, &bazarID, &hasReturns)
 	if err == pgx.ErrNoRows {
 		return nil, &serviceError{Status: http.StatusNotFound, Message: "Venta no encontrada."}
 	}
@@ -840,9 +1054,19 @@ func (r *Repository) CancelSale(
 	if !canCancelAny && (!sellerID.Valid || sellerID.UUID != userID) {
 		return nil, &serviceError{Status: http.StatusForbidden, Message: "Solo puedes deshacer tus propias ventas."}
 	}
+	// Cancelar devolvería otra vez las piezas ya devueltas y dejaría el
+	// reembolso contado dos veces en caja.
+	if hasReturns {
+		return nil, &serviceError{
+			Status:  http.StatusConflict,
+			Message: "La venta ya tiene devoluciones; registra una devolución por lo que resta.",
+		}
+	}
 
+	// Lo vendido sin stock registrado nunca se descontó, así que tampoco se
+	// regresa al cancelar.
 	rows, err := tx.Query(ctx, `
-		SELECT product_id, quantity
+		SELECT product_id, quantity - oversold_quantity, from_stand
 		FROM bazar_sale_items
 		WHERE sale_id = $1 AND organization_id = $2
 		ORDER BY id
@@ -854,11 +1078,12 @@ func (r *Repository) CancelSale(
 	type cancellationItem struct {
 		ProductID uuid.UUID
 		Quantity  int
+		FromStand bool
 	}
 	items := make([]cancellationItem, 0)
 	for rows.Next() {
 		var item cancellationItem
-		if err := rows.Scan(&item.ProductID, &item.Quantity); err != nil {
+		if err := rows.Scan(&item.ProductID, &item.Quantity, &item.FromStand); err != nil {
 			rows.Close()
 			return nil, err
 		}
@@ -880,7 +1105,7 @@ func (r *Repository) CancelSale(
 		`, item.ProductID, organizationID).Scan(&stockBefore, &trackStock); err != nil {
 			return nil, err
 		}
-		if !trackStock {
+		if !trackStock || item.Quantity == 0 {
 			continue
 		}
 		stockAfter := stockBefore + item.Quantity
@@ -890,12 +1115,36 @@ func (r *Repository) CancelSale(
 		`, stockAfter, item.ProductID, organizationID); err != nil {
 			return nil, err
 		}
+		standBefore, standAfter, err := returnToStand(
+			ctx,
+			tx,
+			organizationID,
+			bazarID,
+			item.ProductID,
+			item.Quantity,
+			item.FromStand,
+		)
+		if err != nil {
+			return nil, err
+		}
 		if _, err := tx.Exec(ctx, `
 			INSERT INTO bazar_inventory_movements (
 				organization_id, product_id, sale_id, bazar_id, movement_type,
-				quantity, stock_before, stock_after, reason, created_by
-			) VALUES ($1, $2, $3, $4, 'sale_cancelled', $5, $6, $7, 'Venta cancelada', $8)
-		`, organizationID, item.ProductID, saleID, bazarID, item.Quantity, stockBefore, stockAfter, userID); err != nil {
+				quantity, stock_before, stock_after, stand_stock_before,
+				stand_stock_after, reason, created_by
+			) VALUES ($1, $2, $3, $4, 'sale_cancelled', $5, $6, $7, $8, $9, 'Venta cancelada', $10)
+		`,
+			organizationID,
+			item.ProductID,
+			saleID,
+			bazarID,
+			item.Quantity,
+			stockBefore,
+			stockAfter,
+			standBefore,
+			standAfter,
+			userID,
+		); err != nil {
 			return nil, err
 		}
 	}
@@ -904,7 +1153,10 @@ func (r *Repository) CancelSale(
 		UPDATE bazar_sales
 		SET status = 'cancelled',
 		    sync_status = 'pending',
+		    sync_version = sync_version + 1,
+		    sync_attempts = 0,
 		    sync_error = NULL,
+		    next_sync_at = NULL,
 		    cancelled_at = NOW(),
 		    cancelled_by = $1,
 		    updated_at = NOW()
@@ -943,20 +1195,39 @@ func (r *Repository) GetCurrentStocksForSale(ctx context.Context, organizationID
 	return stocks, rows.Err()
 }
 
-func (r *Repository) MarkSaleSynced(ctx context.Context, organizationID string, saleID uuid.UUID) error {
-	_, err := r.db.Exec(ctx, `
+// MarkSaleSynced cierra el envío de la versión apartada. Devuelve false si la
+// venta cambió mientras se enviaba o alguien más ya la cerró; en ese caso la
+// versión nueva sigue en la cola.
+func (r *Repository) MarkSaleSynced(ctx context.Context, organizationID string, saleID uuid.UUID, version int) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
 		UPDATE bazar_sales
 		SET sync_status = 'synced',
 		    sync_attempts = sync_attempts + 1,
 		    last_sync_at = NOW(),
 		    sync_error = NULL,
+		    next_sync_at = NULL,
 		    updated_at = NOW()
 		WHERE id = $1 AND organization_id = $2
-	`, saleID, organizationID)
-	return err
+		  AND sync_status IN ('pending', 'error')
+		  AND sync_version = $3
+	`, saleID, organizationID, version)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
 }
 
-func (r *Repository) MarkSaleSyncError(ctx context.Context, organizationID string, saleID uuid.UUID, syncErr error) error {
+// MarkSaleSyncError guarda el intento fallido de la versión apartada. Con
+// nextSyncAt nil la venta pasa a 'dead' y el worker deja de reintentarla.
+func (r *Repository) MarkSaleSyncError(
+	ctx context.Context,
+	organizationID string,
+	saleID uuid.UUID,
+	version int,
+	attempts int,
+	syncErr error,
+	nextSyncAt *time.Time,
+) error {
 	message := "Error desconocido de sincronización"
 	if syncErr != nil {
 		message = syncErr.Error()
@@ -964,58 +1235,24 @@ func (r *Repository) MarkSaleSyncError(ctx context.Context, organizationID strin
 	if len(message) > 1000 {
 		message = message[:1000]
 	}
+	status := "error"
+	if nextSyncAt == nil {
+		status = "dead"
+	}
 	_, err := r.db.Exec(ctx, `
 		UPDATE bazar_sales
-		SET sync_status = 'error',
-		    sync_attempts = sync_attempts + 1,
-		    sync_error = $1,
+		SET sync_status = $1,
+		    sync_attempts = $2,
+		    sync_error = $3,
+		    next_sync_at = $4,
 		    updated_at = NOW()
-		WHERE id = $2 AND organization_id = $3
-	`, message, saleID, organizationID)
+		WHERE id = $5 AND organization_id = $6
+		  AND sync_status IN ('pending', 'error')
+		  AND sync_version = $7
+	`, status, attempts, message, nextSyncAt, saleID, organizationID, version)
 	return err
 }
 
-func (r *Repository) ListUnsyncedSales(ctx context.Context, organizationID string, limit int) ([]Sale, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 50
-	}
-	rows, err := r.db.Query(ctx, `
-		SELECT id
-		FROM bazar_sales
-		WHERE organization_id = $1 AND sync_status IN ('pending', 'error')
-		ORDER BY created_at
-		LIMIT $2
-	`, organizationID, limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	ids := make([]uuid.UUID, 0)
-	for rows.Next() {
-		var id uuid.UUID
-		if err := rows.Scan(&id); err != nil {
-			return nil, err
-		}
-		ids = append(ids, id)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	sales := make([]Sale, 0, len(ids))
-	for _, id := range ids {
-		sale, err := r.GetSale(ctx, organizationID, id)
-		if err != nil {
-			return nil, err
-		}
-		if sale != nil {
-			sales = append(sales, *sale)
-		}
-	}
-	return sales, nil
-}
-
 func (r *Repository) GetDailyStats(
 	ctx context.Context,
 	organizationID string,
@@ -1088,17 +1325,25 @@ func (r *Repository) GetSyncStatus(ctx context.Context, organizationID string, c
 	status.Configured = configured
 	status.ConfigurationMsg = configurationMessage
 
-	var lastProductSync, lastSaleSync sql.NullTime
+	var lastProductSync, lastSaleSync, nextRetryAt sql.NullTime
 	if err := r.db.QueryRow(ctx, `
 		SELECT
 			COUNT(*) FILTER (WHERE sync_status = 'pending'),
 			COUNT(*) FILTER (WHERE sync_status = 'error'),
+			COUNT(*) FILTER (WHERE sync_status = 'dead'),
+			COUNT(*) FILTER (
+				WHERE sync_status IN ('pending', 'error')
+				  AND (next_sync_at IS NULL OR next_sync_at <= NOW())
+			),
+			MIN(next_sync_at) FILTER (
+				WHERE sync_status IN ('pending', 'error') AND next_sync_at > NOW()
+			),
 			MAX(last_sync_at),
 			COALESCE((
 				SELECT latest.sync_error
 				FROM bazar_sales latest
 				WHERE latest.organization_id = $1
-				  AND latest.sync_status = 'error'
+				  AND latest.sync_status IN ('error', 'dead')
 				  AND latest.sync_error IS NOT NULL
 				ORDER BY latest.updated_at DESC
 				LIMIT 1
@@ -1108,6 +1353,9 @@ func (r *Repository) GetSyncStatus(ctx context.Context, organizationID string, c
 	`, organizationID).Scan(
 		&status.PendingSales,
 		&status.FailedSales,
+		&status.DeadSales,
+		&status.DueSales,
+		&nextRetryAt,
 		&lastSaleSync,
 		&status.LastError,
 	); err != nil {
@@ -1127,11 +1375,15 @@ func (r *Repository) GetSyncStatus(ctx context.Context, organizationID string, c
 	if lastSaleSync.Valid {
 		status.LastSaleSync = &lastSaleSync.Time
 	}
+	if nextRetryAt.Valid {
+		status.NextRetryAt = &nextRetryAt.Time
+	}
+	status.QueueDepth = status.PendingSales + status.FailedSales
 
 	switch {
 	case !configured:
 		status.Status = "not_configured"
-	case status.FailedSales > 0:
+	case status.FailedSales > 0 || status.DeadSales > 0:
 		status.Status = "error"
 	case status.PendingSales > 0:
 		status.Status = "pending"
@@ -54,29 +54,47 @@ func (r *Repository) CloseDailyCut(
 		return nil, err
 	}
 
+	// En ventas con pagos combinados solo cuenta la parte en efectivo.
 	var cashSales float64
 	if err := tx.QueryRow(ctx, `
-		SELECT COALESCE(SUM(total), 0)
-		FROM bazar_sales
+		SELECT COALESCE(SUM(p.amount), 0)
+		FROM bazar_sale_payments p
+		JOIN bazar_sales s ON s.id = p.sale_id
+		WHERE s.organization_id = $1
+		  AND s.bazar_id = $2
+		  AND s.status = 'completed'
+		  AND p.method = 'cash'
+		  AND s.sold_at >= $3
+		  AND s.sold_at < $4
+	`, organizationID, bazarID, from, to).Scan(&cashSales); err != nil {
+		return nil, err
+	}
+
+	// Los reembolsos en efectivo salen de la caja el día en que se devuelven,
+	// aunque la venta sea de otro día.
+	var cashRefunds float64
+	if err := tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(refund_amount), 0)
+		FROM bazar_sale_returns
 		WHERE organization_id = $1
 		  AND bazar_id = $2
-		  AND status = 'completed'
-		  AND payment_method = 'cash'
-		  AND sold_at >= $3
-		  AND sold_at < $4
-	`, organizationID, bazarID, from, to).Scan(&cashSales); err != nil {
+		  AND refund_method = 'cash'
+		  AND returned_at >= $3
+		  AND returned_at < $4
+	`, organizationID, bazarID, from, to).Scan(&cashRefunds); err != nil {
 		return nil, err
 	}
 
-	expectedCash := openingCash + cashSales
+	expectedCash := openingCash + cashSales - cashRefunds
 	difference := closingCash - expectedCash
 	row := tx.QueryRow(ctx, `
 		INSERT INTO bazar_daily_cuts (
 			organization_id, bazar_id, business_date, opening_cash, cash_sales,
-			expected_cash, closing_cash, cash_difference, notes, closed_by, closed_by_name
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			cash_refunds, expected_cash, closing_cash, cash_difference, notes,
+			closed_by, closed_by_name
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id, bazar_id, business_date, opening_cash, cash_sales,
-		          expected_cash, closing_cash, cash_difference, notes,
+		          cash_refunds, expected_cash, closing_cash, cash_difference, notes,
 		          closed_by_name, closed_at
 	`,
 		organizationID,
@@ -84,6 +102,7 @@ func (r *Repository) CloseDailyCut(
 		businessDate,
 		openingCash,
 		cashSales,
+		cashRefunds,
 		expectedCash,
 		closingCash,
 		difference,
@@ -131,7 +150,7 @@ func (r *Repository) ListDailyCuts(
 	}
 	rows, err := r.db.Query(ctx, `
 		SELECT c.id, c.bazar_id, b.name, c.business_date, c.opening_cash,
-		       c.cash_sales, c.expected_cash, c.closing_cash, c.cash_difference,
+		       c.cash_sales, c.cash_refunds, c.expected_cash, c.closing_cash, c.cash_difference,
 		       c.notes, c.closed_by_name, c.closed_at
 		FROM bazar_daily_cuts c
 		JOIN bazaars b ON b.id = c.bazar_id
@@ -156,6 +175,7 @@ func (r *Repository) ListDailyCuts(
 			&businessDate,
 			&cut.OpeningCash,
 			&cut.CashSales,
+			&cut.CashRefunds,
 			&cut.ExpectedCash,
 			&cut.ClosingCash,
 			&cut.CashDifference,
@@ -184,6 +204,7 @@ func scanDailyCut(row pgx.Row) (*DailyCut, error) {
 		&businessDate,
 		&cut.OpeningCash,
 		&cut.CashSales,
+		&cut.CashRefunds,
 		&cut.ExpectedCash,
 		&cut.ClosingCash,
 		&cut.CashDifference,
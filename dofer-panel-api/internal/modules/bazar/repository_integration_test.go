@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -13,6 +14,14 @@ import (
 
 type unconfiguredSheets struct{}
 
+func (s unconfiguredSheets) ForOrganization(context.Context, string) (SyncTarget, error) {
+	return s, nil
+}
+
+func (unconfiguredSheets) Kind() string {
+	return TargetGoogleSheets
+}
+
 func (unconfiguredSheets) Configured() (bool, string) {
 	return false, "Google Sheets no está configurado"
 }
@@ -101,7 +110,7 @@ func TestManualProductSaleLifecycleIntegration(t *testing.T) {
 		t.Fatalf("expected duplicate SKU conflict, got %v", err)
 	}
 
-	result, err := service.CreateSale(ctx, organizationID.String(), userID, "Integration Admin", CreateSaleRequest{
+	result, err := service.CreateSale(ctx, organizationID.String(), userID, "Integration Admin", true, CreateSaleRequest{
 		ClientRequestID: uuid.NewString(),
 		BazarID:         bazarItem.ID.String(),
 		ProductID:       product.ID.String(),
@@ -133,6 +142,15 @@ func TestManualProductSaleLifecycleIntegration(t *testing.T) {
 	if cancelled.Status != "cancelled" || cancelled.SyncStatus != "pending" {
 		t.Fatalf("unexpected cancelled sale: %#v", cancelled)
 	}
+	// Un envío que apartó la venta antes de cancelarla no puede cerrarla.
+	marked, err := repository.MarkSaleSynced(ctx, organizationID.String(), result.Sale.ID, result.Sale.SyncVersion)
+	if err != nil || marked {
+		t.Fatalf("stale sync version marked the sale as synced: marked=%v err=%v", marked, err)
+	}
+	marked, err = repository.MarkSaleSynced(ctx, organizationID.String(), cancelled.ID, cancelled.SyncVersion)
+	if err != nil || !marked {
+		t.Fatalf("current sync version was not marked: marked=%v err=%v", marked, err)
+	}
 
 	restored, err := repository.GetProduct(ctx, organizationID.String(), product.ID)
 	if err != nil {
@@ -176,7 +194,7 @@ func TestManualProductSaleLifecycleIntegration(t *testing.T) {
 	if err != nil || retriedProduct.ID != freeSaleProduct.ID {
 		t.Fatalf("retry offline product: %#v, err=%v", retriedProduct, err)
 	}
-	freeSale, err := service.CreateSale(ctx, organizationID.String(), userID, "Integration Admin", CreateSaleRequest{
+	freeSale, err := service.CreateSale(ctx, organizationID.String(), userID, "Integration Admin", true, CreateSaleRequest{
 		ClientRequestID: uuid.NewString(),
 		BazarID:         bazarItem.ID.String(),
 		ProductID:       freeSaleProduct.ID.String(),
@@ -257,6 +275,71 @@ func TestManualProductSaleLifecycleIntegration(t *testing.T) {
 	if adjustedProduct.Stock != 15 {
 		t.Fatalf("expected stock 15 after adjustment, got %d", adjustedProduct.Stock)
 	}
+	locations, err := service.TransferStock(
+		ctx,
+		organizationID.String(),
+		bazarItem.ID,
+		userID,
+		"Integration Admin",
+		StockTransferRequest{
+			Direction: StockToBazar,
+			Items:     []StockTransferItemRequest{{ProductID: product.ID.String(), Quantity: 2}},
+		},
+	)
+	if err != nil {
+		t.Fatalf("transfer stock to bazar: %v", err)
+	}
+	if len(locations) != 1 || locations[0].WarehouseStock != 10 || len(locations[0].Locations) != 1 ||
+		locations[0].Locations[0].Quantity != 5 {
+		t.Fatalf("unexpected stock locations after transfer: %#v", locations)
+	}
+	_, err = service.TransferStock(
+		ctx,
+		organizationID.String(),
+		bazarItem.ID,
+		userID,
+		"Integration Admin",
+		StockTransferRequest{
+			Direction: StockToBazar,
+			Items:     []StockTransferItemRequest{{ProductID: product.ID.String(), Quantity: 11}},
+		},
+	)
+	var transferError *serviceError
+	if !errors.As(err, &transferError) || transferError.Status != http.StatusConflict {
+		t.Fatalf("expected warehouse stock conflict, got %v", err)
+	}
+
+	standProduct, err := service.CreateProduct(ctx, organizationID.String(), userID, "Integration Admin", CreateProductRequest{
+		SKU:   "TEST-STAND-01",
+		Name:  "Producto en el puesto",
+		Price: 20,
+		Stock: 6,
+	})
+	if err != nil {
+		t.Fatalf("create stand product: %v", err)
+	}
+	_, err = service.TransferStock(ctx, organizationID.String(), bazarItem.ID, userID, "Integration Admin", StockTransferRequest{
+		Direction: StockToBazar,
+		Items:     []StockTransferItemRequest{{ProductID: standProduct.ID.String(), Quantity: 4}},
+	})
+	if err != nil {
+		t.Fatalf("transfer stand product: %v", err)
+	}
+	err = repository.UpsertSheetProducts(ctx, organizationID.String(), []sheetProduct{{
+		ExternalID: standProduct.ExternalID,
+		Name:       standProduct.Name,
+		Price:      standProduct.Price,
+		Stock:      1,
+		Active:     true,
+		SheetRow:   4,
+	}}, "use_sheet")
+	if err != nil {
+		t.Fatalf("import stock below stand allocation: %v", err)
+	}
+	standProduct, err = repository.GetProduct(ctx, organizationID.String(), standProduct.ID)
+	if err != nil || standProduct == nil || standProduct.Stock != 4 {
+		t.Fatalf("sheet import must keep the stand allocation, got %#v, err=%v", standProduct, err)
+	}
 	err = repository.UpsertSheetProducts(ctx, organizationID.String(), []sheetProduct{{
 		ExternalID: product.ExternalID,
 		Name:       "Nombre incompleto de Sheets",
@@ -319,8 +402,9 @@ func TestManualProductSaleLifecycleIntegration(t *testing.T) {
 	}
 
 	cashReceived := 200.0
-	cartSale, err := service.CreateSale(ctx, organizationID.String(), userID, "Integration Admin", CreateSaleRequest{
-		ClientRequestID: uuid.NewString(),
+	cartRequestID := uuid.NewString()
+	cartSale, err := service.CreateSale(ctx, organizationID.String(), userID, "Integration Admin", true, CreateSaleRequest{
+		ClientRequestID: cartRequestID,
 		BazarID:         bazarItem.ID.String(),
 		Items: []CreateSaleItemRequest{
 			{ProductID: product.ID.String(), Quantity: 2},
@@ -399,6 +483,31 @@ func TestManualProductSaleLifecycleIntegration(t *testing.T) {
 		t.Fatalf("unexpected daily cuts: %#v, err=%v", cuts, err)
 	}
 
+	// Un reenvío del POS después del corte devuelve la venta ya guardada; una
+	// venta nueva para ese día se rechaza.
+	soldToday := time.Now()
+	resent, err := service.CreateSale(ctx, organizationID.String(), userID, "Integration Admin", true, CreateSaleRequest{
+		ClientRequestID: cartRequestID,
+		BazarID:         bazarItem.ID.String(),
+		Items:           []CreateSaleItemRequest{{ProductID: secondProduct.ID.String(), Quantity: 1}},
+		PaymentMethod:   PaymentCash,
+		SoldAt:          &soldToday,
+	})
+	if err != nil || !resent.Duplicated || resent.Sale.ID != cartSale.Sale.ID {
+		t.Fatalf("expected resent sale to be reported as duplicated, got %#v, err=%v", resent, err)
+	}
+	_, err = service.CreateSale(ctx, organizationID.String(), userID, "Integration Admin", true, CreateSaleRequest{
+		ClientRequestID: uuid.NewString(),
+		BazarID:         bazarItem.ID.String(),
+		Items:           []CreateSaleItemRequest{{ProductID: secondProduct.ID.String(), Quantity: 1}},
+		PaymentMethod:   PaymentCash,
+		SoldAt:          &soldToday,
+	})
+	var cutClosedError *serviceError
+	if !errors.As(err, &cutClosedError) || cutClosedError.Code != "daily_cut_closed" {
+		t.Fatalf("expected daily cut conflict, got %v", err)
+	}
+
 	closed, err := service.CloseBazar(
 		ctx,
 		organizationID.String(),
@@ -422,8 +531,26 @@ func TestManualProductSaleLifecycleIntegration(t *testing.T) {
 		finalReport.ClosingCash == nil || *finalReport.ClosingCash != 210 {
 		t.Fatalf("unexpected final report: %#v", finalReport)
 	}
+	if finalReport.Profitability == nil ||
+		finalReport.Profitability.Revenue != finalReport.Total-finalReport.Refunds ||
+		finalReport.Profitability.ProductsWithoutCost == 0 {
+		t.Fatalf("unexpected profitability: %#v", finalReport.Profitability)
+	}
+	// Lo que quedó en el puesto (3 capibaras café) regresa al almacén.
+	if finalReport.StockSent != 2 || finalReport.StockReturned != 3 || len(finalReport.StandStock) != 0 {
+		t.Fatalf("unexpected stand stock in final report: %#v", finalReport)
+	}
+	locations, err = service.ListStockLocations(ctx, organizationID.String(), nil)
+	if err != nil {
+		t.Fatalf("list stock locations: %v", err)
+	}
+	for _, item := range locations {
+		if len(item.Locations) != 0 || item.WarehouseStock != item.TotalStock {
+			t.Fatalf("stand stock was not returned on close: %#v", item)
+		}
+	}
 
-	_, err = service.CreateSale(ctx, organizationID.String(), userID, "Integration Admin", CreateSaleRequest{
+	_, err = service.CreateSale(ctx, organizationID.String(), userID, "Integration Admin", true, CreateSaleRequest{
 		ClientRequestID: uuid.NewString(),
 		BazarID:         bazarItem.ID.String(),
 		ProductID:       secondProduct.ID.String(),
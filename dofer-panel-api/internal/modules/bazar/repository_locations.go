@@ -0,0 +1,426 @@
+package bazar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// standStock es lo asignado a los puestos de un grupo de productos, leído con
+// sus filas bloqueadas. allocated suma todos los puestos y stand solo trae los
+// productos que tienen fila en el bazar consultado.
+type standStock struct {
+	allocated map[uuid.UUID]int
+	stand     map[uuid.UUID]int
+}
+
+// warehouse es lo que queda en el almacén de un producto con total stock.
+func (s standStock) warehouse(productID uuid.UUID, stock int) int {
+	available := stock - s.allocated[productID]
+	if available < 0 {
+		return 0
+	}
+	return available
+}
+
+// lockStandStock bloquea las filas de ubicación de los productos. Se llama
+// después de bloquear los productos para respetar el orden de CreateSale.
+func lockStandStock(
+	ctx context.Context,
+	tx pgx.Tx,
+	organizationID string,
+	bazarID uuid.UUID,
+	productIDs []uuid.UUID,
+) (standStock, error) {
+	result := standStock{allocated: make(map[uuid.UUID]int), stand: make(map[uuid.UUID]int)}
+	rows, err := tx.Query(ctx, `
+		SELECT product_id, bazar_id, quantity
+		FROM bazar_stock_locations
+		WHERE organization_id = $1 AND product_id = ANY($2)
+		ORDER BY product_id, bazar_id
+		FOR UPDATE
+	`, organizationID, productIDs)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var productID, locationBazarID uuid.UUID
+		var quantity int
+		if err := rows.Scan(&productID, &locationBazarID, &quantity); err != nil {
+			return result, err
+		}
+		result.allocated[productID] += quantity
+		if locationBazarID == bazarID {
+			result.stand[productID] = quantity
+		}
+	}
+	return result, rows.Err()
+}
+
+func setStandStock(
+	ctx context.Context,
+	tx pgx.Tx,
+	organizationID string,
+	bazarID, productID uuid.UUID,
+	quantity int,
+) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO bazar_stock_locations (organization_id, product_id, bazar_id, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (product_id, bazar_id) DO UPDATE SET quantity = EXCLUDED.quantity
+	`, organizationID, productID, bazarID, quantity)
+	return err
+}
+
+// returnToStand regresa piezas canceladas o devueltas al puesto del que
+// salieron mientras el bazar siga abierto. Si salieron del almacén o el bazar
+// ya cerró, se quedan en el almacén y no devuelve nada.
+func returnToStand(
+	ctx context.Context,
+	tx pgx.Tx,
+	organizationID string,
+	bazarID, productID uuid.UUID,
+	quantity int,
+	fromStand bool,
+) (*int, *int, error) {
+	if !fromStand {
+		return nil, nil, nil
+	}
+	var after int
+	err := tx.QueryRow(ctx, `
+		INSERT INTO bazar_stock_locations (organization_id, product_id, bazar_id, quantity)
+		SELECT $1, $2, b.id, $4
+		FROM bazaars b
+		WHERE b.id = $3 AND b.organization_id = $1 AND b.status = 'active'
+		ON CONFLICT (product_id, bazar_id)
+		DO UPDATE SET quantity = bazar_stock_locations.quantity + EXCLUDED.quantity
+		RETURNING quantity
+	`, organizationID, productID, bazarID, quantity).Scan(&after)
+	if err == pgx.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	before := after - quantity
+	return &before, &after, nil
+}
+
+// TransferStock mueve piezas entre el almacén y el puesto de un bazar abierto.
+// El stock total de cada producto no cambia.
+func (r *Repository) TransferStock(
+	ctx context.Context,
+	organizationID string,
+	cmd stockTransferCommand,
+) ([]ProductStockLocations, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	err = tx.QueryRow(ctx, `
+		SELECT status FROM bazaars
+		WHERE id = $1 AND organization_id = $2
+		FOR UPDATE
+	`, cmd.BazarID, organizationID).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return nil, &serviceError{Status: http.StatusNotFound, Message: "Bazar no encontrado."}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != "active" {
+		return nil, &serviceError{Status: http.StatusConflict, Message: "La sesión del bazar está cerrada."}
+	}
+
+	items := append([]stockTransferItemCommand(nil), cmd.Items...)
+	sort.Slice(items, func(i, j int) bool { return items[i].ProductID.String() < items[j].ProductID.String() })
+	productIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	type transferProduct struct {
+		Name       string
+		Stock      int
+		TrackStock bool
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT id, name, stock, track_stock
+		FROM products
+		WHERE id = ANY($1) AND organization_id = $2 AND bazar_enabled = TRUE
+		ORDER BY id
+		FOR UPDATE
+	`, productIDs, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	products := make(map[uuid.UUID]transferProduct, len(items))
+	for rows.Next() {
+		var id uuid.UUID
+		var product transferProduct
+		if err := rows.Scan(&id, &product.Name, &product.Stock, &product.TrackStock); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		products[id] = product
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	locations, err := lockStandStock(ctx, tx, organizationID, cmd.BazarID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		product, found := products[item.ProductID]
+		if !found {
+			return nil, &serviceError{Status: http.StatusNotFound, Message: "Producto no encontrado."}
+		}
+		if !product.TrackStock {
+			return nil, &serviceError{
+				Status:  http.StatusConflict,
+				Message: product.Name + " no lleva control de inventario.",
+			}
+		}
+
+		standBefore := locations.stand[item.ProductID]
+		standAfter := standBefore
+		movementType := "transfer_to_bazar"
+		delta := item.Quantity
+		if cmd.Direction == StockToBazar {
+			if available := locations.warehouse(item.ProductID, product.Stock); item.Quantity > available {
+				return nil, &serviceError{
+					Status:  http.StatusConflict,
+					Message: fmt.Sprintf("Stock insuficiente en almacén para %s. Disponibles: %d.", product.Name, available),
+				}
+			}
+			standAfter += item.Quantity
+		} else {
+			if item.Quantity > standBefore {
+				return nil, &serviceError{
+					Status:  http.StatusConflict,
+					Message: fmt.Sprintf("Solo hay %d de %s en el bazar.", standBefore, product.Name),
+				}
+			}
+			standAfter -= item.Quantity
+			movementType = "transfer_to_warehouse"
+			delta = -item.Quantity
+		}
+
+		if err := setStandStock(ctx, tx, organizationID, cmd.BazarID, item.ProductID, standAfter); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO bazar_inventory_movements (
+				organization_id, product_id, bazar_id, movement_type, quantity,
+				stock_before, stock_after, stand_stock_before, stand_stock_after,
+				reason, created_by
+			) VALUES ($1, $2, $3, $4, $5, $6, $6, $7, $8, $9, $10)
+		`,
+			organizationID,
+			item.ProductID,
+			cmd.BazarID,
+			movementType,
+			delta,
+			product.Stock,
+			standBefore,
+			standAfter,
+			cmd.Reason,
+			cmd.ActorID,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.ListStockLocations(ctx, organizationID, &cmd.BazarID)
+}
+
+// returnStandStock regresa al almacén todo lo que queda en el puesto de un
+// bazar que se está cerrando y devuelve cuántas piezas movió.
+func returnStandStock(
+	ctx context.Context,
+	tx pgx.Tx,
+	organizationID string,
+	bazarID, userID uuid.UUID,
+) (int, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT l.product_id, l.quantity, p.stock
+		FROM bazar_stock_locations l
+		JOIN products p ON p.id = l.product_id
+		WHERE l.organization_id = $1 AND l.bazar_id = $2
+		ORDER BY l.product_id
+		FOR UPDATE OF l
+	`, organizationID, bazarID)
+	if err != nil {
+		return 0, err
+	}
+	type standItem struct {
+		ProductID uuid.UUID
+		Quantity  int
+		Stock     int
+	}
+	items := make([]standItem, 0)
+	for rows.Next() {
+		var item standItem
+		if err := rows.Scan(&item.ProductID, &item.Quantity, &item.Stock); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	returned := 0
+	for _, item := range items {
+		if item.Quantity == 0 {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO bazar_inventory_movements (
+				organization_id, product_id, bazar_id, movement_type, quantity,
+				stock_before, stock_after, stand_stock_before, stand_stock_after,
+				reason, created_by
+			) VALUES ($1, $2, $3, 'transfer_to_warehouse', $4, $5, $5, $6, 0, 'Cierre de bazar', $7)
+		`, organizationID, item.ProductID, bazarID, -item.Quantity, item.Stock, item.Quantity, userID); err != nil {
+			return 0, err
+		}
+		returned += item.Quantity
+	}
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM bazar_stock_locations
+		WHERE organization_id = $1 AND bazar_id = $2
+	`, organizationID, bazarID); err != nil {
+		return 0, err
+	}
+	return returned, nil
+}
+
+// ListStockLocations reparte el stock de los productos con inventario entre
+// el almacén y los puestos. Con bazarID solo devuelve los productos que tienen
+// lugar en ese puesto.
+func (r *Repository) ListStockLocations(
+	ctx context.Context,
+	organizationID string,
+	bazarID *uuid.UUID,
+) ([]ProductStockLocations, error) {
+	query := `
+		SELECT p.id, p.sku, p.name, p.stock, l.bazar_id, b.name, l.quantity
+		FROM products p
+		LEFT JOIN bazar_stock_locations l ON l.product_id = p.id AND l.organization_id = p.organization_id
+		LEFT JOIN bazaars b ON b.id = l.bazar_id
+		WHERE p.organization_id = $1 AND p.bazar_enabled = TRUE AND p.track_stock = TRUE
+	`
+	args := []any{organizationID}
+	if bazarID != nil {
+		args = append(args, *bazarID)
+		query += fmt.Sprintf(` AND EXISTS (
+			SELECT 1 FROM bazar_stock_locations f
+			WHERE f.product_id = p.id AND f.bazar_id = $%d
+		)`, len(args))
+	}
+	query += " ORDER BY COALESCE(p.category, ''), p.name, p.id, b.name"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]ProductStockLocations, 0)
+	for rows.Next() {
+		var productID uuid.UUID
+		var externalID, name string
+		var stock int
+		var locationBazarID uuid.NullUUID
+		var bazarName *string
+		var quantity *int
+		if err := rows.Scan(&productID, &externalID, &name, &stock, &locationBazarID, &bazarName, &quantity); err != nil {
+			return nil, err
+		}
+		if len(items) == 0 || items[len(items)-1].ProductID != productID {
+			items = append(items, ProductStockLocations{
+				ProductID:      productID,
+				ExternalID:     externalID,
+				ProductName:    name,
+				TotalStock:     stock,
+				WarehouseStock: stock,
+				Locations:      make([]StockLocation, 0),
+			})
+		}
+		if !locationBazarID.Valid || quantity == nil {
+			continue
+		}
+		item := &items[len(items)-1]
+		location := StockLocation{BazarID: locationBazarID.UUID, Quantity: *quantity}
+		if bazarName != nil {
+			location.BazarName = *bazarName
+		}
+		item.Locations = append(item.Locations, location)
+		item.WarehouseStock -= *quantity
+	}
+	for index := range items {
+		if items[index].WarehouseStock < 0 {
+			items[index].WarehouseStock = 0
+		}
+	}
+	return items, rows.Err()
+}
+
+// standStockReport completa el reporte de un bazar con sus traspasos del
+// periodo y lo que hay ahora en su puesto.
+func (r *Repository) standStockReport(
+	ctx context.Context,
+	organizationID string,
+	bazarID uuid.UUID,
+	from, to time.Time,
+	report *BazarReport,
+) error {
+	if err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(quantity) FILTER (WHERE movement_type = 'transfer_to_bazar'), 0),
+		       COALESCE(-SUM(quantity) FILTER (WHERE movement_type = 'transfer_to_warehouse'), 0)
+		FROM bazar_inventory_movements
+		WHERE organization_id = $1 AND bazar_id = $2
+		  AND created_at >= $3 AND created_at < $4
+	`, organizationID, bazarID, from, to).Scan(&report.StockSent, &report.StockReturned); err != nil {
+		return err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT l.product_id, p.sku, p.name, l.quantity
+		FROM bazar_stock_locations l
+		JOIN products p ON p.id = l.product_id
+		WHERE l.organization_id = $1 AND l.bazar_id = $2
+		ORDER BY p.name
+	`, organizationID, bazarID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	report.StandStock = make([]StandStockItem, 0)
+	for rows.Next() {
+		var item StandStockItem
+		if err := rows.Scan(&item.ProductID, &item.ExternalID, &item.ProductName, &item.Quantity); err != nil {
+			return err
+		}
+		report.StandStock = append(report.StandStock, item)
+	}
+	return rows.Err()
+}
@@ -78,6 +78,25 @@ func (r *Repository) AdjustStock(
 	}
 	defer tx.Rollback(ctx)
 
+	// Con un bazar abierto el ajuste se hace en su puesto (mermas o piezas
+	// contadas ahí); sin bazar, o con uno ya cerrado, se hace en el almacén.
+	atStand := false
+	if command.BazarID != nil {
+		var status string
+		err := tx.QueryRow(ctx, `
+			SELECT status FROM bazaars
+			WHERE id = $1 AND organization_id = $2
+			FOR UPDATE
+		`, *command.BazarID, organizationID).Scan(&status)
+		if err == pgx.ErrNoRows {
+			return nil, &serviceError{Status: http.StatusNotFound, Message: "Bazar no encontrado."}
+		}
+		if err != nil {
+			return nil, err
+		}
+		atStand = status == "active"
+	}
+
 	var stockBefore int
 	var productName string
 	err = tx.QueryRow(ctx, `
@@ -101,6 +120,35 @@ func (r *Repository) AdjustStock(
 		}
 	}
 
+	locationBazarID := uuid.Nil
+	if command.BazarID != nil {
+		locationBazarID = *command.BazarID
+	}
+	locations, err := lockStandStock(ctx, tx, organizationID, locationBazarID, []uuid.UUID{command.ProductID})
+	if err != nil {
+		return nil, err
+	}
+	var standBefore, standAfter *int
+	if atStand {
+		before := locations.stand[command.ProductID]
+		after := before + command.Delta
+		if after < 0 {
+			return nil, &serviceError{
+				Status:  http.StatusConflict,
+				Message: fmt.Sprintf("Solo hay %d de %s en el bazar.", before, productName),
+			}
+		}
+		if err := setStandStock(ctx, tx, organizationID, *command.BazarID, command.ProductID, after); err != nil {
+			return nil, err
+		}
+		standBefore, standAfter = &before, &after
+	} else if available := locations.warehouse(command.ProductID, stockBefore); -command.Delta > available {
+		return nil, &serviceError{
+			Status:  http.StatusConflict,
+			Message: fmt.Sprintf("Stock insuficiente en almacén para %s. Disponibles: %d.", productName, available),
+		}
+	}
+
 	if _, err := tx.Exec(ctx, `
 		UPDATE products
 		SET stock = $1, track_stock = TRUE, stock_sync_policy = 'manual', updated_at = NOW()
@@ -112,8 +160,9 @@ func (r *Repository) AdjustStock(
 	if _, err := tx.Exec(ctx, `
 		INSERT INTO bazar_inventory_movements (
 			organization_id, product_id, bazar_id, movement_type, quantity,
-			stock_before, stock_after, reason, created_by
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			stock_before, stock_after, stand_stock_before, stand_stock_after,
+			reason, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`,
 		organizationID,
 		command.ProductID,
@@ -122,6 +171,8 @@ func (r *Repository) AdjustStock(
 		command.Delta,
 		stockBefore,
 		stockAfter,
+		standBefore,
+		standAfter,
 		command.Reason,
 		command.ActorID,
 	); err != nil {
@@ -146,7 +197,8 @@ func (r *Repository) ListInventoryMovements(
 
 	query := `
 		SELECT m.id, m.product_id, p.name, m.bazar_id, b.name, m.movement_type,
-		       m.quantity, m.stock_before, m.stock_after, m.reason,
+		       m.quantity, m.stock_before, m.stock_after, m.stand_stock_before,
+		       m.stand_stock_after, m.reason,
 		       COALESCE(u.full_name, u.email, 'Sistema'), m.created_at
 		FROM bazar_inventory_movements m
 		JOIN products p ON p.id = m.product_id
@@ -187,6 +239,8 @@ func (r *Repository) ListInventoryMovements(
 			&item.Quantity,
 			&item.StockBefore,
 			&item.StockAfter,
+			&item.StandStockBefore,
+			&item.StandStockAfter,
 			&reason,
 			&item.ActorName,
 			&item.CreatedAt,
@@ -227,10 +281,10 @@ func (r *Repository) CloseBazar(
 	bazarID, userID uuid.UUID,
 	closingCash float64,
 	notes *string,
-) (*Bazar, error) {
+) (*Bazar, int, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer tx.Rollback(ctx)
 
@@ -243,13 +297,13 @@ func (r *Repository) CloseBazar(
 		FOR UPDATE
 	`, bazarID, organizationID).Scan(&status, &openingCash)
 	if err == pgx.ErrNoRows {
-		return nil, &serviceError{Status: http.StatusNotFound, Message: "Bazar no encontrado."}
+		return nil, 0, &serviceError{Status: http.StatusNotFound, Message: "Bazar no encontrado."}
 	}
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	if status != "active" {
-		return nil, &serviceError{Status: http.StatusConflict, Message: "El bazar ya está cerrado."}
+		return nil, 0, &serviceError{Status: http.StatusConflict, Message: "El bazar ya está cerrado."}
 	}
 
 	var cutCount int
@@ -265,21 +319,32 @@ func (r *Repository) CloseBazar(
 		&finalClosingCash,
 		&difference,
 	); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	if cutCount == 0 {
 		var cashSales float64
 		if err := tx.QueryRow(ctx, `
-			SELECT COALESCE(SUM(total), 0)
-			FROM bazar_sales
+			SELECT COALESCE(SUM(p.amount), 0)
+			FROM bazar_sale_payments p
+			JOIN bazar_sales s ON s.id = p.sale_id
+			WHERE s.organization_id = $1
+			  AND s.bazar_id = $2
+			  AND s.status = 'completed'
+			  AND p.method = 'cash'
+		`, organizationID, bazarID).Scan(&cashSales); err != nil {
+			return nil, 0, err
+		}
+		var cashRefunds float64
+		if err := tx.QueryRow(ctx, `
+			SELECT COALESCE(SUM(refund_amount), 0)
+			FROM bazar_sale_returns
 			WHERE organization_id = $1
 			  AND bazar_id = $2
-			  AND status = 'completed'
-			  AND payment_method = 'cash'
-		`, organizationID, bazarID).Scan(&cashSales); err != nil {
-			return nil, err
+			  AND refund_method = 'cash'
+		`, organizationID, bazarID).Scan(&cashRefunds); err != nil {
+			return nil, 0, err
 		}
-		expectedCash = openingCash + cashSales
+		expectedCash = openingCash + cashSales - cashRefunds
 		finalClosingCash = closingCash
 		difference = closingCash - expectedCash
 	}
@@ -297,12 +362,17 @@ func (r *Repository) CloseBazar(
 		WHERE id = $6 AND organization_id = $7
 	`, expectedCash, finalClosingCash, difference, notes, userID, bazarID, organizationID)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	stockReturned, err := returnStandStock(ctx, tx, organizationID, bazarID, userID)
+	if err != nil {
+		return nil, 0, err
 	}
 	if err := tx.Commit(ctx); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return r.GetBazar(ctx, organizationID, bazarID)
+	closed, err := r.GetBazar(ctx, organizationID, bazarID)
+	return closed, stockReturned, err
 }
 
 func (r *Repository) GetReport(
@@ -341,11 +411,16 @@ func (r *Repository) GetReport(
 		}
 		report.ClosingCash = bazarItem.ClosingCash
 		report.CashDifference = bazarItem.CashDifference
+		if err := r.standStockReport(ctx, organizationID, *bazarID, from, to, report); err != nil {
+			return nil, err
+		}
 	}
 
 	summaryQuery := `
 		SELECT
 			COALESCE(SUM(s.total) FILTER (WHERE s.status = 'completed'), 0),
+			COALESCE(SUM(s.subtotal) FILTER (WHERE s.status = 'completed'), 0),
+			COALESCE(SUM(s.discount_total) FILTER (WHERE s.status = 'completed'), 0),
 			COUNT(*) FILTER (WHERE s.status = 'completed'),
 			COUNT(*) FILTER (WHERE s.status = 'cancelled')
 		FROM bazar_sales s
@@ -353,34 +428,61 @@ func (r *Repository) GetReport(
 	` + filter
 	if err := r.db.QueryRow(ctx, summaryQuery, args...).Scan(
 		&report.Total,
+		&report.Subtotal,
+		&report.Discounts,
 		&report.Operations,
 		&report.CancelledSales,
 	); err != nil {
 		return nil, err
 	}
 
+	returnFilter := strings.ReplaceAll(filter, "s.bazar_id", "sr.bazar_id")
+	returnsQuery := `
+		SELECT COUNT(*),
+		       COALESCE(SUM(sr.refund_amount), 0),
+		       COALESCE(SUM(sr.refund_amount) FILTER (WHERE sr.refund_method = 'cash'), 0)
+		FROM bazar_sale_returns sr
+		WHERE sr.organization_id = $1 AND sr.returned_at >= $2 AND sr.returned_at < $3
+	` + returnFilter
+	if err := r.db.QueryRow(ctx, returnsQuery, args...).Scan(
+		&report.Returns,
+		&report.Refunds,
+		&report.CashRefunds,
+	); err != nil {
+		return nil, err
+	}
+	if !useStoredExpectedCash {
+		report.ExpectedCash -= report.CashRefunds
+	}
+
 	productQuantityQuery := `
-		SELECT COALESCE(SUM(i.quantity), 0)
+		SELECT COALESCE(SUM(i.quantity), 0), COALESCE(SUM(i.promotion_discount), 0)
 		FROM bazar_sale_items i
 		JOIN bazar_sales s ON s.id = i.sale_id
 		WHERE s.organization_id = $1 AND s.sold_at >= $2 AND s.sold_at < $3
 		  AND s.status = 'completed'
 	` + filter
-	if err := r.db.QueryRow(ctx, productQuantityQuery, args...).Scan(&report.ProductsSold); err != nil {
+	if err := r.db.QueryRow(ctx, productQuantityQuery, args...).Scan(
+		&report.ProductsSold,
+		&report.PromotionDiscounts,
+	); err != nil {
 		return nil, err
 	}
 	if report.Operations > 0 {
 		report.AverageTicket = report.Total / float64(report.Operations)
 	}
 
+	// Los métodos salen del desglose de pagos: una venta combinada cuenta como
+	// operación en cada método con la parte que se cobró en él.
 	paymentRows, err := r.db.Query(ctx, `
-		SELECT s.payment_method, COUNT(*), COALESCE(SUM(s.total), 0)
-		FROM bazar_sales s
+		SELECT p.method, COUNT(DISTINCT s.id), COALESCE(SUM(p.amount), 0)
+		FROM bazar_sale_payments p
+		JOIN bazar_sales s ON s.id = p.sale_id
 		WHERE s.organization_id = $1 AND s.sold_at >= $2 AND s.sold_at < $3
 		  AND s.status = 'completed'
 	`+filter+`
-		GROUP BY s.payment_method
-		ORDER BY SUM(s.total) DESC
+		GROUP BY p.method
+		ORDER BY SUM(p.amount) DESC
 	`, args...)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,394 @@
+package bazar
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const expenseColumns = `
+	id, bazar_id, category, description, amount, spent_at, finance_expense_id,
+	created_by_name, created_at
+`
+
+// financeExpenseCategories traduce las categorías del bazar a las de
+// finanzas.
+var financeExpenseCategories = map[string]string{
+	ExpenseStandFee:    "renta",
+	ExpenseTransport:   "otros",
+	ExpenseStaff:       "otros",
+	ExpenseSupplies:    "empaque",
+	ExpenseAdvertising: "publicidad",
+	ExpenseOther:       "otros",
+}
+
+func (r *Repository) ListBazarExpenses(ctx context.Context, organizationID string, bazarID uuid.UUID) ([]BazarExpense, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+expenseColumns+`
+		FROM bazar_expenses
+		WHERE organization_id = $1 AND bazar_id = $2
+		ORDER BY spent_at, created_at
+	`, organizationID, bazarID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	expenses := make([]BazarExpense, 0)
+	for rows.Next() {
+		expense, err := scanBazarExpense(rows)
+		if err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, *expense)
+	}
+	return expenses, rows.Err()
+}
+
+// CreateBazarExpense registra un gasto mientras el bazar no se haya pasado a
+// finanzas.
+func (r *Repository) CreateBazarExpense(
+	ctx context.Context,
+	organizationID string,
+	actorID uuid.UUID,
+	actorName string,
+	expense BazarExpense,
+) (*BazarExpense, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockUnpostedBazar(ctx, tx, organizationID, expense.BazarID); err != nil {
+		return nil, err
+	}
+	created, err := scanBazarExpense(tx.QueryRow(ctx, `
+		INSERT INTO bazar_expenses (
+			organization_id, bazar_id, category, description, amount, spent_at,
+			created_by, created_by_name
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+expenseColumns,
+		organizationID,
+		expense.BazarID,
+		expense.Category,
+		expense.Description,
+		expense.Amount,
+		expense.SpentAt,
+		actorID,
+		actorName,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit(ctx)
+}
+
+// DeleteBazarExpense borra un gasto; devuelve nil si no existe.
+func (r *Repository) DeleteBazarExpense(ctx context.Context, organizationID string, expenseID uuid.UUID) (*BazarExpense, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var bazarID uuid.UUID
+	err = tx.QueryRow(ctx, `
+		SELECT bazar_id FROM bazar_expenses
+		WHERE id = $1 AND organization_id = $2
+	`, expenseID, organizationID).Scan(&bazarID)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := lockUnpostedBazar(ctx, tx, organizationID, bazarID); err != nil {
+		return nil, err
+	}
+	deleted, err := scanBazarExpense(tx.QueryRow(ctx, `
+		DELETE FROM bazar_expenses
+		WHERE id = $1 AND organization_id = $2
+		RETURNING `+expenseColumns,
+		expenseID,
+		organizationID,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return deleted, tx.Commit(ctx)
+}
+
+func lockUnpostedBazar(ctx context.Context, tx pgx.Tx, organizationID string, bazarID uuid.UUID) error {
+	var postedAt sql.NullTime
+	err := tx.QueryRow(ctx, `
+		SELECT finance_posted_at FROM bazaars
+		WHERE id = $1 AND organization_id = $2
+		FOR UPDATE
+	`, bazarID, organizationID).Scan(&postedAt)
+	if err == pgx.ErrNoRows {
+		return &serviceError{Status: http.StatusNotFound, Message: "Bazar no encontrado."}
+	}
+	if err != nil {
+		return err
+	}
+	if postedAt.Valid {
+		return &serviceError{Status: http.StatusConflict, Message: "El bazar ya se pasó a finanzas; sus gastos no se pueden cambiar."}
+	}
+	return nil
+}
+
+// profitabilityReport agrega al reporte final la utilidad del bazar. Las
+// piezas devueltas no cuentan ni en el ingreso ni en el costo del producto.
+func (r *Repository) profitabilityReport(
+	ctx context.Context,
+	organizationID string,
+	bazarID uuid.UUID,
+	from, to time.Time,
+	report *BazarReport,
+) error {
+	rows, err := r.db.Query(ctx, `
+		SELECT i.product_id, i.product_external_id, i.product_name,
+		       COALESCE(NULLIF(TRIM(p.category), ''), 'Sin categoría'),
+		       SUM(i.quantity - i.returned_quantity),
+		       COALESCE(SUM(i.total * (i.quantity - i.returned_quantity) / i.quantity), 0),
+		       COALESCE(SUM(i.unit_cost * (i.quantity - i.returned_quantity)), 0),
+		       BOOL_OR(i.unit_cost IS NULL)
+		FROM bazar_sale_items i
+		JOIN bazar_sales s ON s.id = i.sale_id
+		LEFT JOIN products p ON p.id = i.product_id
+		WHERE s.organization_id = $1 AND s.bazar_id = $2
+		  AND s.sold_at >= $3 AND s.sold_at < $4
+		  AND s.status = 'completed'
+		GROUP BY i.product_id, i.product_external_id, i.product_name, p.category
+		HAVING SUM(i.quantity - i.returned_quantity) > 0
+		ORDER BY SUM(i.total * (i.quantity - i.returned_quantity) / i.quantity) DESC, i.product_name
+	`, organizationID, bazarID, from, to)
+	if err != nil {
+		return err
+	}
+	products := make([]ProductProfit, 0)
+	for rows.Next() {
+		var item ProductProfit
+		if err := rows.Scan(
+			&item.ProductID,
+			&item.ExternalID,
+			&item.ProductName,
+			&item.Category,
+			&item.Quantity,
+			&item.Revenue,
+			&item.Cost,
+			&item.CostMissing,
+		); err != nil {
+			rows.Close()
+			return err
+		}
+		products = append(products, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	expenses, err := r.ListBazarExpenses(ctx, organizationID, bazarID)
+	if err != nil {
+		return err
+	}
+	profitability := buildProfitability(report.Total-report.Refunds, products, expenses)
+	var incomeID uuid.NullUUID
+	var postedAt sql.NullTime
+	if err := r.db.QueryRow(ctx, `
+		SELECT finance_income_id, finance_posted_at FROM bazaars
+		WHERE id = $1 AND organization_id = $2
+	`, bazarID, organizationID).Scan(&incomeID, &postedAt); err != nil {
+		return err
+	}
+	if incomeID.Valid {
+		profitability.FinanceIncomeID = &incomeID.UUID
+	}
+	if postedAt.Valid {
+		profitability.FinancePostedAt = &postedAt.Time
+	}
+	report.Profitability = profitability
+	return nil
+}
+
+// PostBazarToFinance registra el ingreso neto del bazar y sus gastos en
+// finanzas, una sola vez y con el bazar ya cerrado.
+func (r *Repository) PostBazarToFinance(
+	ctx context.Context,
+	organizationID string,
+	bazarID, actorID uuid.UUID,
+	profitability *BazarProfitability,
+	includeCostOfGoods bool,
+) (*FinancePostResult, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var name, status string
+	var endsAt, postedAt sql.NullTime
+	err = tx.QueryRow(ctx, `
+		SELECT name, status, ends_at, finance_posted_at FROM bazaars
+		WHERE id = $1 AND organization_id = $2
+		FOR UPDATE
+	`, bazarID, organizationID).Scan(&name, &status, &endsAt, &postedAt)
+	if err == pgx.ErrNoRows {
+		return nil, &serviceError{Status: http.StatusNotFound, Message: "Bazar no encontrado."}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != "closed" {
+		return nil, &serviceError{Status: http.StatusConflict, Message: "Cierra el bazar antes de pasarlo a finanzas."}
+	}
+	if postedAt.Valid {
+		return nil, &serviceError{Status: http.StatusConflict, Message: "El bazar ya se pasó a finanzas."}
+	}
+
+	postedDate := time.Now()
+	if endsAt.Valid {
+		postedDate = endsAt.Time
+	}
+	result := &FinancePostResult{ExpenseIDs: make([]uuid.UUID, 0), PostedAt: time.Now()}
+
+	// finance_external_incomes exige importes positivos: un bazar sin ventas
+	// netas solo pasa sus gastos.
+	if profitability.Revenue > 0 {
+		var incomeID uuid.UUID
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO finance_external_incomes (
+				organization_id, source, description, amount, income_date, notes, created_by
+			) VALUES ($1, 'ventas_locales', $2, $3, $4, $5, $6)
+			RETURNING id
+		`,
+			organizationID,
+			"Bazar "+name,
+			profitability.Revenue,
+			postedDate,
+			fmt.Sprintf(
+				"Ventas netas de reembolsos. Costo de mercancía %.2f, gastos %.2f, utilidad neta %.2f.",
+				profitability.CostOfGoods,
+				profitability.Expenses,
+				profitability.NetProfit,
+			),
+			actorID,
+		).Scan(&incomeID); err != nil {
+			return nil, err
+		}
+		result.IncomeID = &incomeID
+		result.Income = profitability.Revenue
+	}
+
+	// Los gastos se vuelven a leer con el bazar bloqueado para no dejar fuera
+	// uno capturado después de armar el reporte.
+	rows, err := tx.Query(ctx, `
+		SELECT `+expenseColumns+`
+		FROM bazar_expenses
+		WHERE organization_id = $1 AND bazar_id = $2
+		ORDER BY spent_at, created_at
+	`, organizationID, bazarID)
+	if err != nil {
+		return nil, err
+	}
+	expenses := make([]BazarExpense, 0)
+	for rows.Next() {
+		expense, err := scanBazarExpense(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		expenses = append(expenses, *expense)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, expense := range expenses {
+		var financeExpenseID uuid.UUID
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO finance_expenses (
+				organization_id, description, category, amount, expense_date, created_by
+			) VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`,
+			organizationID,
+			fmt.Sprintf("Bazar %s: %s", name, expense.Description),
+			financeExpenseCategories[expense.Category],
+			expense.Amount,
+			expense.SpentAt,
+			actorID,
+		).Scan(&financeExpenseID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE bazar_expenses SET finance_expense_id = $1
+			WHERE id = $2 AND organization_id = $3
+		`, financeExpenseID, expense.ID, organizationID); err != nil {
+			return nil, err
+		}
+		result.ExpenseIDs = append(result.ExpenseIDs, financeExpenseID)
+		result.Expenses += expense.Amount
+	}
+	if includeCostOfGoods && profitability.CostOfGoods > 0 {
+		var financeExpenseID uuid.UUID
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO finance_expenses (
+				organization_id, description, category, amount, expense_date, created_by
+			) VALUES ($1, $2, 'otros', $3, $4, $5)
+			RETURNING id
+		`,
+			organizationID,
+			"Bazar "+name+": costo de mercancía vendida",
+			profitability.CostOfGoods,
+			postedDate,
+			actorID,
+		).Scan(&financeExpenseID); err != nil {
+			return nil, err
+		}
+		result.ExpenseIDs = append(result.ExpenseIDs, financeExpenseID)
+		result.Expenses += profitability.CostOfGoods
+	}
+	result.Expenses = roundMoney(result.Expenses)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE bazaars
+		SET finance_income_id = $1, finance_posted_at = $2
+		WHERE id = $3 AND organization_id = $4
+	`, result.IncomeID, result.PostedAt, bazarID, organizationID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func scanBazarExpense(row pgx.Row) (*BazarExpense, error) {
+	var expense BazarExpense
+	var financeExpenseID uuid.NullUUID
+	if err := row.Scan(
+		&expense.ID,
+		&expense.BazarID,
+		&expense.Category,
+		&expense.Description,
+		&expense.Amount,
+		&expense.SpentAt,
+		&financeExpenseID,
+		&expense.CreatedByName,
+		&expense.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if financeExpenseID.Valid {
+		expense.FinanceExpenseID = &financeExpenseID.UUID
+	}
+	return &expense, nil
+}
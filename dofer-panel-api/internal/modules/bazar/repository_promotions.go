@@ -0,0 +1,204 @@
+package bazar
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const promotionColumns = `
+	id, bazar_id, name, kind, product_id, variant_group_id, category,
+	quantity, pay_quantity, bundle_price, discount_percent, active,
+	starts_at, ends_at, created_at, updated_at
+`
+
+func (r *Repository) ListPromotions(ctx context.Context, organizationID string, bazarID uuid.UUID) ([]Promotion, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+promotionColumns+`
+		FROM bazar_promotions
+		WHERE organization_id = $1 AND bazar_id = $2
+		ORDER BY active DESC, created_at
+	`, organizationID, bazarID)
+	if err != nil {
+		return nil, err
+	}
+	return collectPromotions(rows)
+}
+
+// listActivePromotions devuelve las promociones vigentes en at, en el orden
+// en que se evalúan.
+func listActivePromotions(
+	ctx context.Context,
+	tx pgx.Tx,
+	organizationID string,
+	bazarID uuid.UUID,
+	at time.Time,
+) ([]Promotion, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT `+promotionColumns+`
+		FROM bazar_promotions
+		WHERE organization_id = $1
+		  AND bazar_id = $2
+		  AND active = TRUE
+		  AND (starts_at IS NULL OR starts_at <= $3)
+		  AND (ends_at IS NULL OR ends_at > $3)
+		ORDER BY created_at, id
+	`, organizationID, bazarID, at)
+	if err != nil {
+		return nil, err
+	}
+	return collectPromotions(rows)
+}
+
+func (r *Repository) CreatePromotion(
+	ctx context.Context,
+	organizationID string,
+	actorID uuid.UUID,
+	promotion Promotion,
+) (*Promotion, error) {
+	row := r.db.QueryRow(ctx, `
+		INSERT INTO bazar_promotions (
+			organization_id, bazar_id, name, kind, product_id, variant_group_id,
+			category, quantity, pay_quantity, bundle_price, discount_percent,
+			active, starts_at, ends_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, 0), $9, $10, $11, $12, $13, $14, $15)
+		RETURNING `+promotionColumns,
+		organizationID,
+		promotion.BazarID,
+		promotion.Name,
+		promotion.Kind,
+		promotion.ProductID,
+		promotion.VariantGroupID,
+		promotion.Category,
+		promotion.Quantity,
+		promotionPayQuantity(promotion),
+		promotion.BundlePrice,
+		promotion.DiscountPercent,
+		promotion.Active,
+		promotion.StartsAt,
+		promotion.EndsAt,
+		actorID,
+	)
+	return scanPromotion(row)
+}
+
+// UpdatePromotion reemplaza la configuración; devuelve nil si no existe.
+func (r *Repository) UpdatePromotion(
+	ctx context.Context,
+	organizationID string,
+	promotionID uuid.UUID,
+	promotion Promotion,
+) (*Promotion, error) {
+	row := r.db.QueryRow(ctx, `
+		UPDATE bazar_promotions
+		SET name = $3,
+		    kind = $4,
+		    product_id = $5,
+		    variant_group_id = $6,
+		    category = $7,
+		    quantity = NULLIF($8, 0),
+		    pay_quantity = $9,
+		    bundle_price = $10,
+		    discount_percent = $11,
+		    active = $12,
+		    starts_at = $13,
+		    ends_at = $14
+		WHERE id = $1 AND organization_id = $2
+		RETURNING `+promotionColumns,
+		promotionID,
+		organizationID,
+		promotion.Name,
+		promotion.Kind,
+		promotion.ProductID,
+		promotion.VariantGroupID,
+		promotion.Category,
+		promotion.Quantity,
+		promotionPayQuantity(promotion),
+		promotion.BundlePrice,
+		promotion.DiscountPercent,
+		promotion.Active,
+		promotion.StartsAt,
+		promotion.EndsAt,
+	)
+	updated, err := scanPromotion(row)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return updated, err
+}
+
+func promotionPayQuantity(promotion Promotion) *int {
+	if promotion.Kind != PromotionBuyXPayY {
+		return nil
+	}
+	return &promotion.PayQuantity
+}
+
+func collectPromotions(rows pgx.Rows) ([]Promotion, error) {
+	defer rows.Close()
+	promotions := make([]Promotion, 0)
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, *promotion)
+	}
+	return promotions, rows.Err()
+}
+
+func scanPromotion(row pgx.Row) (*Promotion, error) {
+	var promotion Promotion
+	var productID, variantGroupID uuid.NullUUID
+	var category sql.NullString
+	var quantity, payQuantity sql.NullInt32
+	var bundlePrice, discountPercent sql.NullFloat64
+	var startsAt, endsAt sql.NullTime
+	if err := row.Scan(
+		&promotion.ID,
+		&promotion.BazarID,
+		&promotion.Name,
+		&promotion.Kind,
+		&productID,
+		&variantGroupID,
+		&category,
+		&quantity,
+		&payQuantity,
+		&bundlePrice,
+		&discountPercent,
+		&promotion.Active,
+		&startsAt,
+		&endsAt,
+		&promotion.CreatedAt,
+		&promotion.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if productID.Valid {
+		promotion.ProductID = &productID.UUID
+	}
+	if variantGroupID.Valid {
+		promotion.VariantGroupID = &variantGroupID.UUID
+	}
+	if category.Valid {
+		promotion.Category = &category.String
+	}
+	promotion.Quantity = int(quantity.Int32)
+	promotion.PayQuantity = int(payQuantity.Int32)
+	if bundlePrice.Valid {
+		promotion.BundlePrice = &bundlePrice.Float64
+	}
+	if discountPercent.Valid {
+		promotion.DiscountPercent = &discountPercent.Float64
+	}
+	if startsAt.Valid {
+		promotion.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		promotion.EndsAt = &endsAt.Time
+	}
+	return &promotion, nil
+}
@@ -0,0 +1,440 @@
+package bazar
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateSaleReturn registra una devolución: regresa las piezas al inventario
+// con movimientos 'return', acumula lo devuelto en la venta y la deja
+// pendiente de sincronizar para que el destino reciba los renglones.
+func (r *Repository) CreateSaleReturn(
+	ctx context.Context,
+	organizationID string,
+	cmd createSaleReturnCommand,
+) (*Sale, *SaleReturn, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status, paymentMethod string
+	var bazarID uuid.UUID
+	var total, refundedTotal float64
+	err = tx.QueryRow(ctx, `
+		SELECT status, bazar_id, payment_method, total, refunded_total
+		FROM bazar_sales
+		WHERE id = $1 AND organization_id = $2
+		FOR UPDATE
+	`, cmd.SaleID, organizationID).Scan(&status, &bazarID, &paymentMethod, &total, &refundedTotal)
+	if err == pgx.ErrNoRows {
+		return nil, nil, &serviceError{Status: http.StatusNotFound, Message: "Venta no encontrada."}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if status == "cancelled" {
+		return nil, nil, &serviceError{Status: http.StatusConflict, Message: "La venta está cancelada."}
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, product_id, product_external_id, product_name, quantity,
+		       returned_quantity, oversold_quantity, unit_price, total, from_stand
+		FROM bazar_sale_items
+		WHERE sale_id = $1 AND organization_id = $2
+		FOR UPDATE
+	`, cmd.SaleID, organizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	saleItems := make(map[uuid.UUID]returnableItem)
+	for rows.Next() {
+		var item returnableItem
+		if err := rows.Scan(
+			&item.ID,
+			&item.ProductID,
+			&item.ProductExternalID,
+			&item.ProductName,
+			&item.Quantity,
+			&item.ReturnedQuantity,
+			&item.OversoldQuantity,
+			&item.UnitPrice,
+			&item.Total,
+			&item.FromStand,
+		); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		saleItems[item.ID] = item
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	itemsAmount := 0.0
+	for _, requested := range cmd.Items {
+		item, found := saleItems[requested.SaleItemID]
+		if !found {
+			return nil, nil, &serviceError{Status: http.StatusNotFound, Message: "El producto no pertenece a esta venta."}
+		}
+		if available := item.Quantity - item.ReturnedQuantity; requested.Quantity > available {
+			return nil, nil, &serviceError{
+				Status:  http.StatusConflict,
+				Message: fmt.Sprintf("Solo quedan %d piezas de %s por devolver.", available, item.ProductName),
+			}
+		}
+		itemsAmount += item.returnValue(requested.Quantity)
+	}
+
+	refundAmount := itemsAmount
+	if cmd.RefundAmount != nil {
+		refundAmount = roundMoney(*cmd.RefundAmount)
+	}
+	if remaining := roundMoney(total - refundedTotal); refundAmount > remaining {
+		return nil, nil, &serviceError{
+			Status:  http.StatusConflict,
+			Message: fmt.Sprintf("El reembolso no puede superar %.2f.", remaining),
+		}
+	}
+	itemRefunds := splitRefund(cmd.Items, func(requested createSaleReturnItemCommand) float64 {
+		return saleItems[requested.SaleItemID].returnValue(requested.Quantity)
+	}, refundAmount)
+	refundMethod := cmd.RefundMethod
+	if refundMethod == "" {
+		refundMethod = paymentMethod
+	}
+	if refundMethod == PaymentMixed {
+		return nil, nil, &serviceError{
+			Status:  http.StatusBadRequest,
+			Message: "La venta se pagó con varios métodos; indica cómo se reembolsa.",
+		}
+	}
+	if refundMethod == PaymentCash && refundAmount > 0 {
+		var bazarStatus string
+		if err := tx.QueryRow(ctx, `
+			SELECT status FROM bazaars WHERE id = $1 AND organization_id = $2
+		`, bazarID, organizationID).Scan(&bazarStatus); err != nil {
+			return nil, nil, err
+		}
+		if bazarStatus != "active" {
+			return nil, nil, &serviceError{
+				Status:  http.StatusConflict,
+				Message: "El bazar ya está cerrado; reembolsa por otro método.",
+			}
+		}
+	}
+
+	returnID := uuid.New()
+	externalID := fmt.Sprintf(
+		"RET-%s-%s",
+		time.Now().Format("20060102"),
+		strings.ToUpper(strings.ReplaceAll(returnID.String(), "-", "")[:8]),
+	)
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO bazar_sale_returns (
+			id, organization_id, external_id, sale_id, bazar_id, refund_method,
+			refund_amount, reason, created_by, created_by_name
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`,
+		returnID,
+		organizationID,
+		externalID,
+		cmd.SaleID,
+		bazarID,
+		refundMethod,
+		refundAmount,
+		cmd.Reason,
+		cmd.UserID,
+		cmd.ActorName,
+	); err != nil {
+		return nil, nil, err
+	}
+
+	// Igual que en la venta, los productos se bloquean en orden de id para no
+	// cruzarse con otras cajas.
+	requestedItems := append([]createSaleReturnItemCommand(nil), cmd.Items...)
+	sort.Slice(requestedItems, func(i, j int) bool {
+		left := saleItems[requestedItems[i].SaleItemID].ProductID.String()
+		right := saleItems[requestedItems[j].SaleItemID].ProductID.String()
+		return left < right
+	})
+	for _, requested := range requestedItems {
+		item := saleItems[requested.SaleItemID]
+		var stockBefore int
+		var trackStock bool
+		if err := tx.QueryRow(ctx, `
+			SELECT stock, track_stock FROM products
+			WHERE id = $1 AND organization_id = $2
+			FOR UPDATE
+		`, item.ProductID, organizationID).Scan(&stockBefore, &trackStock); err != nil {
+			return nil, nil, err
+		}
+		restockQuantity := 0
+		if requested.Restock && trackStock {
+			restockQuantity = item.restockQuantity(requested.Quantity)
+		}
+		restocked := restockQuantity > 0
+		stockAfter := stockBefore
+		if restocked {
+			stockAfter += restockQuantity
+			if _, err := tx.Exec(ctx, `
+				UPDATE products SET stock = $1, updated_at = NOW()
+				WHERE id = $2 AND organization_id = $3
+			`, stockAfter, item.ProductID, organizationID); err != nil {
+				return nil, nil, err
+			}
+			standBefore, standAfter, err := returnToStand(
+				ctx,
+				tx,
+				organizationID,
+				bazarID,
+				item.ProductID,
+				restockQuantity,
+				item.FromStand,
+			)
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO bazar_inventory_movements (
+					organization_id, product_id, sale_id, bazar_id, movement_type,
+					quantity, stock_before, stock_after, stand_stock_before,
+					stand_stock_after, reason, created_by
+				) VALUES ($1, $2, $3, $4, 'return', $5, $6, $7, $8, $9, $10, $11)
+			`,
+				organizationID,
+				item.ProductID,
+				cmd.SaleID,
+				bazarID,
+				restockQuantity,
+				stockBefore,
+				stockAfter,
+				standBefore,
+				standAfter,
+				"Devolución "+externalID,
+				cmd.UserID,
+			); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO bazar_sale_return_items (
+				organization_id, return_id, sale_item_id, product_id, product_external_id,
+				product_name, quantity, unit_price, refund_amount, restocked,
+				stock_before, stock_after
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		`,
+			organizationID,
+			returnID,
+			item.ID,
+			item.ProductID,
+			item.ProductExternalID,
+			item.ProductName,
+			requested.Quantity,
+			item.UnitPrice,
+			itemRefunds[requested.SaleItemID],
+			restocked,
+			stockBefore,
+			stockAfter,
+		); err != nil {
+			return nil, nil, err
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE bazar_sale_items
+			SET returned_quantity = returned_quantity + $1
+			WHERE id = $2 AND organization_id = $3
+		`, requested.Quantity, item.ID, organizationID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE bazar_sales
+		SET refunded_total = refunded_total + $1,
+		    sync_status = 'pending',
+		    sync_version = sync_version + 1,
+		    sync_attempts = 0,
+		    sync_error = NULL,
+		    next_sync_at = NULL,
+		    updated_at = NOW()
+		WHERE id = $2 AND organization_id = $3
+	`, refundAmount, cmd.SaleID, organizationID); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	sale, err := r.GetSale(ctx, organizationID, cmd.SaleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for index := range sale.Returns {
+		if sale.Returns[index].ID == returnID {
+			return sale, &sale.Returns[index], nil
+		}
+	}
+	return sale, nil, nil
+}
+
+type returnableItem struct {
+	ID                uuid.UUID
+	ProductID         uuid.UUID
+	ProductExternalID string
+	ProductName       string
+	Quantity          int
+	ReturnedQuantity  int
+	OversoldQuantity  int
+	UnitPrice         float64
+	Total             float64
+	FromStand         bool
+}
+
+// returnValue es lo que se cobró por quantity piezas del renglón, ya con sus
+// descuentos.
+func (i returnableItem) returnValue(quantity int) float64 {
+	if i.Quantity <= 0 {
+		return 0
+	}
+	return roundMoney(i.Total * float64(quantity) / float64(i.Quantity))
+}
+
+// restockQuantity es cuántas de las quantity piezas devueltas vuelven al
+// inventario. Las vendidas sin stock registrado nunca se descontaron, igual
+// que al cancelar; se cuentan como las primeras devueltas del renglón.
+func (i returnableItem) restockQuantity(quantity int) int {
+	pendingOversold := i.OversoldQuantity - i.ReturnedQuantity
+	if pendingOversold < 0 {
+		pendingOversold = 0
+	}
+	if quantity <= pendingOversold {
+		return 0
+	}
+	return quantity - pendingOversold
+}
+
+func (r *Repository) listSaleReturns(ctx context.Context, organizationID string, saleID uuid.UUID) ([]SaleReturn, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, external_id, sale_id, refund_method, refund_amount, reason,
+		       created_by_name, returned_at
+		FROM bazar_sale_returns
+		WHERE sale_id = $1 AND organization_id = $2
+		ORDER BY returned_at, id
+	`, saleID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	returns := make([]SaleReturn, 0)
+	positions := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var saleReturn SaleReturn
+		var reason sql.NullString
+		if err := rows.Scan(
+			&saleReturn.ID,
+			&saleReturn.ExternalID,
+			&saleReturn.SaleID,
+			&saleReturn.RefundMethod,
+			&saleReturn.RefundAmount,
+			&reason,
+			&saleReturn.CreatedByName,
+			&saleReturn.ReturnedAt,
+		); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if reason.Valid {
+			saleReturn.Reason = &reason.String
+		}
+		saleReturn.Items = make([]SaleReturnItem, 0)
+		positions[saleReturn.ID] = len(returns)
+		returns = append(returns, saleReturn)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(returns) == 0 {
+		return returns, nil
+	}
+
+	itemRows, err := r.db.Query(ctx, `
+		SELECT i.id, i.return_id, i.sale_item_id, i.product_id, i.product_external_id,
+		       i.product_name, i.quantity, i.unit_price, i.refund_amount, i.restocked,
+		       i.stock_before, i.stock_after
+		FROM bazar_sale_return_items i
+		JOIN bazar_sale_returns sr ON sr.id = i.return_id
+		WHERE sr.sale_id = $1 AND i.organization_id = $2
+		ORDER BY i.created_at, i.id
+	`, saleID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+	for itemRows.Next() {
+		var item SaleReturnItem
+		var returnID uuid.UUID
+		if err := itemRows.Scan(
+			&item.ID,
+			&returnID,
+			&item.SaleItemID,
+			&item.ProductID,
+			&item.ProductExternalID,
+			&item.ProductName,
+			&item.Quantity,
+			&item.UnitPrice,
+			&item.RefundAmount,
+			&item.Restocked,
+			&item.StockBefore,
+			&item.StockAfter,
+		); err != nil {
+			return nil, err
+		}
+		if position, found := positions[returnID]; found {
+			returns[position].Items = append(returns[position].Items, item)
+		}
+	}
+	return returns, itemRows.Err()
+}
+
+// splitRefund reparte el reembolso entre los renglones en proporción a su
+// valor; el último absorbe el redondeo para que la suma cuadre.
+func splitRefund(
+	items []createSaleReturnItemCommand,
+	value func(createSaleReturnItemCommand) float64,
+	refundAmount float64,
+) map[uuid.UUID]float64 {
+	shares := make(map[uuid.UUID]float64, len(items))
+	itemsAmount := 0.0
+	for _, item := range items {
+		itemsAmount += value(item)
+	}
+	assigned := 0.0
+	for index, item := range items {
+		share := 0.0
+		switch {
+		case index == len(items)-1:
+			share = roundMoney(refundAmount - assigned)
+		case itemsAmount > 0:
+			share = roundMoney(value(item) * refundAmount / itemsAmount)
+		}
+		shares[item.SaleItemID] = share
+		assigned += share
+	}
+	return shares
+}
+
+func roundMoney(value float64) float64 {
+	return math.Round(value*100) / 100
+}
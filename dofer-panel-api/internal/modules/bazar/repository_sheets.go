@@ -0,0 +1,75 @@
+package bazar
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetSheetsSettings devuelve nil si la organización no configuró Google Sheets.
+func (r *Repository) GetSheetsSettings(ctx context.Context, organizationID string) (*sheetsSettingsRecord, error) {
+	var record sheetsSettingsRecord
+	err := r.db.QueryRow(ctx, `
+		SELECT spreadsheet_id, service_email, private_key_encrypted, inventory_sheet_name,
+		       sales_sheet_name, last_tested_at, last_test_error, updated_at
+		FROM bazar_sheets_settings
+		WHERE organization_id = $1
+	`, organizationID).Scan(
+		&record.SpreadsheetID,
+		&record.ServiceEmail,
+		&record.PrivateKeyEncrypted,
+		&record.InventoryName,
+		&record.SalesName,
+		&record.LastTestedAt,
+		&record.LastTestError,
+		&record.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// SaveSheetsSettings reemplaza la configuración y borra el resultado de la
+// última prueba, que correspondía a las credenciales anteriores.
+func (r *Repository) SaveSheetsSettings(ctx context.Context, organizationID string, record sheetsSettingsRecord, actorID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO bazar_sheets_settings (
+			organization_id, spreadsheet_id, service_email, private_key_encrypted,
+			inventory_sheet_name, sales_sheet_name, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (organization_id) DO UPDATE SET
+			spreadsheet_id = EXCLUDED.spreadsheet_id,
+			service_email = EXCLUDED.service_email,
+			private_key_encrypted = EXCLUDED.private_key_encrypted,
+			inventory_sheet_name = EXCLUDED.inventory_sheet_name,
+			sales_sheet_name = EXCLUDED.sales_sheet_name,
+			updated_by = EXCLUDED.updated_by,
+			last_tested_at = NULL,
+			last_test_error = NULL
+	`, organizationID, record.SpreadsheetID, record.ServiceEmail, record.PrivateKeyEncrypted,
+		record.InventoryName, record.SalesName, actorID)
+	return err
+}
+
+func (r *Repository) DeleteSheetsSettings(ctx context.Context, organizationID string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM bazar_sheets_settings WHERE organization_id = $1`, organizationID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *Repository) RecordSheetsTest(ctx context.Context, organizationID string, testError *string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE bazar_sheets_settings
+		SET last_tested_at = NOW(), last_test_error = $2
+		WHERE organization_id = $1
+	`, organizationID, testError)
+	return err
+}
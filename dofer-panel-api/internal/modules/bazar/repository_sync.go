@@ -0,0 +1,135 @@
+package bazar
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ListOrganizationsWithDueSales devuelve las organizaciones con ventas por
+// sincronizar cuyo siguiente intento ya venció.
+func (r *Repository) ListOrganizationsWithDueSales(ctx context.Context) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT DISTINCT organization_id::text
+		FROM bazar_sales
+		WHERE sync_status IN ('pending', 'error')
+		  AND (next_sync_at IS NULL OR next_sync_at <= NOW())
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	organizations := make([]string, 0)
+	for rows.Next() {
+		var organizationID string
+		if err := rows.Scan(&organizationID); err != nil {
+			return nil, err
+		}
+		organizations = append(organizations, organizationID)
+	}
+	return organizations, rows.Err()
+}
+
+// ClaimDueSales aparta hasta limit ventas vencidas adelantando next_sync_at
+// en lease; si el proceso muere a medias, la venta vuelve a la cola al
+// vencer el apartado.
+func (r *Repository) ClaimDueSales(ctx context.Context, organizationID string, limit int, lease time.Duration) ([]Sale, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	rows, err := r.db.Query(ctx, `
+		UPDATE bazar_sales
+		SET next_sync_at = NOW() + $3::interval
+		WHERE id IN (
+			SELECT id
+			FROM bazar_sales
+			WHERE organization_id = $1
+			  AND sync_status IN ('pending', 'error')
+			  AND (next_sync_at IS NULL OR next_sync_at <= NOW())
+			ORDER BY created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id
+	`, organizationID, limit, lease.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sales := make([]Sale, 0, len(ids))
+	for _, id := range ids {
+		sale, err := r.GetSale(ctx, organizationID, id)
+		if err != nil {
+			return nil, err
+		}
+		if sale != nil {
+			sales = append(sales, *sale)
+		}
+	}
+	// La hoja de ventas se llena en el orden en que se registraron.
+	sort.Slice(sales, func(i, j int) bool {
+		return sales[i].CreatedAt.Before(sales[j].CreatedAt)
+	})
+	return sales, nil
+}
+
+// ClaimSale aparta una sola venta para sincronizarla al momento; devuelve
+// false si el worker ya la tiene o no está pendiente.
+func (r *Repository) ClaimSale(ctx context.Context, organizationID string, saleID uuid.UUID, lease time.Duration) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE bazar_sales
+		SET next_sync_at = NOW() + $3::interval
+		WHERE id = $1
+		  AND organization_id = $2
+		  AND sync_status IN ('pending', 'error')
+		  AND (next_sync_at IS NULL OR next_sync_at <= NOW())
+	`, saleID, organizationID, lease.String())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CountUnsyncedSales cuenta las ventas que siguen en la cola, apartadas o no.
+func (r *Repository) CountUnsyncedSales(ctx context.Context, organizationID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM bazar_sales
+		WHERE organization_id = $1 AND sync_status IN ('pending', 'error')
+	`, organizationID).Scan(&count)
+	return count, err
+}
+
+// RequeueDeadSales devuelve a la cola las ventas descartadas, con los
+// intentos en cero.
+func (r *Repository) RequeueDeadSales(ctx context.Context, organizationID string) (int, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE bazar_sales
+		SET sync_status = 'pending',
+		    sync_attempts = 0,
+		    next_sync_at = NULL,
+		    updated_at = NOW()
+		WHERE organization_id = $1 AND sync_status = 'dead'
+	`, organizationID)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
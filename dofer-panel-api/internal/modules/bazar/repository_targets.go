@@ -0,0 +1,130 @@
+package bazar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxExportSales limita la exportación de ventas para no armar archivos
+// enormes en memoria.
+const maxExportSales = 5000
+
+// GetSyncTarget devuelve nil si la organización no eligió destino.
+func (r *Repository) GetSyncTarget(ctx context.Context, organizationID string) (*syncTargetRecord, error) {
+	var record syncTargetRecord
+	var webhookURL, webhookSecret, inventoryURL, inventoryCSV sql.NullString
+	err := r.db.QueryRow(ctx, `
+		SELECT target_kind, webhook_url, webhook_secret_encrypted, webhook_inventory_url,
+		       inventory_csv, inventory_uploaded_at, updated_at
+		FROM bazar_sync_targets
+		WHERE organization_id = $1
+	`, organizationID).Scan(
+		&record.Kind,
+		&webhookURL,
+		&webhookSecret,
+		&inventoryURL,
+		&inventoryCSV,
+		&record.InventoryUploadedAt,
+		&record.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	record.WebhookURL = webhookURL.String
+	record.WebhookSecretEncrypted = webhookSecret.String
+	record.WebhookInventoryURL = inventoryURL.String
+	record.InventoryCSV = inventoryCSV.String
+	return &record, nil
+}
+
+// SaveSyncTarget cambia el destino y su webhook; el inventario subido se
+// conserva.
+func (r *Repository) SaveSyncTarget(ctx context.Context, organizationID string, record syncTargetRecord, actorID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO bazar_sync_targets (
+			organization_id, target_kind, webhook_url, webhook_secret_encrypted,
+			webhook_inventory_url, updated_by
+		) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6)
+		ON CONFLICT (organization_id) DO UPDATE SET
+			target_kind = EXCLUDED.target_kind,
+			webhook_url = EXCLUDED.webhook_url,
+			webhook_secret_encrypted = EXCLUDED.webhook_secret_encrypted,
+			webhook_inventory_url = EXCLUDED.webhook_inventory_url,
+			updated_by = EXCLUDED.updated_by
+	`, organizationID, record.Kind, record.WebhookURL, record.WebhookSecretEncrypted,
+		record.WebhookInventoryURL, actorID)
+	return err
+}
+
+func (r *Repository) SaveTargetInventory(ctx context.Context, organizationID, inventoryCSV string, actorID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE bazar_sync_targets
+		SET inventory_csv = $2,
+		    inventory_uploaded_at = NOW(),
+		    updated_by = $3
+		WHERE organization_id = $1
+	`, organizationID, inventoryCSV, actorID)
+	return err
+}
+
+// ListSalesForExport devuelve las ventas completas en orden de registro; from
+// y to son opcionales.
+func (r *Repository) ListSalesForExport(ctx context.Context, organizationID string, from, to *time.Time) ([]Sale, error) {
+	query := `
+		SELECT s.id, s.external_id, s.client_request_id, s.bazar_id, b.name,
+		       s.seller_id, s.seller_name, s.subtotal, s.total, s.payment_method,
+		       s.cash_received, s.change_due,
+		       s.status, s.sync_status, s.sync_attempts, s.last_sync_at,
+		       s.next_sync_at, s.sync_error, s.notes, s.sold_at, s.created_at, s.cancelled_at,
+		       s.refunded_total, s.discount_total, s.ticket_discount, s.discount_reason,
+		       s.receipt_number, s.sync_version
+		FROM bazar_sales s
+		JOIN bazaars b ON b.id = s.bazar_id
+		WHERE s.organization_id = $1
+	`
+	args := []any{organizationID}
+	if from != nil {
+		args = append(args, *from)
+		query += fmt.Sprintf(" AND s.sold_at >= $%d", len(args))
+	}
+	if to != nil {
+		args = append(args, *to)
+		query += fmt.Sprintf(" AND s.sold_at < $%d", len(args))
+	}
+	args = append(args, maxExportSales)
+	query += fmt.Sprintf(" ORDER BY s.created_at LIMIT $%d", len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := make([]Sale, 0)
+	for rows.Next() {
+		sale, err := scanSale(rows)
+		if err != nil {
+			return nil, err
+		}
+		sales = append(sales, *sale)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for index := range sales {
+		if err := r.loadSaleDetails(ctx, organizationID, &sales[index]); err != nil {
+			return nil, err
+		}
+	}
+	return sales, nil
+}
@@ -0,0 +1,131 @@
+package bazar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const maxSaleBatchSize = 200
+
+// CreateSaleBatch registra en orden las ventas que el punto de venta capturó
+// sin conexión. Cada venta va en su propia transacción: una rechazada no
+// detiene a las siguientes y reenviar el lote no duplica nada gracias a
+// client_request_id.
+func (s *Service) CreateSaleBatch(
+	ctx context.Context,
+	organizationID string,
+	userID uuid.UUID,
+	sellerName string,
+	canDiscountFreely bool,
+	req SaleBatchRequest,
+) (*SaleBatchResult, error) {
+	policy := strings.ToLower(strings.TrimSpace(req.StockPolicy))
+	if policy == "" {
+		policy = StockPolicyReject
+	}
+	if policy != StockPolicyReject && policy != StockPolicyAllowOversell {
+		return nil, &serviceError{Status: http.StatusBadRequest, Message: "La política de stock debe ser reject o allow_oversell."}
+	}
+	if len(req.Sales) == 0 {
+		return nil, &serviceError{Status: http.StatusBadRequest, Message: "El lote debe incluir al menos una venta."}
+	}
+	if len(req.Sales) > maxSaleBatchSize {
+		return nil, &serviceError{
+			Status:  http.StatusBadRequest,
+			Message: "El lote no puede tener más de 200 ventas.",
+		}
+	}
+
+	result := &SaleBatchResult{Results: make([]SaleBatchItemResult, 0, len(req.Sales))}
+	for index, sale := range req.Sales {
+		item := SaleBatchItemResult{Index: index, ClientRequestID: strings.TrimSpace(sale.ClientRequestID)}
+		if err := ctx.Err(); err != nil {
+			// Si se corta la conexión las que faltan quedan para el siguiente
+			// envío.
+			item.Status, item.Reason, item.Message = SaleBatchFailed, "cancelled", "La solicitud se interrumpió."
+			result.add(item)
+			continue
+		}
+		created, err := s.createSale(
+			ctx,
+			organizationID,
+			userID,
+			sellerName,
+			canDiscountFreely,
+			policy == StockPolicyAllowOversell,
+			sale,
+		)
+		switch {
+		case err != nil:
+			item.Status, item.Reason, item.Message = saleBatchFailure(err)
+		case created.Duplicated:
+			item.Status, item.Sale = SaleBatchDuplicated, created.Sale
+		default:
+			item.Status, item.Sale = SaleBatchCreated, created.Sale
+			for _, saleItem := range created.Sale.Items {
+				item.Oversold += saleItem.OversoldQuantity
+			}
+		}
+		result.add(item)
+	}
+
+	_ = s.repo.RecordAudit(
+		ctx,
+		organizationID,
+		nil,
+		userID,
+		sellerName,
+		"sale.batch_received",
+		"sale",
+		nil,
+		map[string]any{
+			"stock_policy": policy,
+			"created":      result.Created,
+			"duplicated":   result.Duplicated,
+			"rejected":     result.Rejected,
+			"failed":       result.Failed,
+		},
+	)
+	return result, nil
+}
+
+func (r *SaleBatchResult) add(item SaleBatchItemResult) {
+	r.Results = append(r.Results, item)
+	switch item.Status {
+	case SaleBatchCreated:
+		r.Created++
+	case SaleBatchDuplicated:
+		r.Duplicated++
+	case SaleBatchRejected:
+		r.Rejected++
+	default:
+		r.Failed++
+	}
+}
+
+// saleBatchFailure separa los rechazos definitivos (datos inválidos, stock,
+// corte cerrado) de los errores que se resuelven reintentando.
+func saleBatchFailure(err error) (status, reason, message string) {
+	var domainError *serviceError
+	if !errors.As(err, &domainError) {
+		return SaleBatchFailed, "internal_error", "No se pudo registrar la venta; vuelve a intentarlo."
+	}
+	reason = domainError.Code
+	if reason == "" {
+		switch domainError.Status {
+		case http.StatusNotFound:
+			reason = "not_found"
+		case http.StatusForbidden:
+			reason = "forbidden"
+		case http.StatusConflict:
+			reason = "conflict"
+		default:
+			reason = "invalid"
+		}
+	}
+	return SaleBatchRejected, reason, domainError.Message
+}
@@ -0,0 +1,56 @@
+package bazar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateSaleBatchValidatesRequest(t *testing.T) {
+	service := &Service{}
+	oversized := SaleBatchRequest{Sales: make([]CreateSaleRequest, maxSaleBatchSize+1)}
+	for name, req := range map[string]SaleBatchRequest{
+		"policy":    {Sales: []CreateSaleRequest{{}}, StockPolicy: "negative"},
+		"empty":     {StockPolicy: StockPolicyAllowOversell},
+		"oversized": oversized,
+	} {
+		_, err := service.CreateSaleBatch(context.Background(), uuid.NewString(), uuid.New(), "Caja", false, req)
+		var serviceErr *serviceError
+		if !errors.As(err, &serviceErr) || serviceErr.Status != http.StatusBadRequest {
+			t.Fatalf("%s: expected bad request, got %v", name, err)
+		}
+	}
+}
+
+func TestSaleBatchFailure(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		status string
+		reason string
+	}{
+		{&serviceError{Status: http.StatusConflict, Message: "Sin stock", Code: "insufficient_stock"}, SaleBatchRejected, "insufficient_stock"},
+		{&serviceError{Status: http.StatusConflict, Message: "Corte", Code: "daily_cut_closed"}, SaleBatchRejected, "daily_cut_closed"},
+		{&serviceError{Status: http.StatusNotFound, Message: "Producto no encontrado."}, SaleBatchRejected, "not_found"},
+		{&serviceError{Status: http.StatusBadRequest, Message: "Inválido"}, SaleBatchRejected, "invalid"},
+		{fmt.Errorf("insert sale: %w", errors.New("connection reset")), SaleBatchFailed, "internal_error"},
+	} {
+		status, reason, message := saleBatchFailure(tc.err)
+		if status != tc.status || reason != tc.reason || message == "" {
+			t.Fatalf("saleBatchFailure(%v) = %s, %s, %q", tc.err, status, reason, message)
+		}
+	}
+}
+
+func TestSaleBatchResultCounts(t *testing.T) {
+	result := &SaleBatchResult{}
+	for _, status := range []string{SaleBatchCreated, SaleBatchCreated, SaleBatchDuplicated, SaleBatchRejected, SaleBatchFailed} {
+		result.add(SaleBatchItemResult{Status: status})
+	}
+	if result.Created != 2 || result.Duplicated != 1 || result.Rejected != 1 || result.Failed != 1 || len(result.Results) != 5 {
+		t.Fatalf("unexpected counts: %+v", result)
+	}
+}
@@ -0,0 +1,113 @@
+package bazar
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// paymentMethodOrder fija el orden en que se guardan y muestran los pagos.
+var paymentMethodOrder = []string{PaymentCash, PaymentTransfer, PaymentCard, PaymentMercadoPago, PaymentOther}
+
+// normalizeSalePayments valida los pagos de una venta. Sin pagos, la venta se
+// cobra completa con method; con uno solo, ese es su método; con varios, la
+// venta queda como 'mixed'. Los montos se comparan contra el total en el
+// repositorio, que es donde se conocen los precios.
+func normalizeSalePayments(method string, payments []SalePayment) (string, []SalePayment, error) {
+	if len(payments) == 0 {
+		normalized := normalizePaymentMethod(method)
+		if normalized == "" {
+			return "", nil, &serviceError{Status: http.StatusBadRequest, Message: "El método de pago no es válido."}
+		}
+		return normalized, nil, nil
+	}
+
+	amounts := make(map[string]float64, len(payments))
+	for _, payment := range payments {
+		normalized := normalizePaymentMethod(payment.Method)
+		if normalized == "" || strings.TrimSpace(payment.Method) == "" {
+			return "", nil, &serviceError{Status: http.StatusBadRequest, Message: "El método de pago no es válido."}
+		}
+		if payment.Amount <= 0 || payment.Amount > 999999999 {
+			return "", nil, &serviceError{Status: http.StatusBadRequest, Message: "Cada pago debe ser mayor a cero."}
+		}
+		amounts[normalized] = roundMoney(amounts[normalized] + payment.Amount)
+	}
+
+	normalized := make([]SalePayment, 0, len(amounts))
+	for _, candidate := range paymentMethodOrder {
+		if amount, found := amounts[candidate]; found {
+			normalized = append(normalized, SalePayment{Method: candidate, Amount: amount})
+		}
+	}
+	if len(normalized) == 1 {
+		return normalized[0].Method, normalized, nil
+	}
+	return PaymentMixed, normalized, nil
+}
+
+func hasCashTender(method string, payments []SalePayment) bool {
+	if len(payments) == 0 {
+		return method == PaymentCash
+	}
+	return cashTender(payments) > 0
+}
+
+func cashTender(payments []SalePayment) float64 {
+	for _, payment := range payments {
+		if payment.Method == PaymentCash {
+			return payment.Amount
+		}
+	}
+	return 0
+}
+
+// settleSalePayments cuadra los pagos contra el total ya calculado y devuelve
+// el cambio, que solo sale de la parte en efectivo.
+func settleSalePayments(
+	method string,
+	payments []SalePayment,
+	total float64,
+	cashReceived *float64,
+) ([]SalePayment, *float64, *float64, error) {
+	if len(payments) == 0 {
+		payments = []SalePayment{{Method: method, Amount: total}}
+	}
+	sum := 0.0
+	for _, payment := range payments {
+		sum += payment.Amount
+	}
+	if roundMoney(sum) != roundMoney(total) {
+		return nil, nil, nil, &serviceError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("Los pagos suman %.2f y el total de la venta es %.2f.", sum, total),
+		}
+	}
+
+	cash := cashTender(payments)
+	if cashReceived == nil || cash <= 0 {
+		return payments, nil, nil, nil
+	}
+	if *cashReceived < cash {
+		return nil, nil, nil, &serviceError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("El efectivo recibido debe ser al menos %.2f.", cash),
+		}
+	}
+	received := *cashReceived
+	change := roundMoney(received - cash)
+	return payments, &received, &change, nil
+}
+
+// salePaymentLabel describe el cobro para la hoja: el método, o el desglose si
+// fue combinado.
+func salePaymentLabel(sale *Sale) string {
+	if sale.PaymentMethod != PaymentMixed || len(sale.Payments) == 0 {
+		return paymentMethodLabel(sale.PaymentMethod)
+	}
+	parts := make([]string, 0, len(sale.Payments))
+	for _, payment := range sale.Payments {
+		parts = append(parts, fmt.Sprintf("%s %.2f", paymentMethodLabel(payment.Method), payment.Amount))
+	}
+	return strings.Join(parts, " + ")
+}
@@ -0,0 +1,90 @@
+package bazar
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestNormalizeSalePayments(t *testing.T) {
+	method, payments, err := normalizeSalePayments("", nil)
+	if err != nil || method != PaymentCash || payments != nil {
+		t.Fatalf("expected plain cash sale, got %q %v %v", method, payments, err)
+	}
+
+	method, payments, err = normalizeSalePayments("card", []SalePayment{
+		{Method: "transfer", Amount: 50},
+		{Method: " CASH ", Amount: 30},
+		{Method: "cash", Amount: 20.005},
+	})
+	if err != nil {
+		t.Fatalf("normalizeSalePayments() error = %v", err)
+	}
+	if method != PaymentMixed || len(payments) != 2 {
+		t.Fatalf("expected mixed sale with two tenders, got %q %+v", method, payments)
+	}
+	if payments[0].Method != PaymentCash || payments[0].Amount != 50.01 || payments[1].Method != PaymentTransfer {
+		t.Fatalf("unexpected tenders %+v", payments)
+	}
+
+	method, _, err = normalizeSalePayments("cash", []SalePayment{{Method: "mercado_pago", Amount: 10}})
+	if err != nil || method != PaymentMercadoPago {
+		t.Fatalf("expected a single tender to set the method, got %q %v", method, err)
+	}
+
+	for name, payments := range map[string][]SalePayment{
+		"empty method": {{Method: "", Amount: 10}},
+		"unknown":      {{Method: "crypto", Amount: 10}},
+		"zero amount":  {{Method: "cash", Amount: 0}},
+	} {
+		_, _, err := normalizeSalePayments("cash", payments)
+		var serviceErr *serviceError
+		if !errors.As(err, &serviceErr) || serviceErr.Status != http.StatusBadRequest {
+			t.Fatalf("%s: expected bad request, got %v", name, err)
+		}
+	}
+}
+
+func TestSettleSalePaymentsChangeComesFromCash(t *testing.T) {
+	received := 100.0
+	payments, cashReceived, changeDue, err := settleSalePayments(PaymentMixed, []SalePayment{
+		{Method: PaymentCash, Amount: 70},
+		{Method: PaymentTransfer, Amount: 80},
+	}, 150, &received)
+	if err != nil {
+		t.Fatalf("settleSalePayments() error = %v", err)
+	}
+	if len(payments) != 2 || cashReceived == nil || *cashReceived != 100 || changeDue == nil || *changeDue != 30 {
+		t.Fatalf("unexpected settlement %+v %v %v", payments, cashReceived, changeDue)
+	}
+
+	if _, _, _, err := settleSalePayments(PaymentMixed, []SalePayment{
+		{Method: PaymentCash, Amount: 70},
+		{Method: PaymentTransfer, Amount: 70},
+	}, 150, nil); err == nil {
+		t.Fatalf("expected tenders that do not add up to be rejected")
+	}
+
+	short := 50.0
+	if _, _, _, err := settleSalePayments(PaymentCash, nil, 60, &short); err == nil {
+		t.Fatalf("expected insufficient cash to be rejected")
+	}
+
+	payments, _, changeDue, err = settleSalePayments(PaymentCard, nil, 60, nil)
+	if err != nil || len(payments) != 1 || payments[0].Amount != 60 || changeDue != nil {
+		t.Fatalf("expected single card tender, got %+v %v %v", payments, changeDue, err)
+	}
+}
+
+func TestSalePaymentLabel(t *testing.T) {
+	sale := &Sale{PaymentMethod: PaymentMixed, Payments: []SalePayment{
+		{Method: PaymentCash, Amount: 70},
+		{Method: PaymentMercadoPago, Amount: 80.5},
+	}}
+	if label := salePaymentLabel(sale); label != "Efectivo 70.00 + Mercado Pago 80.50" {
+		t.Fatalf("unexpected label %q", label)
+	}
+	if label := salePaymentLabel(&Sale{PaymentMethod: PaymentCard}); label != "Tarjeta" {
+		t.Fatalf("unexpected label %q", label)
+	}
+}
@@ -0,0 +1,145 @@
+package bazar
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateSaleReturn devuelve piezas de una venta y reembolsa el importe. Los
+// reembolsos en efectivo salen de la caja de hoy, así que no se aceptan si el
+// corte del día ya se cerró.
+func (s *Service) CreateSaleReturn(
+	ctx context.Context,
+	organizationID string,
+	saleID, userID uuid.UUID,
+	actorName string,
+	req CreateSaleReturnRequest,
+) (*Sale, *SaleReturn, error) {
+	items, err := normalizeSaleReturnItems(req.Items)
+	if err != nil {
+		return nil, nil, err
+	}
+	refundMethod := ""
+	if strings.TrimSpace(req.RefundMethod) != "" {
+		refundMethod = normalizePaymentMethod(req.RefundMethod)
+		if refundMethod == "" {
+			return nil, nil, &serviceError{Status: http.StatusBadRequest, Message: "El método de reembolso no es válido."}
+		}
+	}
+	if req.RefundAmount != nil && (*req.RefundAmount < 0 || *req.RefundAmount > 999999999) {
+		return nil, nil, &serviceError{Status: http.StatusBadRequest, Message: "El importe a reembolsar no es válido."}
+	}
+	if len(items) == 0 && (req.RefundAmount == nil || *req.RefundAmount == 0) {
+		return nil, nil, &serviceError{
+			Status:  http.StatusBadRequest,
+			Message: "La devolución debe incluir productos o un importe a reembolsar.",
+		}
+	}
+
+	sale, err := s.repo.GetSale(ctx, organizationID, saleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sale == nil {
+		return nil, nil, &serviceError{Status: http.StatusNotFound, Message: "Venta no encontrada."}
+	}
+	if refundMethod == PaymentCash || (refundMethod == "" && sale.PaymentMethod == PaymentCash) {
+		now := time.Now().In(s.location)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+		closed, err := s.repo.HasDailyCut(ctx, organizationID, sale.BazarID, today)
+		if err != nil {
+			return nil, nil, err
+		}
+		if closed {
+			return nil, nil, &serviceError{
+				Status:  http.StatusConflict,
+				Message: "El corte de hoy ya está cerrado; reembolsa por otro método.",
+			}
+		}
+	}
+	if strings.TrimSpace(actorName) == "" {
+		actorName = "Sistema"
+	}
+
+	sale, saleReturn, err := s.repo.CreateSaleReturn(ctx, organizationID, createSaleReturnCommand{
+		SaleID:       saleID,
+		UserID:       userID,
+		ActorName:    actorName,
+		Items:        items,
+		RefundMethod: refundMethod,
+		RefundAmount: req.RefundAmount,
+		Reason:       sanitizeString(&req.Reason),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	details := map[string]any{"sale_external_id": sale.ExternalID}
+	if saleReturn != nil {
+		details["return_external_id"] = saleReturn.ExternalID
+		details["refund_amount"] = saleReturn.RefundAmount
+		details["refund_method"] = saleReturn.RefundMethod
+		details["items"] = len(saleReturn.Items)
+	}
+	_ = s.repo.RecordAudit(
+		ctx,
+		organizationID,
+		&sale.BazarID,
+		userID,
+		actorName,
+		"sale.returned",
+		"sale",
+		&sale.ID,
+		details,
+	)
+	s.syncSaleAsync(organizationID, sale)
+	return sale, saleReturn, nil
+}
+
+// normalizeSaleReturnItems junta los renglones repetidos y los ordena para
+// que dos devoluciones simultáneas bloqueen en el mismo orden.
+func normalizeSaleReturnItems(requestItems []CreateSaleReturnItemRequest) ([]createSaleReturnItemCommand, error) {
+	merged := make(map[uuid.UUID]*createSaleReturnItemCommand)
+	for _, requestItem := range requestItems {
+		saleItemID, err := uuid.Parse(strings.TrimSpace(requestItem.SaleItemID))
+		if err != nil {
+			return nil, &serviceError{Status: http.StatusBadRequest, Message: "La devolución contiene un producto inválido."}
+		}
+		if requestItem.Quantity <= 0 || requestItem.Quantity > 999 {
+			return nil, &serviceError{Status: http.StatusBadRequest, Message: "La cantidad debe estar entre 1 y 999."}
+		}
+		restock := requestItem.Restock == nil || *requestItem.Restock
+		item, found := merged[saleItemID]
+		if !found {
+			merged[saleItemID] = &createSaleReturnItemCommand{
+				SaleItemID: saleItemID,
+				Quantity:   requestItem.Quantity,
+				Restock:    restock,
+			}
+			continue
+		}
+		if item.Restock != restock {
+			return nil, &serviceError{
+				Status:  http.StatusBadRequest,
+				Message: "Un mismo producto no puede regresar y no regresar al inventario en la misma devolución.",
+			}
+		}
+		item.Quantity += requestItem.Quantity
+		if item.Quantity > 999 {
+			return nil, &serviceError{Status: http.StatusBadRequest, Message: "La cantidad acumulada no puede superar 999."}
+		}
+	}
+
+	items := make([]createSaleReturnItemCommand, 0, len(merged))
+	for _, item := range merged {
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].SaleItemID.String() < items[j].SaleItemID.String()
+	})
+	return items, nil
+}
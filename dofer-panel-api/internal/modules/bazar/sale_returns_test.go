@@ -0,0 +1,130 @@
+package bazar
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNormalizeSaleReturnItemsMergesLines(t *testing.T) {
+	first := uuid.MustParse("00000000-0000-0000-0000-0000000000b1")
+	second := uuid.MustParse("00000000-0000-0000-0000-0000000000a1")
+	keep := false
+	items, err := normalizeSaleReturnItems([]CreateSaleReturnItemRequest{
+		{SaleItemID: first.String(), Quantity: 1},
+		{SaleItemID: second.String(), Quantity: 2, Restock: &keep},
+		{SaleItemID: " " + first.String() + " ", Quantity: 2},
+	})
+	if err != nil {
+		t.Fatalf("normalizeSaleReturnItems() error = %v", err)
+	}
+	if len(items) != 2 || items[0].SaleItemID != second || items[1].SaleItemID != first {
+		t.Fatalf("unexpected items order %+v", items)
+	}
+	if items[0].Restock || items[0].Quantity != 2 || !items[1].Restock || items[1].Quantity != 3 {
+		t.Fatalf("unexpected items %+v", items)
+	}
+}
+
+func TestNormalizeSaleReturnItemsValidation(t *testing.T) {
+	itemID := uuid.NewString()
+	keep := false
+	cases := map[string][]CreateSaleReturnItemRequest{
+		"invalid id":    {{SaleItemID: "x", Quantity: 1}},
+		"zero quantity": {{SaleItemID: itemID, Quantity: 0}},
+		"mixed restock": {{SaleItemID: itemID, Quantity: 1}, {SaleItemID: itemID, Quantity: 1, Restock: &keep}},
+		"accumulated":   {{SaleItemID: itemID, Quantity: 999}, {SaleItemID: itemID, Quantity: 1}},
+	}
+	for name, request := range cases {
+		_, err := normalizeSaleReturnItems(request)
+		var serviceErr *serviceError
+		if !errors.As(err, &serviceErr) || serviceErr.Status != http.StatusBadRequest {
+			t.Fatalf("%s: expected bad request, got %v", name, err)
+		}
+	}
+}
+
+func TestRestockQuantitySkipsOversoldUnits(t *testing.T) {
+	// Se vendieron 5 sin conexión y faltaban 2 en el inventario registrado.
+	item := returnableItem{Quantity: 5, OversoldQuantity: 2}
+	if got := item.restockQuantity(1); got != 0 {
+		t.Fatalf("first returned unit was oversold, restocked %d", got)
+	}
+	if got := item.restockQuantity(5); got != 3 {
+		t.Fatalf("returning the whole line must restock only the deducted units, got %d", got)
+	}
+
+	item.ReturnedQuantity = 2
+	if got := item.restockQuantity(3); got != 3 {
+		t.Fatalf("after the oversold units every return restocks, got %d", got)
+	}
+
+	item = returnableItem{Quantity: 3, ReturnedQuantity: 1}
+	if got := item.restockQuantity(2); got != 2 {
+		t.Fatalf("lines without oversold units restock everything, got %d", got)
+	}
+}
+
+func TestSplitRefundKeepsTotal(t *testing.T) {
+	items := []createSaleReturnItemCommand{
+		{SaleItemID: uuid.New(), Quantity: 1},
+		{SaleItemID: uuid.New(), Quantity: 1},
+		{SaleItemID: uuid.New(), Quantity: 1},
+	}
+	shares := splitRefund(items, func(createSaleReturnItemCommand) float64 { return 10 }, 10)
+	total := 0.0
+	for _, item := range items {
+		total += shares[item.SaleItemID]
+	}
+	if roundMoney(total) != 10 || shares[items[0].SaleItemID] != 3.33 || shares[items[2].SaleItemID] != 3.34 {
+		t.Fatalf("unexpected shares %+v", shares)
+	}
+}
+
+func TestSaleSheetRowsIncludeReturns(t *testing.T) {
+	soldAt := time.Date(2026, 5, 2, 18, 0, 0, 0, time.UTC)
+	reason := "Llegó dañado"
+	sale := &Sale{
+		ExternalID:    "SALE-1",
+		BazarName:     "Bazar Centro",
+		SellerName:    "Ana",
+		PaymentMethod: PaymentCash,
+		Status:        "completed",
+		CreatedAt:     soldAt,
+		Items: []SaleItem{
+			{ProductExternalID: "DOF-001", ProductName: "Capibara", Quantity: 3, UnitPrice: 40, Total: 120},
+		},
+		Returns: []SaleReturn{
+			{
+				ExternalID:    "RET-1",
+				RefundMethod:  PaymentCard,
+				RefundAmount:  40,
+				Reason:        &reason,
+				CreatedByName: "Luis",
+				ReturnedAt:    soldAt.Add(24 * time.Hour),
+				Items: []SaleReturnItem{
+					{ProductExternalID: "DOF-001", ProductName: "Capibara", Quantity: 1, UnitPrice: 40, RefundAmount: 40},
+				},
+			},
+			{ExternalID: "RET-2", RefundMethod: PaymentCash, RefundAmount: 5, ReturnedAt: soldAt.Add(48 * time.Hour)},
+		},
+	}
+
+	rows := saleSheetRows(sale, time.UTC)
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+	returned := rows[1]
+	if returned[0] != "RET-1" || returned[1] != "03/05/2026" || returned[5] != -1 || returned[7] != -40.0 {
+		t.Fatalf("unexpected return row %v", returned)
+	}
+	if returned[10] != "Tarjeta" || returned[11] != "Devolución" || returned[12] != "Devolución de SALE-1: Llegó dañado" {
+		t.Fatalf("unexpected return labels %v", returned)
+	}
+	if refund := rows[2]; refund[0] != "RET-2" || refund[3] != "" || refund[7] != -5.0 {
+		t.Fatalf("unexpected refund row %v", refund)
+	}
+}
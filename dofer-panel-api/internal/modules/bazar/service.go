@@ -3,6 +3,7 @@ package bazar
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -19,12 +20,12 @@ const defaultTimezone = "America/Mexico_City"
 
 type Service struct {
 	repo     *Repository
-	sheets   SheetsGateway
+	targets  SyncTargetResolver
 	location *time.Location
 	syncMu   sync.Mutex
 }
 
-func NewService(repo *Repository, sheets SheetsGateway, timezone string) *Service {
+func NewService(repo *Repository, targets SyncTargetResolver, timezone string) *Service {
 	name := strings.TrimSpace(timezone)
 	location, err := time.LoadLocation(name)
 	if err != nil && name != defaultTimezone {
@@ -38,7 +39,7 @@ func NewService(repo *Repository, sheets SheetsGateway, timezone string) *Servic
 		slog.Error("sin base de zonas horarias; el bazar usará UTC", "timezone", name, "error", err)
 		location = time.UTC
 	}
-	return &Service{repo: repo, sheets: sheets, location: location}
+	return &Service{repo: repo, targets: targets, location: location}
 }
 
 func (s *Service) SyncProducts(ctx context.Context, organizationID string) (int, error) {
@@ -48,10 +49,14 @@ func (s *Service) SyncProducts(ctx context.Context, organizationID string) (int,
 }
 
 func (s *Service) syncProducts(ctx context.Context, organizationID, conflictStrategy string) (int, error) {
-	if configured, message := s.sheets.Configured(); !configured {
-		return 0, &serviceError{Status: http.StatusServiceUnavailable, Message: message}
+	target, err := s.configuredTarget(ctx, organizationID)
+	if err != nil {
+		return 0, err
+	}
+	products, err := target.ReadProducts(ctx)
+	if errors.Is(err, errNoTargetInventory) {
+		return 0, &serviceError{Status: http.StatusConflict, Message: "El destino de sincronización no publica inventario."}
 	}
-	products, err := s.sheets.ReadProducts(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -202,6 +207,18 @@ func (s *Service) CreateSale(
 	organizationID string,
 	userID uuid.UUID,
 	sellerName string,
+	canDiscountFreely bool,
+	req CreateSaleRequest,
+) (*CreateSaleResult, error) {
+	return s.createSale(ctx, organizationID, userID, sellerName, canDiscountFreely, false, req)
+}
+
+func (s *Service) createSale(
+	ctx context.Context,
+	organizationID string,
+	userID uuid.UUID,
+	sellerName string,
+	canDiscountFreely, allowOversell bool,
 	req CreateSaleRequest,
 ) (*CreateSaleResult, error) {
 	clientRequestID, err := uuid.Parse(strings.TrimSpace(req.ClientRequestID))
@@ -225,6 +242,7 @@ func (s *Service) CreateSale(
 	}
 
 	quantities := make(map[uuid.UUID]int)
+	discounts := make(map[uuid.UUID]*manualDiscount)
 	for _, requestItem := range requestItems {
 		productID, err := uuid.Parse(strings.TrimSpace(requestItem.ProductID))
 		if err != nil {
@@ -237,6 +255,26 @@ func (s *Service) CreateSale(
 		if quantities[productID] > 999 {
 			return nil, &serviceError{Status: http.StatusBadRequest, Message: "La cantidad acumulada no puede superar 999."}
 		}
+		discount, err := normalizeManualDiscount(requestItem.Discount)
+		if err != nil {
+			return nil, err
+		}
+		if discount != nil {
+			// Los renglones del mismo producto se juntan, así que solo uno
+			// puede traer descuento.
+			if discounts[productID] != nil {
+				return nil, &serviceError{Status: http.StatusBadRequest, Message: "Aplica el descuento una sola vez por producto."}
+			}
+			discounts[productID] = discount
+		}
+	}
+	ticketDiscount, err := normalizeManualDiscount(req.Discount)
+	if err != nil {
+		return nil, err
+	}
+	maxDiscountPercent := float64(maxOperatorDiscountPercent)
+	if canDiscountFreely {
+		maxDiscountPercent = 100
 	}
 
 	productIDs := make([]uuid.UUID, 0, len(quantities))
@@ -252,15 +290,16 @@ func (s *Service) CreateSale(
 		items = append(items, createSaleItemCommand{
 			ProductID: productID,
 			Quantity:  quantities[productID],
+			Discount:  discounts[productID],
 		})
 	}
 
-	paymentMethod := normalizePaymentMethod(req.PaymentMethod)
-	if paymentMethod == "" {
-		return nil, &serviceError{Status: http.StatusBadRequest, Message: "El método de pago no es válido."}
+	paymentMethod, payments, err := normalizeSalePayments(req.PaymentMethod, req.Payments)
+	if err != nil {
+		return nil, err
 	}
 	if req.CashReceived != nil {
-		if paymentMethod != PaymentCash {
+		if !hasCashTender(paymentMethod, payments) {
 			return nil, &serviceError{Status: http.StatusBadRequest, Message: "El efectivo recibido solo aplica a pagos en efectivo."}
 		}
 		if *req.CashReceived < 0 || *req.CashReceived > 999999999 {
@@ -275,30 +314,27 @@ func (s *Service) CreateSale(
 	if err != nil {
 		return nil, err
 	}
+	var businessDate *time.Time
 	if soldAt != nil {
-		businessDate := time.Date(soldAt.Year(), soldAt.Month(), soldAt.Day(), 0, 0, 0, 0, s.location)
-		closed, err := s.repo.HasDailyCut(ctx, organizationID, bazarID, businessDate)
-		if err != nil {
-			return nil, err
-		}
-		if closed {
-			return nil, &serviceError{
-				Status:  http.StatusConflict,
-				Message: "Ese día ya tiene corte de caja cerrado; no se le pueden agregar ventas.",
-			}
-		}
+		date := time.Date(soldAt.Year(), soldAt.Month(), soldAt.Day(), 0, 0, 0, 0, s.location)
+		businessDate = &date
 	}
 
 	result, err := s.repo.CreateSale(ctx, organizationID, createSaleCommand{
-		ClientRequestID: clientRequestID,
-		BazarID:         bazarID,
-		SellerID:        userID,
-		SellerName:      sellerName,
-		Items:           items,
-		PaymentMethod:   paymentMethod,
-		CashReceived:    req.CashReceived,
-		Notes:           req.Notes,
-		SoldAt:          soldAt,
+		ClientRequestID:    clientRequestID,
+		BazarID:            bazarID,
+		SellerID:           userID,
+		SellerName:         sellerName,
+		Items:              items,
+		PaymentMethod:      paymentMethod,
+		Payments:           payments,
+		Discount:           ticketDiscount,
+		MaxDiscountPercent: maxDiscountPercent,
+		CashReceived:       req.CashReceived,
+		Notes:              req.Notes,
+		SoldAt:             soldAt,
+		BusinessDate:       businessDate,
+		AllowOversell:      allowOversell,
 	})
 	if err != nil {
 		return nil, err
@@ -314,7 +350,12 @@ func (s *Service) CreateSale(
 			"sale.created",
 			"sale",
 			&result.Sale.ID,
-			map[string]any{"total": result.Sale.Total, "items": result.Sale.Items},
+			map[string]any{
+				"total":          result.Sale.Total,
+				"discount_total": result.Sale.DiscountTotal,
+				"items":          result.Sale.Items,
+				"payments":       result.Sale.Payments,
+			},
 		)
 	}
 	s.syncSaleAsync(organizationID, result.Sale)
@@ -348,10 +389,14 @@ func (s *Service) CancelSale(
 }
 
 func (s *Service) SyncConflicts(ctx context.Context, organizationID string) ([]SyncConflict, error) {
-	if configured, message := s.sheets.Configured(); !configured {
-		return nil, &serviceError{Status: http.StatusServiceUnavailable, Message: message}
+	target, err := s.configuredTarget(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	products, err := target.ReadProducts(ctx)
+	if errors.Is(err, errNoTargetInventory) {
+		return []SyncConflict{}, nil
 	}
-	products, err := s.sheets.ReadProducts(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -362,46 +407,62 @@ func (s *Service) SyncAll(ctx context.Context, organizationID, conflictStrategy
 	s.syncMu.Lock()
 	defer s.syncMu.Unlock()
 
-	if configured, message := s.sheets.Configured(); !configured {
-		return nil, &serviceError{Status: http.StatusServiceUnavailable, Message: message}
+	target, err := s.configuredTarget(ctx, organizationID)
+	if err != nil {
+		return nil, err
 	}
 
-	products, err := s.sheets.ReadProducts(ctx)
-	if err != nil {
+	// Los destinos que solo reciben ventas no tienen inventario que importar
+	// ni conflictos que revisar.
+	products, err := target.ReadProducts(ctx)
+	hasInventory := !errors.Is(err, errNoTargetInventory)
+	if err != nil && hasInventory {
 		return nil, fmt.Errorf("leer productos: %w", err)
 	}
-	conflicts, err := s.repo.FindSyncConflicts(ctx, organizationID, products)
-	if err != nil {
-		return nil, err
+	conflicts := []SyncConflict{}
+	if hasInventory {
+		conflicts, err = s.repo.FindSyncConflicts(ctx, organizationID, products)
+		if err != nil {
+			return nil, err
+		}
 	}
 	if len(conflicts) > 0 && conflictStrategy != "keep_manual" && conflictStrategy != "use_sheet" {
 		return nil, &serviceError{
 			Status:  http.StatusConflict,
-			Message: fmt.Sprintf("Hay %d productos con diferencias entre el inventario manual y Google Sheets.", len(conflicts)),
+			Message: fmt.Sprintf("Hay %d productos con diferencias entre el inventario manual y %s.", len(conflicts), targetLabel(target.Kind())),
 		}
 	}
 	if conflictStrategy == "" {
 		conflictStrategy = "use_sheet"
 	}
 
-	sales, err := s.repo.ListUnsyncedSales(ctx, organizationID, 100)
+	// Las ventas se apartan igual que en el worker: las que otro proceso ya
+	// tiene o que esperan su reintento no se envían dos veces.
+	sales, err := s.repo.ClaimDueSales(ctx, organizationID, 100, saleSyncLease)
 	if err != nil {
 		return nil, err
 	}
 	result := &SyncResult{}
 	for index := range sales {
-		if err := s.syncSaleUnlocked(ctx, organizationID, &sales[index]); err != nil {
+		if err := s.syncSaleUnlocked(ctx, target, organizationID, &sales[index]); err != nil {
 			result.SalesFailed++
 			continue
 		}
 		result.SalesSynced++
 	}
-	if result.SalesFailed > 0 {
+	if result.SalesFailed > 0 || !hasInventory {
+		return result, nil
+	}
+	// Con ventas aún en la cola el inventario de la hoja no las refleja.
+	if result.SalesPending, err = s.repo.CountUnsyncedSales(ctx, organizationID); err != nil {
+		return nil, err
+	}
+	if result.SalesPending > 0 {
 		return result, nil
 	}
 
 	if result.SalesSynced > 0 {
-		products, err = s.sheets.ReadProducts(ctx)
+		products, err = target.ReadProducts(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("releer productos después de sincronizar ventas: %w", err)
 		}
@@ -414,42 +475,97 @@ func (s *Service) SyncAll(ctx context.Context, organizationID, conflictStrategy
 	return result, nil
 }
 
+// configuredTarget resuelve el destino de la organización y falla con 503 si
+// no está configurado.
+func (s *Service) configuredTarget(ctx context.Context, organizationID string) (SyncTarget, error) {
+	target, err := s.targets.ForOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if configured, message := target.Configured(); !configured {
+		return nil, &serviceError{Status: http.StatusServiceUnavailable, Message: message}
+	}
+	return target, nil
+}
+
 func (s *Service) syncSaleAsync(organizationID string, sale *Sale) {
 	if sale == nil {
 		return
 	}
-	if configured, _ := s.sheets.Configured(); !configured {
-		return
-	}
 	saleCopy := *sale
 	saleCopy.Items = append([]SaleItem(nil), sale.Items...)
 	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), saleSyncTimeout)
 		defer cancel()
-		_ = s.syncSale(ctx, organizationID, &saleCopy)
+		target, err := s.targets.ForOrganization(ctx, organizationID)
+		if err != nil {
+			slog.Error("no se pudo resolver el destino de sincronización del bazar", "organization_id", organizationID, "error", err)
+			return
+		}
+		if configured, _ := target.Configured(); !configured {
+			return
+		}
+		// Si el worker ya la apartó, él se encarga de sincronizarla.
+		claimed, err := s.repo.ClaimSale(ctx, organizationID, saleCopy.ID, saleSyncLease)
+		if err != nil || !claimed {
+			return
+		}
+		_ = s.syncSale(ctx, target, organizationID, &saleCopy)
 	}()
 }
 
-func (s *Service) syncSale(ctx context.Context, organizationID string, sale *Sale) error {
+func (s *Service) syncSale(ctx context.Context, target SyncTarget, organizationID string, sale *Sale) error {
 	s.syncMu.Lock()
 	defer s.syncMu.Unlock()
-	return s.syncSaleUnlocked(ctx, organizationID, sale)
+	return s.syncSaleUnlocked(ctx, target, organizationID, sale)
 }
 
-func (s *Service) syncSaleUnlocked(ctx context.Context, organizationID string, sale *Sale) error {
+func (s *Service) syncSaleUnlocked(ctx context.Context, target SyncTarget, organizationID string, sale *Sale) error {
 	if sale == nil {
 		return nil
 	}
 	stocks, err := s.repo.GetCurrentStocksForSale(ctx, organizationID, sale.ID)
+	if err == nil {
+		err = target.SyncSale(ctx, sale, stocks)
+	}
 	if err != nil {
-		_ = s.repo.MarkSaleSyncError(ctx, organizationID, sale.ID, err)
+		s.recordSyncFailure(ctx, organizationID, sale, err)
 		return err
 	}
-	if err := s.sheets.SyncSale(ctx, sale, stocks); err != nil {
-		_ = s.repo.MarkSaleSyncError(ctx, organizationID, sale.ID, err)
+	marked, err := s.repo.MarkSaleSynced(ctx, organizationID, sale.ID, sale.SyncVersion)
+	if err != nil {
 		return err
 	}
-	return s.repo.MarkSaleSynced(ctx, organizationID, sale.ID)
+	if !marked {
+		slog.Info("la venta del bazar cambió durante el envío; queda en la cola", "sale_id", sale.ID)
+	}
+	return nil
+}
+
+// recordSyncFailure programa el siguiente intento con espera exponencial o
+// descarta la venta al agotar los intentos.
+func (s *Service) recordSyncFailure(ctx context.Context, organizationID string, sale *Sale, syncErr error) {
+	attempts := sale.SyncAttempts + 1
+	var nextSyncAt *time.Time
+	if attempts < maxSaleSyncAttempts {
+		next := time.Now().Add(saleSyncRetryDelay(attempts))
+		nextSyncAt = &next
+	} else {
+		slog.Warn("venta del bazar descartada de la cola de sincronización",
+			"organization_id", organizationID,
+			"sale_id", sale.ID,
+			"attempts", attempts,
+			"error", syncErr,
+		)
+	}
+	if err := s.repo.MarkSaleSyncError(ctx, organizationID, sale.ID, sale.SyncVersion, attempts, syncErr, nextSyncAt); err != nil {
+		slog.Error("no se pudo guardar el error de sincronización", "sale_id", sale.ID, "error", err)
+	}
+}
+
+// RequeueDeadSales devuelve a la cola las ventas que agotaron sus intentos.
+func (s *Service) RequeueDeadSales(ctx context.Context, organizationID string) (int, error) {
+	return s.repo.RequeueDeadSales(ctx, organizationID)
 }
 
 // dayWindow devuelve el inicio y el fin del dia pedido en la zona horaria de
@@ -578,7 +694,7 @@ func (s *Service) CloseBazar(
 	if req.ClosingCash < 0 || req.ClosingCash > 999999999 {
 		return nil, &serviceError{Status: http.StatusBadRequest, Message: "El efectivo contado no es válido."}
 	}
-	closed, err := s.repo.CloseBazar(
+	closed, stockReturned, err := s.repo.CloseBazar(
 		ctx,
 		organizationID,
 		bazarID,
@@ -602,6 +718,7 @@ func (s *Service) CloseBazar(
 			"expected_cash":   closed.ExpectedCash,
 			"closing_cash":    closed.ClosingCash,
 			"cash_difference": closed.CashDifference,
+			"stock_returned":  stockReturned,
 		},
 	)
 	return closed, nil
@@ -636,7 +753,14 @@ func (s *Service) FinalBazarReport(
 	if bazarItem.EndsAt != nil {
 		to = bazarItem.EndsAt.Add(time.Nanosecond)
 	}
-	return s.repo.GetReport(ctx, organizationID, &bazarID, bazarItem.StartsAt, to)
+	report, err := s.repo.GetReport(ctx, organizationID, &bazarID, bazarItem.StartsAt, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.profitabilityReport(ctx, organizationID, bazarID, bazarItem.StartsAt, to, report); err != nil {
+		return nil, err
+	}
+	return report, nil
 }
 
 func prepareCreateProduct(req CreateProductRequest) (createProductCommand, error) {
@@ -37,12 +37,6 @@ type SheetsConfig struct {
 	Timezone      string
 }
 
-type SheetsGateway interface {
-	Configured() (bool, string)
-	ReadProducts(context.Context) ([]sheetProduct, error)
-	SyncSale(context.Context, *Sale, map[string]int) error
-}
-
 type GoogleSheetsClient struct {
 	config      SheetsConfig
 	httpClient  *http.Client
@@ -76,6 +70,10 @@ func NewGoogleSheetsClient(config SheetsConfig) *GoogleSheetsClient {
 	}
 }
 
+func (c *GoogleSheetsClient) Kind() string {
+	return TargetGoogleSheets
+}
+
 func (c *GoogleSheetsClient) Configured() (bool, string) {
 	missing := make([]string, 0, 3)
 	if c.config.SpreadsheetID == "" {
@@ -101,6 +99,19 @@ func (c *GoogleSheetsClient) ReadProducts(ctx context.Context) ([]sheetProduct,
 	return parseInventoryRows(values)
 }
 
+// Check confirma que las credenciales funcionan y que ambas hojas existen;
+// devuelve cuántos productos válidos tiene el inventario.
+func (c *GoogleSheetsClient) Check(ctx context.Context) (int, error) {
+	products, err := c.ReadProducts(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("hoja %s: %w", c.config.InventoryName, err)
+	}
+	if _, err := c.readRange(ctx, c.config.SalesName, "A1:N1"); err != nil {
+		return 0, fmt.Errorf("hoja %s: %w", c.config.SalesName, err)
+	}
+	return len(products), nil
+}
+
 func (c *GoogleSheetsClient) SyncSale(ctx context.Context, sale *Sale, stocks map[string]int) error {
 	if sale == nil {
 		return errors.New("missing sale")
@@ -263,31 +274,11 @@ func (c *GoogleSheetsClient) appendMissingSaleRows(ctx context.Context, existing
 	}
 
 	values := make([][]any, 0, len(sale.Items))
-	status := "Completada"
-	if sale.Status == "cancelled" {
-		status = "Cancelada"
-	}
-	createdAt := sale.CreatedAt.In(c.location)
-	for _, item := range sale.Items {
-		if existingItems[sale.ExternalID+"|"+item.ProductExternalID] {
+	for _, row := range saleSheetRows(sale, c.location) {
+		if existingItems[fmt.Sprint(row[0])+"|"+fmt.Sprint(row[3])] {
 			continue
 		}
-		values = append(values, []any{
-			sale.ExternalID,
-			createdAt.Format("02/01/2006"),
-			createdAt.Format("15:04:05"),
-			item.ProductExternalID,
-			item.ProductName,
-			item.Quantity,
-			item.UnitPrice,
-			item.Total,
-			sale.SellerName,
-			sale.BazarName,
-			paymentMethodLabel(sale.PaymentMethod),
-			status,
-			optionalString(sale.Notes),
-			createdAt.Format(time.RFC3339),
-		})
+		values = append(values, row)
 	}
 	if len(values) == 0 {
 		return nil
@@ -298,7 +289,7 @@ func (c *GoogleSheetsClient) appendMissingSaleRows(ctx context.Context, existing
 		"insertDataOption": {"INSERT_ROWS"},
 	}
 	endpoint := c.spreadsheetEndpoint(
-		"/values/" + url.PathEscape(quoteSheetName(c.config.SalesName)+"!A:N") + ":append",
+		"/values/" + url.PathEscape(quoteSheetName(c.config.SalesName)+"!A:P") + ":append",
 	)
 	return c.doJSON(ctx, http.MethodPost, endpoint, query, map[string]any{"values": values}, nil)
 }
@@ -430,24 +421,9 @@ func (c *GoogleSheetsClient) token(ctx context.Context) (string, error) {
 }
 
 func (c *GoogleSheetsClient) signedAssertion() (string, error) {
-	block, _ := pem.Decode([]byte(c.config.PrivateKey))
-	if block == nil {
-		return "", errors.New("GOOGLE_PRIVATE_KEY no contiene una clave PEM válida")
-	}
-
-	var privateKey *rsa.PrivateKey
-	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-	if err == nil {
-		var ok bool
-		privateKey, ok = key.(*rsa.PrivateKey)
-		if !ok {
-			return "", errors.New("GOOGLE_PRIVATE_KEY no es una clave RSA")
-		}
-	} else {
-		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
-		if err != nil {
-			return "", fmt.Errorf("leer GOOGLE_PRIVATE_KEY: %w", err)
-		}
+	privateKey, err := parsePrivateKey(c.config.PrivateKey)
+	if err != nil {
+		return "", err
 	}
 
 	now := time.Now().Unix()
@@ -470,6 +446,27 @@ func (c *GoogleSheetsClient) signedAssertion() (string, error) {
 	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
 }
 
+func parsePrivateKey(value string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(value))
+	if block == nil {
+		return nil, errors.New("la clave privada no es una clave PEM válida")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err == nil {
+		privateKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("la clave privada no es una clave RSA")
+		}
+		return privateKey, nil
+	}
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("leer la clave privada: %w", err)
+	}
+	return privateKey, nil
+}
+
 func parseInventoryRows(rows [][]any) ([]sheetProduct, error) {
 	if len(rows) == 0 {
 		return nil, errors.New("la hoja Inventario está vacía")
@@ -642,6 +639,92 @@ func columnName(index int) string {
 	return result
 }
 
+// salesSheetHeaders son las columnas de la hoja Ventas, en el orden de
+// saleSheetRows.
+var salesSheetHeaders = []any{
+	"ID Venta", "Fecha", "Hora", "ID Producto", "Producto", "Cantidad", "Precio Unitario",
+	"Total", "Vendedor", "Bazar", "Método de Pago", "Estado", "Observaciones", "Fecha de Registro",
+	"Descuento", "Motivo de Descuento",
+}
+
+// inventorySheetHeaders son las columnas de la hoja Inventario que entiende
+// parseInventoryRows.
+var inventorySheetHeaders = []any{
+	"ID", "Producto", "Categoría", "Precio", "Costo", "Stock", "Imagen", "Activo", "Fecha de actualización",
+}
+
+// saleSheetRows devuelve un renglón por producto vendido y, después, uno por
+// producto devuelto con cantidad y total negativos bajo el ID de la
+// devolución. Un reembolso sin productos sale como un solo renglón.
+func saleSheetRows(sale *Sale, location *time.Location) [][]any {
+	status := "Completada"
+	if sale.Status == "cancelled" {
+		status = "Cancelada"
+	}
+	createdAt := sale.CreatedAt.In(location)
+	rows := make([][]any, 0, len(sale.Items)+len(sale.Returns))
+	for _, item := range sale.Items {
+		rows = append(rows, []any{
+			sale.ExternalID,
+			createdAt.Format("02/01/2006"),
+			createdAt.Format("15:04:05"),
+			item.ProductExternalID,
+			item.ProductName,
+			item.Quantity,
+			item.UnitPrice,
+			item.Total,
+			sale.SellerName,
+			sale.BazarName,
+			salePaymentLabel(sale),
+			status,
+			optionalString(sale.Notes),
+			createdAt.Format(time.RFC3339),
+			item.Discount,
+			optionalString(item.DiscountReason),
+		})
+	}
+	for _, saleReturn := range sale.Returns {
+		rows = append(rows, returnSheetRows(sale, &saleReturn, location)...)
+	}
+	return rows
+}
+
+func returnSheetRows(sale *Sale, saleReturn *SaleReturn, location *time.Location) [][]any {
+	returnedAt := saleReturn.ReturnedAt.In(location)
+	notes := "Devolución de " + sale.ExternalID
+	if reason := optionalString(saleReturn.Reason); reason != "" {
+		notes += ": " + reason
+	}
+	row := func(productID, productName string, quantity int, unitPrice, total float64) []any {
+		return []any{
+			saleReturn.ExternalID,
+			returnedAt.Format("02/01/2006"),
+			returnedAt.Format("15:04:05"),
+			productID,
+			productName,
+			-quantity,
+			unitPrice,
+			-total,
+			saleReturn.CreatedByName,
+			sale.BazarName,
+			paymentMethodLabel(saleReturn.RefundMethod),
+			"Devolución",
+			notes,
+			returnedAt.Format(time.RFC3339),
+			0.0,
+			"",
+		}
+	}
+	if len(saleReturn.Items) == 0 {
+		return [][]any{row("", "Reembolso", 0, 0, saleReturn.RefundAmount)}
+	}
+	rows := make([][]any, 0, len(saleReturn.Items))
+	for _, item := range saleReturn.Items {
+		rows = append(rows, row(item.ProductExternalID, item.ProductName, item.Quantity, item.UnitPrice, item.RefundAmount))
+	}
+	return rows
+}
+
 func optionalString(value *string) string {
 	if value == nil {
 		return ""
@@ -659,6 +742,8 @@ func paymentMethodLabel(method string) string {
 		return "Mercado Pago"
 	case PaymentOther:
 		return "Otro"
+	case PaymentMixed:
+		return "Mixto"
 	default:
 		return "Efectivo"
 	}
@@ -0,0 +1,288 @@
+package bazar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	authDomain "github.com/dofer/panel-api/internal/modules/auth/domain"
+	"github.com/dofer/panel-api/internal/platform/secrets"
+	"github.com/google/uuid"
+)
+
+var spreadsheetURLPattern = regexp.MustCompile(`/spreadsheets/d/([a-zA-Z0-9_-]+)`)
+
+type cachedSheetsClient struct {
+	client    *GoogleSheetsClient
+	updatedAt time.Time
+}
+
+// SheetsDirectory arma los clientes a partir de bazar_sheets_settings y los
+// conserva mientras la configuración no cambie, para reutilizar el token de
+// acceso.
+type SheetsDirectory struct {
+	repo     *Repository
+	box      *secrets.Box
+	legacy   *GoogleSheetsClient
+	timezone string
+
+	mu      sync.Mutex
+	clients map[string]cachedSheetsClient
+}
+
+func NewSheetsDirectory(repo *Repository, box *secrets.Box, legacy SheetsConfig) *SheetsDirectory {
+	return &SheetsDirectory{
+		repo:     repo,
+		box:      box,
+		legacy:   NewGoogleSheetsClient(legacy),
+		timezone: legacy.Timezone,
+		clients:  make(map[string]cachedSheetsClient),
+	}
+}
+
+// ForOrganization entrega el cliente de Google Sheets de la organización.
+func (d *SheetsDirectory) ForOrganization(ctx context.Context, organizationID string) (SyncTarget, error) {
+	record, err := d.repo.GetSheetsSettings(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		d.forget(organizationID)
+		// Solo la organización previa al multi-tenant sigue usando las
+		// credenciales de las variables de entorno mientras no guarde las suyas.
+		if organizationID == authDomain.DefaultOrganizationID {
+			if configured, _ := d.legacy.Configured(); configured {
+				return d.legacy, nil
+			}
+		}
+		return missingSheets{message: "Google Sheets no está configurado para esta organización."}, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if cached, ok := d.clients[organizationID]; ok && cached.updatedAt.Equal(record.UpdatedAt) {
+		return cached.client, nil
+	}
+
+	privateKey, err := d.box.Open(record.PrivateKeyEncrypted)
+	if err != nil {
+		slog.Error("no se pudo descifrar la clave de Google Sheets", "organization_id", organizationID, "error", err)
+		return missingSheets{message: "No se pudo leer la clave guardada de Google Sheets; vuelve a configurar la integración."}, nil
+	}
+	client := NewGoogleSheetsClient(SheetsConfig{
+		SpreadsheetID: record.SpreadsheetID,
+		ServiceEmail:  record.ServiceEmail,
+		PrivateKey:    privateKey,
+		InventoryName: record.InventoryName,
+		SalesName:     record.SalesName,
+		Timezone:      d.timezone,
+	})
+	d.clients[organizationID] = cachedSheetsClient{client: client, updatedAt: record.UpdatedAt}
+	return client, nil
+}
+
+func (d *SheetsDirectory) forget(organizationID string) {
+	d.mu.Lock()
+	delete(d.clients, organizationID)
+	d.mu.Unlock()
+}
+
+func (d *SheetsDirectory) Settings(ctx context.Context, organizationID string) (*SheetsSettings, error) {
+	record, err := d.repo.GetSheetsSettings(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		settings := &SheetsSettings{
+			Source:        "organization",
+			SpreadsheetID: record.SpreadsheetID,
+			ServiceEmail:  record.ServiceEmail,
+			HasPrivateKey: record.PrivateKeyEncrypted != "",
+			InventoryName: record.InventoryName,
+			SalesName:     record.SalesName,
+			LastTestedAt:  record.LastTestedAt,
+			UpdatedAt:     &record.UpdatedAt,
+		}
+		if record.LastTestError != nil {
+			settings.LastTestError = *record.LastTestError
+		}
+		return settings, nil
+	}
+
+	if organizationID == authDomain.DefaultOrganizationID {
+		if configured, _ := d.legacy.Configured(); configured {
+			return &SheetsSettings{
+				Source:        "environment",
+				SpreadsheetID: d.legacy.config.SpreadsheetID,
+				ServiceEmail:  d.legacy.config.ServiceEmail,
+				HasPrivateKey: true,
+				InventoryName: d.legacy.config.InventoryName,
+				SalesName:     d.legacy.config.SalesName,
+			}, nil
+		}
+	}
+	return &SheetsSettings{Source: "none", InventoryName: "Inventario", SalesName: "Ventas"}, nil
+}
+
+// Configure valida y cifra las credenciales antes de guardarlas.
+func (d *SheetsDirectory) Configure(ctx context.Context, organizationID string, actorID uuid.UUID, req UpdateSheetsSettingsRequest) (*SheetsSettings, error) {
+	if !d.box.Enabled() {
+		return nil, &serviceError{Status: http.StatusServiceUnavailable, Message: "Falta SECRETS_ENCRYPTION_KEY en el servidor para guardar credenciales."}
+	}
+
+	current, err := d.repo.GetSheetsSettings(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	record, privateKey, err := normalizeSheetsSettings(req)
+	if err != nil {
+		return nil, err
+	}
+	if privateKey == "" {
+		if current == nil {
+			return nil, &serviceError{Status: http.StatusBadRequest, Message: "La clave privada de la cuenta de servicio es obligatoria."}
+		}
+		record.PrivateKeyEncrypted = current.PrivateKeyEncrypted
+	} else {
+		sealed, err := d.box.Seal(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		record.PrivateKeyEncrypted = sealed
+	}
+
+	if err := d.repo.SaveSheetsSettings(ctx, organizationID, record, actorID); err != nil {
+		return nil, err
+	}
+	d.forget(organizationID)
+	return d.Settings(ctx, organizationID)
+}
+
+// Test prueba la configuración vigente y guarda el resultado.
+func (d *SheetsDirectory) Test(ctx context.Context, organizationID string) (*SheetsTestResult, error) {
+	gateway, err := d.ForOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if configured, message := gateway.Configured(); !configured {
+		return nil, &serviceError{Status: http.StatusServiceUnavailable, Message: message}
+	}
+	client, ok := gateway.(*GoogleSheetsClient)
+	if !ok {
+		return nil, errors.New("unexpected sheets gateway")
+	}
+
+	result := &SheetsTestResult{TestedAt: time.Now()}
+	products, checkErr := client.Check(ctx)
+	var testError *string
+	if checkErr != nil {
+		message := checkErr.Error()
+		testError = &message
+		result.Message = message
+	} else {
+		result.OK = true
+		result.Inventory = products
+		result.Message = "Conexión correcta con Google Sheets."
+	}
+	if err := d.repo.RecordSheetsTest(ctx, organizationID, testError); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d *SheetsDirectory) Disconnect(ctx context.Context, organizationID string) error {
+	deleted, err := d.repo.DeleteSheetsSettings(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+	d.forget(organizationID)
+	if !deleted {
+		return &serviceError{Status: http.StatusNotFound, Message: "La organización no tiene Google Sheets configurado."}
+	}
+	return nil
+}
+
+// normalizeSheetsSettings devuelve el registro sin la clave y la clave en
+// claro (vacía si no se envió).
+func normalizeSheetsSettings(req UpdateSheetsSettingsRequest) (sheetsSettingsRecord, string, error) {
+	serviceEmail := strings.TrimSpace(req.ServiceEmail)
+	privateKey := ""
+	if req.PrivateKey != nil {
+		privateKey = *req.PrivateKey
+	}
+
+	if raw := strings.TrimSpace(req.CredentialsJSON); raw != "" {
+		var credentials struct {
+			ClientEmail string `json:"client_email"`
+			PrivateKey  string `json:"private_key"`
+		}
+		if err := json.Unmarshal([]byte(raw), &credentials); err != nil {
+			return sheetsSettingsRecord{}, "", &serviceError{Status: http.StatusBadRequest, Message: "El JSON de la cuenta de servicio no es válido."}
+		}
+		serviceEmail = strings.TrimSpace(credentials.ClientEmail)
+		privateKey = credentials.PrivateKey
+	}
+
+	privateKey = strings.ReplaceAll(strings.TrimSpace(privateKey), `\n`, "\n")
+	if privateKey != "" {
+		if _, err := parsePrivateKey(privateKey); err != nil {
+			return sheetsSettingsRecord{}, "", &serviceError{Status: http.StatusBadRequest, Message: "La clave privada no es una clave RSA en formato PEM."}
+		}
+	}
+
+	record := sheetsSettingsRecord{
+		SpreadsheetID: spreadsheetIDFromInput(req.SpreadsheetID),
+		ServiceEmail:  serviceEmail,
+		InventoryName: strings.TrimSpace(req.InventoryName),
+		SalesName:     strings.TrimSpace(req.SalesName),
+	}
+	if record.SpreadsheetID == "" {
+		return sheetsSettingsRecord{}, "", &serviceError{Status: http.StatusBadRequest, Message: "El ID o la URL de la hoja de cálculo es obligatorio."}
+	}
+	if !strings.Contains(record.ServiceEmail, "@") {
+		return sheetsSettingsRecord{}, "", &serviceError{Status: http.StatusBadRequest, Message: "El correo de la cuenta de servicio no es válido."}
+	}
+	if record.InventoryName == "" {
+		record.InventoryName = "Inventario"
+	}
+	if record.SalesName == "" {
+		record.SalesName = "Ventas"
+	}
+	return record, privateKey, nil
+}
+
+// spreadsheetIDFromInput acepta el ID o la URL completa de la hoja.
+func spreadsheetIDFromInput(value string) string {
+	value = strings.TrimSpace(value)
+	if match := spreadsheetURLPattern.FindStringSubmatch(value); match != nil {
+		return match[1]
+	}
+	return value
+}
+
+type missingSheets struct {
+	message string
+}
+
+func (m missingSheets) Kind() string {
+	return TargetGoogleSheets
+}
+
+func (m missingSheets) Configured() (bool, string) {
+	return false, m.message
+}
+
+func (m missingSheets) ReadProducts(context.Context) ([]sheetProduct, error) {
+	return nil, errors.New(m.message)
+}
+
+func (m missingSheets) SyncSale(context.Context, *Sale, map[string]int) error {
+	return errors.New(m.message)
+}
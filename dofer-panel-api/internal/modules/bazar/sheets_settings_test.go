@@ -0,0 +1,84 @@
+package bazar
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func testPrivateKeyPEM(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	encoded, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: encoded}))
+}
+
+func TestSpreadsheetIDFromInput(t *testing.T) {
+	cases := map[string]string{
+		"1AbC_d-9":  "1AbC_d-9",
+		" 1AbC_d-9": "1AbC_d-9",
+		"https://docs.google.com/spreadsheets/d/1AbC_d-9/edit#gid=0": "1AbC_d-9",
+	}
+	for input, expected := range cases {
+		if got := spreadsheetIDFromInput(input); got != expected {
+			t.Fatalf("spreadsheetIDFromInput(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestNormalizeSheetsSettingsFromCredentialsJSON(t *testing.T) {
+	privateKey := testPrivateKeyPEM(t)
+	credentials, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "bazar@proyecto.iam.gserviceaccount.com",
+		"private_key":  privateKey,
+	})
+
+	record, key, err := normalizeSheetsSettings(UpdateSheetsSettingsRequest{
+		SpreadsheetID:   "https://docs.google.com/spreadsheets/d/abc123/edit",
+		CredentialsJSON: string(credentials),
+	})
+	if err != nil {
+		t.Fatalf("normalizeSheetsSettings() error = %v", err)
+	}
+	if record.SpreadsheetID != "abc123" || record.ServiceEmail != "bazar@proyecto.iam.gserviceaccount.com" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if record.InventoryName != "Inventario" || record.SalesName != "Ventas" {
+		t.Fatalf("expected default sheet names, got %+v", record)
+	}
+	if key != strings.TrimSpace(privateKey) {
+		t.Fatalf("expected private key from credentials JSON")
+	}
+}
+
+func TestNormalizeSheetsSettingsRejectsInvalidInput(t *testing.T) {
+	invalidKey := "no es una clave"
+	escapedKey := strings.ReplaceAll(testPrivateKeyPEM(t), "\n", `\n`)
+
+	cases := []UpdateSheetsSettingsRequest{
+		{ServiceEmail: "bazar@example.com"},
+		{SpreadsheetID: "abc", ServiceEmail: "bazar"},
+		{SpreadsheetID: "abc", ServiceEmail: "bazar@example.com", PrivateKey: &invalidKey},
+		{SpreadsheetID: "abc", CredentialsJSON: "{"},
+	}
+	for _, req := range cases {
+		if _, _, err := normalizeSheetsSettings(req); err == nil {
+			t.Fatalf("expected error for %+v", req)
+		}
+	}
+
+	if _, key, err := normalizeSheetsSettings(UpdateSheetsSettingsRequest{SpreadsheetID: "abc", ServiceEmail: "bazar@example.com", PrivateKey: &escapedKey}); err != nil || !strings.Contains(key, "\n") {
+		t.Fatalf("expected escaped key to be accepted, got err=%v", err)
+	}
+}
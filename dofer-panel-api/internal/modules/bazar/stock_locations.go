@@ -0,0 +1,101 @@
+package bazar
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+func (s *Service) ListStockLocations(
+	ctx context.Context,
+	organizationID string,
+	bazarID *uuid.UUID,
+) ([]ProductStockLocations, error) {
+	return s.repo.ListStockLocations(ctx, organizationID, bazarID)
+}
+
+func (s *Service) TransferStock(
+	ctx context.Context,
+	organizationID string,
+	bazarID, actorID uuid.UUID,
+	actorName string,
+	req StockTransferRequest,
+) ([]ProductStockLocations, error) {
+	command, err := prepareStockTransfer(bazarID, actorID, req)
+	if err != nil {
+		return nil, err
+	}
+	locations, err := s.repo.TransferStock(ctx, organizationID, command)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]any, 0, len(command.Items))
+	for _, item := range command.Items {
+		items = append(items, map[string]any{"product_id": item.ProductID, "quantity": item.Quantity})
+	}
+	_ = s.repo.RecordAudit(
+		ctx,
+		organizationID,
+		&bazarID,
+		actorID,
+		actorName,
+		"inventory.transferred",
+		"bazar",
+		&bazarID,
+		map[string]any{
+			"direction": command.Direction,
+			"items":     items,
+			"reason":    command.Reason,
+		},
+	)
+	return locations, nil
+}
+
+// prepareStockTransfer valida un traspaso y junta los renglones repetidos del
+// mismo producto.
+func prepareStockTransfer(
+	bazarID, actorID uuid.UUID,
+	req StockTransferRequest,
+) (stockTransferCommand, error) {
+	direction := strings.ToLower(strings.TrimSpace(req.Direction))
+	if direction != StockToBazar && direction != StockToWarehouse {
+		return stockTransferCommand{}, &serviceError{
+			Status:  http.StatusBadRequest,
+			Message: "El traspaso debe ser to_bazar o to_warehouse.",
+		}
+	}
+	if len(req.Items) == 0 || len(req.Items) > 200 {
+		return stockTransferCommand{}, &serviceError{
+			Status:  http.StatusBadRequest,
+			Message: "El traspaso debe incluir entre 1 y 200 productos.",
+		}
+	}
+
+	items := make([]stockTransferItemCommand, 0, len(req.Items))
+	positions := make(map[uuid.UUID]int, len(req.Items))
+	for _, item := range req.Items {
+		productID, err := uuid.Parse(strings.TrimSpace(item.ProductID))
+		if err != nil {
+			return stockTransferCommand{}, &serviceError{Status: http.StatusBadRequest, Message: "Producto inválido en el traspaso."}
+		}
+		if item.Quantity <= 0 || item.Quantity > 999999 {
+			return stockTransferCommand{}, &serviceError{Status: http.StatusBadRequest, Message: "La cantidad del traspaso no es válida."}
+		}
+		if position, found := positions[productID]; found {
+			items[position].Quantity += item.Quantity
+			continue
+		}
+		positions[productID] = len(items)
+		items = append(items, stockTransferItemCommand{ProductID: productID, Quantity: item.Quantity})
+	}
+
+	return stockTransferCommand{
+		BazarID:   bazarID,
+		Direction: direction,
+		Items:     items,
+		Reason:    sanitizeString(&req.Reason),
+		ActorID:   actorID,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package bazar
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPrepareStockTransferMergesProducts(t *testing.T) {
+	bazarID := uuid.New()
+	productID := uuid.New()
+	command, err := prepareStockTransfer(bazarID, uuid.New(), StockTransferRequest{
+		Direction: " TO_BAZAR ",
+		Items: []StockTransferItemRequest{
+			{ProductID: productID.String(), Quantity: 2},
+			{ProductID: uuid.NewString(), Quantity: 1},
+			{ProductID: productID.String(), Quantity: 3},
+		},
+		Reason: "  ",
+	})
+	if err != nil {
+		t.Fatalf("prepareStockTransfer() error = %v", err)
+	}
+	if command.Direction != StockToBazar || command.BazarID != bazarID || command.Reason != nil {
+		t.Fatalf("unexpected command %+v", command)
+	}
+	if len(command.Items) != 2 || command.Items[0].ProductID != productID || command.Items[0].Quantity != 5 {
+		t.Fatalf("unexpected items %+v", command.Items)
+	}
+
+	for name, req := range map[string]StockTransferRequest{
+		"direction": {Direction: "to_store", Items: []StockTransferItemRequest{{ProductID: productID.String(), Quantity: 1}}},
+		"empty":     {Direction: StockToWarehouse},
+		"product":   {Direction: StockToWarehouse, Items: []StockTransferItemRequest{{ProductID: "x", Quantity: 1}}},
+		"quantity":  {Direction: StockToWarehouse, Items: []StockTransferItemRequest{{ProductID: productID.String()}}},
+	} {
+		_, err := prepareStockTransfer(bazarID, uuid.New(), req)
+		var serviceErr *serviceError
+		if !errors.As(err, &serviceErr) || serviceErr.Status != http.StatusBadRequest {
+			t.Fatalf("%s: expected bad request, got %v", name, err)
+		}
+	}
+}
+
+func TestStandStockWarehouse(t *testing.T) {
+	productID := uuid.New()
+	locations := standStock{allocated: map[uuid.UUID]int{productID: 7}, stand: map[uuid.UUID]int{}}
+	if available := locations.warehouse(productID, 10); available != 3 {
+		t.Fatalf("expected 3 units in the warehouse, got %d", available)
+	}
+	// Si Sheets bajó el total por debajo de lo asignado, el almacén queda vacío.
+	if available := locations.warehouse(productID, 5); available != 0 {
+		t.Fatalf("expected an empty warehouse, got %d", available)
+	}
+	if available := locations.warehouse(uuid.New(), 4); available != 4 {
+		t.Fatalf("expected unassigned product to be fully in the warehouse, got %d", available)
+	}
+}
@@ -0,0 +1,146 @@
+package bazar
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dofer/panel-api/internal/platform/xlsx"
+)
+
+// SyncExport es un archivo descargable con el inventario o las ventas en las
+// mismas columnas que las hojas de Google.
+type SyncExport struct {
+	Content     []byte
+	ContentType string
+	Filename    string
+}
+
+// ExportSyncData arma el CSV o XLSX de dataset (inventory o sales). Para
+// ventas, from y to son fechas YYYY-MM-DD opcionales e inclusivas.
+func (s *Service) ExportSyncData(ctx context.Context, organizationID, dataset, format, from, to string) (*SyncExport, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		return nil, &serviceError{Status: http.StatusBadRequest, Message: "El formato debe ser csv o xlsx."}
+	}
+
+	var sheetName string
+	var rows [][]any
+	switch dataset {
+	case "inventory":
+		products, err := s.repo.ListProducts(ctx, organizationID, "", "")
+		if err != nil {
+			return nil, err
+		}
+		sheetName = "Inventario"
+		rows = inventoryExportRows(products, s.location)
+	case "sales":
+		var fromTime, toTime *time.Time
+		if strings.TrimSpace(from) != "" {
+			start, _, err := s.dayWindow(from)
+			if err != nil {
+				return nil, err
+			}
+			fromTime = &start
+		}
+		if strings.TrimSpace(to) != "" {
+			_, end, err := s.dayWindow(to)
+			if err != nil {
+				return nil, err
+			}
+			toTime = &end
+		}
+		sales, err := s.repo.ListSalesForExport(ctx, organizationID, fromTime, toTime)
+		if err != nil {
+			return nil, err
+		}
+		sheetName = "Ventas"
+		rows = [][]any{salesSheetHeaders}
+		for index := range sales {
+			rows = append(rows, saleSheetRows(&sales[index], s.location)...)
+		}
+	default:
+		return nil, &serviceError{Status: http.StatusNotFound, Message: "Solo se exportan inventory o sales."}
+	}
+
+	filename := fmt.Sprintf("bazar-%s-%s.%s", dataset, time.Now().In(s.location).Format("2006-01-02"), format)
+	if format == "xlsx" {
+		content, err := xlsx.Encode(xlsx.Sheet{Name: sheetName, Rows: rows})
+		if err != nil {
+			return nil, err
+		}
+		return &SyncExport{Content: content, ContentType: xlsx.ContentType, Filename: filename}, nil
+	}
+	content, err := encodeCSV(rows)
+	if err != nil {
+		return nil, err
+	}
+	return &SyncExport{Content: content, ContentType: "text/csv; charset=utf-8", Filename: filename}, nil
+}
+
+func inventoryExportRows(products []Product, location *time.Location) [][]any {
+	rows := [][]any{inventorySheetHeaders}
+	for _, product := range products {
+		var cost any
+		if product.Cost != nil {
+			cost = *product.Cost
+		}
+		// Las fotos subidas desde el punto de venta son data URL; solo se
+		// exportan los enlaces.
+		image := ""
+		if product.ImageURL != nil && strings.HasPrefix(*product.ImageURL, "http") {
+			image = *product.ImageURL
+		}
+		active := "No"
+		if product.Active {
+			active = "Sí"
+		}
+		updated := ""
+		if product.SheetSyncedAt != nil {
+			updated = product.SheetSyncedAt.In(location).Format(time.RFC3339)
+		}
+		rows = append(rows, []any{
+			product.ExternalID,
+			product.Name,
+			product.Category,
+			product.Price,
+			cost,
+			product.Stock,
+			image,
+			active,
+			updated,
+		})
+	}
+	return rows
+}
+
+func encodeCSV(rows [][]any) ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(utf8BOM)
+	writer := csv.NewWriter(&buffer)
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for index, value := range row {
+			switch typed := value.(type) {
+			case nil:
+			case float64:
+				record[index] = strconv.FormatFloat(typed, 'f', -1, 64)
+			default:
+				record[index] = fmt.Sprint(typed)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
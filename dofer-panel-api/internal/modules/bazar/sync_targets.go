@@ -0,0 +1,495 @@
+package bazar
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dofer/panel-api/internal/platform/secrets"
+	"github.com/google/uuid"
+)
+
+const (
+	TargetGoogleSheets = "google_sheets"
+	TargetFile         = "file"
+	TargetWebhook      = "webhook"
+)
+
+// utf8BOM encabeza los CSV para que Excel reconozca los acentos.
+const utf8BOM = "\ufeff"
+
+// errNoTargetInventory indica que el destino solo recibe ventas: no hay
+// inventario que importar ni conflictos que revisar.
+var errNoTargetInventory = errors.New("sync target does not publish inventory")
+
+// errInternalWebhookAddress se devuelve al intentar conectar a la red interna.
+var errInternalWebhookAddress = errors.New("webhook address is not public")
+
+// SyncTarget es un destino al que se reflejan el inventario y las ventas del
+// bazar. ReadProducts devuelve errNoTargetInventory si el destino no publica
+// inventario.
+type SyncTarget interface {
+	Kind() string
+	Configured() (bool, string)
+	ReadProducts(context.Context) ([]sheetProduct, error)
+	SyncSale(context.Context, *Sale, map[string]int) error
+}
+
+// SyncTargetResolver entrega el destino de cada organización en el momento
+// de usarlo.
+type SyncTargetResolver interface {
+	ForOrganization(ctx context.Context, organizationID string) (SyncTarget, error)
+}
+
+func targetLabel(kind string) string {
+	switch kind {
+	case TargetFile:
+		return "el archivo de inventario"
+	case TargetWebhook:
+		return "el webhook"
+	default:
+		return "Google Sheets"
+	}
+}
+
+// SyncTargets elige el destino según bazar_sync_targets; sin registro la
+// organización sigue con Google Sheets.
+type SyncTargets struct {
+	repo   *Repository
+	box    *secrets.Box
+	sheets *SheetsDirectory
+	client *http.Client
+}
+
+func NewSyncTargets(repo *Repository, box *secrets.Box, sheets *SheetsDirectory) *SyncTargets {
+	return &SyncTargets{
+		repo:   repo,
+		box:    box,
+		sheets: sheets,
+		client: newWebhookClient(),
+	}
+}
+
+func (t *SyncTargets) ForOrganization(ctx context.Context, organizationID string) (SyncTarget, error) {
+	record, err := t.repo.GetSyncTarget(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return t.sheets.ForOrganization(ctx, organizationID)
+	}
+
+	switch record.Kind {
+	case TargetFile:
+		return fileTarget{inventoryCSV: record.InventoryCSV}, nil
+	case TargetWebhook:
+		target := &webhookTarget{
+			organizationID: organizationID,
+			url:            record.WebhookURL,
+			inventoryURL:   record.WebhookInventoryURL,
+			client:         t.client,
+		}
+		if record.WebhookSecretEncrypted != "" {
+			secret, err := t.box.Open(record.WebhookSecretEncrypted)
+			if err != nil {
+				target.problem = "No se pudo leer el secreto guardado del webhook; vuelve a configurarlo."
+			}
+			target.secret = secret
+		}
+		return target, nil
+	default:
+		return t.sheets.ForOrganization(ctx, organizationID)
+	}
+}
+
+func (t *SyncTargets) Settings(ctx context.Context, organizationID string) (*SyncTargetSettings, error) {
+	record, err := t.repo.GetSyncTarget(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return &SyncTargetSettings{Kind: TargetGoogleSheets}, nil
+	}
+	return &SyncTargetSettings{
+		Kind:                record.Kind,
+		WebhookURL:          record.WebhookURL,
+		HasWebhookSecret:    record.WebhookSecretEncrypted != "",
+		WebhookInventoryURL: record.WebhookInventoryURL,
+		InventoryUploadedAt: record.InventoryUploadedAt,
+		UpdatedAt:           &record.UpdatedAt,
+	}, nil
+}
+
+func (t *SyncTargets) Configure(ctx context.Context, organizationID string, actorID uuid.UUID, req UpdateSyncTargetRequest) (*SyncTargetSettings, error) {
+	current, err := t.repo.GetSyncTarget(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	record := syncTargetRecord{Kind: strings.TrimSpace(req.Kind)}
+	switch record.Kind {
+	case TargetGoogleSheets, TargetFile:
+	case TargetWebhook:
+		if record.WebhookURL, err = normalizeTargetURL(ctx, req.WebhookURL, true); err != nil {
+			return nil, err
+		}
+		if record.WebhookInventoryURL, err = normalizeTargetURL(ctx, req.WebhookInventoryURL, false); err != nil {
+			return nil, err
+		}
+		switch {
+		case req.WebhookSecret == nil:
+			if current != nil {
+				record.WebhookSecretEncrypted = current.WebhookSecretEncrypted
+			}
+		case strings.TrimSpace(*req.WebhookSecret) != "":
+			if !t.box.Enabled() {
+				return nil, &serviceError{Status: http.StatusServiceUnavailable, Message: "Falta SECRETS_ENCRYPTION_KEY en el servidor para guardar credenciales."}
+			}
+			sealed, err := t.box.Seal(strings.TrimSpace(*req.WebhookSecret))
+			if err != nil {
+				return nil, err
+			}
+			record.WebhookSecretEncrypted = sealed
+		}
+	default:
+		return nil, &serviceError{Status: http.StatusBadRequest, Message: "El destino debe ser google_sheets, file o webhook."}
+	}
+
+	if err := t.repo.SaveSyncTarget(ctx, organizationID, record, actorID); err != nil {
+		return nil, err
+	}
+	return t.Settings(ctx, organizationID)
+}
+
+// UploadInventory guarda el CSV de inventario del destino de archivo después
+// de validarlo con las mismas reglas que la hoja de Google.
+func (t *SyncTargets) UploadInventory(ctx context.Context, organizationID string, actorID uuid.UUID, content []byte) (int, error) {
+	record, err := t.repo.GetSyncTarget(ctx, organizationID)
+	if err != nil {
+		return 0, err
+	}
+	if record == nil || record.Kind != TargetFile {
+		return 0, &serviceError{Status: http.StatusConflict, Message: "El inventario en archivo solo aplica con el destino file."}
+	}
+	products, err := parseInventoryCSV(string(content))
+	if err != nil {
+		return 0, &serviceError{Status: http.StatusBadRequest, Message: err.Error()}
+	}
+	if err := t.repo.SaveTargetInventory(ctx, organizationID, string(content), actorID); err != nil {
+		return 0, err
+	}
+	return len(products), nil
+}
+
+// normalizeTargetURL valida la URL del webhook y rechaza las que apuntan a la
+// red interna del servidor (loopback, privadas, link-local).
+func normalizeTargetURL(ctx context.Context, value string, required bool) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if required {
+			return "", &serviceError{Status: http.StatusBadRequest, Message: "La URL del webhook es obligatoria."}
+		}
+		return "", nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return "", &serviceError{Status: http.StatusBadRequest, Message: "La URL del webhook no es válida."}
+	}
+
+	addresses, err := resolveTargetHost(ctx, parsed.Hostname())
+	if err != nil || len(addresses) == 0 {
+		return "", &serviceError{Status: http.StatusBadRequest, Message: "No se pudo resolver el dominio del webhook."}
+	}
+	for _, address := range addresses {
+		if !isPublicAddress(address) {
+			return "", &serviceError{Status: http.StatusBadRequest, Message: "La URL del webhook debe apuntar a una dirección pública."}
+		}
+	}
+	return parsed.String(), nil
+}
+
+func resolveTargetHost(ctx context.Context, host string) ([]netip.Addr, error) {
+	if address, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{address}, nil
+	}
+	return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+}
+
+// nonPublicPrefixes completa lo que netip no clasifica: la red compartida de
+// los proveedores (CGNAT) y la red "esta red".
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+func isPublicAddress(address netip.Addr) bool {
+	address = address.Unmap()
+	if !address.IsValid() || address.IsLoopback() || address.IsPrivate() || address.IsUnspecified() ||
+		address.IsLinkLocalUnicast() || address.IsLinkLocalMulticast() ||
+		address.IsInterfaceLocalMulticast() || address.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(address) {
+			return false
+		}
+	}
+	return true
+}
+
+// newWebhookClient vuelve a revisar la dirección al conectar: el dominio
+// pudo cambiar de IP después de guardarse (DNS rebinding) o redirigir a la
+// red interna. Sin proxy, para que la revisión aplique al destino real.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !isPublicAddress(ip) {
+				return errInternalWebhookAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: 15 * time.Second, Transport: transport}
+}
+
+// fileTarget no envía nada: las ventas quedan en la exportación CSV/XLSX y
+// el inventario sale del último CSV que subió la organización.
+type fileTarget struct {
+	inventoryCSV string
+}
+
+func (fileTarget) Kind() string {
+	return TargetFile
+}
+
+func (fileTarget) Configured() (bool, string) {
+	return true, ""
+}
+
+func (f fileTarget) ReadProducts(context.Context) ([]sheetProduct, error) {
+	if strings.TrimSpace(f.inventoryCSV) == "" {
+		return nil, errNoTargetInventory
+	}
+	return parseInventoryCSV(f.inventoryCSV)
+}
+
+func (fileTarget) SyncSale(context.Context, *Sale, map[string]int) error {
+	return nil
+}
+
+func parseInventoryCSV(content string) ([]sheetProduct, error) {
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(content, utf8BOM)))
+	reader.FieldsPerRecord = -1
+	// Excel en español guarda los CSV con punto y coma.
+	if firstLine, _, _ := strings.Cut(content, "\n"); strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("el CSV no es válido: %w", err)
+	}
+	rows := make([][]any, 0, len(records))
+	for _, record := range records {
+		row := make([]any, len(record))
+		for index, value := range record {
+			row[index] = value
+		}
+		rows = append(rows, row)
+	}
+	return parseInventoryRows(rows)
+}
+
+// webhookTarget envía cada venta como JSON firmado con HMAC-SHA256 y, si
+// tiene inventoryURL, lee de ahí el inventario.
+type webhookTarget struct {
+	organizationID string
+	url            string
+	inventoryURL   string
+	secret         string
+	problem        string
+	client         *http.Client
+}
+
+type webhookSalePayload struct {
+	Event          string         `json:"event"`
+	OrganizationID string         `json:"organization_id"`
+	Sale           *Sale          `json:"sale"`
+	Stocks         map[string]int `json:"stocks"`
+	SentAt         time.Time      `json:"sent_at"`
+}
+
+type webhookProduct struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Price    float64  `json:"price"`
+	Cost     *float64 `json:"cost"`
+	Stock    int      `json:"stock"`
+	ImageURL *string  `json:"image_url"`
+	Active   *bool    `json:"active"`
+}
+
+func (w *webhookTarget) Kind() string {
+	return TargetWebhook
+}
+
+func (w *webhookTarget) Configured() (bool, string) {
+	if w.problem != "" {
+		return false, w.problem
+	}
+	if w.url == "" {
+		return false, "Falta la URL del webhook de sincronización."
+	}
+	return true, ""
+}
+
+func (w *webhookTarget) SyncSale(ctx context.Context, sale *Sale, stocks map[string]int) error {
+	if sale == nil {
+		return errors.New("missing sale")
+	}
+	event := "bazar.sale.completed"
+	idempotencyKey := sale.ExternalID + ":" + sale.Status
+	switch {
+	case sale.Status == "cancelled":
+		event = "bazar.sale.cancelled"
+	case len(sale.Returns) > 0:
+		// Cada devolución es un envío nuevo aunque la venta siga completada.
+		event = "bazar.sale.returned"
+		idempotencyKey = sale.ExternalID + ":" + sale.Returns[len(sale.Returns)-1].ExternalID
+	}
+	body, err := json.Marshal(webhookSalePayload{
+		Event:          event,
+		OrganizationID: w.organizationID,
+		Sale:           sale,
+		Stocks:         stocks,
+		SentAt:         time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Dofer-Event", event)
+	// El receptor puede descartar reenvíos con la misma llave.
+	request.Header.Set("Idempotency-Key", idempotencyKey)
+	w.sign(request, body)
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer response.Body.Close()
+	// El cuerpo de la respuesta no se guarda: sync_error lo ve todo el equipo
+	// y el receptor podría devolver datos internos.
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook respondió %d", response.StatusCode)
+	}
+	return nil
+}
+
+// ReadProducts acepta {"products": [...]} o directamente la lista.
+func (w *webhookTarget) ReadProducts(ctx context.Context) ([]sheetProduct, error) {
+	if w.inventoryURL == "" {
+		return nil, errNoTargetInventory
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, w.inventoryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	w.sign(request, nil)
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("inventario del webhook: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("inventario del webhook respondió %d", response.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	return parseWebhookProducts(body)
+}
+
+func (w *webhookTarget) sign(request *http.Request, body []byte) {
+	if w.secret == "" {
+		return
+	}
+	mac := hmac.New(sha256.New, []byte(w.secret))
+	mac.Write(body)
+	request.Header.Set("X-Dofer-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+func parseWebhookProducts(body []byte) ([]sheetProduct, error) {
+	var items []webhookProduct
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, fmt.Errorf("inventario del webhook inválido: %w", err)
+		}
+	} else {
+		var envelope struct {
+			Products []webhookProduct `json:"products"`
+		}
+		if err := json.Unmarshal(trimmed, &envelope); err != nil {
+			return nil, fmt.Errorf("inventario del webhook inválido: %w", err)
+		}
+		items = envelope.Products
+	}
+
+	products := make([]sheetProduct, 0, len(items))
+	for index, item := range items {
+		externalID := strings.TrimSpace(item.ID)
+		name := strings.TrimSpace(item.Name)
+		if externalID == "" || name == "" {
+			return nil, fmt.Errorf("producto %d: id y name son obligatorios", index+1)
+		}
+		if item.Price < 0 || item.Stock < 0 || (item.Cost != nil && *item.Cost < 0) {
+			return nil, fmt.Errorf("producto %s: precio, costo y stock no pueden ser negativos", externalID)
+		}
+		active := true
+		if item.Active != nil {
+			active = *item.Active
+		}
+		products = append(products, sheetProduct{
+			ExternalID: externalID,
+			Name:       name,
+			Category:   strings.TrimSpace(item.Category),
+			Price:      item.Price,
+			Cost:       item.Cost,
+			Stock:      item.Stock,
+			ImageURL:   item.ImageURL,
+			Active:     active,
+		})
+	}
+	return products, nil
+}
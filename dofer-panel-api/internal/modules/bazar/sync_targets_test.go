@@ -0,0 +1,158 @@
+package bazar
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseInventoryCSVAcceptsExcelSemicolons(t *testing.T) {
+	content := utf8BOM + "ID;Producto;Precio;Stock;Activo\nDOF-001;Capibara café;40;12;Sí\nDOF-002;Ajolote;55.5;0;No\n"
+	products, err := parseInventoryCSV(content)
+	if err != nil {
+		t.Fatalf("parseInventoryCSV() error = %v", err)
+	}
+	if len(products) != 2 || products[0].ExternalID != "DOF-001" || products[1].Price != 55.5 || products[1].Active {
+		t.Fatalf("unexpected products %+v", products)
+	}
+}
+
+func TestInventoryExportRoundTrip(t *testing.T) {
+	cost := 18.0
+	exported, err := encodeCSV(inventoryExportRows([]Product{
+		{ExternalID: "DOF-001", Name: "Capibara, café", Category: "Doflins", Price: 40, Cost: &cost, Stock: 12, Active: true},
+		{ExternalID: "DOF-002", Name: "Ajolote", Price: 55, Stock: 0, Active: false},
+	}, time.UTC))
+	if err != nil {
+		t.Fatalf("encodeCSV() error = %v", err)
+	}
+
+	products, err := parseInventoryCSV(string(exported))
+	if err != nil {
+		t.Fatalf("exported inventory does not parse back: %v", err)
+	}
+	if len(products) != 2 {
+		t.Fatalf("expected 2 products, got %d", len(products))
+	}
+	if products[0].Name != "Capibara, café" || products[0].Cost == nil || *products[0].Cost != 18 || !products[0].Active {
+		t.Fatalf("unexpected first product %+v", products[0])
+	}
+	if products[1].Cost != nil || products[1].Active {
+		t.Fatalf("unexpected second product %+v", products[1])
+	}
+}
+
+func TestFileTargetWithoutInventory(t *testing.T) {
+	if _, err := (fileTarget{}).ReadProducts(context.Background()); !errors.Is(err, errNoTargetInventory) {
+		t.Fatalf("expected errNoTargetInventory, got %v", err)
+	}
+}
+
+func TestWebhookTargetSignsSales(t *testing.T) {
+	var received webhookSalePayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("secreto"))
+		mac.Write(body)
+		if r.Header.Get("X-Dofer-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Idempotency-Key") != "V-1:cancelled" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	target := &webhookTarget{organizationID: "org-1", url: server.URL, secret: "secreto", client: server.Client()}
+	sale := &Sale{ExternalID: "V-1", Status: "cancelled", Items: []SaleItem{}}
+	if err := target.SyncSale(context.Background(), sale, map[string]int{"DOF-001": 3}); err != nil {
+		t.Fatalf("SyncSale() error = %v", err)
+	}
+	if received.Event != "bazar.sale.cancelled" || received.Stocks["DOF-001"] != 3 || received.OrganizationID != "org-1" {
+		t.Fatalf("unexpected payload %+v", received)
+	}
+
+	target.secret = "otro"
+	if err := target.SyncSale(context.Background(), sale, nil); err == nil {
+		t.Fatalf("expected error when the receiver rejects the signature")
+	}
+}
+
+func TestParseWebhookProducts(t *testing.T) {
+	products, err := parseWebhookProducts([]byte(`{"products":[{"id":"DOF-001","name":"Capibara","price":40,"stock":3,"active":false}]}`))
+	if err != nil {
+		t.Fatalf("parseWebhookProducts() error = %v", err)
+	}
+	if len(products) != 1 || products[0].Stock != 3 || products[0].Active {
+		t.Fatalf("unexpected products %+v", products)
+	}
+
+	products, err = parseWebhookProducts([]byte(`[{"id":"DOF-002","name":"Ajolote","price":55,"stock":0}]`))
+	if err != nil || len(products) != 1 || !products[0].Active {
+		t.Fatalf("expected bare list to be accepted, got %+v, %v", products, err)
+	}
+
+	if _, err := parseWebhookProducts([]byte(`[{"id":"DOF-003","name":"X","price":-1,"stock":0}]`)); err == nil {
+		t.Fatalf("expected negative price to be rejected")
+	}
+}
+
+func TestNormalizeTargetURLRejectsInternalAddresses(t *testing.T) {
+	for _, raw := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://10.0.0.5/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[::ffff:192.168.1.10]/hook",
+		"http://100.64.0.1/hook",
+		"ftp://8.8.8.8/hook",
+	} {
+		if _, err := normalizeTargetURL(context.Background(), raw, true); err == nil {
+			t.Fatalf("expected %s to be rejected", raw)
+		}
+	}
+
+	normalized, err := normalizeTargetURL(context.Background(), " https://8.8.8.8/hook ", true)
+	if err != nil || normalized != "https://8.8.8.8/hook" {
+		t.Fatalf("expected public address to be accepted, got %q, %v", normalized, err)
+	}
+}
+
+func TestWebhookClientRefusesInternalConnections(t *testing.T) {
+	reached := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer server.Close()
+
+	target := &webhookTarget{url: server.URL, client: newWebhookClient()}
+	err := target.SyncSale(context.Background(), &Sale{ExternalID: "V-1", Status: "completed"}, nil)
+	if !errors.Is(err, errInternalWebhookAddress) || reached {
+		t.Fatalf("expected the loopback connection to be refused, got %v (reached=%v)", err, reached)
+	}
+}
+
+func TestWebhookSyncErrorOmitsResponseBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "token=interno", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	target := &webhookTarget{url: server.URL, client: server.Client()}
+	err := target.SyncSale(context.Background(), &Sale{ExternalID: "V-1", Status: "completed"}, nil)
+	if err == nil || err.Error() != "webhook respondió 502" {
+		t.Fatalf("expected only the status code, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package bazar
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	maxSaleSyncAttempts = 8
+	saleSyncBaseDelay   = 30 * time.Second
+	maxSaleSyncDelay    = time.Hour
+	saleSyncLease       = 2 * time.Minute
+	saleSyncTimeout     = 20 * time.Second
+)
+
+type SyncWorkerResult struct {
+	Organizations int
+	Skipped       int
+	Claimed       int
+	Synced        int
+	Retried       int
+	DeadLettered  int
+}
+
+// SyncWorker vacía periódicamente la cola de ventas pendientes de enviar al
+// destino de sincronización de cada organización. El estado vive en
+// bazar_sales, así que un reinicio retoma la cola donde quedó.
+type SyncWorker struct {
+	service   *Service
+	batchSize int
+}
+
+func NewSyncWorker(service *Service) *SyncWorker {
+	return &SyncWorker{service: service, batchSize: 20}
+}
+
+// RunOnce procesa un lote por organización. Al cancelarse ctx termina la
+// venta en curso y deja las demás apartadas para el siguiente arranque.
+func (w *SyncWorker) RunOnce(ctx context.Context) (SyncWorkerResult, error) {
+	result := SyncWorkerResult{}
+
+	organizations, err := w.service.repo.ListOrganizationsWithDueSales(ctx)
+	if err != nil {
+		return result, fmt.Errorf("list organizations: %w", err)
+	}
+
+	for _, organizationID := range organizations {
+		if ctx.Err() != nil {
+			return result, nil
+		}
+		result.Organizations++
+
+		target, err := w.service.targets.ForOrganization(ctx, organizationID)
+		if err != nil {
+			slog.Error("no se pudo resolver el destino de sincronización del bazar", "organization_id", organizationID, "error", err)
+			continue
+		}
+		// Sin configuración no se gastan intentos: las ventas esperan.
+		if configured, _ := target.Configured(); !configured {
+			result.Skipped++
+			continue
+		}
+
+		sales, err := w.service.repo.ClaimDueSales(ctx, organizationID, w.batchSize, saleSyncLease)
+		if err != nil {
+			return result, fmt.Errorf("claim sales: %w", err)
+		}
+		result.Claimed += len(sales)
+
+		for index := range sales {
+			if ctx.Err() != nil {
+				return result, nil
+			}
+			saleCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), saleSyncTimeout)
+			err := w.service.syncSale(saleCtx, target, organizationID, &sales[index])
+			cancel()
+			switch {
+			case err == nil:
+				result.Synced++
+			case sales[index].SyncAttempts+1 >= maxSaleSyncAttempts:
+				result.DeadLettered++
+			default:
+				result.Retried++
+			}
+		}
+	}
+	return result, nil
+}
+
+// Run ejecuta RunOnce cada interval hasta que se cancele ctx.
+func (w *SyncWorker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := w.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("bazar sync worker failed", slog.Any("error", err))
+		} else if result.Claimed > 0 {
+			slog.Info(
+				"bazar sync worker completed",
+				slog.Int("organizations", result.Organizations),
+				slog.Int("claimed", result.Claimed),
+				slog.Int("synced", result.Synced),
+				slog.Int("retried", result.Retried),
+				slog.Int("dead_lettered", result.DeadLettered),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// saleSyncRetryDelay crece de forma exponencial desde 30 segundos hasta una
+// hora.
+func saleSyncRetryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := saleSyncBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxSaleSyncDelay {
+			return maxSaleSyncDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,23 @@
+package bazar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSaleSyncRetryDelay(t *testing.T) {
+	cases := map[int]time.Duration{
+		0:  30 * time.Second,
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		7:  32 * time.Minute,
+		8:  time.Hour,
+		50: time.Hour,
+	}
+	for attempts, expected := range cases {
+		if got := saleSyncRetryDelay(attempts); got != expected {
+			t.Fatalf("saleSyncRetryDelay(%d) = %v, want %v", attempts, got, expected)
+		}
+	}
+}
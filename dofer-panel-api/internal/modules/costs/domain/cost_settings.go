@@ -7,6 +7,12 @@ import (
 
 var ErrMaterialNotFound = errors.New("material not found")
 
+// Origen del costo por gramo usado en un cálculo.
+const (
+	MaterialCostFromInventory = "inventory"
+	MaterialCostFromSettings  = "settings"
+)
+
 type CostSettings struct {
 	ID                     string    `json:"id"`
 	OrganizationID         string    `json:"organization_id,omitempty"`
@@ -38,6 +44,10 @@ type CostBreakdown struct {
 	UnitPrice       float64 `json:"unit_price"`
 	Total           float64 `json:"total"`
 	MaterialName    string  `json:"material_name,omitempty"`
+	// Costo por gramo aplicado: el promedio ponderado de los carretes en
+	// existencia o, si no hay, el configurado para el material.
+	MaterialCostPerGram float64 `json:"material_cost_per_gram"`
+	MaterialCostSource  string  `json:"material_cost_source"`
 }
 
 type CostSettingsRepository interface {
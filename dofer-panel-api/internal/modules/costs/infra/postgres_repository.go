@@ -179,8 +179,19 @@ func (r *PostgresCostSettingsRepository) CalculateCost(input domain.CalculationI
 		}
 	}
 
+	costPerGram := settings.MaterialCostPerGram
+	costSource := domain.MaterialCostFromSettings
+	inventoryCost, err := r.inventoryCostPerGram(input.OrganizationID, settings.MaterialName)
+	if err != nil {
+		return nil, err
+	}
+	if inventoryCost > 0 {
+		costPerGram = inventoryCost
+		costSource = domain.MaterialCostFromInventory
+	}
+
 	// Calcular costos con redondeo monetario consistente.
-	materialCost := roundMoney(input.WeightGrams * settings.MaterialCostPerGram)
+	materialCost := roundMoney(input.WeightGrams * costPerGram)
 	laborCost := roundMoney(input.PrintTimeHours * settings.LaborCostPerHour)
 	electricityCost := roundMoney(input.PrintTimeHours * settings.ElectricityCostPerHour)
 	otherCosts := roundMoney(input.OtherCosts)
@@ -200,9 +211,33 @@ func (r *PostgresCostSettingsRepository) CalculateCost(input domain.CalculationI
 		UnitPrice:       unitPrice,
 		Total:           total,
 		MaterialName:    settings.MaterialName,
+
+		MaterialCostPerGram: costPerGram,
+		MaterialCostSource:  costSource,
 	}, nil
 }
 
+// inventoryCostPerGram es el costo promedio de los carretes del material que
+// siguen en existencia, ponderado por los gramos que le quedan a cada uno.
+// Devuelve 0 si no hay carretes con costo registrado.
+func (r *PostgresCostSettingsRepository) inventoryCostPerGram(organizationID, materialName string) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(remaining_grams * cost / initial_grams) / NULLIF(SUM(remaining_grams), 0), 0)::float8
+		FROM material_spools
+		WHERE UPPER(material) = UPPER(TRIM($1))
+		  AND ($2 = '' OR organization_id::text = $2)
+		  AND is_archived = false
+		  AND remaining_grams > 0
+		  AND cost > 0
+	`
+
+	var costPerGram float64
+	if err := r.db.QueryRow(context.Background(), query, materialName, organizationID).Scan(&costPerGram); err != nil {
+		return 0, err
+	}
+	return math.Round(costPerGram*10000) / 10000, nil
+}
+
 func roundMoney(value float64) float64 {
 	return math.Round(value*100) / 100
 }
@@ -0,0 +1,101 @@
+package materials
+
+import (
+	"context"
+	"log/slog"
+
+	ordersDomain "github.com/dofer/panel-api/internal/modules/orders/domain"
+	"github.com/google/uuid"
+)
+
+// spoolBalance es lo que hace falta de un carrete para repartir un consumo.
+type spoolBalance struct {
+	ID             uuid.UUID
+	RemainingGrams float64
+	CostPerGram    float64
+}
+
+// allocation es la parte de un consumo que sale de un carrete. SpoolID nil
+// es el faltante que ningún carrete pudo cubrir.
+type allocation struct {
+	SpoolID *uuid.UUID
+	Grams   float64
+	Cost    float64
+}
+
+// allocate reparte grams entre los carretes en el orden recibido (primero
+// los más vacíos, para terminar los abiertos antes de abrir otro).
+func allocate(spools []spoolBalance, grams float64) []allocation {
+	allocations := make([]allocation, 0, 1)
+	pending := roundGrams(grams)
+
+	for _, spool := range spools {
+		if pending <= 0 {
+			break
+		}
+		if spool.RemainingGrams <= 0 {
+			continue
+		}
+		taken := pending
+		if spool.RemainingGrams < taken {
+			taken = spool.RemainingGrams
+		}
+		taken = roundGrams(taken)
+		id := spool.ID
+		allocations = append(allocations, allocation{
+			SpoolID: &id,
+			Grams:   taken,
+			Cost:    roundGrams(taken * spool.CostPerGram),
+		})
+		pending = roundGrams(pending - taken)
+	}
+
+	if pending > 0 {
+		allocations = append(allocations, allocation{Grams: pending})
+	}
+	return allocations
+}
+
+// ConsumptionResult resume lo que se descontó en una llamada.
+type ConsumptionResult struct {
+	Consumptions   []Consumption   `json:"consumptions"`
+	ShortfallGrams float64         `json:"shortfall_grams"`
+	LowStock       []MaterialStock `json:"low_stock,omitempty"`
+}
+
+// ConsumeOrder descuenta el filamento de todos los items de la orden que aún
+// no tienen consumo. Se llama al detener el timer.
+func (r *Repository) ConsumeOrder(ctx context.Context, orderID, organizationID string) error {
+	_, err := r.consumeOrderItems(ctx, organizationID, orderID, "", SourceTimerStopped)
+	return err
+}
+
+// ConsumeOrderItem descuenta el filamento de un item al completarlo.
+func (r *Repository) ConsumeOrderItem(ctx context.Context, orderID, itemID, organizationID string) error {
+	_, err := r.consumeOrderItems(ctx, organizationID, orderID, itemID, SourceItemCompleted)
+	return err
+}
+
+var _ ordersDomain.MaterialConsumer = (*Repository)(nil)
+
+// ConsumingTimer envuelve el repositorio de timers para que las órdenes que
+// detiene la telemetría de impresoras también descuenten filamento.
+type ConsumingTimer struct {
+	ordersDomain.TimerRepository
+	consumer ordersDomain.MaterialConsumer
+}
+
+func NewConsumingTimer(timers ordersDomain.TimerRepository, consumer ordersDomain.MaterialConsumer) *ConsumingTimer {
+	return &ConsumingTimer{TimerRepository: timers, consumer: consumer}
+}
+
+func (t *ConsumingTimer) StopTimer(orderID, organizationID string) error {
+	if err := t.TimerRepository.StopTimer(orderID, organizationID); err != nil {
+		return err
+	}
+	// Un error de inventario no debe deshacer el timer ya detenido.
+	if err := t.consumer.ConsumeOrder(context.Background(), orderID, organizationID); err != nil {
+		slog.Error("failed to consume order material", "order_id", orderID, "error", err)
+	}
+	return nil
+}
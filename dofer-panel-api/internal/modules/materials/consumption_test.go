@@ -0,0 +1,53 @@
+package materials
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAllocateEmptiesOpenSpoolsFirst(t *testing.T) {
+	open := spoolBalance{ID: uuid.New(), RemainingGrams: 120, CostPerGram: 0.4}
+	fresh := spoolBalance{ID: uuid.New(), RemainingGrams: 1000, CostPerGram: 0.5}
+
+	allocations := allocate([]spoolBalance{open, fresh}, 300)
+
+	if len(allocations) != 2 {
+		t.Fatalf("expected 2 allocations, got %+v", allocations)
+	}
+	if *allocations[0].SpoolID != open.ID || allocations[0].Grams != 120 || allocations[0].Cost != 48 {
+		t.Fatalf("unexpected first allocation %+v", allocations[0])
+	}
+	if *allocations[1].SpoolID != fresh.ID || allocations[1].Grams != 180 || allocations[1].Cost != 90 {
+		t.Fatalf("unexpected second allocation %+v", allocations[1])
+	}
+}
+
+func TestAllocateRecordsShortfall(t *testing.T) {
+	spool := spoolBalance{ID: uuid.New(), RemainingGrams: 50.5, CostPerGram: 0.3}
+
+	allocations := allocate([]spoolBalance{spool, {ID: uuid.New()}}, 80)
+
+	if len(allocations) != 2 {
+		t.Fatalf("expected spool and shortfall allocations, got %+v", allocations)
+	}
+	if allocations[0].Grams != 50.5 {
+		t.Fatalf("expected the whole spool to be used, got %+v", allocations[0])
+	}
+	if allocations[1].SpoolID != nil || allocations[1].Grams != 29.5 || allocations[1].Cost != 0 {
+		t.Fatalf("unexpected shortfall %+v", allocations[1])
+	}
+
+	if none := allocate(nil, 10); len(none) != 1 || none[0].SpoolID != nil || none[0].Grams != 10 {
+		t.Fatalf("without stock everything is shortfall, got %+v", none)
+	}
+}
+
+func TestIsLowStock(t *testing.T) {
+	if !isLowStock(100, 100) || isLowStock(100.01, 100) {
+		t.Fatal("stock at or below the threshold is low")
+	}
+	if isLowStock(0, 0) {
+		t.Fatal("a zero threshold disables the alert")
+	}
+}
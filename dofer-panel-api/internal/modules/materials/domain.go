@@ -0,0 +1,103 @@
+package materials
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SourceTimerStopped  = "timer_stopped"
+	SourceItemCompleted = "item_completed"
+	SourceManual        = "manual"
+)
+
+type Spool struct {
+	ID                     uuid.UUID `json:"id"`
+	OrganizationID         uuid.UUID `json:"organization_id"`
+	Material               string    `json:"material"`
+	Color                  *string   `json:"color,omitempty"`
+	Brand                  *string   `json:"brand,omitempty"`
+	InitialGrams           float64   `json:"initial_grams"`
+	RemainingGrams         float64   `json:"remaining_grams"`
+	Cost                   float64   `json:"cost"`
+	CostPerGram            float64   `json:"cost_per_gram"`
+	Location               *string   `json:"location,omitempty"`
+	LowStockThresholdGrams float64   `json:"low_stock_threshold_grams"`
+	IsArchived             bool      `json:"is_archived"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+// Consumption es un descuento de filamento. SpoolID es nil cuando no había
+// existencias del material y el consumo quedó como faltante.
+type Consumption struct {
+	ID          uuid.UUID  `json:"id"`
+	SpoolID     *uuid.UUID `json:"spool_id,omitempty"`
+	Material    string     `json:"material"`
+	Grams       float64    `json:"grams"`
+	Cost        float64    `json:"cost"`
+	OrderID     *string    `json:"order_id,omitempty"`
+	OrderItemID *string    `json:"order_item_id,omitempty"`
+	Source      string     `json:"source"`
+	Notes       *string    `json:"notes,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// MaterialStock resume las existencias de un material. Está bajo cuando lo
+// que queda en todos sus carretes no supera el umbral más alto de ellos.
+type MaterialStock struct {
+	Material               string  `json:"material"`
+	Spools                 int     `json:"spools"`
+	RemainingGrams         float64 `json:"remaining_grams"`
+	LowStockThresholdGrams float64 `json:"low_stock_threshold_grams"`
+	AverageCostPerGram     float64 `json:"average_cost_per_gram"`
+	LowStock               bool    `json:"low_stock"`
+}
+
+type CreateSpoolRequest struct {
+	Material               string   `json:"material"`
+	Color                  *string  `json:"color,omitempty"`
+	Brand                  *string  `json:"brand,omitempty"`
+	InitialGrams           float64  `json:"initial_grams"`
+	RemainingGrams         *float64 `json:"remaining_grams,omitempty"` // Por defecto igual a initial_grams
+	Cost                   float64  `json:"cost"`
+	Location               *string  `json:"location,omitempty"`
+	LowStockThresholdGrams *float64 `json:"low_stock_threshold_grams,omitempty"`
+}
+
+type UpdateSpoolRequest struct {
+	Material               *string  `json:"material,omitempty"`
+	Color                  *string  `json:"color,omitempty"`
+	Brand                  *string  `json:"brand,omitempty"`
+	InitialGrams           *float64 `json:"initial_grams,omitempty"`
+	RemainingGrams         *float64 `json:"remaining_grams,omitempty"`
+	Cost                   *float64 `json:"cost,omitempty"`
+	Location               *string  `json:"location,omitempty"`
+	LowStockThresholdGrams *float64 `json:"low_stock_threshold_grams,omitempty"`
+	IsArchived             *bool    `json:"is_archived,omitempty"`
+}
+
+// ConsumeSpoolRequest registra un descuento manual (purgas, pruebas, mermas).
+type ConsumeSpoolRequest struct {
+	Grams float64 `json:"grams"`
+	Notes *string `json:"notes,omitempty"`
+}
+
+type ConsumptionFilters struct {
+	OrderID string
+	SpoolID string
+	Limit   int
+	Offset  int
+}
+
+var (
+	ErrSpoolNotFound         = errors.New("spool not found")
+	ErrInvalidSpoolID        = errors.New("invalid spool ID")
+	ErrMaterialRequired      = errors.New("material is required")
+	ErrInvalidGrams          = errors.New("grams must be greater than zero")
+	ErrRemainingExceedsTotal = errors.New("remaining_grams cannot exceed initial_grams")
+	ErrInvalidCost           = errors.New("cost cannot be negative")
+	ErrInsufficientStock     = errors.New("spool does not have enough filament")
+)
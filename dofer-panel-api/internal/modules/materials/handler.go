@@ -0,0 +1,214 @@
+package materials
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Route("/materials", func(r chi.Router) {
+		r.Use(middleware.RequireAuth)
+		// Excluye explícitamente el rol "affiliate": no debe ver costos internos.
+		r.Use(middleware.RequireRole("admin", "operator", "viewer"))
+
+		r.Get("/stock", h.Stock)
+		r.Get("/consumptions", h.ListConsumptions)
+
+		r.Get("/spools", h.ListSpools)
+		r.Post("/spools", h.CreateSpool)
+		r.Get("/spools/{id}", h.GetSpool)
+		r.Put("/spools/{id}", h.UpdateSpool)
+		r.Delete("/spools/{id}", h.DeleteSpool)
+		r.Post("/spools/{id}/consume", h.ConsumeSpool)
+	})
+}
+
+func organizationIDFromRequest(r *http.Request) string {
+	organizationID, _ := middleware.OrganizationIDFromContext(r.Context())
+	return organizationID
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrSpoolNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case ErrInvalidSpoolID, ErrMaterialRequired, ErrInvalidGrams, ErrRemainingExceedsTotal, ErrInvalidCost:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case ErrInsufficientStock:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func spoolIDFromRequest(r *http.Request) (uuid.UUID, error) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return uuid.Nil, ErrInvalidSpoolID
+	}
+	return id, nil
+}
+
+func (h *Handler) ListSpools(w http.ResponseWriter, r *http.Request) {
+	includeArchived, _ := strconv.ParseBool(r.URL.Query().Get("include_archived"))
+
+	spools, err := h.repo.ListSpools(r.Context(), organizationIDFromRequest(r), r.URL.Query().Get("material"), includeArchived)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"spools": spools})
+}
+
+func (h *Handler) GetSpool(w http.ResponseWriter, r *http.Request) {
+	id, err := spoolIDFromRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	spool, err := h.repo.GetSpool(r.Context(), organizationIDFromRequest(r), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(spool)
+}
+
+func (h *Handler) CreateSpool(w http.ResponseWriter, r *http.Request) {
+	var req CreateSpoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	spool, err := h.repo.CreateSpool(r.Context(), organizationIDFromRequest(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(spool)
+}
+
+func (h *Handler) UpdateSpool(w http.ResponseWriter, r *http.Request) {
+	id, err := spoolIDFromRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req UpdateSpoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	spool, err := h.repo.UpdateSpool(r.Context(), organizationIDFromRequest(r), id, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(spool)
+}
+
+func (h *Handler) DeleteSpool(w http.ResponseWriter, r *http.Request) {
+	id, err := spoolIDFromRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.repo.DeleteSpool(r.Context(), organizationIDFromRequest(r), id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ConsumeSpool(w http.ResponseWriter, r *http.Request) {
+	id, err := spoolIDFromRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req ConsumeSpoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.repo.ConsumeSpool(r.Context(), organizationIDFromRequest(r), id, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Stock devuelve las existencias por material; con low_stock=true solo los
+// materiales en alerta.
+func (h *Handler) Stock(w http.ResponseWriter, r *http.Request) {
+	stock, err := h.repo.Stock(r.Context(), organizationIDFromRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if onlyLow, _ := strconv.ParseBool(r.URL.Query().Get("low_stock")); onlyLow {
+		filtered := make([]MaterialStock, 0)
+		for _, item := range stock {
+			if item.LowStock {
+				filtered = append(filtered, item)
+			}
+		}
+		stock = filtered
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"materials": stock})
+}
+
+func (h *Handler) ListConsumptions(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	consumptions, err := h.repo.ListConsumptions(r.Context(), organizationIDFromRequest(r), ConsumptionFilters{
+		OrderID: r.URL.Query().Get("order_id"),
+		SpoolID: r.URL.Query().Get("spool_id"),
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"consumptions": consumptions})
+}
@@ -0,0 +1,413 @@
+package materials
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	spoolSelectColumns = `id, organization_id, material, color, brand, initial_grams, remaining_grams, cost,
+		location, low_stock_threshold_grams, is_archived, created_at, updated_at`
+	defaultLowStockThresholdGrams = 100.0
+)
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+func normalizeMaterial(raw string) string {
+	return strings.ToUpper(strings.TrimSpace(raw))
+}
+
+func sanitizeOptionalString(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func roundGrams(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func scanSpool(row pgx.Row) (*Spool, error) {
+	var spool Spool
+	var color, brand, location sql.NullString
+
+	err := row.Scan(
+		&spool.ID,
+		&spool.OrganizationID,
+		&spool.Material,
+		&color,
+		&brand,
+		&spool.InitialGrams,
+		&spool.RemainingGrams,
+		&spool.Cost,
+		&location,
+		&spool.LowStockThresholdGrams,
+		&spool.IsArchived,
+		&spool.CreatedAt,
+		&spool.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if color.Valid {
+		spool.Color = &color.String
+	}
+	if brand.Valid {
+		spool.Brand = &brand.String
+	}
+	if location.Valid {
+		spool.Location = &location.String
+	}
+	if spool.InitialGrams > 0 {
+		spool.CostPerGram = math.Round(spool.Cost/spool.InitialGrams*10000) / 10000
+	}
+
+	return &spool, nil
+}
+
+func (r *Repository) ListSpools(ctx context.Context, organizationID, material string, includeArchived bool) ([]Spool, error) {
+	query := `SELECT ` + spoolSelectColumns + ` FROM material_spools WHERE organization_id = $1`
+	args := []interface{}{organizationID}
+
+	if !includeArchived {
+		query += " AND is_archived = false"
+	}
+	if normalized := normalizeMaterial(material); normalized != "" {
+		query += " AND UPPER(material) = $2"
+		args = append(args, normalized)
+	}
+	query += " ORDER BY UPPER(material), remaining_grams ASC, created_at ASC"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spools := make([]Spool, 0)
+	for rows.Next() {
+		spool, err := scanSpool(rows)
+		if err != nil {
+			return nil, err
+		}
+		spools = append(spools, *spool)
+	}
+
+	return spools, rows.Err()
+}
+
+func (r *Repository) GetSpool(ctx context.Context, organizationID string, id uuid.UUID) (*Spool, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+spoolSelectColumns+` FROM material_spools WHERE id = $1 AND organization_id = $2`, id, organizationID)
+	spool, err := scanSpool(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSpoolNotFound
+	}
+	return spool, err
+}
+
+func (r *Repository) CreateSpool(ctx context.Context, organizationID string, req CreateSpoolRequest) (*Spool, error) {
+	material := normalizeMaterial(req.Material)
+	if material == "" {
+		return nil, ErrMaterialRequired
+	}
+	if req.InitialGrams <= 0 {
+		return nil, ErrInvalidGrams
+	}
+	if req.Cost < 0 {
+		return nil, ErrInvalidCost
+	}
+
+	remaining := req.InitialGrams
+	if req.RemainingGrams != nil {
+		remaining = *req.RemainingGrams
+	}
+	if remaining < 0 {
+		return nil, ErrInvalidGrams
+	}
+	if remaining > req.InitialGrams {
+		return nil, ErrRemainingExceedsTotal
+	}
+
+	threshold := defaultLowStockThresholdGrams
+	if req.LowStockThresholdGrams != nil && *req.LowStockThresholdGrams >= 0 {
+		threshold = *req.LowStockThresholdGrams
+	}
+
+	query := `
+		INSERT INTO material_spools (
+			organization_id, material, color, brand, initial_grams, remaining_grams, cost, location, low_stock_threshold_grams
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + spoolSelectColumns
+
+	row := r.db.QueryRow(
+		ctx,
+		query,
+		organizationID,
+		material,
+		sanitizeOptionalString(req.Color),
+		sanitizeOptionalString(req.Brand),
+		req.InitialGrams,
+		remaining,
+		req.Cost,
+		sanitizeOptionalString(req.Location),
+		threshold,
+	)
+
+	return scanSpool(row)
+}
+
+func (r *Repository) UpdateSpool(ctx context.Context, organizationID string, id uuid.UUID, req UpdateSpoolRequest) (*Spool, error) {
+	current, err := r.GetSpool(ctx, organizationID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "UPDATE material_spools SET updated_at = CURRENT_TIMESTAMP"
+	args := []interface{}{}
+	argNum := 1
+
+	if req.Material != nil {
+		material := normalizeMaterial(*req.Material)
+		if material == "" {
+			return nil, ErrMaterialRequired
+		}
+		query += fmt.Sprintf(", material = $%d", argNum)
+		args = append(args, material)
+		argNum++
+	}
+
+	if req.Color != nil {
+		query += fmt.Sprintf(", color = $%d", argNum)
+		args = append(args, sanitizeOptionalString(req.Color))
+		argNum++
+	}
+
+	if req.Brand != nil {
+		query += fmt.Sprintf(", brand = $%d", argNum)
+		args = append(args, sanitizeOptionalString(req.Brand))
+		argNum++
+	}
+
+	initial := current.InitialGrams
+	if req.InitialGrams != nil {
+		if *req.InitialGrams <= 0 {
+			return nil, ErrInvalidGrams
+		}
+		initial = *req.InitialGrams
+		query += fmt.Sprintf(", initial_grams = $%d", argNum)
+		args = append(args, initial)
+		argNum++
+	}
+
+	remaining := current.RemainingGrams
+	if req.RemainingGrams != nil {
+		if *req.RemainingGrams < 0 {
+			return nil, ErrInvalidGrams
+		}
+		remaining = *req.RemainingGrams
+		query += fmt.Sprintf(", remaining_grams = $%d", argNum)
+		args = append(args, remaining)
+		argNum++
+	}
+	if remaining > initial {
+		return nil, ErrRemainingExceedsTotal
+	}
+
+	if req.Cost != nil {
+		if *req.Cost < 0 {
+			return nil, ErrInvalidCost
+		}
+		query += fmt.Sprintf(", cost = $%d", argNum)
+		args = append(args, *req.Cost)
+		argNum++
+	}
+
+	if req.Location != nil {
+		query += fmt.Sprintf(", location = $%d", argNum)
+		args = append(args, sanitizeOptionalString(req.Location))
+		argNum++
+	}
+
+	if req.LowStockThresholdGrams != nil {
+		if *req.LowStockThresholdGrams < 0 {
+			return nil, ErrInvalidGrams
+		}
+		query += fmt.Sprintf(", low_stock_threshold_grams = $%d", argNum)
+		args = append(args, *req.LowStockThresholdGrams)
+		argNum++
+	}
+
+	if req.IsArchived != nil {
+		query += fmt.Sprintf(", is_archived = $%d", argNum)
+		args = append(args, *req.IsArchived)
+		argNum++
+	}
+
+	query += fmt.Sprintf(" WHERE id = $%d AND organization_id = $%d RETURNING %s", argNum, argNum+1, spoolSelectColumns)
+	args = append(args, id, organizationID)
+
+	spool, err := scanSpool(r.db.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSpoolNotFound
+	}
+	return spool, err
+}
+
+// DeleteSpool borra el carrete; su historial de consumo se conserva sin
+// referencia al carrete.
+func (r *Repository) DeleteSpool(ctx context.Context, organizationID string, id uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM material_spools WHERE id = $1 AND organization_id = $2`, id, organizationID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSpoolNotFound
+	}
+	return nil
+}
+
+// Stock agrupa los carretes activos por material. El costo promedio se
+// pondera por los gramos que quedan en cada carrete.
+func (r *Repository) Stock(ctx context.Context, organizationID string) ([]MaterialStock, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT UPPER(material),
+		       COUNT(*)::int,
+		       COALESCE(SUM(remaining_grams), 0)::float8,
+		       COALESCE(MAX(low_stock_threshold_grams), 0)::float8,
+		       COALESCE(
+		           SUM(remaining_grams * cost / initial_grams) FILTER (WHERE cost > 0)
+		           / NULLIF(SUM(remaining_grams) FILTER (WHERE cost > 0), 0),
+		           0
+		       )::float8
+		FROM material_spools
+		WHERE organization_id = $1 AND is_archived = false
+		GROUP BY UPPER(material)
+		ORDER BY UPPER(material)
+	`, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stock := make([]MaterialStock, 0)
+	for rows.Next() {
+		var item MaterialStock
+		if err := rows.Scan(
+			&item.Material,
+			&item.Spools,
+			&item.RemainingGrams,
+			&item.LowStockThresholdGrams,
+			&item.AverageCostPerGram,
+		); err != nil {
+			return nil, err
+		}
+		item.RemainingGrams = roundGrams(item.RemainingGrams)
+		item.AverageCostPerGram = math.Round(item.AverageCostPerGram*10000) / 10000
+		item.LowStock = isLowStock(item.RemainingGrams, item.LowStockThresholdGrams)
+		stock = append(stock, item)
+	}
+
+	return stock, rows.Err()
+}
+
+func isLowStock(remaining, threshold float64) bool {
+	return threshold > 0 && remaining <= threshold
+}
+
+func (r *Repository) ListConsumptions(ctx context.Context, organizationID string, filters ConsumptionFilters) ([]Consumption, error) {
+	query := `
+		SELECT id, spool_id, material, grams, cost, order_id::text, order_item_id::text, source, notes, created_at
+		FROM material_consumptions
+		WHERE organization_id = $1
+	`
+	args := []interface{}{organizationID}
+	argNum := 2
+
+	if filters.OrderID != "" {
+		query += fmt.Sprintf(" AND order_id::text = $%d", argNum)
+		args = append(args, filters.OrderID)
+		argNum++
+	}
+	if filters.SpoolID != "" {
+		query += fmt.Sprintf(" AND spool_id::text = $%d", argNum)
+		args = append(args, filters.SpoolID)
+		argNum++
+	}
+
+	query += " ORDER BY created_at DESC"
+
+	limit := filters.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	query += fmt.Sprintf(" LIMIT $%d", argNum)
+	args = append(args, limit)
+	argNum++
+
+	if filters.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filters.Offset)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	consumptions := make([]Consumption, 0)
+	for rows.Next() {
+		var item Consumption
+		var spoolID *uuid.UUID
+		var orderID, orderItemID, notes sql.NullString
+
+		if err := rows.Scan(
+			&item.ID,
+			&spoolID,
+			&item.Material,
+			&item.Grams,
+			&item.Cost,
+			&orderID,
+			&orderItemID,
+			&item.Source,
+			&notes,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		item.SpoolID = spoolID
+		if orderID.Valid {
+			item.OrderID = &orderID.String
+		}
+		if orderItemID.Valid {
+			item.OrderItemID = &orderItemID.String
+		}
+		if notes.Valid {
+			item.Notes = &notes.String
+		}
+		consumptions = append(consumptions, item)
+	}
+
+	return consumptions, rows.Err()
+}
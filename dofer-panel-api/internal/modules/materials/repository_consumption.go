@@ -0,0 +1,348 @@
+package materials
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dofer/panel-api/internal/platform/outbox"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// pendingItem es un item de orden sin consumo registrado, con el peso y el
+// material del item de la cotización de la que salió.
+type pendingItem struct {
+	ID       string
+	Grams    float64
+	Material string
+}
+
+type stockLevel struct {
+	RemainingGrams float64
+	ThresholdGrams float64
+}
+
+// consumeOrderItems descuenta en una sola transacción los items pendientes de
+// la orden (o solo itemID). Los items se bloquean para que dos llamadas
+// simultáneas no descuenten dos veces.
+func (r *Repository) consumeOrderItems(ctx context.Context, organizationID, orderID, itemID, source string) (*ConsumptionResult, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	items, err := loadPendingItems(ctx, tx, organizationID, orderID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConsumptionResult{Consumptions: make([]Consumption, 0)}
+	before := map[string]stockLevel{}
+	for _, item := range items {
+		if item.Grams <= 0 || item.Material == "" {
+			continue
+		}
+		if _, ok := before[item.Material]; !ok {
+			level, err := materialStockLevel(ctx, tx, organizationID, item.Material)
+			if err != nil {
+				return nil, err
+			}
+			before[item.Material] = level
+		}
+
+		orderRef, itemRef := orderID, item.ID
+		consumptions, err := consumeMaterial(ctx, tx, organizationID, item.Material, item.Grams, source, &orderRef, &itemRef, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, consumption := range consumptions {
+			if consumption.SpoolID == nil {
+				result.ShortfallGrams = roundGrams(result.ShortfallGrams + consumption.Grams)
+			}
+		}
+		result.Consumptions = append(result.Consumptions, consumptions...)
+	}
+
+	lowStock, err := writeLowStockEvents(ctx, tx, organizationID, orderID, before)
+	if err != nil {
+		return nil, err
+	}
+	result.LowStock = lowStock
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// loadPendingItems toma el peso y el material del item de la cotización del
+// que salió cada item de la orden; los items agregados a mano no tienen
+// filamento que descontar. Sin material en la cotización se usa el material
+// de los costos por defecto de la organización.
+func loadPendingItems(ctx context.Context, tx pgx.Tx, organizationID, orderID, itemID string) ([]pendingItem, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT oi.id::text,
+		       (qi.weight_grams * oi.quantity)::float8,
+		       UPPER(TRIM(COALESCE(NULLIF(TRIM(qi.material_name), ''), default_settings.material_name, '')))
+		FROM order_items oi
+		JOIN quote_items qi ON qi.id = oi.quote_item_id AND qi.organization_id = oi.organization_id
+		LEFT JOIN LATERAL (
+			SELECT cs.material_name
+			FROM cost_settings cs
+			WHERE cs.organization_id = oi.organization_id
+			ORDER BY cs.updated_at DESC
+			LIMIT 1
+		) default_settings ON true
+		WHERE oi.order_id::text = $1
+		  AND oi.organization_id = $2
+		  AND ($3 = '' OR oi.id::text = $3)
+		  AND NOT EXISTS (
+			SELECT 1 FROM material_consumptions mc WHERE mc.order_item_id = oi.id
+		  )
+		ORDER BY oi.created_at ASC
+		FOR UPDATE OF oi
+	`, orderID, organizationID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]pendingItem, 0)
+	for rows.Next() {
+		var item pendingItem
+		if err := rows.Scan(&item.ID, &item.Grams, &item.Material); err != nil {
+			return nil, err
+		}
+		item.Grams = roundGrams(item.Grams)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func materialStockLevel(ctx context.Context, tx pgx.Tx, organizationID, material string) (stockLevel, error) {
+	var level stockLevel
+	err := tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(remaining_grams), 0)::float8, COALESCE(MAX(low_stock_threshold_grams), 0)::float8
+		FROM material_spools
+		WHERE organization_id = $1 AND is_archived = false AND UPPER(material) = $2
+	`, organizationID, material).Scan(&level.RemainingGrams, &level.ThresholdGrams)
+	return level, err
+}
+
+// consumeMaterial bloquea los carretes activos del material, reparte los
+// gramos y deja un renglón por carrete (y uno sin carrete por el faltante).
+func consumeMaterial(ctx context.Context, tx pgx.Tx, organizationID, material string, grams float64, source string, orderID, orderItemID, notes *string) ([]Consumption, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id, remaining_grams::float8, (cost / initial_grams)::float8
+		FROM material_spools
+		WHERE organization_id = $1 AND is_archived = false AND UPPER(material) = $2 AND remaining_grams > 0
+		ORDER BY remaining_grams ASC, created_at ASC
+		FOR UPDATE
+	`, organizationID, material)
+	if err != nil {
+		return nil, err
+	}
+	spools := make([]spoolBalance, 0)
+	for rows.Next() {
+		var spool spoolBalance
+		if err := rows.Scan(&spool.ID, &spool.RemainingGrams, &spool.CostPerGram); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		spools = append(spools, spool)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return insertAllocations(ctx, tx, organizationID, material, allocate(spools, grams), source, orderID, orderItemID, notes)
+}
+
+func insertAllocations(ctx context.Context, tx pgx.Tx, organizationID, material string, allocations []allocation, source string, orderID, orderItemID, notes *string) ([]Consumption, error) {
+	consumptions := make([]Consumption, 0, len(allocations))
+	for _, part := range allocations {
+		if part.SpoolID != nil {
+			if _, err := tx.Exec(ctx, `
+				UPDATE material_spools
+				SET remaining_grams = GREATEST(remaining_grams - $1, 0), updated_at = CURRENT_TIMESTAMP
+				WHERE id = $2 AND organization_id = $3
+			`, part.Grams, *part.SpoolID, organizationID); err != nil {
+				return nil, err
+			}
+		}
+
+		consumption := Consumption{
+			SpoolID:     part.SpoolID,
+			Material:    material,
+			Grams:       part.Grams,
+			Cost:        part.Cost,
+			OrderID:     orderID,
+			OrderItemID: orderItemID,
+			Source:      source,
+			Notes:       notes,
+		}
+		err := tx.QueryRow(ctx, `
+			INSERT INTO material_consumptions (
+				organization_id, spool_id, material, grams, cost, order_id, order_item_id, source, notes
+			) VALUES ($1, $2, $3, $4, $5, $6::uuid, $7::uuid, $8, $9)
+			RETURNING id, created_at
+		`, organizationID, part.SpoolID, material, part.Grams, part.Cost, orderID, orderItemID, source, notes).Scan(
+			&consumption.ID,
+			&consumption.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		consumptions = append(consumptions, consumption)
+	}
+	return consumptions, nil
+}
+
+type alertRecipient struct {
+	Email string
+	Name  string
+}
+
+// lowStockRecipients son los administradores de la organización con correo.
+func lowStockRecipients(ctx context.Context, tx pgx.Tx, organizationID string) ([]alertRecipient, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT u.email, COALESCE(u.full_name, '')
+		FROM organization_members om
+		JOIN users u ON u.id = om.user_id
+		WHERE om.organization_id = $1
+		  AND om.role = 'admin'
+		  AND TRIM(COALESCE(u.email, '')) <> ''
+		ORDER BY u.email
+	`, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipients := make([]alertRecipient, 0)
+	for rows.Next() {
+		var recipient alertRecipient
+		if err := rows.Scan(&recipient.Email, &recipient.Name); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, rows.Err()
+}
+
+// writeLowStockEvents deja un evento por cada material que cruzó su umbral
+// en esta transacción, uno por administrador para que el dispatcher tenga a
+// quién entregarlo; los que ya estaban bajos no vuelven a avisar.
+func writeLowStockEvents(ctx context.Context, tx pgx.Tx, organizationID, orderID string, before map[string]stockLevel) ([]MaterialStock, error) {
+	lowStock := make([]MaterialStock, 0)
+	events := make([]outbox.Event, 0)
+	var recipients []alertRecipient
+	for material, previous := range before {
+		current, err := materialStockLevel(ctx, tx, organizationID, material)
+		if err != nil {
+			return nil, err
+		}
+		if !isLowStock(current.RemainingGrams, current.ThresholdGrams) || isLowStock(previous.RemainingGrams, previous.ThresholdGrams) {
+			continue
+		}
+
+		stock := MaterialStock{
+			Material:               material,
+			RemainingGrams:         roundGrams(current.RemainingGrams),
+			LowStockThresholdGrams: current.ThresholdGrams,
+			LowStock:               true,
+		}
+		lowStock = append(lowStock, stock)
+
+		if recipients == nil {
+			if recipients, err = lowStockRecipients(ctx, tx, organizationID); err != nil {
+				return nil, err
+			}
+		}
+		for _, recipient := range recipients {
+			payload := map[string]any{
+				"material":        material,
+				"remaining_grams": stock.RemainingGrams,
+				"threshold_grams": stock.LowStockThresholdGrams,
+				"recipient_email": recipient.Email,
+				"recipient_name":  recipient.Name,
+			}
+			if orderID != "" {
+				payload["order_id"] = orderID
+			}
+			events = append(events, outbox.Event{
+				OrganizationID: organizationID,
+				Type:           outbox.EventInventoryLowStock,
+				AggregateType:  "material",
+				AggregateID:    material,
+				Payload:        payload,
+			})
+		}
+	}
+	if err := outbox.Write(ctx, tx, events...); err != nil {
+		return nil, err
+	}
+	return lowStock, nil
+}
+
+// ConsumeSpool registra un descuento manual de un carrete concreto.
+func (r *Repository) ConsumeSpool(ctx context.Context, organizationID string, spoolID uuid.UUID, req ConsumeSpoolRequest) (*ConsumptionResult, error) {
+	if req.Grams <= 0 {
+		return nil, ErrInvalidGrams
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		material    string
+		remaining   float64
+		costPerGram float64
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT UPPER(material), remaining_grams::float8, (cost / initial_grams)::float8
+		FROM material_spools
+		WHERE id = $1 AND organization_id = $2
+		FOR UPDATE
+	`, spoolID, organizationID).Scan(&material, &remaining, &costPerGram)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSpoolNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	grams := roundGrams(req.Grams)
+	if grams > remaining {
+		return nil, ErrInsufficientStock
+	}
+
+	level, err := materialStockLevel(ctx, tx, organizationID, material)
+	if err != nil {
+		return nil, err
+	}
+
+	id := spoolID
+	consumptions, err := insertAllocations(ctx, tx, organizationID, material, []allocation{{
+		SpoolID: &id,
+		Grams:   grams,
+		Cost:    roundGrams(grams * costPerGram),
+	}}, SourceManual, nil, nil, sanitizeOptionalString(req.Notes))
+	if err != nil {
+		return nil, err
+	}
+
+	lowStock, err := writeLowStockEvents(ctx, tx, organizationID, "", map[string]stockLevel{material: level})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &ConsumptionResult{Consumptions: consumptions, LowStock: lowStock}, nil
+}
@@ -0,0 +1,174 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxDeliveryAttempts = 6
+	maxRetryDelay       = 2 * time.Hour
+)
+
+// store es lo que el dispatcher necesita del repositorio; en pruebas se
+// sustituye por una implementación en memoria.
+type store interface {
+	FanOutEvents(ctx context.Context, limit int, plan func(Event) []plannedDelivery) (int, error)
+	ClaimDueDeliveries(ctx context.Context, limit int) ([]pendingDelivery, error)
+	MarkSent(ctx context.Context, id uuid.UUID, attempts int) error
+	Reschedule(ctx context.Context, id uuid.UUID, attempts int, lastError string, nextAttemptAt time.Time) error
+	MarkFailed(ctx context.Context, id uuid.UUID, attempts int, lastError string) error
+}
+
+type DispatchResult struct {
+	Events  int
+	Claimed int
+	Sent    int
+	Retried int
+	Failed  int
+}
+
+// Dispatcher reparte los eventos del outbox entre los canales registrados y
+// entrega las notificaciones pendientes con reintentos.
+type Dispatcher struct {
+	store     store
+	channels  []Channel
+	byName    map[string]Channel
+	batchSize int
+	now       func() time.Time
+}
+
+func NewDispatcher(repo store, channels ...Channel) *Dispatcher {
+	byName := make(map[string]Channel, len(channels))
+	for _, channel := range channels {
+		byName[channel.Name()] = channel
+	}
+	return &Dispatcher{
+		store:     repo,
+		channels:  channels,
+		byName:    byName,
+		batchSize: 50,
+		now:       time.Now,
+	}
+}
+
+func (d *Dispatcher) plan(event Event) []plannedDelivery {
+	planned := make([]plannedDelivery, 0, len(d.channels))
+	for _, channel := range d.channels {
+		recipient := channel.Recipient(event)
+		status := DeliveryPending
+		if recipient == "" {
+			status = DeliverySkipped
+		}
+		planned = append(planned, plannedDelivery{
+			Channel:   channel.Name(),
+			Recipient: recipient,
+			Status:    status,
+		})
+	}
+	return planned
+}
+
+// RunOnce procesa un lote: primero reparte eventos nuevos y luego entrega las
+// notificaciones vencidas.
+func (d *Dispatcher) RunOnce(ctx context.Context) (DispatchResult, error) {
+	result := DispatchResult{}
+
+	events, err := d.store.FanOutEvents(ctx, d.batchSize*2, d.plan)
+	if err != nil {
+		return result, fmt.Errorf("fan out events: %w", err)
+	}
+	result.Events = events
+
+	deliveries, err := d.store.ClaimDueDeliveries(ctx, d.batchSize)
+	if err != nil {
+		return result, fmt.Errorf("claim deliveries: %w", err)
+	}
+	result.Claimed = len(deliveries)
+
+	for _, delivery := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		attempts := delivery.Attempts + 1
+		channel, ok := d.byName[delivery.Channel]
+		if !ok {
+			if err := d.store.MarkFailed(ctx, delivery.ID, attempts, "channel not configured: "+delivery.Channel); err != nil {
+				return result, err
+			}
+			result.Failed++
+			continue
+		}
+
+		deliverErr := channel.Deliver(ctx, delivery.Event, delivery.Recipient)
+		if deliverErr == nil {
+			if err := d.store.MarkSent(ctx, delivery.ID, attempts); err != nil {
+				return result, err
+			}
+			result.Sent++
+			continue
+		}
+
+		if attempts >= maxDeliveryAttempts {
+			if err := d.store.MarkFailed(ctx, delivery.ID, attempts, deliverErr.Error()); err != nil {
+				return result, err
+			}
+			result.Failed++
+			continue
+		}
+		if err := d.store.Reschedule(ctx, delivery.ID, attempts, deliverErr.Error(), d.now().Add(retryDelay(attempts))); err != nil {
+			return result, err
+		}
+		result.Retried++
+	}
+
+	return result, nil
+}
+
+// Run ejecuta RunOnce cada interval hasta que se cancele ctx.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := d.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("notification dispatch failed", slog.Any("error", err))
+		} else if result.Claimed > 0 || result.Events > 0 {
+			slog.Info(
+				"notification dispatch completed",
+				slog.Int("events", result.Events),
+				slog.Int("claimed", result.Claimed),
+				slog.Int("sent", result.Sent),
+				slog.Int("retried", result.Retried),
+				slog.Int("failed", result.Failed),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// retryDelay crece de forma exponencial desde 30 segundos.
+func retryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := 30 * time.Second
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,278 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dofer/panel-api/internal/platform/outbox"
+	"github.com/google/uuid"
+)
+
+type memoryStore struct {
+	events     []Event
+	deliveries map[uuid.UUID]*memoryDelivery
+}
+
+type memoryDelivery struct {
+	pendingDelivery
+	status        string
+	lastError     string
+	nextAttemptAt time.Time
+}
+
+func newMemoryStore(events ...Event) *memoryStore {
+	return &memoryStore{events: events, deliveries: map[uuid.UUID]*memoryDelivery{}}
+}
+
+func (s *memoryStore) FanOutEvents(_ context.Context, _ int, plan func(Event) []plannedDelivery) (int, error) {
+	count := len(s.events)
+	for _, event := range s.events {
+		for _, planned := range plan(event) {
+			id := uuid.New()
+			s.deliveries[id] = &memoryDelivery{
+				pendingDelivery: pendingDelivery{
+					ID:        id,
+					Channel:   planned.Channel,
+					Recipient: planned.Recipient,
+					Event:     event,
+				},
+				status: planned.Status,
+			}
+		}
+	}
+	s.events = nil
+	return count, nil
+}
+
+func (s *memoryStore) ClaimDueDeliveries(context.Context, int) ([]pendingDelivery, error) {
+	claimed := []pendingDelivery{}
+	for _, delivery := range s.deliveries {
+		if delivery.status == DeliveryPending {
+			claimed = append(claimed, delivery.pendingDelivery)
+		}
+	}
+	return claimed, nil
+}
+
+func (s *memoryStore) MarkSent(_ context.Context, id uuid.UUID, attempts int) error {
+	s.deliveries[id].status = DeliverySent
+	s.deliveries[id].Attempts = attempts
+	return nil
+}
+
+func (s *memoryStore) Reschedule(_ context.Context, id uuid.UUID, attempts int, lastError string, nextAttemptAt time.Time) error {
+	s.deliveries[id].Attempts = attempts
+	s.deliveries[id].lastError = lastError
+	s.deliveries[id].nextAttemptAt = nextAttemptAt
+	return nil
+}
+
+func (s *memoryStore) MarkFailed(_ context.Context, id uuid.UUID, attempts int, lastError string) error {
+	s.deliveries[id].status = DeliveryFailed
+	s.deliveries[id].Attempts = attempts
+	s.deliveries[id].lastError = lastError
+	return nil
+}
+
+func (s *memoryStore) only(t *testing.T) *memoryDelivery {
+	t.Helper()
+	if len(s.deliveries) != 1 {
+		t.Fatalf("expected one delivery, got %d", len(s.deliveries))
+	}
+	for _, delivery := range s.deliveries {
+		return delivery
+	}
+	return nil
+}
+
+type recordingMailer struct {
+	err      error
+	sent     []string
+	amount   float64
+	deadline time.Time
+}
+
+func (m *recordingMailer) SendOrderStatusUpdate(to, _, orderNumber, status, _ string) error {
+	m.sent = append(m.sent, "status:"+to+":"+orderNumber+":"+status)
+	return m.err
+}
+
+func (m *recordingMailer) SendOrderSLAReminder(to, _, orderNumber string, deadline time.Time, state, _ string) error {
+	m.sent = append(m.sent, "sla:"+to+":"+orderNumber+":"+state)
+	m.deadline = deadline
+	return m.err
+}
+
+func (m *recordingMailer) SendQuoteSent(to, _, quoteNumber string, total float64, _ time.Time) error {
+	m.sent = append(m.sent, "quote:"+to+":"+quoteNumber)
+	m.amount = total
+	return m.err
+}
+
+func (m *recordingMailer) SendQuoteExpiring(to, _, quoteNumber string, total float64, _ time.Time) error {
+	m.sent = append(m.sent, "quote_expiring:"+to+":"+quoteNumber)
+	m.amount = total
+	return m.err
+}
+
+func (m *recordingMailer) SendPaymentReceived(to, _, reference string, amount, _ float64) error {
+	m.sent = append(m.sent, "payment:"+to+":"+reference)
+	m.amount = amount
+	return m.err
+}
+
+func (m *recordingMailer) SendLowStockAlert(to, _, material string, remainingGrams, _ float64) error {
+	m.sent = append(m.sent, "low_stock:"+to+":"+material)
+	m.amount = remainingGrams
+	return m.err
+}
+
+func statusChangedEvent(email string) Event {
+	return Event{
+		ID:             uuid.New(),
+		OrganizationID: uuid.New(),
+		Type:           outbox.EventOrderStatusChanged,
+		AggregateType:  "order",
+		AggregateID:    uuid.NewString(),
+		Payload: map[string]any{
+			"order_number":   "ORD-001",
+			"customer_name":  "Juan",
+			"customer_email": email,
+			"new_status":     "printing",
+			"tracking_url":   "https://panel.dofer.mx/track/abc",
+		},
+	}
+}
+
+func TestDispatcherDeliversStatusChangeByEmail(t *testing.T) {
+	store := newMemoryStore(statusChangedEvent("cliente@example.com"))
+	mailer := &recordingMailer{}
+	dispatcher := NewDispatcher(store, NewEmailChannel(mailer))
+
+	result, err := dispatcher.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce returned an error: %v", err)
+	}
+	if result.Events != 1 || result.Sent != 1 {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0] != "status:cliente@example.com:ORD-001:printing" {
+		t.Fatalf("unexpected emails: %#v", mailer.sent)
+	}
+	delivery := store.only(t)
+	if delivery.status != DeliverySent || delivery.Attempts != 1 {
+		t.Fatalf("expected sent delivery after one attempt, got %#v", delivery)
+	}
+}
+
+func TestDispatcherSkipsEventsWithoutRecipient(t *testing.T) {
+	store := newMemoryStore(statusChangedEvent("  "))
+	mailer := &recordingMailer{}
+	dispatcher := NewDispatcher(store, NewEmailChannel(mailer))
+
+	if _, err := dispatcher.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce returned an error: %v", err)
+	}
+	if len(mailer.sent) != 0 {
+		t.Fatalf("expected no emails, got %#v", mailer.sent)
+	}
+	if delivery := store.only(t); delivery.status != DeliverySkipped {
+		t.Fatalf("expected skipped delivery, got %q", delivery.status)
+	}
+}
+
+func TestDispatcherRetriesWithBackoffAndGivesUp(t *testing.T) {
+	store := newMemoryStore(statusChangedEvent("cliente@example.com"))
+	mailer := &recordingMailer{err: errors.New("smtp down")}
+	dispatcher := NewDispatcher(store, NewEmailChannel(mailer))
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	dispatcher.now = func() time.Time { return now }
+
+	if _, err := dispatcher.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce returned an error: %v", err)
+	}
+	delivery := store.only(t)
+	if delivery.status != DeliveryPending || delivery.Attempts != 1 || delivery.lastError != "smtp down" {
+		t.Fatalf("expected rescheduled delivery, got %#v", delivery)
+	}
+	if !delivery.nextAttemptAt.Equal(now.Add(30 * time.Second)) {
+		t.Fatalf("expected first retry after 30s, got %s", delivery.nextAttemptAt)
+	}
+
+	for i := 1; i < maxDeliveryAttempts; i++ {
+		if _, err := dispatcher.RunOnce(context.Background()); err != nil {
+			t.Fatalf("RunOnce returned an error: %v", err)
+		}
+	}
+	if delivery.status != DeliveryFailed || delivery.Attempts != maxDeliveryAttempts {
+		t.Fatalf("expected failed delivery after %d attempts, got %#v", maxDeliveryAttempts, delivery)
+	}
+}
+
+func TestEmailChannelDecodesPayloadValues(t *testing.T) {
+	mailer := &recordingMailer{}
+	channel := NewEmailChannel(mailer)
+
+	err := channel.Deliver(context.Background(), Event{
+		Type: outbox.EventPaymentReceived,
+		Payload: map[string]any{
+			"reference": "COT-010",
+			"amount":    float64(450),
+			"balance":   float64(0),
+		},
+	}, "cliente@example.com")
+	if err != nil {
+		t.Fatalf("Deliver returned an error: %v", err)
+	}
+	if mailer.amount != 450 || mailer.sent[0] != "payment:cliente@example.com:COT-010" {
+		t.Fatalf("unexpected payment email: %#v amount=%v", mailer.sent, mailer.amount)
+	}
+
+	err = channel.Deliver(context.Background(), Event{
+		Type: outbox.EventOrderSLARisk,
+		Payload: map[string]any{
+			"order_number":      "ORD-002",
+			"delivery_deadline": "2026-03-02T18:00:00Z",
+			"state":             "risk",
+		},
+	}, "cliente@example.com")
+	if err != nil {
+		t.Fatalf("Deliver returned an error: %v", err)
+	}
+	if !mailer.deadline.Equal(time.Date(2026, 3, 2, 18, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected deadline: %s", mailer.deadline)
+	}
+
+	err = channel.Deliver(context.Background(), Event{Type: "unknown"}, "cliente@example.com")
+	if err == nil {
+		t.Fatal("expected unsupported events to fail")
+	}
+}
+
+func TestEmailChannelSendsLowStockToRecipient(t *testing.T) {
+	mailer := &recordingMailer{}
+	channel := NewEmailChannel(mailer)
+	event := Event{
+		Type: outbox.EventInventoryLowStock,
+		Payload: map[string]any{
+			"material":        "PLA",
+			"remaining_grams": float64(120),
+			"threshold_grams": float64(500),
+			"recipient_email": "admin@example.com",
+			"recipient_name":  "Admin",
+		},
+	}
+
+	recipient := channel.Recipient(event)
+	if recipient != "admin@example.com" {
+		t.Fatalf("expected the admin as recipient, got %q", recipient)
+	}
+	if err := channel.Deliver(context.Background(), event, recipient); err != nil {
+		t.Fatalf("Deliver returned an error: %v", err)
+	}
+	if mailer.amount != 120 || mailer.sent[0] != "low_stock:admin@example.com:PLA" {
+		t.Fatalf("unexpected low stock email: %#v amount=%v", mailer.sent, mailer.amount)
+	}
+}
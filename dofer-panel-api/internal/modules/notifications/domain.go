@@ -0,0 +1,88 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+	DeliverySkipped = "skipped"
+)
+
+// Event es un evento de dominio leído de notification_events.
+type Event struct {
+	ID             uuid.UUID      `json:"id"`
+	OrganizationID uuid.UUID      `json:"organization_id"`
+	Type           string         `json:"event_type"`
+	AggregateType  string         `json:"aggregate_type"`
+	AggregateID    string         `json:"aggregate_id"`
+	Payload        map[string]any `json:"payload"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// Delivery es el intento de entregar un evento por un canal.
+type Delivery struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	EventID        uuid.UUID  `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	AggregateType  string     `json:"aggregate_type"`
+	AggregateID    string     `json:"aggregate_id"`
+	Channel        string     `json:"channel"`
+	Recipient      string     `json:"recipient"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastError      *string    `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type DeliveryFilters struct {
+	Status      string
+	Channel     string
+	EventType   string
+	AggregateID string
+	Limit       int
+	Offset      int
+}
+
+// Channel entrega eventos por un medio concreto (email, webhook...). Para
+// agregar un canal basta implementarlo y pasarlo a NewDispatcher.
+type Channel interface {
+	Name() string
+	// Recipient indica a quién va el evento por este canal. Vacío significa
+	// que el canal no aplica y la entrega se registra como skipped.
+	Recipient(event Event) string
+	Deliver(ctx context.Context, event Event, recipient string) error
+}
+
+// plannedDelivery es la entrega que se crea al repartir un evento.
+type plannedDelivery struct {
+	Channel   string
+	Recipient string
+	Status    string
+}
+
+// pendingDelivery es una entrega tomada por el dispatcher junto con su evento.
+type pendingDelivery struct {
+	ID        uuid.UUID
+	Channel   string
+	Recipient string
+	Attempts  int
+	Event     Event
+}
+
+var (
+	ErrDeliveryNotFound   = errors.New("notification delivery not found")
+	ErrDeliveryNotFailed  = errors.New("only failed deliveries can be retried")
+	ErrInvalidDeliveryID  = errors.New("invalid delivery ID")
+	ErrInvalidStatusQuery = errors.New("invalid delivery status")
+)
@@ -0,0 +1,133 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dofer/panel-api/internal/platform/email"
+	"github.com/dofer/panel-api/internal/platform/outbox"
+)
+
+// EmailChannel entrega los eventos por correo: al cliente, o a quien venga en
+// recipient_email (avisos internos como inventario bajo). Los fallos de SMTP
+// se devuelven para que el dispatcher decida el reintento.
+type EmailChannel struct {
+	mailer email.Mailer
+}
+
+func NewEmailChannel(mailer email.Mailer) *EmailChannel {
+	return &EmailChannel{mailer: mailer}
+}
+
+func (c *EmailChannel) Name() string {
+	return "email"
+}
+
+func (c *EmailChannel) Recipient(event Event) string {
+	if recipient := strings.TrimSpace(payloadString(event.Payload, "recipient_email")); recipient != "" {
+		return recipient
+	}
+	return strings.TrimSpace(payloadString(event.Payload, "customer_email"))
+}
+
+func (c *EmailChannel) Deliver(_ context.Context, event Event, recipient string) error {
+	payload := event.Payload
+	customerName := payloadString(payload, "customer_name")
+
+	switch event.Type {
+	case outbox.EventOrderStatusChanged:
+		// Las etapas propias de la organización traen su etiqueta; el mailer
+		// muestra tal cual los estados que no conoce.
+		status := payloadString(payload, "new_status_label")
+		if status == "" {
+			status = payloadString(payload, "new_status")
+		}
+		return c.mailer.SendOrderStatusUpdate(
+			recipient,
+			customerName,
+			payloadString(payload, "order_number"),
+			status,
+			payloadString(payload, "tracking_url"),
+		)
+	case outbox.EventOrderSLARisk:
+		deadline, err := time.Parse(time.RFC3339, payloadString(payload, "delivery_deadline"))
+		if err != nil {
+			return fmt.Errorf("invalid delivery_deadline: %w", err)
+		}
+		return c.mailer.SendOrderSLAReminder(
+			recipient,
+			customerName,
+			payloadString(payload, "order_number"),
+			deadline,
+			payloadString(payload, "state"),
+			payloadString(payload, "tracking_url"),
+		)
+	case outbox.EventQuoteSent:
+		validUntil, err := time.Parse(time.RFC3339, payloadString(payload, "valid_until"))
+		if err != nil {
+			return fmt.Errorf("invalid valid_until: %w", err)
+		}
+		return c.mailer.SendQuoteSent(
+			recipient,
+			customerName,
+			payloadString(payload, "quote_number"),
+			payloadFloat(payload, "total"),
+			validUntil,
+		)
+	case outbox.EventQuoteExpiring:
+		validUntil, err := time.Parse(time.RFC3339, payloadString(payload, "valid_until"))
+		if err != nil {
+			return fmt.Errorf("invalid valid_until: %w", err)
+		}
+		return c.mailer.SendQuoteExpiring(
+			recipient,
+			customerName,
+			payloadString(payload, "quote_number"),
+			payloadFloat(payload, "total"),
+			validUntil,
+		)
+	case outbox.EventPaymentReceived:
+		return c.mailer.SendPaymentReceived(
+			recipient,
+			customerName,
+			payloadString(payload, "reference"),
+			payloadFloat(payload, "amount"),
+			payloadFloat(payload, "balance"),
+		)
+	case outbox.EventInventoryLowStock:
+		return c.mailer.SendLowStockAlert(
+			recipient,
+			payloadString(payload, "recipient_name"),
+			payloadString(payload, "material"),
+			payloadFloat(payload, "remaining_grams"),
+			payloadFloat(payload, "threshold_grams"),
+		)
+	default:
+		return fmt.Errorf("email channel does not support event %s", event.Type)
+	}
+}
+
+func payloadString(payload map[string]any, key string) string {
+	value, ok := payload[key]
+	if !ok || value == nil {
+		return ""
+	}
+	if text, ok := value.(string); ok {
+		return text
+	}
+	return fmt.Sprint(value)
+}
+
+// payloadFloat lee números del payload; al decodificar JSON llegan como float64.
+func payloadFloat(payload map[string]any, key string) float64 {
+	switch value := payload[key].(type) {
+	case float64:
+		return value
+	case int:
+		return float64(value)
+	default:
+		return 0
+	}
+}
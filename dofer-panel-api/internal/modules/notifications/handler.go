@@ -0,0 +1,91 @@
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Route("/notifications", func(r chi.Router) {
+		r.Use(middleware.RequireAuth)
+		r.Use(middleware.RequireRole("admin", "operator"))
+
+		r.Get("/", h.List)
+		r.Post("/{id}/retry", h.Retry)
+	})
+}
+
+func organizationIDFromRequest(r *http.Request) string {
+	organizationID, _ := middleware.OrganizationIDFromContext(r.Context())
+	return organizationID
+}
+
+// List devuelve el registro de entregas de la organización, lo más reciente
+// primero.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filters := DeliveryFilters{
+		Status:      query.Get("status"),
+		Channel:     query.Get("channel"),
+		EventType:   query.Get("event_type"),
+		AggregateID: query.Get("aggregate_id"),
+	}
+	switch filters.Status {
+	case "", DeliveryPending, DeliverySent, DeliveryFailed, DeliverySkipped:
+	default:
+		http.Error(w, ErrInvalidStatusQuery.Error(), http.StatusBadRequest)
+		return
+	}
+	filters.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filters.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+	deliveries, total, err := h.repo.ListDeliveries(r.Context(), organizationIDFromRequest(r), filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"deliveries": deliveries,
+		"total":      total,
+	})
+}
+
+func (h *Handler) Retry(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, ErrInvalidDeliveryID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.repo.RetryDelivery(r.Context(), organizationIDFromRequest(r), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDeliveryNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrDeliveryNotFailed):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
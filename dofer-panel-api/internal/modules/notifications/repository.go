@@ -0,0 +1,311 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// deliveryLease aparta las entregas tomadas por una instancia para que otra no
+// las envíe en paralelo; si el proceso muere vuelven a la cola al vencer.
+const deliveryLease = 5 * time.Minute
+
+const deliverySelectColumns = `
+	d.id, d.organization_id, d.event_id, e.event_type, e.aggregate_type, e.aggregate_id,
+	d.channel, d.recipient, d.status, d.attempts, d.last_error, d.next_attempt_at,
+	d.delivered_at, d.created_at, d.updated_at
+`
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// FanOutEvents toma eventos sin repartir y crea sus entregas por canal en la
+// misma transacción que los marca como repartidos.
+func (r *Repository) FanOutEvents(ctx context.Context, limit int, plan func(Event) []plannedDelivery) (int, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, organization_id, event_type, aggregate_type, aggregate_id, payload, created_at
+		FROM notification_events
+		WHERE dispatched_at IS NULL
+		ORDER BY created_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit)
+	if err != nil {
+		return 0, err
+	}
+	events := []Event{}
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		for _, delivery := range plan(event) {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO notification_deliveries (
+					id, organization_id, event_id, channel, recipient, status
+				) VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (event_id, channel) DO NOTHING
+			`, uuid.New(), event.OrganizationID, event.ID, delivery.Channel, delivery.Recipient, delivery.Status)
+			if err != nil {
+				return 0, err
+			}
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE notification_events SET dispatched_at = NOW() WHERE id = $1
+		`, event.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+func (r *Repository) ClaimDueDeliveries(ctx context.Context, limit int) ([]pendingDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := r.db.Query(ctx, `
+		UPDATE notification_deliveries d
+		SET next_attempt_at = NOW() + $2::interval,
+			updated_at = NOW()
+		FROM notification_events e
+		WHERE e.id = d.event_id
+		  AND d.id IN (
+			SELECT id
+			FROM notification_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		  )
+		RETURNING d.id, d.channel, d.recipient, d.attempts,
+			e.id, e.organization_id, e.event_type, e.aggregate_type, e.aggregate_id, e.payload, e.created_at
+	`, limit, deliveryLease.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []pendingDelivery{}
+	for rows.Next() {
+		var delivery pendingDelivery
+		var payload []byte
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.Channel,
+			&delivery.Recipient,
+			&delivery.Attempts,
+			&delivery.Event.ID,
+			&delivery.Event.OrganizationID,
+			&delivery.Event.Type,
+			&delivery.Event.AggregateType,
+			&delivery.Event.AggregateID,
+			&payload,
+			&delivery.Event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		delivery.Event.Payload = decodePayload(payload)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *Repository) MarkSent(ctx context.Context, id uuid.UUID, attempts int) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE notification_deliveries
+		SET status = 'sent', attempts = $2, last_error = NULL, delivered_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, id, attempts)
+	return err
+}
+
+func (r *Repository) Reschedule(ctx context.Context, id uuid.UUID, attempts int, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE notification_deliveries
+		SET attempts = $2, last_error = $3, next_attempt_at = $4, updated_at = NOW()
+		WHERE id = $1
+	`, id, attempts, lastError, nextAttemptAt)
+	return err
+}
+
+func (r *Repository) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, lastError string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE notification_deliveries
+		SET status = 'failed', attempts = $2, last_error = $3, updated_at = NOW()
+		WHERE id = $1
+	`, id, attempts, lastError)
+	return err
+}
+
+func (r *Repository) ListDeliveries(ctx context.Context, organizationID string, filters DeliveryFilters) ([]Delivery, int, error) {
+	where := " WHERE d.organization_id = $1"
+	args := []any{organizationID}
+
+	addFilter := func(column, value string) {
+		if value == "" {
+			return
+		}
+		args = append(args, value)
+		where += fmt.Sprintf(" AND %s = $%d", column, len(args))
+	}
+	addFilter("d.status", filters.Status)
+	addFilter("d.channel", filters.Channel)
+	addFilter("e.event_type", filters.EventType)
+	addFilter("e.aggregate_id", filters.AggregateID)
+
+	from := " FROM notification_deliveries d JOIN notification_events e ON e.id = d.event_id"
+
+	var total int
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*)"+from+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := filters.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset := filters.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	args = append(args, limit, offset)
+	query := "SELECT" + deliverySelectColumns + from + where +
+		fmt.Sprintf(" ORDER BY d.created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries, total, rows.Err()
+}
+
+// RetryDelivery vuelve a poner en cola una entrega fallida.
+func (r *Repository) RetryDelivery(ctx context.Context, organizationID string, id uuid.UUID) (*Delivery, error) {
+	var status string
+	err := r.db.QueryRow(ctx, `
+		SELECT status FROM notification_deliveries WHERE id = $1 AND organization_id = $2
+	`, id, organizationID).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != DeliveryFailed {
+		return nil, ErrDeliveryNotFailed
+	}
+
+	if _, err := r.db.Exec(ctx, `
+		UPDATE notification_deliveries
+		SET status = 'pending', next_attempt_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2
+	`, id, organizationID); err != nil {
+		return nil, err
+	}
+
+	row := r.db.QueryRow(ctx, "SELECT"+deliverySelectColumns+`
+		FROM notification_deliveries d
+		JOIN notification_events e ON e.id = d.event_id
+		WHERE d.id = $1 AND d.organization_id = $2
+	`, id, organizationID)
+	return scanDelivery(row)
+}
+
+func scanEvent(row pgx.Row) (Event, error) {
+	var event Event
+	var payload []byte
+	err := row.Scan(
+		&event.ID,
+		&event.OrganizationID,
+		&event.Type,
+		&event.AggregateType,
+		&event.AggregateID,
+		&payload,
+		&event.CreatedAt,
+	)
+	if err != nil {
+		return Event{}, err
+	}
+	event.Payload = decodePayload(payload)
+	return event, nil
+}
+
+func scanDelivery(row pgx.Row) (*Delivery, error) {
+	var delivery Delivery
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.OrganizationID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.AggregateType,
+		&delivery.AggregateID,
+		&delivery.Channel,
+		&delivery.Recipient,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.LastError,
+		&delivery.NextAttemptAt,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func decodePayload(raw []byte) map[string]any {
+	payload := map[string]any{}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &payload)
+	}
+	return payload
+}
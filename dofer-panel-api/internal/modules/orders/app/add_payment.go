@@ -47,14 +47,14 @@ func (h *AddOrderPaymentHandler) Handle(ctx context.Context, cmd AddOrderPayment
 		CreatedAt:      time.Now(),
 	}
 
-	if err := h.repo.AddPayment(payment); err != nil {
-		return nil, err
-	}
-
 	// Actualizar totales de la orden
 	newAmountPaid := order.AmountPaid + cmd.Amount
 	newBalance := order.Amount - newAmountPaid
 
+	if err := h.repo.AddPayment(payment, orderPaymentReceivedEvent(order, payment, newBalance)); err != nil {
+		return nil, err
+	}
+
 	if err := h.repo.UpdateOrderPaymentTotals(cmd.OrderID, organizationID, newAmountPaid, newBalance); err != nil {
 		return nil, err
 	}
@@ -18,20 +18,28 @@ type BulkUpdateOrderStatusCommand struct {
 }
 
 type BulkUpdateOrderStatusHandler struct {
-	repo        domain.OrderRepository
-	historyRepo domain.OrderHistoryRepository
+	repo         domain.OrderRepository
+	historyRepo  domain.OrderHistoryRepository
+	workflowRepo domain.WorkflowRepository
 }
 
-func NewBulkUpdateOrderStatusHandler(repo domain.OrderRepository, historyRepo domain.OrderHistoryRepository) *BulkUpdateOrderStatusHandler {
+func NewBulkUpdateOrderStatusHandler(repo domain.OrderRepository, historyRepo domain.OrderHistoryRepository, workflowRepo domain.WorkflowRepository) *BulkUpdateOrderStatusHandler {
 	return &BulkUpdateOrderStatusHandler{
-		repo:        repo,
-		historyRepo: historyRepo,
+		repo:         repo,
+		historyRepo:  historyRepo,
+		workflowRepo: workflowRepo,
 	}
 }
 
 func (h *BulkUpdateOrderStatusHandler) Handle(ctx context.Context, cmd BulkUpdateOrderStatusCommand) (*BulkUpdateOrdersResult, error) {
+	organizationID := organizationIDFromContext(ctx)
+	workflow, err := h.workflowRepo.FindByOrganization(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
 	newStatus := strings.TrimSpace(cmd.NewStatus)
-	if !isValidOrderStatus(newStatus) {
+	if _, ok := workflow.Stage(domain.OrderStatus(newStatus)); !ok {
 		return nil, errors.New("invalid status")
 	}
 	if len(cmd.OrderIDs) == 0 {
@@ -74,7 +82,7 @@ func (h *BulkUpdateOrderStatusHandler) Handle(ctx context.Context, cmd BulkUpdat
 		}
 		processed[orderID] = struct{}{}
 
-		order, err := h.repo.FindByID(orderID, organizationIDFromContext(ctx))
+		order, err := h.repo.FindByID(orderID, organizationID)
 		if err != nil {
 			result.Failed++
 			result.Errors = append(result.Errors, BulkUpdateOrderError{
@@ -90,7 +98,7 @@ func (h *BulkUpdateOrderStatusHandler) Handle(ctx context.Context, cmd BulkUpdat
 			continue
 		}
 
-		if err := order.ChangeStatus(domain.OrderStatus(newStatus)); err != nil {
+		if err := order.ChangeStatus(workflow, domain.OrderStatus(newStatus)); err != nil {
 			result.Failed++
 			result.Errors = append(result.Errors, BulkUpdateOrderError{
 				OrderID: orderID,
@@ -99,7 +107,7 @@ func (h *BulkUpdateOrderStatusHandler) Handle(ctx context.Context, cmd BulkUpdat
 			continue
 		}
 
-		if err := h.repo.Update(order); err != nil {
+		if err := h.repo.Update(order, orderStatusChangedEvent(order, workflow, oldStatus, changedBy)); err != nil {
 			result.Failed++
 			result.Errors = append(result.Errors, BulkUpdateOrderError{
 				OrderID: orderID,
@@ -123,18 +131,3 @@ func (h *BulkUpdateOrderStatusHandler) Handle(ctx context.Context, cmd BulkUpdat
 
 	return result, nil
 }
-
-func isValidOrderStatus(status string) bool {
-	switch domain.OrderStatus(status) {
-	case domain.StatusNew,
-		domain.StatusPrinting,
-		domain.StatusPost,
-		domain.StatusPacked,
-		domain.StatusReady,
-		domain.StatusDelivered,
-		domain.StatusCancelled:
-		return true
-	default:
-		return false
-	}
-}
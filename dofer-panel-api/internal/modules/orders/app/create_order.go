@@ -3,7 +3,6 @@ package app
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/dofer/panel-api/internal/modules/orders/domain"
@@ -29,19 +28,18 @@ type CreateOrderCommand struct {
 }
 
 type CreateOrderHandler struct {
-	repo domain.OrderRepository
+	repo         domain.OrderRepository
+	workflowRepo domain.WorkflowRepository
 }
 
-func NewCreateOrderHandler(repo domain.OrderRepository) *CreateOrderHandler {
-	return &CreateOrderHandler{repo: repo}
+func NewCreateOrderHandler(repo domain.OrderRepository, workflowRepo domain.WorkflowRepository) *CreateOrderHandler {
+	return &CreateOrderHandler{repo: repo, workflowRepo: workflowRepo}
 }
 
 func (h *CreateOrderHandler) Handle(ctx context.Context, cmd CreateOrderCommand) (*domain.Order, error) {
-	// Auto-generar número de orden basado en timestamp
-	orderNumber := fmt.Sprintf("ORD-%s", time.Now().Format("20060102150405"))
-
+	// El número se asigna al guardar con el folio de la organización.
 	order, err := domain.NewOrder(
-		orderNumber,
+		"",
 		domain.OrderPlatform(cmd.Platform),
 		cmd.CustomerName,
 		cmd.ProductName,
@@ -55,6 +53,12 @@ func (h *CreateOrderHandler) Handle(ctx context.Context, cmd CreateOrderCommand)
 	order.CustomerEmail = cmd.CustomerEmail
 	order.CustomerPhone = cmd.CustomerPhone
 	order.OrganizationID = organizationIDFromContext(ctx)
+
+	workflow, err := h.workflowRepo.FindByOrganization(order.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	order.Status = workflow.InitialStage()
 	order.ProductImage = cmd.ProductImage
 	order.PrintFile = cmd.PrintFile
 	order.PrintFileName = cmd.PrintFileName
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/orders/domain"
+	"github.com/dofer/panel-api/internal/platform/outbox"
+)
+
+func frontendBaseURL() string {
+	frontendURL := strings.TrimSpace(os.Getenv("FRONTEND_URL"))
+	if frontendURL == "" {
+		frontendURL = "http://localhost:3000"
+	}
+	return strings.TrimRight(frontendURL, "/")
+}
+
+func trackingURL(order *domain.Order) string {
+	return fmt.Sprintf("%s/track/%s", frontendBaseURL(), order.PublicID)
+}
+
+func orderEventPayload(order *domain.Order) map[string]any {
+	return map[string]any{
+		"order_id":       order.ID,
+		"order_number":   order.OrderNumber,
+		"customer_name":  order.CustomerName,
+		"customer_email": order.CustomerEmail,
+		"tracking_url":   trackingURL(order),
+	}
+}
+
+func orderStatusChangedEvent(order *domain.Order, workflow *domain.Workflow, oldStatus, changedBy string) outbox.Event {
+	payload := orderEventPayload(order)
+	payload["old_status"] = oldStatus
+	payload["new_status"] = string(order.Status)
+	if stage, ok := workflow.Stage(order.Status); ok {
+		payload["new_status_label"] = stage.Label
+	}
+	payload["changed_by"] = changedBy
+	return outbox.Event{
+		OrganizationID: order.OrganizationID,
+		Type:           outbox.EventOrderStatusChanged,
+		AggregateType:  "order",
+		AggregateID:    order.ID,
+		Payload:        payload,
+	}
+}
+
+func orderSLARiskEvent(order *domain.Order, deadline time.Time, state string) outbox.Event {
+	payload := orderEventPayload(order)
+	payload["delivery_deadline"] = deadline.UTC().Format(time.RFC3339)
+	payload["state"] = state
+	return outbox.Event{
+		OrganizationID: order.OrganizationID,
+		Type:           outbox.EventOrderSLARisk,
+		AggregateType:  "order",
+		AggregateID:    order.ID,
+		Payload:        payload,
+	}
+}
+
+func orderPaymentReceivedEvent(order *domain.Order, payment *domain.OrderPayment, balance float64) outbox.Event {
+	payload := orderEventPayload(order)
+	payload["reference"] = order.OrderNumber
+	payload["payment_id"] = payment.ID
+	payload["amount"] = payment.Amount
+	payload["payment_method"] = payment.PaymentMethod
+	payload["balance"] = balance
+	return outbox.Event{
+		OrganizationID: order.OrganizationID,
+		Type:           outbox.EventPaymentReceived,
+		AggregateType:  "order",
+		AggregateID:    order.ID,
+		Payload:        payload,
+	}
+}
@@ -8,20 +8,34 @@ import (
 )
 
 type OrderStats struct {
-	TotalOrders    int            `json:"total_orders"`
-	OrdersByStatus map[string]int `json:"orders_by_status"`
-	UrgentOrders   int            `json:"urgent_orders"`
-	TodayOrders    int            `json:"today_orders"`
-	CompletedToday int            `json:"completed_today"`
-	AveragePerDay  float64        `json:"average_per_day"`
+	TotalOrders    int               `json:"total_orders"`
+	OrdersByStatus map[string]int    `json:"orders_by_status"`
+	Stages         []OrderStageStats `json:"stages"`
+	OpenOrders     int               `json:"open_orders"`
+	UrgentOrders   int               `json:"urgent_orders"`
+	TodayOrders    int               `json:"today_orders"`
+	CompletedToday int               `json:"completed_today"`
+	AveragePerDay  float64           `json:"average_per_day"`
+}
+
+// OrderStageStats reporta las órdenes por etapa en el orden del flujo de la
+// organización.
+type OrderStageStats struct {
+	Key       string `json:"key"`
+	Label     string `json:"label"`
+	Color     string `json:"color,omitempty"`
+	Completes bool   `json:"completes,omitempty"`
+	Cancels   bool   `json:"cancels,omitempty"`
+	Count     int    `json:"count"`
 }
 
 type GetOrderStatsHandler struct {
-	repo domain.OrderRepository
+	repo         domain.OrderRepository
+	workflowRepo domain.WorkflowRepository
 }
 
-func NewGetOrderStatsHandler(repo domain.OrderRepository) *GetOrderStatsHandler {
-	return &GetOrderStatsHandler{repo: repo}
+func NewGetOrderStatsHandler(repo domain.OrderRepository, workflowRepo domain.WorkflowRepository) *GetOrderStatsHandler {
+	return &GetOrderStatsHandler{repo: repo, workflowRepo: workflowRepo}
 }
 
 func isToday(t time.Time) bool {
@@ -34,8 +48,14 @@ func isToday(t time.Time) bool {
 func (h *GetOrderStatsHandler) Handle(ctx context.Context) (*OrderStats, error) {
 	// Get all orders (we'll aggregate in memory for simplicity)
 	// For production with many orders, this should be done with SQL aggregations
+	organizationID := organizationIDFromContext(ctx)
+	workflow, err := h.workflowRepo.FindByOrganization(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
 	filters := domain.OrderFilters{
-		OrganizationID: organizationIDFromContext(ctx),
+		OrganizationID: organizationID,
 		Limit:          1000, // Reasonable limit
 		Offset:         0,
 	}
@@ -52,11 +72,17 @@ func (h *GetOrderStatsHandler) Handle(ctx context.Context) (*OrderStats, error)
 		TodayOrders:    0,
 		CompletedToday: 0,
 	}
+	for _, stage := range workflow.Stages {
+		stats.OrdersByStatus[stage.Key] = 0
+	}
 
 	// Calculate stats
 	for _, order := range orders {
 		// Count by status
 		stats.OrdersByStatus[string(order.Status)]++
+		if !workflow.IsClosed(order.Status) {
+			stats.OpenOrders++
+		}
 
 		// Count urgent
 		if order.Priority == domain.PriorityUrgent {
@@ -74,5 +100,17 @@ func (h *GetOrderStatsHandler) Handle(ctx context.Context) (*OrderStats, error)
 		}
 	}
 
+	stats.Stages = make([]OrderStageStats, 0, len(workflow.Stages))
+	for _, stage := range workflow.Stages {
+		stats.Stages = append(stats.Stages, OrderStageStats{
+			Key:       stage.Key,
+			Label:     stage.Label,
+			Color:     stage.Color,
+			Completes: stage.Completes,
+			Cancels:   stage.Cancels,
+			Count:     stats.OrdersByStatus[stage.Key],
+		})
+	}
+
 	return stats, nil
 }
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dofer/panel-api/internal/modules/orders/domain"
+)
+
+var (
+	ErrInvalidWorkflow    = errors.New("invalid workflow")
+	ErrWorkflowStageInUse = errors.New("cannot remove stages that still have orders")
+)
+
+type GetOrderWorkflowHandler struct {
+	repo domain.WorkflowRepository
+}
+
+func NewGetOrderWorkflowHandler(repo domain.WorkflowRepository) *GetOrderWorkflowHandler {
+	return &GetOrderWorkflowHandler{repo: repo}
+}
+
+func (h *GetOrderWorkflowHandler) Handle(ctx context.Context) (*domain.Workflow, error) {
+	return h.repo.FindByOrganization(organizationIDFromContext(ctx))
+}
+
+type UpdateOrderWorkflowCommand struct {
+	Stages      []domain.WorkflowStage
+	Transitions []domain.WorkflowTransition
+}
+
+type UpdateOrderWorkflowHandler struct {
+	repo domain.WorkflowRepository
+}
+
+func NewUpdateOrderWorkflowHandler(repo domain.WorkflowRepository) *UpdateOrderWorkflowHandler {
+	return &UpdateOrderWorkflowHandler{repo: repo}
+}
+
+func (h *UpdateOrderWorkflowHandler) Handle(ctx context.Context, cmd UpdateOrderWorkflowCommand) (*domain.Workflow, error) {
+	workflow := &domain.Workflow{
+		OrganizationID: organizationIDFromContext(ctx),
+		Stages:         cmd.Stages,
+		Transitions:    cmd.Transitions,
+	}
+	workflow.Normalize()
+	if err := workflow.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+	if err := ensureStagesNotInUse(h.repo, workflow); err != nil {
+		return nil, err
+	}
+
+	if err := h.repo.Save(workflow); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// ResetOrderWorkflowHandler borra el flujo propio y regresa al flujo por
+// defecto.
+type ResetOrderWorkflowHandler struct {
+	repo domain.WorkflowRepository
+}
+
+func NewResetOrderWorkflowHandler(repo domain.WorkflowRepository) *ResetOrderWorkflowHandler {
+	return &ResetOrderWorkflowHandler{repo: repo}
+}
+
+func (h *ResetOrderWorkflowHandler) Handle(ctx context.Context) (*domain.Workflow, error) {
+	workflow := domain.DefaultWorkflow()
+	workflow.OrganizationID = organizationIDFromContext(ctx)
+	if err := ensureStagesNotInUse(h.repo, workflow); err != nil {
+		return nil, err
+	}
+	if err := h.repo.Delete(workflow.OrganizationID); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// ensureStagesNotInUse evita dejar órdenes en un estado que ya no existe en
+// el flujo; primero hay que moverlas a otra etapa.
+func ensureStagesNotInUse(repo domain.WorkflowRepository, workflow *domain.Workflow) error {
+	counts, err := repo.CountOrdersByStatus(workflow.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	orphaned := []string{}
+	for status, count := range counts {
+		if count == 0 {
+			continue
+		}
+		if _, ok := workflow.Stage(status); !ok {
+			orphaned = append(orphaned, fmt.Sprintf("%s (%d)", status, count))
+		}
+	}
+	if len(orphaned) == 0 {
+		return nil
+	}
+	sort.Strings(orphaned)
+	return fmt.Errorf("%w: %s", ErrWorkflowStageInUse, strings.Join(orphaned, ", "))
+}
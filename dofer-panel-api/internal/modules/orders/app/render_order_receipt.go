@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dofer/panel-api/internal/modules/orders/domain"
+	"github.com/dofer/panel-api/internal/platform/branding"
+	"github.com/dofer/panel-api/internal/platform/pdf"
+)
+
+var orderStatusLabels = map[domain.OrderStatus]string{
+	domain.StatusNew:       "Nueva",
+	domain.StatusPrinting:  "Imprimiendo",
+	domain.StatusPost:      "Post-procesado",
+	domain.StatusPacked:    "Empacada",
+	domain.StatusReady:     "Lista para entrega",
+	domain.StatusDelivered: "Entregada",
+	domain.StatusCancelled: "Cancelada",
+}
+
+// RenderOrderReceiptHandler genera el recibo en PDF de una orden con sus
+// renglones y los pagos registrados.
+type RenderOrderReceiptHandler struct {
+	repo     domain.OrderRepository
+	branding branding.Reader
+}
+
+func NewRenderOrderReceiptHandler(repo domain.OrderRepository, brandingReader branding.Reader) *RenderOrderReceiptHandler {
+	return &RenderOrderReceiptHandler{repo: repo, branding: brandingReader}
+}
+
+// Handle devuelve el PDF y el nombre de archivo sugerido.
+func (h *RenderOrderReceiptHandler) Handle(ctx context.Context, orderID string) ([]byte, string, error) {
+	organizationID := organizationIDFromContext(ctx)
+
+	order, err := h.repo.FindByID(orderID, organizationID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to find order: %w", err)
+	}
+	if order == nil {
+		return nil, "", fmt.Errorf("order not found")
+	}
+	items, err := h.repo.GetOrderItems(orderID, organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	payments, err := h.repo.GetPayments(orderID, organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	brand, err := h.branding.Get(ctx, organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	statement := pdf.Statement{
+		Title:    "Recibo",
+		Number:   order.OrderNumber,
+		Status:   orderStatusLabel(order.Status),
+		IssuedAt: order.CreatedAt,
+		Brand:    brand.PDFBrand(),
+		Customer: pdf.Party{
+			Name:  order.CustomerName,
+			Email: order.CustomerEmail,
+			Phone: order.CustomerPhone,
+		},
+		Notes:  order.Notes,
+		Footer: brand.Footer("Gracias por su compra."),
+	}
+
+	for _, item := range items {
+		statement.Items = append(statement.Items, pdf.LineItem{
+			Description: item.ProductName,
+			Detail:      item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Total:       item.Total,
+		})
+	}
+	// Las órdenes antiguas no tienen renglones: se usa el producto principal.
+	if len(statement.Items) == 0 {
+		unitPrice := order.Amount
+		if order.Quantity > 0 {
+			unitPrice = order.Amount / float64(order.Quantity)
+		}
+		statement.Items = append(statement.Items, pdf.LineItem{
+			Description: order.ProductName,
+			Quantity:    order.Quantity,
+			UnitPrice:   unitPrice,
+			Total:       order.Amount,
+		})
+	}
+
+	for _, payment := range payments {
+		statement.Payments = append(statement.Payments, pdf.PaymentLine{
+			Date:   payment.PaymentDate,
+			Method: payment.PaymentMethod,
+			Notes:  payment.Notes,
+			Amount: payment.Amount,
+		})
+	}
+	statement.Totals = []pdf.TotalLine{
+		{Label: "Total", Amount: order.Amount, Emphasis: true},
+		{Label: "Pagado", Amount: order.AmountPaid},
+		{Label: "Saldo", Amount: order.Balance},
+	}
+
+	document, err := pdf.RenderStatement(statement)
+	if err != nil {
+		return nil, "", err
+	}
+	return document, fmt.Sprintf("recibo-%s.pdf", order.OrderNumber), nil
+}
+
+func orderStatusLabel(status domain.OrderStatus) string {
+	if label, ok := orderStatusLabels[status]; ok {
+		return label
+	}
+	return string(status)
+}
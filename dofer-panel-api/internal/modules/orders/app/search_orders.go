@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -9,6 +10,8 @@ import (
 	"github.com/dofer/panel-api/internal/modules/orders/domain"
 )
 
+var ErrInvalidSearch = errors.New("invalid search")
+
 type SearchOrdersHandler struct {
 	repo domain.OrderRepository
 }
@@ -17,107 +20,102 @@ func NewSearchOrdersHandler(repo domain.OrderRepository) *SearchOrdersHandler {
 	return &SearchOrdersHandler{repo: repo}
 }
 
+// SearchOrdersParams llega tal cual de la URL. Las fechas de creación son
+// días completos (YYYY-MM-DD); las de entrega aceptan además RFC3339.
 type SearchOrdersParams struct {
-	Query    string
-	Status   string
-	Customer string
-	Operator string
-	DateFrom string
-	DateTo   string
+	Query        string
+	Customer     string
+	Operator     string
+	Statuses     []string
+	Priorities   []string
+	Platforms    []string
+	DateFrom     string
+	DateTo       string
+	DeadlineFrom string
+	DeadlineTo   string
+	WithBalance  bool
+	Limit        int
+	Cursor       string
 }
 
-func (h *SearchOrdersHandler) Handle(ctx context.Context, params SearchOrdersParams) ([]domain.Order, error) {
-	// Get all orders (no filters to get everything)
-	orderPtrs, err := h.repo.FindAll(domain.OrderFilters{
-		OrganizationID: organizationIDFromContext(ctx),
-	})
+func (h *SearchOrdersHandler) Handle(ctx context.Context, params SearchOrdersParams) (*domain.OrderSearchResult, error) {
+	search, err := buildOrderSearch(params)
 	if err != nil {
-		return nil, fmt.Errorf("error getting orders: %w", err)
+		return nil, err
 	}
+	search.OrganizationID = organizationIDFromContext(ctx)
+	search.CountTotal = true
 
-	// Convert []*Order to []Order
-	allOrders := make([]domain.Order, 0, len(orderPtrs))
-	for _, ptr := range orderPtrs {
-		if ptr != nil {
-			allOrders = append(allOrders, *ptr)
-		}
+	result, err := h.repo.Search(search)
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		return nil, fmt.Errorf("%w: cursor is not valid", ErrInvalidSearch)
 	}
+	return result, err
+}
 
-	// Parse dates if provided
-	var dateFrom, dateTo time.Time
-	if params.DateFrom != "" {
-		dateFrom, err = time.Parse("2006-01-02", params.DateFrom)
-		if err != nil {
-			return nil, fmt.Errorf("invalid date_from format: %w", err)
-		}
-	}
-	if params.DateTo != "" {
-		dateTo, err = time.Parse("2006-01-02", params.DateTo)
-		if err != nil {
-			return nil, fmt.Errorf("invalid date_to format: %w", err)
-		}
-		// Set to end of day
-		dateTo = dateTo.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+func buildOrderSearch(params SearchOrdersParams) (domain.OrderSearch, error) {
+	search := domain.OrderSearch{
+		Text:        strings.TrimSpace(params.Query),
+		Customer:    strings.TrimSpace(params.Customer),
+		AssignedTo:  strings.TrimSpace(params.Operator),
+		WithBalance: params.WithBalance,
+		Limit:       params.Limit,
+		Cursor:      strings.TrimSpace(params.Cursor),
 	}
 
-	// Filter orders
-	var filtered []domain.Order
-	for _, order := range allOrders {
-		// Skip if doesn't match filters
-		if !matchesFilters(order, params, dateFrom, dateTo) {
-			continue
+	for _, status := range params.Statuses {
+		search.Statuses = append(search.Statuses, domain.OrderStatus(status))
+	}
+	for _, priority := range params.Priorities {
+		if !isValidOrderPriority(priority) {
+			return domain.OrderSearch{}, fmt.Errorf("%w: unknown priority %q", ErrInvalidSearch, priority)
 		}
-		filtered = append(filtered, order)
+		search.Priorities = append(search.Priorities, domain.OrderPriority(priority))
 	}
-
-	return filtered, nil
-}
-
-func matchesFilters(order domain.Order, params SearchOrdersParams, dateFrom, dateTo time.Time) bool {
-	// Query filter (search in ProductName, OrderNumber, CustomerName)
-	if params.Query != "" {
-		query := strings.ToLower(params.Query)
-		productName := strings.ToLower(order.ProductName)
-		orderNumber := strings.ToLower(order.OrderNumber)
-		customerName := strings.ToLower(order.CustomerName)
-
-		if !strings.Contains(productName, query) &&
-			!strings.Contains(orderNumber, query) &&
-			!strings.Contains(customerName, query) {
-			return false
+	for _, platform := range params.Platforms {
+		switch domain.OrderPlatform(platform) {
+		case domain.PlatformTikTok, domain.PlatformShopify, domain.PlatformLocal, domain.PlatformOther, domain.PlatformAffiliate:
+			search.Platforms = append(search.Platforms, domain.OrderPlatform(platform))
+		default:
+			return domain.OrderSearch{}, fmt.Errorf("%w: unknown platform %q", ErrInvalidSearch, platform)
 		}
 	}
 
-	// Status filter
-	if params.Status != "" && string(order.Status) != params.Status {
-		return false
+	var err error
+	if search.CreatedFrom, err = parseSearchDate("date_from", params.DateFrom, false, false); err != nil {
+		return domain.OrderSearch{}, err
 	}
-
-	// Customer filter
-	if params.Customer != "" {
-		customer := strings.ToLower(params.Customer)
-		customerName := strings.ToLower(order.CustomerName)
-		if !strings.Contains(customerName, customer) {
-			return false
-		}
+	if search.CreatedTo, err = parseSearchDate("date_to", params.DateTo, true, false); err != nil {
+		return domain.OrderSearch{}, err
+	}
+	if search.DeadlineFrom, err = parseSearchDate("deadline_from", params.DeadlineFrom, false, true); err != nil {
+		return domain.OrderSearch{}, err
+	}
+	if search.DeadlineTo, err = parseSearchDate("deadline_to", params.DeadlineTo, true, true); err != nil {
+		return domain.OrderSearch{}, err
 	}
+	return search, nil
+}
 
-	// Operator filter
-	if params.Operator != "" {
-		operator := strings.ToLower(params.Operator)
-		assignedTo := strings.ToLower(order.AssignedTo)
-		if !strings.Contains(assignedTo, operator) {
-			return false
+// parseSearchDate convierte YYYY-MM-DD en el inicio del día, o en el inicio
+// del día siguiente cuando es el final del rango (los límites superiores son
+// exclusivos). Con allowTime también acepta un instante RFC3339 exacto.
+func parseSearchDate(field, raw string, endOfRange, allowTime bool) (*time.Time, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return nil, nil
+	}
+	if allowTime {
+		if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+			return &parsed, nil
 		}
 	}
-
-	// Date range filter
-	if !dateFrom.IsZero() && order.CreatedAt.Before(dateFrom) {
-		return false
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s format", ErrInvalidSearch, field)
 	}
-	if !dateTo.IsZero() && order.CreatedAt.After(dateTo) {
-		return false
+	if endOfRange {
+		parsed = parsed.AddDate(0, 0, 1)
 	}
-
-	return true
+	return &parsed, nil
 }
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/orders/domain"
+)
+
+func TestBuildOrderSearch(t *testing.T) {
+	search, err := buildOrderSearch(SearchOrdersParams{
+		Query:        "  dragón ",
+		Statuses:     []string{"new", "printing"},
+		Priorities:   []string{"urgent"},
+		Platforms:    []string{"local", "tiktok"},
+		DateFrom:     "2026-10-01",
+		DateTo:       "2026-10-17",
+		DeadlineTo:   "2026-10-20T18:00:00-06:00",
+		WithBalance:  true,
+		DeadlineFrom: "2026-10-18",
+	})
+	if err != nil {
+		t.Fatalf("buildOrderSearch() error = %v", err)
+	}
+	if search.Text != "dragón" || len(search.Statuses) != 2 || search.Priorities[0] != domain.PriorityUrgent ||
+		len(search.Platforms) != 2 || !search.WithBalance {
+		t.Fatalf("unexpected search %+v", search)
+	}
+	if !search.CreatedFrom.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) ||
+		!search.CreatedTo.Equal(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("date_to must include the whole day: %v - %v", search.CreatedFrom, search.CreatedTo)
+	}
+	if !search.DeadlineTo.Equal(time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)) ||
+		!search.DeadlineFrom.Equal(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected deadline window %v - %v", search.DeadlineFrom, search.DeadlineTo)
+	}
+
+	for name, params := range map[string]SearchOrdersParams{
+		"priority": {Priorities: []string{"asap"}},
+		"platform": {Platforms: []string{"amazon"}},
+		"date":     {DateFrom: "17/10/2026"},
+		"deadline": {DeadlineTo: "mañana"},
+	} {
+		if _, err := buildOrderSearch(params); !errors.Is(err, ErrInvalidSearch) {
+			t.Fatalf("%s: expected invalid search, got %v", name, err)
+		}
+	}
+}
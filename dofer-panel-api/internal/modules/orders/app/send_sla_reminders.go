@@ -2,13 +2,10 @@ package app
 
 import (
 	"context"
-	"fmt"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/dofer/panel-api/internal/modules/orders/domain"
-	"github.com/dofer/panel-api/internal/platform/email"
 )
 
 type SendSLARemindersCommand struct {
@@ -29,17 +26,19 @@ type SendSLARemindersResult struct {
 	Errors       []BulkUpdateOrderError `json:"errors,omitempty"`
 }
 
+const slaReminderPageSize = 200
+
 type SendSLARemindersHandler struct {
-	repo        domain.OrderRepository
-	historyRepo domain.OrderHistoryRepository
-	mailer      email.Mailer
+	repo         domain.OrderRepository
+	historyRepo  domain.OrderHistoryRepository
+	workflowRepo domain.WorkflowRepository
 }
 
-func NewSendSLARemindersHandler(repo domain.OrderRepository, historyRepo domain.OrderHistoryRepository, mailer email.Mailer) *SendSLARemindersHandler {
+func NewSendSLARemindersHandler(repo domain.OrderRepository, historyRepo domain.OrderHistoryRepository, workflowRepo domain.WorkflowRepository) *SendSLARemindersHandler {
 	return &SendSLARemindersHandler{
-		repo:        repo,
-		historyRepo: historyRepo,
-		mailer:      mailer,
+		repo:         repo,
+		historyRepo:  historyRepo,
+		workflowRepo: workflowRepo,
 	}
 }
 
@@ -49,22 +48,6 @@ func (h *SendSLARemindersHandler) Handle(ctx context.Context, cmd SendSLAReminde
 		horizonHours = 24
 	}
 
-	scanLimit := 5000
-	orders, err := h.repo.FindAll(domain.OrderFilters{
-		OrganizationID: organizationIDFromContext(ctx),
-		Limit:          scanLimit,
-		Offset:         0,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	frontendURL := strings.TrimSpace(os.Getenv("FRONTEND_URL"))
-	if frontendURL == "" {
-		frontendURL = "http://localhost:3000"
-	}
-	frontendURL = strings.TrimRight(frontendURL, "/")
-
 	triggeredBy := strings.TrimSpace(cmd.TriggeredBy)
 	if triggeredBy == "" {
 		triggeredBy = "system"
@@ -72,87 +55,111 @@ func (h *SendSLARemindersHandler) Handle(ctx context.Context, cmd SendSLAReminde
 
 	now := time.Now()
 	horizon := time.Duration(horizonHours) * time.Hour
+	// El worker recorre todas las organizaciones; cada una tiene su flujo.
+	workflows := map[string]*domain.Workflow{}
 
 	result := &SendSLARemindersResult{
 		HorizonHours: horizonHours,
 		DryRun:       cmd.DryRun,
 	}
 
-	for _, order := range orders {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
-
-		result.Scanned++
-		if order == nil {
-			continue
-		}
-		if order.Status == domain.StatusDelivered || order.Status == domain.StatusCancelled {
-			continue
-		}
-		if order.DeliveryDeadline == nil {
-			continue
-		}
-
-		deadline := *order.DeliveryDeadline
-		remaining := deadline.Sub(now)
-		state := ""
-
-		if remaining < 0 {
-			state = "overdue"
-			result.Overdue++
-		} else if remaining <= horizon {
-			state = "risk"
-			result.Risk++
-		} else {
-			continue
-		}
-
-		if strings.TrimSpace(order.CustomerEmail) == "" {
-			result.Failed++
-			result.Errors = append(result.Errors, BulkUpdateOrderError{
-				OrderID: order.ID,
-				Error:   "missing customer email",
-			})
-			continue
+	// Solo se leen los pedidos abiertos con entrega dentro del horizonte
+	// (incluidos los vencidos), página por página, en lugar de cargar la
+	// organización entera.
+	windowEnd := now.Add(horizon)
+	search := domain.OrderSearch{
+		OrganizationID: organizationIDFromContext(ctx),
+		DeadlineTo:     &windowEnd,
+		OpenOnly:       true,
+		Limit:          slaReminderPageSize,
+	}
+	for {
+		page, err := h.repo.Search(search)
+		if err != nil {
+			return nil, err
 		}
 
-		result.Candidates++
-
-		if cmd.DryRun {
-			continue
+		for _, order := range page.Orders {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+
+			result.Scanned++
+			if order == nil {
+				continue
+			}
+			workflow, ok := workflows[order.OrganizationID]
+			if !ok {
+				workflow, err = h.workflowRepo.FindByOrganization(order.OrganizationID)
+				if err != nil {
+					return nil, err
+				}
+				workflows[order.OrganizationID] = workflow
+			}
+			if workflow.IsClosed(order.Status) {
+				continue
+			}
+			if order.DeliveryDeadline == nil {
+				continue
+			}
+
+			deadline := *order.DeliveryDeadline
+			remaining := deadline.Sub(now)
+			state := ""
+
+			if remaining < 0 {
+				state = "overdue"
+				result.Overdue++
+			} else if remaining <= horizon {
+				state = "risk"
+				result.Risk++
+			} else {
+				continue
+			}
+
+			if strings.TrimSpace(order.CustomerEmail) == "" {
+				result.Failed++
+				result.Errors = append(result.Errors, BulkUpdateOrderError{
+					OrderID: order.ID,
+					Error:   "missing customer email",
+				})
+				continue
+			}
+
+			result.Candidates++
+
+			if cmd.DryRun {
+				continue
+			}
+
+			// El historial y el evento se guardan juntos; el dispatcher de
+			// notificaciones entrega el recordatorio y registra sus intentos.
+			if err := h.historyRepo.Create(&domain.OrderHistoryEntry{
+				OrderID:    order.ID,
+				ChangedBy:  triggeredBy,
+				ChangeType: "sla_reminder_sent",
+				FieldName:  "delivery_deadline",
+				OldValue:   deadline.UTC().Format(time.RFC3339),
+				NewValue:   state,
+				CreatedAt:  time.Now(),
+			}, orderSLARiskEvent(order, deadline, state)); err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, BulkUpdateOrderError{
+					OrderID: order.ID,
+					Error:   err.Error(),
+				})
+				continue
+			}
+
+			result.Notified++
 		}
 
-		trackingURL := fmt.Sprintf("%s/track/%s", frontendURL, order.PublicID)
-		if err := h.mailer.SendOrderSLAReminder(
-			order.CustomerEmail,
-			order.CustomerName,
-			order.OrderNumber,
-			deadline,
-			state,
-			trackingURL,
-		); err != nil {
-			result.Failed++
-			result.Errors = append(result.Errors, BulkUpdateOrderError{
-				OrderID: order.ID,
-				Error:   err.Error(),
-			})
-			continue
+		if page.NextCursor == "" {
+			break
 		}
-
-		result.Notified++
-
-		_ = h.historyRepo.Create(&domain.OrderHistoryEntry{
-			OrderID:    order.ID,
-			ChangedBy:  triggeredBy,
-			ChangeType: "sla_reminder_sent",
-			FieldName:  "delivery_deadline",
-			OldValue:   deadline.UTC().Format(time.RFC3339),
-			NewValue:   state,
-			CreatedAt:  time.Now(),
-		})
+		search.Cursor = page.NextCursor
 	}
 
 	return result, nil
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"log/slog"
 
 	"github.com/dofer/panel-api/internal/modules/orders/domain"
 )
@@ -78,12 +79,14 @@ func (h *PauseTimerHandler) Handle(ctx context.Context, orderID string) error {
 type StopTimerHandler struct {
 	orderRepo domain.OrderRepository
 	timerRepo domain.TimerRepository
+	consumer  domain.MaterialConsumer
 }
 
-func NewStopTimerHandler(orderRepo domain.OrderRepository, timerRepo domain.TimerRepository) *StopTimerHandler {
+func NewStopTimerHandler(orderRepo domain.OrderRepository, timerRepo domain.TimerRepository, consumer domain.MaterialConsumer) *StopTimerHandler {
 	return &StopTimerHandler{
 		orderRepo: orderRepo,
 		timerRepo: timerRepo,
+		consumer:  consumer,
 	}
 }
 
@@ -98,7 +101,17 @@ func (h *StopTimerHandler) Handle(ctx context.Context, orderID string) error {
 
 	// No importa si está corriendo o pausado, se puede detener
 	// Detener el timer
-	return h.timerRepo.StopTimer(orderID, organizationID)
+	if err := h.timerRepo.StopTimer(orderID, organizationID); err != nil {
+		return err
+	}
+
+	// Descontar el filamento del inventario; si falla, el timer ya quedó detenido
+	if h.consumer != nil {
+		if err := h.consumer.ConsumeOrder(ctx, orderID, organizationID); err != nil {
+			slog.Warn("could not record material consumption", "order_id", orderID, "error", err)
+		}
+	}
+	return nil
 }
 
 // GetTimerHandler obtiene el estado actual del timer
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/dofer/panel-api/internal/modules/orders/domain"
 )
@@ -13,13 +14,25 @@ type UpdateOrderItemStatusCommand struct {
 }
 
 type UpdateOrderItemStatusHandler struct {
-	repo domain.OrderRepository
+	repo     domain.OrderRepository
+	consumer domain.MaterialConsumer
 }
 
-func NewUpdateOrderItemStatusHandler(repo domain.OrderRepository) *UpdateOrderItemStatusHandler {
-	return &UpdateOrderItemStatusHandler{repo: repo}
+func NewUpdateOrderItemStatusHandler(repo domain.OrderRepository, consumer domain.MaterialConsumer) *UpdateOrderItemStatusHandler {
+	return &UpdateOrderItemStatusHandler{repo: repo, consumer: consumer}
 }
 
 func (h *UpdateOrderItemStatusHandler) Handle(ctx context.Context, cmd UpdateOrderItemStatusCommand) error {
-	return h.repo.UpdateOrderItemStatus(cmd.OrderID, cmd.ItemID, organizationIDFromContext(ctx), cmd.IsCompleted)
+	organizationID := organizationIDFromContext(ctx)
+	if err := h.repo.UpdateOrderItemStatus(cmd.OrderID, cmd.ItemID, organizationID, cmd.IsCompleted); err != nil {
+		return err
+	}
+
+	// Al completar un item se descuenta su filamento (una sola vez por item)
+	if cmd.IsCompleted && h.consumer != nil {
+		if err := h.consumer.ConsumeOrderItem(ctx, cmd.OrderID, cmd.ItemID, organizationID); err != nil {
+			slog.Warn("could not record material consumption", "order_id", cmd.OrderID, "item_id", cmd.ItemID, "error", err)
+		}
+	}
+	return nil
 }
@@ -2,12 +2,9 @@ package app
 
 import (
 	"context"
-	"fmt"
-	"os"
 	"time"
 
 	"github.com/dofer/panel-api/internal/modules/orders/domain"
-	"github.com/dofer/panel-api/internal/platform/email"
 )
 
 type UpdateOrderStatusCommand struct {
@@ -17,16 +14,16 @@ type UpdateOrderStatusCommand struct {
 }
 
 type UpdateOrderStatusHandler struct {
-	repo        domain.OrderRepository
-	historyRepo domain.OrderHistoryRepository
-	mailer      email.Mailer
+	repo         domain.OrderRepository
+	historyRepo  domain.OrderHistoryRepository
+	workflowRepo domain.WorkflowRepository
 }
 
-func NewUpdateOrderStatusHandler(repo domain.OrderRepository, historyRepo domain.OrderHistoryRepository, mailer email.Mailer) *UpdateOrderStatusHandler {
+func NewUpdateOrderStatusHandler(repo domain.OrderRepository, historyRepo domain.OrderHistoryRepository, workflowRepo domain.WorkflowRepository) *UpdateOrderStatusHandler {
 	return &UpdateOrderStatusHandler{
-		repo:        repo,
-		historyRepo: historyRepo,
-		mailer:      mailer,
+		repo:         repo,
+		historyRepo:  historyRepo,
+		workflowRepo: workflowRepo,
 	}
 }
 
@@ -36,13 +33,20 @@ func (h *UpdateOrderStatusHandler) Handle(ctx context.Context, cmd UpdateOrderSt
 		return nil, ErrOrderNotFound
 	}
 
+	workflow, err := h.workflowRepo.FindByOrganization(order.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
 	oldStatus := string(order.Status)
 
-	if err := order.ChangeStatus(domain.OrderStatus(cmd.NewStatus)); err != nil {
+	if err := order.ChangeStatus(workflow, domain.OrderStatus(cmd.NewStatus)); err != nil {
 		return nil, err
 	}
 
-	if err := h.repo.Update(order); err != nil {
+	// El evento viaja en la misma transacción: el dispatcher de notificaciones
+	// avisa al cliente solo si el cambio quedó guardado.
+	if err := h.repo.Update(order, orderStatusChangedEvent(order, workflow, oldStatus, cmd.ChangedBy)); err != nil {
 		return nil, err
 	}
 
@@ -58,27 +62,5 @@ func (h *UpdateOrderStatusHandler) Handle(ctx context.Context, cmd UpdateOrderSt
 	}
 	h.historyRepo.Create(historyEntry)
 
-	// Enviar notificación por email si el cliente tiene email
-	if order.CustomerEmail != "" {
-		frontendURL := os.Getenv("FRONTEND_URL")
-		if frontendURL == "" {
-			frontendURL = "http://localhost:3000"
-		}
-		trackingURL := fmt.Sprintf("%s/track/%s", frontendURL, order.PublicID)
-
-		go func() {
-			err := h.mailer.SendOrderStatusUpdate(
-				order.CustomerEmail,
-				order.CustomerName,
-				order.OrderNumber,
-				cmd.NewStatus,
-				trackingURL,
-			)
-			if err != nil {
-				fmt.Printf("Error sending email notification: %v\n", err)
-			}
-		}()
-	}
-
 	return order, nil
 }
@@ -81,6 +81,7 @@ type OrderItem struct {
 	OrderID        string     `json:"order_id"`
 	ProductName    string     `json:"product_name"`
 	Description    string     `json:"description"`
+	QuoteItemID    string     `json:"quote_item_id,omitempty"`
 	Quantity       int        `json:"quantity"`
 	UnitPrice      float64    `json:"unit_price"`
 	Total          float64    `json:"total"`
@@ -89,6 +90,8 @@ type OrderItem struct {
 	CreatedAt      time.Time  `json:"created_at"`
 }
 
+// NewOrder valida los datos mínimos de una orden. Con orderNumber vacío el
+// repositorio asigna el folio de la organización al guardarla.
 func NewOrder(
 	orderNumber string,
 	platform OrderPlatform,
@@ -96,9 +99,6 @@ func NewOrder(
 	productName string,
 	quantity int,
 ) (*Order, error) {
-	if orderNumber == "" {
-		return nil, errors.New("order number is required")
-	}
 	if customerName == "" {
 		return nil, errors.New("customer name is required")
 	}
@@ -125,15 +125,25 @@ func NewOrder(
 	}, nil
 }
 
-func (o *Order) ChangeStatus(newStatus OrderStatus) error {
-	if !o.canTransitionTo(newStatus) {
-		return errors.New("invalid status transition")
+// ChangeStatus mueve la orden a otra etapa del flujo de su organización. Sin
+// flujo se usa DefaultWorkflow.
+func (o *Order) ChangeStatus(workflow *Workflow, newStatus OrderStatus) error {
+	if workflow == nil {
+		workflow = DefaultWorkflow()
+	}
+
+	stage, ok := workflow.Stage(newStatus)
+	if !ok {
+		return ErrUnknownStage
+	}
+	if !workflow.CanTransition(o.Status, newStatus) {
+		return ErrInvalidStatusTransition
 	}
 
 	o.Status = newStatus
 	o.UpdatedAt = time.Now()
 
-	if newStatus == StatusDelivered {
+	if stage.Completes {
 		now := time.Now()
 		o.CompletedAt = &now
 	}
@@ -141,32 +151,6 @@ func (o *Order) ChangeStatus(newStatus OrderStatus) error {
 	return nil
 }
 
-func (o *Order) canTransitionTo(newStatus OrderStatus) bool {
-	// Permitir transiciones hacia adelante y hacia atrás, excepto desde estados terminales
-	validTransitions := map[OrderStatus][]OrderStatus{
-		StatusNew:       {StatusPrinting, StatusCancelled},
-		StatusPrinting:  {StatusNew, StatusPost, StatusCancelled},
-		StatusPost:      {StatusPrinting, StatusPacked, StatusCancelled},
-		StatusPacked:    {StatusPost, StatusReady, StatusCancelled},
-		StatusReady:     {StatusPacked, StatusDelivered, StatusCancelled},
-		StatusDelivered: {StatusReady},
-		StatusCancelled: {StatusNew},
-	}
-
-	allowed, ok := validTransitions[o.Status]
-	if !ok {
-		return false
-	}
-
-	for _, status := range allowed {
-		if status == newStatus {
-			return true
-		}
-	}
-
-	return false
-}
-
 func (o *Order) AssignTo(userID string) {
 	o.AssignedTo = userID
 	now := time.Now()
@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"github.com/dofer/panel-api/internal/platform/outbox"
+)
 
 type OrderHistoryEntry struct {
 	ID             string
@@ -15,6 +19,6 @@ type OrderHistoryEntry struct {
 }
 
 type OrderHistoryRepository interface {
-	Create(entry *OrderHistoryEntry) error
+	Create(entry *OrderHistoryEntry, events ...outbox.Event) error
 	FindByOrderID(orderID, organizationID string) ([]*OrderHistoryEntry, error)
 }
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// OrderSearch es la consulta de búsqueda de pedidos. Los campos vacíos no
+// filtran; los conjuntos (estados, prioridades, plataformas) aceptan
+// cualquiera de sus valores. Sin OrganizationID se busca en todas las
+// organizaciones, como hace el worker de recordatorios.
+type OrderSearch struct {
+	OrganizationID string
+	// Text busca en número, cliente, producto y notas, por palabra o parcial.
+	Text       string
+	Customer   string
+	Statuses   []OrderStatus
+	Priorities []OrderPriority
+	Platforms  []OrderPlatform
+	// AssignedTo busca en el ID, nombre o correo del operador, también parcial.
+	AssignedTo string
+	// CreatedFrom es inclusivo y CreatedTo exclusivo; igual las fechas de
+	// entrega. Un filtro de entrega deja fuera los pedidos sin fecha.
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	DeadlineFrom *time.Time
+	DeadlineTo   *time.Time
+	WithBalance  bool
+	// OpenOnly deja fuera los estados que cierran la orden en el flujo de su
+	// organización (el flujo por defecto si no definió uno).
+	OpenOnly bool
+
+	Limit  int
+	Cursor string
+	// CountTotal pide además el total de coincidencias sin paginar.
+	CountTotal bool
+}
+
+// OrderSearchResult trae una página ordenada del pedido más nuevo al más
+// viejo. NextCursor queda vacío en la última página.
+type OrderSearchResult struct {
+	Orders     []*Order
+	Total      int
+	NextCursor string
+}
+
+// OrderCursor es la posición (created_at, id) del último pedido de una
+// página; la siguiente empieza justo después.
+type OrderCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+func (c OrderCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeOrderCursor(encoded string) (OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return OrderCursor{}, ErrInvalidCursor
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return OrderCursor{}, ErrInvalidCursor
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return OrderCursor{}, ErrInvalidCursor
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return OrderCursor{}, ErrInvalidCursor
+	}
+	return OrderCursor{CreatedAt: parsed, ID: id}, nil
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrderCursorRoundTrip(t *testing.T) {
+	cursor := OrderCursor{
+		CreatedAt: time.Date(2026, 10, 17, 9, 30, 0, 123456000, time.FixedZone("CST", -6*3600)),
+		ID:        "6f1c2d2e-8f43-4f38-9a51-0d4a5e7b9c10",
+	}
+
+	decoded, err := DecodeOrderCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("DecodeOrderCursor() error = %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Fatalf("unexpected cursor %+v", decoded)
+	}
+}
+
+func TestDecodeOrderCursorRejectsGarbage(t *testing.T) {
+	for _, encoded := range []string{
+		"no es base64!",
+		OrderCursor{CreatedAt: time.Now(), ID: "not-a-uuid"}.Encode(),
+		"MjAyNi0xMC0xNw",
+	} {
+		if _, err := DecodeOrderCursor(encoded); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("expected invalid cursor for %q, got %v", encoded, err)
+		}
+	}
+}
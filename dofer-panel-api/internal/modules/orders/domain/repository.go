@@ -1,11 +1,20 @@
 package domain
 
+import (
+	"context"
+
+	"github.com/dofer/panel-api/internal/platform/outbox"
+)
+
 type OrderRepository interface {
 	Create(order *Order) error
 	FindByID(id string, organizationID ...string) (*Order, error)
 	FindByPublicID(publicID string) (*Order, error)
 	FindAll(filters OrderFilters) ([]*Order, error)
-	Update(order *Order) error
+	// Search filtra y pagina en la base con cursor (created_at, id).
+	Search(search OrderSearch) (*OrderSearchResult, error)
+	// Update guarda la orden; los eventos se escriben en la misma transacción.
+	Update(order *Order, events ...outbox.Event) error
 
 	// Order Items
 	CreateOrderItem(item *OrderItem) error
@@ -14,13 +23,20 @@ type OrderRepository interface {
 	DeleteOrderItem(orderID, itemID, organizationID string) error
 
 	// Order Payments
-	AddPayment(payment *OrderPayment) error
+	AddPayment(payment *OrderPayment, events ...outbox.Event) error
 	GetPayments(orderID, organizationID string) ([]*OrderPayment, error)
 	GetPaymentByID(paymentID, organizationID string) (*OrderPayment, error)
 	DeletePayment(paymentID, organizationID string) error
 	UpdateOrderPaymentTotals(orderID, organizationID string, amountPaid float64, balance float64) error
 }
 
+// MaterialConsumer descuenta del inventario el filamento que usó una orden.
+// Es idempotente: los items que ya tienen consumo registrado se ignoran.
+type MaterialConsumer interface {
+	ConsumeOrder(ctx context.Context, orderID, organizationID string) error
+	ConsumeOrderItem(ctx context.Context, orderID, itemID, organizationID string) error
+}
+
 type OrderFilters struct {
 	OrganizationID string
 	Status         OrderStatus
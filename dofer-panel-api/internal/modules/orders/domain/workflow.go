@@ -0,0 +1,203 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	ErrUnknownStage            = errors.New("status is not a stage of the organization workflow")
+)
+
+const maxWorkflowStages = 20
+
+var stageKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// WorkflowStage es una etapa del flujo de producción de una organización. El
+// Key es lo que se guarda en orders.status.
+type WorkflowStage struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Color string `json:"color,omitempty"`
+	// Completes marca la orden como terminada (completed_at) al entrar.
+	Completes bool `json:"completes,omitempty"`
+	// Cancels cierra la orden sin completarla.
+	Cancels bool `json:"cancels,omitempty"`
+}
+
+func (s WorkflowStage) IsClosed() bool {
+	return s.Completes || s.Cancels
+}
+
+type WorkflowTransition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Workflow define las etapas y transiciones permitidas de las órdenes de una
+// organización. La primera etapa es la inicial de las órdenes nuevas.
+type Workflow struct {
+	OrganizationID string               `json:"organization_id,omitempty"`
+	Stages         []WorkflowStage      `json:"stages"`
+	Transitions    []WorkflowTransition `json:"transitions"`
+	IsDefault      bool                 `json:"is_default"`
+	UpdatedAt      *time.Time           `json:"updated_at,omitempty"`
+}
+
+// DefaultWorkflow es el flujo que usan las organizaciones que no han definido
+// uno propio: new→printing→post→packed→ready→delivered, con cancelación.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Stages: []WorkflowStage{
+			{Key: string(StatusNew), Label: "Nueva"},
+			{Key: string(StatusPrinting), Label: "En Impresión"},
+			{Key: string(StatusPost), Label: "Post-procesamiento"},
+			{Key: string(StatusPacked), Label: "Empacada"},
+			{Key: string(StatusReady), Label: "Lista para entrega"},
+			{Key: string(StatusDelivered), Label: "Entregada", Completes: true},
+			{Key: string(StatusCancelled), Label: "Cancelada", Cancels: true},
+		},
+		Transitions: []WorkflowTransition{
+			{From: "new", To: "printing"}, {From: "new", To: "cancelled"},
+			{From: "printing", To: "new"}, {From: "printing", To: "post"}, {From: "printing", To: "cancelled"},
+			{From: "post", To: "printing"}, {From: "post", To: "packed"}, {From: "post", To: "cancelled"},
+			{From: "packed", To: "post"}, {From: "packed", To: "ready"}, {From: "packed", To: "cancelled"},
+			{From: "ready", To: "packed"}, {From: "ready", To: "delivered"}, {From: "ready", To: "cancelled"},
+			{From: "delivered", To: "ready"},
+			{From: "cancelled", To: "new"},
+		},
+		IsDefault: true,
+	}
+}
+
+func (w *Workflow) Stage(status OrderStatus) (WorkflowStage, bool) {
+	for _, stage := range w.Stages {
+		if stage.Key == string(status) {
+			return stage, true
+		}
+	}
+	return WorkflowStage{}, false
+}
+
+func (w *Workflow) StageIndex(status OrderStatus) int {
+	for i, stage := range w.Stages {
+		if stage.Key == string(status) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (w *Workflow) InitialStage() OrderStatus {
+	if len(w.Stages) == 0 {
+		return StatusNew
+	}
+	return OrderStatus(w.Stages[0].Key)
+}
+
+func (w *Workflow) CanTransition(from, to OrderStatus) bool {
+	for _, transition := range w.Transitions {
+		if transition.From == string(from) && transition.To == string(to) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClosed indica si la orden ya no está en producción. Estados que no existen
+// en el flujo se tratan como abiertos.
+func (w *Workflow) IsClosed(status OrderStatus) bool {
+	stage, ok := w.Stage(status)
+	return ok && stage.IsClosed()
+}
+
+// ClosedStatuses lista los keys de las etapas que cierran la orden.
+func (w *Workflow) ClosedStatuses() []string {
+	statuses := []string{}
+	for _, stage := range w.Stages {
+		if stage.IsClosed() {
+			statuses = append(statuses, stage.Key)
+		}
+	}
+	return statuses
+}
+
+// Normalize limpia espacios y pasa los keys a minúsculas antes de validar.
+func (w *Workflow) Normalize() {
+	for i := range w.Stages {
+		w.Stages[i].Key = strings.ToLower(strings.TrimSpace(w.Stages[i].Key))
+		w.Stages[i].Label = strings.TrimSpace(w.Stages[i].Label)
+		w.Stages[i].Color = strings.TrimSpace(w.Stages[i].Color)
+	}
+	for i := range w.Transitions {
+		w.Transitions[i].From = strings.ToLower(strings.TrimSpace(w.Transitions[i].From))
+		w.Transitions[i].To = strings.ToLower(strings.TrimSpace(w.Transitions[i].To))
+	}
+}
+
+func (w *Workflow) Validate() error {
+	if len(w.Stages) < 2 {
+		return errors.New("workflow requires at least two stages")
+	}
+	if len(w.Stages) > maxWorkflowStages {
+		return fmt.Errorf("workflow supports at most %d stages", maxWorkflowStages)
+	}
+
+	keys := make(map[string]struct{}, len(w.Stages))
+	hasCompletion := false
+	for i, stage := range w.Stages {
+		if !stageKeyPattern.MatchString(stage.Key) {
+			return fmt.Errorf("invalid stage key %q: use lowercase letters, digits and underscores", stage.Key)
+		}
+		if stage.Label == "" {
+			return fmt.Errorf("stage %q requires a label", stage.Key)
+		}
+		if _, exists := keys[stage.Key]; exists {
+			return fmt.Errorf("duplicate stage key %q", stage.Key)
+		}
+		if stage.Completes && stage.Cancels {
+			return fmt.Errorf("stage %q cannot both complete and cancel orders", stage.Key)
+		}
+		if i == 0 && stage.IsClosed() {
+			return fmt.Errorf("initial stage %q cannot close orders", stage.Key)
+		}
+		if stage.Completes {
+			hasCompletion = true
+		}
+		keys[stage.Key] = struct{}{}
+	}
+	if !hasCompletion {
+		return errors.New("workflow requires at least one stage that completes orders")
+	}
+
+	seen := make(map[WorkflowTransition]struct{}, len(w.Transitions))
+	for _, transition := range w.Transitions {
+		if _, ok := keys[transition.From]; !ok {
+			return fmt.Errorf("transition from unknown stage %q", transition.From)
+		}
+		if _, ok := keys[transition.To]; !ok {
+			return fmt.Errorf("transition to unknown stage %q", transition.To)
+		}
+		if transition.From == transition.To {
+			return fmt.Errorf("stage %q cannot transition to itself", transition.From)
+		}
+		if _, exists := seen[transition]; exists {
+			return fmt.Errorf("duplicate transition %s -> %s", transition.From, transition.To)
+		}
+		seen[transition] = struct{}{}
+	}
+	return nil
+}
+
+type WorkflowRepository interface {
+	// FindByOrganization devuelve el flujo de la organización o el flujo por
+	// defecto si no ha configurado uno.
+	FindByOrganization(organizationID string) (*Workflow, error)
+	Save(workflow *Workflow) error
+	Delete(organizationID string) error
+	CountOrdersByStatus(organizationID string) (map[OrderStatus]int, error)
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func customWorkflow() *Workflow {
+	return &Workflow{
+		Stages: []WorkflowStage{
+			{Key: "design_review", Label: "Revisión de diseño"},
+			{Key: "printing", Label: "Imprimiendo"},
+			{Key: "qa", Label: "Control de calidad"},
+			{Key: "shipped", Label: "Enviado", Completes: true},
+			{Key: "cancelled", Label: "Cancelado", Cancels: true},
+		},
+		Transitions: []WorkflowTransition{
+			{From: "design_review", To: "printing"},
+			{From: "printing", To: "qa"},
+			{From: "qa", To: "printing"},
+			{From: "qa", To: "shipped"},
+			{From: "design_review", To: "cancelled"},
+		},
+	}
+}
+
+func TestDefaultWorkflowIsValid(t *testing.T) {
+	workflow := DefaultWorkflow()
+	if err := workflow.Validate(); err != nil {
+		t.Fatalf("default workflow should be valid: %v", err)
+	}
+	if workflow.InitialStage() != StatusNew {
+		t.Fatalf("unexpected initial stage %q", workflow.InitialStage())
+	}
+	if !workflow.CanTransition(StatusReady, StatusDelivered) || workflow.CanTransition(StatusNew, StatusDelivered) {
+		t.Fatal("default transitions do not match the legacy graph")
+	}
+}
+
+func TestOrderChangeStatusFollowsOrganizationWorkflow(t *testing.T) {
+	workflow := customWorkflow()
+	order := &Order{Status: workflow.InitialStage()}
+
+	if err := order.ChangeStatus(workflow, "qa"); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatalf("expected invalid transition, got %v", err)
+	}
+	if err := order.ChangeStatus(workflow, StatusPacked); !errors.Is(err, ErrUnknownStage) {
+		t.Fatalf("expected unknown stage, got %v", err)
+	}
+
+	for _, status := range []OrderStatus{"printing", "qa", "shipped"} {
+		if err := order.ChangeStatus(workflow, status); err != nil {
+			t.Fatalf("ChangeStatus(%s) returned an error: %v", status, err)
+		}
+	}
+	if order.CompletedAt == nil {
+		t.Fatal("expected completing stage to set CompletedAt")
+	}
+	if !workflow.IsClosed(order.Status) {
+		t.Fatal("expected shipped to close the order")
+	}
+}
+
+func TestWorkflowValidate(t *testing.T) {
+	tests := map[string]struct {
+		mutate func(*Workflow)
+		want   string
+	}{
+		"invalid key": {
+			mutate: func(w *Workflow) { w.Stages[1].Key = "Imprimiendo!" },
+			want:   "invalid stage key",
+		},
+		"duplicate key": {
+			mutate: func(w *Workflow) { w.Stages[2].Key = "printing" },
+			want:   "duplicate stage key",
+		},
+		"no completion": {
+			mutate: func(w *Workflow) { w.Stages[3].Completes = false },
+			want:   "completes orders",
+		},
+		"closed initial stage": {
+			mutate: func(w *Workflow) { w.Stages[0].Cancels = true },
+			want:   "initial stage",
+		},
+		"unknown transition target": {
+			mutate: func(w *Workflow) { w.Transitions[0].To = "packed" },
+			want:   "unknown stage",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			workflow := customWorkflow()
+			test.mutate(workflow)
+			err := workflow.Validate()
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("expected error containing %q, got %v", test.want, err)
+			}
+		})
+	}
+}
+
+func TestWorkflowClosedStatuses(t *testing.T) {
+	got := strings.Join(customWorkflow().ClosedStatuses(), ",")
+	if got != "shipped,cancelled" {
+		t.Fatalf("ClosedStatuses() = %q", got)
+	}
+	if got := strings.Join(DefaultWorkflow().ClosedStatuses(), ","); got != "delivered,cancelled" {
+		t.Fatalf("default ClosedStatuses() = %q", got)
+	}
+}
@@ -11,9 +11,10 @@ import (
 func (r *PostgresOrderRepository) CreateOrderItem(item *domain.OrderItem) error {
 	query := `
 		INSERT INTO order_items (
-			id, organization_id, order_id, product_name, description, quantity, unit_price, total, is_completed
+			id, organization_id, order_id, product_name, description, quantity, unit_price, total, is_completed,
+			quote_item_id
 		)
-		SELECT $1, organization_id, $2, $3, $4, $5, $6, $7, $8
+		SELECT $1, organization_id, $2, $3, $4, $5, $6, $7, $8, NULLIF($10, '')::uuid
 		FROM orders
 		WHERE id = $2 AND organization_id = $9
 	`
@@ -30,6 +31,7 @@ func (r *PostgresOrderRepository) CreateOrderItem(item *domain.OrderItem) error
 		item.Total,
 		item.IsCompleted,
 		item.OrganizationID,
+		item.QuoteItemID,
 	)
 
 	return err
@@ -5,10 +5,11 @@ import (
 	"database/sql"
 
 	"github.com/dofer/panel-api/internal/modules/orders/domain"
+	"github.com/dofer/panel-api/internal/platform/outbox"
 )
 
 // AddPayment agrega un pago a una orden
-func (r *PostgresOrderRepository) AddPayment(payment *domain.OrderPayment) error {
+func (r *PostgresOrderRepository) AddPayment(payment *domain.OrderPayment, events ...outbox.Event) error {
 	query := `
 		INSERT INTO order_payments (
 			id, organization_id, order_id, amount, payment_method, payment_date, notes, created_by, created_at
@@ -18,8 +19,10 @@ func (r *PostgresOrderRepository) AddPayment(payment *domain.OrderPayment) error
 		WHERE id = $2 AND organization_id = $9
 	`
 
-	_, err := r.db.Exec(
+	return outbox.ExecWithEvents(
 		context.Background(),
+		r.db,
+		events,
 		query,
 		payment.ID,
 		payment.OrderID,
@@ -31,8 +34,6 @@ func (r *PostgresOrderRepository) AddPayment(payment *domain.OrderPayment) error
 		payment.CreatedAt,
 		payment.OrganizationID,
 	)
-
-	return err
 }
 
 // GetPayments obtiene todos los pagos de una orden
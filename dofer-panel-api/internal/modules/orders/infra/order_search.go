@@ -0,0 +1,167 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dofer/panel-api/internal/modules/orders/domain"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+func (r *PostgresOrderRepository) Search(search domain.OrderSearch) (*domain.OrderSearchResult, error) {
+	ctx := context.Background()
+	limit := search.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	conditions, args := orderSearchConditions(search)
+	result := &domain.OrderSearchResult{Orders: []*domain.Order{}}
+	if search.CountTotal {
+		query := "SELECT COUNT(*) FROM orders WHERE " + strings.Join(conditions, " AND ")
+		if err := r.db.QueryRow(ctx, query, args...).Scan(&result.Total); err != nil {
+			return nil, err
+		}
+	}
+
+	if search.Cursor != "" {
+		cursor, err := domain.DecodeOrderCursor(search.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	// Se pide un pedido de más para saber si hay otra página.
+	args = append(args, limit+1)
+	query := fmt.Sprintf(`
+		SELECT id, organization_id, public_id, order_number, platform, status, priority,
+			customer_name, customer_email, customer_phone,
+			product_name, product_image, print_file, print_file_name,
+			quantity, notes, internal_notes, metadata,
+			assigned_to, assigned_at, created_at, updated_at, completed_at, delivery_deadline,
+			amount, amount_paid, balance, affiliate_id
+		FROM orders
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		order, err := r.scanOrderFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		result.Orders = append(result.Orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(result.Orders) > limit {
+		result.Orders = result.Orders[:limit]
+		last := result.Orders[limit-1]
+		result.NextCursor = domain.OrderCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	return result, nil
+}
+
+// orderSearchConditions arma el WHERE compartido por la página y el conteo.
+func orderSearchConditions(search domain.OrderSearch) ([]string, []any) {
+	conditions := []string{"TRUE"}
+	args := []any{}
+	add := func(condition string, values ...any) {
+		placeholders := make([]any, len(values))
+		for index, value := range values {
+			args = append(args, value)
+			placeholders[index] = len(args)
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
+
+	if search.OrganizationID != "" {
+		add("organization_id = $%d", search.OrganizationID)
+	}
+	if text := strings.ToLower(strings.TrimSpace(search.Text)); text != "" {
+		// La búsqueda por palabras encuentra "dragones" con "dragón"; la
+		// parcial con trigramas cubre folios y fragmentos como "ORD-2026-0004".
+		add(
+			"(search_vector @@ websearch_to_tsquery('spanish', $%d) OR search_text LIKE $%d)",
+			text,
+			"%"+escapeLike(text)+"%",
+		)
+	}
+	if customer := strings.ToLower(strings.TrimSpace(search.Customer)); customer != "" {
+		add("lower(customer_name) LIKE $%d", "%"+escapeLike(customer)+"%")
+	}
+	if len(search.Statuses) > 0 {
+		add("status = ANY($%d)", toStrings(search.Statuses))
+	}
+	if len(search.Priorities) > 0 {
+		add("priority = ANY($%d)", toStrings(search.Priorities))
+	}
+	if len(search.Platforms) > 0 {
+		add("platform = ANY($%d)", toStrings(search.Platforms))
+	}
+	if operator := strings.ToLower(strings.TrimSpace(search.AssignedTo)); operator != "" {
+		add(`(assigned_to::text LIKE $%[1]d OR EXISTS (
+			SELECT 1 FROM users u
+			WHERE u.id = orders.assigned_to
+				AND (lower(u.full_name) LIKE $%[1]d OR lower(u.email) LIKE $%[1]d)
+		))`, "%"+escapeLike(operator)+"%")
+	}
+	if search.CreatedFrom != nil {
+		add("created_at >= $%d", *search.CreatedFrom)
+	}
+	if search.CreatedTo != nil {
+		add("created_at < $%d", *search.CreatedTo)
+	}
+	if search.DeadlineFrom != nil {
+		add("delivery_deadline >= $%d", *search.DeadlineFrom)
+	}
+	if search.DeadlineTo != nil {
+		add("delivery_deadline < $%d", *search.DeadlineTo)
+	}
+	if search.WithBalance {
+		conditions = append(conditions, "balance > 0")
+	}
+	if search.OpenOnly {
+		// Las etapas cerradas salen del flujo guardado de la organización; sin
+		// flujo propio se usan las del flujo por defecto.
+		add(`status <> ALL(COALESCE(
+			(SELECT array_agg(stage->>'key')
+			FROM order_workflows wf, jsonb_array_elements(wf.stages) stage
+			WHERE wf.organization_id = orders.organization_id
+				AND (COALESCE((stage->>'completes')::boolean, FALSE) OR COALESCE((stage->>'cancels')::boolean, FALSE))),
+			CASE WHEN EXISTS (SELECT 1 FROM order_workflows wf WHERE wf.organization_id = orders.organization_id)
+				THEN ARRAY[]::text[] ELSE $%d::text[] END
+		))`, domain.DefaultWorkflow().ClosedStatuses())
+	}
+	return conditions, args
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func toStrings[T ~string](values []T) []string {
+	result := make([]string, len(values))
+	for index, value := range values {
+		result[index] = string(value)
+	}
+	return result
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/dofer/panel-api/internal/modules/orders/domain"
+	"github.com/dofer/panel-api/internal/platform/outbox"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -16,7 +17,7 @@ func NewPostgresOrderHistoryRepository(db *pgxpool.Pool) *PostgresOrderHistoryRe
 	return &PostgresOrderHistoryRepository{db: db}
 }
 
-func (r *PostgresOrderHistoryRepository) Create(entry *domain.OrderHistoryEntry) error {
+func (r *PostgresOrderHistoryRepository) Create(entry *domain.OrderHistoryEntry, events ...outbox.Event) error {
 	query := `
 		INSERT INTO order_history (
 			id, organization_id, order_id, changed_by, change_type, field_name, old_value, new_value, created_at
@@ -26,8 +27,10 @@ func (r *PostgresOrderHistoryRepository) Create(entry *domain.OrderHistoryEntry)
 		WHERE id = $2
 	`
 
-	_, err := r.db.Exec(
+	return outbox.ExecWithEvents(
 		context.Background(),
+		r.db,
+		events,
 		query,
 		uuid.New().String(),
 		entry.OrderID,
@@ -38,8 +41,6 @@ func (r *PostgresOrderHistoryRepository) Create(entry *domain.OrderHistoryEntry)
 		entry.NewValue,
 		entry.CreatedAt,
 	)
-
-	return err
 }
 
 func (r *PostgresOrderHistoryRepository) FindByOrderID(orderID, organizationID string) ([]*domain.OrderHistoryEntry, error) {
@@ -6,20 +6,31 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/dofer/panel-api/internal/modules/orders/domain"
+	"github.com/dofer/panel-api/internal/platform/numbering"
+	"github.com/dofer/panel-api/internal/platform/outbox"
+	"github.com/dofer/panel-api/internal/platform/unitofwork"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PostgresOrderRepository struct {
-	db *pgxpool.Pool
+	db unitofwork.DB
 }
 
 func NewPostgresOrderRepository(db *pgxpool.Pool) *PostgresOrderRepository {
 	return &PostgresOrderRepository{db: db}
 }
 
+// WithTx devuelve el repositorio atado a una transacción abierta.
+func (r *PostgresOrderRepository) WithTx(tx pgx.Tx) domain.OrderRepository {
+	return &PostgresOrderRepository{db: tx}
+}
+
+// Create guarda la orden. Si no trae número le asigna el siguiente folio de la
+// organización en la misma transacción del INSERT.
 func (r *PostgresOrderRepository) Create(order *domain.Order) error {
 	if err := r.ensureMonthlyOrderLimit(order.OrganizationID); err != nil {
 		return err
@@ -42,37 +53,52 @@ func (r *PostgresOrderRepository) Create(order *domain.Order) error {
 		affiliateID = order.AffiliateID
 	}
 
-	_, err := r.db.Exec(
-		context.Background(),
-		query,
-		order.ID,
-		order.OrganizationID,
-		order.PublicID,
-		order.OrderNumber,
-		order.Platform,
-		order.Status,
-		order.Priority,
-		order.CustomerName,
-		order.CustomerEmail,
-		order.CustomerPhone,
-		order.ProductName,
-		order.ProductImage,
-		order.PrintFile,
-		order.PrintFileName,
-		order.Quantity,
-		order.Notes,
-		order.InternalNotes,
-		metadata,
-		order.DeliveryDeadline,
-		order.Amount,
-		order.AmountPaid,
-		order.Balance,
-		affiliateID,
-		order.CreatedAt,
-		order.UpdatedAt,
-	)
-
-	return err
+	ctx := context.Background()
+	orderNumber := order.OrderNumber
+	err := unitofwork.Run(ctx, r.db, func(tx pgx.Tx) error {
+		if orderNumber == "" {
+			number, err := numbering.Next(ctx, tx, order.OrganizationID, numbering.DocumentOrder, time.Now())
+			if err != nil {
+				return err
+			}
+			orderNumber = number
+		}
+		_, err := tx.Exec(
+			ctx,
+			query,
+			order.ID,
+			order.OrganizationID,
+			order.PublicID,
+			orderNumber,
+			order.Platform,
+			order.Status,
+			order.Priority,
+			order.CustomerName,
+			order.CustomerEmail,
+			order.CustomerPhone,
+			order.ProductName,
+			order.ProductImage,
+			order.PrintFile,
+			order.PrintFileName,
+			order.Quantity,
+			order.Notes,
+			order.InternalNotes,
+			metadata,
+			order.DeliveryDeadline,
+			order.Amount,
+			order.AmountPaid,
+			order.Balance,
+			affiliateID,
+			order.CreatedAt,
+			order.UpdatedAt,
+		)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	order.OrderNumber = orderNumber
+	return nil
 }
 
 func (r *PostgresOrderRepository) ensureMonthlyOrderLimit(organizationID string) error {
@@ -213,7 +239,7 @@ func (r *PostgresOrderRepository) FindAll(filters domain.OrderFilters) ([]*domai
 	return orders, nil
 }
 
-func (r *PostgresOrderRepository) Update(order *domain.Order) error {
+func (r *PostgresOrderRepository) Update(order *domain.Order, events ...outbox.Event) error {
 	query := `
 		UPDATE orders SET
 			status = $2,
@@ -276,8 +302,7 @@ func (r *PostgresOrderRepository) Update(order *domain.Order) error {
 		args = append(args, order.OrganizationID)
 	}
 
-	_, err := r.db.Exec(context.Background(), query, args...)
-	return err
+	return outbox.ExecWithEvents(context.Background(), r.db, events, query, args...)
 }
 
 func (r *PostgresOrderRepository) scanOrder(row pgx.Row) (*domain.Order, error) {
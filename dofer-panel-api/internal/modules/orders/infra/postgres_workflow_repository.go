@@ -0,0 +1,109 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/orders/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresWorkflowRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresWorkflowRepository(db *pgxpool.Pool) *PostgresWorkflowRepository {
+	return &PostgresWorkflowRepository{db: db}
+}
+
+func (r *PostgresWorkflowRepository) FindByOrganization(organizationID string) (*domain.Workflow, error) {
+	query := `
+		SELECT stages, transitions, updated_at
+		FROM order_workflows
+		WHERE organization_id = $1
+	`
+
+	var stagesJSON, transitionsJSON []byte
+	var updatedAt time.Time
+	err := r.db.QueryRow(context.Background(), query, organizationID).Scan(&stagesJSON, &transitionsJSON, &updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		workflow := domain.DefaultWorkflow()
+		workflow.OrganizationID = organizationID
+		return workflow, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	workflow := &domain.Workflow{
+		OrganizationID: organizationID,
+		UpdatedAt:      &updatedAt,
+	}
+	if err := json.Unmarshal(stagesJSON, &workflow.Stages); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(transitionsJSON, &workflow.Transitions); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+func (r *PostgresWorkflowRepository) Save(workflow *domain.Workflow) error {
+	stagesJSON, err := json.Marshal(workflow.Stages)
+	if err != nil {
+		return err
+	}
+	transitionsJSON, err := json.Marshal(workflow.Transitions)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO order_workflows (organization_id, stages, transitions)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id) DO UPDATE
+		SET stages = EXCLUDED.stages,
+			transitions = EXCLUDED.transitions
+		RETURNING updated_at
+	`
+
+	var updatedAt time.Time
+	if err := r.db.QueryRow(context.Background(), query, workflow.OrganizationID, stagesJSON, transitionsJSON).Scan(&updatedAt); err != nil {
+		return err
+	}
+	workflow.IsDefault = false
+	workflow.UpdatedAt = &updatedAt
+	return nil
+}
+
+func (r *PostgresWorkflowRepository) Delete(organizationID string) error {
+	_, err := r.db.Exec(context.Background(), `DELETE FROM order_workflows WHERE organization_id = $1`, organizationID)
+	return err
+}
+
+func (r *PostgresWorkflowRepository) CountOrdersByStatus(organizationID string) (map[domain.OrderStatus]int, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT status, COUNT(*)
+		FROM orders
+		WHERE organization_id = $1
+		GROUP BY status
+	`, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[domain.OrderStatus]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[domain.OrderStatus(status)] = count
+	}
+	return counts, rows.Err()
+}
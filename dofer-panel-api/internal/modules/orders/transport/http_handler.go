@@ -3,11 +3,14 @@ package transport
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/dofer/panel-api/internal/modules/orders/app"
+	"github.com/dofer/panel-api/internal/modules/orders/domain"
 	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
 	"github.com/go-chi/chi/v5"
 )
@@ -39,6 +42,10 @@ type OrderHandler struct {
 	getPaymentsHandler        *app.GetOrderPaymentsHandler
 	deletePaymentHandler      *app.DeleteOrderPaymentHandler
 	recalculateTotalsHandler  *app.RecalculateOrderTotalsHandler
+	getWorkflowHandler        *app.GetOrderWorkflowHandler
+	updateWorkflowHandler     *app.UpdateOrderWorkflowHandler
+	resetWorkflowHandler      *app.ResetOrderWorkflowHandler
+	renderReceiptHandler      *app.RenderOrderReceiptHandler
 }
 
 func NewOrderHandler(
@@ -68,6 +75,10 @@ func NewOrderHandler(
 	getPaymentsHandler *app.GetOrderPaymentsHandler,
 	deletePaymentHandler *app.DeleteOrderPaymentHandler,
 	recalculateTotalsHandler *app.RecalculateOrderTotalsHandler,
+	getWorkflowHandler *app.GetOrderWorkflowHandler,
+	updateWorkflowHandler *app.UpdateOrderWorkflowHandler,
+	resetWorkflowHandler *app.ResetOrderWorkflowHandler,
+	renderReceiptHandler *app.RenderOrderReceiptHandler,
 ) *OrderHandler {
 	return &OrderHandler{
 		createHandler:             createHandler,
@@ -96,6 +107,10 @@ func NewOrderHandler(
 		deletePaymentHandler:      deletePaymentHandler,
 		getPaymentsHandler:        getPaymentsHandler,
 		recalculateTotalsHandler:  recalculateTotalsHandler,
+		getWorkflowHandler:        getWorkflowHandler,
+		updateWorkflowHandler:     updateWorkflowHandler,
+		resetWorkflowHandler:      resetWorkflowHandler,
+		renderReceiptHandler:      renderReceiptHandler,
 	}
 }
 
@@ -578,29 +593,161 @@ func (h *OrderHandler) GetOrderStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
+type UpdateWorkflowRequest struct {
+	Stages      []domain.WorkflowStage      `json:"stages"`
+	Transitions []domain.WorkflowTransition `json:"transitions"`
+}
+
+func (h *OrderHandler) GetOrderWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflow, err := h.getWorkflowHandler.Handle(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+func (h *OrderHandler) UpdateOrderWorkflow(w http.ResponseWriter, r *http.Request) {
+	var req UpdateWorkflowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	workflow, err := h.updateWorkflowHandler.Handle(r.Context(), app.UpdateOrderWorkflowCommand{
+		Stages:      req.Stages,
+		Transitions: req.Transitions,
+	})
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+func (h *OrderHandler) ResetOrderWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflow, err := h.resetWorkflowHandler.Handle(r.Context())
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+func writeWorkflowError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrInvalidWorkflow):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, app.ErrWorkflowStageInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// SearchOrders filtra en la base y pagina con cursor: next_cursor se manda
+// como cursor para pedir la siguiente página. status, priority y platform
+// aceptan varios valores separados por coma.
 func (h *OrderHandler) SearchOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
 	params := app.SearchOrdersParams{
-		Query:    r.URL.Query().Get("query"),
-		Status:   r.URL.Query().Get("status"),
-		Customer: r.URL.Query().Get("customer"),
-		Operator: r.URL.Query().Get("operator"),
-		DateFrom: r.URL.Query().Get("date_from"),
-		DateTo:   r.URL.Query().Get("date_to"),
+		Query:        query.Get("query"),
+		Customer:     query.Get("customer"),
+		Operator:     query.Get("operator"),
+		Statuses:     queryList(query["status"]),
+		Priorities:   queryList(query["priority"]),
+		Platforms:    queryList(query["platform"]),
+		DateFrom:     query.Get("date_from"),
+		DateTo:       query.Get("date_to"),
+		DeadlineFrom: query.Get("deadline_from"),
+		DeadlineTo:   query.Get("deadline_to"),
+		WithBalance:  query.Get("with_balance") == "true",
+		Cursor:       query.Get("cursor"),
+	}
+	if params.Query == "" {
+		params.Query = query.Get("q")
+	}
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		params.Limit = limit
 	}
 
-	orders, err := h.searchHandler.Handle(r.Context(), params)
+	result, err := h.searchHandler.Handle(r.Context(), params)
 	if err != nil {
+		if errors.Is(err, app.ErrInvalidSearch) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	responses := make([]OrderResponse, len(result.Orders))
+	for i, order := range result.Orders {
+		responses[i] = newOrderResponse(order)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"orders": orders,
-		"total":  len(orders),
+		"orders":      responses,
+		"total":       result.Total,
+		"next_cursor": result.NextCursor,
 	})
 }
 
+func newOrderResponse(order *domain.Order) OrderResponse {
+	return OrderResponse{
+		ID:               order.ID,
+		PublicID:         order.PublicID,
+		OrderNumber:      order.OrderNumber,
+		Platform:         string(order.Platform),
+		Status:           string(order.Status),
+		Priority:         string(order.Priority),
+		CustomerName:     order.CustomerName,
+		CustomerEmail:    order.CustomerEmail,
+		CustomerPhone:    order.CustomerPhone,
+		ProductName:      order.ProductName,
+		ProductImage:     order.ProductImage,
+		PrintFile:        order.PrintFile,
+		PrintFileName:    order.PrintFileName,
+		Quantity:         order.Quantity,
+		Notes:            order.Notes,
+		AssignedTo:       order.AssignedTo,
+		AssignedAt:       order.AssignedAt,
+		AffiliateID:      order.AffiliateID,
+		CreatedAt:        order.CreatedAt,
+		UpdatedAt:        order.UpdatedAt,
+		CompletedAt:      order.CompletedAt,
+		DeliveryDeadline: order.DeliveryDeadline,
+		Amount:           order.Amount,
+		AmountPaid:       order.AmountPaid,
+		Balance:          order.Balance,
+	}
+}
+
+// queryList junta los valores repetidos y separados por coma de un parámetro.
+func queryList(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
 // Timer endpoints
 
 func (h *OrderHandler) StartTimer(w http.ResponseWriter, r *http.Request) {
@@ -919,6 +1066,25 @@ func (h *OrderHandler) GetOrderPayments(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// GetOrderReceipt entrega el recibo de la orden en PDF
+func (h *OrderHandler) GetOrderReceipt(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "id")
+
+	document, filename, err := h.renderReceiptHandler.Handle(r.Context(), orderID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "no rows") {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	w.Write(document)
+}
+
 // DeleteOrderPayment elimina un pago de una orden
 func (h *OrderHandler) DeleteOrderPayment(w http.ResponseWriter, r *http.Request) {
 	orderID := chi.URLParam(r, "id")
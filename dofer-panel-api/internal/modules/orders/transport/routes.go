@@ -16,6 +16,12 @@ func RegisterRoutes(r chi.Router, handler *OrderHandler) {
 		r.Get("/", handler.ListOrders)
 		// Rutas específicas antes de rutas genéricas con parámetros
 		r.Get("/stats", handler.GetOrderStats)
+		r.Get("/workflow", handler.GetOrderWorkflow)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireRole("admin"))
+			r.Put("/workflow", handler.UpdateOrderWorkflow)
+			r.Delete("/workflow", handler.ResetOrderWorkflow)
+		})
 		r.Get("/search", handler.SearchOrders)
 		r.Get("/operator-stats", handler.GetOperatorStats)
 		r.Post("/bulk/status", handler.BulkUpdateOrderStatus)
@@ -34,6 +40,7 @@ func RegisterRoutes(r chi.Router, handler *OrderHandler) {
 		r.Get("/{id}/payments", handler.GetOrderPayments)
 		r.Post("/{id}/payments", handler.AddOrderPayment)
 		r.Delete("/{id}/payments/{paymentId}", handler.DeleteOrderPayment)
+		r.Get("/{id}/receipt", handler.GetOrderReceipt)
 		// Timer endpoints
 		r.Get("/{id}/timer", handler.GetTimer)
 		r.Post("/{id}/timer/start", handler.StartTimer)
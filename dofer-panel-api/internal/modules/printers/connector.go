@@ -0,0 +1,246 @@
+package printers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ConnectorOctoPrint = "octoprint"
+	ConnectorMoonraker = "moonraker"
+
+	TelemetryPrinting = "printing"
+	TelemetryIdle     = "idle"
+	TelemetryError    = "error"
+	TelemetryOffline  = "offline"
+)
+
+var (
+	ErrConnectorNotFound    = errors.New("printer connector not found")
+	ErrInvalidConnectorType = errors.New("connector type must be octoprint or moonraker")
+	ErrInvalidConnectorURL  = errors.New("connector base_url must be an http(s) URL")
+	ErrSecretsUnavailable   = errors.New("SECRETS_ENCRYPTION_KEY is required to store connector api keys")
+)
+
+// Telemetry es el estado normalizado que reporta un conector.
+type Telemetry struct {
+	State            string   `json:"state"`
+	JobName          string   `json:"job_name,omitempty"`
+	Progress         *float64 `json:"progress,omitempty"` // 0-100
+	RemainingSeconds *int     `json:"remaining_seconds,omitempty"`
+}
+
+type PrinterConnector struct {
+	PrinterID           uuid.UUID  `json:"printer_id"`
+	OrganizationID      uuid.UUID  `json:"organization_id"`
+	Type                string     `json:"type"`
+	BaseURL             string     `json:"base_url"`
+	APIKey              string     `json:"-"`
+	HasAPIKey           bool       `json:"has_api_key"`
+	Enabled             bool       `json:"enabled"`
+	TelemetryState      *string    `json:"telemetry_state,omitempty"`
+	JobName             *string    `json:"job_name,omitempty"`
+	JobProgress         *float64   `json:"job_progress,omitempty"`
+	JobRemainingSeconds *int       `json:"job_remaining_seconds,omitempty"`
+	LastPolledAt        *time.Time `json:"last_polled_at,omitempty"`
+	LastError           *string    `json:"last_error,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+
+	// La llave se guarda cifrada; api_key en texto plano solo queda en filas
+	// anteriores al cifrado hasta que se sellan.
+	sealedAPIKey string
+	legacyAPIKey string
+	keyErr       error
+}
+
+// UpsertConnectorRequest: si api_key se omite se conserva la guardada.
+type UpsertConnectorRequest struct {
+	Type    string  `json:"type"`
+	BaseURL string  `json:"base_url"`
+	APIKey  *string `json:"api_key,omitempty"`
+	Enabled *bool   `json:"enabled,omitempty"`
+}
+
+// Connector consulta el estado de una impresora en su servidor de control.
+type Connector interface {
+	Poll(ctx context.Context) (*Telemetry, error)
+}
+
+func normalizeConnectorType(raw string) (string, error) {
+	kind := strings.ToLower(strings.TrimSpace(raw))
+	if kind != ConnectorOctoPrint && kind != ConnectorMoonraker {
+		return "", ErrInvalidConnectorType
+	}
+	return kind, nil
+}
+
+func normalizeConnectorURL(raw string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", ErrInvalidConnectorURL
+	}
+	return strings.TrimRight(parsed.String(), "/"), nil
+}
+
+func NewConnector(kind, baseURL, apiKey string, client *http.Client) (Connector, error) {
+	kind, err := normalizeConnectorType(kind)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, err = normalizeConnectorURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	base := connectorClient{baseURL: baseURL, apiKey: apiKey, client: client}
+	if kind == ConnectorOctoPrint {
+		return &octoPrintConnector{base}, nil
+	}
+	return &moonrakerConnector{base}, nil
+}
+
+type connectorClient struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// getJSON: OctoPrint y Moonraker aceptan la llave en el header X-Api-Key.
+func (c connectorClient) getJSON(ctx context.Context, path string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-Api-Key", c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned %d: %s", path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+type octoPrintConnector struct {
+	connectorClient
+}
+
+func (c *octoPrintConnector) Poll(ctx context.Context) (*Telemetry, error) {
+	var payload struct {
+		State string `json:"state"`
+		Job   struct {
+			File struct {
+				Name string `json:"name"`
+			} `json:"file"`
+		} `json:"job"`
+		Progress struct {
+			Completion    *float64 `json:"completion"`
+			PrintTimeLeft *int     `json:"printTimeLeft"`
+		} `json:"progress"`
+	}
+	if err := c.getJSON(ctx, "/api/job", &payload); err != nil {
+		return nil, err
+	}
+
+	telemetry := &Telemetry{State: octoPrintState(payload.State)}
+	if telemetry.State == TelemetryPrinting {
+		telemetry.JobName = payload.Job.File.Name
+		telemetry.Progress = payload.Progress.Completion
+		telemetry.RemainingSeconds = payload.Progress.PrintTimeLeft
+	}
+	return telemetry, nil
+}
+
+// octoPrintState traduce el texto de estado de OctoPrint ("Printing",
+// "Operational", "Offline after error", ...).
+func octoPrintState(raw string) string {
+	state := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case strings.Contains(state, "error"):
+		return TelemetryError
+	case strings.HasPrefix(state, "printing"), strings.HasPrefix(state, "paus"),
+		strings.HasPrefix(state, "resuming"), strings.HasPrefix(state, "cancelling"),
+		strings.HasPrefix(state, "starting"), strings.HasPrefix(state, "finishing"):
+		return TelemetryPrinting
+	case state == "operational", state == "ready":
+		return TelemetryIdle
+	default:
+		return TelemetryOffline
+	}
+}
+
+type moonrakerConnector struct {
+	connectorClient
+}
+
+func (c *moonrakerConnector) Poll(ctx context.Context) (*Telemetry, error) {
+	var payload struct {
+		Result struct {
+			Status struct {
+				PrintStats struct {
+					State         string  `json:"state"`
+					Filename      string  `json:"filename"`
+					PrintDuration float64 `json:"print_duration"`
+				} `json:"print_stats"`
+				VirtualSDCard struct {
+					Progress *float64 `json:"progress"`
+				} `json:"virtual_sdcard"`
+			} `json:"status"`
+		} `json:"result"`
+	}
+	if err := c.getJSON(ctx, "/printer/objects/query?print_stats&virtual_sdcard", &payload); err != nil {
+		return nil, err
+	}
+
+	stats := payload.Result.Status.PrintStats
+	telemetry := &Telemetry{State: moonrakerState(stats.State)}
+	if telemetry.State != TelemetryPrinting {
+		return telemetry, nil
+	}
+
+	telemetry.JobName = stats.Filename
+	if fraction := payload.Result.Status.VirtualSDCard.Progress; fraction != nil {
+		progress := *fraction * 100
+		telemetry.Progress = &progress
+		// Moonraker no da tiempo restante; se extrapola del avance.
+		if *fraction > 0 && stats.PrintDuration > 0 {
+			remaining := int(stats.PrintDuration / *fraction - stats.PrintDuration)
+			telemetry.RemainingSeconds = &remaining
+		}
+	}
+	return telemetry, nil
+}
+
+func moonrakerState(raw string) string {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "printing", "paused":
+		return TelemetryPrinting
+	case "standby", "complete", "cancelled":
+		return TelemetryIdle
+	case "error":
+		return TelemetryError
+	default:
+		return TelemetryOffline
+	}
+}
@@ -0,0 +1,210 @@
+package printers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestOctoPrintConnectorPoll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/job" || r.Header.Get("X-Api-Key") != "secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"state":"Printing","job":{"file":{"name":"llavero.gcode"}},"progress":{"completion":42.5,"printTimeLeft":1800}}`))
+	}))
+	defer server.Close()
+
+	connector, err := NewConnector(ConnectorOctoPrint, server.URL+"/", "secret", server.Client())
+	if err != nil {
+		t.Fatalf("NewConnector returned an error: %v", err)
+	}
+	telemetry, err := connector.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned an error: %v", err)
+	}
+
+	if telemetry.State != TelemetryPrinting || telemetry.JobName != "llavero.gcode" {
+		t.Fatalf("unexpected telemetry %+v", telemetry)
+	}
+	if telemetry.Progress == nil || *telemetry.Progress != 42.5 || telemetry.RemainingSeconds == nil || *telemetry.RemainingSeconds != 1800 {
+		t.Fatalf("unexpected progress %+v", telemetry)
+	}
+
+	bad, _ := NewConnector(ConnectorOctoPrint, server.URL, "wrong", server.Client())
+	if _, err := bad.Poll(context.Background()); err == nil {
+		t.Fatal("expected an error with a wrong API key")
+	}
+}
+
+func TestMoonrakerConnectorPoll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":{"status":{"print_stats":{"state":"printing","filename":"soporte.gcode","print_duration":600},"virtual_sdcard":{"progress":0.25}}}}`))
+	}))
+	defer server.Close()
+
+	connector, err := NewConnector(ConnectorMoonraker, server.URL, "", server.Client())
+	if err != nil {
+		t.Fatalf("NewConnector returned an error: %v", err)
+	}
+	telemetry, err := connector.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned an error: %v", err)
+	}
+
+	if telemetry.State != TelemetryPrinting || *telemetry.Progress != 25 || *telemetry.RemainingSeconds != 1800 {
+		t.Fatalf("unexpected telemetry %+v", telemetry)
+	}
+}
+
+func TestConnectorStateMapping(t *testing.T) {
+	octoPrint := map[string]string{
+		"Operational":         TelemetryIdle,
+		"Paused":              TelemetryPrinting,
+		"Offline after error": TelemetryError,
+		"Closed":              TelemetryOffline,
+	}
+	for raw, want := range octoPrint {
+		if got := octoPrintState(raw); got != want {
+			t.Fatalf("octoPrintState(%q) = %q, want %q", raw, got, want)
+		}
+	}
+
+	moonraker := map[string]string{
+		"complete":  TelemetryIdle,
+		"paused":    TelemetryPrinting,
+		"error":     TelemetryError,
+		"startup":   TelemetryOffline,
+		"cancelled": TelemetryIdle,
+	}
+	for raw, want := range moonraker {
+		if got := moonrakerState(raw); got != want {
+			t.Fatalf("moonrakerState(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+type memoryTelemetryStore struct {
+	mu         sync.Mutex
+	connectors []PrinterConnector
+	saved      map[uuid.UUID]*Telemetry
+	pollErrors map[uuid.UUID]error
+	queues     map[uuid.UUID][]string
+	running    map[uuid.UUID]string
+	completed  []string
+}
+
+func (s *memoryTelemetryStore) listEnabledConnectors(context.Context) ([]PrinterConnector, error) {
+	return s.connectors, nil
+}
+
+func (s *memoryTelemetryStore) saveTelemetry(_ context.Context, connector PrinterConnector, telemetry *Telemetry, pollErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pollErr != nil {
+		s.pollErrors[connector.PrinterID] = pollErr
+		return nil
+	}
+	s.saved[connector.PrinterID] = telemetry
+	for i := range s.connectors {
+		if s.connectors[i].PrinterID == connector.PrinterID {
+			state := telemetry.State
+			s.connectors[i].TelemetryState = &state
+		}
+	}
+	return nil
+}
+
+func (s *memoryTelemetryStore) startPrinterJob(_ context.Context, _, printerID uuid.UUID) (string, error) {
+	if orderID := s.running[printerID]; orderID != "" {
+		return orderID, nil
+	}
+	queue := s.queues[printerID]
+	if len(queue) == 0 {
+		return "", nil
+	}
+	s.running[printerID], s.queues[printerID] = queue[0], queue[1:]
+	return queue[0], nil
+}
+
+func (s *memoryTelemetryStore) finishPrinterJob(_ context.Context, _, printerID uuid.UUID, completed bool) (string, error) {
+	orderID := s.running[printerID]
+	if completed && orderID != "" {
+		delete(s.running, printerID)
+		s.completed = append(s.completed, orderID)
+	}
+	return orderID, nil
+}
+
+type recordingTimer struct {
+	started []string
+	stopped []string
+}
+
+func (t *recordingTimer) StartTimer(orderID, _ string, _ *string) error {
+	t.started = append(t.started, orderID)
+	return nil
+}
+
+func (t *recordingTimer) StopTimer(orderID, _ string) error {
+	t.stopped = append(t.stopped, orderID)
+	return nil
+}
+
+func TestTelemetryPollerStartsAndStopsOrderTimer(t *testing.T) {
+	state := "printing"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":{"status":{"print_stats":{"state":"` + state + `"}}}}`))
+	}))
+	defer server.Close()
+
+	printerID := uuid.New()
+	store := &memoryTelemetryStore{
+		connectors: []PrinterConnector{{
+			PrinterID:      printerID,
+			OrganizationID: uuid.New(),
+			Type:           ConnectorMoonraker,
+			BaseURL:        server.URL,
+			Enabled:        true,
+		}},
+		saved:      map[uuid.UUID]*Telemetry{},
+		pollErrors: map[uuid.UUID]error{},
+		queues:     map[uuid.UUID][]string{printerID: {"order-1", "order-2"}},
+		running:    map[uuid.UUID]string{},
+	}
+	timers := &recordingTimer{}
+	poller := newTelemetryPoller(store, timers, server.Client())
+
+	for _, next := range []string{"printing", "printing", "complete", "printing", "complete"} {
+		state = next
+		if _, err := poller.RunOnce(context.Background()); err != nil {
+			t.Fatalf("RunOnce returned an error: %v", err)
+		}
+	}
+
+	// Cada impresión toma la siguiente orden: la terminada no se retoma.
+	if strings.Join(timers.started, ",") != "order-1,order-2" || strings.Join(timers.stopped, ",") != "order-1,order-2" {
+		t.Fatalf("expected order-1 then order-2, got %v / %v", timers.started, timers.stopped)
+	}
+	if strings.Join(store.completed, ",") != "order-1,order-2" {
+		t.Fatalf("expected both assignments completed, got %v", store.completed)
+	}
+
+	server.Close()
+	result, err := poller.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce returned an error: %v", err)
+	}
+	if result.Failed != 1 || store.pollErrors[printerID] == nil {
+		t.Fatalf("expected the poll error to be recorded, got %+v", result)
+	}
+	if store.saved[printerID].State != TelemetryIdle {
+		t.Fatalf("a failed poll should keep the last state, got %+v", store.saved[printerID])
+	}
+}
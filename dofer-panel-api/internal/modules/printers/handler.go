@@ -1,7 +1,9 @@
 package printers
 
 import (
+	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -26,6 +28,8 @@ func RegisterRoutes(r chi.Router, h *Handler) {
 		r.Use(middleware.RequireRole("admin", "operator", "viewer"))
 
 		r.Get("/", h.List)
+		r.Get("/schedule", h.GetSchedule)
+		r.Post("/schedule", h.Schedule)
 		r.Get("/{id}", h.GetByID)
 		r.Post("/", h.Create)
 		r.Put("/{id}", h.Update)
@@ -33,6 +37,15 @@ func RegisterRoutes(r chi.Router, h *Handler) {
 		r.Delete("/{id}", h.Delete)
 		r.Post("/auto-assign", h.AutoAssign)
 		r.Post("/complete-assignment", h.CompleteAssignment)
+
+		r.Get("/{id}/connector", h.GetConnector)
+		r.Post("/{id}/connector/test", h.TestConnector)
+		r.Group(func(r chi.Router) {
+			// La llave del servidor de impresión solo la administra un admin.
+			r.Use(middleware.RequireRole("admin"))
+			r.Put("/{id}/connector", h.UpsertConnector)
+			r.Delete("/{id}/connector", h.DeleteConnector)
+		})
 	})
 }
 
@@ -121,6 +134,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	h.replanIfUnavailable(r.Context(), printer)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(printer)
@@ -148,6 +162,7 @@ func (h *Handler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	h.replanIfUnavailable(r.Context(), printer)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(printer)
@@ -237,3 +252,172 @@ func (h *Handler) CompleteAssignment(w http.ResponseWriter, r *http.Request) {
 		"message": "assignment completed successfully",
 	})
 }
+
+// GetSchedule devuelve el plan de producción sin aplicarlo.
+func (h *Handler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, err := h.repo.Schedule(r.Context(), organizationIDFromRequest(r), true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+type ScheduleRequest struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// Schedule recalcula el plan y, salvo dry_run, asigna las órdenes pendientes.
+func (h *Handler) Schedule(w http.ResponseWriter, r *http.Request) {
+	var req ScheduleRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	schedule, err := h.repo.Schedule(r.Context(), organizationIDFromRequest(r), req.DryRun)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// replanIfUnavailable mueve la cola de una impresora que pasa a mantenimiento
+// u offline. Un fallo no revierte el cambio de estado.
+func (h *Handler) replanIfUnavailable(ctx context.Context, printer *Printer) {
+	if printer.Status != "maintenance" && printer.Status != "offline" {
+		return
+	}
+
+	schedule, err := h.repo.Schedule(ctx, printer.OrganizationID.String(), false)
+	if err != nil {
+		slog.Error("printer replan failed", "printer_id", printer.ID, "error", err)
+		return
+	}
+	slog.Info("printer queue replanned",
+		"printer_id", printer.ID,
+		"status", printer.Status,
+		"replanned", len(schedule.Replanned),
+		"deadline_misses", len(schedule.DeadlineMisses),
+	)
+}
+
+func (h *Handler) GetConnector(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid printer ID", http.StatusBadRequest)
+		return
+	}
+
+	connector, err := h.repo.GetConnector(r.Context(), organizationIDFromRequest(r), id)
+	if err != nil {
+		if err == ErrConnectorNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(connector)
+}
+
+func (h *Handler) UpsertConnector(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid printer ID", http.StatusBadRequest)
+		return
+	}
+
+	var req UpsertConnectorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	connector, err := h.repo.UpsertConnector(r.Context(), organizationIDFromRequest(r), id, req)
+	if err != nil {
+		switch err {
+		case ErrInvalidConnectorType, ErrInvalidConnectorURL:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case ErrSecretsUnavailable:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		case ErrPrinterNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(connector)
+}
+
+func (h *Handler) DeleteConnector(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid printer ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.DeleteConnector(r.Context(), organizationIDFromRequest(r), id); err != nil {
+		if err == ErrConnectorNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "connector deleted successfully"})
+}
+
+// TestConnector consulta el servidor configurado una vez, sin guardar nada.
+func (h *Handler) TestConnector(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid printer ID", http.StatusBadRequest)
+		return
+	}
+
+	connector, err := h.repo.GetConnector(r.Context(), organizationIDFromRequest(r), id)
+	if err != nil {
+		if err == ErrConnectorNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.repo.openAPIKey(r.Context(), connector); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	client, err := NewConnector(connector.Type, connector.BaseURL, connector.APIKey, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	telemetry, err := client.Poll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"telemetry": telemetry})
+}
@@ -3,11 +3,14 @@ package printers
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	ordersDomain "github.com/dofer/panel-api/internal/modules/orders/domain"
+	"github.com/dofer/panel-api/internal/platform/secrets"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -26,7 +29,8 @@ const (
 )
 
 type Repository struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	box *secrets.Box
 }
 
 type selectedPrinter struct {
@@ -34,8 +38,10 @@ type selectedPrinter struct {
 	ActiveJobs int
 }
 
-func NewRepository(db *pgxpool.Pool) *Repository {
-	return &Repository{db: db}
+// NewRepository recibe la caja de secretos con la que se cifran las llaves de
+// los conectores.
+func NewRepository(db *pgxpool.Pool, box *secrets.Box) *Repository {
+	return &Repository{db: db, box: box}
 }
 
 func normalizePrinterStatus(raw string) (string, error) {
@@ -161,7 +167,7 @@ func (r *Repository) List(ctx context.Context, organizationID string, status str
 			WHERE pa.printer_id = p.id
 			  AND pa.organization_id = p.organization_id
 			  AND pa.completed_at IS NULL
-			ORDER BY pa.assigned_at ASC
+			ORDER BY pa.started_at IS NULL, COALESCE(pa.planned_start_at, pa.assigned_at) ASC
 			LIMIT 1
 		) current_job ON true
 		LEFT JOIN LATERAL (
@@ -565,8 +571,22 @@ func (r *Repository) AutoAssign(ctx context.Context, req AutoAssignRequest) (*Au
 	}
 
 	if orderStatus == "new" {
-		_, err = tx.Exec(ctx,
-			"UPDATE orders SET status = 'printing', updated_at = NOW() WHERE id = $1 AND organization_id = $2",
+		// Solo avanza la orden si el flujo de la organización permite
+		// new→printing; sin flujo propio aplica el flujo por defecto.
+		_, err = tx.Exec(ctx, `
+			UPDATE orders SET status = 'printing', updated_at = NOW()
+			WHERE id = $1 AND organization_id = $2
+			  AND (
+				NOT EXISTS (SELECT 1 FROM order_workflows WHERE organization_id = $2)
+				OR EXISTS (
+					SELECT 1
+					FROM order_workflows ow, jsonb_array_elements(ow.transitions) AS t
+					WHERE ow.organization_id = $2
+					  AND t->>'from' = 'new'
+					  AND t->>'to' = 'printing'
+				)
+			  )
+		`,
 			orderUUID,
 			organizationID,
 		)
@@ -675,3 +695,229 @@ func (r *Repository) CompleteAssignment(ctx context.Context, req CompleteAssignm
 
 	return printer, nil
 }
+
+// productionWorkflow devuelve el flujo de la organización para decidir qué
+// órdenes todavía necesitan impresora.
+func productionWorkflow(ctx context.Context, tx pgx.Tx, organizationID string) (*ordersDomain.Workflow, error) {
+	var stagesJSON []byte
+	err := tx.QueryRow(ctx, "SELECT stages FROM order_workflows WHERE organization_id = $1", organizationID).Scan(&stagesJSON)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ordersDomain.DefaultWorkflow(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	workflow := &ordersDomain.Workflow{OrganizationID: organizationID}
+	if err := json.Unmarshal(stagesJSON, &workflow.Stages); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// needsPrinting: órdenes abiertas que no han pasado de la etapa "printing".
+// Si el flujo no tiene esa etapa, cualquier etapa abierta cuenta.
+func needsPrinting(workflow *ordersDomain.Workflow, status string) bool {
+	stage := ordersDomain.OrderStatus(status)
+	if _, ok := workflow.Stage(stage); !ok || workflow.IsClosed(stage) {
+		return false
+	}
+	printingIndex := workflow.StageIndex(ordersDomain.StatusPrinting)
+	return printingIndex < 0 || workflow.StageIndex(stage) <= printingIndex
+}
+
+func (r *Repository) loadScheduleInputs(ctx context.Context, tx pgx.Tx, organizationID string, lock bool) ([]Printer, []scheduleJob, error) {
+	printersQuery := "SELECT " + printerSelectColumns + " FROM printers WHERE organization_id = $1 ORDER BY created_at ASC"
+	if lock {
+		printersQuery += " FOR UPDATE"
+	}
+
+	rows, err := tx.Query(ctx, printersQuery, organizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	printers := []Printer{}
+	for rows.Next() {
+		printer, err := scanPrinterRow(rows)
+		if err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		printers = append(printers, *printer)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	workflow, err := productionWorkflow(ctx, tx, organizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Las órdenes con una asignación ya completada ya se imprimieron. Un
+	// trabajo cuenta como empezado si la telemetría lo marcó o si la orden ya
+	// registró tiempo.
+	rows, err = tx.Query(ctx, `
+		SELECT o.id, o.order_number, o.product_name, o.status, o.priority,
+		       COALESCE(NULLIF(TRIM(p.material), ''), o.metadata->>'material', '') AS material,
+		       COALESCE(o.estimated_time_minutes, 0),
+		       o.delivery_deadline, o.created_at,
+		       pa.id, pa.printer_id, pa.assigned_at,
+		       CASE WHEN pa.id IS NOT NULL THEN COALESCE(pa.started_at, (
+				SELECT MIN(te.started_at) FROM order_time_entries te WHERE te.order_id = o.id
+		       )) END AS started_at
+		FROM orders o
+		LEFT JOIN products p ON p.id = o.product_id
+		LEFT JOIN LATERAL (
+			SELECT id, printer_id, assigned_at, started_at
+			FROM printer_assignments
+			WHERE order_id = o.id
+			  AND completed_at IS NULL
+			ORDER BY assigned_at ASC
+			LIMIT 1
+		) pa ON true
+		WHERE o.organization_id = $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM printer_assignments done
+			WHERE done.order_id = o.id
+			  AND done.completed_at IS NOT NULL
+		  )
+		ORDER BY o.created_at ASC
+	`, organizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	jobs := []scheduleJob{}
+	for rows.Next() {
+		var job scheduleJob
+		var status string
+		if err := rows.Scan(
+			&job.OrderID,
+			&job.OrderNumber,
+			&job.ProductName,
+			&status,
+			&job.Priority,
+			&job.Material,
+			&job.EstimatedMinutes,
+			&job.DeliveryDeadline,
+			&job.CreatedAt,
+			&job.AssignmentID,
+			&job.PrinterID,
+			&job.AssignedAt,
+			&job.StartedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		if !needsPrinting(workflow, status) {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	return printers, jobs, rows.Err()
+}
+
+// Schedule planifica la cola de todas las impresoras de la organización. En
+// dry-run solo devuelve el plan; si no, crea o mueve las asignaciones que aún
+// no empiezan para que coincidan con él y guarda su inicio planeado en
+// planned_start_at. El estado de las órdenes no cambia.
+func (r *Repository) Schedule(ctx context.Context, organizationID string, dryRun bool) (*Schedule, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	printers, jobs, err := r.loadScheduleInputs(ctx, tx, organizationID, !dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := buildSchedule(time.Now().UTC(), printers, jobs)
+	schedule.DryRun = dryRun
+	for _, timeline := range schedule.Printers {
+		for _, slot := range timeline.Slots {
+			if slot.New {
+				schedule.Assigned++
+			}
+			if slot.Moved {
+				schedule.Moved++
+			}
+		}
+	}
+	if dryRun {
+		return schedule, nil
+	}
+
+	hadQueue := make(map[uuid.UUID]bool, len(jobs))
+	for _, job := range jobs {
+		if job.PrinterID != nil {
+			hadQueue[*job.PrinterID] = true
+		}
+	}
+
+	for _, timeline := range schedule.Printers {
+		for _, slot := range timeline.Slots {
+			if slot.InProgress {
+				continue
+			}
+
+			if slot.assignmentID != nil {
+				_, err = tx.Exec(ctx, `
+					UPDATE printer_assignments SET printer_id = $1, planned_start_at = $2
+					WHERE id = $3 AND organization_id = $4 AND started_at IS NULL
+				`,
+					timeline.printerUUID,
+					slot.Start,
+					*slot.assignmentID,
+					organizationID,
+				)
+			} else {
+				_, err = tx.Exec(ctx,
+					"INSERT INTO printer_assignments (id, organization_id, order_id, printer_id, assigned_at, planned_start_at) VALUES ($1, $2, $3, $4, NOW(), $5)",
+					uuid.New(),
+					organizationID,
+					slot.orderUUID,
+					timeline.printerUUID,
+					slot.Start,
+				)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case len(timeline.Slots) > 0 && timeline.Status == "available":
+			_, err = tx.Exec(ctx,
+				"UPDATE printers SET status = 'busy', updated_at = NOW() WHERE id = $1 AND organization_id = $2",
+				timeline.printerUUID,
+				organizationID,
+			)
+		case len(timeline.Slots) == 0 && timeline.Status == "busy" && hadQueue[timeline.printerUUID]:
+			// Su cola se movió completa a otras impresoras.
+			_, err = tx.Exec(ctx, `
+				UPDATE printers SET status = 'available', updated_at = NOW()
+				WHERE id = $1 AND organization_id = $2
+				  AND NOT EXISTS (
+					SELECT 1 FROM printer_assignments pa
+					WHERE pa.printer_id = $1 AND pa.completed_at IS NULL
+				  )
+			`,
+				timeline.printerUUID,
+				organizationID,
+			)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
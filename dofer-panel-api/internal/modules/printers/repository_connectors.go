@@ -0,0 +1,311 @@
+package printers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const connectorSelectColumns = `printer_id, organization_id, connector_type, base_url,
+	COALESCE(api_key_encrypted, ''), COALESCE(api_key, ''), enabled,
+	telemetry_state, job_name, job_progress::float8, job_remaining_seconds, last_polled_at, last_error,
+	created_at, updated_at`
+
+func scanConnectorRow(row pgx.Row) (*PrinterConnector, error) {
+	var connector PrinterConnector
+	err := row.Scan(
+		&connector.PrinterID,
+		&connector.OrganizationID,
+		&connector.Type,
+		&connector.BaseURL,
+		&connector.sealedAPIKey,
+		&connector.legacyAPIKey,
+		&connector.Enabled,
+		&connector.TelemetryState,
+		&connector.JobName,
+		&connector.JobProgress,
+		&connector.JobRemainingSeconds,
+		&connector.LastPolledAt,
+		&connector.LastError,
+		&connector.CreatedAt,
+		&connector.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	connector.HasAPIKey = connector.sealedAPIKey != "" || connector.legacyAPIKey != ""
+	return &connector, nil
+}
+
+// openAPIKey descifra la llave guardada en connector.APIKey. Una llave de
+// antes del cifrado se sella en ese momento si hay SECRETS_ENCRYPTION_KEY.
+func (r *Repository) openAPIKey(ctx context.Context, connector *PrinterConnector) error {
+	if connector.sealedAPIKey != "" {
+		key, err := r.box.Open(connector.sealedAPIKey)
+		if err != nil {
+			return fmt.Errorf("stored connector api key cannot be read, save it again: %w", err)
+		}
+		connector.APIKey = key
+		return nil
+	}
+	if connector.legacyAPIKey == "" {
+		return nil
+	}
+
+	connector.APIKey = connector.legacyAPIKey
+	if !r.box.Enabled() {
+		return nil
+	}
+	sealed, err := r.box.Seal(connector.legacyAPIKey)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx,
+		"UPDATE printer_connectors SET api_key_encrypted = $1, api_key = NULL WHERE printer_id = $2 AND api_key = $3",
+		sealed,
+		connector.PrinterID,
+		connector.legacyAPIKey,
+	)
+	if err != nil {
+		slog.Warn("failed to encrypt legacy connector api key", "printer_id", connector.PrinterID, "error", err)
+		return nil
+	}
+	connector.sealedAPIKey, connector.legacyAPIKey = sealed, ""
+	return nil
+}
+
+func (r *Repository) GetConnector(ctx context.Context, organizationID string, printerID uuid.UUID) (*PrinterConnector, error) {
+	row := r.db.QueryRow(ctx,
+		"SELECT "+connectorSelectColumns+" FROM printer_connectors WHERE printer_id = $1 AND organization_id = $2",
+		printerID,
+		organizationID,
+	)
+	connector, err := scanConnectorRow(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrConnectorNotFound
+	}
+	return connector, err
+}
+
+func (r *Repository) UpsertConnector(ctx context.Context, organizationID string, printerID uuid.UUID, req UpsertConnectorRequest) (*PrinterConnector, error) {
+	kind, err := normalizeConnectorType(req.Type)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, err := normalizeConnectorURL(req.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// nil conserva la llave guardada y "" la borra.
+	var apiKey *string
+	if req.APIKey != nil {
+		sealed := strings.TrimSpace(*req.APIKey)
+		if sealed != "" {
+			if !r.box.Enabled() {
+				return nil, ErrSecretsUnavailable
+			}
+			if sealed, err = r.box.Seal(sealed); err != nil {
+				return nil, err
+			}
+		}
+		apiKey = &sealed
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM printers WHERE id = $1 AND organization_id = $2)",
+		printerID,
+		organizationID,
+	).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrPrinterNotFound
+	}
+
+	// Cambiar de servidor invalida el estado anterior.
+	query := `
+		INSERT INTO printer_connectors (printer_id, organization_id, connector_type, base_url, api_key_encrypted, enabled)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), COALESCE($6, true))
+		ON CONFLICT (printer_id) DO UPDATE
+		SET connector_type = EXCLUDED.connector_type,
+			base_url = EXCLUDED.base_url,
+			api_key_encrypted = CASE WHEN $5::text IS NULL THEN printer_connectors.api_key_encrypted ELSE EXCLUDED.api_key_encrypted END,
+			api_key = CASE WHEN $5::text IS NULL THEN printer_connectors.api_key END,
+			enabled = COALESCE($6, printer_connectors.enabled),
+			telemetry_state = CASE WHEN printer_connectors.base_url = EXCLUDED.base_url THEN printer_connectors.telemetry_state END,
+			last_error = NULL
+		RETURNING ` + connectorSelectColumns
+
+	return scanConnectorRow(r.db.QueryRow(ctx, query, printerID, organizationID, kind, baseURL, apiKey, req.Enabled))
+}
+
+func (r *Repository) DeleteConnector(ctx context.Context, organizationID string, printerID uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, "DELETE FROM printer_connectors WHERE printer_id = $1 AND organization_id = $2", printerID, organizationID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrConnectorNotFound
+	}
+	return nil
+}
+
+func (r *Repository) listEnabledConnectors(ctx context.Context) ([]PrinterConnector, error) {
+	rows, err := r.db.Query(ctx, "SELECT "+connectorSelectColumns+" FROM printer_connectors WHERE enabled = true ORDER BY organization_id, printer_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	connectors := []PrinterConnector{}
+	for rows.Next() {
+		connector, err := scanConnectorRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		connectors = append(connectors, *connector)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Una llave ilegible se reporta como error de consulta de esa impresora.
+	for i := range connectors {
+		connectors[i].keyErr = r.openAPIKey(ctx, &connectors[i])
+	}
+	return connectors, nil
+}
+
+// saveTelemetry guarda la lectura y ajusta printers.status: printing→busy,
+// idle→available (busy si aún tiene cola), error/offline→offline. Una
+// impresora en mantenimiento no se toca; ese estado solo se cambia a mano.
+// Si la consulta falló solo se registra el error: un corte pasajero no dice
+// nada del estado real de la impresora.
+func (r *Repository) saveTelemetry(ctx context.Context, connector PrinterConnector, telemetry *Telemetry, pollErr error) error {
+	if pollErr != nil {
+		_, err := r.db.Exec(ctx,
+			"UPDATE printer_connectors SET last_polled_at = NOW(), last_error = $1 WHERE printer_id = $2",
+			pollErr.Error(),
+			connector.PrinterID,
+		)
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE printer_connectors
+		SET telemetry_state = $1,
+			job_name = NULLIF($2, ''),
+			job_progress = $3,
+			job_remaining_seconds = $4,
+			last_polled_at = NOW(),
+			last_error = NULL
+		WHERE printer_id = $5
+	`,
+		telemetry.State,
+		telemetry.JobName,
+		telemetry.Progress,
+		telemetry.RemainingSeconds,
+		connector.PrinterID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		WITH target AS (
+			SELECT CASE
+				WHEN $1 = 'printing' THEN 'busy'
+				WHEN $1 = 'idle' AND EXISTS (
+					SELECT 1 FROM printer_assignments pa
+					WHERE pa.printer_id = $2 AND pa.completed_at IS NULL
+				) THEN 'busy'
+				WHEN $1 = 'idle' THEN 'available'
+				ELSE 'offline'
+			END AS status
+		)
+		UPDATE printers p
+		SET status = target.status, updated_at = NOW()
+		FROM target
+		WHERE p.id = $2
+		  AND p.organization_id = $3
+		  AND p.status <> 'maintenance'
+		  AND p.status <> target.status
+	`,
+		telemetry.State,
+		connector.PrinterID,
+		connector.OrganizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// startPrinterJob marca como empezada la asignación que la impresora está
+// imprimiendo: la que ya estaba en curso o, si no hay, la siguiente de la
+// cola según el plan. Devuelve "" si la impresora no tiene cola.
+func (r *Repository) startPrinterJob(ctx context.Context, organizationID, printerID uuid.UUID) (string, error) {
+	var orderID string
+	err := r.db.QueryRow(ctx, `
+		UPDATE printer_assignments pa
+		SET started_at = COALESCE(pa.started_at, NOW())
+		FROM (
+			SELECT id
+			FROM printer_assignments
+			WHERE printer_id = $1
+			  AND organization_id = $2
+			  AND completed_at IS NULL
+			ORDER BY started_at IS NULL, started_at, COALESCE(planned_start_at, assigned_at)
+			LIMIT 1
+			FOR UPDATE
+		) next
+		WHERE pa.id = next.id
+		RETURNING pa.order_id::text
+	`, printerID, organizationID).Scan(&orderID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return orderID, err
+}
+
+// finishPrinterJob devuelve la orden del trabajo en curso y, si terminó bien,
+// cierra su asignación para que la siguiente impresión no la retome.
+func (r *Repository) finishPrinterJob(ctx context.Context, organizationID, printerID uuid.UUID, completed bool) (string, error) {
+	var orderID string
+	err := r.db.QueryRow(ctx, `
+		UPDATE printer_assignments pa
+		SET completed_at = CASE WHEN $3 THEN NOW() END
+		FROM (
+			SELECT id
+			FROM printer_assignments
+			WHERE printer_id = $1
+			  AND organization_id = $2
+			  AND completed_at IS NULL
+			  AND started_at IS NOT NULL
+			ORDER BY started_at
+			LIMIT 1
+			FOR UPDATE
+		) running
+		WHERE pa.id = running.id
+		RETURNING pa.order_id::text
+	`, printerID, organizationID, completed).Scan(&orderID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return orderID, err
+}
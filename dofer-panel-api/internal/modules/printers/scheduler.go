@@ -0,0 +1,314 @@
+package printers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// scheduleJob es una orden abierta que necesita tiempo de impresora.
+type scheduleJob struct {
+	OrderID          uuid.UUID
+	OrderNumber      string
+	ProductName      string
+	Material         string
+	Priority         string
+	EstimatedMinutes int
+	DeliveryDeadline *time.Time
+	CreatedAt        time.Time
+	// Asignación activa, si la orden ya está en la cola de una impresora.
+	AssignmentID *uuid.UUID
+	PrinterID    *uuid.UUID
+	AssignedAt   *time.Time
+	// StartedAt indica que la impresión ya empezó; esas órdenes no se mueven.
+	StartedAt *time.Time
+}
+
+type ScheduleSlot struct {
+	OrderID          string     `json:"order_id"`
+	OrderNumber      string     `json:"order_number"`
+	ProductName      string     `json:"product_name"`
+	Material         string     `json:"material,omitempty"`
+	Priority         string     `json:"priority"`
+	Start            time.Time  `json:"start"`
+	End              time.Time  `json:"end"`
+	DurationMinutes  int        `json:"duration_minutes"`
+	DefaultEstimate  bool       `json:"default_estimate,omitempty"`
+	DeliveryDeadline *time.Time `json:"delivery_deadline,omitempty"`
+	Late             bool       `json:"late"`
+	// InProgress es el trabajo que la impresora ya tiene en curso.
+	InProgress bool `json:"in_progress,omitempty"`
+	// New indica que el planificador agregó la orden a esta impresora.
+	New bool `json:"new,omitempty"`
+	// Moved indica una orden en cola que se pasó a otra impresora para dejar
+	// lugar a trabajo más prioritario.
+	Moved bool `json:"moved,omitempty"`
+
+	assignmentID *uuid.UUID
+	orderUUID    uuid.UUID
+}
+
+type PrinterTimeline struct {
+	PrinterID   string         `json:"printer_id"`
+	PrinterName string         `json:"printer_name"`
+	Status      string         `json:"status"`
+	Material    *string        `json:"material,omitempty"`
+	FreeAt      time.Time      `json:"free_at"`
+	BusyMinutes int            `json:"busy_minutes"`
+	Slots       []ScheduleSlot `json:"slots"`
+
+	printerUUID uuid.UUID
+}
+
+type DeadlineMiss struct {
+	OrderID          string    `json:"order_id"`
+	OrderNumber      string    `json:"order_number"`
+	PrinterID        string    `json:"printer_id"`
+	PrinterName      string    `json:"printer_name"`
+	DeliveryDeadline time.Time `json:"delivery_deadline"`
+	PredictedFinish  time.Time `json:"predicted_finish"`
+	LateMinutes      int       `json:"late_minutes"`
+}
+
+type UnscheduledOrder struct {
+	OrderID     string `json:"order_id"`
+	OrderNumber string `json:"order_number"`
+	Material    string `json:"material,omitempty"`
+	Reason      string `json:"reason"`
+}
+
+// ReplannedOrder es una orden que estaba en la cola de una impresora en
+// mantenimiento u offline y se movió a otra.
+type ReplannedOrder struct {
+	OrderID       string `json:"order_id"`
+	OrderNumber   string `json:"order_number"`
+	FromPrinterID string `json:"from_printer_id"`
+	ToPrinterID   string `json:"to_printer_id,omitempty"`
+}
+
+type Schedule struct {
+	GeneratedAt    time.Time          `json:"generated_at"`
+	DryRun         bool               `json:"dry_run"`
+	Printers       []PrinterTimeline  `json:"printers"`
+	DeadlineMisses []DeadlineMiss     `json:"deadline_misses"`
+	Unscheduled    []UnscheduledOrder `json:"unscheduled"`
+	Replanned      []ReplannedOrder   `json:"replanned"`
+	Assigned       int                `json:"assigned"`
+	Moved          int                `json:"moved"`
+}
+
+func priorityRank(priority string) int {
+	switch priority {
+	case "urgent":
+		return 0
+	case "low":
+		return 2
+	default:
+		return 1
+	}
+}
+
+func printerSchedulable(status string) bool {
+	return status == "available" || status == "busy"
+}
+
+// buildSchedule reparte las órdenes abiertas entre impresoras. Solo se
+// respetan los trabajos que ya empezaron; el resto de la cola se vuelve a
+// planificar junto con las órdenes sin impresora (o cuya impresora está en
+// mantenimiento/offline), ordenadas por prioridad, fecha de entrega y
+// antigüedad, en la impresora compatible que las termine primero. Así una
+// orden urgente pasa delante de trabajo normal que aún no arranca.
+func buildSchedule(now time.Time, printers []Printer, jobs []scheduleJob) *Schedule {
+	schedule := &Schedule{
+		GeneratedAt:    now,
+		Printers:       []PrinterTimeline{},
+		DeadlineMisses: []DeadlineMiss{},
+		Unscheduled:    []UnscheduledOrder{},
+		Replanned:      []ReplannedOrder{},
+	}
+
+	timelines := make(map[uuid.UUID]*PrinterTimeline, len(printers))
+	ordered := make([]*PrinterTimeline, 0, len(printers))
+	for _, printer := range printers {
+		timeline := &PrinterTimeline{
+			PrinterID:   printer.ID.String(),
+			PrinterName: printer.Name,
+			Status:      printer.Status,
+			Material:    printer.Material,
+			FreeAt:      now,
+			Slots:       []ScheduleSlot{},
+			printerUUID: printer.ID,
+		}
+		timelines[printer.ID] = timeline
+		ordered = append(ordered, timeline)
+	}
+
+	// 1. Trabajos en curso en impresoras utilizables; el resto se replanifica.
+	running := []scheduleJob{}
+	pending := []scheduleJob{}
+	for _, job := range jobs {
+		if job.PrinterID == nil {
+			pending = append(pending, job)
+			continue
+		}
+		timeline, ok := timelines[*job.PrinterID]
+		if !ok || !printerSchedulable(timeline.Status) {
+			schedule.Replanned = append(schedule.Replanned, ReplannedOrder{
+				OrderID:       job.OrderID.String(),
+				OrderNumber:   job.OrderNumber,
+				FromPrinterID: job.PrinterID.String(),
+			})
+			pending = append(pending, job)
+			continue
+		}
+		if job.StartedAt == nil {
+			pending = append(pending, job)
+			continue
+		}
+		running = append(running, job)
+	}
+	sort.SliceStable(running, func(i, j int) bool {
+		return running[i].StartedAt.Before(*running[j].StartedAt)
+	})
+	for _, job := range running {
+		timeline := timelines[*job.PrinterID]
+		duration, defaultEstimate := jobDuration(job)
+		start := timeline.FreeAt
+		if len(timeline.Slots) == 0 {
+			// Si ya debía haber terminado se asume que termina ahora.
+			start = *job.StartedAt
+			if start.After(now) {
+				start = now
+			}
+			if start.Add(duration).Before(now) {
+				start = now.Add(-duration)
+			}
+		}
+		placeSlot(schedule, timeline, job, start, duration, defaultEstimate, true, false, false)
+	}
+
+	// 2. Órdenes por planificar: urgentes primero, luego la entrega más próxima.
+	sort.SliceStable(pending, func(i, j int) bool {
+		a, b := pending[i], pending[j]
+		if priorityRank(a.Priority) != priorityRank(b.Priority) {
+			return priorityRank(a.Priority) < priorityRank(b.Priority)
+		}
+		if (a.DeliveryDeadline == nil) != (b.DeliveryDeadline == nil) {
+			return a.DeliveryDeadline != nil
+		}
+		if a.DeliveryDeadline != nil && !a.DeliveryDeadline.Equal(*b.DeliveryDeadline) {
+			return a.DeliveryDeadline.Before(*b.DeliveryDeadline)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	replanned := make(map[uuid.UUID]int, len(schedule.Replanned))
+	for i, moved := range schedule.Replanned {
+		replanned[uuid.MustParse(moved.OrderID)] = i
+	}
+
+	for _, job := range pending {
+		duration, defaultEstimate := jobDuration(job)
+		_, stranded := replanned[job.OrderID]
+
+		var best *PrinterTimeline
+		for _, timeline := range ordered {
+			if !printerSchedulable(timeline.Status) || !materialSupported(timeline.Material, job.Material) {
+				continue
+			}
+			if best == nil || timeline.FreeAt.Before(best.FreeAt) ||
+				(timeline.FreeAt.Equal(best.FreeAt) && preferPrinter(job, timeline, best)) {
+				best = timeline
+			}
+		}
+
+		if best == nil {
+			reason := "no compatible printer available"
+			if job.Material != "" {
+				reason = "no available printer supports material " + job.Material
+			}
+			schedule.Unscheduled = append(schedule.Unscheduled, UnscheduledOrder{
+				OrderID:     job.OrderID.String(),
+				OrderNumber: job.OrderNumber,
+				Material:    job.Material,
+				Reason:      reason,
+			})
+			continue
+		}
+
+		isNew := job.PrinterID == nil || stranded
+		moved := !isNew && *job.PrinterID != best.printerUUID
+		placeSlot(schedule, best, job, best.FreeAt, duration, defaultEstimate, false, isNew, moved)
+		if i, ok := replanned[job.OrderID]; ok {
+			schedule.Replanned[i].ToPrinterID = best.PrinterID
+		}
+	}
+
+	for _, timeline := range ordered {
+		schedule.Printers = append(schedule.Printers, *timeline)
+	}
+	return schedule
+}
+
+// preferPrinter desempata impresoras que quedan libres a la misma hora: la
+// impresora actual de la orden primero, para no mover colas sin necesidad, y
+// luego la que tiene menos trabajos.
+func preferPrinter(job scheduleJob, candidate, best *PrinterTimeline) bool {
+	if job.PrinterID != nil {
+		if candidate.printerUUID == *job.PrinterID {
+			return true
+		}
+		if best.printerUUID == *job.PrinterID {
+			return false
+		}
+	}
+	return len(candidate.Slots) < len(best.Slots)
+}
+
+func placeSlot(schedule *Schedule, timeline *PrinterTimeline, job scheduleJob, start time.Time, duration time.Duration, defaultEstimate, inProgress, isNew, moved bool) {
+	end := start.Add(duration)
+	late := job.DeliveryDeadline != nil && end.After(*job.DeliveryDeadline)
+
+	timeline.Slots = append(timeline.Slots, ScheduleSlot{
+		OrderID:          job.OrderID.String(),
+		OrderNumber:      job.OrderNumber,
+		ProductName:      job.ProductName,
+		Material:         job.Material,
+		Priority:         job.Priority,
+		Start:            start,
+		End:              end,
+		DurationMinutes:  int(duration / time.Minute),
+		DefaultEstimate:  defaultEstimate,
+		DeliveryDeadline: job.DeliveryDeadline,
+		Late:             late,
+		InProgress:       inProgress,
+		New:              isNew,
+		Moved:            moved,
+		assignmentID:     job.AssignmentID,
+		orderUUID:        job.OrderID,
+	})
+	timeline.FreeAt = end
+	timeline.BusyMinutes += int(duration / time.Minute)
+
+	if late {
+		schedule.DeadlineMisses = append(schedule.DeadlineMisses, DeadlineMiss{
+			OrderID:          job.OrderID.String(),
+			OrderNumber:      job.OrderNumber,
+			PrinterID:        timeline.PrinterID,
+			PrinterName:      timeline.PrinterName,
+			DeliveryDeadline: *job.DeliveryDeadline,
+			PredictedFinish:  end,
+			LateMinutes:      int(end.Sub(*job.DeliveryDeadline) / time.Minute),
+		})
+	}
+}
+
+// jobDuration usa estimated_time_minutes; sin estimación cae al mismo valor
+// por defecto que AutoAssign.
+func jobDuration(job scheduleJob) (time.Duration, bool) {
+	if job.EstimatedMinutes > 0 {
+		return time.Duration(job.EstimatedMinutes) * time.Minute, false
+	}
+	return time.Duration(defaultEstimateHours * float64(time.Hour)), true
+}
@@ -0,0 +1,139 @@
+package printers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testPrinter(name, status string, material *string) Printer {
+	return Printer{ID: uuid.New(), Name: name, Status: status, Material: material}
+}
+
+func testJob(number, priority string, minutes int) scheduleJob {
+	return scheduleJob{OrderID: uuid.New(), OrderNumber: number, Priority: priority, EstimatedMinutes: minutes}
+}
+
+func TestBuildScheduleOrdersByPriorityAndDeadline(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	printer := testPrinter("P1", "available", nil)
+
+	soon := now.Add(90 * time.Minute)
+	later := now.Add(48 * time.Hour)
+	normalLate := testJob("ORD-1", "normal", 60)
+	normalLate.DeliveryDeadline = &later
+	normalSoon := testJob("ORD-2", "normal", 60)
+	normalSoon.DeliveryDeadline = &soon
+	urgent := testJob("ORD-3", "urgent", 60)
+
+	schedule := buildSchedule(now, []Printer{printer}, []scheduleJob{normalLate, normalSoon, urgent})
+
+	slots := schedule.Printers[0].Slots
+	got := []string{slots[0].OrderNumber, slots[1].OrderNumber, slots[2].OrderNumber}
+	want := []string{"ORD-3", "ORD-2", "ORD-1"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected order %v, want %v", got, want)
+		}
+	}
+
+	if len(schedule.DeadlineMisses) != 1 || schedule.DeadlineMisses[0].OrderNumber != "ORD-2" {
+		t.Fatalf("expected ORD-2 to miss its deadline, got %+v", schedule.DeadlineMisses)
+	}
+	if schedule.DeadlineMisses[0].LateMinutes != 30 {
+		t.Fatalf("expected 30 late minutes, got %d", schedule.DeadlineMisses[0].LateMinutes)
+	}
+}
+
+func TestBuildScheduleReplansMaintenanceQueue(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	petg := "PETG"
+	broken := testPrinter("P1", "maintenance", nil)
+	busy := testPrinter("P2", "busy", nil)
+	petgOnly := testPrinter("P3", "available", &petg)
+
+	assignedAt := now.Add(-30 * time.Minute)
+	running := testJob("ORD-1", "normal", 120)
+	running.PrinterID = &busy.ID
+	running.AssignedAt = &assignedAt
+	running.StartedAt = &assignedAt
+
+	stranded := testJob("ORD-2", "normal", 60)
+	stranded.Material = "PLA"
+	stranded.PrinterID = &broken.ID
+	strandedAt := now.Add(-time.Hour)
+	stranded.AssignedAt = &strandedAt
+
+	schedule := buildSchedule(now, []Printer{broken, busy, petgOnly}, []scheduleJob{running, stranded})
+
+	if len(schedule.Replanned) != 1 || schedule.Replanned[0].ToPrinterID != busy.ID.String() {
+		t.Fatalf("expected ORD-2 to move to P2, got %+v", schedule.Replanned)
+	}
+
+	p2 := schedule.Printers[1]
+	if len(p2.Slots) != 2 || !p2.Slots[0].InProgress || !p2.Slots[1].New {
+		t.Fatalf("unexpected P2 timeline %+v", p2.Slots)
+	}
+	if want := now.Add(90 * time.Minute); !p2.Slots[1].Start.Equal(want) {
+		t.Fatalf("expected ORD-2 to start at %s, got %s", want, p2.Slots[1].Start)
+	}
+	if len(schedule.Printers[0].Slots) != 0 {
+		t.Fatal("maintenance printer should not keep jobs")
+	}
+}
+
+func TestBuildScheduleDefaultsEstimateAndReportsUnscheduled(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	pla := "PLA"
+	printer := testPrinter("P1", "available", &pla)
+
+	noEstimate := testJob("ORD-1", "low", 0)
+	resin := testJob("ORD-2", "normal", 30)
+	resin.Material = "RESIN"
+
+	schedule := buildSchedule(now, []Printer{printer}, []scheduleJob{noEstimate, resin})
+
+	slot := schedule.Printers[0].Slots[0]
+	if !slot.DefaultEstimate || slot.DurationMinutes != 240 {
+		t.Fatalf("expected 4h default estimate, got %+v", slot)
+	}
+	if len(schedule.Unscheduled) != 1 || schedule.Unscheduled[0].OrderNumber != "ORD-2" {
+		t.Fatalf("expected ORD-2 to be unscheduled, got %+v", schedule.Unscheduled)
+	}
+}
+
+func TestBuildScheduleMovesUnstartedWorkForUrgentOrders(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	printer := testPrinter("P1", "busy", nil)
+
+	startedAt := now.Add(-15 * time.Minute)
+	running := testJob("ORD-1", "normal", 60)
+	running.PrinterID = &printer.ID
+	running.AssignedAt = &startedAt
+	running.StartedAt = &startedAt
+
+	queuedAt := now.Add(-10 * time.Minute)
+	queued := testJob("ORD-2", "normal", 60)
+	queued.PrinterID = &printer.ID
+	queued.AssignedAt = &queuedAt
+
+	urgent := testJob("ORD-3", "urgent", 30)
+
+	schedule := buildSchedule(now, []Printer{printer}, []scheduleJob{running, queued, urgent})
+
+	slots := schedule.Printers[0].Slots
+	got := []string{slots[0].OrderNumber, slots[1].OrderNumber, slots[2].OrderNumber}
+	want := []string{"ORD-1", "ORD-3", "ORD-2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected order %v, want %v", got, want)
+		}
+	}
+	if !slots[0].InProgress || !slots[1].New || slots[2].New || slots[2].Moved {
+		t.Fatalf("unexpected slot flags %+v", slots)
+	}
+	if want := now.Add(75 * time.Minute); !slots[2].Start.Equal(want) {
+		t.Fatalf("expected ORD-2 to start at %s, got %s", want, slots[2].Start)
+	}
+}
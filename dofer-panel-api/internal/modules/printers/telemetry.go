@@ -0,0 +1,180 @@
+package printers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	ordersDomain "github.com/dofer/panel-api/internal/modules/orders/domain"
+	"github.com/google/uuid"
+)
+
+// OrderTimer es la parte de orders.TimerRepository que usa el poller.
+type OrderTimer interface {
+	StartTimer(orderID, organizationID string, operatorID *string) error
+	StopTimer(orderID, organizationID string) error
+}
+
+type telemetryStore interface {
+	listEnabledConnectors(ctx context.Context) ([]PrinterConnector, error)
+	saveTelemetry(ctx context.Context, connector PrinterConnector, telemetry *Telemetry, pollErr error) error
+	startPrinterJob(ctx context.Context, organizationID, printerID uuid.UUID) (string, error)
+	finishPrinterJob(ctx context.Context, organizationID, printerID uuid.UUID, completed bool) (string, error)
+}
+
+type PollResult struct {
+	Polled  int `json:"polled"`
+	Failed  int `json:"failed"`
+	Started int `json:"timers_started"`
+	Stopped int `json:"timers_stopped"`
+}
+
+// TelemetryPoller consulta los conectores configurados y arranca/detiene el
+// timer de la orden al frente de la cola cuando la impresora empieza o
+// termina un trabajo.
+type TelemetryPoller struct {
+	store  telemetryStore
+	timers OrderTimer
+	client *http.Client
+}
+
+func NewTelemetryPoller(repo *Repository, timers OrderTimer) *TelemetryPoller {
+	return newTelemetryPoller(repo, timers, &http.Client{Timeout: 10 * time.Second})
+}
+
+func newTelemetryPoller(store telemetryStore, timers OrderTimer, client *http.Client) *TelemetryPoller {
+	return &TelemetryPoller{store: store, timers: timers, client: client}
+}
+
+func (p *TelemetryPoller) RunOnce(ctx context.Context) (PollResult, error) {
+	result := PollResult{}
+
+	connectors, err := p.store.listEnabledConnectors(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	for _, connector := range connectors {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		result.Polled++
+
+		telemetry, pollErr := p.poll(ctx, connector)
+		if pollErr != nil {
+			result.Failed++
+			if err := p.store.saveTelemetry(ctx, connector, nil, pollErr); err != nil {
+				slog.Error("failed to save printer telemetry", "printer_id", connector.PrinterID, "error", err)
+			}
+			continue
+		}
+
+		// El timer va antes que el estado: al cerrar la asignación terminada la
+		// impresora ya no cuenta ese trabajo como cola pendiente. Si después
+		// falla el guardado, el siguiente ciclo repite la transición sin
+		// duplicar nada.
+		previous := ""
+		if connector.TelemetryState != nil {
+			previous = *connector.TelemetryState
+		}
+		started, stopped := p.syncTimer(ctx, connector, previous, telemetry.State)
+		if started {
+			result.Started++
+		}
+		if stopped {
+			result.Stopped++
+		}
+
+		if err := p.store.saveTelemetry(ctx, connector, telemetry, nil); err != nil {
+			slog.Error("failed to save printer telemetry", "printer_id", connector.PrinterID, "error", err)
+		}
+	}
+
+	return result, nil
+}
+
+func (p *TelemetryPoller) poll(ctx context.Context, connector PrinterConnector) (*Telemetry, error) {
+	if connector.keyErr != nil {
+		return nil, connector.keyErr
+	}
+	client, err := NewConnector(connector.Type, connector.BaseURL, connector.APIKey, p.client)
+	if err != nil {
+		return nil, err
+	}
+	return client.Poll(ctx)
+}
+
+// syncTimer: al pasar a printing arranca el timer de la asignación que se
+// empieza a imprimir; al pasar de printing a idle lo detiene y cierra la
+// asignación, y con error solo lo detiene para que un reintento retome la
+// misma orden. Un corte de red (offline) no toca el timer porque la impresión
+// puede seguir.
+func (p *TelemetryPoller) syncTimer(ctx context.Context, connector PrinterConnector, previous, current string) (bool, bool) {
+	// Tras un corte (offline→printing) StartTimer ignora un timer que ya corre.
+	starting := current == TelemetryPrinting && previous != TelemetryPrinting
+	stopping := previous == TelemetryPrinting && (current == TelemetryIdle || current == TelemetryError)
+	if !starting && !stopping {
+		return false, false
+	}
+
+	var orderID string
+	var err error
+	if starting {
+		orderID, err = p.store.startPrinterJob(ctx, connector.OrganizationID, connector.PrinterID)
+	} else {
+		orderID, err = p.store.finishPrinterJob(ctx, connector.OrganizationID, connector.PrinterID, current == TelemetryIdle)
+	}
+	if err != nil {
+		slog.Error("failed to update active printer job", "printer_id", connector.PrinterID, "error", err)
+		return false, false
+	}
+	if orderID == "" {
+		return false, false
+	}
+
+	organizationID := connector.OrganizationID.String()
+	if starting {
+		err = p.timers.StartTimer(orderID, organizationID, nil)
+		if errors.Is(err, ordersDomain.ErrTimerAlreadyRunning) {
+			return false, false
+		}
+		if err != nil {
+			slog.Error("failed to start order timer", "order_id", orderID, "printer_id", connector.PrinterID, "error", err)
+			return false, false
+		}
+		return true, false
+	}
+
+	if err := p.timers.StopTimer(orderID, organizationID); err != nil {
+		slog.Error("failed to stop order timer", "order_id", orderID, "printer_id", connector.PrinterID, "error", err)
+		return false, false
+	}
+	return false, true
+}
+
+func (p *TelemetryPoller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := p.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("printer telemetry poll failed", "error", err)
+		} else if result.Started > 0 || result.Stopped > 0 || result.Failed > 0 {
+			slog.Info("printer telemetry polled",
+				"polled", result.Polled,
+				"failed", result.Failed,
+				"timers_started", result.Started,
+				"timers_stopped", result.Stopped,
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
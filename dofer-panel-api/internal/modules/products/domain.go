@@ -14,6 +14,7 @@ type Product struct {
 	Description               *string   `json:"description,omitempty" db:"description"`
 	STLFilePath               *string   `json:"stl_file_path,omitempty" db:"stl_file_path"`
 	EstimatedPrintTimeMinutes *int      `json:"estimated_print_time_minutes,omitempty" db:"estimated_print_time_minutes"`
+	EstimatedWeightGrams      *float64  `json:"estimated_weight_grams,omitempty" db:"estimated_weight_grams"`
 	Material                  *string   `json:"material,omitempty" db:"material"`
 	Color                     *string   `json:"color,omitempty" db:"color"`
 	IsActive                  bool      `json:"is_active" db:"is_active"`
@@ -33,6 +34,7 @@ type CreateProductRequest struct {
 	Description               *string  `json:"description,omitempty"`
 	STLFilePath               *string  `json:"stl_file_path,omitempty"`
 	EstimatedPrintTimeMinutes *int     `json:"estimated_print_time_minutes,omitempty"`
+	EstimatedWeightGrams      *float64 `json:"estimated_weight_grams,omitempty"`
 	Material                  *string  `json:"material,omitempty"`
 	Color                     *string  `json:"color,omitempty"`
 	ImageURL                  *string  `json:"image_url,omitempty"`
@@ -50,6 +52,7 @@ type UpdateProductRequest struct {
 	Description               *string  `json:"description,omitempty"`
 	STLFilePath               *string  `json:"stl_file_path,omitempty"`
 	EstimatedPrintTimeMinutes *int     `json:"estimated_print_time_minutes,omitempty"`
+	EstimatedWeightGrams      *float64 `json:"estimated_weight_grams,omitempty"`
 	Material                  *string  `json:"material,omitempty"`
 	Color                     *string  `json:"color,omitempty"`
 	ImageURL                  *string  `json:"image_url,omitempty"`
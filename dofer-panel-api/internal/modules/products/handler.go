@@ -2,10 +2,12 @@ package products
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
+	"github.com/dofer/panel-api/internal/platform/slicefile"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -31,6 +33,7 @@ func RegisterRoutes(r chi.Router, h *Handler) {
 		r.Post("/", h.Create)
 		r.Put("/{id}", h.Update)
 		r.Patch("/{id}/active", h.UpdateActive)
+		r.Post("/{id}/slice-file", h.ApplySliceFile)
 		r.Delete("/{id}", h.Delete)
 	})
 }
@@ -176,3 +179,74 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "product deleted successfully"})
 }
+
+// ApplySliceFile toma tiempo, peso y material del G-code/3MF/STL subido
+// (campo "file"). El material solo se llena si el producto no tiene uno.
+func (h *Handler) ApplySliceFile(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 200<<20)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, "invalid multipart upload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	organizationID := organizationIDFromRequest(r)
+	product, err := h.repo.GetByID(r.Context(), organizationID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if product == nil {
+		http.Error(w, "product not found", http.StatusNotFound)
+		return
+	}
+
+	profile := slicefile.PrintProfile{}
+	if product.Material != nil {
+		profile.Material = *product.Material
+	}
+	metadata, err := slicefile.Analyze(header.Filename, file, profile)
+	if err != nil {
+		if errors.Is(err, slicefile.ErrUnsupportedFormat) || errors.Is(err, slicefile.ErrNoSliceData) || errors.Is(err, slicefile.ErrInvalidSTL) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	req := UpdateProductRequest{}
+	if metadata.PrintTimeSeconds > 0 {
+		minutes := metadata.PrintTimeMinutes()
+		req.EstimatedPrintTimeMinutes = &minutes
+	}
+	if metadata.FilamentGrams > 0 {
+		req.EstimatedWeightGrams = &metadata.FilamentGrams
+	}
+	if product.Material == nil && metadata.Material != "" {
+		req.Material = &metadata.Material
+	}
+
+	updated, err := h.repo.Update(r.Context(), organizationID, id, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"product": updated,
+		"slice":   metadata,
+	})
+}
@@ -13,7 +13,7 @@ import (
 
 const productSelectColumns = `
 	id, organization_id, sku, name, description, stl_file_path,
-	estimated_print_time_minutes, material, color, is_active, image_url,
+	estimated_print_time_minutes, estimated_weight_grams::float8, material, color, is_active, image_url,
 	suggested_price, affiliate_visible, affiliate_min_price,
 	affiliate_commission_type, affiliate_commission_value, created_at, updated_at
 `
@@ -41,7 +41,7 @@ func scanProductRow(row pgx.Row) (*Product, error) {
 	var product Product
 	var description, stlFilePath, material, color, imageURL, affiliateCommissionType sql.NullString
 	var estimatedPrintTime sql.NullInt32
-	var suggestedPrice, affiliateMinPrice, affiliateCommissionValue sql.NullFloat64
+	var estimatedWeight, suggestedPrice, affiliateMinPrice, affiliateCommissionValue sql.NullFloat64
 
 	err := row.Scan(
 		&product.ID,
@@ -51,6 +51,7 @@ func scanProductRow(row pgx.Row) (*Product, error) {
 		&description,
 		&stlFilePath,
 		&estimatedPrintTime,
+		&estimatedWeight,
 		&material,
 		&color,
 		&product.IsActive,
@@ -77,6 +78,9 @@ func scanProductRow(row pgx.Row) (*Product, error) {
 		value := int(estimatedPrintTime.Int32)
 		product.EstimatedPrintTimeMinutes = &value
 	}
+	if estimatedWeight.Valid {
+		product.EstimatedWeightGrams = &estimatedWeight.Float64
+	}
 	if material.Valid {
 		product.Material = &material.String
 	}
@@ -186,6 +190,9 @@ func (r *Repository) Create(ctx context.Context, organizationID string, req Crea
 	if req.AffiliateMinPrice != nil && *req.AffiliateMinPrice < 0 {
 		return nil, fmt.Errorf("affiliate_min_price cannot be negative")
 	}
+	if req.EstimatedWeightGrams != nil && *req.EstimatedWeightGrams < 0 {
+		return nil, fmt.Errorf("estimated_weight_grams cannot be negative")
+	}
 	if err := validateAffiliateCommission(req.AffiliateCommissionType, req.AffiliateCommissionValue); err != nil {
 		return nil, err
 	}
@@ -194,8 +201,8 @@ func (r *Repository) Create(ctx context.Context, organizationID string, req Crea
 		INSERT INTO products (
 			organization_id, sku, name, description, stl_file_path, estimated_print_time_minutes,
 			material, color, is_active, image_url, suggested_price, affiliate_visible, affiliate_min_price,
-			affiliate_commission_type, affiliate_commission_value
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
+			affiliate_commission_type, affiliate_commission_value, estimated_weight_grams
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)
 		RETURNING `+productSelectColumns,
 		organizationID,
 		sku,
@@ -212,6 +219,7 @@ func (r *Repository) Create(ctx context.Context, organizationID string, req Crea
 		req.AffiliateMinPrice,
 		req.AffiliateCommissionType,
 		req.AffiliateCommissionValue,
+		req.EstimatedWeightGrams,
 	)
 
 	return scanProductRow(row)
@@ -260,6 +268,15 @@ func (r *Repository) Update(ctx context.Context, organizationID string, id uuid.
 		argNum++
 	}
 
+	if req.EstimatedWeightGrams != nil {
+		if *req.EstimatedWeightGrams < 0 {
+			return nil, fmt.Errorf("estimated_weight_grams cannot be negative")
+		}
+		query += fmt.Sprintf(", estimated_weight_grams = $%d", argNum)
+		args = append(args, *req.EstimatedWeightGrams)
+		argNum++
+	}
+
 	if req.Material != nil {
 		query += fmt.Sprintf(", material = $%d", argNum)
 		args = append(args, sanitizeOptionalString(req.Material))
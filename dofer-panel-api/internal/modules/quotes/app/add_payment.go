@@ -64,7 +64,7 @@ func (h *AddPaymentHandler) Handle(ctx context.Context, cmd AddPaymentCommand) (
 		CreatedBy:      cmd.CreatedBy,
 		CreatedAt:      now,
 	}
-	if err := h.repo.AddPayment(payment); err != nil {
+	if err := h.repo.AddPayment(payment, quotePaymentReceivedEvent(quote, payment, quote.Total-newAmountPaid)); err != nil {
 		return nil, err
 	}
 
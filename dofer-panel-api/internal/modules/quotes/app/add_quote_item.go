@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"strings"
 
 	costsApp "github.com/dofer/panel-api/internal/modules/costs/app"
 	"github.com/dofer/panel-api/internal/modules/costs/domain"
@@ -23,20 +24,26 @@ type AddQuoteItemCommand struct {
 
 type AddQuoteItemHandler struct {
 	quoteRepo quoteDomain.QuoteRepository
+	revisions quoteDomain.QuoteRevisionRepository
 	costCalc  *costsApp.CalculateCostHandler
 }
 
-func NewAddQuoteItemHandler(quoteRepo quoteDomain.QuoteRepository, costCalc *costsApp.CalculateCostHandler) *AddQuoteItemHandler {
+func NewAddQuoteItemHandler(quoteRepo quoteDomain.QuoteRepository, revisions quoteDomain.QuoteRevisionRepository, costCalc *costsApp.CalculateCostHandler) *AddQuoteItemHandler {
 	return &AddQuoteItemHandler{
 		quoteRepo: quoteRepo,
+		revisions: revisions,
 		costCalc:  costCalc,
 	}
 }
 
-func (h *AddQuoteItemHandler) Handle(ctx context.Context, cmd AddQuoteItemCommand) error {
+func (h *AddQuoteItemHandler) Handle(ctx context.Context, cmd AddQuoteItemCommand) (*quoteDomain.QuoteItem, error) {
 	organizationID := organizationIDFromContext(ctx)
-	if _, err := h.quoteRepo.FindByID(cmd.QuoteID, organizationID); err != nil {
-		return err
+	quote, err := h.quoteRepo.FindByID(cmd.QuoteID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if !quoteDomain.IsEditableQuote(quote) {
+		return nil, quoteDomain.ErrQuoteNotEditable
 	}
 
 	var (
@@ -59,10 +66,9 @@ func (h *AddQuoteItemHandler) Handle(ctx context.Context, cmd AddQuoteItemComman
 			MaterialName:   cmd.MaterialName,
 		}
 
-		var err error
 		breakdown, err = h.costCalc.Handle(ctx, costInput)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		unitPrice = breakdown.UnitPrice
@@ -78,6 +84,7 @@ func (h *AddQuoteItemHandler) Handle(ctx context.Context, cmd AddQuoteItemComman
 		Description:    cmd.Description,
 		WeightGrams:    cmd.WeightGrams,
 		PrintTimeHours: cmd.PrintTimeHours,
+		MaterialName:   strings.ToUpper(strings.TrimSpace(cmd.MaterialName)),
 		OtherCosts:     cmd.OtherCosts,
 		Quantity:       cmd.Quantity,
 		UnitPrice:      unitPrice,
@@ -90,23 +97,28 @@ func (h *AddQuoteItemHandler) Handle(ctx context.Context, cmd AddQuoteItemComman
 		item.LaborCost = breakdown.LaborCost
 		item.ElectricityCost = breakdown.ElectricityCost
 		item.Subtotal = breakdown.Subtotal
+		// Sin material explícito se cotizó con el de la configuración por defecto
+		item.MaterialName = strings.ToUpper(breakdown.MaterialName)
 	}
 
-	if err := h.quoteRepo.AddItem(item); err != nil {
-		return err
-	}
-
-	// Actualizar totales de la cotización
-	return h.updateQuoteTotals(ctx, cmd.QuoteID)
-}
-
-func (h *AddQuoteItemHandler) updateQuoteTotals(ctx context.Context, quoteID string) error {
-	quote, err := h.quoteRepo.FindByID(quoteID, organizationIDFromContext(ctx))
+	// Guardar el item y actualizar totales de la cotización en la misma
+	// transacción
+	_, err = h.revisions.EditWithRevision(quote.ID, organizationID, quoteRevisionFor(ctx, quote, quoteDomain.RevisionReasonEdited), func(repo quoteDomain.QuoteRepository, quote *quoteDomain.Quote) error {
+		if err := repo.AddItem(item); err != nil {
+			return err
+		}
+		return recalculateQuoteTotals(repo, quote)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return item, nil
+}
 
-	items, err := h.quoteRepo.GetItems(quoteID, organizationIDFromContext(ctx))
+// recalculateQuoteTotals suma los items y guarda los totales de la cotización
+// con el repositorio recibido.
+func recalculateQuoteTotals(repo quoteDomain.QuoteRepository, quote *quoteDomain.Quote) error {
+	items, err := repo.GetItems(quote.ID, quote.OrganizationID)
 	if err != nil {
 		return err
 	}
@@ -121,5 +133,5 @@ func (h *AddQuoteItemHandler) updateQuoteTotals(ctx context.Context, quoteID str
 	quote.Tax = 0 // Sin IVA por ahora
 	quote.Total = quote.Subtotal - quote.Discount
 
-	return h.quoteRepo.Update(quote)
+	return repo.Update(quote)
 }
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	costsDomain "github.com/dofer/panel-api/internal/modules/costs/domain"
+	quoteDomain "github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/slicefile"
+)
+
+var ErrQuoteTemplateNotFound = errors.New("quote template not found")
+
+type AddQuoteItemFromFileCommand struct {
+	QuoteID      string
+	Filename     string
+	File         io.Reader
+	ProductName  string
+	Description  string
+	Quantity     int
+	OtherCosts   float64
+	MaterialName string   // Si viene vacío se usa el de la plantilla o el del archivo
+	CustomPrice  *float64 // Precio personalizado (opcional)
+
+	// Solo para STL: perfil de impresión con el que se estima la pieza.
+	TemplateID       string
+	InfillPercentage float64
+	LayerHeight      float64
+}
+
+type AddQuoteItemFromFileResult struct {
+	Item  *quoteDomain.QuoteItem `json:"item"`
+	Slice *slicefile.Metadata    `json:"slice"`
+}
+
+// AddQuoteItemFromFileHandler crea un item cotizado con el tiempo y el peso
+// que reporta el slicer en el G-code o 3MF, o que se estiman desde un STL.
+type AddQuoteItemFromFileHandler struct {
+	quoteRepo quoteDomain.QuoteRepository
+	addItem   *AddQuoteItemHandler
+}
+
+func NewAddQuoteItemFromFileHandler(quoteRepo quoteDomain.QuoteRepository, addItem *AddQuoteItemHandler) *AddQuoteItemFromFileHandler {
+	return &AddQuoteItemFromFileHandler{quoteRepo: quoteRepo, addItem: addItem}
+}
+
+func (h *AddQuoteItemFromFileHandler) Handle(ctx context.Context, cmd AddQuoteItemFromFileCommand) (*AddQuoteItemFromFileResult, error) {
+	profile, err := h.printProfile(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := slicefile.Analyze(cmd.Filename, cmd.File, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	productName := strings.TrimSpace(cmd.ProductName)
+	if productName == "" {
+		base := filepath.Base(cmd.Filename)
+		productName = strings.TrimSuffix(strings.TrimSuffix(base, filepath.Ext(base)), ".gcode")
+	}
+	quantity := cmd.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+
+	materialFromFile := strings.TrimSpace(cmd.MaterialName) == ""
+	addCmd := AddQuoteItemCommand{
+		QuoteID:        cmd.QuoteID,
+		ProductName:    productName,
+		Description:    cmd.Description,
+		WeightGrams:    metadata.FilamentGrams,
+		PrintTimeHours: metadata.PrintTimeHours(),
+		Quantity:       quantity,
+		OtherCosts:     cmd.OtherCosts,
+		MaterialName:   cmd.MaterialName,
+		CustomPrice:    cmd.CustomPrice,
+	}
+	if materialFromFile {
+		addCmd.MaterialName = firstNonEmpty(profile.Material, metadata.Material)
+	}
+
+	item, err := h.addItem.Handle(ctx, addCmd)
+	if materialFromFile && addCmd.MaterialName != "" && errors.Is(err, costsDomain.ErrMaterialNotFound) {
+		// El material del slicer no está en los costos de la organización;
+		// se cotiza con la configuración por defecto.
+		addCmd.MaterialName = ""
+		item, err = h.addItem.Handle(ctx, addCmd)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &AddQuoteItemFromFileResult{Item: item, Slice: metadata}, nil
+}
+
+// printProfile arma el perfil para STL: la plantilla de cotización da los
+// valores base y los campos del comando los sobrescriben.
+func (h *AddQuoteItemFromFileHandler) printProfile(ctx context.Context, cmd AddQuoteItemFromFileCommand) (slicefile.PrintProfile, error) {
+	profile := slicefile.PrintProfile{
+		Material:         strings.TrimSpace(cmd.MaterialName),
+		InfillPercentage: cmd.InfillPercentage,
+		LayerHeightMM:    cmd.LayerHeight,
+	}
+
+	templateID := strings.TrimSpace(cmd.TemplateID)
+	if templateID == "" {
+		return profile, nil
+	}
+	template, err := h.quoteRepo.FindTemplateByID(templateID, organizationIDFromContext(ctx))
+	if err != nil {
+		return profile, err
+	}
+	if template == nil {
+		return profile, ErrQuoteTemplateNotFound
+	}
+
+	profile.Material = firstNonEmpty(profile.Material, template.Material)
+	if profile.InfillPercentage <= 0 {
+		profile.InfillPercentage = template.InfillPercentage
+	}
+	if profile.LayerHeightMM <= 0 {
+		profile.LayerHeightMM = template.LayerHeight
+	}
+	profile.PrintSpeedMMS = template.PrintSpeed
+	return profile, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -12,70 +12,147 @@ import (
 )
 
 var (
-	ErrQuoteNotApproved = errors.New("quote must be approved to convert to order")
-	ErrQuoteNoItems     = errors.New("quote must have at least one item")
+	ErrQuoteNotApproved     = errors.New("quote must be approved to convert to order")
+	ErrQuoteNoItems         = errors.New("quote must have at least one item")
+	ErrQuoteNotConverted    = errors.New("quote has not been converted to order yet")
+	ErrOrderAlreadyHasItems = errors.New("order already has items")
 )
 
+// Pasos de la conversión que reporta ConversionError.
+const (
+	ConversionStepCreateOrder  = "create_order"
+	ConversionStepCopyItems    = "copy_items"
+	ConversionStepCopyPayments = "copy_payments"
+	ConversionStepLinkQuote    = "link_quote"
+)
+
+// ConversionError indica en qué paso falló la escritura del pedido. La
+// transacción ya se revirtió: no queda pedido, items ni pagos a medias.
+type ConversionError struct {
+	QuoteID string
+	Step    string
+	Err     error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("convert quote %s to order: %s: %v", e.QuoteID, e.Step, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
 type ConvertToOrderCommand struct {
 	QuoteID string
 }
 
 type ConvertToOrderHandler struct {
-	quoteRepo domain.QuoteRepository
-	orderRepo ordersDomain.OrderRepository
+	uow          domain.UnitOfWork
+	workflowRepo ordersDomain.WorkflowRepository
 }
 
-func NewConvertToOrderHandler(quoteRepo domain.QuoteRepository, orderRepo ordersDomain.OrderRepository) *ConvertToOrderHandler {
+func NewConvertToOrderHandler(uow domain.UnitOfWork, workflowRepo ordersDomain.WorkflowRepository) *ConvertToOrderHandler {
 	return &ConvertToOrderHandler{
-		quoteRepo: quoteRepo,
-		orderRepo: orderRepo,
+		uow:          uow,
+		workflowRepo: workflowRepo,
 	}
 }
 
+// Handle crea el pedido con sus items y pagos y marca la cotización como
+// convertida en una sola transacción. La cotización queda bloqueada mientras
+// tanto, así que una segunda conversión simultánea recibe
+// domain.ErrQuoteAlreadyConverted en lugar de crear otro pedido.
 func (h *ConvertToOrderHandler) Handle(ctx context.Context, cmd ConvertToOrderCommand) (*ordersDomain.Order, error) {
-	// Obtener la cotización
 	organizationID := organizationIDFromContext(ctx)
-	quote, err := h.quoteRepo.FindByID(cmd.QuoteID, organizationID)
+	workflow, err := h.workflowRepo.FindByOrganization(organizationID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Verificar que la cotización esté aprobada
-	if quote.Status != "approved" {
-		return nil, ErrQuoteNotApproved
-	}
+	var order *ordersDomain.Order
+	err = h.uow.Do(ctx, func(repos domain.ConversionRepositories) error {
+		quote, err := repos.Quotes.FindByIDForUpdate(cmd.QuoteID, organizationID)
+		if err != nil {
+			return err
+		}
+		if quote.ConvertedToOrderID != "" {
+			return domain.ErrQuoteAlreadyConverted
+		}
+		if quote.Status != domain.QuoteStatusApproved {
+			return ErrQuoteNotApproved
+		}
+
+		items, err := repos.Quotes.GetItems(quote.ID, organizationID)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return ErrQuoteNoItems
+		}
+		payments, err := repos.Quotes.GetPayments(quote.ID, organizationID)
+		if err != nil {
+			return err
+		}
+
+		order, err = newOrderFromQuote(quote, items, len(payments) > 0)
+		if err != nil {
+			return err
+		}
+		order.OrganizationID = organizationID
+		order.Status = workflow.InitialStage()
+
+		if err := repos.Orders.Create(order); err != nil {
+			return &ConversionError{QuoteID: quote.ID, Step: ConversionStepCreateOrder, Err: err}
+		}
+		if err := createOrderItems(repos.Orders, order.ID, organizationID, items); err != nil {
+			return &ConversionError{QuoteID: quote.ID, Step: ConversionStepCopyItems, Err: err}
+		}
+		for _, quotePayment := range payments {
+			orderPayment := &ordersDomain.OrderPayment{
+				ID:             uuid.New().String(),
+				OrganizationID: organizationID,
+				OrderID:        order.ID,
+				Amount:         quotePayment.Amount,
+				PaymentMethod:  quotePayment.PaymentMethod,
+				PaymentDate:    quotePayment.PaymentDate,
+				Notes:          fmt.Sprintf("🔄 Copiado desde cotización %s: %s", quote.QuoteNumber, quotePayment.Notes),
+				CreatedBy:      quotePayment.CreatedBy,
+				CreatedAt:      time.Now(),
+			}
+			if err := repos.Orders.AddPayment(orderPayment); err != nil {
+				return &ConversionError{QuoteID: quote.ID, Step: ConversionStepCopyPayments, Err: err}
+			}
+		}
 
-	// Obtener los items de la cotización
-	items, err := h.quoteRepo.GetItems(cmd.QuoteID, organizationID)
+		if err := repos.Quotes.MarkConverted(quote.ID, organizationID, order.ID); err != nil {
+			return &ConversionError{QuoteID: quote.ID, Step: ConversionStepLinkQuote, Err: err}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return order, nil
+}
 
-	if len(items) == 0 {
-		return nil, ErrQuoteNoItems
-	}
-
-	// Crear descripción del producto con todos los items
-	productDescription := fmt.Sprintf("Pedido desde cotización %s", quote.QuoteNumber)
-	if len(items) > 0 {
-		productDescription = items[0].ProductName
-		if len(items) > 1 {
-			productDescription += fmt.Sprintf(" (+%d items más)", len(items)-1)
-		}
+// newOrderFromQuote arma el pedido local con los datos del cliente, el total
+// de los items y, si la cotización ya tenía pagos, lo abonado.
+func newOrderFromQuote(quote *domain.Quote, items []*domain.QuoteItem, hasPayments bool) (*ordersDomain.Order, error) {
+	productDescription := items[0].ProductName
+	if len(items) > 1 {
+		productDescription += fmt.Sprintf(" (+%d items más)", len(items)-1)
 	}
 
-	// Calcular cantidad total
 	totalQuantity := 0
+	totalAmount := 0.0
 	for _, item := range items {
 		totalQuantity += item.Quantity
+		totalAmount += item.Total
 	}
 
-	// Auto-generar número de orden basado en timestamp
-	orderNumber := fmt.Sprintf("ORD-%s", time.Now().Format("20060102150405"))
-
-	// Crear la orden
+	// El número lo asigna el repositorio con el folio de la organización.
 	order, err := ordersDomain.NewOrder(
-		orderNumber,
+		"",
 		ordersDomain.PlatformLocal, // Las cotizaciones convertidas son pedidos locales
 		quote.CustomerName,
 		productDescription,
@@ -85,90 +162,42 @@ func (h *ConvertToOrderHandler) Handle(ctx context.Context, cmd ConvertToOrderCo
 		return nil, err
 	}
 
-	// Copiar información del cliente
 	order.CustomerEmail = quote.CustomerEmail
 	order.CustomerPhone = quote.CustomerPhone
-	order.OrganizationID = organizationID
 
-	// Agregar notas simplificadas
 	notesDetail := fmt.Sprintf("🔄 Generado desde cotización %s\n", quote.QuoteNumber)
 	notesDetail += fmt.Sprintf("💰 Total: $%.2f | Items: %d\n", quote.Total, len(items))
-
 	if quote.Notes != "" {
 		notesDetail += fmt.Sprintf("\n📝 %s", quote.Notes)
 	}
-
 	order.Notes = notesDetail
 
-	// Guardar la orden
-	if err := h.orderRepo.Create(order); err != nil {
-		return nil, err
+	order.Amount = totalAmount
+	order.Balance = totalAmount
+	if hasPayments {
+		order.AmountPaid = quote.AmountPaid
+		order.Balance = quote.Balance
 	}
+	return order, nil
+}
 
-	// Crear los items individuales de la orden y calcular el total
-	totalAmount := 0.0
+func createOrderItems(orders ordersDomain.OrderRepository, orderID, organizationID string, items []*domain.QuoteItem) error {
 	for _, quoteItem := range items {
 		orderItem := &ordersDomain.OrderItem{
 			ID:             uuid.New().String(),
 			OrganizationID: organizationID,
-			OrderID:        order.ID,
+			OrderID:        orderID,
 			ProductName:    quoteItem.ProductName,
 			Description:    quoteItem.Description,
+			QuoteItemID:    quoteItem.ID,
 			Quantity:       quoteItem.Quantity,
 			UnitPrice:      quoteItem.UnitPrice,
 			Total:          quoteItem.Total,
 			IsCompleted:    false,
 		}
-
-		if err := h.orderRepo.CreateOrderItem(orderItem); err != nil {
-			// Log error but continue
-			fmt.Printf("Warning: could not create order item: %v\n", err)
-		} else {
-			totalAmount += orderItem.Total
-		}
-	}
-
-	// Actualizar el amount de la orden con el total calculado
-	order.Amount = totalAmount
-	order.Balance = totalAmount // Balance inicial es igual al total
-
-	// Copiar pagos de cotización a orden (sincronización automática)
-	quotePayments, err := h.quoteRepo.GetPayments(cmd.QuoteID, organizationID)
-	if err != nil {
-		fmt.Printf("Warning: could not fetch quote payments: %v\n", err)
-	} else if len(quotePayments) > 0 {
-		for _, quotePayment := range quotePayments {
-			orderPayment := &ordersDomain.OrderPayment{
-				ID:             uuid.New().String(),
-				OrganizationID: organizationID,
-				OrderID:        order.ID,
-				Amount:         quotePayment.Amount,
-				PaymentMethod:  quotePayment.PaymentMethod,
-				PaymentDate:    quotePayment.PaymentDate,
-				Notes:          fmt.Sprintf("🔄 Copiado desde cotización %s: %s", quote.QuoteNumber, quotePayment.Notes),
-				CreatedBy:      quotePayment.CreatedBy,
-				CreatedAt:      time.Now(),
-			}
-
-			if err := h.orderRepo.AddPayment(orderPayment); err != nil {
-				fmt.Printf("Warning: could not copy payment to order: %v\n", err)
-			}
-		}
-
-		// Actualizar los montos de la orden
-		order.AmountPaid = quote.AmountPaid
-		order.Balance = quote.Balance
-		if err := h.orderRepo.Update(order); err != nil {
-			fmt.Printf("Warning: could not update order payment amounts: %v\n", err)
+		if err := orders.CreateOrderItem(orderItem); err != nil {
+			return err
 		}
 	}
-
-	// Actualizar la cotización para marcarla como convertida
-	quote.ConvertedToOrderID = order.ID
-	if err := h.quoteRepo.Update(quote); err != nil {
-		// Log error pero no fallar, la orden ya fue creada
-		fmt.Printf("Warning: could not update quote with order ID: %v\n", err)
-	}
-
-	return order, nil
+	return nil
 }
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ordersDomain "github.com/dofer/panel-api/internal/modules/orders/domain"
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/outbox"
+)
+
+// memoryStore guarda lo confirmado; fakeUnitOfWork solo copia ahí las
+// escrituras de una transacción si fn termina sin error.
+type memoryStore struct {
+	quotes     map[string]domain.Quote
+	items      []*domain.QuoteItem
+	payments   []*domain.QuotePayment
+	orders     []*ordersDomain.Order
+	orderItems []*ordersDomain.OrderItem
+	orderPays  []*ordersDomain.OrderPayment
+}
+
+type fakeUnitOfWork struct {
+	store         *memoryStore
+	failItemAfter int
+}
+
+func (u *fakeUnitOfWork) Do(_ context.Context, fn func(repos domain.ConversionRepositories) error) error {
+	tx := &fakeTx{store: u.store, failItemAfter: u.failItemAfter, quotes: map[string]domain.Quote{}}
+	if err := fn(domain.ConversionRepositories{Quotes: &fakeQuoteRepo{tx: tx}, Orders: &fakeOrderRepo{tx: tx}}); err != nil {
+		return err
+	}
+	for id, quote := range tx.quotes {
+		u.store.quotes[id] = quote
+	}
+	u.store.orders = append(u.store.orders, tx.orders...)
+	u.store.orderItems = append(u.store.orderItems, tx.orderItems...)
+	u.store.orderPays = append(u.store.orderPays, tx.orderPays...)
+	return nil
+}
+
+type fakeTx struct {
+	store         *memoryStore
+	failItemAfter int
+	quotes        map[string]domain.Quote
+	orders        []*ordersDomain.Order
+	orderItems    []*ordersDomain.OrderItem
+	orderPays     []*ordersDomain.OrderPayment
+}
+
+type fakeQuoteRepo struct {
+	domain.QuoteRepository
+	tx *fakeTx
+}
+
+func (r *fakeQuoteRepo) FindByIDForUpdate(id, _ string) (*domain.Quote, error) {
+	quote, ok := r.tx.store.quotes[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &quote, nil
+}
+
+func (r *fakeQuoteRepo) GetItems(string, string) ([]*domain.QuoteItem, error) {
+	return r.tx.store.items, nil
+}
+
+func (r *fakeQuoteRepo) GetPayments(string, string) ([]*domain.QuotePayment, error) {
+	return r.tx.store.payments, nil
+}
+
+func (r *fakeQuoteRepo) MarkConverted(quoteID, _, orderID string) error {
+	quote := r.tx.store.quotes[quoteID]
+	quote.ConvertedToOrderID = orderID
+	r.tx.quotes[quoteID] = quote
+	return nil
+}
+
+type fakeOrderRepo struct {
+	ordersDomain.OrderRepository
+	tx *fakeTx
+}
+
+func (r *fakeOrderRepo) Create(order *ordersDomain.Order) error {
+	r.tx.orders = append(r.tx.orders, order)
+	return nil
+}
+
+func (r *fakeOrderRepo) CreateOrderItem(item *ordersDomain.OrderItem) error {
+	if r.tx.failItemAfter > 0 && len(r.tx.orderItems) >= r.tx.failItemAfter {
+		return errors.New("connection reset")
+	}
+	r.tx.orderItems = append(r.tx.orderItems, item)
+	return nil
+}
+
+func (r *fakeOrderRepo) GetOrderItems(orderID, _ string) ([]*ordersDomain.OrderItem, error) {
+	var items []*ordersDomain.OrderItem
+	for _, item := range r.tx.store.orderItems {
+		if item.OrderID == orderID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeOrderRepo) AddPayment(payment *ordersDomain.OrderPayment, _ ...outbox.Event) error {
+	r.tx.orderPays = append(r.tx.orderPays, payment)
+	return nil
+}
+
+type fakeWorkflowRepo struct {
+	ordersDomain.WorkflowRepository
+}
+
+func (fakeWorkflowRepo) FindByOrganization(string) (*ordersDomain.Workflow, error) {
+	return &ordersDomain.Workflow{Stages: []ordersDomain.WorkflowStage{{Key: "design"}}}, nil
+}
+
+func newConversionStore() *memoryStore {
+	return &memoryStore{
+		quotes: map[string]domain.Quote{
+			"quote-1": {ID: "quote-1", QuoteNumber: "COT-1", CustomerName: "Ana", Status: "approved", Total: 300, AmountPaid: 100, Balance: 200},
+		},
+		items: []*domain.QuoteItem{
+			{ProductName: "Capibara", Quantity: 2, UnitPrice: 100, Total: 200},
+			{ProductName: "Llavero", Quantity: 4, UnitPrice: 25, Total: 100},
+		},
+		payments: []*domain.QuotePayment{{Amount: 100, PaymentMethod: "cash"}},
+	}
+}
+
+func TestConvertToOrderCommitsEverythingTogether(t *testing.T) {
+	store := newConversionStore()
+	handler := NewConvertToOrderHandler(&fakeUnitOfWork{store: store}, fakeWorkflowRepo{})
+
+	order, err := handler.Handle(context.Background(), ConvertToOrderCommand{QuoteID: "quote-1"})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if order.Status != "design" || order.Quantity != 6 || order.Amount != 300 ||
+		order.AmountPaid != 100 || order.Balance != 200 || order.ProductName != "Capibara (+1 items más)" {
+		t.Fatalf("unexpected order %+v", order)
+	}
+	if len(store.orders) != 1 || len(store.orderItems) != 2 || len(store.orderPays) != 1 {
+		t.Fatalf("expected order, items and payment committed, got %+v", store)
+	}
+	if store.quotes["quote-1"].ConvertedToOrderID != order.ID {
+		t.Fatalf("quote was not linked to the order: %+v", store.quotes["quote-1"])
+	}
+
+	if _, err := handler.Handle(context.Background(), ConvertToOrderCommand{QuoteID: "quote-1"}); !errors.Is(err, domain.ErrQuoteAlreadyConverted) {
+		t.Fatalf("expected already converted, got %v", err)
+	}
+	if len(store.orders) != 1 {
+		t.Fatalf("second conversion created another order")
+	}
+}
+
+func TestConvertToOrderRollsBackWhenAStepFails(t *testing.T) {
+	store := newConversionStore()
+	handler := NewConvertToOrderHandler(&fakeUnitOfWork{store: store, failItemAfter: 1}, fakeWorkflowRepo{})
+
+	_, err := handler.Handle(context.Background(), ConvertToOrderCommand{QuoteID: "quote-1"})
+	var conversionErr *ConversionError
+	if !errors.As(err, &conversionErr) || conversionErr.Step != ConversionStepCopyItems {
+		t.Fatalf("expected copy_items conversion error, got %v", err)
+	}
+	if len(store.orders) != 0 || len(store.orderItems) != 0 || len(store.orderPays) != 0 ||
+		store.quotes["quote-1"].ConvertedToOrderID != "" {
+		t.Fatalf("failed conversion left partial writes: %+v", store)
+	}
+}
+
+func TestConvertToOrderValidatesQuote(t *testing.T) {
+	store := newConversionStore()
+	quote := store.quotes["quote-1"]
+	quote.Status = "sent"
+	store.quotes["quote-1"] = quote
+	handler := NewConvertToOrderHandler(&fakeUnitOfWork{store: store}, fakeWorkflowRepo{})
+
+	if _, err := handler.Handle(context.Background(), ConvertToOrderCommand{QuoteID: "quote-1"}); !errors.Is(err, ErrQuoteNotApproved) {
+		t.Fatalf("expected not approved, got %v", err)
+	}
+
+	quote.Status = "approved"
+	store.quotes["quote-1"] = quote
+	store.items = nil
+	if _, err := handler.Handle(context.Background(), ConvertToOrderCommand{QuoteID: "quote-1"}); !errors.Is(err, ErrQuoteNoItems) {
+		t.Fatalf("expected no items, got %v", err)
+	}
+}
+
+func TestSyncItemsToOrder(t *testing.T) {
+	store := newConversionStore()
+	handler := NewSyncItemsToOrderHandler(&fakeUnitOfWork{store: store})
+	ctx := context.Background()
+
+	if err := handler.Handle(ctx, SyncItemsToOrderCommand{QuoteID: "quote-1"}); !errors.Is(err, ErrQuoteNotConverted) {
+		t.Fatalf("expected not converted, got %v", err)
+	}
+
+	quote := store.quotes["quote-1"]
+	quote.ConvertedToOrderID = "order-1"
+	store.quotes["quote-1"] = quote
+	failing := NewSyncItemsToOrderHandler(&fakeUnitOfWork{store: store, failItemAfter: 1})
+	if err := failing.Handle(ctx, SyncItemsToOrderCommand{QuoteID: "quote-1"}); err == nil || len(store.orderItems) != 0 {
+		t.Fatalf("expected failed sync without items, got %v and %d items", err, len(store.orderItems))
+	}
+
+	if err := handler.Handle(ctx, SyncItemsToOrderCommand{QuoteID: "quote-1"}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if len(store.orderItems) != 2 {
+		t.Fatalf("expected 2 synced items, got %d", len(store.orderItems))
+	}
+	if err := handler.Handle(ctx, SyncItemsToOrderCommand{QuoteID: "quote-1"}); !errors.Is(err, ErrOrderAlreadyHasItems) {
+		t.Fatalf("expected order already has items, got %v", err)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/dofer/panel-api/internal/modules/quotes/domain"
-	"github.com/dofer/panel-api/internal/modules/quotes/infra"
 	"github.com/google/uuid"
 )
 
@@ -30,11 +29,10 @@ func (h *CreateQuoteHandler) Handle(ctx context.Context, cmd CreateQuoteCommand)
 	quote := &domain.Quote{
 		ID:             uuid.New().String(),
 		OrganizationID: organizationIDFromContext(ctx),
-		QuoteNumber:    infra.GenerateQuoteNumber(),
 		CustomerName:   cmd.CustomerName,
 		CustomerEmail:  cmd.CustomerEmail,
 		CustomerPhone:  cmd.CustomerPhone,
-		Status:         "pending",
+		Status:         domain.QuoteStatusDraft,
 		Subtotal:       0,
 		Discount:       0,
 		Tax:            0,
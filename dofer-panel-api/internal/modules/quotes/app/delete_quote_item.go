@@ -12,44 +12,29 @@ type DeleteQuoteItemCommand struct {
 }
 
 type DeleteQuoteItemHandler struct {
-	repo domain.QuoteRepository
+	repo      domain.QuoteRepository
+	revisions domain.QuoteRevisionRepository
 }
 
-func NewDeleteQuoteItemHandler(repo domain.QuoteRepository) *DeleteQuoteItemHandler {
-	return &DeleteQuoteItemHandler{repo: repo}
+func NewDeleteQuoteItemHandler(repo domain.QuoteRepository, revisions domain.QuoteRevisionRepository) *DeleteQuoteItemHandler {
+	return &DeleteQuoteItemHandler{repo: repo, revisions: revisions}
 }
 
 func (h *DeleteQuoteItemHandler) Handle(ctx context.Context, cmd DeleteQuoteItemCommand) error {
-	organizationID := organizationIDFromContext(ctx)
-
-	// Eliminar el item
-	if err := h.repo.DeleteQuoteItem(ctx, cmd.QuoteID, cmd.ItemID, organizationID); err != nil {
-		return err
-	}
-
-	// Recalcular totales de la cotización
-	items, err := h.repo.GetItems(cmd.QuoteID, organizationID)
-	if err != nil {
-		return err
-	}
-
-	// Calcular nuevo subtotal
-	var subtotal float64
-	for _, item := range items {
-		subtotal += item.Total
-	}
-
-	// Obtener la cotización actual
 	quote, err := h.repo.FindByID(cmd.QuoteID, organizationIDFromContext(ctx))
 	if err != nil {
 		return err
 	}
+	if !domain.IsEditableQuote(quote) {
+		return domain.ErrQuoteNotEditable
+	}
 
-	// Actualizar totales
-	quote.Subtotal = subtotal
-	quote.Tax = 0 // Sin IVA por ahora
-	quote.Total = quote.Subtotal - quote.Discount
-
-	// Guardar cotización actualizada
-	return h.repo.Update(quote)
+	// Eliminar el item y recalcular totales en la misma transacción
+	_, err = h.revisions.EditWithRevision(quote.ID, quote.OrganizationID, quoteRevisionFor(ctx, quote, domain.RevisionReasonEdited), func(repo domain.QuoteRepository, quote *domain.Quote) error {
+		if err := repo.DeleteQuoteItem(ctx, quote.ID, cmd.ItemID, quote.OrganizationID); err != nil {
+			return err
+		}
+		return recalculateQuoteTotals(repo, quote)
+	})
+	return err
 }
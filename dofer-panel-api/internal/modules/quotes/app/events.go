@@ -0,0 +1,58 @@
+package app
+
+import (
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/outbox"
+)
+
+func quoteEventPayload(quote *domain.Quote) map[string]any {
+	return map[string]any{
+		"quote_id":       quote.ID,
+		"quote_number":   quote.QuoteNumber,
+		"customer_name":  quote.CustomerName,
+		"customer_email": quote.CustomerEmail,
+		"total":          quote.Total,
+		"valid_until":    quote.ValidUntil.UTC().Format(time.RFC3339),
+	}
+}
+
+func quoteSentEvent(quote *domain.Quote) outbox.Event {
+	return outbox.Event{
+		OrganizationID: quote.OrganizationID,
+		Type:           outbox.EventQuoteSent,
+		AggregateType:  "quote",
+		AggregateID:    quote.ID,
+		Payload:        quoteEventPayload(quote),
+	}
+}
+
+// quoteExpiringEvent avisa al cliente que la cotización está por vencer.
+func quoteExpiringEvent(quote *domain.Quote, now time.Time) outbox.Event {
+	payload := quoteEventPayload(quote)
+	payload["days_left"] = int(quote.ValidUntil.Sub(now).Hours() / 24)
+	return outbox.Event{
+		OrganizationID: quote.OrganizationID,
+		Type:           outbox.EventQuoteExpiring,
+		AggregateType:  "quote",
+		AggregateID:    quote.ID,
+		Payload:        payload,
+	}
+}
+
+func quotePaymentReceivedEvent(quote *domain.Quote, payment *domain.QuotePayment, balance float64) outbox.Event {
+	payload := quoteEventPayload(quote)
+	payload["reference"] = quote.QuoteNumber
+	payload["payment_id"] = payment.ID
+	payload["amount"] = payment.Amount
+	payload["payment_method"] = payment.PaymentMethod
+	payload["balance"] = balance
+	return outbox.Event{
+		OrganizationID: quote.OrganizationID,
+		Type:           outbox.EventPaymentReceived,
+		AggregateType:  "quote",
+		AggregateID:    quote.ID,
+		Payload:        payload,
+	}
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+)
+
+// quoteLifecycleBatchSize limita cuántas cotizaciones se procesan por
+// ejecución; las que sobren se toman en la siguiente.
+const quoteLifecycleBatchSize = 500
+
+type ExpireQuotesCommand struct {
+	// ReminderDays es cuántos días antes del vencimiento se avisa al cliente;
+	// 0 desactiva el aviso.
+	ReminderDays int
+	TriggeredBy  string
+}
+
+type ExpireQuotesResult struct {
+	Expired  int `json:"expired"`
+	Reminded int `json:"reminded"`
+	Failed   int `json:"failed"`
+}
+
+// ExpireQuotesHandler marca como vencidas las cotizaciones abiertas cuya
+// vigencia terminó y avisa por correo a las que están por vencer. Recorre
+// todas las organizaciones.
+type ExpireQuotesHandler struct {
+	repo domain.QuoteLifecycleRepository
+	now  func() time.Time
+}
+
+func NewExpireQuotesHandler(repo domain.QuoteLifecycleRepository) *ExpireQuotesHandler {
+	return &ExpireQuotesHandler{repo: repo, now: time.Now}
+}
+
+func (h *ExpireQuotesHandler) Handle(ctx context.Context, cmd ExpireQuotesCommand) (*ExpireQuotesResult, error) {
+	triggeredBy := strings.TrimSpace(cmd.TriggeredBy)
+	if triggeredBy == "" {
+		triggeredBy = "system"
+	}
+	now := h.now()
+	result := &ExpireQuotesResult{}
+
+	expiring, err := h.repo.FindOpenValidBefore(now, quoteLifecycleBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	for _, quote := range expiring {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		previousStatus := quote.Status
+		quote.Status = domain.QuoteStatusExpired
+		err := h.repo.ChangeStatus(quote, previousStatus, &domain.QuoteHistoryEntry{
+			QuoteID:    quote.ID,
+			ChangedBy:  triggeredBy,
+			ChangeType: domain.HistoryStatusChanged,
+			FieldName:  "status",
+			OldValue:   previousStatus,
+			NewValue:   domain.QuoteStatusExpired,
+			Notes:      "Vigencia terminada el " + quote.ValidUntil.UTC().Format(time.RFC3339),
+		})
+		switch {
+		case errors.Is(err, domain.ErrQuoteStatusChanged):
+			// Alguien la respondió o la editó mientras tanto.
+		case err != nil:
+			result.Failed++
+		default:
+			result.Expired++
+		}
+	}
+
+	if cmd.ReminderDays <= 0 {
+		return result, nil
+	}
+	reminders, err := h.repo.FindPendingExpiryReminders(now, now.AddDate(0, 0, cmd.ReminderDays), quoteLifecycleBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	for _, quote := range reminders {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		marked, err := h.repo.MarkExpiryReminderSent(quote, &domain.QuoteHistoryEntry{
+			QuoteID:    quote.ID,
+			ChangedBy:  triggeredBy,
+			ChangeType: domain.HistoryExpiryReminder,
+			NewValue:   quote.CustomerEmail,
+		}, quoteExpiringEvent(quote, now))
+		switch {
+		case err != nil:
+			result.Failed++
+		case marked:
+			result.Reminded++
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/outbox"
+)
+
+// fakeLifecycleRepo filtra en memoria igual que las consultas de Postgres.
+type fakeLifecycleRepo struct {
+	quotes   []*domain.Quote
+	reminded map[string]bool
+	history  []*domain.QuoteHistoryEntry
+	events   []outbox.Event
+}
+
+func (r *fakeLifecycleRepo) ChangeStatus(quote *domain.Quote, previousStatus string, entry *domain.QuoteHistoryEntry, events ...outbox.Event) error {
+	r.history = append(r.history, entry)
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func (r *fakeLifecycleRepo) FindOpenValidBefore(before time.Time, _ int) ([]*domain.Quote, error) {
+	var found []*domain.Quote
+	for _, quote := range r.quotes {
+		if domain.IsOpenQuoteStatus(quote.Status) && quote.ValidUntil.Before(before) {
+			found = append(found, quote)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeLifecycleRepo) FindPendingExpiryReminders(from, to time.Time, _ int) ([]*domain.Quote, error) {
+	var found []*domain.Quote
+	for _, quote := range r.quotes {
+		if quote.Status == domain.QuoteStatusSent && !quote.ValidUntil.Before(from) && quote.ValidUntil.Before(to) &&
+			quote.CustomerEmail != "" && !r.reminded[quote.ID] {
+			found = append(found, quote)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeLifecycleRepo) MarkExpiryReminderSent(quote *domain.Quote, entry *domain.QuoteHistoryEntry, events ...outbox.Event) (bool, error) {
+	if r.reminded[quote.ID] {
+		return false, nil
+	}
+	r.reminded[quote.ID] = true
+	r.history = append(r.history, entry)
+	r.events = append(r.events, events...)
+	return true, nil
+}
+
+func TestExpireQuotesHandler(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	repo := &fakeLifecycleRepo{
+		reminded: map[string]bool{},
+		quotes: []*domain.Quote{
+			{ID: "past-sent", OrganizationID: "org", Status: domain.QuoteStatusSent, ValidUntil: now.Add(-time.Hour)},
+			{ID: "past-draft", OrganizationID: "org", Status: domain.QuoteStatusDraft, ValidUntil: now.AddDate(0, 0, -3)},
+			{ID: "past-approved", OrganizationID: "org", Status: domain.QuoteStatusApproved, ValidUntil: now.AddDate(0, 0, -3)},
+			{ID: "soon", OrganizationID: "org", Status: domain.QuoteStatusSent, CustomerEmail: "ana@example.com", ValidUntil: now.AddDate(0, 0, 2)},
+			{ID: "soon-draft", OrganizationID: "org", Status: domain.QuoteStatusDraft, CustomerEmail: "ana@example.com", ValidUntil: now.AddDate(0, 0, 2)},
+			{ID: "later", OrganizationID: "org", Status: domain.QuoteStatusSent, CustomerEmail: "ana@example.com", ValidUntil: now.AddDate(0, 0, 10)},
+		},
+	}
+	handler := NewExpireQuotesHandler(repo)
+	handler.now = func() time.Time { return now }
+
+	result, err := handler.Handle(context.Background(), ExpireQuotesCommand{ReminderDays: 3})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if result.Expired != 2 || result.Reminded != 1 || result.Failed != 0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if repo.quotes[0].Status != domain.QuoteStatusExpired || repo.quotes[1].Status != domain.QuoteStatusExpired {
+		t.Fatal("open quotes past valid_until must expire")
+	}
+	if repo.quotes[2].Status != domain.QuoteStatusApproved {
+		t.Fatal("approved quotes never expire")
+	}
+	if len(repo.events) != 1 || repo.events[0].Type != outbox.EventQuoteExpiring || repo.events[0].AggregateID != "soon" {
+		t.Fatalf("expected one expiring reminder, got %+v", repo.events)
+	}
+	if repo.history[0].ChangedBy != "system" || repo.history[0].OldValue != domain.QuoteStatusSent {
+		t.Fatalf("unexpected history entry %+v", repo.history[0])
+	}
+
+	// La segunda ejecución no repite vencimientos ni avisos.
+	result, err = handler.Handle(context.Background(), ExpireQuotesCommand{ReminderDays: 3})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if result.Expired != 0 || result.Reminded != 0 {
+		t.Fatalf("second run must be a no-op, got %+v", result)
+	}
+}
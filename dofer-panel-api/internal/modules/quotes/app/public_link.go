@@ -0,0 +1,90 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+)
+
+var (
+	ErrPublicLinksDisabled = errors.New("public quote links are not configured")
+	ErrInvalidPublicLink   = errors.New("invalid quote link")
+	ErrPublicLinkExpired   = errors.New("quote link expired")
+)
+
+// PublicLinkSigner firma los tokens de los enlaces públicos con HMAC-SHA256.
+// El token lleva la cotización, la versión del enlace y su expiración; no se
+// guarda en la base de datos.
+type PublicLinkSigner struct {
+	secret []byte
+}
+
+func NewPublicLinkSigner(secret string) *PublicLinkSigner {
+	return &PublicLinkSigner{secret: []byte(strings.TrimSpace(secret))}
+}
+
+type publicLinkClaims struct {
+	QuoteID   string
+	Version   int
+	ExpiresAt time.Time
+}
+
+func (s *PublicLinkSigner) Sign(link *domain.QuotePublicLink) (string, error) {
+	if len(s.secret) == 0 {
+		return "", ErrPublicLinksDisabled
+	}
+	if link.ExpiresAt == nil {
+		return "", ErrPublicLinkExpired
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(
+		[]byte(fmt.Sprintf("%s.%d.%d", link.QuoteID, link.Version, link.ExpiresAt.Unix())),
+	)
+	return payload + "." + s.signature(payload), nil
+}
+
+func (s *PublicLinkSigner) Verify(token string, now time.Time) (publicLinkClaims, error) {
+	if len(s.secret) == 0 {
+		return publicLinkClaims{}, ErrPublicLinksDisabled
+	}
+
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.signature(payload))) {
+		return publicLinkClaims{}, ErrInvalidPublicLink
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return publicLinkClaims{}, ErrInvalidPublicLink
+	}
+	parts := strings.Split(string(decoded), ".")
+	if len(parts) != 3 || parts[0] == "" {
+		return publicLinkClaims{}, ErrInvalidPublicLink
+	}
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return publicLinkClaims{}, ErrInvalidPublicLink
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return publicLinkClaims{}, ErrInvalidPublicLink
+	}
+
+	claims := publicLinkClaims{QuoteID: parts[0], Version: version, ExpiresAt: time.Unix(expiresAt, 0)}
+	if !now.Before(claims.ExpiresAt) {
+		return publicLinkClaims{}, ErrPublicLinkExpired
+	}
+	return claims, nil
+}
+
+func (s *PublicLinkSigner) signature(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+)
+
+func TestPublicLinkSignerRoundTrip(t *testing.T) {
+	signer := NewPublicLinkSigner("secret")
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	token, err := signer.Sign(&domain.QuotePublicLink{QuoteID: "quote-1", Version: 3, ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	claims, err := signer.Verify(token, time.Now())
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if claims.QuoteID != "quote-1" || claims.Version != 3 || !claims.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+}
+
+func TestPublicLinkSignerRejectsTamperedAndExpiredTokens(t *testing.T) {
+	signer := NewPublicLinkSigner("secret")
+	expiresAt := time.Now().Add(time.Hour)
+	token, _ := signer.Sign(&domain.QuotePublicLink{QuoteID: "quote-1", Version: 1, ExpiresAt: &expiresAt})
+
+	if _, err := NewPublicLinkSigner("other").Verify(token, time.Now()); !errors.Is(err, ErrInvalidPublicLink) {
+		t.Fatalf("expected invalid link with another secret, got %v", err)
+	}
+
+	payload, signature, _ := strings.Cut(token, ".")
+	forged, _ := signer.Sign(&domain.QuotePublicLink{QuoteID: "quote-2", Version: 1, ExpiresAt: &expiresAt})
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+	if _, err := signer.Verify(forgedPayload+"."+signature, time.Now()); !errors.Is(err, ErrInvalidPublicLink) {
+		t.Fatalf("expected invalid link for swapped payload, got %v", err)
+	}
+	if _, err := signer.Verify(payload, time.Now()); !errors.Is(err, ErrInvalidPublicLink) {
+		t.Fatalf("expected invalid link without signature, got %v", err)
+	}
+
+	if _, err := signer.Verify(token, expiresAt.Add(time.Second)); !errors.Is(err, ErrPublicLinkExpired) {
+		t.Fatalf("expected expired link, got %v", err)
+	}
+}
+
+func TestPublicLinkSignerWithoutSecret(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	if _, err := NewPublicLinkSigner(" ").Sign(&domain.QuotePublicLink{QuoteID: "q", ExpiresAt: &expiresAt}); !errors.Is(err, ErrPublicLinksDisabled) {
+		t.Fatalf("expected links disabled, got %v", err)
+	}
+}
+
+func TestPublicLinkExpiry(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	validUntil := now.AddDate(0, 0, 15)
+	if got := publicLinkExpiry(validUntil, 0, now); !got.Equal(validUntil) {
+		t.Fatalf("expected quote validity, got %v", got)
+	}
+	if got := publicLinkExpiry(validUntil, 3, now); !got.Equal(now.AddDate(0, 0, 3)) {
+		t.Fatalf("expected requested days, got %v", got)
+	}
+	if got := publicLinkExpiry(now.AddDate(0, 0, -1), 0, now); !got.Equal(now.AddDate(0, 0, defaultPublicLinkDays)) {
+		t.Fatalf("expected default days for an expired quote, got %v", got)
+	}
+	if got := publicLinkExpiry(validUntil, 365, now); !got.Equal(now.AddDate(0, 0, maxPublicLinkDays)) {
+		t.Fatalf("expected expiry capped at %d days, got %v", maxPublicLinkDays, got)
+	}
+}
@@ -0,0 +1,256 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/branding"
+	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
+)
+
+var (
+	ErrQuoteNotOpen          = errors.New("quote can no longer be answered")
+	ErrQuoteValidityExpired  = errors.New("quote is past its validity date")
+	ErrSignatureNameRequired = errors.New("signature name is required")
+	ErrResponseTooLong       = errors.New("signature name or note is too long")
+)
+
+const (
+	maxSignatureNameLength = 120
+	maxResponseNoteLength  = 1000
+)
+
+type PublicQuoteItem struct {
+	ProductName string  `json:"product_name"`
+	Description string  `json:"description,omitempty"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Total       float64 `json:"total"`
+}
+
+type PublicQuoteOrganization struct {
+	Name        string  `json:"name"`
+	LogoDataURL *string `json:"logo_data_url,omitempty"`
+	BrandColor  *string `json:"brand_color,omitempty"`
+}
+
+// PublicQuoteView es lo que ve el cliente: sin costos internos ni datos de
+// otras cotizaciones.
+type PublicQuoteView struct {
+	QuoteNumber   string                  `json:"quote_number"`
+	Status        string                  `json:"status"`
+	StatusLabel   string                  `json:"status_label"`
+	CustomerName  string                  `json:"customer_name"`
+	Notes         string                  `json:"notes,omitempty"`
+	Items         []PublicQuoteItem       `json:"items"`
+	Subtotal      float64                 `json:"subtotal"`
+	Discount      float64                 `json:"discount"`
+	Tax           float64                 `json:"tax"`
+	Total         float64                 `json:"total"`
+	AmountPaid    float64                 `json:"amount_paid"`
+	Balance       float64                 `json:"balance"`
+	ValidUntil    time.Time               `json:"valid_until"`
+	CreatedAt     time.Time               `json:"created_at"`
+	LinkExpiresAt time.Time               `json:"link_expires_at"`
+	CanRespond    bool                    `json:"can_respond"`
+	Organization  PublicQuoteOrganization `json:"organization"`
+}
+
+type RespondToQuoteCommand struct {
+	Token     string
+	Accept    bool
+	Name      string // nombre que escribe el cliente como firma
+	Note      string // nota de anticipo al aceptar o motivo al rechazar
+	IPAddress string
+	UserAgent string
+}
+
+type RespondToQuoteResult struct {
+	QuoteNumber string `json:"quote_number"`
+	Status      string `json:"status"`
+	StatusLabel string `json:"status_label"`
+	OrderID     string `json:"order_id,omitempty"`
+}
+
+// PublicQuoteHandler atiende el enlace público: consulta, PDF y la respuesta
+// firmada del cliente. Opcionalmente convierte en orden al aceptar.
+type PublicQuoteHandler struct {
+	quoteRepo      domain.QuoteRepository
+	linkRepo       domain.QuotePublicLinkRepository
+	signer         *PublicLinkSigner
+	branding       branding.Reader
+	renderPDF      *RenderQuotePDFHandler
+	convertToOrder *ConvertToOrderHandler
+}
+
+func NewPublicQuoteHandler(
+	quoteRepo domain.QuoteRepository,
+	linkRepo domain.QuotePublicLinkRepository,
+	signer *PublicLinkSigner,
+	brandingReader branding.Reader,
+	renderPDF *RenderQuotePDFHandler,
+	convertToOrder *ConvertToOrderHandler,
+) *PublicQuoteHandler {
+	return &PublicQuoteHandler{
+		quoteRepo:      quoteRepo,
+		linkRepo:       linkRepo,
+		signer:         signer,
+		branding:       brandingReader,
+		renderPDF:      renderPDF,
+		convertToOrder: convertToOrder,
+	}
+}
+
+// resolve valida el token contra la versión vigente del enlace y devuelve la
+// cotización con un contexto de su organización.
+func (h *PublicQuoteHandler) resolve(ctx context.Context, token string) (context.Context, *domain.Quote, *domain.QuotePublicLink, error) {
+	now := time.Now()
+	claims, err := h.signer.Verify(token, now)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	link, err := h.linkRepo.FindPublicLink(claims.QuoteID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if link.Version != claims.Version {
+		return nil, nil, nil, ErrInvalidPublicLink
+	}
+	if link.ExpiresAt == nil || !now.Before(*link.ExpiresAt) {
+		return nil, nil, nil, ErrPublicLinkExpired
+	}
+
+	quote, err := h.quoteRepo.FindByID(link.QuoteID, link.OrganizationID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	orgCtx := context.WithValue(ctx, middleware.OrganizationIDKey, link.OrganizationID)
+	return orgCtx, quote, link, nil
+}
+
+func (h *PublicQuoteHandler) View(ctx context.Context, token string) (*PublicQuoteView, error) {
+	_, quote, link, err := h.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	items, err := h.quoteRepo.GetItems(quote.ID, quote.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	brand, err := h.branding.Get(ctx, quote.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &PublicQuoteView{
+		QuoteNumber:   quote.QuoteNumber,
+		Status:        quote.Status,
+		StatusLabel:   quoteStatusLabel(quote.Status),
+		CustomerName:  quote.CustomerName,
+		Notes:         quote.Notes,
+		Items:         make([]PublicQuoteItem, 0, len(items)),
+		Subtotal:      quote.Subtotal,
+		Discount:      quote.Discount,
+		Tax:           quote.Tax,
+		Total:         quote.Total,
+		AmountPaid:    quote.AmountPaid,
+		Balance:       quote.Balance,
+		ValidUntil:    quote.ValidUntil,
+		CreatedAt:     quote.CreatedAt,
+		LinkExpiresAt: *link.ExpiresAt,
+		CanRespond:    quote.Status == domain.QuoteStatusSent && time.Now().Before(quote.ValidUntil),
+		Organization: PublicQuoteOrganization{
+			Name:        brand.Name,
+			LogoDataURL: brand.LogoDataURL,
+			BrandColor:  brand.BrandColor,
+		},
+	}
+	for _, item := range items {
+		view.Items = append(view.Items, PublicQuoteItem{
+			ProductName: item.ProductName,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Total:       item.Total,
+		})
+	}
+	return view, nil
+}
+
+func (h *PublicQuoteHandler) RenderPDF(ctx context.Context, token string) ([]byte, string, error) {
+	orgCtx, quote, _, err := h.resolve(ctx, token)
+	if err != nil {
+		return nil, "", err
+	}
+	return h.renderPDF.Handle(orgCtx, quote.ID)
+}
+
+func (h *PublicQuoteHandler) Respond(ctx context.Context, cmd RespondToQuoteCommand) (*RespondToQuoteResult, error) {
+	name := strings.Join(strings.Fields(cmd.Name), " ")
+	note := strings.TrimSpace(cmd.Note)
+	if name == "" {
+		return nil, ErrSignatureNameRequired
+	}
+	if utf8.RuneCountInString(name) > maxSignatureNameLength || utf8.RuneCountInString(note) > maxResponseNoteLength {
+		return nil, ErrResponseTooLong
+	}
+
+	orgCtx, quote, link, err := h.resolve(ctx, cmd.Token)
+	if err != nil {
+		return nil, err
+	}
+	// El cliente solo responde cotizaciones enviadas; un borrador no se
+	// aprueba sin pasar por sent.
+	if quote.Status != domain.QuoteStatusSent {
+		return nil, ErrQuoteNotOpen
+	}
+	if cmd.Accept && !time.Now().Before(quote.ValidUntil) {
+		return nil, ErrQuoteValidityExpired
+	}
+
+	previousStatus := quote.Status
+	entry := &domain.QuoteHistoryEntry{
+		QuoteID:    quote.ID,
+		ChangedBy:  name,
+		ChangeType: domain.HistoryCustomerRejected,
+		FieldName:  "status",
+		OldValue:   previousStatus,
+		Notes:      note,
+		IPAddress:  cmd.IPAddress,
+		UserAgent:  cmd.UserAgent,
+	}
+	quote.Status = domain.QuoteStatusRejected
+	if cmd.Accept {
+		quote.Status = domain.QuoteStatusApproved
+		entry.ChangeType = domain.HistoryCustomerAccepted
+	}
+	entry.NewValue = quote.Status
+
+	if err := h.linkRepo.RecordCustomerResponse(quote, previousStatus, entry); err != nil {
+		return nil, err
+	}
+
+	result := &RespondToQuoteResult{
+		QuoteNumber: quote.QuoteNumber,
+		Status:      quote.Status,
+		StatusLabel: quoteStatusLabel(quote.Status),
+	}
+	// La aceptación ya quedó registrada; si la conversión falla, el equipo
+	// la hace a mano desde el panel.
+	if cmd.Accept && link.AutoConvert && quote.ConvertedToOrderID == "" {
+		order, err := h.convertToOrder.Handle(orgCtx, ConvertToOrderCommand{QuoteID: quote.ID})
+		if err != nil {
+			slog.Warn("could not auto-convert accepted quote", "quote_id", quote.ID, "error", err)
+		} else {
+			result.OrderID = order.ID
+		}
+	}
+	return result, nil
+}
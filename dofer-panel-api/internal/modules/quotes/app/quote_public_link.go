@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
+)
+
+const (
+	defaultPublicLinkDays = 7
+	maxPublicLinkDays     = 90
+)
+
+type CreateQuotePublicLinkCommand struct {
+	QuoteID       string
+	ExpiresInDays int // 0 = hasta la vigencia de la cotización
+	AutoConvert   bool
+}
+
+type QuotePublicLinkResult struct {
+	Token       string    `json:"token"`
+	URL         string    `json:"url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	AutoConvert bool      `json:"auto_convert"`
+}
+
+type CreateQuotePublicLinkHandler struct {
+	quoteRepo   domain.QuoteRepository
+	linkRepo    domain.QuotePublicLinkRepository
+	historyRepo domain.QuoteHistoryRepository
+	signer      *PublicLinkSigner
+}
+
+func NewCreateQuotePublicLinkHandler(
+	quoteRepo domain.QuoteRepository,
+	linkRepo domain.QuotePublicLinkRepository,
+	historyRepo domain.QuoteHistoryRepository,
+	signer *PublicLinkSigner,
+) *CreateQuotePublicLinkHandler {
+	return &CreateQuotePublicLinkHandler{
+		quoteRepo:   quoteRepo,
+		linkRepo:    linkRepo,
+		historyRepo: historyRepo,
+		signer:      signer,
+	}
+}
+
+// Handle emite un enlace nuevo; los enlaces anteriores de la cotización dejan
+// de funcionar.
+func (h *CreateQuotePublicLinkHandler) Handle(ctx context.Context, cmd CreateQuotePublicLinkCommand) (*QuotePublicLinkResult, error) {
+	if len(h.signer.secret) == 0 {
+		return nil, ErrPublicLinksDisabled
+	}
+
+	organizationID := organizationIDFromContext(ctx)
+	quote, err := h.quoteRepo.FindByID(cmd.QuoteID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := publicLinkExpiry(quote.ValidUntil, cmd.ExpiresInDays, time.Now())
+	link, err := h.linkRepo.IssuePublicLink(quote.ID, organizationID, expiresAt, cmd.AutoConvert)
+	if err != nil {
+		return nil, err
+	}
+	token, err := h.signer.Sign(link)
+	if err != nil {
+		return nil, err
+	}
+
+	changedBy, _ := middleware.UserIDFromContext(ctx)
+	if err := h.historyRepo.Create(&domain.QuoteHistoryEntry{
+		QuoteID:    quote.ID,
+		ChangedBy:  changedBy,
+		ChangeType: domain.HistoryPublicLinkCreated,
+		NewValue:   expiresAt.UTC().Format(time.RFC3339),
+	}); err != nil {
+		slog.Warn("could not record quote history", "quote_id", quote.ID, "error", err)
+	}
+
+	return &QuotePublicLinkResult{
+		Token:       token,
+		URL:         publicQuoteURL(token),
+		ExpiresAt:   expiresAt,
+		AutoConvert: link.AutoConvert,
+	}, nil
+}
+
+// publicLinkExpiry usa los días pedidos o, si no se indican, la vigencia de
+// la cotización; nunca más de 90 días.
+func publicLinkExpiry(validUntil time.Time, days int, now time.Time) time.Time {
+	expiresAt := validUntil
+	if days > 0 {
+		expiresAt = now.AddDate(0, 0, days)
+	}
+	if !expiresAt.After(now) {
+		expiresAt = now.AddDate(0, 0, defaultPublicLinkDays)
+	}
+	if limit := now.AddDate(0, 0, maxPublicLinkDays); expiresAt.After(limit) {
+		expiresAt = limit
+	}
+	return expiresAt.Truncate(time.Second)
+}
+
+func publicQuoteURL(token string) string {
+	frontendURL := strings.TrimRight(strings.TrimSpace(os.Getenv("FRONTEND_URL")), "/")
+	if frontendURL == "" {
+		frontendURL = "http://localhost:3000"
+	}
+	return fmt.Sprintf("%s/q/%s", frontendURL, token)
+}
+
+type RevokeQuotePublicLinkHandler struct {
+	quoteRepo   domain.QuoteRepository
+	linkRepo    domain.QuotePublicLinkRepository
+	historyRepo domain.QuoteHistoryRepository
+}
+
+func NewRevokeQuotePublicLinkHandler(
+	quoteRepo domain.QuoteRepository,
+	linkRepo domain.QuotePublicLinkRepository,
+	historyRepo domain.QuoteHistoryRepository,
+) *RevokeQuotePublicLinkHandler {
+	return &RevokeQuotePublicLinkHandler{quoteRepo: quoteRepo, linkRepo: linkRepo, historyRepo: historyRepo}
+}
+
+func (h *RevokeQuotePublicLinkHandler) Handle(ctx context.Context, quoteID string) error {
+	organizationID := organizationIDFromContext(ctx)
+	if _, err := h.quoteRepo.FindByID(quoteID, organizationID); err != nil {
+		return err
+	}
+	if err := h.linkRepo.RevokePublicLink(quoteID, organizationID); err != nil {
+		return err
+	}
+
+	changedBy, _ := middleware.UserIDFromContext(ctx)
+	if err := h.historyRepo.Create(&domain.QuoteHistoryEntry{
+		QuoteID:    quoteID,
+		ChangedBy:  changedBy,
+		ChangeType: domain.HistoryPublicLinkRevoked,
+	}); err != nil {
+		slog.Warn("could not record quote history", "quote_id", quoteID, "error", err)
+	}
+	return nil
+}
+
+type GetQuoteHistoryHandler struct {
+	quoteRepo   domain.QuoteRepository
+	historyRepo domain.QuoteHistoryRepository
+}
+
+func NewGetQuoteHistoryHandler(quoteRepo domain.QuoteRepository, historyRepo domain.QuoteHistoryRepository) *GetQuoteHistoryHandler {
+	return &GetQuoteHistoryHandler{quoteRepo: quoteRepo, historyRepo: historyRepo}
+}
+
+func (h *GetQuoteHistoryHandler) Handle(ctx context.Context, quoteID string) ([]*domain.QuoteHistoryEntry, error) {
+	organizationID := organizationIDFromContext(ctx)
+	if _, err := h.quoteRepo.FindByID(quoteID, organizationID); err != nil {
+		return nil, err
+	}
+	return h.historyRepo.FindByQuoteID(quoteID, organizationID)
+}
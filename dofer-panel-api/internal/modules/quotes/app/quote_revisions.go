@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
+)
+
+// QuoteRevisionsHandler consulta, compara y restaura las revisiones de una
+// cotización.
+type QuoteRevisionsHandler struct {
+	quoteRepo domain.QuoteRepository
+	revisions domain.QuoteRevisionRepository
+}
+
+func NewQuoteRevisionsHandler(quoteRepo domain.QuoteRepository, revisions domain.QuoteRevisionRepository) *QuoteRevisionsHandler {
+	return &QuoteRevisionsHandler{quoteRepo: quoteRepo, revisions: revisions}
+}
+
+func (h *QuoteRevisionsHandler) List(ctx context.Context, quoteID string) ([]*domain.QuoteRevision, error) {
+	organizationID := organizationIDFromContext(ctx)
+	if _, err := h.quoteRepo.FindByID(quoteID, organizationID); err != nil {
+		return nil, err
+	}
+	return h.revisions.ListRevisions(quoteID, organizationID)
+}
+
+func (h *QuoteRevisionsHandler) Get(ctx context.Context, quoteID string, revision int) (*domain.QuoteRevision, error) {
+	return h.revisions.FindRevision(quoteID, organizationIDFromContext(ctx), revision)
+}
+
+// Compare devuelve los cambios de la revisión from a la revisión to; con to
+// en cero compara contra la cotización tal como está ahora.
+func (h *QuoteRevisionsHandler) Compare(ctx context.Context, quoteID string, from, to int) (*domain.QuoteDiff, error) {
+	organizationID := organizationIDFromContext(ctx)
+	base, err := h.revisions.FindRevision(quoteID, organizationID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var target domain.QuoteSnapshot
+	if to > 0 {
+		revision, err := h.revisions.FindRevision(quoteID, organizationID, to)
+		if err != nil {
+			return nil, err
+		}
+		target = revision.Snapshot
+	} else {
+		quote, err := h.quoteRepo.FindByID(quoteID, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		items, err := h.quoteRepo.GetItems(quoteID, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		target = domain.NewQuoteSnapshot(quote, items)
+	}
+
+	diff := domain.DiffQuoteSnapshots(base.Snapshot, target)
+	diff.FromRevision = from
+	diff.ToRevision = to
+	return &diff, nil
+}
+
+// Restore deja la cotización como estaba en la revisión indicada. Si ya se
+// había enviado, el resultado queda como una revisión nueva.
+func (h *QuoteRevisionsHandler) Restore(ctx context.Context, quoteID string, number int) (*domain.Quote, error) {
+	organizationID := organizationIDFromContext(ctx)
+	quote, err := h.quoteRepo.FindByID(quoteID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if !domain.IsEditableQuote(quote) {
+		return nil, domain.ErrQuoteNotEditable
+	}
+	source, err := h.revisions.FindRevision(quoteID, organizationID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	var revision *domain.QuoteRevision
+	if quote.Status != domain.QuoteStatusDraft {
+		createdBy, _ := middleware.UserIDFromContext(ctx)
+		revision = &domain.QuoteRevision{
+			QuoteID:        quote.ID,
+			OrganizationID: organizationID,
+			Reason:         domain.RevisionReasonRestored,
+			SourceRevision: &source.Revision,
+			CreatedBy:      createdBy,
+		}
+	}
+	if err := h.revisions.RestoreRevision(quote, source.Snapshot, revision); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// quoteRevisionFor arma la revisión que se guarda junto con el cambio de la
+// cotización.
+func quoteRevisionFor(ctx context.Context, quote *domain.Quote, reason string) *domain.QuoteRevision {
+	createdBy, _ := middleware.UserIDFromContext(ctx)
+	return &domain.QuoteRevision{
+		QuoteID:        quote.ID,
+		OrganizationID: quote.OrganizationID,
+		Reason:         reason,
+		CreatedBy:      createdBy,
+	}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/branding"
+	"github.com/dofer/panel-api/internal/platform/pdf"
+)
+
+var quoteStatusLabels = map[string]string{
+	domain.QuoteStatusDraft:    "Borrador",
+	domain.QuoteStatusSent:     "Enviada",
+	domain.QuoteStatusApproved: "Aprobada",
+	domain.QuoteStatusRejected: "Rechazada",
+	domain.QuoteStatusExpired:  "Expirada",
+}
+
+// RenderQuotePDFHandler genera el PDF de la cotización con la marca de la
+// organización, sus renglones, totales y pagos recibidos.
+type RenderQuotePDFHandler struct {
+	quoteRepo domain.QuoteRepository
+	branding  branding.Reader
+}
+
+func NewRenderQuotePDFHandler(quoteRepo domain.QuoteRepository, brandingReader branding.Reader) *RenderQuotePDFHandler {
+	return &RenderQuotePDFHandler{quoteRepo: quoteRepo, branding: brandingReader}
+}
+
+// Handle devuelve el PDF y el nombre de archivo sugerido.
+func (h *RenderQuotePDFHandler) Handle(ctx context.Context, quoteID string) ([]byte, string, error) {
+	organizationID := organizationIDFromContext(ctx)
+
+	quote, err := h.quoteRepo.FindByID(quoteID, organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	items, err := h.quoteRepo.GetItems(quoteID, organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	payments, err := h.quoteRepo.GetPayments(quoteID, organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	brand, err := h.branding.Get(ctx, organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	validUntil := quote.ValidUntil
+	statement := pdf.Statement{
+		Title:      "Cotización",
+		Number:     quote.QuoteNumber,
+		Status:     quoteStatusLabel(quote.Status),
+		IssuedAt:   quote.CreatedAt,
+		ValidUntil: &validUntil,
+		Brand:      brand.PDFBrand(),
+		Customer: pdf.Party{
+			Name:  quote.CustomerName,
+			Email: quote.CustomerEmail,
+			Phone: quote.CustomerPhone,
+		},
+		Notes:  quote.Notes,
+		Footer: brand.Footer(fmt.Sprintf("Gracias por su preferencia. Precios válidos hasta el %s.", validUntil.Format("02/01/2006"))),
+	}
+
+	for _, item := range items {
+		statement.Items = append(statement.Items, pdf.LineItem{
+			Description: item.ProductName,
+			Detail:      quoteItemDetail(item),
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Total:       item.Total,
+		})
+	}
+
+	statement.Totals = append(statement.Totals, pdf.TotalLine{Label: "Subtotal", Amount: quote.Subtotal})
+	if quote.Discount > 0 {
+		statement.Totals = append(statement.Totals, pdf.TotalLine{Label: "Descuento", Amount: -quote.Discount})
+	}
+	if quote.Tax > 0 {
+		statement.Totals = append(statement.Totals, pdf.TotalLine{Label: "IVA", Amount: quote.Tax})
+	}
+	statement.Totals = append(statement.Totals, pdf.TotalLine{Label: "Total", Amount: quote.Total, Emphasis: true})
+
+	if len(payments) > 0 {
+		for _, payment := range payments {
+			statement.Payments = append(statement.Payments, pdf.PaymentLine{
+				Date:   payment.PaymentDate,
+				Method: payment.PaymentMethod,
+				Notes:  payment.Notes,
+				Amount: payment.Amount,
+			})
+		}
+		statement.Totals = append(statement.Totals,
+			pdf.TotalLine{Label: "Pagado", Amount: quote.AmountPaid},
+			pdf.TotalLine{Label: "Saldo", Amount: quote.Balance},
+		)
+	}
+
+	document, err := pdf.RenderStatement(statement)
+	if err != nil {
+		return nil, "", err
+	}
+	return document, fmt.Sprintf("cotizacion-%s.pdf", quote.QuoteNumber), nil
+}
+
+func quoteStatusLabel(status string) string {
+	if label, ok := quoteStatusLabels[status]; ok {
+		return label
+	}
+	return status
+}
+
+func quoteItemDetail(item *domain.QuoteItem) string {
+	specs := []string{}
+	if item.WeightGrams > 0 {
+		specs = append(specs, fmt.Sprintf("Peso: %.0f g", item.WeightGrams))
+	}
+	if item.PrintTimeHours > 0 {
+		specs = append(specs, fmt.Sprintf("Impresión: %.1f h", item.PrintTimeHours))
+	}
+	if item.MaterialName != "" {
+		specs = append(specs, "Material: "+item.MaterialName)
+	}
+
+	detail := strings.TrimSpace(item.Description)
+	if len(specs) > 0 {
+		if detail != "" {
+			detail += "\n"
+		}
+		detail += strings.Join(specs, " · ")
+	}
+	return detail
+}
@@ -2,11 +2,8 @@ package app
 
 import (
 	"context"
-	"fmt"
 
-	ordersDomain "github.com/dofer/panel-api/internal/modules/orders/domain"
 	"github.com/dofer/panel-api/internal/modules/quotes/domain"
-	"github.com/google/uuid"
 )
 
 type SyncItemsToOrderCommand struct {
@@ -14,70 +11,47 @@ type SyncItemsToOrderCommand struct {
 }
 
 type SyncItemsToOrderHandler struct {
-	quoteRepo domain.QuoteRepository
-	orderRepo ordersDomain.OrderRepository
+	uow domain.UnitOfWork
 }
 
-func NewSyncItemsToOrderHandler(quoteRepo domain.QuoteRepository, orderRepo ordersDomain.OrderRepository) *SyncItemsToOrderHandler {
-	return &SyncItemsToOrderHandler{
-		quoteRepo: quoteRepo,
-		orderRepo: orderRepo,
-	}
+func NewSyncItemsToOrderHandler(uow domain.UnitOfWork) *SyncItemsToOrderHandler {
+	return &SyncItemsToOrderHandler{uow: uow}
 }
 
+// Handle copia los items de la cotización al pedido que generó, solo si el
+// pedido aún no tiene items. Se copian todos o ninguno; la cotización queda
+// bloqueada para que dos sincronizaciones simultáneas no dupliquen items.
 func (h *SyncItemsToOrderHandler) Handle(ctx context.Context, cmd SyncItemsToOrderCommand) error {
 	organizationID := organizationIDFromContext(ctx)
 
-	// Obtener la cotización
-	quote, err := h.quoteRepo.FindByID(cmd.QuoteID, organizationID)
-	if err != nil {
-		return err
-	}
-
-	// Verificar que tenga un pedido asociado
-	if quote.ConvertedToOrderID == "" {
-		return fmt.Errorf("quote has not been converted to order yet")
-	}
-
-	// Obtener los items de la cotización
-	items, err := h.quoteRepo.GetItems(cmd.QuoteID, organizationID)
-	if err != nil {
-		return err
-	}
-
-	if len(items) == 0 {
-		return fmt.Errorf("quote has no items")
-	}
-
-	// Verificar si el pedido ya tiene items
-	existingItems, err := h.orderRepo.GetOrderItems(quote.ConvertedToOrderID, organizationID)
-	if err != nil {
-		return err
-	}
-
-	// Si ya tiene items, no hacer nada
-	if len(existingItems) > 0 {
-		return fmt.Errorf("order already has %d items", len(existingItems))
-	}
+	return h.uow.Do(ctx, func(repos domain.ConversionRepositories) error {
+		quote, err := repos.Quotes.FindByIDForUpdate(cmd.QuoteID, organizationID)
+		if err != nil {
+			return err
+		}
+		if quote.ConvertedToOrderID == "" {
+			return ErrQuoteNotConverted
+		}
 
-	// Crear los items individuales de la orden
-	for _, quoteItem := range items {
-		orderItem := &ordersDomain.OrderItem{
-			ID:             uuid.New().String(),
-			OrganizationID: organizationID,
-			OrderID:        quote.ConvertedToOrderID,
-			ProductName:    quoteItem.ProductName,
-			Description:    quoteItem.Description,
-			Quantity:       quoteItem.Quantity,
-			UnitPrice:      quoteItem.UnitPrice,
-			Total:          quoteItem.Total,
-			IsCompleted:    false,
+		items, err := repos.Quotes.GetItems(quote.ID, organizationID)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return ErrQuoteNoItems
 		}
 
-		if err := h.orderRepo.CreateOrderItem(orderItem); err != nil {
-			return fmt.Errorf("could not create order item: %v", err)
+		existingItems, err := repos.Orders.GetOrderItems(quote.ConvertedToOrderID, organizationID)
+		if err != nil {
+			return err
+		}
+		if len(existingItems) > 0 {
+			return ErrOrderAlreadyHasItems
 		}
-	}
 
-	return nil
+		if err := createOrderItems(repos.Orders, quote.ConvertedToOrderID, organizationID, items); err != nil {
+			return &ConversionError{QuoteID: quote.ID, Step: ConversionStepCopyItems, Err: err}
+		}
+		return nil
+	})
 }
@@ -15,11 +15,12 @@ type UpdateQuoteCommand struct {
 }
 
 type UpdateQuoteHandler struct {
-	repo domain.QuoteRepository
+	repo      domain.QuoteRepository
+	revisions domain.QuoteRevisionRepository
 }
 
-func NewUpdateQuoteHandler(repo domain.QuoteRepository) *UpdateQuoteHandler {
-	return &UpdateQuoteHandler{repo: repo}
+func NewUpdateQuoteHandler(repo domain.QuoteRepository, revisions domain.QuoteRevisionRepository) *UpdateQuoteHandler {
+	return &UpdateQuoteHandler{repo: repo, revisions: revisions}
 }
 
 func (h *UpdateQuoteHandler) Handle(ctx context.Context, cmd UpdateQuoteCommand) (*domain.Quote, error) {
@@ -28,25 +29,25 @@ func (h *UpdateQuoteHandler) Handle(ctx context.Context, cmd UpdateQuoteCommand)
 	if err != nil {
 		return nil, err
 	}
-
-	// Update only provided fields
-	if cmd.CustomerName != nil {
-		quote.CustomerName = *cmd.CustomerName
-	}
-	if cmd.CustomerEmail != nil {
-		quote.CustomerEmail = *cmd.CustomerEmail
-	}
-	if cmd.CustomerPhone != nil {
-		quote.CustomerPhone = *cmd.CustomerPhone
-	}
-	if cmd.Notes != nil {
-		quote.Notes = *cmd.Notes
+	if !domain.IsEditableQuote(quote) {
+		return nil, domain.ErrQuoteNotEditable
 	}
 
 	// Save updates
-	if err := h.repo.Update(quote); err != nil {
-		return nil, err
-	}
-
-	return quote, nil
+	return h.revisions.EditWithRevision(quote.ID, quote.OrganizationID, quoteRevisionFor(ctx, quote, domain.RevisionReasonEdited), func(repo domain.QuoteRepository, quote *domain.Quote) error {
+		// Update only provided fields
+		if cmd.CustomerName != nil {
+			quote.CustomerName = *cmd.CustomerName
+		}
+		if cmd.CustomerEmail != nil {
+			quote.CustomerEmail = *cmd.CustomerEmail
+		}
+		if cmd.CustomerPhone != nil {
+			quote.CustomerPhone = *cmd.CustomerPhone
+		}
+		if cmd.Notes != nil {
+			quote.Notes = *cmd.Notes
+		}
+		return repo.Update(quote)
+	})
 }
@@ -2,30 +2,83 @@ package app
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
+	"github.com/dofer/panel-api/internal/platform/outbox"
 )
 
 type UpdateQuoteStatusCommand struct {
 	QuoteID string
-	Status  string // approved, rejected, expired
+	Status  string // draft (reabrir), sent, approved, rejected, expired
+	// ValidUntil reemplaza la vigencia; hace falta al reabrir una cotización
+	// vencida.
+	ValidUntil *time.Time
+	Notes      string
 }
 
 type UpdateQuoteStatusHandler struct {
-	repo domain.QuoteRepository
+	repo      domain.QuoteRepository
+	lifecycle domain.QuoteLifecycleRepository
+	revisions domain.QuoteRevisionRepository
 }
 
-func NewUpdateQuoteStatusHandler(repo domain.QuoteRepository) *UpdateQuoteStatusHandler {
-	return &UpdateQuoteStatusHandler{repo: repo}
+func NewUpdateQuoteStatusHandler(
+	repo domain.QuoteRepository,
+	lifecycle domain.QuoteLifecycleRepository,
+	revisions domain.QuoteRevisionRepository,
+) *UpdateQuoteStatusHandler {
+	return &UpdateQuoteStatusHandler{repo: repo, lifecycle: lifecycle, revisions: revisions}
 }
 
-func (h *UpdateQuoteStatusHandler) Handle(ctx context.Context, cmd UpdateQuoteStatusCommand) error {
+// Handle aplica la transición si la máquina de estados la permite y la deja
+// en el historial. Enviar, aprobar o reabrir exige que la vigencia no haya
+// terminado.
+func (h *UpdateQuoteStatusHandler) Handle(ctx context.Context, cmd UpdateQuoteStatusCommand) (*domain.Quote, error) {
 	quote, err := h.repo.FindByID(cmd.QuoteID, organizationIDFromContext(ctx))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	quote.Status = cmd.Status
+	status := strings.TrimSpace(cmd.Status)
+	if err := domain.ValidateQuoteTransition(quote.Status, status); err != nil {
+		return nil, err
+	}
+	if cmd.ValidUntil != nil {
+		quote.ValidUntil = *cmd.ValidUntil
+	}
+	if status != domain.QuoteStatusRejected && status != domain.QuoteStatusExpired && !time.Now().Before(quote.ValidUntil) {
+		return nil, ErrQuoteValidityExpired
+	}
+
+	previousStatus := quote.Status
+	quote.Status = status
+	changedBy, _ := middleware.UserIDFromContext(ctx)
+	entry := &domain.QuoteHistoryEntry{
+		QuoteID:    quote.ID,
+		ChangedBy:  changedBy,
+		ChangeType: domain.HistoryStatusChanged,
+		FieldName:  "status",
+		OldValue:   previousStatus,
+		NewValue:   status,
+		Notes:      strings.TrimSpace(cmd.Notes),
+	}
 
-	return h.repo.Update(quote)
+	var events []outbox.Event
+	if status == domain.QuoteStatusSent {
+		events = append(events, quoteSentEvent(quote))
+	}
+	// Cada envío deja, en la misma transacción, la versión que recibió el
+	// cliente.
+	if status == domain.QuoteStatusSent {
+		err = h.revisions.ChangeStatusWithRevision(quote, previousStatus, entry, quoteRevisionFor(ctx, quote, domain.RevisionReasonSent), events...)
+	} else {
+		err = h.lifecycle.ChangeStatus(quote, previousStatus, entry, events...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return quote, nil
 }
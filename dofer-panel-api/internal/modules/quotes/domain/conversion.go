@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	ordersDomain "github.com/dofer/panel-api/internal/modules/orders/domain"
+)
+
+var ErrQuoteAlreadyConverted = errors.New("quote already converted to an order")
+
+// ConversionRepositories son los repositorios de cotizaciones y pedidos atados
+// a la misma transacción.
+type ConversionRepositories struct {
+	Quotes QuoteRepository
+	Orders ordersDomain.OrderRepository
+}
+
+// UnitOfWork ejecuta fn en una transacción. Si fn devuelve error no queda
+// escrito nada de lo que hizo con los repositorios recibidos.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos ConversionRepositories) error) error
+}
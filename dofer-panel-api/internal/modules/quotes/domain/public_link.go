@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrQuoteAlreadyAnswered = errors.New("quote already answered")
+
+// QuotePublicLink es el estado del enlace público de una cotización. Los
+// tokens firmados llevan Version; emitir o revocar un enlace la incrementa y
+// deja sin efecto los anteriores.
+type QuotePublicLink struct {
+	QuoteID        string
+	OrganizationID string
+	Version        int
+	ExpiresAt      *time.Time
+	AutoConvert    bool
+}
+
+type QuotePublicLinkRepository interface {
+	IssuePublicLink(quoteID, organizationID string, expiresAt time.Time, autoConvert bool) (*QuotePublicLink, error)
+	RevokePublicLink(quoteID, organizationID string) error
+	FindPublicLink(quoteID string) (*QuotePublicLink, error)
+	// RecordCustomerResponse cambia el estado solo si sigue en previousStatus y
+	// guarda la entrada de historial en la misma transacción.
+	RecordCustomerResponse(quote *Quote, previousStatus string, entry *QuoteHistoryEntry) error
+}
@@ -3,6 +3,8 @@ package domain
 import (
 	"context"
 	"time"
+
+	"github.com/dofer/panel-api/internal/platform/outbox"
 )
 
 type Quote struct {
@@ -12,7 +14,7 @@ type Quote struct {
 	CustomerName       string       `json:"customer_name"`
 	CustomerEmail      string       `json:"customer_email"`
 	CustomerPhone      string       `json:"customer_phone"`
-	Status             string       `json:"status"` // pending, approved, rejected, expired
+	Status             string       `json:"status"` // draft, sent, approved, rejected, expired
 	Subtotal           float64      `json:"subtotal"`
 	Discount           float64      `json:"discount"`
 	Tax                float64      `json:"tax"`
@@ -36,6 +38,7 @@ type QuoteItem struct {
 	Description     string    `json:"description"`
 	WeightGrams     float64   `json:"weight_grams"`
 	PrintTimeHours  float64   `json:"print_time_hours"`
+	MaterialName    string    `json:"material_name,omitempty"`
 	MaterialCost    float64   `json:"material_cost"`
 	LaborCost       float64   `json:"labor_cost"`
 	ElectricityCost float64   `json:"electricity_cost"`
@@ -78,8 +81,16 @@ type QuoteTemplate struct {
 type QuoteRepository interface {
 	Create(quote *Quote) error
 	FindByID(id string, organizationID ...string) (*Quote, error)
+	// FindByIDForUpdate bloquea la cotización hasta que termine la
+	// transacción; fuera de una transacción equivale a FindByID.
+	FindByIDForUpdate(id, organizationID string) (*Quote, error)
 	FindAll(filters map[string]interface{}) ([]*Quote, error)
-	Update(quote *Quote) error
+	// Update guarda la cotización; los eventos se escriben en la misma transacción.
+	Update(quote *Quote, events ...outbox.Event) error
+	// MarkConverted enlaza la cotización con su pedido. Solo lo usa la
+	// conversión, dentro de su transacción; devuelve ErrQuoteAlreadyConverted
+	// si ya tenía pedido.
+	MarkConverted(quoteID, organizationID, orderID string) error
 	Delete(id string, organizationID ...string) error
 
 	// Items
@@ -90,7 +101,7 @@ type QuoteRepository interface {
 	DeleteQuoteItem(ctx context.Context, quoteID, itemID, organizationID string) error
 
 	// Payments
-	AddPayment(payment *QuotePayment) error
+	AddPayment(payment *QuotePayment, events ...outbox.Event) error
 	GetPayments(quoteID, organizationID string) ([]*QuotePayment, error)
 
 	// Templates
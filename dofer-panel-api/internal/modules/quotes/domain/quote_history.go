@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// Tipos de cambio que se registran en el historial de la cotización.
+const (
+	HistoryPublicLinkCreated = "public_link_created"
+	HistoryPublicLinkRevoked = "public_link_revoked"
+	HistoryCustomerAccepted  = "customer_accepted"
+	HistoryCustomerRejected  = "customer_rejected"
+	HistoryStatusChanged     = "status_changed"
+	HistoryExpiryReminder    = "expiry_reminder_sent"
+)
+
+type QuoteHistoryEntry struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organization_id,omitempty"`
+	QuoteID        string    `json:"quote_id"`
+	ChangedBy      string    `json:"changed_by"`
+	ChangeType     string    `json:"change_type"`
+	FieldName      string    `json:"field_name,omitempty"`
+	OldValue       string    `json:"old_value,omitempty"`
+	NewValue       string    `json:"new_value,omitempty"`
+	Notes          string    `json:"notes,omitempty"`
+	IPAddress      string    `json:"ip_address,omitempty"`
+	UserAgent      string    `json:"user_agent,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type QuoteHistoryRepository interface {
+	Create(entry *QuoteHistoryEntry) error
+	FindByQuoteID(quoteID, organizationID string) ([]*QuoteHistoryEntry, error)
+}
@@ -0,0 +1,248 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/dofer/panel-api/internal/platform/outbox"
+)
+
+// Motivos por los que se guarda una revisión.
+const (
+	RevisionReasonSent     = "sent"
+	RevisionReasonEdited   = "edited"
+	RevisionReasonRestored = "restored"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("quote revision not found")
+	// ErrQuoteNotEditable se devuelve al editar o restaurar una cotización que
+	// ya se respondió o se convirtió en pedido.
+	ErrQuoteNotEditable = errors.New("only draft or sent quotes without order can be edited")
+)
+
+// QuoteSnapshot es la parte de la cotización que ve el cliente. La vigencia
+// se guarda para compararla, pero restaurar no la cambia.
+type QuoteSnapshot struct {
+	CustomerName  string              `json:"customer_name"`
+	CustomerEmail string              `json:"customer_email"`
+	CustomerPhone string              `json:"customer_phone"`
+	Notes         string              `json:"notes"`
+	ValidUntil    time.Time           `json:"valid_until"`
+	Subtotal      float64             `json:"subtotal"`
+	Discount      float64             `json:"discount"`
+	Tax           float64             `json:"tax"`
+	Total         float64             `json:"total"`
+	Items         []QuoteSnapshotItem `json:"items"`
+}
+
+type QuoteSnapshotItem struct {
+	ID              string    `json:"id"`
+	ProductName     string    `json:"product_name"`
+	Description     string    `json:"description"`
+	MaterialName    string    `json:"material_name,omitempty"`
+	WeightGrams     float64   `json:"weight_grams"`
+	PrintTimeHours  float64   `json:"print_time_hours"`
+	MaterialCost    float64   `json:"material_cost"`
+	LaborCost       float64   `json:"labor_cost"`
+	ElectricityCost float64   `json:"electricity_cost"`
+	OtherCosts      float64   `json:"other_costs"`
+	Subtotal        float64   `json:"subtotal"`
+	Quantity        int       `json:"quantity"`
+	UnitPrice       float64   `json:"unit_price"`
+	Total           float64   `json:"total"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// IsEditableQuote indica si todavía se pueden cambiar los datos y los items
+// de la cotización.
+func IsEditableQuote(quote *Quote) bool {
+	return IsOpenQuoteStatus(quote.Status) && quote.ConvertedToOrderID == ""
+}
+
+type QuoteRevision struct {
+	ID             string `json:"id"`
+	OrganizationID string `json:"organization_id,omitempty"`
+	QuoteID        string `json:"quote_id"`
+	Revision       int    `json:"revision"`
+	Reason         string `json:"reason"`
+	// SourceRevision es la revisión restaurada cuando Reason es "restored".
+	SourceRevision *int          `json:"source_revision,omitempty"`
+	Snapshot       QuoteSnapshot `json:"snapshot"`
+	CreatedBy      string        `json:"created_by,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+type QuoteRevisionRepository interface {
+	// EditWithRevision bloquea la cotización, revisa que siga editable y corre
+	// edit con el repositorio de la transacción. Si la cotización ya se envió
+	// guarda además la foto resultante con el siguiente número; una foto igual
+	// a la última revisión no se repite.
+	EditWithRevision(quoteID, organizationID string, revision *QuoteRevision, edit func(repo QuoteRepository, quote *Quote) error) (*Quote, error)
+	// ChangeStatusWithRevision es ChangeStatus más la foto de la cotización
+	// con el nuevo estado, en la misma transacción.
+	ChangeStatusWithRevision(quote *Quote, previousStatus string, entry *QuoteHistoryEntry, revision *QuoteRevision, events ...outbox.Event) error
+	ListRevisions(quoteID, organizationID string) ([]*QuoteRevision, error)
+	FindRevision(quoteID, organizationID string, revision int) (*QuoteRevision, error)
+	// RestoreRevision reemplaza datos del cliente, notas, totales e items por
+	// los de la foto y guarda la revisión resultante, todo en una transacción.
+	RestoreRevision(quote *Quote, snapshot QuoteSnapshot, revision *QuoteRevision) error
+}
+
+func NewQuoteSnapshot(quote *Quote, items []*QuoteItem) QuoteSnapshot {
+	snapshot := QuoteSnapshot{
+		CustomerName:  quote.CustomerName,
+		CustomerEmail: quote.CustomerEmail,
+		CustomerPhone: quote.CustomerPhone,
+		Notes:         quote.Notes,
+		ValidUntil:    quote.ValidUntil.UTC(),
+		Subtotal:      quote.Subtotal,
+		Discount:      quote.Discount,
+		Tax:           quote.Tax,
+		Total:         quote.Total,
+		Items:         make([]QuoteSnapshotItem, 0, len(items)),
+	}
+	for _, item := range items {
+		snapshot.Items = append(snapshot.Items, QuoteSnapshotItem{
+			ID:              item.ID,
+			ProductName:     item.ProductName,
+			Description:     item.Description,
+			MaterialName:    item.MaterialName,
+			WeightGrams:     item.WeightGrams,
+			PrintTimeHours:  item.PrintTimeHours,
+			MaterialCost:    item.MaterialCost,
+			LaborCost:       item.LaborCost,
+			ElectricityCost: item.ElectricityCost,
+			OtherCosts:      item.OtherCosts,
+			Subtotal:        item.Subtotal,
+			Quantity:        item.Quantity,
+			UnitPrice:       item.UnitPrice,
+			Total:           item.Total,
+			CreatedAt:       item.CreatedAt.UTC(),
+		})
+	}
+	return snapshot
+}
+
+type QuoteFieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type QuoteTotalChange struct {
+	Field string  `json:"field"`
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Delta float64 `json:"delta"`
+}
+
+type QuoteItemChange struct {
+	ItemID      string             `json:"item_id"`
+	ProductName string             `json:"product_name"`
+	Repriced    bool               `json:"repriced"`
+	Changes     []QuoteFieldChange `json:"changes"`
+}
+
+// QuoteDiff describe qué cambió de una versión a otra. ToRevision en cero
+// significa el estado actual de la cotización.
+type QuoteDiff struct {
+	FromRevision int                 `json:"from_revision"`
+	ToRevision   int                 `json:"to_revision"`
+	Fields       []QuoteFieldChange  `json:"fields"`
+	ItemsAdded   []QuoteSnapshotItem `json:"items_added"`
+	ItemsRemoved []QuoteSnapshotItem `json:"items_removed"`
+	ItemsChanged []QuoteItemChange   `json:"items_changed"`
+	Totals       []QuoteTotalChange  `json:"totals"`
+}
+
+// DiffQuoteSnapshots compara dos fotos. Los items se emparejan por ID, que se
+// conserva al editar y al restaurar.
+func DiffQuoteSnapshots(from, to QuoteSnapshot) QuoteDiff {
+	diff := QuoteDiff{
+		Fields:       []QuoteFieldChange{},
+		ItemsAdded:   []QuoteSnapshotItem{},
+		ItemsRemoved: []QuoteSnapshotItem{},
+		ItemsChanged: []QuoteItemChange{},
+		Totals:       []QuoteTotalChange{},
+	}
+
+	diff.Fields = appendTextChange(diff.Fields, "customer_name", from.CustomerName, to.CustomerName)
+	diff.Fields = appendTextChange(diff.Fields, "customer_email", from.CustomerEmail, to.CustomerEmail)
+	diff.Fields = appendTextChange(diff.Fields, "customer_phone", from.CustomerPhone, to.CustomerPhone)
+	diff.Fields = appendTextChange(diff.Fields, "notes", from.Notes, to.Notes)
+	if !from.ValidUntil.Equal(to.ValidUntil) {
+		diff.Fields = append(diff.Fields, QuoteFieldChange{Field: "valid_until", From: from.ValidUntil, To: to.ValidUntil})
+	}
+
+	previous := make(map[string]QuoteSnapshotItem, len(from.Items))
+	for _, item := range from.Items {
+		previous[item.ID] = item
+	}
+	current := make(map[string]bool, len(to.Items))
+	for _, item := range to.Items {
+		current[item.ID] = true
+		old, ok := previous[item.ID]
+		if !ok {
+			diff.ItemsAdded = append(diff.ItemsAdded, item)
+			continue
+		}
+		if change, changed := diffSnapshotItem(old, item); changed {
+			diff.ItemsChanged = append(diff.ItemsChanged, change)
+		}
+	}
+	for _, item := range from.Items {
+		if !current[item.ID] {
+			diff.ItemsRemoved = append(diff.ItemsRemoved, item)
+		}
+	}
+
+	diff.Totals = appendTotalChange(diff.Totals, "subtotal", from.Subtotal, to.Subtotal)
+	diff.Totals = appendTotalChange(diff.Totals, "discount", from.Discount, to.Discount)
+	diff.Totals = appendTotalChange(diff.Totals, "tax", from.Tax, to.Tax)
+	diff.Totals = appendTotalChange(diff.Totals, "total", from.Total, to.Total)
+	return diff
+}
+
+// HasChanges indica si hay alguna diferencia entre las dos versiones.
+func (d QuoteDiff) HasChanges() bool {
+	return len(d.Fields)+len(d.ItemsAdded)+len(d.ItemsRemoved)+len(d.ItemsChanged)+len(d.Totals) > 0
+}
+
+func diffSnapshotItem(from, to QuoteSnapshotItem) (QuoteItemChange, bool) {
+	change := QuoteItemChange{ItemID: to.ID, ProductName: to.ProductName, Changes: []QuoteFieldChange{}}
+	change.Changes = appendTextChange(change.Changes, "product_name", from.ProductName, to.ProductName)
+	change.Changes = appendTextChange(change.Changes, "description", from.Description, to.Description)
+	change.Changes = appendTextChange(change.Changes, "material_name", from.MaterialName, to.MaterialName)
+	if from.Quantity != to.Quantity {
+		change.Changes = append(change.Changes, QuoteFieldChange{Field: "quantity", From: from.Quantity, To: to.Quantity})
+	}
+	if !sameAmount(from.UnitPrice, to.UnitPrice) {
+		change.Repriced = true
+		change.Changes = append(change.Changes, QuoteFieldChange{Field: "unit_price", From: from.UnitPrice, To: to.UnitPrice})
+	}
+	if !sameAmount(from.Total, to.Total) {
+		change.Changes = append(change.Changes, QuoteFieldChange{Field: "total", From: from.Total, To: to.Total})
+	}
+	return change, len(change.Changes) > 0
+}
+
+func appendTextChange(changes []QuoteFieldChange, field, from, to string) []QuoteFieldChange {
+	if from == to {
+		return changes
+	}
+	return append(changes, QuoteFieldChange{Field: field, From: from, To: to})
+}
+
+func appendTotalChange(changes []QuoteTotalChange, field string, from, to float64) []QuoteTotalChange {
+	if sameAmount(from, to) {
+		return changes
+	}
+	return append(changes, QuoteTotalChange{Field: field, From: from, To: to, Delta: math.Round((to-from)*100) / 100})
+}
+
+// sameAmount ignora diferencias por debajo del centavo.
+func sameAmount(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffQuoteSnapshots(t *testing.T) {
+	validUntil := time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC)
+	from := QuoteSnapshot{
+		CustomerName: "Ana",
+		ValidUntil:   validUntil,
+		Subtotal:     300,
+		Total:        300,
+		Items: []QuoteSnapshotItem{
+			{ID: "a", ProductName: "Llavero", Quantity: 10, UnitPrice: 10, Total: 100},
+			{ID: "b", ProductName: "Maceta", Quantity: 1, UnitPrice: 200, Total: 200},
+		},
+	}
+	to := QuoteSnapshot{
+		CustomerName: "Ana López",
+		ValidUntil:   validUntil,
+		Subtotal:     270.004,
+		Total:        270.004,
+		Items: []QuoteSnapshotItem{
+			{ID: "a", ProductName: "Llavero", Quantity: 10, UnitPrice: 12, Total: 120},
+			{ID: "c", ProductName: "Soporte", Quantity: 1, UnitPrice: 150.004, Total: 150.004},
+		},
+	}
+
+	diff := DiffQuoteSnapshots(from, to)
+
+	if len(diff.Fields) != 1 || diff.Fields[0].Field != "customer_name" {
+		t.Fatalf("expected only customer_name to change, got %+v", diff.Fields)
+	}
+	if len(diff.ItemsAdded) != 1 || diff.ItemsAdded[0].ID != "c" {
+		t.Fatalf("expected item c added, got %+v", diff.ItemsAdded)
+	}
+	if len(diff.ItemsRemoved) != 1 || diff.ItemsRemoved[0].ID != "b" {
+		t.Fatalf("expected item b removed, got %+v", diff.ItemsRemoved)
+	}
+	if len(diff.ItemsChanged) != 1 || !diff.ItemsChanged[0].Repriced {
+		t.Fatalf("expected item a repriced, got %+v", diff.ItemsChanged)
+	}
+	if len(diff.Totals) != 2 || diff.Totals[1].Field != "total" || diff.Totals[1].Delta != -30 {
+		t.Fatalf("expected subtotal and total down by 30, got %+v", diff.Totals)
+	}
+
+	if same := DiffQuoteSnapshots(from, from); same.HasChanges() {
+		t.Fatalf("expected no changes comparing a snapshot with itself, got %+v", same)
+	}
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dofer/panel-api/internal/platform/outbox"
+)
+
+const (
+	QuoteStatusDraft    = "draft"
+	QuoteStatusSent     = "sent"
+	QuoteStatusApproved = "approved"
+	QuoteStatusRejected = "rejected"
+	QuoteStatusExpired  = "expired"
+)
+
+var (
+	ErrInvalidQuoteStatus     = errors.New("status must be draft, sent, approved, rejected or expired")
+	ErrInvalidQuoteTransition = errors.New("quote status transition is not allowed")
+	// ErrQuoteStatusChanged indica que otro proceso cambió el estado entre la
+	// lectura y la escritura (por ejemplo, el job de vigencia).
+	ErrQuoteStatusChanged = errors.New("quote status changed, reload and try again")
+)
+
+// quoteTransitions es la máquina de estados de la cotización. Solo se aprueba
+// lo que ya se envió. Volver a draft es reabrirla; las aprobadas no se
+// reabren porque pueden tener pedido.
+var quoteTransitions = map[string][]string{
+	QuoteStatusDraft:    {QuoteStatusSent, QuoteStatusRejected, QuoteStatusExpired},
+	QuoteStatusSent:     {QuoteStatusApproved, QuoteStatusRejected, QuoteStatusExpired},
+	QuoteStatusRejected: {QuoteStatusDraft},
+	QuoteStatusExpired:  {QuoteStatusDraft},
+}
+
+func IsValidQuoteStatus(status string) bool {
+	switch status {
+	case QuoteStatusDraft, QuoteStatusSent, QuoteStatusApproved, QuoteStatusRejected, QuoteStatusExpired:
+		return true
+	}
+	return false
+}
+
+// IsOpenQuoteStatus indica si la cotización todavía espera respuesta del
+// cliente y puede vencer.
+func IsOpenQuoteStatus(status string) bool {
+	return status == QuoteStatusDraft || status == QuoteStatusSent
+}
+
+func ValidateQuoteTransition(from, to string) error {
+	if !IsValidQuoteStatus(to) {
+		return ErrInvalidQuoteStatus
+	}
+	for _, allowed := range quoteTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidQuoteTransition, from, to)
+}
+
+// QuoteLifecycleRepository lo usa el job de vigencia y recorre todas las
+// organizaciones.
+type QuoteLifecycleRepository interface {
+	// ChangeStatus guarda el estado y la vigencia solo si la cotización sigue
+	// en previousStatus; el historial y los eventos van en la misma transacción.
+	ChangeStatus(quote *Quote, previousStatus string, entry *QuoteHistoryEntry, events ...outbox.Event) error
+	// FindOpenValidBefore devuelve cotizaciones en draft o sent cuya vigencia
+	// termina antes de before.
+	FindOpenValidBefore(before time.Time, limit int) ([]*Quote, error)
+	// FindPendingExpiryReminders devuelve cotizaciones enviadas, con correo del
+	// cliente, que vencen entre from y to y aún no recibieron el aviso.
+	FindPendingExpiryReminders(from, to time.Time, limit int) ([]*Quote, error)
+	// MarkExpiryReminderSent registra el aviso una sola vez; devuelve false si
+	// otra ejecución ya lo había registrado.
+	MarkExpiryReminderSent(quote *Quote, entry *QuoteHistoryEntry, events ...outbox.Event) (bool, error)
+}
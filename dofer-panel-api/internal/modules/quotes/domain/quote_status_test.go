@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateQuoteTransition(t *testing.T) {
+	for _, tc := range []struct {
+		from, to string
+		want     error
+	}{
+		{QuoteStatusDraft, QuoteStatusSent, nil},
+		{QuoteStatusSent, QuoteStatusApproved, nil},
+		{QuoteStatusSent, QuoteStatusExpired, nil},
+		{QuoteStatusExpired, QuoteStatusDraft, nil},
+		{QuoteStatusRejected, QuoteStatusDraft, nil},
+		{QuoteStatusSent, QuoteStatusDraft, ErrInvalidQuoteTransition},
+		{QuoteStatusApproved, QuoteStatusDraft, ErrInvalidQuoteTransition},
+		{QuoteStatusExpired, QuoteStatusApproved, ErrInvalidQuoteTransition},
+		{QuoteStatusDraft, QuoteStatusApproved, ErrInvalidQuoteTransition},
+		{QuoteStatusDraft, QuoteStatusDraft, ErrInvalidQuoteTransition},
+		{QuoteStatusDraft, "pending", ErrInvalidQuoteStatus},
+	} {
+		err := ValidateQuoteTransition(tc.from, tc.to)
+		if tc.want == nil && err != nil {
+			t.Fatalf("%s -> %s: unexpected error %v", tc.from, tc.to, err)
+		}
+		if tc.want != nil && !errors.Is(err, tc.want) {
+			t.Fatalf("%s -> %s: expected %v, got %v", tc.from, tc.to, tc.want, err)
+		}
+	}
+}
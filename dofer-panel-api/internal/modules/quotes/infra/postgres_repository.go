@@ -3,21 +3,31 @@ package infra
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
 
 	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/numbering"
+	"github.com/dofer/panel-api/internal/platform/outbox"
+	"github.com/dofer/panel-api/internal/platform/unitofwork"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PostgresQuoteRepository struct {
-	db *pgxpool.Pool
+	db unitofwork.DB
 }
 
 func NewPostgresQuoteRepository(db *pgxpool.Pool) *PostgresQuoteRepository {
 	return &PostgresQuoteRepository{db: db}
 }
 
+// WithTx devuelve el repositorio atado a una transacción abierta.
+func (r *PostgresQuoteRepository) WithTx(tx pgx.Tx) *PostgresQuoteRepository {
+	return &PostgresQuoteRepository{db: tx}
+}
+
+// Create guarda la cotización. Si no trae número le asigna el siguiente folio
+// de la organización en la misma transacción del INSERT.
 func (r *PostgresQuoteRepository) Create(quote *domain.Quote) error {
 	// Calcular balance inicial
 	quote.Balance = quote.Total - quote.AmountPaid
@@ -29,29 +39,52 @@ func (r *PostgresQuoteRepository) Create(quote *domain.Quote) error {
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	`
 
-	_, err := r.db.Exec(context.Background(), query,
-		quote.ID,
-		quote.OrganizationID,
-		quote.QuoteNumber,
-		quote.CustomerName,
-		quote.CustomerEmail,
-		quote.CustomerPhone,
-		quote.Status,
-		quote.Subtotal,
-		quote.Discount,
-		quote.Tax,
-		quote.Total,
-		quote.AmountPaid,
-		quote.Balance,
-		quote.Notes,
-		quote.ValidUntil,
-		quote.CreatedBy,
-	)
-
-	return err
+	ctx := context.Background()
+	quoteNumber := quote.QuoteNumber
+	err := unitofwork.Run(ctx, r.db, func(tx pgx.Tx) error {
+		if quoteNumber == "" {
+			number, err := numbering.Next(ctx, tx, quote.OrganizationID, numbering.DocumentQuote, time.Now())
+			if err != nil {
+				return err
+			}
+			quoteNumber = number
+		}
+		_, err := tx.Exec(ctx, query,
+			quote.ID,
+			quote.OrganizationID,
+			quoteNumber,
+			quote.CustomerName,
+			quote.CustomerEmail,
+			quote.CustomerPhone,
+			quote.Status,
+			quote.Subtotal,
+			quote.Discount,
+			quote.Tax,
+			quote.Total,
+			quote.AmountPaid,
+			quote.Balance,
+			quote.Notes,
+			quote.ValidUntil,
+			quote.CreatedBy,
+		)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	quote.QuoteNumber = quoteNumber
+	return nil
 }
 
 func (r *PostgresQuoteRepository) FindByID(id string, organizationID ...string) (*domain.Quote, error) {
+	return r.findByID(id, "", organizationID...)
+}
+
+func (r *PostgresQuoteRepository) FindByIDForUpdate(id, organizationID string) (*domain.Quote, error) {
+	return r.findByID(id, " FOR UPDATE", organizationID)
+}
+
+func (r *PostgresQuoteRepository) findByID(id, lock string, organizationID ...string) (*domain.Quote, error) {
 	query := `
 		SELECT id, organization_id, quote_number, customer_name, customer_email, customer_phone,
 		       status, subtotal, discount, tax, total, amount_paid, balance, notes, valid_until,
@@ -66,6 +99,7 @@ func (r *PostgresQuoteRepository) FindByID(id string, organizationID ...string)
 		query += " AND organization_id = $2"
 		args = append(args, organizationID[0])
 	}
+	query += lock
 
 	var quote domain.Quote
 	var validUntil, createdAt, updatedAt time.Time
@@ -198,29 +232,25 @@ func (r *PostgresQuoteRepository) FindAll(filters map[string]interface{}) ([]*do
 	return quotes, nil
 }
 
-func (r *PostgresQuoteRepository) Update(quote *domain.Quote) error {
+func (r *PostgresQuoteRepository) Update(quote *domain.Quote, events ...outbox.Event) error {
 	// Calcular balance
 	quote.Balance = quote.Total - quote.AmountPaid
 
+	// El estado y el pedido no se escriben aquí: solo cambian con ChangeStatus
+	// y MarkConverted, para que una edición con datos viejos no deshaga una
+	// transición ni suelte una cotización ya convertida.
 	query := `
 		UPDATE quotes
 		SET customer_name = $1, customer_email = $2, customer_phone = $3,
-		    status = $4, subtotal = $5, discount = $6, tax = $7, total = $8,
-		    amount_paid = $9, balance = $10, notes = $11, valid_until = $12, updated_at = NOW(),
-		    converted_to_order_id = $13
-		WHERE id = $14 AND organization_id = $15
+		    subtotal = $4, discount = $5, tax = $6, total = $7,
+		    amount_paid = $8, balance = $9, notes = $10, valid_until = $11, updated_at = NOW()
+		WHERE id = $12 AND organization_id = $13
 	`
 
-	var convertedToOrderID *string
-	if quote.ConvertedToOrderID != "" {
-		convertedToOrderID = &quote.ConvertedToOrderID
-	}
-
-	_, err := r.db.Exec(context.Background(), query,
+	return outbox.ExecWithEvents(context.Background(), r.db, events, query,
 		quote.CustomerName,
 		quote.CustomerEmail,
 		quote.CustomerPhone,
-		quote.Status,
 		quote.Subtotal,
 		quote.Discount,
 		quote.Tax,
@@ -229,12 +259,24 @@ func (r *PostgresQuoteRepository) Update(quote *domain.Quote) error {
 		quote.Balance,
 		quote.Notes,
 		quote.ValidUntil,
-		convertedToOrderID,
 		quote.ID,
 		quote.OrganizationID,
 	)
+}
 
-	return err
+func (r *PostgresQuoteRepository) MarkConverted(quoteID, organizationID, orderID string) error {
+	tag, err := r.db.Exec(context.Background(), `
+		UPDATE quotes
+		SET converted_to_order_id = $3, updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND converted_to_order_id IS NULL
+	`, quoteID, organizationID, orderID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrQuoteAlreadyConverted
+	}
+	return nil
 }
 
 func (r *PostgresQuoteRepository) Delete(id string, organizationID ...string) error {
@@ -254,9 +296,9 @@ func (r *PostgresQuoteRepository) AddItem(item *domain.QuoteItem) error {
 		INSERT INTO quote_items (
 			id, organization_id, quote_id, product_name, description, weight_grams, print_time_hours,
 			material_cost, labor_cost, electricity_cost, other_costs, subtotal,
-			quantity, unit_price, total
+			quantity, unit_price, total, material_name
 		)
-		SELECT $1, organization_id, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+		SELECT $1, organization_id, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NULLIF($16, '')
 		FROM quotes
 		WHERE id = $2 AND organization_id = $15
 	`
@@ -277,6 +319,7 @@ func (r *PostgresQuoteRepository) AddItem(item *domain.QuoteItem) error {
 		item.UnitPrice,
 		item.Total,
 		item.OrganizationID,
+		item.MaterialName,
 	)
 
 	return err
@@ -286,7 +329,7 @@ func (r *PostgresQuoteRepository) GetItems(quoteID, organizationID string) ([]*d
 	query := `
 		SELECT id, organization_id, quote_id, product_name, description, weight_grams, print_time_hours,
 		       material_cost, labor_cost, electricity_cost, other_costs, subtotal,
-		       quantity, unit_price, total, created_at
+		       quantity, unit_price, total, COALESCE(material_name, ''), created_at
 		FROM quote_items
 		WHERE quote_id = $1 AND organization_id = $2
 		ORDER BY created_at ASC
@@ -320,6 +363,7 @@ func (r *PostgresQuoteRepository) GetItems(quoteID, organizationID string) ([]*d
 			&item.Quantity,
 			&item.UnitPrice,
 			&item.Total,
+			&item.MaterialName,
 			&item.CreatedAt,
 		)
 
@@ -342,7 +386,7 @@ func (r *PostgresQuoteRepository) UpdateItem(item *domain.QuoteItem) error {
 		UPDATE quote_items
 		SET product_name = $1, description = $2, weight_grams = $3, print_time_hours = $4,
 		    material_cost = $5, labor_cost = $6, electricity_cost = $7, other_costs = $8,
-		    subtotal = $9, quantity = $10, unit_price = $11, total = $12
+		    subtotal = $9, quantity = $10, unit_price = $11, total = $12, material_name = NULLIF($14, '')
 		WHERE id = $13
 	`
 
@@ -360,6 +404,7 @@ func (r *PostgresQuoteRepository) UpdateItem(item *domain.QuoteItem) error {
 		item.UnitPrice,
 		item.Total,
 		item.ID,
+		item.MaterialName,
 	)
 
 	return err
@@ -384,8 +429,3 @@ func (r *PostgresQuoteRepository) DeleteQuoteItem(ctx context.Context, quoteID,
 	println("DEBUG REPO: Exec completed, rows affected:", result.RowsAffected())
 	return nil
 }
-
-// GenerateQuoteNumber genera un número de cotización único
-func GenerateQuoteNumber() string {
-	return fmt.Sprintf("COT-%s", time.Now().Format("20060102150405"))
-}
@@ -0,0 +1,111 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/outbox"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresQuoteHistoryRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresQuoteHistoryRepository(db *pgxpool.Pool) *PostgresQuoteHistoryRepository {
+	return &PostgresQuoteHistoryRepository{db: db}
+}
+
+func (r *PostgresQuoteHistoryRepository) Create(entry *domain.QuoteHistoryEntry) error {
+	return insertQuoteHistory(context.Background(), r.db, entry)
+}
+
+// insertQuoteHistory toma la organización de la propia cotización; recibe la
+// transacción abierta cuando el cambio y su historial van juntos.
+func insertQuoteHistory(ctx context.Context, db outbox.Execer, entry *domain.QuoteHistoryEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO quote_history (
+			id, organization_id, quote_id, changed_by, change_type, field_name, old_value, new_value,
+			notes, ip_address, user_agent, created_at
+		)
+		SELECT $1, organization_id, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''),
+		       NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), $11
+		FROM quotes
+		WHERE id = $2
+	`,
+		entry.ID,
+		entry.QuoteID,
+		entry.ChangedBy,
+		entry.ChangeType,
+		entry.FieldName,
+		entry.OldValue,
+		entry.NewValue,
+		entry.Notes,
+		entry.IPAddress,
+		entry.UserAgent,
+		entry.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresQuoteHistoryRepository) FindByQuoteID(quoteID, organizationID string) ([]*domain.QuoteHistoryEntry, error) {
+	query := `
+		SELECT id, organization_id, quote_id, changed_by, change_type, field_name, old_value, new_value,
+		       notes, ip_address, user_agent, created_at
+		FROM quote_history
+		WHERE quote_id = $1 AND organization_id = $2
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(context.Background(), query, quoteID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*domain.QuoteHistoryEntry{}
+	for rows.Next() {
+		var entry domain.QuoteHistoryEntry
+		var changedBy, fieldName, oldValue, newValue, notes, ipAddress, userAgent sql.NullString
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.OrganizationID,
+			&entry.QuoteID,
+			&changedBy,
+			&entry.ChangeType,
+			&fieldName,
+			&oldValue,
+			&newValue,
+			&notes,
+			&ipAddress,
+			&userAgent,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.ChangedBy = changedBy.String
+		entry.FieldName = fieldName.String
+		entry.OldValue = oldValue.String
+		entry.NewValue = newValue.String
+		entry.Notes = notes.String
+		entry.IPAddress = ipAddress.String
+		entry.UserAgent = userAgent.String
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
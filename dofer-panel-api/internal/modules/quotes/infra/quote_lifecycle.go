@@ -0,0 +1,169 @@
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/outbox"
+	"github.com/dofer/panel-api/internal/platform/unitofwork"
+	"github.com/jackc/pgx/v5"
+)
+
+const lifecycleQuoteColumns = `
+	id, organization_id, quote_number, customer_name, customer_email, customer_phone,
+	status, subtotal, discount, tax, total, amount_paid, balance, notes, valid_until,
+	created_by, created_at, updated_at,
+	COALESCE(converted_to_order_id::text, '') AS converted_to_order_id
+`
+
+func (r *PostgresQuoteRepository) ChangeStatus(quote *domain.Quote, previousStatus string, entry *domain.QuoteHistoryEntry, events ...outbox.Event) error {
+	changed, err := r.changeStatus(quote, previousStatus, entry, nil, events...)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return domain.ErrQuoteStatusChanged
+	}
+	return nil
+}
+
+// changeStatus devuelve false si la cotización ya no estaba en previousStatus.
+// Cambiar la vigencia vuelve a habilitar el aviso de vencimiento.
+func (r *PostgresQuoteRepository) changeStatus(quote *domain.Quote, previousStatus string, entry *domain.QuoteHistoryEntry, revision *domain.QuoteRevision, events ...outbox.Event) (bool, error) {
+	ctx := context.Background()
+	changed := false
+	err := unitofwork.Run(ctx, r.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE quotes
+			SET status = $3,
+			    valid_until = $5,
+			    expiry_reminder_sent_at = CASE
+			        WHEN valid_until IS DISTINCT FROM $5 THEN NULL
+			        ELSE expiry_reminder_sent_at
+			    END,
+			    updated_at = NOW()
+			WHERE id = $1 AND organization_id = $2 AND status = $4
+		`, quote.ID, quote.OrganizationID, quote.Status, previousStatus, quote.ValidUntil)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+		changed = true
+
+		if entry != nil {
+			if err := insertQuoteHistory(ctx, tx, entry); err != nil {
+				return err
+			}
+		}
+		if err := r.WithTx(tx).recordRevision(ctx, tx, revision); err != nil {
+			return err
+		}
+		return outbox.Write(ctx, tx, events...)
+	})
+	if err != nil {
+		return false, err
+	}
+	return changed, nil
+}
+
+func (r *PostgresQuoteRepository) FindOpenValidBefore(before time.Time, limit int) ([]*domain.Quote, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT `+lifecycleQuoteColumns+`
+		FROM quotes
+		WHERE status IN ('draft', 'sent') AND valid_until < $1
+		ORDER BY valid_until ASC
+		LIMIT $2
+	`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanLifecycleQuotes(rows)
+}
+
+func (r *PostgresQuoteRepository) FindPendingExpiryReminders(from, to time.Time, limit int) ([]*domain.Quote, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT `+lifecycleQuoteColumns+`
+		FROM quotes
+		WHERE status = 'sent'
+		  AND valid_until >= $1 AND valid_until < $2
+		  AND expiry_reminder_sent_at IS NULL
+		  AND COALESCE(TRIM(customer_email), '') <> ''
+		ORDER BY valid_until ASC
+		LIMIT $3
+	`, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanLifecycleQuotes(rows)
+}
+
+func (r *PostgresQuoteRepository) MarkExpiryReminderSent(quote *domain.Quote, entry *domain.QuoteHistoryEntry, events ...outbox.Event) (bool, error) {
+	ctx := context.Background()
+	marked := false
+	err := unitofwork.Run(ctx, r.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE quotes
+			SET expiry_reminder_sent_at = NOW()
+			WHERE id = $1 AND organization_id = $2 AND expiry_reminder_sent_at IS NULL
+		`, quote.ID, quote.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+		marked = true
+
+		if entry != nil {
+			if err := insertQuoteHistory(ctx, tx, entry); err != nil {
+				return err
+			}
+		}
+		return outbox.Write(ctx, tx, events...)
+	})
+	if err != nil {
+		return false, err
+	}
+	return marked, nil
+}
+
+func scanLifecycleQuotes(rows pgx.Rows) ([]*domain.Quote, error) {
+	defer rows.Close()
+
+	quotes := []*domain.Quote{}
+	for rows.Next() {
+		var quote domain.Quote
+		var customerPhone, notes sql.NullString
+		if err := rows.Scan(
+			&quote.ID,
+			&quote.OrganizationID,
+			&quote.QuoteNumber,
+			&quote.CustomerName,
+			&quote.CustomerEmail,
+			&customerPhone,
+			&quote.Status,
+			&quote.Subtotal,
+			&quote.Discount,
+			&quote.Tax,
+			&quote.Total,
+			&quote.AmountPaid,
+			&quote.Balance,
+			&notes,
+			&quote.ValidUntil,
+			&quote.CreatedBy,
+			&quote.CreatedAt,
+			&quote.UpdatedAt,
+			&quote.ConvertedToOrderID,
+		); err != nil {
+			return nil, err
+		}
+		quote.CustomerPhone = customerPhone.String
+		quote.Notes = notes.String
+		quotes = append(quotes, &quote)
+	}
+	return quotes, rows.Err()
+}
@@ -5,10 +5,11 @@ import (
 	"database/sql"
 
 	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/outbox"
 )
 
 // AddPayment agrega un pago a una cotización
-func (r *PostgresQuoteRepository) AddPayment(payment *domain.QuotePayment) error {
+func (r *PostgresQuoteRepository) AddPayment(payment *domain.QuotePayment, events ...outbox.Event) error {
 	query := `
 		INSERT INTO quote_payments (
 			id, organization_id, quote_id, amount, payment_method, payment_date, notes, created_by, created_at
@@ -18,8 +19,10 @@ func (r *PostgresQuoteRepository) AddPayment(payment *domain.QuotePayment) error
 		WHERE id = $2 AND organization_id = $9
 	`
 
-	_, err := r.db.Exec(
+	return outbox.ExecWithEvents(
 		context.Background(),
+		r.db,
+		events,
 		query,
 		payment.ID,
 		payment.QuoteID,
@@ -31,8 +34,6 @@ func (r *PostgresQuoteRepository) AddPayment(payment *domain.QuotePayment) error
 		payment.CreatedAt,
 		payment.OrganizationID,
 	)
-
-	return err
 }
 
 // GetPayments obtiene todos los pagos de una cotización
@@ -0,0 +1,65 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+)
+
+// IssuePublicLink incrementa la versión del enlace, lo que invalida los
+// tokens emitidos antes.
+func (r *PostgresQuoteRepository) IssuePublicLink(quoteID, organizationID string, expiresAt time.Time, autoConvert bool) (*domain.QuotePublicLink, error) {
+	link := domain.QuotePublicLink{QuoteID: quoteID, OrganizationID: organizationID}
+	err := r.db.QueryRow(context.Background(), `
+		UPDATE quotes
+		SET public_link_version = public_link_version + 1,
+		    public_link_expires_at = $3,
+		    public_link_auto_convert = $4,
+		    updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2
+		RETURNING public_link_version, public_link_expires_at, public_link_auto_convert
+	`, quoteID, organizationID, expiresAt, autoConvert).Scan(&link.Version, &link.ExpiresAt, &link.AutoConvert)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *PostgresQuoteRepository) RevokePublicLink(quoteID, organizationID string) error {
+	_, err := r.db.Exec(context.Background(), `
+		UPDATE quotes
+		SET public_link_version = public_link_version + 1,
+		    public_link_expires_at = NULL,
+		    public_link_auto_convert = false,
+		    updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2
+	`, quoteID, organizationID)
+	return err
+}
+
+// FindPublicLink se consulta sin organización: el token firmado ya identifica
+// la cotización.
+func (r *PostgresQuoteRepository) FindPublicLink(quoteID string) (*domain.QuotePublicLink, error) {
+	var link domain.QuotePublicLink
+	err := r.db.QueryRow(context.Background(), `
+		SELECT id::text, organization_id::text, public_link_version, public_link_expires_at, public_link_auto_convert
+		FROM quotes
+		WHERE id = $1
+	`, quoteID).Scan(&link.QuoteID, &link.OrganizationID, &link.Version, &link.ExpiresAt, &link.AutoConvert)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *PostgresQuoteRepository) RecordCustomerResponse(quote *domain.Quote, previousStatus string, entry *domain.QuoteHistoryEntry) error {
+	changed, err := r.changeStatus(quote, previousStatus, entry, nil)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return domain.ErrQuoteAlreadyAnswered
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/outbox"
+	"github.com/dofer/panel-api/internal/platform/unitofwork"
+	"github.com/jackc/pgx/v5"
+)
+
+const quoteRevisionColumns = `
+	id::text, organization_id::text, quote_id::text, revision, reason, source_revision,
+	snapshot, COALESCE(created_by, ''), created_at
+`
+
+// EditWithRevision toma la foto con la cotización bloqueada: dos revisiones
+// simultáneas no reciben el mismo número. Los borradores se editan sin dejar
+// versiones.
+func (r *PostgresQuoteRepository) EditWithRevision(quoteID, organizationID string, revision *domain.QuoteRevision, edit func(repo domain.QuoteRepository, quote *domain.Quote) error) (*domain.Quote, error) {
+	ctx := context.Background()
+	var edited *domain.Quote
+	err := unitofwork.Run(ctx, r.db, func(tx pgx.Tx) error {
+		repo := r.WithTx(tx)
+		quote, err := repo.FindByIDForUpdate(quoteID, organizationID)
+		if err != nil {
+			return err
+		}
+		if !domain.IsEditableQuote(quote) {
+			return domain.ErrQuoteNotEditable
+		}
+		if err := edit(repo, quote); err != nil {
+			return err
+		}
+		edited = quote
+
+		if quote.Status == domain.QuoteStatusDraft {
+			return nil
+		}
+		return repo.recordRevision(ctx, tx, revision)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return edited, nil
+}
+
+func (r *PostgresQuoteRepository) ChangeStatusWithRevision(quote *domain.Quote, previousStatus string, entry *domain.QuoteHistoryEntry, revision *domain.QuoteRevision, events ...outbox.Event) error {
+	changed, err := r.changeStatus(quote, previousStatus, entry, revision, events...)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return domain.ErrQuoteStatusChanged
+	}
+	return nil
+}
+
+// recordRevision guarda la foto de la cotización tal como quedó en la
+// transacción; el repositorio debe estar atado a tx. Sin revision no hace
+// nada.
+func (r *PostgresQuoteRepository) recordRevision(ctx context.Context, tx pgx.Tx, revision *domain.QuoteRevision) error {
+	if revision == nil {
+		return nil
+	}
+	quote, err := r.FindByID(revision.QuoteID, revision.OrganizationID)
+	if err != nil {
+		return err
+	}
+	items, err := r.GetItems(quote.ID, quote.OrganizationID)
+	if err != nil {
+		return err
+	}
+	_, err = insertQuoteRevision(ctx, tx, revision, domain.NewQuoteSnapshot(quote, items))
+	return err
+}
+
+// insertQuoteRevision guarda la foto con el siguiente número, salvo que sea
+// idéntica a la última revisión.
+func insertQuoteRevision(ctx context.Context, tx pgx.Tx, revision *domain.QuoteRevision, snapshot domain.QuoteSnapshot) (bool, error) {
+	encoded, err := json.Marshal(snapshot)
+	if err != nil {
+		return false, fmt.Errorf("encode quote snapshot: %w", err)
+	}
+
+	var last int
+	var lastSnapshot []byte
+	err = tx.QueryRow(ctx, `
+		SELECT revision, snapshot
+		FROM quote_revisions
+		WHERE quote_id = $1
+		ORDER BY revision DESC
+		LIMIT 1
+	`, revision.QuoteID).Scan(&last, &lastSnapshot)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, err
+	}
+	if last > 0 {
+		// JSONB reordena las claves; se compara la foto ya decodificada.
+		var previous domain.QuoteSnapshot
+		if err := json.Unmarshal(lastSnapshot, &previous); err != nil {
+			return false, fmt.Errorf("decode quote revision %d: %w", last, err)
+		}
+		previousEncoded, err := json.Marshal(previous)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(previousEncoded, encoded) {
+			return false, nil
+		}
+	}
+
+	revision.Revision = last + 1
+	revision.Snapshot = snapshot
+	err = tx.QueryRow(ctx, `
+		INSERT INTO quote_revisions (
+			organization_id, quote_id, revision, reason, source_revision, snapshot, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		RETURNING id::text, created_at
+	`,
+		revision.OrganizationID,
+		revision.QuoteID,
+		revision.Revision,
+		revision.Reason,
+		revision.SourceRevision,
+		encoded,
+		revision.CreatedBy,
+	).Scan(&revision.ID, &revision.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *PostgresQuoteRepository) ListRevisions(quoteID, organizationID string) ([]*domain.QuoteRevision, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT `+quoteRevisionColumns+`
+		FROM quote_revisions
+		WHERE quote_id = $1 AND organization_id = $2
+		ORDER BY revision DESC
+	`, quoteID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*domain.QuoteRevision{}
+	for rows.Next() {
+		revision, err := scanQuoteRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+func (r *PostgresQuoteRepository) FindRevision(quoteID, organizationID string, number int) (*domain.QuoteRevision, error) {
+	revision, err := scanQuoteRevision(r.db.QueryRow(context.Background(), `
+		SELECT `+quoteRevisionColumns+`
+		FROM quote_revisions
+		WHERE quote_id = $1 AND organization_id = $2 AND revision = $3
+	`, quoteID, organizationID, number))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrRevisionNotFound
+	}
+	return revision, err
+}
+
+// RestoreRevision conserva los IDs y el orden de los items de la foto para
+// que las comparaciones posteriores los reconozcan. La vigencia, el estado y
+// los pagos no cambian.
+func (r *PostgresQuoteRepository) RestoreRevision(quote *domain.Quote, snapshot domain.QuoteSnapshot, revision *domain.QuoteRevision) error {
+	ctx := context.Background()
+	return unitofwork.Run(ctx, r.db, func(tx pgx.Tx) error {
+		repo := r.WithTx(tx)
+		current, err := repo.FindByIDForUpdate(quote.ID, quote.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if !domain.IsEditableQuote(current) {
+			return domain.ErrQuoteNotEditable
+		}
+
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM quote_items WHERE quote_id = $1 AND organization_id = $2
+		`, current.ID, current.OrganizationID); err != nil {
+			return err
+		}
+		for _, item := range snapshot.Items {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO quote_items (
+					id, organization_id, quote_id, product_name, description, weight_grams, print_time_hours,
+					material_cost, labor_cost, electricity_cost, other_costs, subtotal,
+					quantity, unit_price, total, material_name, created_at
+				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NULLIF($16, ''), $17)
+			`,
+				item.ID,
+				current.OrganizationID,
+				current.ID,
+				item.ProductName,
+				item.Description,
+				item.WeightGrams,
+				item.PrintTimeHours,
+				item.MaterialCost,
+				item.LaborCost,
+				item.ElectricityCost,
+				item.OtherCosts,
+				item.Subtotal,
+				item.Quantity,
+				item.UnitPrice,
+				item.Total,
+				item.MaterialName,
+				item.CreatedAt,
+			); err != nil {
+				return err
+			}
+		}
+
+		current.CustomerName = snapshot.CustomerName
+		current.CustomerEmail = snapshot.CustomerEmail
+		current.CustomerPhone = snapshot.CustomerPhone
+		current.Notes = snapshot.Notes
+		current.Subtotal = snapshot.Subtotal
+		current.Discount = snapshot.Discount
+		current.Tax = snapshot.Tax
+		current.Total = snapshot.Total
+		if err := repo.Update(current); err != nil {
+			return err
+		}
+		*quote = *current
+
+		if revision == nil {
+			return nil
+		}
+		items, err := repo.GetItems(current.ID, current.OrganizationID)
+		if err != nil {
+			return err
+		}
+		_, err = insertQuoteRevision(ctx, tx, revision, domain.NewQuoteSnapshot(current, items))
+		return err
+	})
+}
+
+func scanQuoteRevision(row pgx.Row) (*domain.QuoteRevision, error) {
+	var revision domain.QuoteRevision
+	var snapshot []byte
+	if err := row.Scan(
+		&revision.ID,
+		&revision.OrganizationID,
+		&revision.QuoteID,
+		&revision.Revision,
+		&revision.Reason,
+		&revision.SourceRevision,
+		&snapshot,
+		&revision.CreatedBy,
+		&revision.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshot, &revision.Snapshot); err != nil {
+		return nil, fmt.Errorf("decode quote revision %d: %w", revision.Revision, err)
+	}
+	return &revision, nil
+}
@@ -0,0 +1,38 @@
+package infra
+
+import (
+	"context"
+
+	ordersDomain "github.com/dofer/panel-api/internal/modules/orders/domain"
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/dofer/panel-api/internal/platform/unitofwork"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TxOrderRepository es el repositorio de pedidos que puede atarse a una
+// transacción abierta.
+type TxOrderRepository interface {
+	WithTx(tx pgx.Tx) ordersDomain.OrderRepository
+}
+
+// PostgresUnitOfWork comparte una transacción entre el repositorio de
+// cotizaciones y el de pedidos.
+type PostgresUnitOfWork struct {
+	db     *pgxpool.Pool
+	quotes *PostgresQuoteRepository
+	orders TxOrderRepository
+}
+
+func NewPostgresUnitOfWork(db *pgxpool.Pool, quotes *PostgresQuoteRepository, orders TxOrderRepository) *PostgresUnitOfWork {
+	return &PostgresUnitOfWork{db: db, quotes: quotes, orders: orders}
+}
+
+func (u *PostgresUnitOfWork) Do(ctx context.Context, fn func(repos domain.ConversionRepositories) error) error {
+	return unitofwork.Run(ctx, u.db, func(tx pgx.Tx) error {
+		return fn(domain.ConversionRepositories{
+			Quotes: u.quotes.WithTx(tx),
+			Orders: u.orders.WithTx(tx),
+		})
+	})
+}
@@ -2,13 +2,18 @@ package transport
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	costsDomain "github.com/dofer/panel-api/internal/modules/costs/domain"
 	"github.com/dofer/panel-api/internal/modules/quotes/app"
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
 	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
+	"github.com/dofer/panel-api/internal/platform/slicefile"
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
 )
@@ -31,6 +36,12 @@ type QuoteHandler struct {
 	listTemplateHandler   *app.ListQuoteTemplatesHandler
 	updateTemplateHandler *app.UpdateQuoteTemplateHandler
 	deleteTemplateHandler *app.DeleteQuoteTemplateHandler
+	addItemFromFile       *app.AddQuoteItemFromFileHandler
+	renderPDFHandler      *app.RenderQuotePDFHandler
+	createPublicLink      *app.CreateQuotePublicLinkHandler
+	revokePublicLink      *app.RevokeQuotePublicLinkHandler
+	historyHandler        *app.GetQuoteHistoryHandler
+	revisionsHandler      *app.QuoteRevisionsHandler
 }
 
 func NewQuoteHandler(
@@ -51,6 +62,12 @@ func NewQuoteHandler(
 	listTemplateHandler *app.ListQuoteTemplatesHandler,
 	updateTemplateHandler *app.UpdateQuoteTemplateHandler,
 	deleteTemplateHandler *app.DeleteQuoteTemplateHandler,
+	addItemFromFile *app.AddQuoteItemFromFileHandler,
+	renderPDFHandler *app.RenderQuotePDFHandler,
+	createPublicLink *app.CreateQuotePublicLinkHandler,
+	revokePublicLink *app.RevokeQuotePublicLinkHandler,
+	historyHandler *app.GetQuoteHistoryHandler,
+	revisionsHandler *app.QuoteRevisionsHandler,
 ) *QuoteHandler {
 	return &QuoteHandler{
 		createHandler:         createHandler,
@@ -70,6 +87,12 @@ func NewQuoteHandler(
 		listTemplateHandler:   listTemplateHandler,
 		updateTemplateHandler: updateTemplateHandler,
 		deleteTemplateHandler: deleteTemplateHandler,
+		addItemFromFile:       addItemFromFile,
+		renderPDFHandler:      renderPDFHandler,
+		createPublicLink:      createPublicLink,
+		revokePublicLink:      revokePublicLink,
+		historyHandler:        historyHandler,
+		revisionsHandler:      revisionsHandler,
 	}
 }
 
@@ -92,8 +115,19 @@ type AddQuoteItemRequest struct {
 	UnitPrice      *float64 `json:"unit_price"` // Precio personalizado (opcional)
 }
 
+// maxSliceUploadBytes limita los G-code subidos; los de piezas grandes rara
+// vez pasan de 100 MB.
+const maxSliceUploadBytes = 200 << 20
+
+type CreatePublicLinkRequest struct {
+	ExpiresInDays int  `json:"expires_in_days"` // 0 = hasta la vigencia de la cotización
+	AutoConvert   bool `json:"auto_convert"`    // convertir en orden al aceptar
+}
+
 type UpdateQuoteStatusRequest struct {
-	Status string `json:"status"`
+	Status    string `json:"status"`               // draft (reabrir), sent, approved, rejected, expired
+	ValidDays *int   `json:"valid_days,omitempty"` // nueva vigencia desde hoy, p. ej. al reabrir
+	Notes     string `json:"notes"`
 }
 
 type UpdateQuoteRequest struct {
@@ -187,6 +221,183 @@ func (h *QuoteHandler) GetQuote(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// GetQuotePDF entrega la cotización como PDF con la marca de la organización.
+func (h *QuoteHandler) GetQuotePDF(w http.ResponseWriter, r *http.Request) {
+	quoteID := chi.URLParam(r, "id")
+
+	document, filename, err := h.renderPDFHandler.Handle(r.Context(), quoteID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "quote not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	w.Write(document)
+}
+
+// CreatePublicLink emite el enlace firmado que se comparte con el cliente.
+func (h *QuoteHandler) CreatePublicLink(w http.ResponseWriter, r *http.Request) {
+	quoteID := chi.URLParam(r, "id")
+
+	var req CreatePublicLinkRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	link, err := h.createPublicLink.Handle(r.Context(), app.CreateQuotePublicLinkCommand{
+		QuoteID:       quoteID,
+		ExpiresInDays: req.ExpiresInDays,
+		AutoConvert:   req.AutoConvert,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.Error(w, "quote not found", http.StatusNotFound)
+		case errors.Is(err, app.ErrPublicLinksDisabled):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+func (h *QuoteHandler) RevokePublicLink(w http.ResponseWriter, r *http.Request) {
+	quoteID := chi.URLParam(r, "id")
+
+	if err := h.revokePublicLink.Handle(r.Context(), quoteID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "quote not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *QuoteHandler) GetQuoteHistory(w http.ResponseWriter, r *http.Request) {
+	quoteID := chi.URLParam(r, "id")
+
+	entries, err := h.historyHandler.Handle(r.Context(), quoteID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "quote not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"history": entries,
+		"total":   len(entries),
+	})
+}
+
+func (h *QuoteHandler) ListQuoteRevisions(w http.ResponseWriter, r *http.Request) {
+	revisions, err := h.revisionsHandler.List(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeQuoteEditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"revisions": revisions,
+		"total":     len(revisions),
+	})
+}
+
+func (h *QuoteHandler) GetQuoteRevision(w http.ResponseWriter, r *http.Request) {
+	number, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil || number < 1 {
+		http.Error(w, "invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	revision, err := h.revisionsHandler.Get(r.Context(), chi.URLParam(r, "id"), number)
+	if err != nil {
+		writeQuoteEditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revision)
+}
+
+// CompareQuoteRevisions compara ?from= contra ?to=; sin to compara contra la
+// cotización actual.
+func (h *QuoteHandler) CompareQuoteRevisions(w http.ResponseWriter, r *http.Request) {
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil || from < 1 {
+		http.Error(w, "from must be a revision number", http.StatusBadRequest)
+		return
+	}
+	to := 0
+	if raw := r.URL.Query().Get("to"); raw != "" && raw != "current" {
+		if to, err = strconv.Atoi(raw); err != nil || to < 1 {
+			http.Error(w, "to must be a revision number or current", http.StatusBadRequest)
+			return
+		}
+	}
+
+	diff, err := h.revisionsHandler.Compare(r.Context(), chi.URLParam(r, "id"), from, to)
+	if err != nil {
+		writeQuoteEditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+func (h *QuoteHandler) RestoreQuoteRevision(w http.ResponseWriter, r *http.Request) {
+	number, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil || number < 1 {
+		http.Error(w, "invalid revision", http.StatusBadRequest)
+		return
+	}
+
+	quote, err := h.revisionsHandler.Restore(r.Context(), chi.URLParam(r, "id"), number)
+	if err != nil {
+		writeQuoteEditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quote)
+}
+
+// writeQuoteEditError responde los errores de editar una cotización o de
+// consultar y restaurar sus revisiones.
+func writeQuoteEditError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "quote not found", http.StatusNotFound)
+	case errors.Is(err, domain.ErrRevisionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrQuoteNotEditable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func (h *QuoteHandler) ListQuotes(w http.ResponseWriter, r *http.Request) {
 	quotes, err := h.listHandler.Handle(r.Context())
 	if err != nil {
@@ -224,8 +435,8 @@ func (h *QuoteHandler) AddQuoteItem(w http.ResponseWriter, r *http.Request) {
 		CustomPrice:    req.UnitPrice, // Pasar precio personalizado si existe
 	}
 
-	if err := h.addItemHandler.Handle(r.Context(), cmd); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if _, err := h.addItemHandler.Handle(r.Context(), cmd); err != nil {
+		writeQuoteEditError(w, err)
 		return
 	}
 
@@ -233,6 +444,89 @@ func (h *QuoteHandler) AddQuoteItem(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Item added successfully"})
 }
 
+// AddQuoteItemFromFile recibe un G-code, 3MF o STL (multipart, campo "file")
+// y crea el item con el tiempo y filamento del slicer o estimados de la malla.
+func (h *QuoteHandler) AddQuoteItemFromFile(w http.ResponseWriter, r *http.Request) {
+	quoteID := chi.URLParam(r, "id")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSliceUploadBytes)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, "invalid multipart upload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	cmd := app.AddQuoteItemFromFileCommand{
+		QuoteID:      quoteID,
+		Filename:     header.Filename,
+		File:         file,
+		ProductName:  r.FormValue("product_name"),
+		Description:  r.FormValue("description"),
+		MaterialName: strings.TrimSpace(r.FormValue("material_name")),
+	}
+	if value := r.FormValue("quantity"); value != "" {
+		if cmd.Quantity, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "invalid quantity", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := r.FormValue("other_costs"); value != "" {
+		if cmd.OtherCosts, err = strconv.ParseFloat(value, 64); err != nil {
+			http.Error(w, "invalid other_costs", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := r.FormValue("unit_price"); value != "" {
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			http.Error(w, "invalid unit_price", http.StatusBadRequest)
+			return
+		}
+		cmd.CustomPrice = &price
+	}
+	cmd.TemplateID = r.FormValue("template_id")
+	if value := r.FormValue("infill_percentage"); value != "" {
+		if cmd.InfillPercentage, err = strconv.ParseFloat(value, 64); err != nil {
+			http.Error(w, "invalid infill_percentage", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := r.FormValue("layer_height"); value != "" {
+		if cmd.LayerHeight, err = strconv.ParseFloat(value, 64); err != nil {
+			http.Error(w, "invalid layer_height", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.addItemFromFile.Handle(r.Context(), cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, slicefile.ErrUnsupportedFormat), errors.Is(err, slicefile.ErrNoSliceData), errors.Is(err, slicefile.ErrInvalidSTL):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, app.ErrQuoteTemplateNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, pgx.ErrNoRows):
+			http.Error(w, "quote not found", http.StatusNotFound)
+		case errors.Is(err, domain.ErrQuoteNotEditable):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, costsDomain.ErrMaterialNotFound):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
 func (h *QuoteHandler) UpdateQuoteStatus(w http.ResponseWriter, r *http.Request) {
 	quoteID := chi.URLParam(r, "id")
 
@@ -245,15 +539,36 @@ func (h *QuoteHandler) UpdateQuoteStatus(w http.ResponseWriter, r *http.Request)
 	cmd := app.UpdateQuoteStatusCommand{
 		QuoteID: quoteID,
 		Status:  req.Status,
+		Notes:   req.Notes,
+	}
+	if req.ValidDays != nil {
+		if *req.ValidDays <= 0 {
+			http.Error(w, "valid_days must be greater than zero", http.StatusBadRequest)
+			return
+		}
+		validUntil := time.Now().AddDate(0, 0, *req.ValidDays)
+		cmd.ValidUntil = &validUntil
 	}
 
-	if err := h.updateStatusHandler.Handle(r.Context(), cmd); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	quote, err := h.updateStatusHandler.Handle(r.Context(), cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.Error(w, "quote not found", http.StatusNotFound)
+		case errors.Is(err, domain.ErrInvalidQuoteStatus):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, app.ErrQuoteValidityExpired):
+			http.Error(w, "quote is past its validity date, send valid_days to extend it", http.StatusConflict)
+		case errors.Is(err, domain.ErrInvalidQuoteTransition), errors.Is(err, domain.ErrQuoteStatusChanged):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Status updated successfully"})
+	json.NewEncoder(w).Encode(quote)
 }
 
 func (h *QuoteHandler) UpdateQuote(w http.ResponseWriter, r *http.Request) {
@@ -275,7 +590,7 @@ func (h *QuoteHandler) UpdateQuote(w http.ResponseWriter, r *http.Request) {
 
 	quote, err := h.updateHandler.Handle(r.Context(), cmd)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeQuoteEditError(w, err)
 		return
 	}
 
@@ -301,7 +616,7 @@ func (h *QuoteHandler) DeleteQuoteItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.deleteItemHandler.Handle(r.Context(), cmd); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeQuoteEditError(w, err)
 		return
 	}
 
@@ -367,15 +682,7 @@ func (h *QuoteHandler) ConvertToOrder(w http.ResponseWriter, r *http.Request) {
 
 	order, err := h.convertToOrderHandler.Handle(r.Context(), cmd)
 	if err != nil {
-		if err == app.ErrQuoteNotApproved {
-			http.Error(w, "La cotización debe estar aprobada para convertirla en pedido", http.StatusBadRequest)
-			return
-		}
-		if err == app.ErrQuoteNoItems {
-			http.Error(w, "La cotización debe tener al menos un item", http.StatusBadRequest)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeConversionError(w, err)
 		return
 	}
 
@@ -432,9 +739,8 @@ func (h *QuoteHandler) SyncItemsToOrder(w http.ResponseWriter, r *http.Request)
 		QuoteID: quoteID,
 	}
 
-	err := h.syncItemsHandler.Handle(r.Context(), cmd)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.syncItemsHandler.Handle(r.Context(), cmd); err != nil {
+		writeConversionError(w, err)
 		return
 	}
 
@@ -444,6 +750,43 @@ func (h *QuoteHandler) SyncItemsToOrder(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// writeConversionError responde con un código estable para que el panel
+// distinga por qué no se pudo convertir o sincronizar la cotización. Si falló
+// una escritura también indica el paso; para entonces la transacción ya se
+// revirtió.
+func writeConversionError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	body := map[string]string{"code": "internal_error", "error": "No se pudo convertir la cotización en pedido"}
+	var conversionErr *app.ConversionError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		status = http.StatusNotFound
+		body["code"], body["error"] = "quote_not_found", "Cotización no encontrada"
+	case errors.Is(err, app.ErrQuoteNotApproved):
+		status = http.StatusBadRequest
+		body["code"], body["error"] = "quote_not_approved", "La cotización debe estar aprobada para convertirla en pedido"
+	case errors.Is(err, app.ErrQuoteNoItems):
+		status = http.StatusBadRequest
+		body["code"], body["error"] = "quote_without_items", "La cotización debe tener al menos un item"
+	case errors.Is(err, domain.ErrQuoteAlreadyConverted):
+		status = http.StatusConflict
+		body["code"], body["error"] = "quote_already_converted", "La cotización ya fue convertida en pedido"
+	case errors.Is(err, app.ErrQuoteNotConverted):
+		status = http.StatusConflict
+		body["code"], body["error"] = "quote_not_converted", "La cotización todavía no tiene pedido"
+	case errors.Is(err, app.ErrOrderAlreadyHasItems):
+		status = http.StatusConflict
+		body["code"], body["error"] = "order_has_items", "El pedido ya tiene items"
+	case errors.As(err, &conversionErr):
+		body["code"], body["step"] = "conversion_failed", conversionErr.Step
+		body["error"] = fmt.Sprintf("No se pudo convertir la cotización (%s); no se guardó ningún cambio", conversionErr.Step)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
 func (h *QuoteHandler) ListQuoteTemplates(w http.ResponseWriter, r *http.Request) {
 	templates, err := h.listTemplateHandler.Handle(r.Context())
 	if err != nil {
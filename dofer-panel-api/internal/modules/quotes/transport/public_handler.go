@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/dofer/panel-api/internal/modules/quotes/app"
+	"github.com/dofer/panel-api/internal/modules/quotes/domain"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const maxPublicResponseBytes = 16 << 10
+
+type PublicQuoteHandler struct {
+	handler *app.PublicQuoteHandler
+}
+
+func NewPublicQuoteHandler(handler *app.PublicQuoteHandler) *PublicQuoteHandler {
+	return &PublicQuoteHandler{handler: handler}
+}
+
+type PublicQuoteResponseRequest struct {
+	Name string `json:"name"` // nombre escrito por el cliente como firma
+	Note string `json:"note"` // nota de anticipo o motivo del rechazo
+}
+
+func (h *PublicQuoteHandler) GetQuote(w http.ResponseWriter, r *http.Request) {
+	view, err := h.handler.View(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		writePublicQuoteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+func (h *PublicQuoteHandler) GetQuotePDF(w http.ResponseWriter, r *http.Request) {
+	document, filename, err := h.handler.RenderPDF(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		writePublicQuoteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	w.Write(document)
+}
+
+func (h *PublicQuoteHandler) AcceptQuote(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, true)
+}
+
+func (h *PublicQuoteHandler) RejectQuote(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, false)
+}
+
+func (h *PublicQuoteHandler) respond(w http.ResponseWriter, r *http.Request, accept bool) {
+	var req PublicQuoteResponseRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPublicResponseBytes)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.handler.Respond(r.Context(), app.RespondToQuoteCommand{
+		Token:     chi.URLParam(r, "token"),
+		Accept:    accept,
+		Name:      req.Name,
+		Note:      req.Note,
+		IPAddress: clientIP(r),
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		writePublicQuoteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// clientIP usa RemoteAddr, que el middleware RealIP ya tomó de los
+// encabezados del proxy.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func writePublicQuoteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrInvalidPublicLink), errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "quote link not found", http.StatusNotFound)
+	case errors.Is(err, app.ErrPublicLinkExpired):
+		http.Error(w, err.Error(), http.StatusGone)
+	case errors.Is(err, app.ErrSignatureNameRequired), errors.Is(err, app.ErrResponseTooLong):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, app.ErrQuoteNotOpen), errors.Is(err, app.ErrQuoteValidityExpired), errors.Is(err, domain.ErrQuoteAlreadyAnswered):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, app.ErrPublicLinksDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "could not process quote link", http.StatusInternalServerError)
+	}
+}
@@ -25,6 +25,14 @@ func RegisterRoutes(r chi.Router, handler *QuoteHandler) {
 		// Rutas anidadas con {id}
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", handler.GetQuote)
+			r.Get("/pdf", handler.GetQuotePDF)
+			r.Get("/history", handler.GetQuoteHistory)
+			r.Get("/revisions", handler.ListQuoteRevisions)
+			r.Get("/revisions/compare", handler.CompareQuoteRevisions)
+			r.Get("/revisions/{revision}", handler.GetQuoteRevision)
+			r.Post("/revisions/{revision}/restore", handler.RestoreQuoteRevision)
+			r.Post("/public-link", handler.CreatePublicLink)
+			r.Delete("/public-link", handler.RevokePublicLink)
 			r.Delete("/", handler.DeleteQuote)
 			r.Patch("/", handler.UpdateQuote)
 			r.Patch("/status", handler.UpdateQuoteStatus)
@@ -34,7 +42,19 @@ func RegisterRoutes(r chi.Router, handler *QuoteHandler) {
 
 			// Items dentro de quote
 			r.Post("/items", handler.AddQuoteItem)
+			r.Post("/items/from-file", handler.AddQuoteItemFromFile)
 			r.Delete("/items/{itemId}", handler.DeleteQuoteItem)
 		})
 	})
 }
+
+// RegisterPublicRoutes expone el enlace de la cotización sin autenticación;
+// el token firmado es la única credencial.
+func RegisterPublicRoutes(r chi.Router, handler *PublicQuoteHandler) {
+	r.Route("/public/quotes/{token}", func(r chi.Router) {
+		r.Get("/", handler.GetQuote)
+		r.Get("/pdf", handler.GetQuotePDF)
+		r.Post("/accept", handler.AcceptQuote)
+		r.Post("/reject", handler.RejectQuote)
+	})
+}
@@ -9,20 +9,34 @@ import (
 )
 
 type TrackingHandler struct {
-	orderRepo domain.OrderRepository
+	orderRepo    domain.OrderRepository
+	workflowRepo domain.WorkflowRepository
 }
 
-func NewTrackingHandler(orderRepo domain.OrderRepository) *TrackingHandler {
-	return &TrackingHandler{orderRepo: orderRepo}
+func NewTrackingHandler(orderRepo domain.OrderRepository, workflowRepo domain.WorkflowRepository) *TrackingHandler {
+	return &TrackingHandler{orderRepo: orderRepo, workflowRepo: workflowRepo}
 }
 
 type PublicOrderResponse struct {
-	OrderNumber  string `json:"order_number"`
-	Status       string `json:"status"`
-	CustomerName string `json:"customer_name"`
-	ProductName  string `json:"product_name"`
-	Quantity     int    `json:"quantity"`
-	CreatedAt    string `json:"created_at"`
+	OrderNumber  string        `json:"order_number"`
+	Status       string        `json:"status"`
+	StatusLabel  string        `json:"status_label"`
+	Completed    bool          `json:"completed"`
+	Cancelled    bool          `json:"cancelled"`
+	Stages       []PublicStage `json:"stages"`
+	CustomerName string        `json:"customer_name"`
+	ProductName  string        `json:"product_name"`
+	Quantity     int           `json:"quantity"`
+	CreatedAt    string        `json:"created_at"`
+}
+
+// PublicStage es un paso de la línea de tiempo que ve el cliente, según el
+// flujo de la organización.
+type PublicStage struct {
+	Key     string `json:"key"`
+	Label   string `json:"label"`
+	Reached bool   `json:"reached"`
+	Current bool   `json:"current"`
 }
 
 func (h *TrackingHandler) GetPublicOrder(w http.ResponseWriter, r *http.Request) {
@@ -34,9 +48,25 @@ func (h *TrackingHandler) GetPublicOrder(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	workflow, err := h.workflowRepo.FindByOrganization(order.OrganizationID)
+	if err != nil {
+		http.Error(w, "failed to load order workflow", http.StatusInternalServerError)
+		return
+	}
+
+	current, _ := workflow.Stage(order.Status)
+	statusLabel := current.Label
+	if statusLabel == "" {
+		statusLabel = string(order.Status)
+	}
+
 	response := PublicOrderResponse{
 		OrderNumber:  order.OrderNumber,
 		Status:       string(order.Status),
+		StatusLabel:  statusLabel,
+		Completed:    current.Completes,
+		Cancelled:    current.Cancels,
+		Stages:       publicStages(workflow, order.Status),
 		CustomerName: order.CustomerName,
 		ProductName:  order.ProductName,
 		Quantity:     order.Quantity,
@@ -47,6 +77,25 @@ func (h *TrackingHandler) GetPublicOrder(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
+// publicStages arma la línea de tiempo sin las etapas de cancelación; las
+// etapas anteriores a la actual en el flujo se marcan como alcanzadas.
+func publicStages(workflow *domain.Workflow, status domain.OrderStatus) []PublicStage {
+	currentIndex := workflow.StageIndex(status)
+	stages := make([]PublicStage, 0, len(workflow.Stages))
+	for i, stage := range workflow.Stages {
+		if stage.Cancels {
+			continue
+		}
+		stages = append(stages, PublicStage{
+			Key:     stage.Key,
+			Label:   stage.Label,
+			Reached: currentIndex >= 0 && i <= currentIndex,
+			Current: i == currentIndex,
+		})
+	}
+	return stages
+}
+
 func RegisterRoutes(r chi.Router, handler *TrackingHandler) {
 	r.Route("/public", func(r chi.Router) {
 		r.Get("/orders/{public_id}", handler.GetPublicOrder)
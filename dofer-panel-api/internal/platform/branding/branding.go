@@ -0,0 +1,210 @@
+// Package branding guarda los datos de marca de cada organización (logo,
+// color, datos fiscales y de contacto) que encabezan sus documentos PDF.
+package branding
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+
+	"github.com/dofer/panel-api/internal/platform/pdf"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	maxLogoBytes     = 500_000
+	maxLogoDimension = 1024
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrInvalidLogo          = errors.New("logo must be a PNG or JPEG data URL up to 500 KB and 1024x1024 px")
+	ErrInvalidColor         = errors.New("brand_color must be a hex color like #4F46E5")
+)
+
+type Branding struct {
+	OrganizationID string  `json:"organization_id"`
+	Name           string  `json:"name"`
+	LogoDataURL    *string `json:"logo_data_url,omitempty"`
+	BrandColor     *string `json:"brand_color,omitempty"`
+	LegalName      *string `json:"legal_name,omitempty"`
+	TaxID          *string `json:"tax_id,omitempty"`
+	Address        *string `json:"address,omitempty"`
+	ContactPhone   *string `json:"contact_phone,omitempty"`
+	ContactEmail   *string `json:"contact_email,omitempty"`
+	DocumentFooter *string `json:"document_footer,omitempty"`
+}
+
+// UpdateRequest reemplaza todos los datos de marca; un campo vacío lo borra.
+type UpdateRequest struct {
+	LogoDataURL    *string `json:"logo_data_url"`
+	BrandColor     *string `json:"brand_color"`
+	LegalName      *string `json:"legal_name"`
+	TaxID          *string `json:"tax_id"`
+	Address        *string `json:"address"`
+	ContactPhone   *string `json:"contact_phone"`
+	ContactEmail   *string `json:"contact_email"`
+	DocumentFooter *string `json:"document_footer"`
+}
+
+// Reader es lo que necesitan los módulos que generan documentos.
+type Reader interface {
+	Get(ctx context.Context, organizationID string) (*Branding, error)
+}
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Get(ctx context.Context, organizationID string) (*Branding, error) {
+	var branding Branding
+	err := r.db.QueryRow(ctx, `
+		SELECT id::text, name, logo_data_url, brand_color, legal_name, tax_id, address,
+		       contact_phone, contact_email, document_footer
+		FROM organizations
+		WHERE id::text = $1
+	`, organizationID).Scan(
+		&branding.OrganizationID,
+		&branding.Name,
+		&branding.LogoDataURL,
+		&branding.BrandColor,
+		&branding.LegalName,
+		&branding.TaxID,
+		&branding.Address,
+		&branding.ContactPhone,
+		&branding.ContactEmail,
+		&branding.DocumentFooter,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &branding, nil
+}
+
+func (r *Repository) Update(ctx context.Context, organizationID string, req UpdateRequest) (*Branding, error) {
+	logo := sanitize(req.LogoDataURL)
+	if logo != nil {
+		if _, err := decodeLogo(*logo); err != nil {
+			return nil, err
+		}
+	}
+	color := sanitize(req.BrandColor)
+	if color != nil {
+		if _, ok := pdf.ParseHexColor(*color); !ok {
+			return nil, ErrInvalidColor
+		}
+		normalized := "#" + strings.ToUpper(strings.TrimPrefix(*color, "#"))
+		color = &normalized
+	}
+
+	tag, err := r.db.Exec(ctx, `
+		UPDATE organizations
+		SET logo_data_url = $2,
+		    brand_color = $3,
+		    legal_name = $4,
+		    tax_id = $5,
+		    address = $6,
+		    contact_phone = $7,
+		    contact_email = $8,
+		    document_footer = $9,
+		    updated_at = NOW()
+		WHERE id::text = $1
+	`, organizationID, logo, color, sanitize(req.LegalName), sanitize(req.TaxID), sanitize(req.Address),
+		sanitize(req.ContactPhone), sanitize(req.ContactEmail), sanitize(req.DocumentFooter))
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrOrganizationNotFound
+	}
+
+	return r.Get(ctx, organizationID)
+}
+
+// PDFBrand arma el encabezado del PDF. Un logo que ya no se puede leer se
+// omite en lugar de impedir el documento.
+func (b *Branding) PDFBrand() pdf.Brand {
+	brand := pdf.Brand{
+		Name:      b.Name,
+		LegalName: value(b.LegalName),
+		TaxID:     value(b.TaxID),
+		Address:   value(b.Address),
+		Phone:     value(b.ContactPhone),
+		Email:     value(b.ContactEmail),
+	}
+	if b.LogoDataURL != nil {
+		if logo, err := decodeLogo(*b.LogoDataURL); err == nil {
+			brand.Logo = logo
+		}
+	}
+	if b.BrandColor != nil {
+		if color, ok := pdf.ParseHexColor(*b.BrandColor); ok {
+			brand.Color = &color
+		}
+	}
+	return brand
+}
+
+// Footer devuelve el pie configurado o fallback.
+func (b *Branding) Footer(fallback string) string {
+	if footer := value(b.DocumentFooter); footer != "" {
+		return footer
+	}
+	return fallback
+}
+
+func decodeLogo(dataURL string) (image.Image, error) {
+	parts := strings.SplitN(dataURL, ",", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidLogo
+	}
+	switch parts[0] {
+	case "data:image/png;base64", "data:image/jpeg;base64", "data:image/jpg;base64":
+	default:
+		return nil, ErrInvalidLogo
+	}
+	decoded, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil || len(decoded) == 0 || len(decoded) > maxLogoBytes {
+		return nil, ErrInvalidLogo
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(decoded))
+	if err != nil || config.Width > maxLogoDimension || config.Height > maxLogoDimension {
+		return nil, ErrInvalidLogo
+	}
+	logo, _, err := image.Decode(bytes.NewReader(decoded))
+	if err != nil {
+		return nil, ErrInvalidLogo
+	}
+	return logo, nil
+}
+
+func sanitize(raw *string) *string {
+	if raw == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*raw)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func value(raw *string) string {
+	if raw == nil {
+		return ""
+	}
+	return strings.TrimSpace(*raw)
+}
@@ -23,6 +23,16 @@ type Config struct {
 	GoogleInventorySheet   string
 	GoogleSalesSheet       string
 	BazarTimezone          string
+	MailerDriver           string
+	SMTPHost               string
+	SMTPPort               string
+	SMTPUsername           string
+	SMTPPassword           string
+	SMTPFrom               string
+	SMTPFromName           string
+	SMTPTLSMode            string
+	QuoteLinkSecret        string
+	SecretsEncryptionKey   string
 }
 
 func Load() (*Config, error) {
@@ -62,8 +72,21 @@ func Load() (*Config, error) {
 		GoogleInventorySheet:   getEnv("GOOGLE_INVENTORY_SHEET_NAME", "Inventario"),
 		GoogleSalesSheet:       getEnv("GOOGLE_SALES_SHEET_NAME", "Ventas"),
 		BazarTimezone:          getEnv("BAZAR_TIMEZONE", "America/Mexico_City"),
+		MailerDriver:           strings.ToLower(strings.TrimSpace(getEnv("MAILER_DRIVER", "console"))),
+		SMTPHost:               strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		SMTPPort:               getEnv("SMTP_PORT", "587"),
+		SMTPUsername:           strings.TrimSpace(getEnv("SMTP_USERNAME", os.Getenv("SMTP_USER"))),
+		SMTPPassword:           os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:               strings.TrimSpace(os.Getenv("SMTP_FROM")),
+		SMTPFromName:           getEnv("SMTP_FROM_NAME", "DOFER"),
+		SMTPTLSMode:            strings.ToLower(strings.TrimSpace(getEnv("SMTP_TLS_MODE", "starttls"))),
+		SecretsEncryptionKey:   strings.TrimSpace(os.Getenv("SECRETS_ENCRYPTION_KEY")),
 	}
 
+	// Los enlaces públicos de cotizaciones se firman con su propia clave, sin
+	// reutilizar otros secretos del servidor; si falta quedan desactivados.
+	cfg.QuoteLinkSecret = strings.TrimSpace(os.Getenv("QUOTE_LINK_SECRET"))
+
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
@@ -89,6 +112,21 @@ func (c *Config) validate() error {
 		return fmt.Errorf("unsupported JWT_VALIDATION_MODE: %s", c.JWTValidationMode)
 	}
 
+	switch c.MailerDriver {
+	case "console":
+	case "smtp":
+		if c.SMTPHost == "" || c.SMTPFrom == "" {
+			return fmt.Errorf("SMTP_HOST and SMTP_FROM are required when MAILER_DRIVER=smtp")
+		}
+		switch c.SMTPTLSMode {
+		case "starttls", "tls", "none":
+		default:
+			return fmt.Errorf("unsupported SMTP_TLS_MODE: %s", c.SMTPTLSMode)
+		}
+	default:
+		return fmt.Errorf("unsupported MAILER_DRIVER: %s", c.MailerDriver)
+	}
+
 	return nil
 }
 
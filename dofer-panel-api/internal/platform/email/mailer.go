@@ -2,14 +2,35 @@ package email
 
 import (
 	"fmt"
-	"os"
 	"strings"
 	"time"
+
+	"github.com/dofer/panel-api/internal/platform/config"
 )
 
 type Mailer interface {
 	SendOrderStatusUpdate(to, customerName, orderNumber, status, trackingURL string) error
 	SendOrderSLAReminder(to, customerName, orderNumber string, deliveryDeadline time.Time, state, trackingURL string) error
+	SendQuoteSent(to, customerName, quoteNumber string, total float64, validUntil time.Time) error
+	SendQuoteExpiring(to, customerName, quoteNumber string, total float64, validUntil time.Time) error
+	SendPaymentReceived(to, customerName, reference string, amount, balance float64) error
+	SendLowStockAlert(to, recipientName, material string, remainingGrams, thresholdGrams float64) error
+}
+
+// New elige el Mailer según MAILER_DRIVER.
+func New(cfg *config.Config) (Mailer, error) {
+	if cfg.MailerDriver != "smtp" {
+		return NewConsoleMailer(), nil
+	}
+	return NewSMTPMailer(SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+		FromName: cfg.SMTPFromName,
+		TLSMode:  cfg.SMTPTLSMode,
+	})
 }
 
 type ConsoleMailer struct{}
@@ -53,6 +74,56 @@ func (m *ConsoleMailer) SendOrderSLAReminder(to, customerName, orderNumber strin
 	return nil
 }
 
+func (m *ConsoleMailer) SendQuoteSent(to, customerName, quoteNumber string, total float64, validUntil time.Time) error {
+	fmt.Println("=== QUOTE EMAIL ===")
+	fmt.Printf("To: %s\n", to)
+	fmt.Printf("Subject: Tu cotización %s - DOFER\n", quoteNumber)
+	fmt.Println("---")
+	fmt.Printf("Hola %s,\n\n", customerName)
+	fmt.Printf("Te compartimos la cotización %s por un total de $%.2f.\n", quoteNumber, total)
+	fmt.Printf("Es válida hasta el %s.\n\n", validUntil.In(time.Local).Format("02/01/2006"))
+	fmt.Println("Equipo DOFER")
+	fmt.Println("==========================")
+	return nil
+}
+
+func (m *ConsoleMailer) SendQuoteExpiring(to, customerName, quoteNumber string, total float64, validUntil time.Time) error {
+	fmt.Println("=== QUOTE EXPIRING EMAIL ===")
+	fmt.Printf("To: %s\n", to)
+	fmt.Printf("Subject: Tu cotización %s vence pronto - DOFER\n", quoteNumber)
+	fmt.Println("---")
+	fmt.Printf("Hola %s,\n\n", customerName)
+	fmt.Printf("La cotización %s por $%.2f vence el %s.\n\n", quoteNumber, total, validUntil.In(time.Local).Format("02/01/2006"))
+	fmt.Println("Equipo DOFER")
+	fmt.Println("==========================")
+	return nil
+}
+
+func (m *ConsoleMailer) SendPaymentReceived(to, customerName, reference string, amount, balance float64) error {
+	fmt.Println("=== PAYMENT EMAIL ===")
+	fmt.Printf("To: %s\n", to)
+	fmt.Printf("Subject: Recibimos tu pago - %s\n", reference)
+	fmt.Println("---")
+	fmt.Printf("Hola %s,\n\n", customerName)
+	fmt.Printf("Registramos tu pago de $%.2f para %s.\n", amount, reference)
+	fmt.Printf("Saldo pendiente: $%.2f\n\n", balance)
+	fmt.Println("Equipo DOFER")
+	fmt.Println("==========================")
+	return nil
+}
+
+func (m *ConsoleMailer) SendLowStockAlert(to, recipientName, material string, remainingGrams, thresholdGrams float64) error {
+	fmt.Println("=== LOW STOCK EMAIL ===")
+	fmt.Printf("To: %s\n", to)
+	fmt.Printf("Subject: Inventario bajo de %s - DOFER\n", material)
+	fmt.Println("---")
+	fmt.Printf("Hola %s,\n\n", recipientName)
+	fmt.Printf("Quedan %.0f g de %s (umbral: %.0f g).\n\n", remainingGrams, material, thresholdGrams)
+	fmt.Println("Equipo DOFER")
+	fmt.Println("==========================")
+	return nil
+}
+
 func getStatusInSpanish(status string) string {
 	statusMap := map[string]string{
 		"new":       "Nueva",
@@ -69,33 +140,3 @@ func getStatusInSpanish(status string) string {
 	}
 	return status
 }
-
-// SMTPMailer - Para producción (comentado por ahora)
-type SMTPMailer struct {
-	host     string
-	port     int
-	username string
-	password string
-	from     string
-}
-
-func NewSMTPMailer() *SMTPMailer {
-	return &SMTPMailer{
-		host:     os.Getenv("SMTP_HOST"),
-		port:     587,
-		username: os.Getenv("SMTP_USERNAME"),
-		password: os.Getenv("SMTP_PASSWORD"),
-		from:     os.Getenv("SMTP_FROM"),
-	}
-}
-
-func (m *SMTPMailer) SendOrderStatusUpdate(to, customerName, orderNumber, status, trackingURL string) error {
-	// TODO: Implementar envío real con net/smtp cuando se configure SMTP
-	fmt.Printf("[SMTP] Would send email to %s for order %s\n", to, orderNumber)
-	return nil
-}
-
-func (m *SMTPMailer) SendOrderSLAReminder(to, customerName, orderNumber string, deliveryDeadline time.Time, state, trackingURL string) error {
-	fmt.Printf("[SMTP] Would send SLA reminder (%s) to %s for order %s\n", state, to, orderNumber)
-	return nil
-}
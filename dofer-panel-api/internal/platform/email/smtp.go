@@ -0,0 +1,299 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TLSModeStartTLS = "starttls"
+	TLSModeImplicit = "tls"
+	TLSModeNone     = "none"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	FromName string
+	// TLSMode es "starttls" (587), "tls" para TLS implícito (465) o "none"
+	// solo para servidores locales de prueba.
+	TLSMode string
+	Timeout time.Duration
+	// TLSConfig permite inyectar certificados raíz en pruebas.
+	TLSConfig *tls.Config
+}
+
+// SMTPMailer entrega los correos por SMTP. Los fallos se devuelven a quien
+// envía; el dispatcher de notificaciones lleva los reintentos.
+type SMTPMailer struct {
+	cfg      SMTPConfig
+	from     mail.Address
+	renderer *Renderer
+	now      func() time.Time
+}
+
+func NewSMTPMailer(cfg SMTPConfig) (*SMTPMailer, error) {
+	if strings.TrimSpace(cfg.Host) == "" {
+		return nil, errors.New("smtp host is required")
+	}
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	if cfg.TLSMode == "" {
+		cfg.TLSMode = TLSModeStartTLS
+	}
+	switch cfg.TLSMode {
+	case TLSModeStartTLS, TLSModeImplicit, TLSModeNone:
+	default:
+		return nil, fmt.Errorf("unsupported smtp tls mode: %s", cfg.TLSMode)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp from address: %w", err)
+	}
+	if from.Name == "" {
+		from.Name = cfg.FromName
+	}
+
+	renderer, err := NewRenderer()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SMTPMailer{
+		cfg:      cfg,
+		from:     *from,
+		renderer: renderer,
+		now:      time.Now,
+	}, nil
+}
+
+func (m *SMTPMailer) SendOrderStatusUpdate(to, customerName, orderNumber, status, trackingURL string) error {
+	msg, err := m.renderer.OrderStatusUpdate(to, customerName, orderNumber, status, trackingURL)
+	if err != nil {
+		return err
+	}
+	return m.deliver(msg)
+}
+
+func (m *SMTPMailer) SendOrderSLAReminder(to, customerName, orderNumber string, deliveryDeadline time.Time, state, trackingURL string) error {
+	msg, err := m.renderer.OrderSLAReminder(to, customerName, orderNumber, deliveryDeadline, state, trackingURL)
+	if err != nil {
+		return err
+	}
+	return m.deliver(msg)
+}
+
+func (m *SMTPMailer) SendQuoteSent(to, customerName, quoteNumber string, total float64, validUntil time.Time) error {
+	msg, err := m.renderer.QuoteSent(to, customerName, quoteNumber, total, validUntil)
+	if err != nil {
+		return err
+	}
+	return m.deliver(msg)
+}
+
+func (m *SMTPMailer) SendQuoteExpiring(to, customerName, quoteNumber string, total float64, validUntil time.Time) error {
+	msg, err := m.renderer.QuoteExpiring(to, customerName, quoteNumber, total, validUntil)
+	if err != nil {
+		return err
+	}
+	return m.deliver(msg)
+}
+
+func (m *SMTPMailer) SendPaymentReceived(to, customerName, reference string, amount, balance float64) error {
+	msg, err := m.renderer.PaymentReceived(to, customerName, reference, amount, balance)
+	if err != nil {
+		return err
+	}
+	return m.deliver(msg)
+}
+
+func (m *SMTPMailer) SendLowStockAlert(to, recipientName, material string, remainingGrams, thresholdGrams float64) error {
+	msg, err := m.renderer.LowStockAlert(to, recipientName, material, remainingGrams, thresholdGrams)
+	if err != nil {
+		return err
+	}
+	return m.deliver(msg)
+}
+
+// deliver entrega el mensaje con el timeout configurado.
+func (m *SMTPMailer) deliver(msg Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.Timeout)
+	defer cancel()
+	return m.Send(ctx, msg)
+}
+
+// Send entrega un mensaje ya renderizado.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	raw, err := m.buildMessage(*to, msg)
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.cfg.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err := client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("smtp RCPT TO: %w", err)
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := writer.Write(raw); err != nil {
+		writer.Close()
+		return fmt.Errorf("smtp write body: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("smtp end body: %w", err)
+	}
+	return client.Quit()
+}
+
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.cfg.Host, m.cfg.Port)
+	dialer := &net.Dialer{Timeout: m.cfg.Timeout}
+
+	var conn net.Conn
+	var err error
+	if m.cfg.TLSMode == TLSModeImplicit {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: m.tlsConfig()}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("smtp connect %s: %w", addr, err)
+	}
+
+	deadline := time.Now().Add(m.cfg.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp handshake: %w", err)
+	}
+
+	if m.cfg.TLSMode == TLSModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(m.tlsConfig()); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp STARTTLS: %w", err)
+		}
+	}
+	return client, nil
+}
+
+func (m *SMTPMailer) tlsConfig() *tls.Config {
+	if m.cfg.TLSConfig != nil {
+		config := m.cfg.TLSConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName = m.cfg.Host
+		}
+		return config
+	}
+	return &tls.Config{ServerName: m.cfg.Host, MinVersion: tls.VersionTLS12}
+}
+
+// buildMessage arma un multipart/alternative con la versión en texto plano
+// primero y la HTML al final, como lo esperan los clientes de correo.
+func (m *SMTPMailer) buildMessage(to mail.Address, msg Message) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	if err := writeQuotedPart(writer, "text/plain; charset=UTF-8", msg.Text); err != nil {
+		return nil, err
+	}
+	if msg.HTML != "" {
+		if err := writeQuotedPart(writer, "text/html; charset=UTF-8", msg.HTML); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	domain := "localhost"
+	if at := strings.LastIndex(m.from.Address, "@"); at >= 0 {
+		domain = m.from.Address[at+1:]
+	}
+
+	var raw bytes.Buffer
+	headers := []struct{ key, value string }{
+		{"From", m.from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("UTF-8", msg.Subject)},
+		{"Date", m.now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", uuid.NewString(), domain)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", writer.Boundary())},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&raw, "%s: %s\r\n", header.key, header.value)
+	}
+	raw.WriteString("\r\n")
+	raw.Write(body.Bytes())
+	return raw.Bytes(), nil
+}
+
+func writeQuotedPart(writer *multipart.Writer, contentType, content string) error {
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	encoder := quotedprintable.NewWriter(part)
+	if _, err := encoder.Write([]byte(content)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
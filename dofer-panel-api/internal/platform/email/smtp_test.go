@@ -0,0 +1,189 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer implementa lo mínimo del protocolo para recibir un correo.
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	auth     string
+	from     string
+	to       []string
+	data     string
+	reject   bool
+}
+
+func startFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTPServer) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 fake.local ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			reply("250-fake.local")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(command, "AUTH PLAIN"):
+			decoded, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(line[len("AUTH PLAIN"):]))
+			s.mu.Lock()
+			s.auth = string(decoded)
+			s.mu.Unlock()
+			reply("235 2.7.0 Authentication successful")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = line[len("MAIL FROM:"):]
+			s.mu.Unlock()
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			s.mu.Lock()
+			rejected := s.reject
+			if !rejected {
+				s.to = append(s.to, line[len("RCPT TO:"):])
+			}
+			s.mu.Unlock()
+			if rejected {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			reply("250 OK queued")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func newTestMailer(t *testing.T, server *fakeSMTPServer) *SMTPMailer {
+	t.Helper()
+	mailer, err := NewSMTPMailer(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "dofer",
+		Password: "secret",
+		From:     "DOFER <noreply@dofer.com>",
+		TLSMode:  TLSModeNone,
+		Timeout:  5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewSMTPMailer returned an error: %v", err)
+	}
+	return mailer
+}
+
+func TestSMTPMailerSendsMultipartStatusUpdate(t *testing.T) {
+	server := startFakeSMTPServer(t)
+	mailer := newTestMailer(t, server)
+
+	err := mailer.SendOrderStatusUpdate(
+		"cliente@example.com",
+		"Juan Pérez",
+		"ORD-001",
+		"printing",
+		"https://panel.dofer.mx/track/abc",
+	)
+	if err != nil {
+		t.Fatalf("SendOrderStatusUpdate returned an error: %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.auth != "\x00dofer\x00secret" {
+		t.Fatalf("unexpected AUTH PLAIN payload: %q", server.auth)
+	}
+	if server.from != "<noreply@dofer.com>" {
+		t.Fatalf("unexpected MAIL FROM: %q", server.from)
+	}
+	if len(server.to) != 1 || server.to[0] != "<cliente@example.com>" {
+		t.Fatalf("unexpected RCPT TO: %#v", server.to)
+	}
+	for _, expected := range []string{
+		"Subject: =?UTF-8?q?Actualizaci=C3=B3n_de_tu_orden_ORD-001_-_DOFER?=",
+		"Content-Type: multipart/alternative;",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Type: text/html; charset=UTF-8",
+		"En Impresi=C3=B3n",
+		"https://panel.dofer.mx/track/abc",
+	} {
+		if !strings.Contains(server.data, expected) {
+			t.Fatalf("expected message to contain %q, got:\n%s", expected, server.data)
+		}
+	}
+}
+
+func TestSMTPMailerReportsDeliveryErrors(t *testing.T) {
+	server := startFakeSMTPServer(t)
+	server.reject = true
+	mailer := newTestMailer(t, server)
+
+	err := mailer.SendOrderStatusUpdate("cliente@example.com", "Juan", "ORD-003", "ready", "https://x")
+	if err == nil || !strings.Contains(err.Error(), "RCPT TO") {
+		t.Fatalf("expected RCPT error, got %v", err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("unexpected timeout")
+	}
+}
@@ -0,0 +1,175 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Message es un correo ya renderizado, listo para entregarse o guardarse en
+// la bandeja de salida.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type orderStatusData struct {
+	CustomerName string
+	OrderNumber  string
+	Status       string
+	StatusLabel  string
+	TrackingURL  string
+}
+
+type quoteSentData struct {
+	CustomerName string
+	QuoteNumber  string
+	Total        string
+	ValidUntil   string
+}
+
+type paymentReceivedData struct {
+	CustomerName string
+	Reference    string
+	Amount       string
+	Balance      string
+	Settled      bool
+}
+
+type lowStockData struct {
+	RecipientName  string
+	Material       string
+	RemainingGrams string
+	ThresholdGrams string
+}
+
+type slaReminderData struct {
+	CustomerName string
+	OrderNumber  string
+	State        string
+	Overdue      bool
+	Deadline     string
+	TrackingURL  string
+}
+
+// Renderer arma los correos a partir de las plantillas embebidas. Cada
+// plantilla define tres bloques: "subject", "text" y "html".
+type Renderer struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+func NewRenderer() (*Renderer, error) {
+	funcs := map[string]any{"upper": strings.ToUpper}
+
+	text, err := texttemplate.New("email").Funcs(funcs).ParseFS(templateFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("parse text templates: %w", err)
+	}
+	html, err := htmltemplate.New("email").Funcs(funcs).ParseFS(templateFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("parse html templates: %w", err)
+	}
+	return &Renderer{text: text, html: html}, nil
+}
+
+func (r *Renderer) OrderStatusUpdate(to, customerName, orderNumber, status, trackingURL string) (Message, error) {
+	return r.render("order_status", to, orderStatusData{
+		CustomerName: customerName,
+		OrderNumber:  orderNumber,
+		Status:       status,
+		StatusLabel:  getStatusInSpanish(status),
+		TrackingURL:  trackingURL,
+	})
+}
+
+func (r *Renderer) OrderSLAReminder(to, customerName, orderNumber string, deliveryDeadline time.Time, state, trackingURL string) (Message, error) {
+	return r.render("sla_reminder", to, slaReminderData{
+		CustomerName: customerName,
+		OrderNumber:  orderNumber,
+		State:        state,
+		Overdue:      state == "overdue",
+		Deadline:     deliveryDeadline.In(time.Local).Format("02/01/2006 15:04"),
+		TrackingURL:  trackingURL,
+	})
+}
+
+func (r *Renderer) QuoteSent(to, customerName, quoteNumber string, total float64, validUntil time.Time) (Message, error) {
+	return r.render("quote_sent", to, quoteSentData{
+		CustomerName: customerName,
+		QuoteNumber:  quoteNumber,
+		Total:        formatMoney(total),
+		ValidUntil:   validUntil.In(time.Local).Format("02/01/2006"),
+	})
+}
+
+// QuoteExpiring usa los mismos datos que QuoteSent.
+func (r *Renderer) QuoteExpiring(to, customerName, quoteNumber string, total float64, validUntil time.Time) (Message, error) {
+	return r.render("quote_expiring", to, quoteSentData{
+		CustomerName: customerName,
+		QuoteNumber:  quoteNumber,
+		Total:        formatMoney(total),
+		ValidUntil:   validUntil.In(time.Local).Format("02/01/2006"),
+	})
+}
+
+func (r *Renderer) PaymentReceived(to, customerName, reference string, amount, balance float64) (Message, error) {
+	return r.render("payment_received", to, paymentReceivedData{
+		CustomerName: customerName,
+		Reference:    reference,
+		Amount:       formatMoney(amount),
+		Balance:      formatMoney(balance),
+		Settled:      balance <= 0.005,
+	})
+}
+
+func (r *Renderer) LowStockAlert(to, recipientName, material string, remainingGrams, thresholdGrams float64) (Message, error) {
+	return r.render("low_stock", to, lowStockData{
+		RecipientName:  recipientName,
+		Material:       material,
+		RemainingGrams: fmt.Sprintf("%.0f g", remainingGrams),
+		ThresholdGrams: fmt.Sprintf("%.0f g", thresholdGrams),
+	})
+}
+
+func (r *Renderer) render(name, to string, data any) (Message, error) {
+	subject, err := executeText(r.text, name+".subject", data)
+	if err != nil {
+		return Message{}, err
+	}
+	text, err := executeText(r.text, name+".text", data)
+	if err != nil {
+		return Message{}, err
+	}
+	var html bytes.Buffer
+	if err := r.html.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return Message{}, fmt.Errorf("render %s.html: %w", name, err)
+	}
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject),
+		Text:    strings.TrimSpace(text) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+func executeText(tmpl *texttemplate.Template, name string, data any) (string, error) {
+	var buffer bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buffer, name, data); err != nil {
+		return "", fmt.Errorf("render %s: %w", name, err)
+	}
+	return buffer.String(), nil
+}
+
+func formatMoney(amount float64) string {
+	return fmt.Sprintf("$%.2f MXN", amount)
+}
@@ -17,6 +17,8 @@ import (
 	costsInfra "github.com/dofer/panel-api/internal/modules/costs/infra"
 	costsTransport "github.com/dofer/panel-api/internal/modules/costs/transport"
 	"github.com/dofer/panel-api/internal/modules/customers"
+	"github.com/dofer/panel-api/internal/modules/materials"
+	"github.com/dofer/panel-api/internal/modules/notifications"
 	ordersApp "github.com/dofer/panel-api/internal/modules/orders/app"
 	ordersInfra "github.com/dofer/panel-api/internal/modules/orders/infra"
 	ordersTransport "github.com/dofer/panel-api/internal/modules/orders/transport"
@@ -26,16 +28,20 @@ import (
 	quotesInfra "github.com/dofer/panel-api/internal/modules/quotes/infra"
 	quotesTransport "github.com/dofer/panel-api/internal/modules/quotes/transport"
 	"github.com/dofer/panel-api/internal/modules/tracking"
+	"github.com/dofer/panel-api/internal/platform/branding"
 	"github.com/dofer/panel-api/internal/platform/config"
-	"github.com/dofer/panel-api/internal/platform/email"
 	"github.com/dofer/panel-api/internal/platform/httpserver/middleware"
+	"github.com/dofer/panel-api/internal/platform/numbering"
+	"github.com/dofer/panel-api/internal/platform/secrets"
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func New(cfg *config.Config, db *pgxpool.Pool) http.Handler {
+// New arma las rutas de la API. El módulo del bazar llega ya armado porque
+// el worker de sincronización comparte su Service.
+func New(cfg *config.Config, db *pgxpool.Pool, bazarModule *bazar.Module) http.Handler {
 	r := chi.NewRouter()
 
 	// Middlewares globales
@@ -69,37 +75,37 @@ func New(cfg *config.Config, db *pgxpool.Pool) http.Handler {
 	orderRepo := ordersInfra.NewPostgresOrderRepository(db)
 	historyRepo := ordersInfra.NewPostgresOrderHistoryRepository(db)
 	timerRepo := ordersInfra.NewPostgresTimerRepository(db)
-
-	// Setup email service (usando ConsoleMailer para desarrollo)
-	mailer := email.NewConsoleMailer()
+	workflowRepo := ordersInfra.NewPostgresWorkflowRepository(db)
+	materialRepo := materials.NewRepository(db)
+	brandingRepo := branding.NewRepository(db)
 
 	// Setup auth handlers
 	getUserHandler := app.NewGetUserByIDHandler(userRepo)
 	authHandler := authTransport.NewAuthHandler(getUserHandler, userRepo, cfg)
 
 	// Setup order handlers
-	createOrderHandler := ordersApp.NewCreateOrderHandler(orderRepo)
+	createOrderHandler := ordersApp.NewCreateOrderHandler(orderRepo, workflowRepo)
 	getOrderHandler := ordersApp.NewGetOrderHandler(orderRepo)
 	listOrdersHandler := ordersApp.NewListOrdersHandler(orderRepo)
-	updateStatusHandler := ordersApp.NewUpdateOrderStatusHandler(orderRepo, historyRepo, mailer)
+	updateStatusHandler := ordersApp.NewUpdateOrderStatusHandler(orderRepo, historyRepo, workflowRepo)
 	updatePriorityHandler := ordersApp.NewUpdateOrderPriorityHandler(orderRepo, historyRepo)
-	bulkUpdateStatusHandler := ordersApp.NewBulkUpdateOrderStatusHandler(orderRepo, historyRepo)
+	bulkUpdateStatusHandler := ordersApp.NewBulkUpdateOrderStatusHandler(orderRepo, historyRepo, workflowRepo)
 	bulkUpdatePriorityHandler := ordersApp.NewBulkUpdateOrderPriorityHandler(orderRepo, historyRepo)
-	sendSLARemindersHandler := ordersApp.NewSendSLARemindersHandler(orderRepo, historyRepo, mailer)
+	sendSLARemindersHandler := ordersApp.NewSendSLARemindersHandler(orderRepo, historyRepo, workflowRepo)
 	assignOrderHandler := ordersApp.NewAssignOrderHandler(orderRepo, historyRepo)
 	getHistoryHandler := ordersApp.NewGetOrderHistoryHandler(historyRepo)
-	getStatsHandler := ordersApp.NewGetOrderStatsHandler(orderRepo)
+	getStatsHandler := ordersApp.NewGetOrderStatsHandler(orderRepo, workflowRepo)
 	searchOrdersHandler := ordersApp.NewSearchOrdersHandler(orderRepo)
 
 	// Setup timer handlers
 	startTimerHandler := ordersApp.NewStartTimerHandler(orderRepo, timerRepo)
 	pauseTimerHandler := ordersApp.NewPauseTimerHandler(orderRepo, timerRepo)
-	stopTimerHandler := ordersApp.NewStopTimerHandler(orderRepo, timerRepo)
+	stopTimerHandler := ordersApp.NewStopTimerHandler(orderRepo, timerRepo, materialRepo)
 	getTimerHandler := ordersApp.NewGetTimerHandler(timerRepo)
 	updateEstimatedHandler := ordersApp.NewUpdateEstimatedTimeHandler(timerRepo)
 	operatorStatsHandler := ordersApp.NewGetOperatorStatsHandler(timerRepo)
 	getOrderItemsHandler := ordersApp.NewGetOrderItemsHandler(orderRepo)
-	updateOrderItemStatusHandler := ordersApp.NewUpdateOrderItemStatusHandler(orderRepo)
+	updateOrderItemStatusHandler := ordersApp.NewUpdateOrderItemStatusHandler(orderRepo, materialRepo)
 
 	// Setup order item and payment handlers
 	addOrderItemHandler := ordersApp.NewAddOrderItemHandler(orderRepo)
@@ -109,6 +115,12 @@ func New(cfg *config.Config, db *pgxpool.Pool) http.Handler {
 	deleteOrderPaymentHandler := ordersApp.NewDeleteOrderPaymentHandler(orderRepo)
 	recalculateOrderTotalsHandler := ordersApp.NewRecalculateOrderTotalsHandler(orderRepo)
 
+	// Setup order workflow handlers
+	getWorkflowHandler := ordersApp.NewGetOrderWorkflowHandler(workflowRepo)
+	updateWorkflowHandler := ordersApp.NewUpdateOrderWorkflowHandler(workflowRepo)
+	resetWorkflowHandler := ordersApp.NewResetOrderWorkflowHandler(workflowRepo)
+	renderOrderReceiptHandler := ordersApp.NewRenderOrderReceiptHandler(orderRepo, brandingRepo)
+
 	orderHandler := ordersTransport.NewOrderHandler(
 		createOrderHandler,
 		getOrderHandler,
@@ -136,6 +148,10 @@ func New(cfg *config.Config, db *pgxpool.Pool) http.Handler {
 		getOrderPaymentsHandler,
 		deleteOrderPaymentHandler,
 		recalculateOrderTotalsHandler,
+		getWorkflowHandler,
+		updateWorkflowHandler,
+		resetWorkflowHandler,
+		renderOrderReceiptHandler,
 	)
 
 	// Setup cost handlers
@@ -154,20 +170,29 @@ func New(cfg *config.Config, db *pgxpool.Pool) http.Handler {
 	createQuoteHandler := quotesApp.NewCreateQuoteHandler(quoteRepo)
 	getQuoteHandler := quotesApp.NewGetQuoteHandler(quoteRepo)
 	listQuotesHandler := quotesApp.NewListQuotesHandler(quoteRepo)
-	addQuoteItemHandler := quotesApp.NewAddQuoteItemHandler(quoteRepo, calculateCostHandler)
-	updateQuoteHandler := quotesApp.NewUpdateQuoteHandler(quoteRepo)
-	updateQuoteStatusHandler := quotesApp.NewUpdateQuoteStatusHandler(quoteRepo)
-	deleteQuoteItemHandler := quotesApp.NewDeleteQuoteItemHandler(quoteRepo)
+	addQuoteItemHandler := quotesApp.NewAddQuoteItemHandler(quoteRepo, quoteRepo, calculateCostHandler)
+	updateQuoteHandler := quotesApp.NewUpdateQuoteHandler(quoteRepo, quoteRepo)
+	updateQuoteStatusHandler := quotesApp.NewUpdateQuoteStatusHandler(quoteRepo, quoteRepo, quoteRepo)
+	deleteQuoteItemHandler := quotesApp.NewDeleteQuoteItemHandler(quoteRepo, quoteRepo)
 	deleteQuoteHandler := quotesApp.NewDeleteQuoteHandler(quoteRepo)
 	searchQuotesHandler := quotesApp.NewSearchQuotesHandler(quoteRepo)
-	convertToOrderHandler := quotesApp.NewConvertToOrderHandler(quoteRepo, orderRepo)
+	quoteConversionUoW := quotesInfra.NewPostgresUnitOfWork(db, quoteRepo, orderRepo)
+	convertToOrderHandler := quotesApp.NewConvertToOrderHandler(quoteConversionUoW, workflowRepo)
 	addPaymentHandler := quotesApp.NewAddPaymentHandler(quoteRepo)
-	syncItemsHandler := quotesApp.NewSyncItemsToOrderHandler(quoteRepo, orderRepo)
+	syncItemsHandler := quotesApp.NewSyncItemsToOrderHandler(quoteConversionUoW)
 	createQuoteTemplateHandler := quotesApp.NewCreateQuoteTemplateHandler(quoteRepo)
 	getQuoteTemplateHandler := quotesApp.NewGetQuoteTemplateHandler(quoteRepo)
 	listQuoteTemplateHandler := quotesApp.NewListQuoteTemplatesHandler(quoteRepo)
 	updateQuoteTemplateHandler := quotesApp.NewUpdateQuoteTemplateHandler(quoteRepo)
 	deleteQuoteTemplateHandler := quotesApp.NewDeleteQuoteTemplateHandler(quoteRepo)
+	addQuoteItemFromFileHandler := quotesApp.NewAddQuoteItemFromFileHandler(quoteRepo, addQuoteItemHandler)
+	renderQuotePDFHandler := quotesApp.NewRenderQuotePDFHandler(quoteRepo, brandingRepo)
+	quoteHistoryRepo := quotesInfra.NewPostgresQuoteHistoryRepository(db)
+	quoteLinkSigner := quotesApp.NewPublicLinkSigner(cfg.QuoteLinkSecret)
+	createQuotePublicLinkHandler := quotesApp.NewCreateQuotePublicLinkHandler(quoteRepo, quoteRepo, quoteHistoryRepo, quoteLinkSigner)
+	revokeQuotePublicLinkHandler := quotesApp.NewRevokeQuotePublicLinkHandler(quoteRepo, quoteRepo, quoteHistoryRepo)
+	getQuoteHistoryHandler := quotesApp.NewGetQuoteHistoryHandler(quoteRepo, quoteHistoryRepo)
+	quoteRevisionsHandler := quotesApp.NewQuoteRevisionsHandler(quoteRepo, quoteRepo)
 	quoteHandler := quotesTransport.NewQuoteHandler(
 		createQuoteHandler,
 		getQuoteHandler,
@@ -186,35 +211,43 @@ func New(cfg *config.Config, db *pgxpool.Pool) http.Handler {
 		listQuoteTemplateHandler,
 		updateQuoteTemplateHandler,
 		deleteQuoteTemplateHandler,
+		addQuoteItemFromFileHandler,
+		renderQuotePDFHandler,
+		createQuotePublicLinkHandler,
+		revokeQuotePublicLinkHandler,
+		getQuoteHistoryHandler,
+		quoteRevisionsHandler,
 	)
+	publicQuoteHandler := quotesTransport.NewPublicQuoteHandler(quotesApp.NewPublicQuoteHandler(
+		quoteRepo,
+		quoteRepo,
+		quoteLinkSigner,
+		brandingRepo,
+		renderQuotePDFHandler,
+		convertToOrderHandler,
+	))
 
 	// Setup tracking handler
-	trackingHandler := tracking.NewTrackingHandler(orderRepo)
+	trackingHandler := tracking.NewTrackingHandler(orderRepo, workflowRepo)
 
 	// Setup customers handler
 	customerRepo := customers.NewRepository(db)
 	customerHandler := customers.NewHandler(customerRepo)
 
 	// Setup printers handler
-	printerRepo := printers.NewRepository(db)
+	secretsBox := secrets.NewBox(cfg.SecretsEncryptionKey)
+	printerRepo := printers.NewRepository(db, secretsBox)
 	printerHandler := printers.NewHandler(printerRepo)
 
+	// Setup materials inventory handler
+	materialHandler := materials.NewHandler(materialRepo)
+
 	// Setup products handler
 	productRepo := products.NewRepository(db)
 	productHandler := products.NewHandler(productRepo)
 
 	// Setup bazar sales handlers
-	bazarRepo := bazar.NewRepository(db)
-	bazarSheets := bazar.NewGoogleSheetsClient(bazar.SheetsConfig{
-		SpreadsheetID: cfg.GoogleSheetsID,
-		ServiceEmail:  cfg.GoogleServiceEmail,
-		PrivateKey:    cfg.GooglePrivateKey,
-		InventoryName: cfg.GoogleInventorySheet,
-		SalesName:     cfg.GoogleSalesSheet,
-		Timezone:      cfg.BazarTimezone,
-	})
-	bazarService := bazar.NewService(bazarRepo, bazarSheets, cfg.BazarTimezone)
-	bazarHandler := bazar.NewHandler(bazarRepo, bazarService, bazarSheets)
+	bazarHandler := bazar.NewHandler(bazarModule.Repo, bazarModule.Service, bazarModule.Targets, brandingRepo)
 
 	// Setup affiliates handlers
 	affiliateRepo := affiliatesInfra.NewPostgresAffiliateRepository(db)
@@ -229,7 +262,7 @@ func New(cfg *config.Config, db *pgxpool.Pool) http.Handler {
 	createOrderRequestHandler := affiliatesApp.NewCreateOrderRequestHandler(affiliateRepo, productRepo)
 	listOrderRequestsHandler := affiliatesApp.NewListOrderRequestsHandler(affiliateRepo)
 	getOrderRequestHandler := affiliatesApp.NewGetOrderRequestHandler(affiliateRepo)
-	approveOrderRequestHandler := affiliatesApp.NewApproveOrderRequestHandler(affiliateRepo, orderRepo)
+	approveOrderRequestHandler := affiliatesApp.NewApproveOrderRequestHandler(affiliateRepo, orderRepo, workflowRepo)
 	rejectOrderRequestHandler := affiliatesApp.NewRejectOrderRequestHandler(affiliateRepo)
 	listCommissionsHandler := affiliatesApp.NewListCommissionsHandler(affiliateRepo)
 	markCommissionPaidHandler := affiliatesApp.NewMarkCommissionPaidHandler(affiliateRepo)
@@ -262,7 +295,11 @@ func New(cfg *config.Config, db *pgxpool.Pool) http.Handler {
 	if passwordVerificationKey == "" {
 		passwordVerificationKey = cfg.SupabaseServiceRoleKey
 	}
-	adminHandler := admin.NewHandler(adminRepo, admin.NewSupabasePasswordVerifier(cfg.SupabaseURL, passwordVerificationKey))
+	adminHandler := admin.NewHandler(adminRepo, admin.NewSupabasePasswordVerifier(cfg.SupabaseURL, passwordVerificationKey), brandingRepo, numbering.NewRepository(db))
+
+	// Setup notifications handler
+	notificationRepo := notifications.NewRepository(db)
+	notificationHandler := notifications.NewHandler(notificationRepo)
 
 	// API v1
 	r.Route("/api/v1", func(r chi.Router) {
@@ -272,6 +309,9 @@ func New(cfg *config.Config, db *pgxpool.Pool) http.Handler {
 			json.NewEncoder(w).Encode(map[string]string{"message": "pong"})
 		})
 
+		// Enlace público de cotizaciones (el token firmado hace de credencial)
+		quotesTransport.RegisterPublicRoutes(r, publicQuoteHandler)
+
 		// Rutas protegidas: RequireAuth + SyncUser (asegura que el usuario exista en DB local)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.RequireAuth)
@@ -291,9 +331,11 @@ func New(cfg *config.Config, db *pgxpool.Pool) http.Handler {
 				tracking.RegisterRoutes(r, trackingHandler)
 				customers.RegisterRoutes(r, customerHandler)
 				printers.RegisterRoutes(r, printerHandler)
+				materials.RegisterRoutes(r, materialHandler)
 				products.RegisterRoutes(r, productHandler)
 				bazar.RegisterRoutes(r, bazarHandler)
 				affiliatesTransport.RegisterRoutes(r, affiliateHandler)
+				notifications.RegisterRoutes(r, notificationHandler)
 			})
 		})
 	})
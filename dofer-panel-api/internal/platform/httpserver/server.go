@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/dofer/panel-api/internal/modules/bazar"
 	"github.com/dofer/panel-api/internal/platform/config"
 	"github.com/dofer/panel-api/internal/platform/httpserver/router"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -16,8 +17,8 @@ type Server struct {
 	cfg        *config.Config
 }
 
-func New(cfg *config.Config, db *pgxpool.Pool) *Server {
-	r := router.New(cfg, db)
+func New(cfg *config.Config, db *pgxpool.Pool, bazarModule *bazar.Module) *Server {
+	r := router.New(cfg, db, bazarModule)
 
 	return &Server{
 		httpServer: &http.Server{
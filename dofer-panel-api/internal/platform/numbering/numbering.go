@@ -0,0 +1,296 @@
+// Package numbering asigna folios consecutivos por organización y tipo de
+// documento (DOF-2026-00042). Los contadores viven en document_sequences y se
+// incrementan con Next dentro de la transacción que guarda el documento: si
+// esa transacción se revierte el número vuelve a quedar libre, así que la
+// serie no tiene huecos.
+package numbering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	DocumentOrder     = "order"
+	DocumentQuote     = "quote"
+	DocumentBazarSale = "bazar_sale"
+	DocumentReceipt   = "receipt"
+)
+
+const (
+	DefaultFormat  = "{prefix}-{year}-{number}"
+	DefaultPadding = 5
+)
+
+var (
+	ErrUnknownDocumentType  = errors.New("document type must be order, quote, bazar_sale or receipt")
+	ErrInvalidPrefix        = errors.New("prefix must have 1 to 12 letters, digits or dashes")
+	ErrInvalidFormat        = errors.New("format must include {number}, use only {prefix}, {year} and {number}, and be at most 40 characters")
+	ErrYearlyResetNeedsYear = errors.New("yearly reset requires {year} in the format so numbers do not repeat")
+	ErrInvalidPadding       = errors.New("padding must be between 1 and 10")
+	ErrNextNumberTooLow     = errors.New("next_number must be greater than the last number issued")
+)
+
+// DocumentTypes en el orden en que se muestran en la configuración.
+var DocumentTypes = []string{DocumentOrder, DocumentQuote, DocumentBazarSale, DocumentReceipt}
+
+var defaultPrefixes = map[string]string{
+	DocumentOrder:     "ORD",
+	DocumentQuote:     "COT",
+	DocumentBazarSale: "SALE",
+	DocumentReceipt:   "TKT",
+}
+
+type Sequence struct {
+	DocumentType string `json:"document_type"`
+	Prefix       string `json:"prefix"`
+	Format       string `json:"format"`
+	Padding      int    `json:"padding"`
+	ResetYearly  bool   `json:"reset_yearly"`
+	PeriodYear   int    `json:"period_year"`
+	LastNumber   int64  `json:"last_number"`
+	// NextPreview es el folio que recibiría el siguiente documento.
+	NextPreview string `json:"next_preview"`
+}
+
+// UpdateRequest reemplaza la configuración de una serie; los campos vacíos
+// toman el valor por defecto. NextNumber permite continuar una numeración
+// previa, nunca retroceder.
+type UpdateRequest struct {
+	Prefix      string `json:"prefix"`
+	Format      string `json:"format"`
+	Padding     int    `json:"padding"`
+	ResetYearly bool   `json:"reset_yearly"`
+	NextNumber  *int64 `json:"next_number"`
+}
+
+// Querier lo cumplen pgx.Tx y *pgxpool.Pool. Next debe recibir la
+// transacción del documento para que el número se confirme con él.
+type Querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Next reserva el siguiente folio de la serie. La fila del contador queda
+// bloqueada hasta que termina la transacción, por lo que dos documentos
+// simultáneos nunca reciben el mismo número.
+func Next(ctx context.Context, db Querier, organizationID, documentType string, now time.Time) (string, error) {
+	prefix, ok := defaultPrefixes[documentType]
+	if !ok {
+		return "", ErrUnknownDocumentType
+	}
+
+	var sequence Sequence
+	err := db.QueryRow(ctx, `
+		INSERT INTO document_sequences (
+			organization_id, document_type, prefix, format, padding, period_year, last_number
+		) VALUES ($1, $2, $3, $4, $5, $6, 1)
+		ON CONFLICT (organization_id, document_type) DO UPDATE
+		SET last_number = CASE
+		        WHEN document_sequences.reset_yearly
+		         AND document_sequences.period_year <> EXCLUDED.period_year THEN 1
+		        ELSE document_sequences.last_number + 1
+		    END,
+		    period_year = EXCLUDED.period_year
+		RETURNING prefix, format, padding, period_year, last_number
+	`, organizationID, documentType, prefix, DefaultFormat, DefaultPadding, now.Year()).Scan(
+		&sequence.Prefix,
+		&sequence.Format,
+		&sequence.Padding,
+		&sequence.PeriodYear,
+		&sequence.LastNumber,
+	)
+	if err != nil {
+		return "", fmt.Errorf("allocate %s number: %w", documentType, err)
+	}
+	return Render(sequence.Prefix, sequence.Format, sequence.Padding, sequence.PeriodYear, sequence.LastNumber), nil
+}
+
+// Render arma el folio sustituyendo {prefix}, {year} y {number}; el número se
+// rellena con ceros a la izquierda hasta padding dígitos.
+func Render(prefix, format string, padding, year int, number int64) string {
+	return strings.NewReplacer(
+		"{prefix}", prefix,
+		"{year}", strconv.Itoa(year),
+		"{number}", fmt.Sprintf("%0*d", padding, number),
+	).Replace(format)
+}
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// List devuelve todas las series de la organización; las que aún no emiten
+// ningún folio aparecen con la configuración por defecto.
+func (r *Repository) List(ctx context.Context, organizationID string) ([]Sequence, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT document_type, prefix, format, padding, reset_yearly, period_year, last_number
+		FROM document_sequences
+		WHERE organization_id = $1
+	`, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stored := make(map[string]Sequence)
+	for rows.Next() {
+		var sequence Sequence
+		if err := rows.Scan(
+			&sequence.DocumentType,
+			&sequence.Prefix,
+			&sequence.Format,
+			&sequence.Padding,
+			&sequence.ResetYearly,
+			&sequence.PeriodYear,
+			&sequence.LastNumber,
+		); err != nil {
+			return nil, err
+		}
+		stored[sequence.DocumentType] = sequence
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sequences := make([]Sequence, 0, len(DocumentTypes))
+	for _, documentType := range DocumentTypes {
+		sequence, ok := stored[documentType]
+		if !ok {
+			sequence = Sequence{
+				DocumentType: documentType,
+				Prefix:       defaultPrefixes[documentType],
+				Format:       DefaultFormat,
+				Padding:      DefaultPadding,
+				PeriodYear:   now.Year(),
+			}
+		}
+		sequence.NextPreview = sequence.preview(now)
+		sequences = append(sequences, sequence)
+	}
+	return sequences, nil
+}
+
+func (r *Repository) Update(ctx context.Context, organizationID, documentType string, req UpdateRequest) (*Sequence, error) {
+	sequence, err := normalize(documentType, req)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var lastNumber *int64
+	if req.NextNumber != nil {
+		if *req.NextNumber < 1 {
+			return nil, ErrNextNumberTooLow
+		}
+		last := *req.NextNumber - 1
+		lastNumber = &last
+	}
+
+	// Con next_number el contador solo avanza: el WHERE descarta el cambio si
+	// retrocedería a folios que ya se emitieron en el periodo actual.
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO document_sequences (
+			organization_id, document_type, prefix, format, padding, reset_yearly,
+			period_year, last_number
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8::bigint, 0))
+		ON CONFLICT (organization_id, document_type) DO UPDATE
+		SET prefix = EXCLUDED.prefix,
+		    format = EXCLUDED.format,
+		    padding = EXCLUDED.padding,
+		    reset_yearly = EXCLUDED.reset_yearly,
+		    period_year = CASE WHEN $8::bigint IS NULL THEN document_sequences.period_year ELSE EXCLUDED.period_year END,
+		    last_number = COALESCE($8::bigint, document_sequences.last_number)
+		WHERE $8::bigint IS NULL
+		   OR (document_sequences.reset_yearly AND document_sequences.period_year <> EXCLUDED.period_year)
+		   OR $8::bigint >= document_sequences.last_number
+		RETURNING period_year, last_number
+	`,
+		organizationID,
+		documentType,
+		sequence.Prefix,
+		sequence.Format,
+		sequence.Padding,
+		sequence.ResetYearly,
+		now.Year(),
+		lastNumber,
+	).Scan(&sequence.PeriodYear, &sequence.LastNumber)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNextNumberTooLow
+	}
+	if err != nil {
+		return nil, err
+	}
+	sequence.NextPreview = sequence.preview(now)
+	return &sequence, nil
+}
+
+// preview replica la lógica de Next sin reservar el número.
+func (s Sequence) preview(now time.Time) string {
+	year, number := s.PeriodYear, s.LastNumber+1
+	if s.PeriodYear != now.Year() {
+		year = now.Year()
+		if s.ResetYearly {
+			number = 1
+		}
+	}
+	return Render(s.Prefix, s.Format, s.Padding, year, number)
+}
+
+func normalize(documentType string, req UpdateRequest) (Sequence, error) {
+	defaultPrefix, ok := defaultPrefixes[documentType]
+	if !ok {
+		return Sequence{}, ErrUnknownDocumentType
+	}
+
+	sequence := Sequence{
+		DocumentType: documentType,
+		Prefix:       strings.ToUpper(strings.TrimSpace(req.Prefix)),
+		Format:       strings.TrimSpace(req.Format),
+		Padding:      req.Padding,
+		ResetYearly:  req.ResetYearly,
+	}
+	if sequence.Prefix == "" {
+		sequence.Prefix = defaultPrefix
+	}
+	if sequence.Format == "" {
+		sequence.Format = DefaultFormat
+	}
+	if sequence.Padding == 0 {
+		sequence.Padding = DefaultPadding
+	}
+
+	if len(sequence.Prefix) > 12 || strings.Trim(sequence.Prefix, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-") != "" {
+		return Sequence{}, ErrInvalidPrefix
+	}
+	if sequence.Padding < 1 || sequence.Padding > 10 {
+		return Sequence{}, ErrInvalidPadding
+	}
+	if !validFormat(sequence.Format) {
+		return Sequence{}, ErrInvalidFormat
+	}
+	if sequence.ResetYearly && !strings.Contains(sequence.Format, "{year}") {
+		return Sequence{}, ErrYearlyResetNeedsYear
+	}
+	return sequence, nil
+}
+
+// validFormat acepta solo los marcadores conocidos y exige {number} una vez;
+// el texto fijo no puede llevar espacios ni llaves sueltas.
+func validFormat(format string) bool {
+	if len(format) > 40 || strings.Count(format, "{number}") != 1 {
+		return false
+	}
+	rest := strings.NewReplacer("{prefix}", "", "{year}", "", "{number}", "").Replace(format)
+	return !strings.ContainsAny(rest, "{} \t\n")
+}
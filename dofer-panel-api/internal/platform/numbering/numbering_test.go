@@ -0,0 +1,64 @@
+package numbering
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	if got := Render("DOF", DefaultFormat, 5, 2026, 42); got != "DOF-2026-00042" {
+		t.Fatalf("Render() = %q", got)
+	}
+	if got := Render("T", "{number}/{year}", 3, 2026, 12345); got != "12345/2026" {
+		t.Fatalf("padding must not truncate larger numbers, got %q", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	sequence, err := normalize(DocumentOrder, UpdateRequest{Prefix: " dof "})
+	if err != nil {
+		t.Fatalf("normalize() error = %v", err)
+	}
+	if sequence.Prefix != "DOF" || sequence.Format != DefaultFormat || sequence.Padding != DefaultPadding {
+		t.Fatalf("unexpected defaults %+v", sequence)
+	}
+	if sequence, _ := normalize(DocumentQuote, UpdateRequest{}); sequence.Prefix != "COT" {
+		t.Fatalf("expected default quote prefix, got %q", sequence.Prefix)
+	}
+
+	for name, tc := range map[string]struct {
+		documentType string
+		req          UpdateRequest
+		want         error
+	}{
+		"type":          {"invoice", UpdateRequest{}, ErrUnknownDocumentType},
+		"prefix":        {DocumentOrder, UpdateRequest{Prefix: "DOF 1"}, ErrInvalidPrefix},
+		"long prefix":   {DocumentOrder, UpdateRequest{Prefix: "ABCDEFGHIJKLM"}, ErrInvalidPrefix},
+		"no number":     {DocumentOrder, UpdateRequest{Format: "{prefix}-{year}"}, ErrInvalidFormat},
+		"unknown token": {DocumentOrder, UpdateRequest{Format: "{prefix}-{month}-{number}"}, ErrInvalidFormat},
+		"padding":       {DocumentOrder, UpdateRequest{Padding: 11}, ErrInvalidPadding},
+		"reset":         {DocumentOrder, UpdateRequest{Format: "{prefix}{number}", ResetYearly: true}, ErrYearlyResetNeedsYear},
+	} {
+		if _, err := normalize(tc.documentType, tc.req); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	now := time.Date(2027, 1, 2, 0, 0, 0, 0, time.UTC)
+	sequence := Sequence{Prefix: "DOF", Format: DefaultFormat, Padding: 5, PeriodYear: 2026, LastNumber: 41}
+
+	if got := sequence.preview(now); got != "DOF-2027-00042" {
+		t.Fatalf("without reset the counter continues, got %q", got)
+	}
+	sequence.ResetYearly = true
+	if got := sequence.preview(now); got != "DOF-2027-00001" {
+		t.Fatalf("with reset the new year starts at 1, got %q", got)
+	}
+	sequence.PeriodYear = 2027
+	if got := sequence.preview(now); got != "DOF-2027-00042" {
+		t.Fatalf("same year must continue, got %q", got)
+	}
+}
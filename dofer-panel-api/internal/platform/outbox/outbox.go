@@ -0,0 +1,97 @@
+// Package outbox guarda eventos de dominio en la misma transacción que el
+// cambio que los origina. El módulo de notificaciones los entrega después,
+// así que un evento existe si y solo si el cambio se confirmó.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	EventOrderStatusChanged = "order.status_changed"
+	EventOrderSLARisk       = "order.sla_risk"
+	EventQuoteSent          = "quote.sent"
+	EventQuoteExpiring      = "quote.expiring"
+	EventPaymentReceived    = "payment.received"
+	EventInventoryLowStock  = "inventory.low_stock"
+)
+
+type Event struct {
+	OrganizationID string
+	Type           string
+	AggregateType  string
+	AggregateID    string
+	Payload        map[string]any
+}
+
+// Execer lo cumplen tanto pgx.Tx como *pgxpool.Pool; los repositorios pasan la
+// transacción abierta para que el evento se confirme junto con el cambio.
+type Execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func Write(ctx context.Context, db Execer, events ...Event) error {
+	for _, event := range events {
+		if event.OrganizationID == "" {
+			return errors.New("outbox event requires organization_id")
+		}
+		if event.Type == "" {
+			return errors.New("outbox event requires type")
+		}
+
+		payload := event.Payload
+		if payload == nil {
+			payload = map[string]any{}
+		}
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("encode outbox payload: %w", err)
+		}
+
+		_, err = db.Exec(ctx, `
+			INSERT INTO notification_events (
+				id, organization_id, event_type, aggregate_type, aggregate_id, payload
+			) VALUES ($1, $2, $3, $4, $5, $6)
+		`, uuid.New(), event.OrganizationID, event.Type, event.AggregateType, event.AggregateID, encoded)
+		if err != nil {
+			return fmt.Errorf("write outbox event %s: %w", event.Type, err)
+		}
+	}
+	return nil
+}
+
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// ExecWithEvents ejecuta una escritura y guarda sus eventos en una sola
+// transacción. Sin eventos se comporta como un Exec normal.
+func ExecWithEvents(ctx context.Context, db Beginner, events []Event, query string, args ...any) error {
+	if len(events) == 0 {
+		if execer, ok := db.(Execer); ok {
+			_, err := execer.Exec(ctx, query, args...)
+			return err
+		}
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return err
+	}
+	if err := Write(ctx, tx, events...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,238 @@
+// Package pdf genera documentos PDF sin dependencias externas: texto con las
+// fuentes estándar Helvetica, rectángulos, líneas e imágenes RGB. Las
+// coordenadas se dan desde la esquina superior izquierda, en puntos.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"strings"
+)
+
+const (
+	// Tamaño carta.
+	PageWidth  = 612.0
+	PageHeight = 792.0
+)
+
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+type Color struct {
+	R, G, B uint8
+}
+
+var (
+	Black = Color{0, 0, 0}
+	White = Color{255, 255, 255}
+	Gray  = Color{107, 114, 128}
+)
+
+// ParseHexColor acepta "#RRGGBB" o "RRGGBB".
+func ParseHexColor(value string) (Color, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(value) != 6 {
+		return Color{}, false
+	}
+	var c Color
+	if _, err := fmt.Sscanf(value, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return Color{}, false
+	}
+	return c, true
+}
+
+type Document struct {
+	pages  []*bytes.Buffer
+	active int
+	images []image.Image
+	width  float64
+	height float64
+}
+
+func New() *Document {
+	return NewWithSize(PageWidth, PageHeight)
+}
+
+// NewWithSize crea un documento con páginas de width x height puntos, por
+// ejemplo un ticket angosto.
+func NewWithSize(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.active = len(d.pages) - 1
+}
+
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// SetPage vuelve a una página ya creada (índice desde 0), por ejemplo para
+// numerar al final.
+func (d *Document) SetPage(index int) {
+	if index >= 0 && index < len(d.pages) {
+		d.active = index
+	}
+}
+
+func (d *Document) current() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[d.active]
+}
+
+// Text dibuja una línea con la base en y.
+func (d *Document) Text(x, y float64, text string, font Font, size float64, color Color) {
+	fmt.Fprintf(d.current(), "BT /F%d %.2f Tf %s rg %.2f %.2f Td (%s) Tj ET\n",
+		int(font)+1, size, colorOperands(color), x, d.height-y, escape(encodeWinAnsi(text)))
+}
+
+// TextRight alinea el final del texto con right.
+func (d *Document) TextRight(right, y float64, text string, font Font, size float64, color Color) {
+	d.Text(right-TextWidth(text, font, size), y, text, font, size, color)
+}
+
+func (d *Document) Rect(x, y, width, height float64, fill Color) {
+	fmt.Fprintf(d.current(), "%s rg %.2f %.2f %.2f %.2f re f\n",
+		colorOperands(fill), x, d.height-y-height, width, height)
+}
+
+func (d *Document) Line(x1, y1, x2, y2, width float64, color Color) {
+	fmt.Fprintf(d.current(), "%s RG %.2f w %.2f %.2f m %.2f %.2f l S\n",
+		colorOperands(color), width, x1, d.height-y1, x2, d.height-y2)
+}
+
+// Image dibuja img escalada a width x height con la esquina superior
+// izquierda en (x, y).
+func (d *Document) Image(img image.Image, x, y, width, height float64) {
+	d.images = append(d.images, img)
+	fmt.Fprintf(d.current(), "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n",
+		width, height, x, d.height-y-height, len(d.images))
+}
+
+func colorOperands(c Color) string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+var stringEscaper = strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", "", "\n", " ")
+
+func escape(text string) string {
+	return stringEscaper.Replace(text)
+}
+
+// Bytes arma el archivo: catálogo, páginas, dos fuentes, imágenes y un
+// contenido comprimido por página.
+func (d *Document) Bytes() ([]byte, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var out bytes.Buffer
+	offsets := []int{}
+	writeObject := func(body string, stream []byte) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\n", len(offsets), body)
+		if stream != nil {
+			out.WriteString("stream\n")
+			out.Write(stream)
+			out.WriteString("\nendstream\n")
+		}
+		out.WriteString("endobj\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catálogo, 2 páginas, 3 y 4 fuentes, luego imágenes y páginas.
+	firstImage := 5
+	firstPage := firstImage + len(d.images)
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)), nil)
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>", nil)
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>", nil)
+
+	xObjects := make([]string, len(d.images))
+	for i, img := range d.images {
+		data, width, height, err := rgbStream(img)
+		if err != nil {
+			return nil, err
+		}
+		writeObject(fmt.Sprintf(
+			"<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>",
+			width, height, len(data),
+		), data)
+		xObjects[i] = fmt.Sprintf("/Im%d %d 0 R", i+1, firstImage+i)
+	}
+
+	resources := "<< /Font << /F1 3 0 R /F2 4 0 R >>"
+	if len(xObjects) > 0 {
+		resources += " /XObject << " + strings.Join(xObjects, " ") + " >>"
+	}
+	resources += " >>"
+
+	for i, page := range d.pages {
+		content, err := deflate(page.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		writeObject(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources %s /Contents %d 0 R >>",
+			d.width, d.height, resources, firstPage+i*2+1,
+		), nil)
+		writeObject(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>", len(content)), content)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes(), nil
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := zlib.NewWriter(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// rgbStream convierte la imagen a RGB de 8 bits; la transparencia se mezcla
+// con fondo blanco.
+func rgbStream(img image.Image) ([]byte, int, int, error) {
+	bounds := img.Bounds()
+	raw := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			white := 0xffff - a
+			raw = append(raw,
+				uint8((r+white)>>8),
+				uint8((g+white)>>8),
+				uint8((b+white)>>8),
+			)
+		}
+	}
+	data, err := deflate(raw)
+	return data, bounds.Dx(), bounds.Dy(), err
+}
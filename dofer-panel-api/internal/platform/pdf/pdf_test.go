@@ -0,0 +1,85 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderStatementProducesValidDocument(t *testing.T) {
+	logo := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	logo.Set(0, 0, color.RGBA{255, 0, 0, 255})
+
+	items := []LineItem{}
+	for i := 0; i < 40; i++ {
+		items = append(items, LineItem{Description: "Soporte (impreso) en PLA", Detail: "Peso: 35 g", Quantity: 2, UnitPrice: 120, Total: 240})
+	}
+	validUntil := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	document, err := RenderStatement(Statement{
+		Title:      "Cotización",
+		Number:     "COT-0001",
+		IssuedAt:   time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+		ValidUntil: &validUntil,
+		Brand:      Brand{Name: "Dofer", Logo: logo},
+		Customer:   Party{Name: "Ana Pérez"},
+		Items:      items,
+		Totals:     []TotalLine{{Label: "Total", Amount: 9600, Emphasis: true}},
+	})
+	if err != nil {
+		t.Fatalf("RenderStatement() error = %v", err)
+	}
+
+	if !bytes.HasPrefix(document, []byte("%PDF-1.4\n")) {
+		t.Fatalf("document does not start with PDF header")
+	}
+	if !bytes.HasSuffix(document, []byte("%%EOF\n")) {
+		t.Fatalf("document does not end with EOF marker")
+	}
+	if !bytes.Contains(document, []byte("/Subtype /Image")) {
+		t.Fatalf("expected logo image object")
+	}
+	if bytes.Contains(document, []byte("/Count 1 ")) {
+		t.Fatalf("expected 40 items to span several pages")
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	cases := map[float64]string{
+		0:          "$0.00",
+		12.5:       "$12.50",
+		1234.567:   "$1,234.57",
+		-250:       "-$250.00",
+		1000000.01: "$1,000,000.01",
+	}
+	for amount, expected := range cases {
+		if got := FormatMoney(amount); got != expected {
+			t.Fatalf("FormatMoney(%v) = %q, want %q", amount, got, expected)
+		}
+	}
+}
+
+func TestWrapTextKeepsLinesWithinWidth(t *testing.T) {
+	text := "Pieza de prueba con una descripción bastante larga para partirse\nsegunda línea"
+	lines := WrapText(text, Regular, 10, 120)
+	if len(lines) < 3 {
+		t.Fatalf("expected at least 3 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if strings.Contains(line, " ") && TextWidth(line, Regular, 10) > 120 {
+			t.Fatalf("line %q exceeds width", line)
+		}
+	}
+	if lines[len(lines)-1] != "segunda línea" {
+		t.Fatalf("expected explicit line break to be kept, got %q", lines[len(lines)-1])
+	}
+}
+
+func TestEncodeWinAnsi(t *testing.T) {
+	if got := encodeWinAnsi("Año €5 ✓"); got != "A\xf1o \x805 ?" {
+		t.Fatalf("encodeWinAnsi() = %q", got)
+	}
+}
@@ -0,0 +1,347 @@
+package pdf
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+	"time"
+)
+
+// DefaultBrandColor es el índigo que ya usa el panel web.
+var DefaultBrandColor = Color{79, 70, 229}
+
+const (
+	margin       = 40.0
+	contentRight = PageWidth - margin
+	bottomLimit  = PageHeight - 60
+)
+
+// Brand son los datos de la organización que encabezan el documento.
+type Brand struct {
+	Name      string
+	LegalName string
+	TaxID     string
+	Address   string
+	Phone     string
+	Email     string
+	Logo      image.Image
+	Color     *Color
+}
+
+type Party struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+type LineItem struct {
+	Description string
+	Detail      string
+	Quantity    int
+	UnitPrice   float64
+	Total       float64
+}
+
+type TotalLine struct {
+	Label    string
+	Amount   float64
+	Emphasis bool
+}
+
+type PaymentLine struct {
+	Date   time.Time
+	Method string
+	Notes  string
+	Amount float64
+}
+
+// Statement es un documento comercial (cotización, recibo) con encabezado de
+// la organización, cliente, renglones, totales y pagos.
+type Statement struct {
+	Title      string
+	Number     string
+	Status     string
+	IssuedAt   time.Time
+	ValidUntil *time.Time
+	Brand      Brand
+	Customer   Party
+	Notes      string
+	Items      []LineItem
+	Totals     []TotalLine
+	Payments   []PaymentLine
+	Footer     string
+}
+
+type statementWriter struct {
+	doc   *Document
+	color Color
+	y     float64
+}
+
+func RenderStatement(statement Statement) ([]byte, error) {
+	w := &statementWriter{doc: New(), color: DefaultBrandColor}
+	if statement.Brand.Color != nil {
+		w.color = *statement.Brand.Color
+	}
+
+	w.doc.AddPage()
+	w.header(statement)
+	w.parties(statement)
+	w.notes(statement.Notes)
+	w.items(statement.Items)
+	w.totals(statement.Totals)
+	w.payments(statement.Payments)
+	w.footers(statement)
+
+	return w.doc.Bytes()
+}
+
+// ensure abre otra página si no caben height puntos más.
+func (w *statementWriter) ensure(height float64) bool {
+	if w.y+height <= bottomLimit {
+		return false
+	}
+	w.doc.AddPage()
+	w.y = margin
+	return true
+}
+
+func (w *statementWriter) header(s Statement) {
+	const bandHeight = 96.0
+	w.doc.Rect(0, 0, PageWidth, bandHeight, w.color)
+
+	textX := margin
+	if s.Brand.Logo != nil {
+		bounds := s.Brand.Logo.Bounds()
+		if bounds.Dx() > 0 && bounds.Dy() > 0 {
+			// El logo cabe en 64x64 conservando su proporción.
+			scale := math.Min(64/float64(bounds.Dx()), 64/float64(bounds.Dy()))
+			width, height := float64(bounds.Dx())*scale, float64(bounds.Dy())*scale
+			w.doc.Rect(margin-4, (bandHeight-height)/2-4, width+8, height+8, White)
+			w.doc.Image(s.Brand.Logo, margin, (bandHeight-height)/2, width, height)
+			textX += width + 16
+		}
+	}
+
+	w.doc.Text(textX, 36, s.Brand.Name, Bold, 18, White)
+	lineY := 50.0
+	for _, line := range brandLines(s.Brand) {
+		w.doc.Text(textX, lineY, line, Regular, 8, White)
+		lineY += 10
+	}
+
+	w.doc.TextRight(contentRight, 36, strings.ToUpper(s.Title), Bold, 20, White)
+	if s.Number != "" {
+		w.doc.TextRight(contentRight, 54, s.Number, Bold, 11, White)
+	}
+	if s.Status != "" {
+		w.doc.TextRight(contentRight, 70, s.Status, Regular, 9, White)
+	}
+
+	w.y = bandHeight + 28
+}
+
+func brandLines(brand Brand) []string {
+	lines := []string{}
+	legal := strings.TrimSpace(brand.LegalName)
+	if brand.TaxID != "" {
+		legal = strings.TrimSpace(legal + "  RFC: " + brand.TaxID)
+	}
+	if legal != "" {
+		lines = append(lines, legal)
+	}
+	if brand.Address != "" {
+		lines = append(lines, brand.Address)
+	}
+	contact := strings.TrimSpace(strings.Join(nonEmpty(brand.Phone, brand.Email), "  ·  "))
+	if contact != "" {
+		lines = append(lines, contact)
+	}
+	if len(lines) > 3 {
+		lines = lines[:3]
+	}
+	return lines
+}
+
+func nonEmpty(values ...string) []string {
+	result := []string{}
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			result = append(result, strings.TrimSpace(value))
+		}
+	}
+	return result
+}
+
+func (w *statementWriter) parties(s Statement) {
+	top := w.y
+	w.doc.Text(margin, top, "CLIENTE", Bold, 8, Gray)
+	y := top + 14
+	w.doc.Text(margin, y, s.Customer.Name, Bold, 11, Black)
+	for _, line := range nonEmpty(s.Customer.Email, s.Customer.Phone) {
+		y += 13
+		w.doc.Text(margin, y, line, Regular, 9, Black)
+	}
+
+	const labelX = 400.0
+	w.doc.Text(labelX, top, "FECHAS", Bold, 8, Gray)
+	dateY := top + 14
+	w.doc.Text(labelX, dateY, "Emisión:", Regular, 9, Gray)
+	w.doc.TextRight(contentRight, dateY, formatDate(s.IssuedAt), Regular, 9, Black)
+	if s.ValidUntil != nil {
+		dateY += 13
+		w.doc.Text(labelX, dateY, "Válida hasta:", Regular, 9, Gray)
+		w.doc.TextRight(contentRight, dateY, formatDate(*s.ValidUntil), Bold, 9, Black)
+	}
+
+	w.y = math.Max(y, dateY) + 24
+}
+
+func (w *statementWriter) notes(notes string) {
+	lines := WrapText(notes, Regular, 9, contentRight-margin)
+	if len(lines) == 0 {
+		return
+	}
+	w.doc.Text(margin, w.y, "NOTAS", Bold, 8, Gray)
+	w.y += 13
+	for _, line := range lines {
+		w.ensure(12)
+		w.doc.Text(margin, w.y, line, Regular, 9, Black)
+		w.y += 12
+	}
+	w.y += 12
+}
+
+// Columnas de la tabla de renglones.
+const (
+	columnDescription = margin + 8
+	descriptionWidth  = 300.0
+	columnQuantity    = 390.0
+	columnUnitPrice   = 480.0
+	columnTotal       = contentRight - 8
+)
+
+func (w *statementWriter) tableHeader() {
+	w.doc.Rect(margin, w.y, contentRight-margin, 20, w.color)
+	baseline := w.y + 13.5
+	w.doc.Text(columnDescription, baseline, "Descripción", Bold, 9, White)
+	w.doc.TextRight(columnQuantity, baseline, "Cant.", Bold, 9, White)
+	w.doc.TextRight(columnUnitPrice, baseline, "P. unitario", Bold, 9, White)
+	w.doc.TextRight(columnTotal, baseline, "Importe", Bold, 9, White)
+	w.y += 20
+}
+
+func (w *statementWriter) items(items []LineItem) {
+	w.ensure(44)
+	w.tableHeader()
+
+	for _, item := range items {
+		description := WrapText(item.Description, Bold, 9, descriptionWidth)
+		detail := WrapText(item.Detail, Regular, 8, descriptionWidth)
+		height := 10 + float64(len(description))*11 + float64(len(detail))*10
+		if height < 24 {
+			height = 24
+		}
+		if w.ensure(height) {
+			w.tableHeader()
+		}
+
+		baseline := w.y + 14
+		w.doc.TextRight(columnQuantity, baseline, fmt.Sprintf("%d", item.Quantity), Regular, 9, Black)
+		w.doc.TextRight(columnUnitPrice, baseline, FormatMoney(item.UnitPrice), Regular, 9, Black)
+		w.doc.TextRight(columnTotal, baseline, FormatMoney(item.Total), Bold, 9, Black)
+		for _, line := range description {
+			w.doc.Text(columnDescription, baseline, line, Bold, 9, Black)
+			baseline += 11
+		}
+		for _, line := range detail {
+			w.doc.Text(columnDescription, baseline-1, line, Regular, 8, Gray)
+			baseline += 10
+		}
+
+		w.y += height
+		w.doc.Line(margin, w.y, contentRight, w.y, 0.5, Color{229, 231, 235})
+	}
+	w.y += 16
+}
+
+func (w *statementWriter) totals(lines []TotalLine) {
+	const labelX = 360.0
+	for _, line := range lines {
+		w.ensure(22)
+		if line.Emphasis {
+			w.doc.Rect(labelX-8, w.y, contentRight-labelX+8, 22, w.color)
+			w.doc.Text(labelX, w.y+15, line.Label, Bold, 11, White)
+			w.doc.TextRight(columnTotal, w.y+15, FormatMoney(line.Amount), Bold, 11, White)
+			w.y += 26
+			continue
+		}
+		w.doc.Text(labelX, w.y+12, line.Label, Regular, 9, Gray)
+		w.doc.TextRight(columnTotal, w.y+12, FormatMoney(line.Amount), Regular, 9, Black)
+		w.y += 17
+	}
+	w.y += 12
+}
+
+func (w *statementWriter) payments(payments []PaymentLine) {
+	if len(payments) == 0 {
+		return
+	}
+	w.ensure(40)
+	w.doc.Text(margin, w.y+10, "PAGOS", Bold, 8, Gray)
+	w.y += 16
+	for _, payment := range payments {
+		w.ensure(16)
+		baseline := w.y + 11
+		w.doc.Text(columnDescription, baseline, formatDate(payment.Date), Regular, 9, Black)
+		w.doc.Text(columnDescription+80, baseline, payment.Method, Regular, 9, Black)
+		notes := WrapText(payment.Notes, Regular, 8, 220)
+		if len(notes) > 0 {
+			w.doc.Text(columnDescription+170, baseline, notes[0], Regular, 8, Gray)
+		}
+		w.doc.TextRight(columnTotal, baseline, FormatMoney(payment.Amount), Regular, 9, Black)
+		w.y += 16
+		w.doc.Line(margin, w.y, contentRight, w.y, 0.5, Color{229, 231, 235})
+	}
+}
+
+// footers se dibuja al final, cuando ya se sabe cuántas páginas hay.
+func (w *statementWriter) footers(s Statement) {
+	total := w.doc.PageCount()
+	for page := 0; page < total; page++ {
+		w.doc.SetPage(page)
+		w.doc.Line(margin, PageHeight-44, contentRight, PageHeight-44, 0.5, Color{229, 231, 235})
+		if footer := WrapText(s.Footer, Regular, 8, 430); len(footer) > 0 {
+			w.doc.Text(margin, PageHeight-30, footer[0], Regular, 8, Gray)
+		}
+		w.doc.TextRight(contentRight, PageHeight-30, fmt.Sprintf("Página %d de %d", page+1, total), Regular, 8, Gray)
+	}
+}
+
+func formatDate(value time.Time) string {
+	if value.IsZero() {
+		return "-"
+	}
+	return value.Format("02/01/2006")
+}
+
+// FormatMoney da formato de pesos: $1,234.56.
+func FormatMoney(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	cents := int64(math.Round(amount * 100))
+	whole := fmt.Sprintf("%d", cents/100)
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	return fmt.Sprintf("%s$%s.%02d", sign, grouped.String(), cents%100)
+}
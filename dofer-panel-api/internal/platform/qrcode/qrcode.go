@@ -0,0 +1,472 @@
+// Package qrcode genera códigos QR sin dependencias externas. Solo cubre lo
+// que usan los tickets: modo byte, corrección de errores M y versiones 1 a 10
+// (hasta 213 bytes).
+package qrcode
+
+import "errors"
+
+var ErrTooLong = errors.New("qrcode: text does not fit in version 10")
+
+// Code es la matriz de módulos; true es un módulo oscuro.
+type Code struct {
+	Size    int
+	modules [][]bool
+}
+
+// Dark indica si el módulo de la columna x y la fila y es oscuro. Fuera de la
+// matriz (la zona de silencio) siempre es claro.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// versionSpec es la estructura de bloques del nivel M de cada versión.
+type versionSpec struct {
+	ecPerBlock int
+	groups     [][2]int // {bloques, palabras de datos por bloque}
+	alignment  []int
+}
+
+var versions = [...]versionSpec{
+	1:  {10, [][2]int{{1, 16}}, nil},
+	2:  {16, [][2]int{{1, 28}}, []int{6, 18}},
+	3:  {26, [][2]int{{1, 44}}, []int{6, 22}},
+	4:  {18, [][2]int{{2, 32}}, []int{6, 26}},
+	5:  {24, [][2]int{{2, 43}}, []int{6, 30}},
+	6:  {16, [][2]int{{4, 27}}, []int{6, 34}},
+	7:  {18, [][2]int{{4, 31}}, []int{6, 22, 38}},
+	8:  {22, [][2]int{{2, 38}, {2, 39}}, []int{6, 24, 42}},
+	9:  {22, [][2]int{{3, 36}, {2, 37}}, []int{6, 26, 46}},
+	10: {26, [][2]int{{4, 43}, {1, 44}}, []int{6, 28, 50}},
+}
+
+func (v versionSpec) dataCodewords() int {
+	total := 0
+	for _, group := range v.groups {
+		total += group[0] * group[1]
+	}
+	return total
+}
+
+// Encode elige la versión más chica donde cabe text y la máscara con menor
+// penalización.
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+	version := 0
+	for candidate := 1; candidate < len(versions); candidate++ {
+		countBits := 8
+		if candidate >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= versions[candidate].dataCodewords()*8 {
+			version = candidate
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	codewords := addErrorCorrection(encodeData(data, version), versions[version])
+
+	builder := newMatrix(version)
+	builder.drawFunctionPatterns()
+	builder.drawCodewords(codewords)
+
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		builder.applyMask(mask)
+		builder.drawFormatBits(mask)
+		if penalty := builder.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+		builder.applyMask(mask)
+	}
+	builder.applyMask(bestMask)
+	builder.drawFormatBits(bestMask)
+
+	return &Code{Size: builder.size, modules: builder.modules}, nil
+}
+
+// encodeData arma el flujo de bits en modo byte con terminador y relleno.
+func encodeData(data []byte, version int) []byte {
+	capacity := versions[version].dataCodewords()
+	bits := make([]bool, 0, capacity*8)
+	appendBits := func(value, length int) {
+		for i := length - 1; i >= 0; i-- {
+			bits = append(bits, (value>>i)&1 == 1)
+		}
+	}
+
+	appendBits(0b0100, 4)
+	if version >= 10 {
+		appendBits(len(data), 16)
+	} else {
+		appendBits(len(data), 8)
+	}
+	for _, b := range data {
+		appendBits(int(b), 8)
+	}
+	terminator := capacity*8 - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	appendBits(0, terminator)
+	if remainder := len(bits) % 8; remainder != 0 {
+		appendBits(0, 8-remainder)
+	}
+	for pad := 0xEC; len(bits) < capacity*8; pad ^= 0xEC ^ 0x11 {
+		appendBits(pad, 8)
+	}
+
+	result := make([]byte, capacity)
+	for i, bit := range bits {
+		if bit {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return result
+}
+
+// addErrorCorrection divide los datos en bloques, calcula su Reed-Solomon e
+// intercala datos y corrección como pide el estándar.
+func addErrorCorrection(data []byte, spec versionSpec) []byte {
+	blocks := make([][]byte, 0)
+	ecBlocks := make([][]byte, 0)
+	offset := 0
+	longest := 0
+	for _, group := range spec.groups {
+		for count := 0; count < group[0]; count++ {
+			block := data[offset : offset+group[1]]
+			offset += group[1]
+			blocks = append(blocks, block)
+			ecBlocks = append(ecBlocks, reedSolomonRemainder(block, spec.ecPerBlock))
+			if len(block) > longest {
+				longest = len(block)
+			}
+		}
+	}
+
+	result := make([]byte, 0, len(data)+len(blocks)*spec.ecPerBlock)
+	for i := 0; i < longest; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < spec.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplica en GF(256) con el polinomio 0x11D.
+func gfMultiply(x, y byte) byte {
+	var result byte
+	for i := 7; i >= 0; i-- {
+		carry := result >> 7
+		result <<= 1
+		if carry == 1 {
+			result ^= 0x1D
+		}
+		if (y>>i)&1 == 1 {
+			result ^= x
+		}
+	}
+	return result
+}
+
+func reedSolomonGenerator(degree int) []byte {
+	generator := make([]byte, degree)
+	generator[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range generator {
+			generator[j] = gfMultiply(generator[j], root)
+			if j+1 < len(generator) {
+				generator[j] ^= generator[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return generator
+}
+
+func reedSolomonRemainder(data []byte, degree int) []byte {
+	generator := reedSolomonGenerator(degree)
+	remainder := make([]byte, degree)
+	for _, b := range data {
+		factor := b ^ remainder[0]
+		copy(remainder, remainder[1:])
+		remainder[degree-1] = 0
+		for i := range remainder {
+			remainder[i] ^= gfMultiply(generator[i], factor)
+		}
+	}
+	return remainder
+}
+
+type matrix struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newMatrix(version int) *matrix {
+	size := 17 + 4*version
+	m := &matrix{version: version, size: size}
+	m.modules = make([][]bool, size)
+	m.function = make([][]bool, size)
+	for y := range m.modules {
+		m.modules[y] = make([]bool, size)
+		m.function[y] = make([]bool, size)
+	}
+	return m
+}
+
+func (m *matrix) setFunction(x, y int, dark bool) {
+	m.modules[y][x] = dark
+	m.function[y][x] = true
+}
+
+func (m *matrix) drawFunctionPatterns() {
+	for i := 0; i < m.size; i++ {
+		m.setFunction(6, i, i%2 == 0)
+		m.setFunction(i, 6, i%2 == 0)
+	}
+	m.drawFinder(3, 3)
+	m.drawFinder(m.size-4, 3)
+	m.drawFinder(3, m.size-4)
+
+	positions := versions[m.version].alignment
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Las esquinas con patrón de búsqueda no llevan alineación.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			m.drawAlignment(x, y)
+		}
+	}
+
+	// Reserva las zonas de formato; drawFormatBits las llena después.
+	m.drawFormatBits(0)
+	m.drawVersionBits()
+}
+
+func (m *matrix) drawFinder(centerX, centerY int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := centerX+dx, centerY+dy
+			if x < 0 || y < 0 || x >= m.size || y >= m.size {
+				continue
+			}
+			distance := max(abs(dx), abs(dy))
+			m.setFunction(x, y, distance != 2 && distance != 4)
+		}
+	}
+}
+
+func (m *matrix) drawAlignment(centerX, centerY int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.setFunction(centerX+dx, centerY+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// formatBits codifica el nivel M (00) y la máscara con su BCH.
+func formatBits(mask int) int {
+	data := mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 9) * 0x537)
+	}
+	return (data<<10 | remainder) ^ 0x5412
+}
+
+func versionBits(version int) int {
+	remainder := version
+	for i := 0; i < 12; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 11) * 0x1F25)
+	}
+	return version<<12 | remainder
+}
+
+func (m *matrix) drawFormatBits(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		m.setFunction(8, i, bit(i))
+	}
+	m.setFunction(8, 7, bit(6))
+	m.setFunction(8, 8, bit(7))
+	m.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		m.setFunction(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.setFunction(8, m.size-15+i, bit(i))
+	}
+	m.setFunction(8, m.size-8, true)
+}
+
+func (m *matrix) drawVersionBits() {
+	if m.version < 7 {
+		return
+	}
+	bits := versionBits(m.version)
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 == 1
+		a := m.size - 11 + i%3
+		b := i / 3
+		m.setFunction(a, b, dark)
+		m.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords recorre la matriz en zigzag de dos columnas desde la esquina
+// inferior derecha, saltando la columna de tiempo.
+func (m *matrix) drawCodewords(codewords []byte) {
+	index := 0
+	total := len(codewords) * 8
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vertical := 0; vertical < m.size; vertical++ {
+			y := vertical
+			if upward {
+				y = m.size - 1 - vertical
+			}
+			for column := 0; column < 2; column++ {
+				x := right - column
+				if m.function[y][x] || index >= total {
+					continue
+				}
+				m.modules[y][x] = (codewords[index/8]>>(7-index%8))&1 == 1
+				index++
+			}
+		}
+	}
+}
+
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				m.modules[y][x] = !m.modules[y][x]
+			}
+		}
+	}
+}
+
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty aplica las cuatro reglas de evaluación de máscaras del estándar.
+func (m *matrix) penalty() int {
+	result := 0
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return m.modules[x][y]
+		}
+		return m.modules[y][x]
+	}
+
+	for _, vertical := range []bool{false, true} {
+		for line := 0; line < m.size; line++ {
+			run := 1
+			for i := 1; i < m.size; i++ {
+				if at(i, line, vertical) == at(i-1, line, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += 3 + run - 5
+				}
+				run = 1
+			}
+			if run >= 5 {
+				result += 3 + run - 5
+			}
+
+			for i := 0; i+11 <= m.size; i++ {
+				for _, pattern := range finderLike {
+					matches := true
+					for k, dark := range pattern {
+						if at(i+k, line, vertical) != dark {
+							matches = false
+							break
+						}
+					}
+					if matches {
+						result += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.modules[y][x] {
+				dark++
+			}
+			if x+1 < m.size && y+1 < m.size {
+				color := m.modules[y][x]
+				if color == m.modules[y][x+1] && color == m.modules[y+1][x] && color == m.modules[y+1][x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+	total := m.size * m.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return result + k*10
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,95 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomonMatchesStandardExample(t *testing.T) {
+	// "HELLO WORLD" en versión 1-M (ejemplo clásico del estándar).
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomonRemainder(data, 10); !bytes.Equal(got, want) {
+		t.Fatalf("reedSolomonRemainder() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	if got := formatBits(0); got != 0b101010000010010 {
+		t.Fatalf("formatBits(0) = %015b", got)
+	}
+	if got := versionBits(7); got != 0b000111110010010100 {
+		t.Fatalf("versionBits(7) = %018b", got)
+	}
+}
+
+func TestEncodeChoosesVersionAndDrawsFinders(t *testing.T) {
+	code, err := Encode("SALE-20261017-ABCDEF12")
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if code.Size != 25 {
+		t.Fatalf("expected version 2 (25 modules), got %d", code.Size)
+	}
+	for _, corner := range [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}} {
+		for i := 0; i < 7; i++ {
+			if !code.Dark(corner[0]+i, corner[1]) || !code.Dark(corner[0], corner[1]+i) {
+				t.Fatalf("finder pattern at %v is incomplete", corner)
+			}
+		}
+		if code.Dark(corner[0]+1, corner[1]+1) || !code.Dark(corner[0]+3, corner[1]+3) {
+			t.Fatalf("finder pattern at %v has the wrong rings", corner)
+		}
+	}
+	if !code.Dark(8, code.Size-8) {
+		t.Fatalf("missing dark module")
+	}
+	if code.Dark(-1, 0) || code.Dark(code.Size, 0) {
+		t.Fatalf("quiet zone must be light")
+	}
+
+	// Las dos copias del formato deben coincidir.
+	var first, second int
+	for i := 0; i <= 5; i++ {
+		first |= bit(code.Dark(8, i)) << i
+	}
+	first |= bit(code.Dark(8, 7))<<6 | bit(code.Dark(8, 8))<<7 | bit(code.Dark(7, 8))<<8
+	for i := 9; i < 15; i++ {
+		first |= bit(code.Dark(14-i, 8)) << i
+	}
+	for i := 0; i < 8; i++ {
+		second |= bit(code.Dark(code.Size-1-i, 8)) << i
+	}
+	for i := 8; i < 15; i++ {
+		second |= bit(code.Dark(8, code.Size-15+i)) << i
+	}
+	if first != second {
+		t.Fatalf("format copies differ: %015b vs %015b", first, second)
+	}
+	valid := false
+	for mask := 0; mask < 8; mask++ {
+		valid = valid || formatBits(mask) == first
+	}
+	if !valid {
+		t.Fatalf("format bits %015b do not encode level M", first)
+	}
+}
+
+func TestEncodeLimits(t *testing.T) {
+	code, err := Encode(strings.Repeat("a", 213))
+	if err != nil || code.Size != 57 {
+		t.Fatalf("expected version 10 for 213 bytes, got %v %v", code, err)
+	}
+	if _, err := Encode(strings.Repeat("a", 214)); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("expected ErrTooLong, got %v", err)
+	}
+}
+
+func bit(dark bool) int {
+	if dark {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+// Package secrets cifra credenciales de integraciones antes de guardarlas en
+// la base de datos (AES-256-GCM). La clave sale de SECRETS_ENCRYPTION_KEY y
+// nunca se guarda junto a los datos.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+const prefix = "v1:"
+
+var (
+	ErrNoKey         = errors.New("SECRETS_ENCRYPTION_KEY is not configured")
+	ErrInvalidSecret = errors.New("encrypted value is invalid or was sealed with another key")
+)
+
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox acepta cualquier texto como clave; se deriva a 32 bytes con SHA-256.
+// Con clave vacía la caja existe pero Seal y Open devuelven ErrNoKey.
+func NewBox(key string) *Box {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return &Box{}
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return &Box{}
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return &Box{}
+	}
+	return &Box{aead: aead}
+}
+
+func (b *Box) Enabled() bool {
+	return b != nil && b.aead != nil
+}
+
+func (b *Box) Seal(plaintext string) (string, error) {
+	if !b.Enabled() {
+		return "", ErrNoKey
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *Box) Open(value string) (string, error) {
+	if !b.Enabled() {
+		return "", ErrNoKey
+	}
+	if !strings.HasPrefix(value, prefix) {
+		return "", ErrInvalidSecret
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", ErrInvalidSecret
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrInvalidSecret
+	}
+	return string(plaintext), nil
+}
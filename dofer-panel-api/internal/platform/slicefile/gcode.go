@@ -0,0 +1,147 @@
+package slicefile
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var durationPartPattern = regexp.MustCompile(`(\d+)\s*([dhms])`)
+
+// parseGCode lee solo los comentarios; PrusaSlicer y OrcaSlicer escriben las
+// estimaciones al final del archivo, Cura y Bambu Studio en la cabecera.
+func parseGCode(r io.Reader) (*Metadata, error) {
+	metadata := &Metadata{Format: FormatGCode, Slicer: SlicerUnknown}
+	var totalGrams float64
+
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, err := reader.ReadString('\n')
+		if strings.HasPrefix(line, ";") {
+			parseCommentLine(metadata, strings.TrimSpace(strings.TrimLeft(line, "; ")), &totalGrams)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if totalGrams > 0 {
+		metadata.FilamentGrams = totalGrams
+	}
+	return metadata, nil
+}
+
+func parseCommentLine(metadata *Metadata, line string, totalGrams *float64) {
+	lower := strings.ToLower(line)
+
+	switch {
+	case strings.Contains(lower, "generated by prusaslicer"):
+		metadata.Slicer = SlicerPrusa
+		return
+	case strings.Contains(lower, "generated by orcaslicer"):
+		metadata.Slicer = SlicerOrca
+		return
+	case strings.Contains(lower, "bambustudio"):
+		metadata.Slicer = SlicerBambu
+		return
+	case strings.Contains(lower, "cura_steamengine"):
+		metadata.Slicer = SlicerCura
+		return
+	}
+
+	// Cura: ;TIME:5025, ;PRINT.TIME:5025 y ;Filament used: 1.23m
+	if strings.HasPrefix(line, "TIME:") || strings.HasPrefix(line, "PRINT.TIME:") {
+		if seconds, err := strconv.Atoi(strings.TrimSpace(line[strings.Index(line, ":")+1:])); err == nil {
+			metadata.PrintTimeSeconds = seconds
+		}
+		return
+	}
+	if strings.HasPrefix(lower, "filament used:") {
+		metadata.FilamentLengthMM = sumList(line[len("filament used:"):], "m") * 1000
+		return
+	}
+
+	// Bambu Studio: "model printing time: 1h 2m; total estimated time: 1h 10m"
+	if strings.Contains(lower, "total estimated time:") {
+		value := lower[strings.Index(lower, "total estimated time:")+len("total estimated time:"):]
+		metadata.PrintTimeSeconds = parseDuration(value)
+		return
+	}
+
+	key, value, ok := splitKeyValue(line)
+	if !ok {
+		return
+	}
+	switch strings.ToLower(key) {
+	case "estimated printing time (normal mode)":
+		metadata.PrintTimeSeconds = parseDuration(value)
+	case "total filament used [g]", "total filament weight [g]":
+		*totalGrams = sumList(value, "")
+	case "filament used [g]":
+		metadata.FilamentGrams = sumList(value, "")
+	case "filament used [mm]", "total filament length [mm]":
+		metadata.FilamentLengthMM = sumList(value, "")
+	case "filament_type":
+		if metadata.Material == "" {
+			metadata.Material = firstListValue(value)
+		}
+	}
+}
+
+// splitKeyValue acepta "clave = valor" (Prusa/Orca) y "clave : valor" (Bambu).
+func splitKeyValue(line string) (string, string, bool) {
+	index := strings.Index(line, " = ")
+	if index < 0 {
+		index = strings.Index(line, " : ")
+	}
+	if index < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(line[:index]), strings.TrimSpace(line[index+3:]), true
+}
+
+// parseDuration entiende "1d 2h 3m 4s" y variantes sin espacios.
+func parseDuration(value string) int {
+	total := 0
+	for _, match := range durationPartPattern.FindAllStringSubmatch(strings.ToLower(value), -1) {
+		amount, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "d":
+			total += amount * 86400
+		case "h":
+			total += amount * 3600
+		case "m":
+			total += amount * 60
+		case "s":
+			total += amount
+		}
+	}
+	return total
+}
+
+// sumList suma listas por extrusor ("12.3, 4.5"), quitando un sufijo de unidad.
+func sumList(value, unit string) float64 {
+	total := 0.0
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		part = strings.TrimSuffix(strings.TrimSpace(part), unit)
+		if number, err := strconv.ParseFloat(strings.TrimSpace(part), 64); err == nil {
+			total += number
+		}
+	}
+	return total
+}
+
+func firstListValue(value string) string {
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		if part = strings.Trim(strings.TrimSpace(part), `"`); part != "" {
+			return strings.ToUpper(part)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+// Package slicefile extrae tiempo de impresión, filamento y material de los
+// archivos que generan los slicers (G-code y 3MF).
+package slicefile
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatGCode = "gcode"
+	Format3MF   = "3mf"
+
+	SlicerPrusa   = "prusaslicer"
+	SlicerCura    = "cura"
+	SlicerBambu   = "bambustudio"
+	SlicerOrca    = "orcaslicer"
+	SlicerUnknown = "unknown"
+
+	// Diámetro de filamento usado para convertir longitud a gramos cuando el
+	// slicer no reporta el peso (Cura).
+	defaultFilamentDiameterMM = 1.75
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported file format, expected .gcode, .3mf or .stl")
+	ErrNoSliceData       = errors.New("file has no slicer estimates; slice it before uploading")
+)
+
+// densities en g/cm³ de los materiales más comunes.
+var densities = map[string]float64{
+	"PLA":  1.24,
+	"PETG": 1.27,
+	"ABS":  1.04,
+	"ASA":  1.07,
+	"TPU":  1.21,
+	"PC":   1.20,
+	"PA":   1.14,
+}
+
+type Metadata struct {
+	Format           string  `json:"format"`
+	Slicer           string  `json:"slicer"`
+	PrintTimeSeconds int     `json:"print_time_seconds"`
+	FilamentGrams    float64 `json:"filament_grams"`
+	FilamentLengthMM float64 `json:"filament_length_mm"`
+	Material         string  `json:"material,omitempty"`
+	// WeightEstimated indica que los gramos no vienen del slicer (se
+	// calcularon desde la longitud o desde la malla).
+	WeightEstimated bool       `json:"weight_estimated,omitempty"`
+	Mesh            *MeshStats `json:"mesh,omitempty"`
+}
+
+func (m *Metadata) PrintTimeHours() float64 {
+	return math.Round(float64(m.PrintTimeSeconds)/3600*100) / 100
+}
+
+func (m *Metadata) PrintTimeMinutes() int {
+	return int(math.Ceil(float64(m.PrintTimeSeconds) / 60))
+}
+
+// Analyze acepta además modelos STL sin rebanar: tiempo y peso se estiman
+// desde la malla con el perfil dado.
+func Analyze(filename string, r io.Reader, profile PrintProfile) (*Metadata, error) {
+	if !strings.HasSuffix(strings.ToLower(filename), ".stl") {
+		return Parse(filename, r)
+	}
+	mesh, err := AnalyzeSTL(r)
+	if err != nil {
+		return nil, err
+	}
+	return EstimateFromMesh(mesh, profile), nil
+}
+
+// Parse lee archivos ya rebanados y detecta el formato por la extensión. Los
+// 3MF se leen completos en memoria porque zip necesita acceso aleatorio.
+func Parse(filename string, r io.Reader) (*Metadata, error) {
+	name := strings.ToLower(filename)
+
+	var (
+		metadata *Metadata
+		err      error
+	)
+	switch {
+	case strings.HasSuffix(name, ".3mf"):
+		data, readErr := io.ReadAll(r)
+		if readErr != nil {
+			return nil, readErr
+		}
+		metadata, err = parse3MF(bytes.NewReader(data), int64(len(data)))
+	case filepath.Ext(name) == ".gcode", filepath.Ext(name) == ".gco", filepath.Ext(name) == ".g":
+		metadata, err = parseGCode(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metadata.fillWeight()
+	if metadata.PrintTimeSeconds <= 0 && metadata.FilamentGrams <= 0 {
+		return nil, ErrNoSliceData
+	}
+	return metadata, nil
+}
+
+func (m *Metadata) fillWeight() {
+	if m.FilamentGrams > 0 || m.FilamentLengthMM <= 0 {
+		return
+	}
+	radius := defaultFilamentDiameterMM / 2
+	volumeCM3 := m.FilamentLengthMM * math.Pi * radius * radius / 1000
+	m.FilamentGrams = round2(volumeCM3 * density(m.Material))
+	m.WeightEstimated = true
+}
@@ -0,0 +1,216 @@
+package slicefile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParsePrusaSlicerGCode(t *testing.T) {
+	gcode := `; generated by PrusaSlicer 2.7.1+win64 on 2024-01-10 at 18:10:12 UTC
+G1 X10 Y10
+; filament used [mm] = 4567.89, 0.00
+; filament used [g] = 13.62, 0.00
+; total filament used [g] = 13.62
+; estimated printing time (normal mode) = 1h 23m 45s
+; filament_type = PETG;PLA
+`
+	metadata, err := Parse("pieza.gcode", strings.NewReader(gcode))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+
+	if metadata.Slicer != SlicerPrusa || metadata.Material != "PETG" {
+		t.Fatalf("unexpected slicer/material %+v", metadata)
+	}
+	if metadata.PrintTimeSeconds != 5025 || metadata.FilamentGrams != 13.62 || metadata.FilamentLengthMM != 4567.89 {
+		t.Fatalf("unexpected estimates %+v", metadata)
+	}
+	if metadata.PrintTimeHours() != 1.4 || metadata.PrintTimeMinutes() != 84 {
+		t.Fatalf("unexpected conversions %.2f h / %d min", metadata.PrintTimeHours(), metadata.PrintTimeMinutes())
+	}
+}
+
+func TestParseCuraGCodeEstimatesWeight(t *testing.T) {
+	gcode := `;FLAVOR:Marlin
+;TIME:3600
+;Filament used: 2.5m
+;Generated with Cura_SteamEngine 5.6.0
+;TIME_ELAPSED:12.5
+`
+	metadata, err := Parse("pieza.GCODE", strings.NewReader(gcode))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+
+	if metadata.Slicer != SlicerCura || metadata.PrintTimeSeconds != 3600 || metadata.FilamentLengthMM != 2500 {
+		t.Fatalf("unexpected metadata %+v", metadata)
+	}
+	// 2.5 m de PLA de 1.75 mm ≈ 7.46 g
+	if !metadata.WeightEstimated || metadata.FilamentGrams != 7.46 {
+		t.Fatalf("expected estimated weight of 7.46 g, got %+v", metadata)
+	}
+}
+
+func TestParseBambuGCodeHeader(t *testing.T) {
+	gcode := `; HEADER_BLOCK_START
+; BambuStudio 01.08.04.51
+; model printing time: 2h 5m 10s; total estimated time: 2h 12m 3s
+; total layer number: 120
+; total filament length [mm] : 8123.45
+; total filament weight [g] : 24.22
+; HEADER_BLOCK_END
+; filament_type = PLA
+`
+	metadata, err := Parse("plate_1.gcode", strings.NewReader(gcode))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+
+	if metadata.Slicer != SlicerBambu || metadata.PrintTimeSeconds != 7923 || metadata.FilamentGrams != 24.22 || metadata.Material != "PLA" {
+		t.Fatalf("unexpected metadata %+v", metadata)
+	}
+}
+
+func TestParse3MFSliceInfo(t *testing.T) {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	writeZipFile(t, archive, "3D/3dmodel.model", "<model/>")
+	writeZipFile(t, archive, "Metadata/slice_info.config", `<?xml version="1.0" encoding="UTF-8"?>
+<config>
+  <plate>
+    <metadata key="index" value="1"/>
+    <metadata key="prediction" value="5400"/>
+    <metadata key="weight" value="31.50"/>
+    <filament id="1" type="PETG" color="#FFFFFF" used_m="10.2" used_g="31.50"/>
+  </plate>
+</config>`)
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := Parse("llavero.3mf", &buffer)
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+
+	if metadata.Format != Format3MF || metadata.PrintTimeSeconds != 5400 || metadata.FilamentGrams != 31.5 || metadata.Material != "PETG" {
+		t.Fatalf("unexpected metadata %+v", metadata)
+	}
+}
+
+func TestParseRejectsUnslicedFiles(t *testing.T) {
+	if _, err := Parse("modelo.stl", strings.NewReader("solid x")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+	if _, err := Parse("vacio.gcode", strings.NewReader("G28\nG1 X1\n")); !errors.Is(err, ErrNoSliceData) {
+		t.Fatalf("expected ErrNoSliceData, got %v", err)
+	}
+}
+
+func writeZipFile(t *testing.T, archive *zip.Writer, name, content string) {
+	t.Helper()
+	file, err := archive.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// cubeTriangles es un cubo de 10 mm con las normales hacia afuera.
+func cubeTriangles() [][3][3]float32 {
+	p := [8][3]float32{
+		{0, 0, 0}, {10, 0, 0}, {10, 10, 0}, {0, 10, 0},
+		{0, 0, 10}, {10, 0, 10}, {10, 10, 10}, {0, 10, 10},
+	}
+	faces := [][3]int{
+		{0, 2, 1}, {0, 3, 2}, {4, 5, 6}, {4, 6, 7},
+		{0, 1, 5}, {0, 5, 4}, {1, 2, 6}, {1, 6, 5},
+		{2, 3, 7}, {2, 7, 6}, {3, 0, 4}, {3, 4, 7},
+	}
+	triangles := make([][3][3]float32, 0, len(faces))
+	for _, face := range faces {
+		triangles = append(triangles, [3][3]float32{p[face[0]], p[face[1]], p[face[2]]})
+	}
+	return triangles
+}
+
+func binaryCube() []byte {
+	var buffer bytes.Buffer
+	buffer.Write(make([]byte, 80))
+	triangles := cubeTriangles()
+	binary.Write(&buffer, binary.LittleEndian, uint32(len(triangles)))
+	for _, triangle := range triangles {
+		binary.Write(&buffer, binary.LittleEndian, [3]float32{})
+		binary.Write(&buffer, binary.LittleEndian, triangle)
+		binary.Write(&buffer, binary.LittleEndian, uint16(0))
+	}
+	return buffer.Bytes()
+}
+
+func asciiCube() string {
+	var builder strings.Builder
+	builder.WriteString("solid cube\n")
+	for _, triangle := range cubeTriangles() {
+		builder.WriteString("  facet normal 0 0 0\n    outer loop\n")
+		for _, v := range triangle {
+			fmt.Fprintf(&builder, "      vertex %g %g %g\n", v[0], v[1], v[2])
+		}
+		builder.WriteString("    endloop\n  endfacet\n")
+	}
+	builder.WriteString("endsolid cube\n")
+	return builder.String()
+}
+
+func TestAnalyzeSTLBinaryAndASCII(t *testing.T) {
+	for name, input := range map[string][]byte{
+		"binary": binaryCube(),
+		"ascii":  []byte(asciiCube()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			mesh, err := AnalyzeSTL(bytes.NewReader(input))
+			if err != nil {
+				t.Fatalf("AnalyzeSTL returned an error: %v", err)
+			}
+			if mesh.Triangles != 12 || mesh.VolumeCM3 != 1 || mesh.SurfaceAreaCM2 != 6 {
+				t.Fatalf("unexpected mesh stats %+v", mesh)
+			}
+			if mesh.SizeMM != [3]float64{10, 10, 10} {
+				t.Fatalf("unexpected bounding box %v", mesh.SizeMM)
+			}
+		})
+	}
+
+	if _, err := AnalyzeSTL(strings.NewReader("not a mesh")); !errors.Is(err, ErrInvalidSTL) {
+		t.Fatalf("expected ErrInvalidSTL, got %v", err)
+	}
+}
+
+func TestEstimateFromMeshUsesInfillAndDensity(t *testing.T) {
+	mesh := &MeshStats{Triangles: 12, VolumeCM3: 8, SurfaceAreaCM2: 24, SizeMM: [3]float64{20, 20, 20}}
+
+	sparse := EstimateFromMesh(mesh, PrintProfile{Material: "pla", InfillPercentage: 15})
+	solid := EstimateFromMesh(mesh, PrintProfile{Material: "PLA", InfillPercentage: 100})
+
+	// 100% de relleno imprime todo el volumen: 8 cm³ × 1.24 g/cm³.
+	if solid.FilamentGrams != 9.92 {
+		t.Fatalf("expected 9.92 g for a solid print, got %.2f", solid.FilamentGrams)
+	}
+	if sparse.FilamentGrams >= solid.FilamentGrams || sparse.PrintTimeSeconds >= solid.PrintTimeSeconds {
+		t.Fatalf("sparse infill should be lighter and faster: %+v vs %+v", sparse, solid)
+	}
+	if sparse.Format != FormatSTL || !sparse.WeightEstimated || sparse.Material != "PLA" || sparse.Mesh != mesh {
+		t.Fatalf("unexpected metadata %+v", sparse)
+	}
+
+	fine := EstimateFromMesh(mesh, PrintProfile{InfillPercentage: 15, LayerHeightMM: 0.1})
+	if fine.PrintTimeSeconds <= sparse.PrintTimeSeconds {
+		t.Fatal("thinner layers should take longer")
+	}
+}
@@ -0,0 +1,244 @@
+package slicefile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatSTL      = "stl"
+	SlicerEstimate = "estimate"
+
+	defaultInfillPercentage = 20.0
+	defaultLayerHeightMM    = 0.2
+	defaultPrintSpeedMMS    = 60.0
+	// Perímetros típicos: 2 líneas de 0.45 mm.
+	extrusionWidthMM = 0.45
+	shellThicknessMM = 2 * extrusionWidthMM
+	// Fracción del tiempo que la boquilla extruye a velocidad nominal
+	// (aceleraciones, desplazamientos, retracciones).
+	printEfficiency = 0.6
+	// Cambio de capa, z-hop y limpieza.
+	layerOverheadSeconds = 2.0
+)
+
+var ErrInvalidSTL = errors.New("invalid STL file")
+
+type MeshStats struct {
+	Triangles      int        `json:"triangles"`
+	VolumeCM3      float64    `json:"volume_cm3"`
+	SurfaceAreaCM2 float64    `json:"surface_area_cm2"`
+	SizeMM         [3]float64 `json:"size_mm"` // Caja envolvente X, Y, Z
+}
+
+// PrintProfile son los parámetros de impresión para estimar desde la malla;
+// los valores en cero usan los defaults (20% de relleno, capa de 0.2 mm).
+type PrintProfile struct {
+	Material         string
+	InfillPercentage float64
+	LayerHeightMM    float64
+	PrintSpeedMMS    float64
+}
+
+type vertex [3]float64
+
+// AnalyzeSTL lee STL binario o ASCII (en milímetros) y calcula volumen,
+// área y caja envolvente.
+func AnalyzeSTL(r io.Reader) (*MeshStats, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var triangles [][3]vertex
+	if isBinarySTL(data) {
+		triangles, err = readBinarySTL(data)
+	} else {
+		triangles, err = readASCIISTL(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(triangles) == 0 {
+		return nil, ErrInvalidSTL
+	}
+
+	return meshStats(triangles), nil
+}
+
+// isBinarySTL: algunos exportadores binarios también empiezan con "solid",
+// así que manda el tamaño declarado.
+func isBinarySTL(data []byte) bool {
+	if len(data) < 84 {
+		return false
+	}
+	count := binary.LittleEndian.Uint32(data[80:84])
+	return uint64(len(data)) == 84+uint64(count)*50
+}
+
+func readBinarySTL(data []byte) ([][3]vertex, error) {
+	count := int(binary.LittleEndian.Uint32(data[80:84]))
+	triangles := make([][3]vertex, 0, count)
+	offset := 84
+	for i := 0; i < count; i++ {
+		var triangle [3]vertex
+		// Se salta la normal (12 bytes); al final van 2 bytes de atributos.
+		position := offset + 12
+		for v := 0; v < 3; v++ {
+			for axis := 0; axis < 3; axis++ {
+				bits := binary.LittleEndian.Uint32(data[position : position+4])
+				triangle[v][axis] = float64(math.Float32frombits(bits))
+				position += 4
+			}
+		}
+		triangles = append(triangles, triangle)
+		offset += 50
+	}
+	return triangles, nil
+}
+
+func readASCIISTL(data []byte) ([][3]vertex, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid")) {
+		return nil, ErrInvalidSTL
+	}
+
+	triangles := [][3]vertex{}
+	current := [3]vertex{}
+	count := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "vertex":
+			if len(fields) != 4 || count >= 3 {
+				return nil, ErrInvalidSTL
+			}
+			for axis := 0; axis < 3; axis++ {
+				value, err := strconv.ParseFloat(fields[axis+1], 64)
+				if err != nil {
+					return nil, ErrInvalidSTL
+				}
+				current[count][axis] = value
+			}
+			count++
+		case "endfacet":
+			if count != 3 {
+				return nil, ErrInvalidSTL
+			}
+			triangles = append(triangles, current)
+			count = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return triangles, nil
+}
+
+func meshStats(triangles [][3]vertex) *MeshStats {
+	var volume, area float64
+	minimum := vertex{math.Inf(1), math.Inf(1), math.Inf(1)}
+	maximum := vertex{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+
+	for _, t := range triangles {
+		a, b, c := t[0], t[1], t[2]
+		// Volumen con signo del tetraedro contra el origen.
+		volume += dot(a, cross(b, c)) / 6
+		area += length(cross(sub(b, a), sub(c, a))) / 2
+		for _, v := range t {
+			for axis := 0; axis < 3; axis++ {
+				minimum[axis] = math.Min(minimum[axis], v[axis])
+				maximum[axis] = math.Max(maximum[axis], v[axis])
+			}
+		}
+	}
+
+	stats := &MeshStats{
+		Triangles:      len(triangles),
+		VolumeCM3:      round2(math.Abs(volume) / 1000),
+		SurfaceAreaCM2: round2(area / 100),
+	}
+	for axis := 0; axis < 3; axis++ {
+		stats.SizeMM[axis] = round2(maximum[axis] - minimum[axis])
+	}
+	return stats
+}
+
+// EstimateFromMesh aproxima gramos y tiempo: paredes macizas de 0.9 mm sobre
+// la superficie más el relleno del interior, impresos al caudal que permiten
+// la altura de capa y la velocidad.
+func EstimateFromMesh(mesh *MeshStats, profile PrintProfile) *Metadata {
+	infill := profile.InfillPercentage
+	if infill <= 0 {
+		infill = defaultInfillPercentage
+	}
+	infill = math.Min(infill, 100)
+	layerHeight := profile.LayerHeightMM
+	if layerHeight <= 0 {
+		layerHeight = defaultLayerHeightMM
+	}
+	speed := profile.PrintSpeedMMS
+	if speed <= 0 {
+		speed = defaultPrintSpeedMMS
+	}
+
+	volume := mesh.VolumeCM3 * 1000
+	shell := math.Min(mesh.SurfaceAreaCM2*100*shellThicknessMM, volume)
+	printed := shell + (volume-shell)*infill/100
+
+	flow := layerHeight * extrusionWidthMM * speed * printEfficiency
+	layers := math.Ceil(mesh.SizeMM[2] / layerHeight)
+	seconds := printed/flow + layers*layerOverheadSeconds
+
+	material := strings.ToUpper(strings.TrimSpace(profile.Material))
+	return &Metadata{
+		Format:           FormatSTL,
+		Slicer:           SlicerEstimate,
+		PrintTimeSeconds: int(math.Ceil(seconds)),
+		FilamentGrams:    round2(printed / 1000 * density(material)),
+		Material:         material,
+		WeightEstimated:  true,
+		Mesh:             mesh,
+	}
+}
+
+func density(material string) float64 {
+	if value, ok := densities[strings.ToUpper(material)]; ok {
+		return value
+	}
+	return densities["PLA"]
+}
+
+func sub(a, b vertex) vertex {
+	return vertex{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func cross(a, b vertex) vertex {
+	return vertex{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func dot(a, b vertex) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func length(a vertex) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
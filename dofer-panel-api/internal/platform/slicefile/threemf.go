@@ -0,0 +1,153 @@
+package slicefile
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type sliceInfo struct {
+	Plates []struct {
+		Metadata []struct {
+			Key   string `xml:"key,attr"`
+			Value string `xml:"value,attr"`
+		} `xml:"metadata"`
+		Filaments []struct {
+			Type  string `xml:"type,attr"`
+			UsedM string `xml:"used_m,attr"`
+			UsedG string `xml:"used_g,attr"`
+		} `xml:"filament"`
+	} `xml:"plate"`
+}
+
+// parse3MF: Bambu Studio y OrcaSlicer guardan las estimaciones en
+// Metadata/slice_info.config y el G-code por placa en Metadata/plate_N.gcode;
+// PrusaSlicer solo guarda su configuración (filament_type).
+func parse3MF(r io.ReaderAt, size int64) (*Metadata, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	metadata := &Metadata{Format: Format3MF, Slicer: SlicerUnknown}
+	var embedded []*zip.File
+
+	for _, file := range archive.File {
+		name := strings.ToLower(file.Name)
+		switch {
+		case name == "metadata/slice_info.config":
+			if err := readSliceInfo(file, metadata); err != nil {
+				return nil, err
+			}
+		case name == "metadata/slic3r_pe.config" || name == "metadata/project_settings.config":
+			if err := readConfigMaterial(file, metadata); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(name, "metadata/") && strings.HasSuffix(name, ".gcode"):
+			embedded = append(embedded, file)
+		}
+	}
+
+	// Sin slice_info se usa el G-code embebido, sumando todas las placas.
+	if metadata.PrintTimeSeconds == 0 && metadata.FilamentGrams == 0 {
+		for _, file := range embedded {
+			plate, err := openGCode(file)
+			if err != nil {
+				return nil, err
+			}
+			plate.fillWeight()
+			metadata.PrintTimeSeconds += plate.PrintTimeSeconds
+			metadata.FilamentGrams += plate.FilamentGrams
+			metadata.FilamentLengthMM += plate.FilamentLengthMM
+			if metadata.Material == "" {
+				metadata.Material = plate.Material
+			}
+			if metadata.Slicer == SlicerUnknown {
+				metadata.Slicer = plate.Slicer
+			}
+		}
+	}
+
+	return metadata, nil
+}
+
+func readSliceInfo(file *zip.File, metadata *Metadata) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var info sliceInfo
+	if err := xml.NewDecoder(rc).Decode(&info); err != nil {
+		return err
+	}
+
+	if metadata.Slicer == SlicerUnknown {
+		metadata.Slicer = SlicerBambu
+	}
+	for _, plate := range info.Plates {
+		for _, entry := range plate.Metadata {
+			switch entry.Key {
+			case "prediction":
+				seconds, _ := strconv.Atoi(entry.Value)
+				metadata.PrintTimeSeconds += seconds
+			case "weight":
+				grams, _ := strconv.ParseFloat(entry.Value, 64)
+				metadata.FilamentGrams += grams
+			}
+		}
+		for _, filament := range plate.Filaments {
+			meters, _ := strconv.ParseFloat(filament.UsedM, 64)
+			metadata.FilamentLengthMM += meters * 1000
+			if metadata.Material == "" && filament.Type != "" {
+				metadata.Material = strings.ToUpper(filament.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// readConfigMaterial lee filament_type de la configuración del proyecto; en
+// Bambu/Orca es JSON y en PrusaSlicer "; clave = valor".
+func readConfigMaterial(file *zip.File, metadata *Metadata) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimLeft(scanner.Text(), "; "))
+		if !strings.HasPrefix(line, "filament_type") && !strings.HasPrefix(line, `"filament_type"`) {
+			continue
+		}
+		index := strings.IndexAny(line, "=:")
+		if index < 0 {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(line[index+1:]), "[],")
+		if metadata.Material == "" {
+			metadata.Material = firstListValue(value)
+		}
+		if metadata.Slicer == SlicerUnknown && strings.EqualFold(file.Name, "metadata/slic3r_pe.config") {
+			metadata.Slicer = SlicerPrusa
+		}
+		break
+	}
+	return scanner.Err()
+}
+
+func openGCode(file *zip.File) (*Metadata, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return parseGCode(rc)
+}
@@ -0,0 +1,35 @@
+// Package unitofwork agrupa escrituras de varios repositorios en una sola
+// transacción de Postgres: o se confirman todas o no queda ninguna.
+package unitofwork
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DB lo cumplen tanto *pgxpool.Pool como pgx.Tx. Los repositorios que guardan
+// un DB funcionan igual sobre el pool o dentro de una transacción abierta; un
+// Begin dentro de la transacción abre un savepoint.
+type DB interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Run ejecuta fn en una transacción y la confirma solo si fn no devuelve
+// error. Si fn falla la transacción se revierte y se devuelve el error de fn.
+func Run(ctx context.Context, db DB, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
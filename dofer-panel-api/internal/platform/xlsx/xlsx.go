@@ -0,0 +1,179 @@
+// Package xlsx escribe libros de Excel sencillos (una o varias hojas con
+// texto y números) sin dependencias externas. Los textos van como cadenas en
+// línea, así que no hace falta tabla de cadenas compartidas ni estilos.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+type Sheet struct {
+	Name string
+	Rows [][]any
+}
+
+// Encode arma el archivo .xlsx. Las celdas int, float64 y bool se guardan
+// como valores; cualquier otro tipo se escribe como texto.
+func Encode(sheets ...Sheet) ([]byte, error) {
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("xlsx: at least one sheet is required")
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes(len(sheets))},
+		{"_rels/.rels", rootRelationships},
+		{"xl/workbook.xml", workbook(sheets)},
+		{"xl/_rels/workbook.xml.rels", workbookRelationships(len(sheets))},
+	}
+	for _, file := range files {
+		if err := writeFile(archive, file.name, file.content); err != nil {
+			return nil, err
+		}
+	}
+	for index, sheet := range sheets {
+		writer, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", index+1))
+		if err != nil {
+			return nil, err
+		}
+		if err := writeWorksheet(writer, sheet.Rows); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+const rootRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func contentTypes(sheetCount int) string {
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	builder.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	builder.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	builder.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	for index := 1; index <= sheetCount; index++ {
+		fmt.Fprintf(&builder, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, index)
+	}
+	builder.WriteString(`</Types>`)
+	return builder.String()
+}
+
+func workbook(sheets []Sheet) string {
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	used := make(map[string]bool, len(sheets))
+	for index, sheet := range sheets {
+		name := sheetName(sheet.Name, index, used)
+		fmt.Fprintf(&builder, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(name), index+1, index+1)
+	}
+	builder.WriteString(`</sheets></workbook>`)
+	return builder.String()
+}
+
+func workbookRelationships(sheetCount int) string {
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for index := 1; index <= sheetCount; index++ {
+		fmt.Fprintf(&builder, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, index, index)
+	}
+	builder.WriteString(`</Relationships>`)
+	return builder.String()
+}
+
+func writeWorksheet(w io.Writer, rows [][]any) error {
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for rowIndex, row := range rows {
+		fmt.Fprintf(&builder, `<row r="%d">`, rowIndex+1)
+		for columnIndex, value := range row {
+			ref := ColumnName(columnIndex) + strconv.Itoa(rowIndex+1)
+			switch typed := value.(type) {
+			case nil:
+				continue
+			case int:
+				fmt.Fprintf(&builder, `<c r="%s"><v>%d</v></c>`, ref, typed)
+			case float64:
+				fmt.Fprintf(&builder, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(typed, 'f', -1, 64))
+			case bool:
+				flag := 0
+				if typed {
+					flag = 1
+				}
+				fmt.Fprintf(&builder, `<c r="%s" t="b"><v>%d</v></c>`, ref, flag)
+			default:
+				fmt.Fprintf(&builder, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escape(fmt.Sprint(typed)))
+			}
+		}
+		builder.WriteString(`</row>`)
+	}
+	builder.WriteString(`</sheetData></worksheet>`)
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// ColumnName convierte un índice desde cero en la letra de columna (0 = A,
+// 26 = AA).
+func ColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// sheetName respeta las reglas de Excel: máximo 31 caracteres, sin : \ / ? * [ ]
+// y sin repetirse.
+func sheetName(name string, index int, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" || used[strings.ToLower(name)] {
+		name = fmt.Sprintf("Hoja%d", index+1)
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+func writeFile(archive *zip.Writer, name, content string) error {
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(writer, content)
+	return err
+}
+
+func escape(value string) string {
+	var builder strings.Builder
+	_ = xml.EscapeText(&builder, []byte(value))
+	return builder.String()
+}
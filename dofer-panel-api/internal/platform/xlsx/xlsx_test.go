@@ -0,0 +1,76 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEncodeProducesReadableWorkbook(t *testing.T) {
+	document, err := Encode(
+		Sheet{Name: "Ventas", Rows: [][]any{
+			{"ID Venta", "Producto", "Cantidad", "Total", "Activo"},
+			{"V-1", "Capibara <café> & té", 2, 80.5, true},
+		}},
+		Sheet{Name: "Ventas", Rows: [][]any{{"duplicada"}}},
+	)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(document), int64(len(document)))
+	if err != nil {
+		t.Fatalf("document is not a zip: %v", err)
+	}
+	files := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(reader)
+		reader.Close()
+		files[file.Name] = string(content)
+
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not valid XML: %v", file.Name, err)
+			}
+		}
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("missing %s", name)
+		}
+	}
+	if !strings.Contains(files["xl/workbook.xml"], `name="Hoja2"`) {
+		t.Fatalf("expected duplicated sheet name to be replaced: %s", files["xl/workbook.xml"])
+	}
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, expected := range []string{
+		`<c r="C2"><v>2</v></c>`,
+		`<c r="D2"><v>80.5</v></c>`,
+		`<c r="E2" t="b"><v>1</v></c>`,
+		`Capibara &lt;café&gt; &amp; té`,
+	} {
+		if !strings.Contains(sheet, expected) {
+			t.Fatalf("sheet1 does not contain %q", expected)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	cases := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for index, expected := range cases {
+		if got := ColumnName(index); got != expected {
+			t.Fatalf("ColumnName(%d) = %q, want %q", index, got, expected)
+		}
+	}
+}
@@ -53,10 +53,14 @@ export default function QuoteDetailPage() {
     loadQuote()
   }, [loadQuote])
 
-  const updateStatus = async (newStatus: 'pending' | 'approved' | 'rejected' | 'expired') => {
+  // Reabrir (volver a draft) renueva la vigencia porque suele estar vencida.
+  const updateStatus = async (newStatus: Quote['status']) => {
     try {
       setUpdating(true)
-      await apiClient.patch(`/quotes/${quoteId}/status`, { status: newStatus })
+      await apiClient.patch(`/quotes/${quoteId}/status`, {
+        status: newStatus,
+        ...(newStatus === 'draft' ? { valid_days: 15 } : {}),
+      })
       await loadQuote()
       addToast({
         title: 'Estado actualizado',
@@ -92,13 +96,15 @@ export default function QuoteDetailPage() {
 
   const getStatusBadge = (status: string) => {
     const badges = {
-      pending: 'bg-yellow-100 text-yellow-800',
+      draft: 'bg-yellow-100 text-yellow-800',
+      sent: 'bg-blue-100 text-blue-800',
       approved: 'bg-green-100 text-green-800',
       rejected: 'bg-red-100 text-red-800',
       expired: 'bg-gray-100 text-gray-800',
     }
     const labels = {
-      pending: '⏳ Pendiente',
+      draft: '📝 Borrador',
+      sent: '📨 Enviada',
       approved: '✅ Aprobada',
       rejected: '❌ Rechazada',
       expired: '⌛ Expirada',
@@ -461,7 +467,7 @@ export default function QuoteDetailPage() {
         <h2 className="text-lg font-semibold text-gray-900 mb-4">⚡ Acciones</h2>
         
         <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-4 gap-3">
-          {quote.status === 'pending' && (
+          {(quote.status === 'draft' || quote.status === 'sent') && (
             <>
               <button
                 onClick={() => router.push(`/dashboard/quotes/new?edit=${quote.id}`)}
@@ -469,13 +475,24 @@ export default function QuoteDetailPage() {
               >
                 ✏️ Editar Cotización
               </button>
-              <button
-                onClick={() => updateStatus('approved')}
-                disabled={updating}
-                className="bg-green-600 text-white font-semibold py-2 px-4 rounded-lg hover:bg-green-700 disabled:opacity-50"
-              >
-                ✅ Aprobar
-              </button>
+              {quote.status === 'draft' && (
+                <button
+                  onClick={() => updateStatus('sent')}
+                  disabled={updating}
+                  className="bg-blue-600 text-white font-semibold py-2 px-4 rounded-lg hover:bg-blue-700 disabled:opacity-50"
+                >
+                  📨 Marcar como Enviada
+                </button>
+              )}
+              {quote.status === 'sent' && (
+                <button
+                  onClick={() => updateStatus('approved')}
+                  disabled={updating}
+                  className="bg-green-600 text-white font-semibold py-2 px-4 rounded-lg hover:bg-green-700 disabled:opacity-50"
+                >
+                  ✅ Aprobar
+                </button>
+              )}
               <button
                 onClick={() => updateStatus('rejected')}
                 disabled={updating}
@@ -486,6 +503,16 @@ export default function QuoteDetailPage() {
             </>
           )}
 
+          {(quote.status === 'rejected' || quote.status === 'expired') && (
+            <button
+              onClick={() => updateStatus('draft')}
+              disabled={updating}
+              className="bg-yellow-600 text-white font-semibold py-2 px-4 rounded-lg hover:bg-yellow-700 disabled:opacity-50"
+            >
+              🔁 Reabrir Cotización
+            </button>
+          )}
+
           {quote.status === 'approved' && (
             <>
               <button
@@ -14,19 +14,20 @@ import { Badge } from '@/components/ui/badge'
 import { Plus, Filter, AlertTriangle, Edit, Search, X } from 'lucide-react'
 import { Quote } from '@/types'
 
-type QuoteStatusFilter = 'all' | 'pending' | 'approved' | 'rejected' | 'expired'
+type QuoteStatusFilter = 'all' | 'draft' | 'sent' | 'approved' | 'rejected' | 'expired'
 const SAVED_QUOTE_VIEWS_KEY = 'dofer_quotes_saved_views_v1'
 
 const QUOTE_STATUS_OPTIONS: Array<{ value: QuoteStatusFilter; label: string }> = [
   { value: 'all', label: 'Todas' },
-  { value: 'pending', label: 'Pendientes' },
+  { value: 'draft', label: 'Borradores' },
+  { value: 'sent', label: 'Enviadas' },
   { value: 'approved', label: 'Aprobadas' },
   { value: 'rejected', label: 'Rechazadas' },
   { value: 'expired', label: 'Expiradas' },
 ]
 
 function normalizeStatusFilter(value: string | null): QuoteStatusFilter {
-  if (value === 'pending' || value === 'approved' || value === 'rejected' || value === 'expired') {
+  if (value === 'draft' || value === 'sent' || value === 'approved' || value === 'rejected' || value === 'expired') {
     return value
   }
   return 'all'
@@ -109,7 +110,8 @@ export default function QuotesPage() {
 
   const getStatusBadge = (status: string): 'default' | 'secondary' | 'destructive' | 'outline' => {
     const variants: Record<string, 'default' | 'secondary' | 'destructive' | 'outline'> = {
-      pending: 'default',
+      draft: 'default',
+      sent: 'default',
       approved: 'outline',
       rejected: 'destructive',
       expired: 'secondary',
@@ -119,7 +121,8 @@ export default function QuotesPage() {
 
   const getStatusLabel = (status: string) => {
     const labels: Record<string, string> = {
-      pending: 'Pendiente',
+      draft: 'Borrador',
+      sent: 'Enviada',
       approved: 'Aprobada',
       rejected: 'Rechazada',
       expired: 'Expirada',
@@ -382,7 +385,7 @@ export default function QuotesPage() {
                       })}
                     </td>
                     <td className="py-3 px-4 text-right">
-                      {quote.status === 'pending' ? (
+                      {quote.status === 'draft' || quote.status === 'sent' ? (
                         <div className="flex items-center justify-end gap-2">
                           <Button
                             variant="outline"
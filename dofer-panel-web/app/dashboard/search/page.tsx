@@ -38,9 +38,17 @@ export default function SearchPage() {
   const [results, setResults] = useState<(Order | Quote)[]>([]);
   const [loading, setLoading] = useState(false);
   const [totalResults, setTotalResults] = useState(0);
+  const [nextCursor, setNextCursor] = useState('');
+  const [loadingMore, setLoadingMore] = useState(false);
 
-  const handleSearch = async () => {
-    setLoading(true);
+  // Sin cursor se hace una búsqueda nueva; con cursor se agrega la siguiente
+  // página de órdenes a los resultados actuales.
+  const handleSearch = async (cursor?: string) => {
+    if (cursor) {
+      setLoadingMore(true);
+    } else {
+      setLoading(true);
+    }
     try {
       const params = new URLSearchParams();
       
@@ -53,6 +61,9 @@ export default function SearchPage() {
       if (searchType === 'orders' && filters.operator) {
         params.append('operator', filters.operator);
       }
+      if (searchType === 'orders' && cursor) {
+        params.append('cursor', cursor);
+      }
       
       if (searchType === 'quotes') {
         if (filters.minTotal) params.append('min_total', filters.minTotal);
@@ -63,20 +74,27 @@ export default function SearchPage() {
       const searchPath = params.toString() ? `${endpoint}?${params.toString()}` : endpoint;
 
       if (searchType === 'orders') {
-        const response = await apiClient.get<{ orders: Order[]; total: number }>(searchPath);
-        setResults(response.orders || []);
+        const response = await apiClient.get<{ orders: Order[]; total: number; next_cursor?: string }>(searchPath);
+        const orders = response.orders || [];
+        setResults((current) => (cursor ? [...current, ...orders] : orders));
         setTotalResults(response.total || 0);
+        setNextCursor(response.next_cursor || '');
       } else {
         const response = await apiClient.get<{ quotes: Quote[]; total: number }>(searchPath);
         setResults(response.quotes || []);
         setTotalResults(response.total || 0);
+        setNextCursor('');
       }
     } catch (error) {
       console.error('Error searching:', error);
-      setResults([]);
-      setTotalResults(0);
+      if (!cursor) {
+        setResults([]);
+        setTotalResults(0);
+        setNextCursor('');
+      }
     } finally {
       setLoading(false);
+      setLoadingMore(false);
     }
   };
 
@@ -93,6 +111,7 @@ export default function SearchPage() {
     });
     setResults([]);
     setTotalResults(0);
+    setNextCursor('');
   };
 
   const getStatusBadgeColor = (status: string): 'default' | 'secondary' | 'destructive' | 'outline' => {
@@ -156,6 +175,7 @@ export default function SearchPage() {
             setSearchType('orders');
             setResults([]);
             setTotalResults(0);
+            setNextCursor('');
           }}
           variant={searchType === 'orders' ? 'default' : 'outline'}
           size="sm"
@@ -167,6 +187,7 @@ export default function SearchPage() {
             setSearchType('quotes');
             setResults([]);
             setTotalResults(0);
+            setNextCursor('');
           }}
           variant={searchType === 'quotes' ? 'default' : 'outline'}
           size="sm"
@@ -311,7 +332,7 @@ export default function SearchPage() {
           </div>
 
           <div className="mt-6 flex gap-3">
-            <Button onClick={handleSearch} disabled={loading}>
+            <Button onClick={() => handleSearch()} disabled={loading}>
               <SearchIcon className="h-4 w-4 mr-2" />
               {loading ? 'Buscando...' : 'Buscar'}
             </Button>
@@ -423,6 +444,21 @@ export default function SearchPage() {
                   ))}
                 </tbody>
               </table>
+              {nextCursor && (
+                <div className="px-4 py-3 border-t flex items-center justify-between">
+                  <p className="text-xs text-muted-foreground">
+                    Mostrando {results.length} de {totalResults}
+                  </p>
+                  <Button
+                    onClick={() => handleSearch(nextCursor)}
+                    disabled={loadingMore}
+                    variant="outline"
+                    size="sm"
+                  >
+                    {loadingMore ? 'Cargando...' : 'Cargar más'}
+                  </Button>
+                </div>
+              )}
             </div>
           )}
         </CardContent>
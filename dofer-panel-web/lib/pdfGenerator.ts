@@ -42,7 +42,8 @@ export function generateQuotePDF(quote: Quote) {
 
   // Estado badge
   const statusLabels: { [key: string]: string } = {
-    pending: 'PENDIENTE',
+    draft: 'BORRADOR',
+    sent: 'ENVIADA',
     approved: 'APROBADA',
     rejected: 'RECHAZADA',
     expired: 'EXPIRADA'
@@ -50,7 +51,8 @@ export function generateQuotePDF(quote: Quote) {
   doc.setFontSize(8)
   doc.setFont('helvetica', 'bold')
   const statusBg: { [key: string]: [number, number, number] } = {
-    pending: [colorYellow.r, colorYellow.g, colorYellow.b],
+    draft: [colorYellow.r, colorYellow.g, colorYellow.b],
+    sent: [colorYellow.r, colorYellow.g, colorYellow.b],
     approved: [76, 175, 80],
     rejected: [244, 67, 54],
     expired: [158, 158, 158]
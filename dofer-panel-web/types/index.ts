@@ -215,7 +215,7 @@ export interface Quote {
   customer_name: string
   customer_email: string
   customer_phone?: string
-  status: 'pending' | 'approved' | 'rejected' | 'expired'
+  status: 'draft' | 'sent' | 'approved' | 'rejected' | 'expired'
   subtotal: number
   discount: number
   tax: number